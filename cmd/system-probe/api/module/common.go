@@ -8,6 +8,8 @@ package module
 
 import (
 	"errors"
+
+	"google.golang.org/grpc"
 )
 
 // ErrNotEnabled is a special error type that should be returned by a Factory
@@ -20,3 +22,8 @@ type Module interface {
 	Register(*Router) error
 	Close()
 }
+
+// GRPCModule is implemented by Modules exposing gRPC services on top of their HTTP endpoints
+type GRPCModule interface {
+	RegisterGRPC(grpc.ServiceRegistrar) error
+}
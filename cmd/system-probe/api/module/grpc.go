@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package module
+
+import (
+	"context"
+	"runtime/pprof"
+	"sync"
+
+	"google.golang.org/grpc"
+)
+
+// GRPCServer provides a wrapper around grpc.ServiceRegistrar so services can be re-registered
+// This is needed to support the module-restart feature, since a grpc.Server does not allow
+// registering the same service twice
+type GRPCServer struct {
+	mux           sync.Mutex
+	implByService map[string]interface{}
+	server        grpc.ServiceRegistrar
+	labels        pprof.LabelSet
+}
+
+var _ grpc.ServiceRegistrar = &GRPCServer{}
+
+// NewGRPCServer returns a new GRPCServer
+func NewGRPCServer(namespace string, parent grpc.ServiceRegistrar) *GRPCServer {
+	return &GRPCServer{
+		implByService: make(map[string]interface{}),
+		server:        parent,
+		labels:        pprof.Labels("module", namespace),
+	}
+}
+
+// RegisterService registers a service in such a way that it can be registered multiple times.
+// Subsequent registrations replace the implementation serving the calls.
+func (s *GRPCServer) RegisterService(desc *grpc.ServiceDesc, impl interface{}) {
+	s.mux.Lock()
+	_, registered := s.implByService[desc.ServiceName]
+	s.implByService[desc.ServiceName] = impl
+	s.mux.Unlock()
+
+	if registered {
+		return
+	}
+
+	s.server.RegisterService(s.wrapServiceDesc(desc), impl)
+}
+
+func (s *GRPCServer) currentImpl(serviceName string) interface{} {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.implByService[serviceName]
+}
+
+// wrapServiceDesc returns a copy of desc whose handlers dispatch calls to the
+// latest implementation registered for the service
+func (s *GRPCServer) wrapServiceDesc(desc *grpc.ServiceDesc) *grpc.ServiceDesc {
+	wrapped := *desc
+	wrapped.Methods = make([]grpc.MethodDesc, len(desc.Methods))
+	for i, m := range desc.Methods {
+		handler := m.Handler
+		wrapped.Methods[i] = m
+		wrapped.Methods[i].Handler = func(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (resp interface{}, err error) {
+			pprof.Do(ctx, s.labels, func(ctx context.Context) {
+				resp, err = handler(s.currentImpl(desc.ServiceName), ctx, dec, interceptor)
+			})
+			return resp, err
+		}
+	}
+
+	wrapped.Streams = make([]grpc.StreamDesc, len(desc.Streams))
+	for i, st := range desc.Streams {
+		handler := st.Handler
+		wrapped.Streams[i] = st
+		wrapped.Streams[i].Handler = func(_ interface{}, stream grpc.ServerStream) (err error) {
+			pprof.Do(stream.Context(), s.labels, func(_ context.Context) {
+				err = handler(s.currentImpl(desc.ServiceName), stream)
+			})
+			return err
+		}
+	}
+
+	return &wrapped
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/datadog-agent/pkg/util/optional"
 	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
 )
 
 var l *loader
@@ -27,6 +28,7 @@ func init() {
 		modules: make(map[sysconfigtypes.ModuleName]Module),
 		errors:  make(map[sysconfigtypes.ModuleName]error),
 		routers: make(map[sysconfigtypes.ModuleName]*Router),
+		grpcs:   make(map[sysconfigtypes.ModuleName]*GRPCServer),
 	}
 }
 
@@ -41,6 +43,7 @@ type loader struct {
 	stats   map[string]interface{}
 	cfg     *sysconfigtypes.Config
 	routers map[sysconfigtypes.ModuleName]*Router
+	grpcs   map[sysconfigtypes.ModuleName]*GRPCServer
 	closed  bool
 }
 
@@ -61,8 +64,8 @@ func withModule(name sysconfigtypes.ModuleName, fn func()) {
 // Register a set of modules, which involves:
 // * Initialization using the provided Factory;
 // * Registering the HTTP endpoints of each module;
-// * Register the gRPC server;
-func Register(cfg *sysconfigtypes.Config, httpMux *mux.Router, factories []Factory, wmeta optional.Option[workloadmeta.Component]) error {
+// * Registering the gRPC services of each module implementing GRPCModule;
+func Register(cfg *sysconfigtypes.Config, httpMux *mux.Router, grpcServer grpc.ServiceRegistrar, factories []Factory, wmeta optional.Option[workloadmeta.Component]) error {
 	var enabledModulesFactories []Factory
 	for _, factory := range factories {
 		if !cfg.ModuleIsEnabled(factory.Name) {
@@ -104,6 +107,16 @@ func Register(cfg *sysconfigtypes.Config, httpMux *mux.Router, factories []Facto
 			continue
 		}
 
+		if grpcModule, ok := module.(GRPCModule); ok {
+			moduleGRPCServer := NewGRPCServer(string(factory.Name), grpcServer)
+			if err = grpcModule.RegisterGRPC(moduleGRPCServer); err != nil {
+				l.errors[factory.Name] = err
+				log.Errorf("error registering gRPC services for module %s: %s", factory.Name, err)
+				continue
+			}
+			l.grpcs[factory.Name] = moduleGRPCServer
+		}
+
 		l.routers[factory.Name] = subRouter
 		l.modules[factory.Name] = module
 
@@ -174,6 +187,17 @@ func RestartModule(factory Factory, wmeta optional.Option[workloadmeta.Component
 		return err
 	}
 
+	if grpcModule, ok := newModule.(GRPCModule); ok {
+		currentGRPCServer, ok := l.grpcs[factory.Name]
+		if !ok {
+			return fmt.Errorf("module %s does not have an associated gRPC server", factory.Name)
+		}
+
+		if err = grpcModule.RegisterGRPC(currentGRPCServer); err != nil {
+			return err
+		}
+	}
+
 	l.modules[factory.Name] = newModule
 	return nil
 }
@@ -13,6 +13,7 @@ import (
 	"net/http"
 
 	gorilla "github.com/gorilla/mux"
+	"google.golang.org/grpc"
 
 	"github.com/DataDog/datadog-agent/cmd/system-probe/api/module"
 	sysconfigtypes "github.com/DataDog/datadog-agent/cmd/system-probe/config/types"
@@ -22,6 +23,7 @@ import (
 	"github.com/DataDog/datadog-agent/comp/core/telemetry"
 	"github.com/DataDog/datadog-agent/comp/core/workloadmeta"
 	"github.com/DataDog/datadog-agent/pkg/process/net"
+	grpcutil "github.com/DataDog/datadog-agent/pkg/util/grpc"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/datadog-agent/pkg/util/optional"
 )
@@ -34,8 +36,9 @@ func StartServer(cfg *sysconfigtypes.Config, telemetry telemetry.Component, wmet
 	}
 
 	mux := gorilla.NewRouter()
+	grpcServer := grpc.NewServer()
 
-	err = module.Register(cfg, mux, modules.All, wmeta)
+	err = module.Register(cfg, mux, grpcServer, modules.All, wmeta)
 	if err != nil {
 		return fmt.Errorf("failed to create system probe: %s", err)
 	}
@@ -54,8 +57,11 @@ func StartServer(cfg *sysconfigtypes.Config, telemetry telemetry.Component, wmet
 	mux.Handle("/debug/vars", http.DefaultServeMux)
 	mux.Handle("/telemetry", telemetry.Handler())
 
+	// gRPC and HTTP requests are served on the same socket
+	srv := grpcutil.NewMuxedGRPCServer("", nil, grpcServer, mux)
+
 	go func() {
-		err = http.Serve(conn.GetListener(), mux)
+		err = srv.Serve(conn.GetListener())
 		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Errorf("error creating HTTP server: %s", err)
 		}
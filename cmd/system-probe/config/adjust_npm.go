@@ -90,4 +90,8 @@ func adjustNetwork(cfg config.Config) {
 		log.Warn("disabling NPM connection rollups since USM connection rollups are not enabled")
 		cfg.Set(netNS("enable_connection_rollup"), false, model.SourceAgentRuntime)
 	}
+	if cfg.GetBool(netNS("enable_ephemeral_port_rollup")) && !cfg.GetBool(smNS("enable_connection_rollup")) {
+		log.Warn("disabling NPM ephemeral port rollups since USM connection rollups are not enabled")
+		cfg.Set(netNS("enable_ephemeral_port_rollup"), false, model.SourceAgentRuntime)
+	}
 }
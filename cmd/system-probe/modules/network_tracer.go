@@ -13,11 +13,15 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/netip"
+	"net/url"
 	"os"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/gorilla/mux"
 	"go.uber.org/atomic"
 
 	"github.com/DataDog/datadog-agent/cmd/system-probe/api/module"
@@ -28,9 +32,15 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/network"
 	networkconfig "github.com/DataDog/datadog-agent/pkg/network/config"
 	"github.com/DataDog/datadog-agent/pkg/network/encoding/marshal"
+	"github.com/DataDog/datadog-agent/pkg/network/exporter/metrics"
+	networkapi "github.com/DataDog/datadog-agent/pkg/network/proto/api"
+	amqpdebugging "github.com/DataDog/datadog-agent/pkg/network/protocols/amqp/debugging"
 	httpdebugging "github.com/DataDog/datadog-agent/pkg/network/protocols/http/debugging"
 	kafkadebugging "github.com/DataDog/datadog-agent/pkg/network/protocols/kafka/debugging"
+	mongodebugging "github.com/DataDog/datadog-agent/pkg/network/protocols/mongo/debugging"
+	mysqldebugging "github.com/DataDog/datadog-agent/pkg/network/protocols/mysql/debugging"
 	postgresdebugging "github.com/DataDog/datadog-agent/pkg/network/protocols/postgres/debugging"
+	redisdebugging "github.com/DataDog/datadog-agent/pkg/network/protocols/redis/debugging"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/network/tracer"
 	usm "github.com/DataDog/datadog-agent/pkg/network/usm/utils"
@@ -65,19 +75,31 @@ func createNetworkTracerModule(cfg *sysconfigtypes.Config, _ optional.Option[wor
 	t, err := tracer.NewTracer(ncfg)
 
 	done := make(chan struct{})
+	nt := &networkTracer{tracer: t, done: done, compressPayloads: ncfg.EnablePayloadCompression}
 	if err == nil {
 		startTelemetryReporter(cfg, done)
+
+		var collector *metrics.Collector
+		if ncfg.EnablePrometheusListener {
+			collector = newMetricsCollector()
+			nt.metricsServer = startMetricsListener(ncfg, newMetricsHandler(collector))
+		}
+		startConnectionsExporters(ncfg, t, collector, done)
 	}
 
-	return &networkTracer{tracer: t, done: done}, err
+	return nt, err
 }
 
 var _ module.Module = &networkTracer{}
 
 type networkTracer struct {
-	tracer       *tracer.Tracer
-	done         chan struct{}
-	restartTimer *time.Timer
+	networkapi.UnimplementedNetworkTracerModuleServer
+
+	tracer           *tracer.Tracer
+	done             chan struct{}
+	restartTimer     *time.Timer
+	compressPayloads bool
+	metricsServer    *http.Server
 }
 
 func (nt *networkTracer) GetStats() map[string]interface{} {
@@ -100,7 +122,11 @@ func (nt *networkTracer) Register(httpMux *module.Router) error {
 		}
 		contentType := req.Header.Get("Accept")
 		marshaler := marshal.GetMarshaler(contentType)
-		writeConnections(w, marshaler, cs)
+		acceptEncoding := ""
+		if nt.compressPayloads {
+			acceptEncoding = req.Header.Get("Accept-Encoding")
+		}
+		writeConnections(w, marshaler, cs, acceptEncoding)
 
 		if nt.restartTimer != nil {
 			nt.restartTimer.Reset(inactivityRestartDuration)
@@ -121,7 +147,58 @@ func (nt *networkTracer) Register(httpMux *module.Router) error {
 		}
 	}))
 
+	httpMux.HandleFunc("/listening_sockets", utils.WithConcurrencyLimit(utils.DefaultMaxConcurrentRequests, func(w http.ResponseWriter, _ *http.Request) {
+		sockets, err := nt.tracer.GetListeningSockets()
+		if err != nil {
+			log.Errorf("unable to retrieve listening sockets: %s", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		utils.WriteAsJSON(w, sockets)
+	}))
+
+	httpMux.HandleFunc("/unix_sockets", utils.WithConcurrencyLimit(utils.DefaultMaxConcurrentRequests, func(w http.ResponseWriter, _ *http.Request) {
+		sockets, err := nt.tracer.GetUnixSockets()
+		if err != nil {
+			log.Errorf("unable to retrieve unix sockets: %s", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		utils.WriteAsJSON(w, sockets)
+	}))
+
+	httpMux.HandleFunc("/packet_drops", utils.WithConcurrencyLimit(utils.DefaultMaxConcurrentRequests, func(w http.ResponseWriter, _ *http.Request) {
+		drops, err := nt.tracer.GetPacketDrops()
+		if err != nil {
+			log.Errorf("unable to retrieve packet drops: %s", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		utils.WriteAsJSON(w, drops)
+	}))
+
+	httpMux.HandleFunc("/interface_stats", utils.WithConcurrencyLimit(utils.DefaultMaxConcurrentRequests, func(w http.ResponseWriter, _ *http.Request) {
+		stats, err := nt.tracer.GetInterfaceStats()
+		if err != nil {
+			log.Errorf("unable to retrieve interface stats: %s", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		utils.WriteAsJSON(w, stats)
+	}))
+
 	httpMux.HandleFunc("/debug/net_maps", func(w http.ResponseWriter, req *http.Request) {
+		filters, err := parseConnectionFilters(req.URL.Query())
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, err)
+			return
+		}
+
 		cs, err := nt.tracer.DebugNetworkMaps()
 		if err != nil {
 			log.Errorf("unable to retrieve connections: %s", err)
@@ -129,9 +206,17 @@ func (nt *networkTracer) Register(httpMux *module.Router) error {
 			return
 		}
 
+		if len(filters) > 0 {
+			cs.Conns = network.FilterConnections(cs, filters...)
+		}
+		if req.URL.Query().Get("format") == "text" {
+			writeConnectionsSummary(w, cs)
+			return
+		}
+
 		contentType := req.Header.Get("Accept")
 		marshaler := marshal.GetMarshaler(contentType)
-		writeConnections(w, marshaler, cs)
+		writeConnections(w, marshaler, cs, "")
 	})
 
 	httpMux.HandleFunc("/debug/net_state", func(w http.ResponseWriter, req *http.Request) {
@@ -193,6 +278,70 @@ func (nt *networkTracer) Register(httpMux *module.Router) error {
 		utils.WriteAsJSON(w, postgresdebugging.Postgres(cs.Postgres))
 	})
 
+	httpMux.HandleFunc("/debug/mysql_monitoring", func(w http.ResponseWriter, _ *http.Request) {
+		if !coreconfig.SystemProbe.GetBool("service_monitoring_config.enable_mysql_monitoring") {
+			writeDisabledProtocolMessage("mysql", w)
+			return
+		}
+		// the MySQL stats are only kept for the debug client
+		cs, err := nt.tracer.GetActiveConnections(network.DEBUGCLIENT)
+		if err != nil {
+			log.Errorf("unable to retrieve connections: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		utils.WriteAsJSON(w, mysqldebugging.MySQL(cs.MySQL))
+	})
+
+	httpMux.HandleFunc("/debug/redis_monitoring", func(w http.ResponseWriter, _ *http.Request) {
+		if !coreconfig.SystemProbe.GetBool("service_monitoring_config.enable_redis_monitoring") {
+			writeDisabledProtocolMessage("redis", w)
+			return
+		}
+		// the Redis stats are only kept for the debug client
+		cs, err := nt.tracer.GetActiveConnections(network.DEBUGCLIENT)
+		if err != nil {
+			log.Errorf("unable to retrieve connections: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		utils.WriteAsJSON(w, redisdebugging.Redis(cs.Redis))
+	})
+
+	httpMux.HandleFunc("/debug/mongo_monitoring", func(w http.ResponseWriter, _ *http.Request) {
+		if !coreconfig.SystemProbe.GetBool("service_monitoring_config.enable_mongo_monitoring") {
+			writeDisabledProtocolMessage("mongo", w)
+			return
+		}
+		// the Mongo stats are only kept for the debug client
+		cs, err := nt.tracer.GetActiveConnections(network.DEBUGCLIENT)
+		if err != nil {
+			log.Errorf("unable to retrieve connections: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		utils.WriteAsJSON(w, mongodebugging.Mongo(cs.Mongo))
+	})
+
+	httpMux.HandleFunc("/debug/amqp_monitoring", func(w http.ResponseWriter, _ *http.Request) {
+		if !coreconfig.SystemProbe.GetBool("service_monitoring_config.enable_amqp_monitoring") {
+			writeDisabledProtocolMessage("amqp", w)
+			return
+		}
+		// the AMQP stats are only kept for the debug client
+		cs, err := nt.tracer.GetActiveConnections(network.DEBUGCLIENT)
+		if err != nil {
+			log.Errorf("unable to retrieve connections: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		utils.WriteAsJSON(w, amqpdebugging.AMQP(cs.AMQP))
+	})
+
 	httpMux.HandleFunc("/debug/http2_monitoring", func(w http.ResponseWriter, req *http.Request) {
 		if !coreconfig.SystemProbe.GetBool("service_monitoring_config.enable_http2_monitoring") {
 			writeDisabledProtocolMessage("http2", w)
@@ -264,6 +413,40 @@ func (nt *networkTracer) Register(httpMux *module.Router) error {
 		utils.WriteAsJSON(w, cache)
 	})
 
+	httpMux.HandleFunc("/debug/preflight", func(w http.ResponseWriter, _ *http.Request) {
+		report, err := nt.tracer.PreflightReport()
+		if err != nil {
+			log.Errorf("unable to retrieve the kernel capabilities: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		utils.WriteAsJSON(w, report)
+	})
+
+	httpMux.HandleFunc("/probe_groups", func(w http.ResponseWriter, _ *http.Request) {
+		utils.WriteAsJSON(w, nt.tracer.ProbeGroups())
+	}).Methods("GET")
+
+	httpMux.HandleFunc("/probe_groups/{group}", func(w http.ResponseWriter, req *http.Request) {
+		group := mux.Vars(req)["group"]
+		enabled, err := strconv.ParseBool(req.URL.Query().Get("enabled"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "invalid enabled parameter: %s", err)
+			return
+		}
+
+		if err := nt.tracer.SetProbeGroupEnabled(group, enabled); err != nil {
+			log.Errorf("unable to update the probe group %s: %s", group, err)
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, err.Error())
+			return
+		}
+
+		utils.WriteAsJSON(w, nt.tracer.ProbeGroups())
+	}).Methods("POST")
+
 	httpMux.HandleFunc("/debug/usm_telemetry", telemetry.Handler)
 	httpMux.HandleFunc("/debug/usm/traced_programs", usm.TracedProgramsEndpoint)
 	httpMux.HandleFunc("/debug/usm/attach-pid", usm.AttachPIDEndpoint)
@@ -292,6 +475,9 @@ func (nt *networkTracer) Register(httpMux *module.Router) error {
 // Close will stop all system probe activities
 func (nt *networkTracer) Close() {
 	close(nt.done)
+	if nt.metricsServer != nil {
+		nt.metricsServer.Close()
+	}
 	nt.tracer.Stop()
 }
 
@@ -314,15 +500,88 @@ func getClientID(req *http.Request) string {
 	return clientID
 }
 
-func writeConnections(w http.ResponseWriter, marshaler marshal.Marshaler, cs *network.Connections) {
+// parseConnectionFilters returns the filters given as query parameters of the connections debug endpoint
+func parseConnectionFilters(query url.Values) ([]network.ConnectionFilterFunc, error) {
+	var filters []network.ConnectionFilterFunc
+
+	if v := query.Get("sport"); v != "" {
+		port, err := strconv.ParseUint(v, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sport %q: %w", v, err)
+		}
+		filters = append(filters, network.BySourcePort(uint16(port)))
+	}
+	if v := query.Get("dport"); v != "" {
+		port, err := strconv.ParseUint(v, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dport %q: %w", v, err)
+		}
+		filters = append(filters, network.ByDestPort(uint16(port)))
+	}
+	if v := query.Get("pid"); v != "" {
+		pid, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pid %q: %w", v, err)
+		}
+		filters = append(filters, network.ByPID(uint32(pid)))
+	}
+	if v := query.Get("process"); v != "" {
+		filters = append(filters, network.ByProcessName(v))
+	}
+	if v := query.Get("cidr"); v != "" {
+		prefix, err := netip.ParsePrefix(v)
+		if err != nil {
+			// a single address is accepted as well
+			addr, aerr := netip.ParseAddr(v)
+			if aerr != nil {
+				return nil, fmt.Errorf("invalid cidr %q: %w", v, err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		filters = append(filters, network.ByCIDR(prefix))
+	}
+	if v := query.Get("container"); v != "" {
+		filters = append(filters, network.ByContainerID(v))
+	}
+	if v := query.Get("type"); v != "" {
+		switch strings.ToLower(v) {
+		case "tcp":
+			filters = append(filters, network.ByType(network.TCP))
+		case "udp":
+			filters = append(filters, network.ByType(network.UDP))
+		default:
+			return nil, fmt.Errorf("invalid type %q, expected tcp or udp", v)
+		}
+	}
+
+	return filters, nil
+}
+
+// writeConnectionsSummary writes a human-readable summary of each connection, one per line
+func writeConnectionsSummary(w http.ResponseWriter, cs *network.Connections) {
+	w.Header().Set("Content-type", "text/plain")
+	for i := range cs.Conns {
+		fmt.Fprintln(w, network.ConnectionSummary(&cs.Conns[i], cs.DNS))
+	}
+}
+
+func writeConnections(w http.ResponseWriter, marshaler marshal.Marshaler, cs *network.Connections, acceptEncoding string) {
 	defer network.Reclaim(cs)
 
 	w.Header().Set("Content-type", marshaler.ContentType())
+	out, contentEncoding := marshal.GetCompressor(w, acceptEncoding)
+	if contentEncoding != "" {
+		w.Header().Set("Content-Encoding", contentEncoding)
+	}
 
 	connectionsModeler := marshal.NewConnectionsModeler(cs)
 	defer connectionsModeler.Close()
 
-	err := marshaler.Marshal(cs, w, connectionsModeler)
+	err := marshaler.Marshal(cs, out, connectionsModeler)
+	// the compressor is closed even if marshaling failed, to release its resources
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
 	if err != nil {
 		log.Errorf("unable to marshall connections with type %s: %s", marshaler.ContentType(), err)
 		w.WriteHeader(500)
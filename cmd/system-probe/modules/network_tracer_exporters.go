@@ -0,0 +1,130 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux || windows
+
+package modules
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	networkconfig "github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/exporter/cef"
+	"github.com/DataDog/datadog-agent/pkg/network/exporter/ipfix"
+	"github.com/DataDog/datadog-agent/pkg/network/exporter/metrics"
+	"github.com/DataDog/datadog-agent/pkg/network/exporter/otlp"
+	"github.com/DataDog/datadog-agent/pkg/network/tracer"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// connectionsExporterClientID is the prefix of the tracer clients of the exporters, the exporters
+// with the same interval sharing one
+const connectionsExporterClientID = "connections-exporter"
+
+// defaultExportInterval replaces the invalid intervals of the configuration
+const defaultExportInterval = 30 * time.Second
+
+// connectionsExporter sends the connections of the tracer to a third party system
+type connectionsExporter interface {
+	Export(conns *network.Connections) error
+	Close() error
+}
+
+// exportersByInterval groups the exporters by interval, so that the connections are read once for
+// all the exporters of an interval
+type exportersByInterval map[time.Duration][]connectionsExporter
+
+func (g exportersByInterval) add(name string, interval time.Duration, e connectionsExporter) {
+	if interval <= 0 {
+		log.Warnf("invalid %s export interval %s, using %s", name, interval, defaultExportInterval)
+		interval = defaultExportInterval
+	}
+	g[interval] = append(g[interval], e)
+}
+
+// startConnectionsExporters starts the exporters enabled in the configuration, and the export of
+// the connections to the prometheus collector if any
+func startConnectionsExporters(cfg *networkconfig.Config, t *tracer.Tracer, collector *metrics.Collector, done <-chan struct{}) {
+	exporters := make(exportersByInterval)
+	if cfg.IPFIXCollector != "" {
+		e, err := ipfix.NewExporter(cfg.IPFIXCollector, cfg.IPFIXObservationDomainID)
+		if err != nil {
+			log.Errorf("unable to create the ipfix exporter: %s", err)
+		} else {
+			log.Infof("exporting the connections as ipfix flows to %s", cfg.IPFIXCollector)
+			exporters.add("ipfix", cfg.IPFIXExportInterval, e)
+		}
+	}
+	if cfg.OTLPEndpoint != "" {
+		log.Infof("exporting the connections as opentelemetry metrics to %s", cfg.OTLPEndpoint)
+		exporters.add("otlp", cfg.OTLPExportInterval, otlp.NewExporter(cfg.OTLPEndpoint, cfg.OTLPBatchSize))
+	}
+	if cfg.CEFExportAddress != "" {
+		if e, err := newCEFExporter(cfg, t); err != nil {
+			log.Errorf("unable to create the cef exporter: %s", err)
+		} else {
+			log.Infof("exporting the notable network events as cef messages to %s", cfg.CEFExportAddress)
+			exporters.add("cef", cfg.CEFExportInterval, e)
+		}
+	}
+	if collector != nil {
+		exporters.add("prometheus", cfg.PrometheusListenerInterval, collector)
+	}
+
+	for interval, es := range exporters {
+		startConnectionsExporter(t, fmt.Sprintf("%s-%s", connectionsExporterClientID, interval), interval, es, done)
+	}
+}
+
+func newCEFExporter(cfg *networkconfig.Config, t *tracer.Tracer) (*cef.Exporter, error) {
+	watchList, err := cef.ParseWatchList(cfg.CEFWatchList)
+	if err != nil {
+		return nil, fmt.Errorf("invalid watch list: %w", err)
+	}
+	return cef.NewExporter(cfg.CEFExportProtocol, cfg.CEFExportAddress, cef.NewDetector(watchList), t.GetListeningSockets)
+}
+
+// startConnectionsExporter exports the connections to the exporters at the given interval. The
+// exporters share a client of the tracer like the process agent, so they get the connections closed
+// between two exports too.
+func startConnectionsExporter(t *tracer.Tracer, clientID string, interval time.Duration, exporters []connectionsExporter, done <-chan struct{}) {
+	closeAll := func() {
+		for _, e := range exporters {
+			e.Close()
+		}
+	}
+	if err := t.RegisterClient(clientID); err != nil {
+		log.Errorf("unable to register the %s client: %s", clientID, err)
+		closeAll()
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		defer closeAll()
+
+		for {
+			select {
+			case <-ticker.C:
+				cs, err := t.GetActiveConnections(clientID)
+				if err != nil {
+					log.Errorf("unable to retrieve connections for %s: %s", clientID, err)
+					continue
+				}
+				for _, e := range exporters {
+					if err := e.Export(cs); err != nil {
+						log.Warnf("unable to export connections for %s: %s", clientID, err)
+					}
+				}
+				network.Reclaim(cs)
+			case <-done:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,76 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux || windows
+
+package modules
+
+import (
+	"bytes"
+
+	"google.golang.org/grpc"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/encoding/marshal"
+	networkapi "github.com/DataDog/datadog-agent/pkg/network/proto/api"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// closedConnectionsStreamBufferSize is the number of closed connection batches
+// buffered for each gRPC subscriber before batches start getting dropped
+const closedConnectionsStreamBufferSize = 16
+
+var _ networkapi.NetworkTracerModuleServer = &networkTracer{}
+
+// RegisterGRPC registers the networkTracer gRPC services
+func (nt *networkTracer) RegisterGRPC(server grpc.ServiceRegistrar) error {
+	networkapi.RegisterNetworkTracerModuleServer(server, nt)
+	return nil
+}
+
+// StreamClosedConnections streams batches of closed connections, encoded as
+// protobuf Connections payloads, until the client goes away
+func (nt *networkTracer) StreamClosedConnections(req *networkapi.StreamClosedConnectionsParams, stream networkapi.NetworkTracerModule_StreamClosedConnectionsServer) error {
+	batches, cancel, err := nt.tracer.SubscribeClosedConnections(closedConnectionsStreamBufferSize)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	log.Debugf("client %s subscribed to the closed connections stream", req.GetClientID())
+	defer log.Debugf("client %s unsubscribed from the closed connections stream", req.GetClientID())
+
+	marshaler := marshal.GetMarshaler(marshal.ContentTypeProtobuf)
+	var buf bytes.Buffer
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case conns, ok := <-batches:
+			if !ok {
+				return nil
+			}
+
+			buf.Reset()
+			if err := marshalClosedConnections(marshaler, &buf, conns); err != nil {
+				log.Errorf("unable to marshal closed connections: %s", err)
+				continue
+			}
+
+			if err := stream.Send(&networkapi.ClosedConnectionsMessage{Data: buf.Bytes()}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func marshalClosedConnections(marshaler marshal.Marshaler, buf *bytes.Buffer, conns []network.ConnectionStats) error {
+	cs := &network.Connections{BufferedData: network.BufferedData{Conns: conns}}
+
+	connectionsModeler := marshal.NewConnectionsModeler(cs)
+	defer connectionsModeler.Close()
+
+	return marshaler.Marshal(cs, buf, connectionsModeler)
+}
@@ -0,0 +1,55 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux || windows
+
+package modules
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/DataDog/datadog-agent/comp/core/telemetry"
+	networkconfig "github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/exporter/metrics"
+	coretelemetry "github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// newMetricsCollector returns the collector of the metrics aggregated from the connections, which
+// the export loop of the tracer hands the connections over to
+func newMetricsCollector() *metrics.Collector {
+	return metrics.NewCollector(func() ([]*telemetry.MetricFamily, error) {
+		return coretelemetry.GetCompatComponent().Gather(false)
+	})
+}
+
+// newMetricsHandler returns the handler serving the metrics of the collector in the Prometheus format
+func newMetricsHandler(collector *metrics.Collector) http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// startMetricsListener serves the Prometheus metrics on the listener address of the configuration
+func startMetricsListener(cfg *networkconfig.Config, handler http.Handler) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", handler)
+	srv := &http.Server{
+		Addr:    cfg.PrometheusListenerAddress,
+		Handler: mux,
+	}
+
+	go func() {
+		log.Infof("serving the network metrics in the prometheus format on %s", cfg.PrometheusListenerAddress)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("error serving the network metrics on %s: %s", cfg.PrometheusListenerAddress, err)
+		}
+	}()
+	return srv
+}
@@ -9,14 +9,19 @@ package modules
 
 import (
 	"bytes"
+	"io"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go4.org/intern"
 
 	"github.com/DataDog/datadog-agent/pkg/network"
 	"github.com/DataDog/datadog-agent/pkg/network/encoding/marshal"
+	"github.com/DataDog/datadog-agent/pkg/network/encoding/unmarshal"
 	"github.com/DataDog/datadog-agent/pkg/process/encoding"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 )
@@ -69,10 +74,94 @@ func TestDecode(t *testing.T) {
 	err := marshaller.Marshal(in, ostream, connectionsModeler)
 	require.NoError(t, err)
 
-	writeConnections(rec, marshaller, in)
+	writeConnections(rec, marshaller, in, "")
 
 	rec.Flush()
 	out := rec.Body.Bytes()
 	assert.Equal(t, ostream.Bytes(), out)
 
+	t.Run("compressed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		writeConnections(rec, marshaller, in, "gzip")
+		assert.Equal(t, marshal.ContentEncodingGzip, rec.Header().Get("Content-Encoding"))
+
+		reader, err := unmarshal.GetDecompressor(rec.Body, rec.Header().Get("Content-Encoding"))
+		require.NoError(t, err)
+		defer reader.Close()
+		out, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, ostream.Bytes(), out)
+	})
 }
+
+func TestParseConnectionFilters(t *testing.T) {
+	nginx := network.ConnectionStats{
+		Source: util.AddressFromString("10.2.0.5"),
+		Dest:   util.AddressFromString("10.3.0.8"),
+		SPort:  443,
+		DPort:  51000,
+		Pid:    1234,
+		Type:   network.TCP,
+	}
+	nginx.Process.Comm = intern.GetByString("nginx")
+	nginx.ContainerID.Source = intern.GetByString("abcdef0123456789")
+
+	dns := network.ConnectionStats{
+		Source: util.AddressFromString("10.3.0.8"),
+		Dest:   util.AddressFromString("8.8.8.8"),
+		SPort:  40000,
+		DPort:  53,
+		Pid:    42,
+		Type:   network.UDP,
+	}
+
+	cs := &network.Connections{BufferedData: network.BufferedData{Conns: []network.ConnectionStats{nginx, dns}}}
+
+	tests := []struct {
+		query    string
+		expected []network.ConnectionStats
+	}{
+		{"", []network.ConnectionStats{nginx, dns}},
+		{"dport=53", []network.ConnectionStats{dns}},
+		{"sport=443&process=nginx", []network.ConnectionStats{nginx}},
+		{"pid=42", []network.ConnectionStats{dns}},
+		{"cidr=10.2.0.0/16", []network.ConnectionStats{nginx}},
+		{"cidr=10.3.0.8", []network.ConnectionStats{nginx, dns}},
+		{"container=abc", []network.ConnectionStats{nginx}},
+		{"type=udp", []network.ConnectionStats{dns}},
+		{"type=tcp&dport=53", nil},
+	}
+	for _, tt := range tests {
+		query, err := url.ParseQuery(tt.query)
+		require.NoError(t, err)
+		filters, err := parseConnectionFilters(query)
+		require.NoError(t, err, tt.query)
+		assert.Equal(t, tt.expected, network.FilterConnections(cs, filters...), tt.query)
+	}
+
+	for _, query := range []string{"dport=https", "pid=-1", "cidr=10.2.0.0/33", "type=sctp"} {
+		values, err := url.ParseQuery(query)
+		require.NoError(t, err)
+		_, err = parseConnectionFilters(values)
+		assert.Error(t, err, query)
+	}
+}
+
+func TestExportersByInterval(t *testing.T) {
+	ipfix, cef, otlp := &nopExporter{"ipfix"}, &nopExporter{"cef"}, &nopExporter{"otlp"}
+	exporters := make(exportersByInterval)
+	exporters.add("ipfix", 30*time.Second, ipfix)
+	exporters.add("cef", 0, cef)
+	exporters.add("otlp", 10*time.Second, otlp)
+
+	// the invalid interval falls back to the default, shared with the ipfix exporter
+	assert.Equal(t, exportersByInterval{
+		defaultExportInterval: {ipfix, cef},
+		10 * time.Second:      {otlp},
+	}, exporters)
+}
+
+type nopExporter struct{ name string }
+
+func (*nopExporter) Export(*network.Connections) error { return nil }
+func (*nopExporter) Close() error                      { return nil }
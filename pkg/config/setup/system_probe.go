@@ -147,10 +147,20 @@ func InitSystemProbeConfig(cfg pkgconfigmodel.Config) {
 	cfg.BindEnvAndSetDefault(join(spNS, "enable_tracepoints"), false)
 	cfg.BindEnvAndSetDefault(join(spNS, "enable_co_re"), true, "DD_ENABLE_CO_RE")
 	cfg.BindEnvAndSetDefault(join(spNS, "btf_path"), "", "DD_SYSTEM_PROBE_BTF_PATH")
+	// e.g. https://github.com/aquasecurity/btfhub-archive/raw/main/{platform}/{platform_version}/{arch}/{kernel}.btf.tar.xz
+	cfg.BindEnvAndSetDefault(join(spNS, "btf_download_url"), "", "DD_SYSTEM_PROBE_BTF_DOWNLOAD_URL")
+	cfg.BindEnvAndSetDefault(join(spNS, "btf_download_dir"), filepath.Join(defaultRunPath, "system-probe", "btf"), "DD_SYSTEM_PROBE_BTF_DOWNLOAD_DIR")
+	// local file pinning the digests of the tarballs which may be downloaded, e.g. the output of
+	// `sha256sum */*/*/*.btf.tar.xz` run from the root of a btfhub-archive checkout
+	cfg.BindEnvAndSetDefault(join(spNS, "btf_download_digests"), "", "DD_SYSTEM_PROBE_BTF_DOWNLOAD_DIGESTS")
 	cfg.BindEnv(join(spNS, "enable_runtime_compiler"), "DD_ENABLE_RUNTIME_COMPILER")
 	cfg.BindEnvAndSetDefault(join(spNS, "allow_precompiled_fallback"), true, "DD_ALLOW_PRECOMPILED_FALLBACK")
 	cfg.BindEnvAndSetDefault(join(spNS, "allow_runtime_compiled_fallback"), true, "DD_ALLOW_RUNTIME_COMPILED_FALLBACK")
 	cfg.BindEnvAndSetDefault(join(spNS, "runtime_compiler_output_dir"), defaultRuntimeCompilerOutputDir, "DD_RUNTIME_COMPILER_OUTPUT_DIR")
+	// store of the programs compiled for each kernel, looked up by the name of their output file before compiling them locally
+	cfg.BindEnvAndSetDefault(join(spNS, "runtime_compiler_artifact_url"), "", "DD_RUNTIME_COMPILER_ARTIFACT_URL")
+	// local file pinning the digests of the programs of the store, in the format of sha256sum
+	cfg.BindEnvAndSetDefault(join(spNS, "runtime_compiler_artifact_digests"), "", "DD_RUNTIME_COMPILER_ARTIFACT_DIGESTS")
 	cfg.BindEnv(join(spNS, "enable_kernel_header_download"), "DD_ENABLE_KERNEL_HEADER_DOWNLOAD")
 	cfg.BindEnvAndSetDefault(join(spNS, "kernel_header_dirs"), []string{}, "DD_KERNEL_HEADER_DIRS")
 	cfg.BindEnvAndSetDefault(join(spNS, "kernel_header_download_dir"), defaultKernelHeadersDownloadDir, "DD_KERNEL_HEADER_DOWNLOAD_DIR")
@@ -182,6 +192,8 @@ func InitSystemProbeConfig(cfg pkgconfigmodel.Config) {
 	cfg.BindEnvAndSetDefault(join(spNS, "closed_connection_flush_threshold"), 0)
 	cfg.BindEnvAndSetDefault(join(spNS, "closed_channel_size"), 500)
 	cfg.BindEnvAndSetDefault(join(spNS, "max_connection_state_buffered"), 75000)
+	// number of fetch intervals a client can miss before its state is evicted, within the two minutes expiry
+	cfg.BindEnvAndSetDefault(join(spNS, "max_client_missed_intervals"), 4)
 
 	cfg.BindEnvAndSetDefault(join(spNS, "disable_dns_inspection"), false, "DD_DISABLE_DNS_INSPECTION")
 	cfg.BindEnvAndSetDefault(join(spNS, "collect_dns_stats"), true, "DD_COLLECT_DNS_STATS")
@@ -193,7 +205,9 @@ func InitSystemProbeConfig(cfg pkgconfigmodel.Config) {
 	cfg.BindEnvAndSetDefault(join(spNS, "enable_conntrack"), true)
 	cfg.BindEnvAndSetDefault(join(spNS, "conntrack_max_state_size"), 65536*2)
 	cfg.BindEnvAndSetDefault(join(spNS, "conntrack_rate_limit"), 500)
+	cfg.BindEnvAndSetDefault(join(spNS, "conntrack_dump_rate_limit"), 0)
 	cfg.BindEnvAndSetDefault(join(spNS, "enable_conntrack_all_namespaces"), true, "DD_SYSTEM_PROBE_ENABLE_CONNTRACK_ALL_NAMESPACES")
+	cfg.BindEnvAndSetDefault(join(spNS, "enable_conntrack_destroy_events"), false)
 	cfg.BindEnvAndSetDefault(join(netNS, "enable_protocol_classification"), true, "DD_ENABLE_PROTOCOL_CLASSIFICATION")
 	cfg.BindEnvAndSetDefault(join(netNS, "enable_ringbuffers"), true, "DD_SYSTEM_PROBE_NETWORK_ENABLE_RINGBUFFERS")
 	cfg.BindEnvAndSetDefault(join(netNS, "ignore_conntrack_init_failure"), false, "DD_SYSTEM_PROBE_NETWORK_IGNORE_CONNTRACK_INIT_FAILURE")
@@ -224,6 +238,8 @@ func InitSystemProbeConfig(cfg pkgconfigmodel.Config) {
 	// For backward compatibility
 	cfg.BindEnv(join(netNS, "enable_https_monitoring"), "DD_SYSTEM_PROBE_NETWORK_ENABLE_HTTPS_MONITORING")
 	cfg.BindEnv(join(smNS, "tls", "native", "enabled"))
+	// patterns of the paths of the executables statically linking an OpenSSL compatible library
+	cfg.BindEnvAndSetDefault(join(smNS, "tls", "native", "static_binaries"), []string{})
 
 	// For backward compatibility
 	cfg.BindEnv(join(smNS, "enable_go_tls_support"))
@@ -233,6 +249,10 @@ func InitSystemProbeConfig(cfg pkgconfigmodel.Config) {
 	cfg.BindEnvAndSetDefault(join(smNS, "enable_http2_monitoring"), false)
 	cfg.BindEnvAndSetDefault(join(smNS, "enable_kafka_monitoring"), false)
 	cfg.BindEnv(join(smNS, "enable_postgres_monitoring"))
+	cfg.BindEnvAndSetDefault(join(smNS, "enable_mysql_monitoring"), false)
+	cfg.BindEnvAndSetDefault(join(smNS, "enable_redis_monitoring"), false)
+	cfg.BindEnvAndSetDefault(join(smNS, "enable_mongo_monitoring"), false)
+	cfg.BindEnvAndSetDefault(join(smNS, "enable_amqp_monitoring"), false)
 	cfg.BindEnvAndSetDefault(join(smNS, "tls", "istio", "enabled"), false)
 	cfg.BindEnv(join(smNS, "tls", "nodejs", "enabled"))
 	cfg.BindEnvAndSetDefault(join(smjtNS, "enabled"), false)
@@ -244,13 +264,40 @@ func InitSystemProbeConfig(cfg pkgconfigmodel.Config) {
 	cfg.BindEnvAndSetDefault(join(smNS, "enable_http_stats_by_status_code"), true)
 
 	cfg.BindEnvAndSetDefault(join(netNS, "enable_gateway_lookup"), true, "DD_SYSTEM_PROBE_NETWORK_ENABLE_GATEWAY_LOOKUP")
+	cfg.BindEnvAndSetDefault(join(netNS, "gateway_lookup_queue_size"), 0)
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_gateway_probing"), false)
+	cfg.BindEnvAndSetDefault(join(netNS, "gateway_probing_interval"), 30*time.Second)
+	// also reads the VPC topology from the EC2 API on AWS (ec2:DescribeSubnets and ec2:DescribeRouteTables)
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_traffic_class_tags"), false)
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_payload_compression"), false)
+	cfg.BindEnvAndSetDefault(join(netNS, "ipfix_export", "collector"), "")
+	cfg.BindEnvAndSetDefault(join(netNS, "ipfix_export", "interval"), 30*time.Second)
+	cfg.BindEnvAndSetDefault(join(netNS, "ipfix_export", "observation_domain_id"), 0)
+	cfg.BindEnvAndSetDefault(join(netNS, "otlp_export", "endpoint"), "")
+	cfg.BindEnvAndSetDefault(join(netNS, "otlp_export", "interval"), 30*time.Second)
+	cfg.BindEnvAndSetDefault(join(netNS, "otlp_export", "batch_size"), 1000)
+	cfg.BindEnvAndSetDefault(join(netNS, "prometheus_listener_enabled"), false)
+	cfg.BindEnvAndSetDefault(join(netNS, "prometheus_listener_address"), "localhost:9091")
+	cfg.BindEnvAndSetDefault(join(netNS, "prometheus_listener_interval"), 30*time.Second)
+	cfg.BindEnvAndSetDefault(join(netNS, "cef_export", "address"), "")
+	cfg.BindEnvAndSetDefault(join(netNS, "cef_export", "protocol"), "udp")
+	cfg.BindEnvAndSetDefault(join(netNS, "cef_export", "interval"), 30*time.Second)
+	cfg.BindEnvAndSetDefault(join(netNS, "cef_export", "watch_list"), []string{})
 	// Default value (100000) is set in `adjustUSM`, to avoid having "deprecation warning", due to the default value.
 	cfg.BindEnv(join(netNS, "max_http_stats_buffered"), "DD_SYSTEM_PROBE_NETWORK_MAX_HTTP_STATS_BUFFERED")
 	cfg.BindEnv(join(smNS, "max_http_stats_buffered"))
 	cfg.BindEnvAndSetDefault(join(smNS, "max_kafka_stats_buffered"), 100000)
 	cfg.BindEnv(join(smNS, "max_postgres_stats_buffered"))
+	cfg.BindEnvAndSetDefault(join(smNS, "max_mysql_stats_buffered"), 100000)
+	cfg.BindEnvAndSetDefault(join(smNS, "max_redis_stats_buffered"), 100000)
+	cfg.BindEnvAndSetDefault(join(smNS, "max_mongo_stats_buffered"), 100000)
+	cfg.BindEnvAndSetDefault(join(smNS, "max_amqp_stats_buffered"), 100000)
 	cfg.BindEnv(join(smNS, "max_concurrent_requests"))
 	cfg.BindEnv(join(smNS, "enable_quantization"))
+	// number of path segments kept by the quantization, 0 keeps them all
+	cfg.BindEnvAndSetDefault(join(smNS, "quantization_max_segments"), 0)
+	// types of the path segments replaced by a wildcard by the quantization, among numeric, hex and uuid
+	cfg.BindEnvAndSetDefault(join(smNS, "quantization_wildcard_types"), []string{})
 	cfg.BindEnv(join(smNS, "enable_connection_rollup"))
 	cfg.BindEnv(join(smNS, "enable_ring_buffers"))
 	cfg.BindEnv(join(smNS, "enable_event_stream"))
@@ -283,10 +330,60 @@ func InitSystemProbeConfig(cfg pkgconfigmodel.Config) {
 
 	// list of DNS query types to be recorded
 	cfg.BindEnvAndSetDefault(join(netNS, "dns_recorded_query_types"), []string{})
+	// list of the ports on which the DNS servers listen
+	cfg.BindEnvAndSetDefault(join(netNS, "dns_monitoring_ports"), []string{"53"})
 	// (temporary) enable submitting DNS stats by query type.
 	cfg.BindEnvAndSetDefault(join(netNS, "enable_dns_by_querytype"), false)
 	// connection aggregation with port rollups
 	cfg.BindEnvAndSetDefault(join(netNS, "enable_connection_rollup"), false)
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_ephemeral_port_rollup"), false)
+	// tracking of failed TCP connection attempts
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_tcp_failed_connections"), true)
+	// aggregation of the traffic by cgroup, destination and port in place of the per connection statistics
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_cgroup_aggregation"), false)
+	// tracking of SCTP associations, which requires the sctp kernel module to be loaded
+	cfg.BindEnvAndSetDefault(join(netNS, "collect_sctp"), false)
+	// (temporary) encoding of the SCTP associations in the connections payload, whose ConnectionType
+	// is not defined by agent-payload yet
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_sctp_encoding"), false)
+	// tracking of the traffic of AF_UNIX sockets
+	cfg.BindEnvAndSetDefault(join(netNS, "collect_unix_sockets"), false)
+	// counting of the packets dropped by the kernel, which requires kernel 5.17+
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_packet_drop_monitoring"), false)
+	// counting of the overflows of the accept queue and SYN backlog of listening TCP sockets
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_listen_overflow_monitoring"), false)
+	// capture of the process and cgroup which created each connection by the eBPF tracer
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_connection_process_info"), false)
+	// capture of the server name, versions, cipher suite and ALPN of the TLS handshakes by the protocol classifier
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_tls_handshake_info"), false)
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_websocket_tracking"), false)
+	// use of the fentry/fexit tracer on the kernels supporting BPF trampolines, unless a feature only
+	// implemented by the kprobe tracer is enabled
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_fentry"), true)
+	// directory of the bpffs in which the tracer maps are pinned across restarts, e.g. /sys/fs/bpf/datadog-agent
+	cfg.BindEnvAndSetDefault(join(netNS, "pinned_maps_dir"), "")
+	// sampling of the counters of the network interfaces of all namespaces with each connections check, for the prometheus metrics
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_interface_stats"), false)
+	// eviction of the connections on which no data was transferred for the given duration, 0 to disable
+	cfg.BindEnvAndSetDefault(join(netNS, "idle_connection_timeout"), time.Duration(0))
+	// rules matching the connections to ignore, e.g. "10.0.0.0/8:9000", "udp://*:123" or "sctp://*:2905"
+	cfg.BindEnvAndSetDefault(join(netNS, "ignore_conns"), []string{})
+	// collection of the DSCP marking and IPv6 flow label of each connection by the eBPF tracer
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_qos_marking"), false)
+	// tagging of the connections which hairpin back to a local workload through the host's NAT
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_nat_hairpin_detection"), false)
+	// histograms of the RTT samples of each TCP connection, exported by the OTLP exporter
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_rtt_histograms"), false)
+	// polling of the sockets with sock_diag when the eBPF tracer can't be loaded
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_sock_diag_fallback"), false)
+	// seeding of the TCP connections established before the tracer started
+	cfg.BindEnvAndSetDefault(join(netNS, "seed_existing_connections"), true)
+	// reassembly of the DNS messages spanning several TCP segments
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_dns_tcp_reassembly"), true)
+	// hostnames of the DNS over HTTPS servers in addition to the public ones
+	cfg.BindEnvAndSetDefault(join(netNS, "encrypted_dns_hosts"), []string{})
+	// tagging of the connections carrying DNS over TLS or DNS over HTTPS
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_encrypted_dns_tags"), false)
 
 	// windows config
 	cfg.BindEnvAndSetDefault(join(spNS, "windows.enable_monotonic_count"), false)
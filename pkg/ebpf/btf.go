@@ -97,8 +97,13 @@ type returnBTF struct {
 type orderedBTFLoader struct {
 	userBTFPath string
 	embeddedDir string
+	downloadURL string
+	downloadDir string
+	digestsPath string
 
-	result         ebpftelemetry.BTFResult
+	result ebpftelemetry.BTFResult
+	// source is the BTF source reported by the telemetry, which tells the downloaded BTF apart
+	source         string
 	loadFunc       funcs.CachedFunc[returnBTF]
 	delayedFlusher *time.Timer
 }
@@ -107,6 +112,9 @@ func initBTFLoader(cfg *Config) *orderedBTFLoader {
 	btfLoader := &orderedBTFLoader{
 		userBTFPath: cfg.BTFPath,
 		embeddedDir: filepath.Join(cfg.BPFDir, "co-re", "btf"),
+		downloadURL: cfg.BTFDownloadURL,
+		downloadDir: cfg.BTFDownloadDir,
+		digestsPath: cfg.BTFDownloadDigests,
 		result:      ebpftelemetry.BtfNotFound,
 	}
 	btfLoader.loadFunc = funcs.CacheWithCallback[returnBTF](btfLoader.get, loadKernelSpec.Flush)
@@ -134,18 +142,26 @@ func (b *orderedBTFLoader) Flush() {
 func (b *orderedBTFLoader) get() (*returnBTF, error) {
 	loaders := []struct {
 		result ebpftelemetry.BTFResult
+		source string
 		loader btfLoaderFunc
 		desc   string
 	}{
-		{ebpftelemetry.SuccessCustomBTF, b.loadUser, "configured BTF file"},
-		{ebpftelemetry.SuccessDefaultBTF, b.loadKernel, "kernel"},
-		{ebpftelemetry.SuccessEmbeddedBTF, b.loadEmbedded, "embedded collection"},
+		{ebpftelemetry.SuccessCustomBTF, "custom", b.loadUser, "configured BTF file"},
+		{ebpftelemetry.SuccessDefaultBTF, "default", b.loadKernel, "kernel"},
+		{ebpftelemetry.SuccessEmbeddedBTF, "embedded", b.loadEmbedded, "embedded collection"},
+		// the payload has no result for the downloaded BTF, which is loaded like a configured file
+		{ebpftelemetry.SuccessCustomBTF, "remote", b.loadRemote, "remote archive"},
 	}
 	var err error
 	var ret *returnBTF
 	for _, l := range loaders {
 		log.Debugf("attempting BTF load from %s", l.desc)
-		ret, err = l.loader()
+		r, loadErr := l.loader()
+		if errors.Is(loadErr, errBTFDownloadNotConfigured) {
+			// keep the error of the previous loaders
+			continue
+		}
+		ret, err = r, loadErr
 		if err != nil {
 			err = fmt.Errorf("BTF load from %s: %w", l.desc, err)
 			// attempting default kernel when not supported will return this error
@@ -157,6 +173,7 @@ func (b *orderedBTFLoader) get() (*returnBTF, error) {
 		if ret != nil {
 			log.Debugf("successfully loaded BTF from %s", l.desc)
 			b.result = l.result
+			b.source = l.source
 			return ret, nil
 		}
 	}
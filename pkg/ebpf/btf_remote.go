@@ -0,0 +1,122 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package ebpf
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/archive"
+	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const btfDownloadTimeout = 2 * time.Minute
+
+// btfDownloadClient is the client used to download the BTF tarballs
+var btfDownloadClient = &http.Client{Timeout: btfDownloadTimeout}
+
+// errBTFDownloadNotConfigured is returned by the remote loader when no BTF archive is configured
+var errBTFDownloadNotConfigured = errors.New("no BTF download URL configured")
+
+// loadRemote loads the BTF of the running kernel from the configured archive, such as BTFHub, for the kernels
+// which aren't in the embedded collection. The downloaded BTF is cached in the download directory, so that it
+// is only fetched once per kernel.
+func (b *orderedBTFLoader) loadRemote() (*returnBTF, error) {
+	if b.downloadURL == "" {
+		return nil, errBTFDownloadNotConfigured
+	}
+
+	platform, err := getBTFPlatform()
+	if err != nil {
+		return nil, fmt.Errorf("BTF platform: %s", err)
+	}
+	platformVersion, err := kernel.PlatformVersion()
+	if err != nil {
+		return nil, fmt.Errorf("platform version: %s", err)
+	}
+	kernelVersion, err := kernel.Release()
+	if err != nil {
+		return nil, fmt.Errorf("kernel release: %s", err)
+	}
+	arch, err := kernel.Machine()
+	if err != nil {
+		return nil, fmt.Errorf("kernel machine: %s", err)
+	}
+	return b.fetchRemoteBTF(platform, platformVersion, btfArch(arch), kernelVersion)
+}
+
+func (b *orderedBTFLoader) fetchRemoteBTF(platform btfPlatform, platformVersion, arch, kernelVersion string) (*returnBTF, error) {
+	// <download_dir>/<platform>/<platform_version>/<arch>/<kernel_version>.btf
+	extractDir := filepath.Join(b.downloadDir, platform.String(), platformVersion, arch)
+	btfPath := filepath.Join(extractDir, kernelVersion+".btf")
+	if _, err := os.Stat(btfPath); err != nil {
+		digests, err := LoadPinnedDigests(b.digestsPath)
+		if err != nil {
+			return nil, fmt.Errorf("BTF download digests: %w", err)
+		}
+		url := remoteBTFURL(b.downloadURL, platform, platformVersion, arch, kernelVersion)
+		// the tarballs are pinned by their path in the archive, as printed by sha256sum from its root
+		name := path.Join(platform.String(), platformVersion, arch, kernelVersion+".btf.tar.xz")
+		log.Infof("BTF of kernel %s not found locally: downloading it from %s", kernelVersion, url)
+		if err := downloadBTF(url, digests, name, extractDir); err != nil {
+			return nil, err
+		}
+	}
+
+	spec, err := loadBTFFrom(btfPath)
+	if err != nil {
+		// download it again next time rather than keep a corrupted file
+		_ = os.Remove(btfPath)
+		return nil, err
+	}
+	// no module load function for single file BTF
+	return &returnBTF{vmlinux: spec, moduleLoadFunc: nil}, nil
+}
+
+// remoteBTFURL expands the placeholders of the configured URL, which follows the layout of BTFHub by default
+func remoteBTFURL(template string, platform btfPlatform, platformVersion, arch, kernelVersion string) string {
+	return strings.NewReplacer(
+		"{platform}", platform.String(),
+		"{platform_version}", platformVersion,
+		"{arch}", arch,
+		"{kernel}", kernelVersion,
+	).Replace(template)
+}
+
+// btfArch returns the name of the architecture used by the BTF archives
+func btfArch(machine string) string {
+	if machine == "aarch64" {
+		return "arm64"
+	}
+	return machine
+}
+
+// downloadBTF downloads the .btf.tar.xz tarball at the given URL and extracts it in the given directory. The
+// tarball must match the digest pinned for the given name.
+func downloadBTF(url string, digests PinnedDigests, name string, extractDir string) error {
+	if err := os.MkdirAll(extractDir, 0755); err != nil {
+		return fmt.Errorf("mkdir %s: %w", extractDir, err)
+	}
+	tarball := filepath.Join(extractDir, path.Base(name))
+	if err := DownloadVerified(btfDownloadClient, url, digests, name, tarball); err != nil {
+		return fmt.Errorf("download BTF tarball: %w", err)
+	}
+	defer os.Remove(tarball)
+
+	if err := archive.TarXZExtractAll(tarball, extractDir); err != nil {
+		return fmt.Errorf("extract downloaded BTF tarball: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package ebpf
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/util/archive"
+)
+
+func TestRemoteBTFURL(t *testing.T) {
+	template := "https://example.com/btfhub-archive/{platform}/{platform_version}/{arch}/{kernel}.btf.tar.xz"
+	assert.Equal(t,
+		"https://example.com/btfhub-archive/ubuntu/18.04/x86_64/4.15.0-1029-aws.btf.tar.xz",
+		remoteBTFURL(template, platformUbuntu, "18.04", btfArch("x86_64"), "4.15.0-1029-aws"),
+	)
+	assert.Equal(t, "arm64", btfArch("aarch64"))
+}
+
+func TestFetchRemoteBTF(t *testing.T) {
+	cd, err := curDir()
+	require.NoError(t, err)
+
+	const kernelVersion = "4.14.320-242.534.amzn2.aarch64"
+	servedDir := t.TempDir()
+	tarballPath := "amzn/" + kernelVersion + ".btf.tar.xz"
+	require.NoError(t, archive.TarXZExtractFile(filepath.Join(cd, "testdata", btfArchiveName), tarballPath, servedDir))
+
+	tarball, err := os.ReadFile(filepath.Join(servedDir, tarballPath))
+	require.NoError(t, err)
+
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/amzn/2/arm64/" + kernelVersion + ".btf.tar.xz":
+			requests++
+			_, _ = w.Write(tarball)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	defaultClient := btfDownloadClient
+	btfDownloadClient = server.Client()
+	t.Cleanup(func() { btfDownloadClient = defaultClient })
+
+	loader := initBTFLoader(&Config{})
+	_, err = loader.loadRemote()
+	assert.ErrorIs(t, err, errBTFDownloadNotConfigured)
+
+	loader.downloadURL = server.URL + "/{platform}/{platform_version}/{arch}/{kernel}.btf.tar.xz"
+	loader.downloadDir = t.TempDir()
+
+	// nothing is downloaded without pinned digests
+	_, err = loader.fetchRemoteBTF(platformAmazon, "2", "arm64", kernelVersion)
+	assert.ErrorContains(t, err, "no pinned digests configured")
+	assert.Equal(t, 0, requests)
+
+	loader.digestsPath = filepath.Join(t.TempDir(), "SHA256SUMS")
+	writeDigests := func(content []byte) {
+		digests := fmt.Sprintf("%x  amzn/2/arm64/%s.btf.tar.xz\n", sha256.Sum256(content), kernelVersion)
+		require.NoError(t, os.WriteFile(loader.digestsPath, []byte(digests), 0644))
+	}
+	writeDigests(tarball)
+
+	ret, err := loader.fetchRemoteBTF(platformAmazon, "2", "arm64", kernelVersion)
+	require.NoError(t, err)
+	require.NotNil(t, ret.vmlinux)
+	assert.FileExists(t, filepath.Join(loader.downloadDir, "amzn", "2", "arm64", kernelVersion+".btf"))
+
+	// the cached BTF is loaded without downloading it again
+	ret, err = loader.fetchRemoteBTF(platformAmazon, "2", "arm64", kernelVersion)
+	require.NoError(t, err)
+	require.NotNil(t, ret.vmlinux)
+	assert.Equal(t, 1, requests)
+
+	// the kernels without a pinned digest aren't downloaded
+	_, err = loader.fetchRemoteBTF(platformAmazon, "2", "arm64", "4.14.0-unknown")
+	assert.ErrorContains(t, err, "no digest pinned")
+	entries, err := os.ReadDir(filepath.Join(loader.downloadDir, "amzn", "2", "arm64"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "the failed download must not be left behind")
+
+	// the tarball must match its pinned digest
+	writeDigests([]byte("foo"))
+	require.NoError(t, os.RemoveAll(loader.downloadDir))
+	_, err = loader.fetchRemoteBTF(platformAmazon, "2", "arm64", kernelVersion)
+	assert.ErrorContains(t, err, "digest mismatch")
+	assert.NoFileExists(t, filepath.Join(loader.downloadDir, "amzn", "2", "arm64", kernelVersion+".btf"))
+
+	// the tarballs are only downloaded over https
+	writeDigests(tarball)
+	loader.downloadURL = strings.Replace(loader.downloadURL, "https://", "http://", 1)
+	_, err = loader.fetchRemoteBTF(platformAmazon, "2", "arm64", kernelVersion)
+	assert.ErrorContains(t, err, "only https URLs are allowed")
+}
@@ -51,20 +51,6 @@ func (a *asset) Compile(config *ebpf.Config, additionalFlags []string, llcFlags
 		}
 	}()
 
-	opts := kernel.HeaderOptions{
-		DownloadEnabled: config.EnableKernelHeaderDownload,
-		Dirs:            config.KernelHeadersDirs,
-		DownloadDir:     config.KernelHeadersDownloadDir,
-		AptConfigDir:    config.AptConfigDir,
-		YumReposDir:     config.YumReposDir,
-		ZypperReposDir:  config.ZypperReposDir,
-	}
-	kernelHeaders := kernel.GetKernelHeaders(opts, client)
-	if len(kernelHeaders) == 0 {
-		a.tm.compilationResult = headerFetchErr
-		return nil, fmt.Errorf("unable to find kernel headers")
-	}
-
 	outputDir := config.RuntimeCompilerOutputDir
 
 	p := filepath.Join(config.BPFDir, "runtime", a.filename)
@@ -93,6 +79,29 @@ func (a *asset) Compile(config *ebpf.Config, additionalFlags []string, llcFlags
 		return nil, fmt.Errorf("error reading input file: %s", err)
 	}
 
+	if config.RuntimeCompilerArtifactURL != "" {
+		out, result, err := fetchRemoteOutput(config.RuntimeCompilerArtifactURL, config.RuntimeCompilerArtifactDigests, outputDir, a.filename, a.hash, additionalFlags)
+		if err == nil {
+			a.tm.compilationResult = result
+			return out, nil
+		}
+		log.Infof("precompiled runtime version of %s unavailable, compiling it locally: %s", a.filename, err)
+	}
+
+	opts := kernel.HeaderOptions{
+		DownloadEnabled: config.EnableKernelHeaderDownload,
+		Dirs:            config.KernelHeadersDirs,
+		DownloadDir:     config.KernelHeadersDownloadDir,
+		AptConfigDir:    config.AptConfigDir,
+		YumReposDir:     config.YumReposDir,
+		ZypperReposDir:  config.ZypperReposDir,
+	}
+	kernelHeaders := kernel.GetKernelHeaders(opts, client)
+	if len(kernelHeaders) == 0 {
+		a.tm.compilationResult = headerFetchErr
+		return nil, fmt.Errorf("unable to find kernel headers")
+	}
+
 	out, result, err := compileToObjectFile(protectedFile.Name(), outputDir, a.filename, a.hash, additionalFlags, llcFlags, kernelHeaders)
 	a.tm.compilationResult = result
 
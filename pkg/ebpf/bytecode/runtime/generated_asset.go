@@ -50,20 +50,6 @@ func (a *generatedAsset) Compile(config *ebpf.Config, inputCode string, addition
 		}
 	}()
 
-	opts := kernel.HeaderOptions{
-		DownloadEnabled: config.EnableKernelHeaderDownload,
-		Dirs:            config.KernelHeadersDirs,
-		DownloadDir:     config.KernelHeadersDownloadDir,
-		AptConfigDir:    config.AptConfigDir,
-		YumReposDir:     config.YumReposDir,
-		ZypperReposDir:  config.ZypperReposDir,
-	}
-	kernelHeaders := kernel.GetKernelHeaders(opts, client)
-	if len(kernelHeaders) == 0 {
-		a.tm.compilationResult = headerFetchErr
-		return nil, fmt.Errorf("unable to find kernel headers")
-	}
-
 	outputDir := config.RuntimeCompilerOutputDir
 
 	inputHash, err := sha256hex([]byte(inputCode))
@@ -87,6 +73,29 @@ func (a *generatedAsset) Compile(config *ebpf.Config, inputCode string, addition
 		}
 	}()
 
+	if config.RuntimeCompilerArtifactURL != "" {
+		out, result, err := fetchRemoteOutput(config.RuntimeCompilerArtifactURL, config.RuntimeCompilerArtifactDigests, outputDir, a.filename, inputHash, additionalFlags)
+		if err == nil {
+			a.tm.compilationResult = result
+			return out, nil
+		}
+		log.Infof("precompiled runtime version of %s unavailable, compiling it locally: %s", a.filename, err)
+	}
+
+	opts := kernel.HeaderOptions{
+		DownloadEnabled: config.EnableKernelHeaderDownload,
+		Dirs:            config.KernelHeadersDirs,
+		DownloadDir:     config.KernelHeadersDownloadDir,
+		AptConfigDir:    config.AptConfigDir,
+		YumReposDir:     config.YumReposDir,
+		ZypperReposDir:  config.ZypperReposDir,
+	}
+	kernelHeaders := kernel.GetKernelHeaders(opts, client)
+	if len(kernelHeaders) == 0 {
+		a.tm.compilationResult = headerFetchErr
+		return nil, fmt.Errorf("unable to find kernel headers")
+	}
+
 	out, result, err := compileToObjectFile(protectedFile.Name(), outputDir, a.filename, inputHash, additionalFlags, llcFlags, kernelHeaders)
 	a.tm.compilationResult = result
 
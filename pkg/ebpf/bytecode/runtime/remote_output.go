@@ -0,0 +1,55 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package runtime
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const remoteOutputDownloadTimeout = time.Minute
+
+// remoteOutputClient is the client used to download the object files from the artifact store
+var remoteOutputClient = &http.Client{Timeout: remoteOutputDownloadTimeout}
+
+// fetchRemoteOutput downloads the object file compiled for the running kernel, input and flags from the artifact
+// store into the output directory, and opens it. The object files are looked up in the store by the name they
+// have in the output directory, which identifies the kernel, the input and the flags, and must match the digest
+// pinned for that name in the local digests file. The object file already present in the output directory,
+// downloaded or compiled locally, is opened instead, so that neither the store nor the kernel headers are needed
+// once the object file is cached.
+func fetchRemoteOutput(storeURL, digestsPath, outputDir, filename, inputHash string, additionalFlags []string) (CompiledOutput, CompilationResult, error) {
+	_, flagHash := computeFlagsAndHash(additionalFlags)
+	outputFile, err := getOutputFilePath(outputDir, filename, inputHash, flagHash)
+	if err != nil {
+		return nil, outputFileErr, fmt.Errorf("unable to get output file path: %w", err)
+	}
+	if _, err := os.Stat(outputFile); err == nil {
+		return openCompiledOutput(outputFile, compiledOutputFound)
+	}
+
+	digests, err := ebpf.LoadPinnedDigests(digestsPath)
+	if err != nil {
+		return nil, resultReadErr, fmt.Errorf("artifact digests: %w", err)
+	}
+	name := filepath.Base(outputFile)
+	url := strings.TrimSuffix(storeURL, "/") + "/" + name
+	if err := ebpf.DownloadVerified(remoteOutputClient, url, digests, name, outputFile); err != nil {
+		return nil, resultReadErr, err
+	}
+	log.Infof("downloaded precompiled runtime version of %s from %s", filename, url)
+
+	return openCompiledOutput(outputFile, remoteOutputFound)
+}
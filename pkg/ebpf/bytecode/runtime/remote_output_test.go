@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package runtime
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newArtifactStore serves the given object files over https, and pins their digests in a local file
+func newArtifactStore(t *testing.T, objects map[string]string, pinned map[string]string) (url string, digestsPath string, requests *int) {
+	requests = new(int)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		object, ok := objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(object))
+	}))
+	t.Cleanup(server.Close)
+	defaultClient := remoteOutputClient
+	remoteOutputClient = server.Client()
+	t.Cleanup(func() { remoteOutputClient = defaultClient })
+
+	var digests string
+	for name, content := range pinned {
+		digests += fmt.Sprintf("%x  %s\n", sha256.Sum256([]byte(content)), name)
+	}
+	digestsPath = filepath.Join(t.TempDir(), "SHA256SUMS")
+	require.NoError(t, os.WriteFile(digestsPath, []byte(digests), 0644))
+	return server.URL, digestsPath, requests
+}
+
+func TestFetchRemoteOutput(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("the compiled outputs must be owned by root")
+	}
+
+	const inputHash = "abcdef"
+	flags := []string{"-DFOO"}
+	_, flagHash := computeFlagsAndHash(flags)
+	expected, err := getOutputFilePath("", "tracer.c", inputHash, flagHash)
+	require.NoError(t, err)
+
+	object := "object"
+	storeURL, digestsPath, requests := newArtifactStore(t,
+		map[string]string{"/store/" + expected: object},
+		map[string]string{expected: object},
+	)
+
+	outputDir := t.TempDir()
+	out, result, err := fetchRemoteOutput(storeURL+"/store/", digestsPath, outputDir, "tracer.c", inputHash, flags)
+	require.NoError(t, err)
+	require.NotNil(t, out)
+	assert.Equal(t, remoteOutputFound, result)
+	content, err := io.ReadAll(out)
+	require.NoError(t, err)
+	assert.Equal(t, object, string(content))
+	require.NoError(t, out.Close())
+	assert.Equal(t, 1, *requests)
+
+	// the downloaded output is opened from the output directory by the next instances
+	out, result, err = fetchRemoteOutput(storeURL+"/store", digestsPath, outputDir, "tracer.c", inputHash, flags)
+	require.NoError(t, err)
+	require.NotNil(t, out)
+	assert.Equal(t, compiledOutputFound, result)
+	require.NoError(t, out.Close())
+	assert.Equal(t, 1, *requests)
+
+	// the outputs without a pinned digest aren't downloaded
+	_, _, err = fetchRemoteOutput(storeURL+"/store", digestsPath, outputDir, "tracer.c", inputHash, []string{"-DBAR"})
+	assert.ErrorContains(t, err, "no digest pinned")
+	assert.Equal(t, 1, *requests)
+	entries, err := os.ReadDir(outputDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "the failed download must not be left behind")
+	assert.Equal(t, filepath.Base(expected), entries[0].Name())
+}
+
+func TestFetchRemoteOutputDigestMismatch(t *testing.T) {
+	const inputHash = "abcdef"
+	_, flagHash := computeFlagsAndHash(nil)
+	expected, err := getOutputFilePath("", "tracer.c", inputHash, flagHash)
+	require.NoError(t, err)
+
+	storeURL, digestsPath, _ := newArtifactStore(t,
+		map[string]string{"/" + expected: "tampered object"},
+		map[string]string{expected: "object"},
+	)
+
+	outputDir := t.TempDir()
+	_, _, err = fetchRemoteOutput(storeURL, digestsPath, outputDir, "tracer.c", inputHash, nil)
+	require.ErrorContains(t, err, "digest mismatch")
+	entries, err := os.ReadDir(outputDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "the object file must not be kept")
+}
+
+func TestFetchRemoteOutputUntrusted(t *testing.T) {
+	const inputHash = "abcdef"
+	_, flagHash := computeFlagsAndHash(nil)
+	expected, err := getOutputFilePath("", "tracer.c", inputHash, flagHash)
+	require.NoError(t, err)
+
+	storeURL, digestsPath, requests := newArtifactStore(t,
+		map[string]string{"/" + expected: "object"},
+		map[string]string{expected: "object"},
+	)
+
+	// nothing is downloaded without pinned digests
+	_, _, err = fetchRemoteOutput(storeURL, "", t.TempDir(), "tracer.c", inputHash, nil)
+	assert.ErrorContains(t, err, "no pinned digests configured")
+
+	// nor over plain http
+	_, _, err = fetchRemoteOutput("http"+storeURL[len("https"):], digestsPath, t.TempDir(), "tracer.c", inputHash, nil)
+	assert.ErrorContains(t, err, "only https URLs are allowed")
+	assert.Equal(t, 0, *requests)
+}
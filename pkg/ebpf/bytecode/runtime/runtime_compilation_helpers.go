@@ -97,7 +97,12 @@ func compileToObjectFile(inFile, outputDir, filename, inHash string, additionalF
 		result = compiledOutputFound
 	}
 
-	err = bytecode.VerifyAssetPermissions(outputFile)
+	return openCompiledOutput(outputFile, result)
+}
+
+// openCompiledOutput opens the object file once its permissions are verified
+func openCompiledOutput(outputFile string, result CompilationResult) (CompiledOutput, CompilationResult, error) {
+	err := bytecode.VerifyAssetPermissions(outputFile)
 	if err != nil {
 		return nil, outputFileErr, err
 	}
@@ -38,6 +38,7 @@ const (
 	headerFetchErr
 	compiledOutputFound
 	inputHashError
+	remoteOutputFound
 )
 
 // CompilationTelemetry is telemetry collected per-program when attempting runtime compilation
@@ -97,7 +98,7 @@ func (tm *CompilationTelemetry) SubmitTelemetry(filename string, statsdClient st
 
 	if tm.compilationResult != notAttempted {
 		var resultTag string
-		if tm.compilationResult == compilationSuccess || tm.compilationResult == compiledOutputFound {
+		if tm.compilationResult == compilationSuccess || tm.compilationResult == compiledOutputFound || tm.compilationResult == remoteOutputFound {
 			resultTag = "success"
 		} else {
 			resultTag = "failure"
@@ -111,16 +111,10 @@ func (c *coreAssetLoader) reportTelemetry(assetName string, result ebpftelemetry
 	tags := make([]string, 0, 6)
 	tags = append(tags, platform.String(), platformVersion, kernelVersion, arch, assetName)
 	if ebpftelemetry.BTFResult(result) < ebpftelemetry.BtfNotFound {
-		switch ebpftelemetry.BTFResult(result) {
-		case ebpftelemetry.SuccessCustomBTF:
-			tags = append(tags, "custom")
-		case ebpftelemetry.SuccessEmbeddedBTF:
-			tags = append(tags, "embedded")
-		case ebpftelemetry.SuccessDefaultBTF:
-			tags = append(tags, "default")
-		default:
+		if c.btfLoader.source == "" {
 			return
 		}
+		tags = append(tags, c.btfLoader.source)
 		c.telemetry.success.Inc(tags...)
 		return
 	}
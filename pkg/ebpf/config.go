@@ -43,6 +43,17 @@ type Config struct {
 	// BTFPath is the path to BTF data for the current kernel
 	BTFPath string
 
+	// BTFDownloadURL is the URL template of the BTF archive to download the BTF from, when it is neither provided by
+	// the kernel nor embedded. Empty to disable the download. Only https URLs are allowed.
+	BTFDownloadURL string
+
+	// BTFDownloadDigests is the path of the local file pinning the SHA-256 digests of the BTF tarballs, in the
+	// format of sha256sum. The tarballs without a pinned digest aren't downloaded.
+	BTFDownloadDigests string
+
+	// BTFDownloadDir is the directory where the downloaded BTF files are cached
+	BTFDownloadDir string
+
 	// EnableRuntimeCompiler enables the use of the embedded compiler to build eBPF programs on-host
 	EnableRuntimeCompiler bool
 
@@ -58,6 +69,14 @@ type Config struct {
 	// RuntimeCompilerOutputDir is the directory where the runtime compiler will store compiled programs
 	RuntimeCompilerOutputDir string
 
+	// RuntimeCompilerArtifactURL is the URL of the store of the programs compiled for each kernel, which
+	// are downloaded instead of being compiled locally when available. Only https URLs are allowed.
+	RuntimeCompilerArtifactURL string
+
+	// RuntimeCompilerArtifactDigests is the path of the local file pinning the SHA-256 digests of the programs of
+	// the artifact store, in the format of sha256sum. The programs without a pinned digest aren't downloaded.
+	RuntimeCompilerArtifactDigests string
+
 	// AptConfigDir is the path to the apt config directory
 	AptConfigDir string
 
@@ -100,16 +119,22 @@ func NewConfig() *Config {
 		EnableCORE: cfg.GetBool(key(spNS, "enable_co_re")),
 		BTFPath:    cfg.GetString(key(spNS, "btf_path")),
 
-		EnableRuntimeCompiler:        cfg.GetBool(key(spNS, "enable_runtime_compiler")),
-		RuntimeCompilerOutputDir:     cfg.GetString(key(spNS, "runtime_compiler_output_dir")),
-		EnableKernelHeaderDownload:   cfg.GetBool(key(spNS, "enable_kernel_header_download")),
-		KernelHeadersDirs:            cfg.GetStringSlice(key(spNS, "kernel_header_dirs")),
-		KernelHeadersDownloadDir:     cfg.GetString(key(spNS, "kernel_header_download_dir")),
-		AptConfigDir:                 cfg.GetString(key(spNS, "apt_config_dir")),
-		YumReposDir:                  cfg.GetString(key(spNS, "yum_repos_dir")),
-		ZypperReposDir:               cfg.GetString(key(spNS, "zypper_repos_dir")),
-		AllowPrecompiledFallback:     cfg.GetBool(key(spNS, "allow_precompiled_fallback")),
-		AllowRuntimeCompiledFallback: cfg.GetBool(key(spNS, "allow_runtime_compiled_fallback")),
+		BTFDownloadURL:     cfg.GetString(key(spNS, "btf_download_url")),
+		BTFDownloadDir:     cfg.GetString(key(spNS, "btf_download_dir")),
+		BTFDownloadDigests: cfg.GetString(key(spNS, "btf_download_digests")),
+
+		EnableRuntimeCompiler:          cfg.GetBool(key(spNS, "enable_runtime_compiler")),
+		RuntimeCompilerOutputDir:       cfg.GetString(key(spNS, "runtime_compiler_output_dir")),
+		RuntimeCompilerArtifactURL:     cfg.GetString(key(spNS, "runtime_compiler_artifact_url")),
+		RuntimeCompilerArtifactDigests: cfg.GetString(key(spNS, "runtime_compiler_artifact_digests")),
+		EnableKernelHeaderDownload:     cfg.GetBool(key(spNS, "enable_kernel_header_download")),
+		KernelHeadersDirs:              cfg.GetStringSlice(key(spNS, "kernel_header_dirs")),
+		KernelHeadersDownloadDir:       cfg.GetString(key(spNS, "kernel_header_download_dir")),
+		AptConfigDir:                   cfg.GetString(key(spNS, "apt_config_dir")),
+		YumReposDir:                    cfg.GetString(key(spNS, "yum_repos_dir")),
+		ZypperReposDir:                 cfg.GetString(key(spNS, "zypper_repos_dir")),
+		AllowPrecompiledFallback:       cfg.GetBool(key(spNS, "allow_precompiled_fallback")),
+		AllowRuntimeCompiledFallback:   cfg.GetBool(key(spNS, "allow_runtime_compiled_fallback")),
 
 		AttachKprobesWithKprobeEventsABI: cfg.GetBool(key(spNS, "attach_kprobes_with_kprobe_events_abi")),
 		EBPFInstrumentationEnabled:       cfg.GetBool(key(spNS, "ebpf_instrumentation", "enabled")),
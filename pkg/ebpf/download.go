@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package ebpf
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PinnedDigests are the SHA-256 digests of the files which may be downloaded, indexed by file name
+type PinnedDigests map[string][]byte
+
+// LoadPinnedDigests reads the SHA-256 digests pinned in the given local file, in the format of sha256sum.
+// The digests are never fetched from the server the files are downloaded from, which could serve any file
+// along with its digest.
+func LoadPinnedDigests(path string) (PinnedDigests, error) {
+	if path == "" {
+		return nil, errors.New("no pinned digests configured")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open pinned digests: %w", err)
+	}
+	defer f.Close()
+
+	digests := make(PinnedDigests)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid pinned digest line %q", line)
+		}
+		digest, err := hex.DecodeString(fields[0])
+		if err != nil || len(digest) != sha256.Size {
+			return nil, fmt.Errorf("invalid SHA-256 digest %q", fields[0])
+		}
+		// sha256sum prefixes the files read in binary mode with '*'
+		name := strings.TrimPrefix(strings.TrimPrefix(fields[1], "*"), "./")
+		digests[name] = digest
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read pinned digests: %w", err)
+	}
+	return digests, nil
+}
+
+// DownloadVerified downloads the file at the given https URL to the given path, if it matches the digest pinned
+// for the given name. The file is renamed once complete and verified, so that a partial or tampered download is
+// never left at the given path.
+func DownloadVerified(client *http.Client, rawURL string, digests PinnedDigests, name string, path string) error {
+	expected, ok := digests[name]
+	if !ok {
+		return fmt.Errorf("no digest pinned for %s", name)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("parse download URL: %w", err)
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("download %s: only https URLs are allowed", rawURL)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create download file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return fmt.Errorf("download %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download %s: unexpected status %s", rawURL, resp.Status)
+	}
+	digest := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, digest), resp.Body); err != nil {
+		return fmt.Errorf("download %s: %w", rawURL, err)
+	}
+	if sum := digest.Sum(nil); !bytes.Equal(sum, expected) {
+		return fmt.Errorf("download %s: digest mismatch: expected %x, got %x", rawURL, expected, sum)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		return fmt.Errorf("chmod download file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close download file: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
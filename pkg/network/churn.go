@@ -0,0 +1,70 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"time"
+
+	"go4.org/intern"
+)
+
+// ConnectionChurn is the rate at which a process creates and closes connections
+type ConnectionChurn struct {
+	Pid         uint32
+	ContainerID string
+	// Created and Closed are the number of connections created and closed since the last check
+	Created uint32
+	Closed  uint32
+
+	CreatedPerSecond float64
+	ClosedPerSecond  float64
+}
+
+type churnKey struct {
+	pid         uint32
+	containerID *intern.Value
+}
+
+// churnCounter counts the connections created and closed by each process during a check
+type churnCounter map[churnKey]*ConnectionChurn
+
+func (cc churnCounter) add(c *ConnectionStats, created, closed bool) {
+	if c.CgroupID != 0 || (!created && !closed) {
+		// the traffic aggregated by cgroup isn't attributed to connections
+		return
+	}
+
+	key := churnKey{pid: c.Pid, containerID: c.ContainerID.Source}
+	churn, ok := cc[key]
+	if !ok {
+		churn = &ConnectionChurn{Pid: c.Pid}
+		if c.ContainerID.Source != nil {
+			churn.ContainerID = c.ContainerID.Source.Get().(string)
+		}
+		cc[key] = churn
+	}
+	if created {
+		churn.Created++
+	}
+	if closed {
+		churn.Closed++
+	}
+}
+
+// rates returns the churn of each process over the given interval
+func (cc churnCounter) rates(interval time.Duration) []ConnectionChurn {
+	if len(cc) == 0 || interval <= 0 {
+		return nil
+	}
+
+	churns := make([]ConnectionChurn, 0, len(cc))
+	for _, churn := range cc {
+		churn.CreatedPerSecond = float64(churn.Created) / interval.Seconds()
+		churn.ClosedPerSecond = float64(churn.Closed) / interval.Seconds()
+		churns = append(churns, *churn)
+	}
+	return churns
+}
@@ -0,0 +1,137 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// CloudSubnet is a subnet of the cloud network of the host
+type CloudSubnet struct {
+	Prefix netip.Prefix
+	Zone   string
+}
+
+// CloudRoute is a route of a route table of the cloud network of the host
+type CloudRoute struct {
+	Destination netip.Prefix
+	// Target is the ID of the target of the route, such as igw-, nat-, tgw- or pcx- on AWS, or
+	// local for the routes within the network
+	Target string
+}
+
+// CloudTopology holds the subnets and the route tables of the cloud network of the host, which
+// tell how the traffic leaving a subnet is routed
+type CloudTopology struct {
+	Subnets []CloudSubnet
+	// RouteTables are the routes of each subnet, those of the main route table being used for the
+	// subnets without a route table of their own
+	RouteTables    map[string][]CloudRoute
+	MainRouteTable []CloudRoute
+}
+
+// route returns the most specific route of the given subnet to the given address
+func (t *CloudTopology) route(subnetID string, addr netip.Addr) (CloudRoute, bool) {
+	routes, ok := t.RouteTables[subnetID]
+	if !ok {
+		routes = t.MainRouteTable
+	}
+
+	var best CloudRoute
+	found := false
+	for _, r := range routes {
+		if r.Destination.Contains(addr) && (!found || r.Destination.Bits() > best.Destination.Bits()) {
+			best, found = r, true
+		}
+	}
+	return best, found
+}
+
+// zone returns the zone of the subnet of the given address
+func (t *CloudTopology) zone(addr netip.Addr) string {
+	for _, s := range t.Subnets {
+		if s.Prefix.Contains(addr) {
+			return s.Zone
+		}
+	}
+	return ""
+}
+
+// classify returns the class of the traffic from the given subnet and zone to the given address,
+// from the target of the route it follows
+func (t *CloudTopology) classify(subnetID, zone string, addr netip.Addr) (TrafficClass, bool) {
+	r, ok := t.route(subnetID, addr)
+	if !ok {
+		return TrafficClassUnknown, false
+	}
+
+	switch gatewayType(r.Target) {
+	case gatewayLocal:
+		if destZone := t.zone(addr); zone != "" && destZone != "" && destZone != zone {
+			return TrafficClassCrossZone, true
+		}
+		return TrafficClassSameNetwork, true
+	case "vpc_peering":
+		return TrafficClassPeering, true
+	case "transit_gateway":
+		return TrafficClassTransitGateway, true
+	case "internet_gateway", "nat_gateway", "egress_only_internet_gateway":
+		return TrafficClassInternet, true
+	case "vpn_gateway":
+		return TrafficClassPrivate, true
+	default:
+		return TrafficClassUnknown, false
+	}
+}
+
+// gatewayLocal is the type of the routes within the network, which have no gateway
+const gatewayLocal = "local"
+
+// gatewayTypes maps the prefix of the IDs of the route targets to their type
+var gatewayTypes = []struct {
+	prefix string
+	name   string
+}{
+	{"igw-", "internet_gateway"},
+	{"nat-", "nat_gateway"},
+	{"eigw-", "egress_only_internet_gateway"},
+	{"tgw-", "transit_gateway"},
+	{"pcx-", "vpc_peering"},
+	{"vgw-", "vpn_gateway"},
+	{"eni-", "network_interface"},
+	{"i-", "instance"},
+}
+
+// gatewayType returns the type of the target of a route, or an empty string if it is unknown
+func gatewayType(target string) string {
+	if target == gatewayLocal {
+		return gatewayLocal
+	}
+	for _, t := range gatewayTypes {
+		if strings.HasPrefix(target, t.prefix) {
+			return t.name
+		}
+	}
+	return ""
+}
+
+// GatewayType returns the type of the gateway the connection is routed through, such as
+// nat_gateway or transit_gateway, or an empty string if it is unknown or the connection stays
+// within the network
+func GatewayType(c *ConnectionStats) string {
+	if c.Via == nil || c.Via.Subnet.Topology == nil {
+		return ""
+	}
+	r, ok := c.Via.Subnet.Topology.route(c.Via.Subnet.Alias, routedDest(c))
+	if !ok {
+		return ""
+	}
+	if t := gatewayType(r.Target); t != gatewayLocal {
+		return t
+	}
+	return ""
+}
@@ -7,6 +7,9 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -28,6 +31,10 @@ const (
 
 	defaultUDPTimeoutSeconds       = 30
 	defaultUDPStreamTimeoutSeconds = 120
+	defaultDNSPort                 = 53
+
+	// MaxDNSMonitoringPorts is the maximum number of ports on which the DNS traffic can be captured
+	MaxDNSMonitoringPorts = 16
 )
 
 // Config stores all flags used by the network eBPF tracer
@@ -52,6 +59,80 @@ type Config struct {
 	// CollectUDPv6Conns specifies whether the tracer should collect traffic statistics for UDPv6 connections
 	CollectUDPv6Conns bool
 
+	// CollectSCTPConns specifies whether the tracer should collect traffic statistics for SCTP associations.
+	// Only supported by the runtime compiled and CO-RE tracers.
+	CollectSCTPConns bool
+
+	// EnableCgroupAggregation makes the eBPF programs aggregate the traffic by cgroup, remote address,
+	// server port and protocol, instead of per connection. Only supported by the runtime compiled
+	// and CO-RE tracers on cgroup v2 hosts; the per connection statistics are not collected then.
+	EnableCgroupAggregation bool
+
+	// CollectUnixSockets specifies whether the tracer should collect traffic statistics for AF_UNIX sockets.
+	// Only supported by the runtime compiled and CO-RE tracers.
+	CollectUnixSockets bool
+
+	// EnablePacketDropMonitoring specifies whether the packets dropped by the kernel should be counted,
+	// per connection and per interface and drop reason. Requires kernel 5.17+ and is only supported
+	// by the runtime compiled and CO-RE tracers.
+	EnablePacketDropMonitoring bool
+
+	// EnableListenOverflowMonitoring specifies whether the overflows of the accept queue and SYN backlog
+	// of the listening TCP sockets should be counted. Only supported by the runtime compiled and CO-RE tracers.
+	EnableListenOverflowMonitoring bool
+
+	// EnableConnectionProcessInfo specifies whether the eBPF tracer should capture the command, executable,
+	// command line hash and cgroup of the process which created each connection, so that connections of
+	// processes which exited early can still be attributed. Only supported by the runtime compiled and CO-RE tracers.
+	EnableConnectionProcessInfo bool
+
+	// EnableTLSHandshakeInfo specifies whether the protocol classifier should read the server name, versions,
+	// cipher suite and application protocol from the hellos of the TLS connections. Requires protocol classification.
+	EnableTLSHandshakeInfo bool
+
+	// PinnedMapsDir is the directory of the bpffs in which the connection map, the maps its entries go with, and the
+	// conntrack map are pinned, so that their state survives the restarts of system-probe. The counters of the reused
+	// connections start over, and the TCP connections closed while system-probe was down are reported as closed.
+	// The maps aren't pinned if empty.
+	PinnedMapsDir string
+
+	// EnableFentry specifies whether the fentry/fexit tracer should be used instead of the kprobe tracer
+	// on the kernels supporting BPF trampolines. The kprobe tracer is still used on the other kernels,
+	// and when a feature the fentry tracer doesn't implement is enabled.
+	EnableFentry bool
+
+	// EnableWebSocketTracking specifies whether the protocol classifier should classify the connections upgraded
+	// to WebSocket, and count the messages and the bytes they exchange. They are otherwise classified as HTTP.
+	// Requires protocol classification.
+	EnableWebSocketTracking bool
+
+	// EnableInterfaceStats specifies whether the traffic, drop and error counters of the network interfaces
+	// of all namespaces should be sampled with the connections, for the Prometheus metrics.
+	EnableInterfaceStats bool
+
+	// EnableQoSMarking specifies whether the DSCP marking and IPv6 flow label of the traffic sent
+	// on each connection should be collected. Only supported by the runtime compiled and CO-RE tracers.
+	EnableQoSMarking bool
+
+	// EnableNATHairpinDetection specifies whether the connections which would leave the host through a gateway
+	// but are translated by the host's NAT back to a local workload should be tagged with nat_hairpin:true.
+	EnableNATHairpinDetection bool
+
+	// EnableRTTHistograms specifies whether a histogram of the RTT samples of each TCP connection should be
+	// collected by the eBPF tracer, in addition to the last smoothed RTT. The histograms are exported by the
+	// OTLP exporter.
+	EnableRTTHistograms bool
+
+	// EnableSockDiagFallback specifies whether the sockets should be polled with NETLINK_SOCK_DIAG when the eBPF
+	// tracer can't be loaded. The connections are then approximate: short-lived ones are missed and UDP
+	// connections have no byte counts.
+	EnableSockDiagFallback bool
+
+	// SeedExistingConnections specifies whether the TCP connections established before the tracer started
+	// should be read from procfs at startup, so that they are reported with their owner pid and direction
+	// rather than with whichever pid and direction are guessed from their first traced packet.
+	SeedExistingConnections bool
+
 	// CollectLocalDNS specifies whether the tracer should capture traffic for local DNS calls
 	CollectLocalDNS bool
 
@@ -67,6 +148,20 @@ type Config struct {
 	// It is relevant *only* when DNSInspection and CollectDNSStats is enabled.
 	CollectDNSDomains bool
 
+	// EnableDNSTCPReassembly specifies whether the DNS messages spanning several TCP segments, such as the large
+	// answers retried over TCP after a truncated UDP response, should be reassembled. Otherwise only the messages
+	// contained in a single segment are parsed.
+	// It is relevant *only* when DNSInspection is enabled.
+	EnableDNSTCPReassembly bool
+
+	// EncryptedDNSHosts are the hostnames of DNS over HTTPS servers, in addition to the public ones,
+	// to which the HTTPS connections are counted as encrypted DNS traffic.
+	EncryptedDNSHosts []string
+
+	// EnableEncryptedDNSTags specifies whether the connections carrying DNS over TLS or DNS over HTTPS
+	// should be tagged with their protocol, besides being counted by server.
+	EnableEncryptedDNSTags bool
+
 	// DNSTimeout determines the length of time to wait before considering a DNS Query to have timed out
 	DNSTimeout time.Duration
 
@@ -86,10 +181,26 @@ type Config struct {
 	// EnablePostgresMonitoring specifies whether the tracer should monitor Postgres traffic.
 	EnablePostgresMonitoring bool
 
+	// EnableMySQLMonitoring specifies whether the tracer should monitor MySQL traffic.
+	EnableMySQLMonitoring bool
+
+	// EnableRedisMonitoring specifies whether the tracer should monitor Redis traffic.
+	EnableRedisMonitoring bool
+
+	// EnableMongoMonitoring specifies whether the tracer should monitor Mongo traffic.
+	EnableMongoMonitoring bool
+
+	// EnableAMQPMonitoring specifies whether the tracer should monitor AMQP traffic.
+	EnableAMQPMonitoring bool
+
 	// EnableNativeTLSMonitoring specifies whether the USM should monitor HTTPS traffic via native libraries.
-	// Supported libraries: OpenSSL, GnuTLS, LibCrypto.
+	// Supported libraries: OpenSSL, GnuTLS, LibCrypto, and LibreSSL whose libraries share the OpenSSL names.
 	EnableNativeTLSMonitoring bool
 
+	// NativeTLSStaticBinaries is the list of the patterns of the paths of the executables statically linking
+	// OpenSSL, LibreSSL or BoringSSL, to which the OpenSSL hooks are attached when native TLS monitoring is enabled.
+	NativeTLSStaticBinaries []string
+
 	// EnableIstioMonitoring specifies whether USM should monitor Istio traffic
 	EnableIstioMonitoring bool
 
@@ -155,11 +266,17 @@ type Config struct {
 	// being marked as idle and flushed to the perf ring.
 	TCPClosedTimeout time.Duration
 
+	// IdleConnectionTimeout is the amount of time after which a connection on which no data was sent or received
+	// is evicted from the tracer's state, even if its socket is still open. This bounds the memory used by
+	// connections held open by connection pools which never reuse them. 0 disables the eviction.
+	IdleConnectionTimeout time.Duration
+
 	// MaxTrackedConnections specifies the maximum number of connections we can track. This determines the size of the eBPF Maps
 	MaxTrackedConnections uint32
 
-	// MaxClosedConnectionsBuffered represents the maximum number of closed connections we'll buffer in memory. These closed connections
-	// get flushed on every client request (default 30s check interval)
+	// MaxClosedConnectionsBuffered represents the maximum number of closed connections we'll buffer in memory, for each client.
+	// These closed connections get flushed on every client request (default 30s check interval); once the buffer of a client
+	// is full, its oldest connections are dropped, and counted in the closed_conn_buffer_overflows telemetry of that client
 	MaxClosedConnectionsBuffered uint32
 
 	// ClosedConnectionFlushThreshold represents the number of closed connections stored before signalling
@@ -186,6 +303,22 @@ type Config struct {
 	// get flushed on every client request (default 30s check interval)
 	MaxPostgresStatsBuffered int
 
+	// MaxMySQLStatsBuffered represents the maximum number of MySQL stats we'll buffer in memory. These stats
+	// get flushed on every client request (default 30s check interval)
+	MaxMySQLStatsBuffered int
+
+	// MaxRedisStatsBuffered represents the maximum number of Redis stats we'll buffer in memory. These stats
+	// get flushed on every client request (default 30s check interval)
+	MaxRedisStatsBuffered int
+
+	// MaxMongoStatsBuffered represents the maximum number of Mongo stats we'll buffer in memory. These stats
+	// get flushed on every client request (default 30s check interval)
+	MaxMongoStatsBuffered int
+
+	// MaxAMQPStatsBuffered represents the maximum number of AMQP stats we'll buffer in memory. These stats
+	// get flushed on every client request (default 30s check interval)
+	MaxAMQPStatsBuffered int
+
 	// MaxConnectionsStateBuffered represents the maximum number of state objects that we'll store in memory. These state objects store
 	// the stats for a connection so we can accurately determine traffic change between client requests.
 	MaxConnectionsStateBuffered int
@@ -193,6 +326,10 @@ type Config struct {
 	// ClientStateExpiry specifies the max time a client (e.g. process-agent)'s state will be stored in memory before being evicted.
 	ClientStateExpiry time.Duration
 
+	// MaxClientMissedIntervals is the number of fetch intervals a client can miss before its state is evicted, when
+	// sooner than ClientStateExpiry. 0 only evicts the clients after ClientStateExpiry.
+	MaxClientMissedIntervals int
+
 	// EnableConntrack enables probing conntrack for network address translation
 	EnableConntrack bool
 
@@ -210,6 +347,10 @@ type Config struct {
 	// ConntrackRateLimitInterval specifies the interval at which the rate limiter is updated
 	ConntrackRateLimitInterval time.Duration
 
+	// ConntrackDumpRateLimit specifies the maximum number of entries *per second* read during the initial dumps of
+	// the conntrack tables. Setting it to 0 disables the limit. The dumps must still complete within ConntrackInitTimeout.
+	ConntrackDumpRateLimit int
+
 	// ConntrackInitTimeout specifies how long we wait for conntrack to initialize before failing
 	ConntrackInitTimeout time.Duration
 
@@ -217,6 +358,11 @@ type Config struct {
 	// default is true
 	EnableConntrackAllNamespaces bool
 
+	// EnableConntrackDestroyEvents enables the removal of the NAT translations as soon as the kernel destroys
+	// their conntrack entry. The destroy events of all the entries, NAT or not, count toward ConntrackRateLimit,
+	// about doubling the event rate, so they are disabled by default.
+	EnableConntrackDestroyEvents bool
+
 	// EnableEbpfConntracker enables the ebpf based network conntracker. Used only for testing at the moment
 	EnableEbpfConntracker bool
 
@@ -229,6 +375,11 @@ type Config struct {
 	// ExcludedDestinationConnections is a map of destination connections to blacklist
 	ExcludedDestinationConnections map[string][]string
 
+	// IgnoredConnections is a list of rules such as "10.0.0.0/8:9000" or "udp://*:123" matching the
+	// connections which are ignored by the tracer. IPv6 addresses must be enclosed in brackets. Up to
+	// 16 port ranges are also ignored in the kernel, so that the connections aren't tracked at all.
+	IgnoredConnections []string
+
 	// OffsetGuessThreshold is the size of the byte threshold we will iterate over when guessing offsets
 	OffsetGuessThreshold uint64
 
@@ -238,9 +389,76 @@ type Config struct {
 	// EnableGatewayLookup enables looking up gateway information for connection destinations
 	EnableGatewayLookup bool
 
+	// GatewayLookupQueueSize is the size of the queue of the background gateway lookups. Setting it to 0
+	// makes the gateway lookups synchronous, during the flush of the connections.
+	GatewayLookupQueueSize int
+
+	// EnableGatewayProbing enables probing the reachability of the gateways resolved by the gateway lookup,
+	// with ICMP echo requests and the state of their ARP/NDP neighbor entry
+	EnableGatewayProbing bool
+
+	// GatewayProbingInterval is the interval at which the gateways are probed
+	GatewayProbingInterval time.Duration
+
+	// EnableTrafficClassTags specifies whether the connections routed through a gateway should be tagged with
+	// the class of their traffic: same subnet, same cloud network, private or internet. On AWS, the cross-zone,
+	// peered and transit gateway traffic is told apart from the topology of the VPC, read from the EC2 API, which
+	// requires the ec2:DescribeSubnets and ec2:DescribeRouteTables permissions.
+	EnableTrafficClassTags bool
+
+	// EnablePayloadCompression specifies whether the connections payload should be compressed with zstd or gzip,
+	// when the client accepts either of them
+	EnablePayloadCompression bool
+
+	// IPFIXCollector is the host:port address of the collector the connections are exported to as IPFIX flows,
+	// the export is disabled when empty
+	IPFIXCollector string
+
+	// IPFIXExportInterval is the interval at which the connections are exported as IPFIX flows
+	IPFIXExportInterval time.Duration
+
+	// IPFIXObservationDomainID is the observation domain of the exported IPFIX flows
+	IPFIXObservationDomainID uint32
+
+	// OTLPEndpoint is the OTLP/HTTP endpoint of the collector the connections are exported to as OpenTelemetry
+	// metrics, such as http://localhost:4318, the export is disabled when empty
+	OTLPEndpoint string
+
+	// OTLPExportInterval is the interval at which the connections are exported as OpenTelemetry metrics
+	OTLPExportInterval time.Duration
+
+	// OTLPBatchSize is the maximum number of data points sent in a single OTLP export request
+	OTLPBatchSize int
+
+	// EnablePrometheusListener specifies whether the metrics aggregated from the connections should be served in the
+	// Prometheus format on PrometheusListenerAddress
+	EnablePrometheusListener bool
+
+	// PrometheusListenerAddress is the address the Prometheus metrics are served on, such as localhost:9091
+	PrometheusListenerAddress string
+
+	// PrometheusListenerInterval is the interval at which the connections are read to update the Prometheus metrics
+	PrometheusListenerInterval time.Duration
+
+	// CEFExportAddress is the host:port address of the syslog collector the notable network events are sent to as
+	// CEF messages, the export is disabled when empty
+	CEFExportAddress string
+
+	// CEFExportProtocol is the protocol the syslog messages are sent over, udp or tcp
+	CEFExportProtocol string
+
+	// CEFExportInterval is the interval at which the connections and listening sockets are checked for notable events
+	CEFExportInterval time.Duration
+
+	// CEFWatchList is the list of CIDRs the connections with are reported as notable events
+	CEFWatchList []string
+
 	// RecordedQueryTypes enables specific DNS query types to be recorded
 	RecordedQueryTypes []string
 
+	// DNSMonitoringPorts are the ports on which the DNS servers listen, and thus the ports of the captured DNS traffic
+	DNSMonitoringPorts []uint16
+
 	// HTTP replace rules
 	HTTPReplaceRules []*ReplaceRule
 
@@ -274,9 +492,26 @@ type Config struct {
 	// EnableNPMConnectionRollup enables aggregating connections by rolling up ephemeral ports
 	EnableNPMConnectionRollup bool
 
+	// EnableEphemeralPortRollup enables aggregating all the connections differing only by
+	// their client ephemeral port, and not only the short-lived ones
+	EnableEphemeralPortRollup bool
+
+	// TCPFailedConnectionsEnabled enables tracking of TCP connection attempts that never got established
+	// (refused, reset or timed out). Only supported by the runtime compiled and CO-RE tracers.
+	TCPFailedConnectionsEnabled bool
+
 	// EnableUSMQuantization enables endpoint quantization for USM programs
 	EnableUSMQuantization bool
 
+	// USMQuantizationMaxSegments is the number of path segments kept by the quantization, the following ones
+	// are collapsed into a single wildcard. Zero means that all segments are kept.
+	USMQuantizationMaxSegments int
+
+	// USMQuantizationWildcardTypes are the types of the path segments replaced by a wildcard by the
+	// quantization, among "numeric", "hex" and "uuid". When empty, the segments containing digits or
+	// special characters are.
+	USMQuantizationWildcardTypes []string
+
 	// NPMRingbuffersEnabled specifies whether ringbuffers are enabled or not
 	NPMRingbuffersEnabled bool
 
@@ -314,14 +549,36 @@ func New() *Config {
 		TCPConnTimeout:    2 * time.Minute,
 		TCPClosedTimeout:  1 * time.Second,
 
+		IdleConnectionTimeout: cfg.GetDuration(join(netNS, "idle_connection_timeout")),
+
 		CollectUDPv4Conns: cfg.GetBool(join(netNS, "collect_udp_v4")),
 		CollectUDPv6Conns: cfg.GetBool(join(netNS, "collect_udp_v6")),
 		UDPConnTimeout:    defaultUDPTimeoutSeconds * time.Second,
 		UDPStreamTimeout:  defaultUDPStreamTimeoutSeconds * time.Second,
 
+		CollectSCTPConns:   cfg.GetBool(join(netNS, "collect_sctp")),
+		CollectUnixSockets: cfg.GetBool(join(netNS, "collect_unix_sockets")),
+
+		EnablePacketDropMonitoring:     cfg.GetBool(join(netNS, "enable_packet_drop_monitoring")),
+		EnableConnectionProcessInfo:    cfg.GetBool(join(netNS, "enable_connection_process_info")),
+		EnableTLSHandshakeInfo:         cfg.GetBool(join(netNS, "enable_tls_handshake_info")),
+		EnableWebSocketTracking:        cfg.GetBool(join(netNS, "enable_websocket_tracking")),
+		EnableFentry:                   cfg.GetBool(join(netNS, "enable_fentry")),
+		PinnedMapsDir:                  cfg.GetString(join(netNS, "pinned_maps_dir")),
+		EnableInterfaceStats:           cfg.GetBool(join(netNS, "enable_interface_stats")),
+		EnableQoSMarking:               cfg.GetBool(join(netNS, "enable_qos_marking")),
+		EnableNATHairpinDetection:      cfg.GetBool(join(netNS, "enable_nat_hairpin_detection")),
+		EnableRTTHistograms:            cfg.GetBool(join(netNS, "enable_rtt_histograms")),
+		EnableSockDiagFallback:         cfg.GetBool(join(netNS, "enable_sock_diag_fallback")),
+		SeedExistingConnections:        cfg.GetBool(join(netNS, "seed_existing_connections")),
+		EnableListenOverflowMonitoring: cfg.GetBool(join(netNS, "enable_listen_overflow_monitoring")),
+
+		EnableCgroupAggregation: cfg.GetBool(join(netNS, "enable_cgroup_aggregation")),
+
 		OffsetGuessThreshold:           uint64(cfg.GetInt64(join(spNS, "offset_guess_threshold"))),
 		ExcludedSourceConnections:      cfg.GetStringMapStringSlice(join(spNS, "source_excludes")),
 		ExcludedDestinationConnections: cfg.GetStringMapStringSlice(join(spNS, "dest_excludes")),
+		IgnoredConnections:             cfg.GetStringSlice(join(netNS, "ignore_conns")),
 
 		MaxTrackedConnections:          uint32(cfg.GetInt64(join(spNS, "max_tracked_connections"))),
 		MaxClosedConnectionsBuffered:   uint32(cfg.GetInt64(join(spNS, "max_closed_connections_buffered"))),
@@ -329,14 +586,18 @@ func New() *Config {
 		ClosedChannelSize:              cfg.GetInt(join(spNS, "closed_channel_size")),
 		MaxConnectionsStateBuffered:    cfg.GetInt(join(spNS, "max_connection_state_buffered")),
 		ClientStateExpiry:              2 * time.Minute,
-
-		DNSInspection:       !cfg.GetBool(join(spNS, "disable_dns_inspection")),
-		CollectDNSStats:     cfg.GetBool(join(spNS, "collect_dns_stats")),
-		CollectLocalDNS:     cfg.GetBool(join(spNS, "collect_local_dns")),
-		CollectDNSDomains:   cfg.GetBool(join(spNS, "collect_dns_domains")),
-		MaxDNSStats:         cfg.GetInt(join(spNS, "max_dns_stats")),
-		MaxDNSStatsBuffered: 75000,
-		DNSTimeout:          time.Duration(cfg.GetInt(join(spNS, "dns_timeout_in_s"))) * time.Second,
+		MaxClientMissedIntervals:       cfg.GetInt(join(spNS, "max_client_missed_intervals")),
+
+		DNSInspection:          !cfg.GetBool(join(spNS, "disable_dns_inspection")),
+		CollectDNSStats:        cfg.GetBool(join(spNS, "collect_dns_stats")),
+		CollectLocalDNS:        cfg.GetBool(join(spNS, "collect_local_dns")),
+		CollectDNSDomains:      cfg.GetBool(join(spNS, "collect_dns_domains")),
+		EnableDNSTCPReassembly: cfg.GetBool(join(netNS, "enable_dns_tcp_reassembly")),
+		EncryptedDNSHosts:      cfg.GetStringSlice(join(netNS, "encrypted_dns_hosts")),
+		EnableEncryptedDNSTags: cfg.GetBool(join(netNS, "enable_encrypted_dns_tags")),
+		MaxDNSStats:            cfg.GetInt(join(spNS, "max_dns_stats")),
+		MaxDNSStatsBuffered:    75000,
+		DNSTimeout:             time.Duration(cfg.GetInt(join(spNS, "dns_timeout_in_s"))) * time.Second,
 
 		ProtocolClassificationEnabled: cfg.GetBool(join(netNS, "enable_protocol_classification")),
 
@@ -346,13 +607,22 @@ func New() *Config {
 		EnableHTTP2Monitoring:     cfg.GetBool(join(smNS, "enable_http2_monitoring")),
 		EnableKafkaMonitoring:     cfg.GetBool(join(smNS, "enable_kafka_monitoring")),
 		EnablePostgresMonitoring:  cfg.GetBool(join(smNS, "enable_postgres_monitoring")),
+		EnableMySQLMonitoring:     cfg.GetBool(join(smNS, "enable_mysql_monitoring")),
+		EnableRedisMonitoring:     cfg.GetBool(join(smNS, "enable_redis_monitoring")),
+		EnableMongoMonitoring:     cfg.GetBool(join(smNS, "enable_mongo_monitoring")),
+		EnableAMQPMonitoring:      cfg.GetBool(join(smNS, "enable_amqp_monitoring")),
 		EnableNativeTLSMonitoring: cfg.GetBool(join(smNS, "tls", "native", "enabled")),
+		NativeTLSStaticBinaries:   cfg.GetStringSlice(join(smNS, "tls", "native", "static_binaries")),
 		EnableIstioMonitoring:     cfg.GetBool(join(smNS, "tls", "istio", "enabled")),
 		EnableNodeJSMonitoring:    cfg.GetBool(join(smNS, "tls", "nodejs", "enabled")),
 		MaxUSMConcurrentRequests:  uint32(cfg.GetInt(join(smNS, "max_concurrent_requests"))),
 		MaxHTTPStatsBuffered:      cfg.GetInt(join(smNS, "max_http_stats_buffered")),
 		MaxKafkaStatsBuffered:     cfg.GetInt(join(smNS, "max_kafka_stats_buffered")),
 		MaxPostgresStatsBuffered:  cfg.GetInt(join(smNS, "max_postgres_stats_buffered")),
+		MaxMySQLStatsBuffered:     cfg.GetInt(join(smNS, "max_mysql_stats_buffered")),
+		MaxRedisStatsBuffered:     cfg.GetInt(join(smNS, "max_redis_stats_buffered")),
+		MaxMongoStatsBuffered:     cfg.GetInt(join(smNS, "max_mongo_stats_buffered")),
+		MaxAMQPStatsBuffered:      cfg.GetInt(join(smNS, "max_amqp_stats_buffered")),
 
 		MaxTrackedHTTPConnections: cfg.GetInt64(join(smNS, "max_tracked_http_connections")),
 		HTTPNotificationThreshold: cfg.GetInt64(join(smNS, "http_notification_threshold")),
@@ -362,12 +632,37 @@ func New() *Config {
 		ConntrackMaxStateSize:        cfg.GetInt(join(spNS, "conntrack_max_state_size")),
 		ConntrackRateLimit:           cfg.GetInt(join(spNS, "conntrack_rate_limit")),
 		ConntrackRateLimitInterval:   3 * time.Second,
+		ConntrackDumpRateLimit:       cfg.GetInt(join(spNS, "conntrack_dump_rate_limit")),
 		EnableConntrackAllNamespaces: cfg.GetBool(join(spNS, "enable_conntrack_all_namespaces")),
+		EnableConntrackDestroyEvents: cfg.GetBool(join(spNS, "enable_conntrack_destroy_events")),
 		IgnoreConntrackInitFailure:   cfg.GetBool(join(netNS, "ignore_conntrack_init_failure")),
 		ConntrackInitTimeout:         cfg.GetDuration(join(netNS, "conntrack_init_timeout")),
 		EnableEbpfConntracker:        true,
 
-		EnableGatewayLookup: cfg.GetBool(join(netNS, "enable_gateway_lookup")),
+		EnableGatewayLookup:    cfg.GetBool(join(netNS, "enable_gateway_lookup")),
+		GatewayLookupQueueSize: cfg.GetInt(join(netNS, "gateway_lookup_queue_size")),
+		EnableGatewayProbing:   cfg.GetBool(join(netNS, "enable_gateway_probing")),
+		GatewayProbingInterval: cfg.GetDuration(join(netNS, "gateway_probing_interval")),
+		EnableTrafficClassTags: cfg.GetBool(join(netNS, "enable_traffic_class_tags")),
+
+		EnablePayloadCompression: cfg.GetBool(join(netNS, "enable_payload_compression")),
+
+		IPFIXCollector:           cfg.GetString(join(netNS, "ipfix_export", "collector")),
+		IPFIXExportInterval:      cfg.GetDuration(join(netNS, "ipfix_export", "interval")),
+		IPFIXObservationDomainID: uint32(cfg.GetInt(join(netNS, "ipfix_export", "observation_domain_id"))),
+
+		OTLPEndpoint:       cfg.GetString(join(netNS, "otlp_export", "endpoint")),
+		OTLPExportInterval: cfg.GetDuration(join(netNS, "otlp_export", "interval")),
+		OTLPBatchSize:      cfg.GetInt(join(netNS, "otlp_export", "batch_size")),
+
+		EnablePrometheusListener:   cfg.GetBool(join(netNS, "prometheus_listener_enabled")),
+		PrometheusListenerAddress:  cfg.GetString(join(netNS, "prometheus_listener_address")),
+		PrometheusListenerInterval: cfg.GetDuration(join(netNS, "prometheus_listener_interval")),
+
+		CEFExportAddress:  cfg.GetString(join(netNS, "cef_export", "address")),
+		CEFExportProtocol: cfg.GetString(join(netNS, "cef_export", "protocol")),
+		CEFExportInterval: cfg.GetDuration(join(netNS, "cef_export", "interval")),
+		CEFWatchList:      cfg.GetStringSlice(join(netNS, "cef_export", "watch_list")),
 
 		EnableMonotonicCount: cfg.GetBool(join(spNS, "windows.enable_monotonic_count")),
 
@@ -384,22 +679,35 @@ func New() *Config {
 		HTTPIdleConnectionTTL:  time.Duration(cfg.GetInt(join(smNS, "http_idle_connection_ttl_in_s"))) * time.Second,
 
 		EnableNPMConnectionRollup: cfg.GetBool(join(netNS, "enable_connection_rollup")),
+		EnableEphemeralPortRollup: cfg.GetBool(join(netNS, "enable_ephemeral_port_rollup")),
+
+		TCPFailedConnectionsEnabled: cfg.GetBool(join(netNS, "enable_tcp_failed_connections")),
 
 		// Service Monitoring
-		EnableJavaTLSSupport:        cfg.GetBool(join(smjtNS, "enabled")),
-		JavaAgentDebug:              cfg.GetBool(join(smjtNS, "debug")),
-		JavaAgentArgs:               cfg.GetString(join(smjtNS, "args")),
-		JavaAgentAllowRegex:         cfg.GetString(join(smjtNS, "allow_regex")),
-		JavaAgentBlockRegex:         cfg.GetString(join(smjtNS, "block_regex")),
-		JavaDir:                     cfg.GetString(join(smjtNS, "dir")),
-		EnableGoTLSSupport:          cfg.GetBool(join(smNS, "tls", "go", "enabled")),
-		GoTLSExcludeSelf:            cfg.GetBool(join(smNS, "tls", "go", "exclude_self")),
-		EnableHTTPStatsByStatusCode: cfg.GetBool(join(smNS, "enable_http_stats_by_status_code")),
-		EnableUSMQuantization:       cfg.GetBool(join(smNS, "enable_quantization")),
-		EnableUSMConnectionRollup:   cfg.GetBool(join(smNS, "enable_connection_rollup")),
-		EnableUSMRingBuffers:        cfg.GetBool(join(smNS, "enable_ring_buffers")),
-		EnableUSMEventStream:        cfg.GetBool(join(smNS, "enable_event_stream")),
+		EnableJavaTLSSupport:         cfg.GetBool(join(smjtNS, "enabled")),
+		JavaAgentDebug:               cfg.GetBool(join(smjtNS, "debug")),
+		JavaAgentArgs:                cfg.GetString(join(smjtNS, "args")),
+		JavaAgentAllowRegex:          cfg.GetString(join(smjtNS, "allow_regex")),
+		JavaAgentBlockRegex:          cfg.GetString(join(smjtNS, "block_regex")),
+		JavaDir:                      cfg.GetString(join(smjtNS, "dir")),
+		EnableGoTLSSupport:           cfg.GetBool(join(smNS, "tls", "go", "enabled")),
+		GoTLSExcludeSelf:             cfg.GetBool(join(smNS, "tls", "go", "exclude_self")),
+		EnableHTTPStatsByStatusCode:  cfg.GetBool(join(smNS, "enable_http_stats_by_status_code")),
+		EnableUSMQuantization:        cfg.GetBool(join(smNS, "enable_quantization")),
+		USMQuantizationMaxSegments:   cfg.GetInt(join(smNS, "quantization_max_segments")),
+		USMQuantizationWildcardTypes: cfg.GetStringSlice(join(smNS, "quantization_wildcard_types")),
+		EnableUSMConnectionRollup:    cfg.GetBool(join(smNS, "enable_connection_rollup")),
+		EnableUSMRingBuffers:         cfg.GetBool(join(smNS, "enable_ring_buffers")),
+		EnableUSMEventStream:         cfg.GetBool(join(smNS, "enable_event_stream")),
+	}
+
+	dnsPortsKey := join(netNS, "dns_monitoring_ports")
+	dnsPorts, err := parseDNSMonitoringPorts(cfg.GetStringSlice(dnsPortsKey))
+	if err != nil {
+		log.Errorf("error parsing %q, only port %d is monitored: %v", dnsPortsKey, defaultDNSPort, err)
+		dnsPorts = []uint16{defaultDNSPort}
 	}
+	c.DNSMonitoringPorts = dnsPorts
 
 	httpRRKey := join(smNS, "http_replace_rules")
 	rr, err := parseReplaceRules(cfg, httpRRKey)
@@ -431,6 +739,25 @@ func New() *Config {
 	return c
 }
 
+func parseDNSMonitoringPorts(ports []string) ([]uint16, error) {
+	if len(ports) == 0 {
+		return nil, errors.New("no port given")
+	}
+	if len(ports) > MaxDNSMonitoringPorts {
+		return nil, fmt.Errorf("at most %d ports can be monitored", MaxDNSMonitoringPorts)
+	}
+
+	parsed := make([]uint16, 0, len(ports))
+	for _, p := range ports {
+		port, err := strconv.ParseUint(p, 10, 16)
+		if err != nil || port == 0 {
+			return nil, fmt.Errorf("invalid port %q", p)
+		}
+		parsed = append(parsed, uint16(port))
+	}
+	return parsed, nil
+}
+
 func (c *Config) RingBufferSupportedNPM() bool {
 	return (features.HaveMapType(cebpf.RingBuf) == nil) && c.NPMRingbuffersEnabled
 }
@@ -1824,3 +1824,51 @@ func modelCfgFromYAML(t *testing.T, yaml string) model.Config {
 
 	return cfg
 }
+
+func TestDNSMonitoringPorts(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		aconfig.ResetSystemProbeConfig(t)
+		cfg := New()
+
+		assert.Equal(t, []uint16{53}, cfg.DNSMonitoringPorts)
+	})
+
+	t.Run("via YAML", func(t *testing.T) {
+		aconfig.ResetSystemProbeConfig(t)
+		cfg := configurationFromYAML(t, `
+network_config:
+    dns_monitoring_ports: [53, 5353]
+`)
+
+		assert.Equal(t, []uint16{53, 5353}, cfg.DNSMonitoringPorts)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		aconfig.ResetSystemProbeConfig(t)
+		t.Setenv("DD_NETWORK_CONFIG_DNS_MONITORING_PORTS", "53 8053")
+		cfg := New()
+
+		assert.Equal(t, []uint16{53, 8053}, cfg.DNSMonitoringPorts)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		aconfig.ResetSystemProbeConfig(t)
+		cfg := configurationFromYAML(t, `
+network_config:
+    dns_monitoring_ports: [53, 70000]
+`)
+
+		assert.Equal(t, []uint16{53}, cfg.DNSMonitoringPorts)
+	})
+}
+
+func TestParseDNSMonitoringPorts(t *testing.T) {
+	ports, err := parseDNSMonitoringPorts([]string{"53", "5353"})
+	require.NoError(t, err)
+	assert.Equal(t, []uint16{53, 5353}, ports)
+
+	for _, invalid := range [][]string{nil, {"0"}, {"dns"}, {"65536"}, make([]string, MaxDNSMonitoringPorts+1)} {
+		_, err := parseDNSMonitoringPorts(invalid)
+		assert.Error(t, err, "%v", invalid)
+	}
+}
@@ -8,6 +8,9 @@ package network
 import (
 	"net"
 	"net/netip"
+	"strings"
+
+	"go4.org/intern"
 
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 )
@@ -50,6 +53,57 @@ func ByFamily(f ConnectionFamily) ConnectionFilterFunc {
 	}
 }
 
+// BySourcePort matches connections with the given source port
+func BySourcePort(port uint16) ConnectionFilterFunc {
+	return func(c ConnectionStats) bool {
+		return c.SPort == port
+	}
+}
+
+// ByDestPort matches connections with the given destination port
+func ByDestPort(port uint16) ConnectionFilterFunc {
+	return func(c ConnectionStats) bool {
+		return c.DPort == port
+	}
+}
+
+// ByPID matches connections owned by the given process
+func ByPID(pid uint32) ConnectionFilterFunc {
+	return func(c ConnectionStats) bool {
+		return c.Pid == pid
+	}
+}
+
+// ByProcessName matches connections owned by a process with the given command name
+func ByProcessName(name string) ConnectionFilterFunc {
+	return func(c ConnectionStats) bool {
+		return c.Process.Comm != nil && c.Process.Comm.Get().(string) == name
+	}
+}
+
+// ByCIDR matches connections whose source or destination address, before or after NAT, is in the given prefix
+func ByCIDR(prefix netip.Prefix) ConnectionFilterFunc {
+	return func(c ConnectionStats) bool {
+		if prefix.Contains(c.Source.Addr) || prefix.Contains(c.Dest.Addr) {
+			return true
+		}
+		return c.IPTranslation != nil &&
+			(prefix.Contains(c.IPTranslation.ReplSrcIP.Addr) || prefix.Contains(c.IPTranslation.ReplDstIP.Addr))
+	}
+}
+
+// ByContainerID matches connections whose source or destination container id starts with the given id,
+// so that the short form of the ids can be used
+func ByContainerID(id string) ConnectionFilterFunc {
+	return func(c ConnectionStats) bool {
+		return containerIDMatches(c.ContainerID.Source, id) || containerIDMatches(c.ContainerID.Dest, id)
+	}
+}
+
+func containerIDMatches(v *intern.Value, id string) bool {
+	return v != nil && strings.HasPrefix(v.Get().(string), id)
+}
+
 // FirstConnection returns the first connection with matches all filters
 func FirstConnection(c *Connections, filters ...ConnectionFilterFunc) *ConnectionStats {
 	if result := FilterConnections(c, filters...); len(result) > 0 {
@@ -11,9 +11,13 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/network/dns"
 )
 
-// DNSKey generates a key suitable for looking up DNS stats based on a ConnectionStats object
-func DNSKey(c *ConnectionStats) (dns.Key, bool) {
-	if c == nil || c.DPort != 53 {
+// DNSKey generates a key suitable for looking up DNS stats based on a ConnectionStats object,
+// if its destination is one of the DNS monitoring ports
+func DNSKey(c *ConnectionStats, dnsPorts map[uint16]struct{}) (dns.Key, bool) {
+	if c == nil {
+		return dns.Key{}, false
+	}
+	if _, ok := dnsPorts[c.DPort]; !ok {
 		return dns.Key{}, false
 	}
 
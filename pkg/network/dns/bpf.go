@@ -8,65 +8,121 @@
 package dns
 
 import (
-	"math"
-
 	"golang.org/x/net/bpf"
 
 	"github.com/DataDog/datadog-agent/pkg/network/config"
 )
 
-const port = 53
+const (
+	captureLabel = "capture"
+	dropLabel    = "drop"
+	ipv4Label    = "ipv4"
+)
+
+// filterBuilder assembles a classic BPF program whose jumps target labels,
+// since their offsets depend on the number of monitored ports
+type filterBuilder struct {
+	insns  []bpf.Instruction
+	labels map[string]int
+	// jumps maps the index of the conditional jumps to their true and false labels,
+	// an empty label falls through to the next instruction
+	jumps map[int][2]string
+	// gotos maps the index of the unconditional jumps to their label
+	gotos map[int]string
+}
 
+func (b *filterBuilder) add(insn bpf.Instruction) {
+	b.insns = append(b.insns, insn)
+}
+
+func (b *filterBuilder) label(name string) {
+	b.labels[name] = len(b.insns)
+}
+
+func (b *filterBuilder) jumpIf(cond bpf.JumpTest, val uint32, ifTrue, ifFalse string) {
+	b.jumps[len(b.insns)] = [2]string{ifTrue, ifFalse}
+	b.add(bpf.JumpIf{Cond: cond, Val: val})
+}
+
+func (b *filterBuilder) jump(to string) {
+	b.gotos[len(b.insns)] = to
+	b.add(bpf.Jump{})
+}
+
+// capturePorts captures the packet if the port loaded in the accumulator is one of ports
+func (b *filterBuilder) capturePorts(ports []uint16) {
+	for _, port := range ports {
+		b.jumpIf(bpf.JumpEqual, uint32(port), captureLabel, "")
+	}
+}
+
+func (b *filterBuilder) assemble() ([]bpf.RawInstruction, error) {
+	skip := func(from int, label string) uint8 {
+		if label == "" {
+			return 0
+		}
+		return uint8(b.labels[label] - from - 1)
+	}
+	for i, labels := range b.jumps {
+		j := b.insns[i].(bpf.JumpIf)
+		j.SkipTrue, j.SkipFalse = skip(i, labels[0]), skip(i, labels[1])
+		b.insns[i] = j
+	}
+	for i, label := range b.gotos {
+		b.insns[i] = bpf.Jump{Skip: uint32(b.labels[label] - i - 1)}
+	}
+	return bpf.Assemble(b.insns)
+}
+
+// generateBPFFilter returns a classic BPF program capturing the TCP and UDP packets from the
+// DNS monitoring ports, and to them when the DNS stats are collected
 func generateBPFFilter(c *config.Config) ([]bpf.RawInstruction, error) {
-	allowedDestPort := uint32(math.MaxUint32)
+	b := &filterBuilder{labels: make(map[string]int), jumps: make(map[int][2]string), gotos: make(map[int]string)}
+
+	// load Ethertype, if IPv6 go next
+	b.add(bpf.LoadAbsolute{Size: 2, Off: 12})
+	b.jumpIf(bpf.JumpEqual, 0x86dd, "", ipv4Label)
+	// load IPv6 Next Header, if TCP or UDP go next, else drop
+	b.add(bpf.LoadAbsolute{Size: 1, Off: 20})
+	b.jumpIf(bpf.JumpEqual, 0x6, "ipv6_ports", "")
+	b.jumpIf(bpf.JumpEqual, 0x11, "", dropLabel)
+	b.label("ipv6_ports")
+	// load source port
+	b.add(bpf.LoadAbsolute{Size: 2, Off: 54})
+	b.capturePorts(c.DNSMonitoringPorts)
+	if c.CollectDNSStats {
+		// load dest port
+		b.add(bpf.LoadAbsolute{Size: 2, Off: 56})
+		b.capturePorts(c.DNSMonitoringPorts)
+	}
+	b.jump(dropLabel)
+
+	// if IPv4 go next, else drop
+	b.label(ipv4Label)
+	b.jumpIf(bpf.JumpEqual, 0x800, "", dropLabel)
+	// load IPv4 Protocol, if TCP or UDP go next, else drop
+	b.add(bpf.LoadAbsolute{Size: 1, Off: 23})
+	b.jumpIf(bpf.JumpEqual, 0x6, "ipv4_fragment", "")
+	b.jumpIf(bpf.JumpEqual, 0x11, "", dropLabel)
+	b.label("ipv4_fragment")
+	// load Fragment Offset, use 0x1fff as mask for fragment offset, if != 0, drop
+	b.add(bpf.LoadAbsolute{Size: 2, Off: 20})
+	b.jumpIf(bpf.JumpBitsSet, 0x1fff, dropLabel, "")
+	// x = IP header length
+	b.add(bpf.LoadMemShift{Off: 14})
+	// load source port
+	b.add(bpf.LoadIndirect{Size: 2, Off: 14})
+	b.capturePorts(c.DNSMonitoringPorts)
 	if c.CollectDNSStats {
-		allowedDestPort = port
+		// load dest port
+		b.add(bpf.LoadIndirect{Size: 2, Off: 16})
+		b.capturePorts(c.DNSMonitoringPorts)
 	}
 
-	return bpf.Assemble([]bpf.Instruction{
-		//(000) ldh      [12] -- load Ethertype
-		bpf.LoadAbsolute{Size: 2, Off: 12},
-		//(001) jeq      #0x86dd          jt 2	jf 9 -- if IPv6, goto 2, else 9
-		bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x86dd, SkipTrue: 0, SkipFalse: 7},
-		//(002) ldb      [20] -- load IPv6 Next Header
-		bpf.LoadAbsolute{Size: 1, Off: 20},
-		//(003) jeq      #0x6             jt 5	jf 4 -- IPv6 Next Header: if TCP, goto 5
-		bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x6, SkipTrue: 1, SkipFalse: 0},
-		//(004) jeq      #0x11            jt 5	jf 21 -- IPv6 Next Header: if UDP, goto 5, else drop
-		bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x11, SkipTrue: 0, SkipFalse: 16},
-		//(005) ldh      [54] -- load source port
-		bpf.LoadAbsolute{Size: 2, Off: 54},
-		//(006) jeq      #0x35            jt 20	jf 7 -- if 53, capture
-		bpf.JumpIf{Cond: bpf.JumpEqual, Val: port, SkipTrue: 13, SkipFalse: 0},
-		//(007) ldh      [56] -- load dest port
-		bpf.LoadAbsolute{Size: 2, Off: 56},
-		//(008) jeq      #0x35            jt 20	jf 21 -- if allowedDestPort, capture, else drop
-		bpf.JumpIf{Cond: bpf.JumpEqual, Val: allowedDestPort, SkipTrue: 11, SkipFalse: 12},
-		//(009) jeq      #0x800           jt 10	jf 21 -- if IPv4, go next, else drop
-		bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x800, SkipTrue: 0, SkipFalse: 11},
-		//(010) ldb      [23] -- load IPv4 Protocol
-		bpf.LoadAbsolute{Size: 1, Off: 23},
-		//(011) jeq      #0x6             jt 13	jf 12 -- if TCP, goto 13
-		bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x6, SkipTrue: 1, SkipFalse: 0},
-		//(012) jeq      #0x11            jt 13	jf 21 -- if UDP, goto 13, else drop
-		bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x11, SkipTrue: 0, SkipFalse: 8},
-		//(013) ldh      [20] -- load Fragment Offset
-		bpf.LoadAbsolute{Size: 2, Off: 20},
-		//(014) jset     #0x1fff          jt 21	jf 15 -- use 0x1fff as mask for fragment offset, if != 0, drop
-		bpf.JumpIf{Cond: bpf.JumpBitsSet, Val: 0x1fff, SkipTrue: 6, SkipFalse: 0},
-		//(015) ldxb     4*([14]&0xf) -- x = IP header length
-		bpf.LoadMemShift{Off: 14},
-		//(016) ldh      [x + 14] -- load source port
-		bpf.LoadIndirect{Size: 2, Off: 14},
-		//(017) jeq      #0x35            jt 20	jf 18 -- if port 53 capture
-		bpf.JumpIf{Cond: bpf.JumpEqual, Val: port, SkipTrue: 2, SkipFalse: 0},
-		//(018) ldh      [x + 16] -- load dest port
-		bpf.LoadIndirect{Size: 2, Off: 16},
-		//(019) jeq      #0x35            jt 20	jf 21 -- if port allowedDestPort capture, else drop
-		bpf.JumpIf{Cond: bpf.JumpEqual, Val: allowedDestPort, SkipTrue: 0, SkipFalse: 1},
-		//(020) ret      #262144 -- capture
-		bpf.RetConstant{Val: 262144},
-		//(021) ret      #0 -- drop
-		bpf.RetConstant{Val: 0},
-	})
+	b.label(dropLabel)
+	b.add(bpf.RetConstant{Val: 0})
+	b.label(captureLabel)
+	b.add(bpf.RetConstant{Val: 262144})
+
+	return b.assemble()
 }
@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package dns
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/bpf"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+)
+
+func udpPacket(t *testing.T, v6 bool, sport, dport uint16) []byte {
+	eth := &layers.Ethernet{SrcMAC: net.HardwareAddr{0, 1, 2, 3, 4, 5}, DstMAC: net.HardwareAddr{0, 1, 2, 3, 4, 6}}
+	udp := &layers.UDP{SrcPort: layers.UDPPort(sport), DstPort: layers.UDPPort(dport)}
+	var ip gopacket.SerializableLayer
+	if v6 {
+		eth.EthernetType = layers.EthernetTypeIPv6
+		ip6 := &layers.IPv6{Version: 6, HopLimit: 64, NextHeader: layers.IPProtocolUDP, SrcIP: net.ParseIP("fd00::1"), DstIP: net.ParseIP("fd00::2")}
+		require.NoError(t, udp.SetNetworkLayerForChecksum(ip6))
+		ip = ip6
+	} else {
+		eth.EthernetType = layers.EthernetTypeIPv4
+		ip4 := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolUDP, SrcIP: net.ParseIP("10.0.0.1"), DstIP: net.ParseIP("10.0.0.2")}
+		require.NoError(t, udp.SetNetworkLayerForChecksum(ip4))
+		ip = ip4
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	require.NoError(t, gopacket.SerializeLayers(buf, opts, eth, ip, udp, gopacket.Payload(make([]byte, dnsHeaderSize))))
+	return buf.Bytes()
+}
+
+func TestGenerateBPFFilter(t *testing.T) {
+	for _, collectStats := range []bool{false, true} {
+		filter, err := generateBPFFilter(&config.Config{CollectDNSStats: collectStats, DNSMonitoringPorts: []uint16{53, 5353}})
+		require.NoError(t, err)
+		insns, ok := bpf.Disassemble(filter)
+		require.True(t, ok)
+		vm, err := bpf.NewVM(insns)
+		require.NoError(t, err)
+
+		for _, v6 := range []bool{false, true} {
+			captured := func(sport, dport uint16) bool {
+				n, err := vm.Run(udpPacket(t, v6, sport, dport))
+				require.NoError(t, err)
+				return n > 0
+			}
+
+			assert.True(t, captured(53, 40000), "response from port 53, ipv6: %v", v6)
+			assert.True(t, captured(5353, 40000), "response from port 5353, ipv6: %v", v6)
+			assert.False(t, captured(80, 40000), "response from port 80, ipv6: %v", v6)
+			assert.Equal(t, collectStats, captured(40000, 5353), "query to port 5353, ipv6: %v", v6)
+			assert.False(t, captured(40000, 80), "query to port 80, ipv6: %v", v6)
+		}
+	}
+}
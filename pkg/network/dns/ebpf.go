@@ -8,6 +8,7 @@
 package dns
 
 import (
+	"fmt"
 	"math"
 
 	manager "github.com/DataDog/ebpf-manager"
@@ -83,8 +84,24 @@ func (e *ebpfProgram) Init() error {
 		ConstantEditors:           constantEditors,
 		DefaultKprobeAttachMethod: kprobeAttachMethod,
 	})
-	if err == nil {
-		ebpfcheck.AddNameMappings(e.Manager, "npm_dns")
+	if err != nil {
+		return err
+	}
+	ebpfcheck.AddNameMappings(e.Manager, "npm_dns")
+	return e.setDNSPorts()
+}
+
+func (e *ebpfProgram) setDNSPorts() error {
+	ports, _, err := e.GetMap(probes.DNSPortsMap)
+	if err != nil {
+		return fmt.Errorf("error retrieving the bpf %s map: %w", probes.DNSPortsMap, err)
+	}
+
+	var unused uint8
+	for _, port := range e.cfg.DNSMonitoringPorts {
+		if err := ports.Put(port, unused); err != nil {
+			return fmt.Errorf("error adding port %d to the bpf %s map: %w", port, probes.DNSPortsMap, err)
+		}
 	}
-	return err
+	return nil
 }
@@ -27,7 +27,8 @@ import (
 
 type dnsMonitor struct {
 	*socketFilterSnooper
-	p *ebpfProgram
+	p         *ebpfProgram
+	packetSrc *filterpkg.AFPacketSource
 }
 
 // NewReverseDNS starts snooping on DNS traffic to allow IP -> domain reverse resolution
@@ -90,6 +91,7 @@ func NewReverseDNS(cfg *config.Config) (ReverseDNS, error) {
 	return &dnsMonitor{
 		snoop,
 		p,
+		packetSrc,
 	}, nil
 }
 
@@ -105,6 +107,23 @@ func (m *dnsMonitor) Start() error {
 	return nil
 }
 
+// Pause stops the capture of the DNS packets by the socket, and the parsing of those already
+// captured, until Resume is called
+func (m *dnsMonitor) Pause() error {
+	if err := m.packetSrc.Pause(); err != nil {
+		return err
+	}
+	return m.socketFilterSnooper.Pause()
+}
+
+// Resume restarts the capture and parsing of the DNS packets stopped by Pause
+func (m *dnsMonitor) Resume() error {
+	if err := m.socketFilterSnooper.Resume(); err != nil {
+		return err
+	}
+	return m.packetSrc.Resume()
+}
+
 // Close releases associated resources
 func (m *dnsMonitor) Close() {
 	m.socketFilterSnooper.Close()
@@ -13,7 +13,7 @@ import (
 
 // NewReverseDNS starts snooping on DNS traffic to allow IP -> domain reverse resolution
 func NewReverseDNS(cfg *config.Config) (ReverseDNS, error) {
-	packetSrc, err := newWindowsPacketSource()
+	packetSrc, err := newWindowsPacketSource(cfg.DNSMonitoringPorts)
 	if err != nil {
 		return nil, err
 	}
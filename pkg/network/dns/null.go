@@ -30,6 +30,10 @@ func (nullReverseDNS) GetDNSStats() StatsByKeyByNameByType {
 	return nil
 }
 
+func (nullReverseDNS) GetResolverLatencies() LatenciesByResolver {
+	return nil
+}
+
 func (nullReverseDNS) Start() error {
 	return nil
 }
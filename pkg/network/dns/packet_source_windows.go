@@ -20,9 +20,9 @@ type windowsPacketSource struct {
 	di *dnsDriver
 }
 
-// newWindowsPacketSource constructs a new packet source
-func newWindowsPacketSource() (packetSource, error) {
-	di, err := newDriver()
+// newWindowsPacketSource constructs a new packet source capturing the traffic of the given ports
+func newWindowsPacketSource(ports []uint16) (packetSource, error) {
+	di, err := newDriver(ports)
 	if err != nil {
 		return nil, err
 	}
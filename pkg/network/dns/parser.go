@@ -63,18 +63,22 @@ type dnsParser struct {
 	layers             []gopacket.LayerType
 	ipv4Payload        *layers.IPv4
 	ipv6Payload        *layers.IPv6
-	udpPayload         *layers.UDP
+	udpPayload         *udpWithDNSSupport
 	tcpPayload         *tcpWithDNSSupport
 	dnsPayload         *layers.DNS
 	collectDNSStats    bool
 	collectDNSDomains  bool
 	recordedQueryTypes map[layers.DNSType]struct{}
+
+	// tcpStreams is nil when the DNS messages spanning several TCP segments are ignored
+	tcpStreams  *tcpDNSReassembler
+	reassembled []tcpDNSMessage
 }
 
 func newDNSParser(layerType gopacket.LayerType, cfg *config.Config) *dnsParser {
 	ipv4Payload := &layers.IPv4{}
 	ipv6Payload := &layers.IPv6{}
-	udpPayload := &layers.UDP{}
+	udpPayload := newUDPWithDNSSupport(cfg.DNSMonitoringPorts)
 	tcpPayload := &tcpWithDNSSupport{}
 	dnsPayload := &layers.DNS{}
 	queryTypes := getRecordedQueryTypes(cfg)
@@ -95,6 +99,10 @@ func newDNSParser(layerType gopacket.LayerType, cfg *config.Config) *dnsParser {
 		i++
 	}
 	log.Infof("Recording dns query types: %v", qtypelist)
+	var tcpStreams *tcpDNSReassembler
+	if cfg.EnableDNSTCPReassembly {
+		tcpStreams = newTCPDNSReassembler()
+	}
 	return &dnsParser{
 		decoder:            gopacket.NewDecodingLayerParser(layerType, stack...),
 		ipv4Payload:        ipv4Payload,
@@ -105,6 +113,7 @@ func newDNSParser(layerType gopacket.LayerType, cfg *config.Config) *dnsParser {
 		collectDNSStats:    cfg.CollectDNSStats,
 		collectDNSDomains:  cfg.CollectDNSDomains,
 		recordedQueryTypes: queryTypes,
+		tcpStreams:         tcpStreams,
 	}
 }
 
@@ -115,10 +124,23 @@ func (p *dnsParser) ParseInto(data []byte, t *translation, pktInfo *dnsPacketInf
 		return errTruncated
 	}
 
+	if p.tcpStreams != nil && len(p.layers) > 0 && p.layers[len(p.layers)-1] == layers.LayerTypeTCP {
+		if _, unsupported := err.(gopacket.UnsupportedLayerType); err == nil || unsupported {
+			p.reassemble()
+			return errSkippedPayload
+		}
+	}
+
 	if err != nil {
 		return err
 	}
 
+	if p.tcpStreams != nil && len(p.layers) > 1 && p.layers[len(p.layers)-2] == layers.LayerTypeTCP && p.tcpStreams.inProgress(p.tcpFlow()) {
+		// a message which fits in this segment, but follows the incomplete one
+		p.reassemble()
+		return errSkippedPayload
+	}
+
 	// If there is a DNS layer then it would be the last layer
 	if p.layers[len(p.layers)-1] != layers.LayerTypeDNS {
 		return errSkippedPayload
@@ -173,6 +195,66 @@ func (p *dnsParser) ParseInto(data []byte, t *translation, pktInfo *dnsPacketInf
 	return nil
 }
 
+// ParseReassembledInto parses the next of the DNS messages reassembled from the TCP segments
+// given to ParseInto. It returns false once there is none left.
+func (p *dnsParser) ParseReassembledInto(t *translation, pktInfo *dnsPacketInfo) (bool, error) {
+	if len(p.reassembled) == 0 {
+		return false, nil
+	}
+	msg := p.reassembled[0]
+	p.reassembled = p.reassembled[1:]
+
+	if err := p.dnsPayload.DecodeFromBytes(msg.payload, gopacket.NilDecodeFeedback); err != nil {
+		return true, err
+	}
+
+	if err := p.parseAnswerInto(p.dnsPayload, t, pktInfo); err != nil {
+		return true, err
+	}
+
+	if !p.collectDNSStats {
+		return true, nil
+	}
+
+	if pktInfo.pktType == query {
+		pktInfo.key.ClientIP, pktInfo.key.ServerIP = msg.flow.src, msg.flow.dst
+		pktInfo.key.ClientPort = msg.flow.sport
+	} else {
+		pktInfo.key.ServerIP, pktInfo.key.ClientIP = msg.flow.src, msg.flow.dst
+		pktInfo.key.ClientPort = msg.flow.dport
+	}
+	pktInfo.key.Protocol = syscall.IPPROTO_TCP
+	pktInfo.transactionID = p.dnsPayload.ID
+	return true, nil
+}
+
+// reassemble gives the payload of the decoded TCP segment to the tcpDNSReassembler
+func (p *dnsParser) reassemble() {
+	flow := p.tcpFlow()
+	p.reassembled = p.tcpStreams.add(flow, p.tcpPayload.Seq, p.tcpPayload.TCP.LayerPayload(), p.reassembled[:0])
+	if p.tcpPayload.FIN || p.tcpPayload.RST {
+		p.tcpStreams.close(flow)
+	}
+}
+
+func (p *dnsParser) tcpFlow() tcpDNSFlow {
+	flow := tcpDNSFlow{
+		sport: uint16(p.tcpPayload.SrcPort),
+		dport: uint16(p.tcpPayload.DstPort),
+	}
+	for _, layer := range p.layers {
+		switch layer {
+		case layers.LayerTypeIPv4:
+			flow.src = util.AddressFromNetIP(p.ipv4Payload.SrcIP)
+			flow.dst = util.AddressFromNetIP(p.ipv4Payload.DstIP)
+		case layers.LayerTypeIPv6:
+			flow.src = util.AddressFromNetIP(p.ipv6Payload.SrcIP)
+			flow.dst = util.AddressFromNetIP(p.ipv6Payload.DstIP)
+		}
+	}
+	return flow
+}
+
 // source: https://github.com/weaveworks/scope
 func (p *dnsParser) parseAnswerInto(
 	dns *layers.DNS,
@@ -0,0 +1,65 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package dns
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+)
+
+func TestGetRecordedQueryTypes(t *testing.T) {
+	types := getRecordedQueryTypes(&config.Config{})
+	assert.Equal(t, map[layers.DNSType]struct{}{layers.DNSTypeA: {}}, types)
+
+	types = getRecordedQueryTypes(&config.Config{RecordedQueryTypes: []string{"AAAA", "SRV", "unknown"}})
+	assert.Equal(t, map[layers.DNSType]struct{}{layers.DNSTypeAAAA: {}, layers.DNSTypeSRV: {}}, types)
+}
+
+func TestParseQueryType(t *testing.T) {
+	p := newDNSParser(layers.LayerTypeIPv4, &config.Config{
+		CollectDNSStats:    true,
+		CollectDNSDomains:  true,
+		RecordedQueryTypes: []string{"AAAA", "SRV"},
+	})
+	for _, qtype := range []layers.DNSType{layers.DNSTypeAAAA, layers.DNSTypeSRV} {
+		msg := &layers.DNS{
+			ID:        1,
+			Questions: []layers.DNSQuestion{{Name: []byte("_svc._tcp.example.com"), Type: qtype, Class: layers.DNSClassIN}},
+		}
+		var pktInfo dnsPacketInfo
+		assert.NoError(t, p.parseAnswerInto(msg, new(translation), &pktInfo))
+		assert.Equal(t, query, pktInfo.pktType)
+		assert.Equal(t, QueryType(qtype), pktInfo.queryType)
+	}
+}
+
+func TestParseDNSOnMonitoringPort(t *testing.T) {
+	ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolUDP, SrcIP: net.ParseIP("10.0.0.1"), DstIP: net.ParseIP("10.0.0.2")}
+	udp := &layers.UDP{SrcPort: 40000, DstPort: 5353}
+	require.NoError(t, udp.SetNetworkLayerForChecksum(ip))
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	require.NoError(t, gopacket.SerializeLayers(buf, opts, ip, udp, dnsQuery(1, "example.com")))
+
+	var pktInfo dnsPacketInfo
+	p := newDNSParser(layers.LayerTypeIPv4, &config.Config{CollectDNSStats: true, DNSMonitoringPorts: []uint16{53}})
+	assert.Error(t, p.ParseInto(buf.Bytes(), new(translation), &pktInfo))
+
+	p = newDNSParser(layers.LayerTypeIPv4, &config.Config{CollectDNSStats: true, DNSMonitoringPorts: []uint16{53, 5353}})
+	require.NoError(t, p.ParseInto(buf.Bytes(), new(translation), &pktInfo))
+	assert.Equal(t, query, pktInfo.pktType)
+	assert.Equal(t, uint16(1), pktInfo.transactionID)
+	assert.Equal(t, uint16(40000), pktInfo.key.ClientPort)
+}
@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package dns
+
+import (
+	"github.com/DataDog/sketches-go/ddsketch"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// ResolverLatencyRelativeAccuracy is the relative accuracy of the quantiles of the resolver latency sketches
+	ResolverLatencyRelativeAccuracy = 0.02
+	// resolverLatencyMaxBins bounds the memory used by each sketch, the lowest latencies are collapsed beyond it
+	resolverLatencyMaxBins = 256
+	// maxResolvers bounds the number of servers for which the latencies are kept
+	maxResolvers = 1024
+)
+
+// ResolverLatencies holds the distributions of the latencies, in microseconds, of the responses of a DNS server.
+// The timed out queries are not part of them, they are only counted in Stats.
+type ResolverLatencies struct {
+	Success *ddsketch.DDSketch
+	Failure *ddsketch.DDSketch
+}
+
+// LatenciesByResolver maps the IP of DNS servers to the distributions of the latencies of their responses
+type LatenciesByResolver map[util.Address]*ResolverLatencies
+
+func newLatencySketch() *ddsketch.DDSketch {
+	sketch, err := ddsketch.LogCollapsingLowestDenseDDSketch(ResolverLatencyRelativeAccuracy, resolverLatencyMaxBins)
+	if err != nil {
+		log.Errorf("could not create the DNS latency sketch: %s", err)
+		return nil
+	}
+	return sketch
+}
+
+func (l LatenciesByResolver) get(server util.Address) *ResolverLatencies {
+	if latencies, ok := l[server]; ok {
+		return latencies
+	}
+	if len(l) >= maxResolvers {
+		return nil
+	}
+	latencies := &ResolverLatencies{Success: newLatencySketch(), Failure: newLatencySketch()}
+	if latencies.Success == nil || latencies.Failure == nil {
+		return nil
+	}
+	l[server] = latencies
+	return latencies
+}
+
+// Add records the latency, in microseconds, of a response of the server
+func (l LatenciesByResolver) Add(server util.Address, latency uint64, success bool) {
+	latencies := l.get(server)
+	if latencies == nil {
+		return
+	}
+	sketch := latencies.Failure
+	if success {
+		sketch = latencies.Success
+	}
+	if err := sketch.Add(float64(latency)); err != nil {
+		log.Debugf("could not add the DNS latency of %s: %s", server, err)
+	}
+}
+
+// Merge adds the latencies of other to those of l. The sketches of other are left untouched.
+func (l LatenciesByResolver) Merge(other LatenciesByResolver) {
+	for server, latencies := range other {
+		into := l.get(server)
+		if into == nil {
+			continue
+		}
+		if err := into.Success.MergeWith(latencies.Success); err != nil {
+			log.Debugf("could not merge the DNS latencies of %s: %s", server, err)
+		}
+		if err := into.Failure.MergeWith(latencies.Failure); err != nil {
+			log.Debugf("could not merge the DNS latencies of %s: %s", server, err)
+		}
+	}
+}
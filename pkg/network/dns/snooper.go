@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/google/gopacket"
+	"go.uber.org/atomic"
 
 	"github.com/DataDog/datadog-agent/pkg/network/config"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
@@ -32,6 +33,9 @@ var snooperTelemetry = struct {
 	queries        *telemetry.StatCounterWrapper
 	successes      *telemetry.StatCounterWrapper
 	errors         *telemetry.StatCounterWrapper
+
+	tcpReassembledMsgs *telemetry.StatCounterWrapper
+	tcpDroppedStreams  *telemetry.StatCounterWrapper
 }{
 	telemetry.NewStatCounterWrapper(dnsModuleName, "decoding_errors", []string{}, "Counter measuring the number of decoding errors while processing packets"),
 	telemetry.NewStatCounterWrapper(dnsModuleName, "truncated_pkts", []string{}, "Counter measuring the number of truncated packets while processing"),
@@ -39,6 +43,8 @@ var snooperTelemetry = struct {
 	telemetry.NewStatCounterWrapper(dnsModuleName, "queries", []string{}, "Counter measuring the number of packets that are DNS queries in processed packets"),
 	telemetry.NewStatCounterWrapper(dnsModuleName, "successes", []string{}, "Counter measuring the number of successful DNS responses in processed packets"),
 	telemetry.NewStatCounterWrapper(dnsModuleName, "errors", []string{}, "Counter measuring the number of failed DNS responses in processed packets"),
+	telemetry.NewStatCounterWrapper(dnsModuleName, "tcp_reassembled_msgs", []string{}, "Counter measuring the number of DNS messages reassembled from several TCP segments"),
+	telemetry.NewStatCounterWrapper(dnsModuleName, "tcp_dropped_streams", []string{}, "Counter measuring the number of TCP streams whose DNS messages could not be reassembled"),
 }
 
 var _ ReverseDNS = &socketFilterSnooper{}
@@ -53,6 +59,8 @@ type socketFilterSnooper struct {
 	wg              sync.WaitGroup
 	collectLocalDNS bool
 	once            sync.Once
+	// paused is set while the packets must be dropped instead of being parsed
+	paused *atomic.Bool
 
 	// cache translation object to avoid allocations
 	translation *translation
@@ -99,6 +107,7 @@ func newSocketFilterSnooper(cfg *config.Config, source packetSource) (*socketFil
 		translation:     new(translation),
 		exit:            make(chan struct{}),
 		collectLocalDNS: cfg.CollectLocalDNS,
+		paused:          atomic.NewBool(false),
 	}
 
 	// Start consuming packets
@@ -130,11 +139,32 @@ func (s *socketFilterSnooper) GetDNSStats() StatsByKeyByNameByType {
 	return s.statKeeper.GetAndResetAllStats()
 }
 
+// GetResolverLatencies gets the distributions of the latencies of the DNS servers seen since the last call
+func (s *socketFilterSnooper) GetResolverLatencies() LatenciesByResolver {
+	if s.statKeeper == nil {
+		return nil
+	}
+	return s.statKeeper.GetAndResetResolverLatencies()
+}
+
 // Start starts the snooper (no-op currently)
 func (s *socketFilterSnooper) Start() error {
 	return nil // no-op as this is done in newSocketFilterSnooper above
 }
 
+// Pause stops the parsing of the DNS packets, until Resume is called. The domains resolved in the meantime
+// are missed. The capture of the packets is stopped by the packet source, see dnsMonitor.
+func (s *socketFilterSnooper) Pause() error {
+	s.paused.Store(true)
+	return nil
+}
+
+// Resume restarts the parsing of the DNS packets stopped by Pause
+func (s *socketFilterSnooper) Resume() error {
+	s.paused.Store(false)
+	return nil
+}
+
 // Close terminates the DNS traffic snooper as well as the underlying socket and the attached filter
 func (s *socketFilterSnooper) Close() {
 	s.once.Do(func() {
@@ -155,10 +185,29 @@ func (s *socketFilterSnooper) Close() {
 // The second parameter `ts` is the time when the packet was captured off the wire. This is used for latency calculation
 // and much more reliable than calling time.Now() at the user layer.
 func (s *socketFilterSnooper) processPacket(data []byte, ts time.Time) error {
+	if s.paused.Load() {
+		return nil
+	}
+
 	t := s.getCachedTranslation()
 	pktInfo := dnsPacketInfo{}
+	s.processDNSMessage(s.parser.ParseInto(data, t, &pktInfo), t, &pktInfo, ts)
+
+	// the messages completed by a TCP segment are processed as if they were received with it
+	for {
+		t = s.getCachedTranslation()
+		pktInfo = dnsPacketInfo{}
+		ok, err := s.parser.ParseReassembledInto(t, &pktInfo)
+		if !ok {
+			break
+		}
+		s.processDNSMessage(err, t, &pktInfo, ts)
+	}
+	return nil
+}
 
-	if err := s.parser.ParseInto(data, t, &pktInfo); err != nil {
+func (s *socketFilterSnooper) processDNSMessage(err error, t *translation, pktInfo *dnsPacketInfo, ts time.Time) {
+	if err != nil {
 		switch err {
 		case errSkippedPayload: // no need to count or log cases where the packet is valid but has no relevant content
 		case errTruncated:
@@ -166,11 +215,11 @@ func (s *socketFilterSnooper) processPacket(data []byte, ts time.Time) error {
 		default:
 			snooperTelemetry.decodingErrors.Inc()
 		}
-		return nil
+		return
 	}
 
 	if s.statKeeper != nil && (s.collectLocalDNS || !pktInfo.key.ServerIP.IsLoopback()) {
-		s.statKeeper.ProcessPacketInfo(pktInfo, ts)
+		s.statKeeper.ProcessPacketInfo(*pktInfo, ts)
 	}
 
 	if pktInfo.pktType == successfulResponse {
@@ -181,8 +230,6 @@ func (s *socketFilterSnooper) processPacket(data []byte, ts time.Time) error {
 	} else {
 		snooperTelemetry.queries.Inc()
 	}
-
-	return nil
 }
 
 func (s *socketFilterSnooper) pollPackets() {
@@ -68,6 +68,7 @@ type dnsStatKeeper struct {
 	mux sync.Mutex
 	// map a DNS key to a map of domain strings to a map of query types to a map of  DNS stats
 	stats            StatsByKeyByNameByType
+	latencies        LatenciesByResolver
 	state            map[stateKey]stateValue
 	expirationPeriod time.Duration
 	exit             chan struct{}
@@ -81,6 +82,7 @@ type dnsStatKeeper struct {
 func newDNSStatkeeper(timeout time.Duration, maxStats int64) *dnsStatKeeper {
 	statsKeeper := &dnsStatKeeper{
 		stats:            make(StatsByKeyByNameByType),
+		latencies:        make(LatenciesByResolver),
 		state:            make(map[stateKey]stateValue),
 		expirationPeriod: timeout,
 		exit:             make(chan struct{}),
@@ -134,6 +136,9 @@ func (d *dnsStatKeeper) ProcessPacketInfo(info dnsPacketInfo, ts time.Time) {
 	d.deleteCount++
 
 	latency := microSecs(ts) - start.ts
+	if latency <= uint64(d.expirationPeriod.Microseconds()) {
+		d.latencies.Add(info.key.ServerIP, latency, info.pktType == successfulResponse)
+	}
 
 	allStats, ok := d.stats[info.key]
 	if !ok {
@@ -186,6 +191,15 @@ func (d *dnsStatKeeper) GetAndResetAllStats() StatsByKeyByNameByType {
 	return ret
 }
 
+// GetAndResetResolverLatencies returns the latencies of the DNS servers recorded since the last call
+func (d *dnsStatKeeper) GetAndResetResolverLatencies() LatenciesByResolver {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	ret := d.latencies
+	d.latencies = make(LatenciesByResolver)
+	return ret
+}
+
 func (d *dnsStatKeeper) WaitForDomain(domain string) error {
 
 	tick := time.NewTicker(10 * time.Millisecond)
@@ -131,3 +131,32 @@ func BenchmarkStats(b *testing.B) {
 		})
 	}
 }
+
+func TestResolverLatencies(t *testing.T) {
+	sk := newDNSStatkeeper(DNSTimeoutSecs*time.Second, 10000)
+	key := getSampleDNSKey()
+	then := time.Now()
+	respond := func(id uint16, respType packetType, delta time.Duration) {
+		sk.ProcessPacketInfo(dnsPacketInfo{transactionID: id, pktType: query, key: key, question: ToHostname("abc.com"), queryType: TypeA}, then)
+		sk.ProcessPacketInfo(dnsPacketInfo{transactionID: id, key: key, pktType: respType, queryType: TypeA}, then.Add(delta))
+	}
+	respond(1, successfulResponse, 10*time.Millisecond)
+	respond(2, successfulResponse, 20*time.Millisecond)
+	respond(3, failedResponse, 5*time.Millisecond)
+	// timeouts have no latency
+	respond(4, failedResponse, DNSTimeoutSecs*time.Second+time.Millisecond)
+
+	latencies := sk.GetAndResetResolverLatencies()
+	require.Contains(t, latencies, key.ServerIP)
+	server := latencies[key.ServerIP]
+	assert.Equal(t, 2.0, server.Success.GetCount())
+	maxLatency, err := server.Success.GetMaxValue()
+	require.NoError(t, err)
+	assert.InEpsilon(t, 20000, maxLatency, ResolverLatencyRelativeAccuracy)
+	assert.Equal(t, 1.0, server.Failure.GetCount())
+	maxLatency, err = server.Failure.GetMaxValue()
+	require.NoError(t, err)
+	assert.InEpsilon(t, 5000, maxLatency, ResolverLatencyRelativeAccuracy)
+
+	assert.Empty(t, sk.GetAndResetResolverLatencies())
+}
@@ -38,11 +38,15 @@ func (m *tcpWithDNSSupport) hasSelfContainedDNSPayload() bool {
 	return int(dnsLengthField) == len(payload)-2
 }
 
+// NextLayerType only returns the DNS layer for the segments which contain exactly one message,
+// the others are left to the tcpDNSReassembler.
 func (m *tcpWithDNSSupport) NextLayerType() gopacket.LayerType {
-	// TODO: deal with TCP fragmentation and out-of-order segments
 	if m.hasSelfContainedDNSPayload() {
 		return layers.LayerTypeDNS
 	}
+	if len(m.TCP.LayerPayload()) > 0 {
+		return gopacket.LayerTypePayload
+	}
 	return m.TCP.NextLayerType()
 }
 
@@ -0,0 +1,152 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build (windows && npm) || linux_bpf
+
+package dns
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+const (
+	// streams of which no segment was seen for this long are forgotten
+	tcpDNSStreamTimeout = 10 * time.Second
+	maxTCPDNSStreams    = 1024
+	// segments received ahead of a gap in the stream which are kept until it is filled
+	maxTCPDNSOutOfOrderSegments = 16
+	dnsHeaderSize               = 12
+)
+
+// tcpDNSFlow identifies one direction of a TCP connection
+type tcpDNSFlow struct {
+	src, dst     util.Address
+	sport, dport uint16
+}
+
+// tcpDNSMessage is a DNS message reassembled from TCP segments, without its length prefix
+type tcpDNSMessage struct {
+	flow    tcpDNSFlow
+	payload []byte
+}
+
+type tcpDNSStream struct {
+	nextSeq    uint32
+	buf        []byte
+	outOfOrder map[uint32][]byte
+	lastSeen   time.Time
+}
+
+// tcpDNSReassembler rebuilds the DNS messages which span several TCP segments, such as
+// the large answers retried over TCP after a truncated UDP response, or the queries
+// pipelined in a single segment. A stream is only kept while a message is incomplete.
+// It isn't safe for concurrent use.
+type tcpDNSReassembler struct {
+	streams map[tcpDNSFlow]*tcpDNSStream
+	now     func() time.Time
+}
+
+func newTCPDNSReassembler() *tcpDNSReassembler {
+	return &tcpDNSReassembler{
+		streams: make(map[tcpDNSFlow]*tcpDNSStream),
+		now:     time.Now,
+	}
+}
+
+// inProgress returns whether a message of the flow is incomplete
+func (r *tcpDNSReassembler) inProgress(flow tcpDNSFlow) bool {
+	_, ok := r.streams[flow]
+	return ok
+}
+
+// close forgets the incomplete message of the flow, if any
+func (r *tcpDNSReassembler) close(flow tcpDNSFlow) {
+	delete(r.streams, flow)
+}
+
+// add adds the payload of a segment starting at sequence number seq to the stream of the flow.
+// The first segment of a stream is assumed to start with the length prefix of a message.
+// The messages it completes are appended to msgs.
+func (r *tcpDNSReassembler) add(flow tcpDNSFlow, seq uint32, payload []byte, msgs []tcpDNSMessage) []tcpDNSMessage {
+	if len(payload) == 0 {
+		return msgs
+	}
+
+	now := r.now()
+	s, ok := r.streams[flow]
+	if !ok {
+		if len(r.streams) >= maxTCPDNSStreams {
+			r.expire(now)
+		}
+		if len(r.streams) >= maxTCPDNSStreams {
+			snooperTelemetry.tcpDroppedStreams.Inc()
+			return msgs
+		}
+		s = &tcpDNSStream{nextSeq: seq}
+		r.streams[flow] = s
+	}
+	s.lastSeen = now
+
+	switch diff := int32(seq - s.nextSeq); {
+	case diff > 0:
+		if len(s.outOfOrder) >= maxTCPDNSOutOfOrderSegments {
+			snooperTelemetry.tcpDroppedStreams.Inc()
+			r.close(flow)
+			return msgs
+		}
+		if s.outOfOrder == nil {
+			s.outOfOrder = make(map[uint32][]byte)
+		}
+		s.outOfOrder[seq] = bytes.Clone(payload)
+		return msgs
+	case diff < 0:
+		// retransmission, of which only the part past the data already seen is kept
+		if -int(diff) >= len(payload) {
+			return msgs
+		}
+		payload = payload[-diff:]
+	}
+
+	s.buf = append(s.buf, payload...)
+	s.nextSeq += uint32(len(payload))
+	for next, ok := s.outOfOrder[s.nextSeq]; ok; next, ok = s.outOfOrder[s.nextSeq] {
+		delete(s.outOfOrder, s.nextSeq)
+		s.buf = append(s.buf, next...)
+		s.nextSeq += uint32(len(next))
+	}
+
+	for len(s.buf) >= 2 {
+		size := int(binary.BigEndian.Uint16(s.buf))
+		if size < dnsHeaderSize {
+			// the stream didn't start at a message boundary
+			snooperTelemetry.tcpDroppedStreams.Inc()
+			r.close(flow)
+			return msgs
+		}
+		if len(s.buf) < 2+size {
+			break
+		}
+		msgs = append(msgs, tcpDNSMessage{flow: flow, payload: s.buf[2 : 2+size : 2+size]})
+		s.buf = s.buf[2+size:]
+		snooperTelemetry.tcpReassembledMsgs.Inc()
+	}
+
+	if len(s.buf) == 0 && len(s.outOfOrder) == 0 {
+		r.close(flow)
+	}
+	return msgs
+}
+
+func (r *tcpDNSReassembler) expire(now time.Time) {
+	for flow, s := range r.streams {
+		if now.Sub(s.lastSeen) > tcpDNSStreamTimeout {
+			delete(r.streams, flow)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package dns
+
+import (
+	"encoding/binary"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func tcpDNSPayload(t *testing.T, msgs ...*layers.DNS) []byte {
+	var payload []byte
+	for _, msg := range msgs {
+		buf := gopacket.NewSerializeBuffer()
+		require.NoError(t, msg.SerializeTo(buf, gopacket.SerializeOptions{FixLengths: true}))
+		payload = binary.BigEndian.AppendUint16(payload, uint16(len(buf.Bytes())))
+		payload = append(payload, buf.Bytes()...)
+	}
+	return payload
+}
+
+func dnsQuery(id uint16, domain string) *layers.DNS {
+	return &layers.DNS{
+		ID:        id,
+		RD:        true,
+		Questions: []layers.DNSQuestion{{Name: []byte(domain), Type: layers.DNSTypeA, Class: layers.DNSClassIN}},
+	}
+}
+
+func TestTCPDNSReassembler(t *testing.T) {
+	flow := tcpDNSFlow{src: util.AddressFromString("10.0.0.1"), dst: util.AddressFromString("10.0.0.2"), sport: 40000, dport: 53}
+	first := tcpDNSPayload(t, dnsQuery(1, "first.example.com"))
+	second := tcpDNSPayload(t, dnsQuery(2, "second.example.com"))
+
+	t.Run("split", func(t *testing.T) {
+		r := newTCPDNSReassembler()
+		msgs := r.add(flow, 100, first[:10], nil)
+		assert.Empty(t, msgs)
+		assert.True(t, r.inProgress(flow))
+
+		// retransmission overlapping the first segment
+		msgs = r.add(flow, 100, first[:20], nil)
+		assert.Empty(t, msgs)
+		msgs = r.add(flow, 120, first[20:], nil)
+		require.Len(t, msgs, 1)
+		assert.Equal(t, first[2:], msgs[0].payload)
+		assert.Equal(t, flow, msgs[0].flow)
+		assert.False(t, r.inProgress(flow))
+	})
+
+	t.Run("out of order", func(t *testing.T) {
+		r := newTCPDNSReassembler()
+		stream := append(append([]byte{}, first...), second...)
+		msgs := r.add(flow, 0, stream[:5], nil)
+		msgs = r.add(flow, 30, stream[30:], msgs)
+		assert.Empty(t, msgs)
+		msgs = r.add(flow, 5, stream[5:30], msgs)
+		require.Len(t, msgs, 2)
+		assert.Equal(t, first[2:], msgs[0].payload)
+		assert.Equal(t, second[2:], msgs[1].payload)
+		assert.False(t, r.inProgress(flow))
+	})
+
+	t.Run("not at a message boundary", func(t *testing.T) {
+		r := newTCPDNSReassembler()
+		msgs := r.add(flow, 0, []byte{0, 1, 0xff}, nil)
+		assert.Empty(t, msgs)
+		assert.False(t, r.inProgress(flow))
+	})
+
+	t.Run("close", func(t *testing.T) {
+		r := newTCPDNSReassembler()
+		r.add(flow, 0, first[:10], nil)
+		r.close(flow)
+		assert.False(t, r.inProgress(flow))
+	})
+}
+
+func TestParseReassembledDNS(t *testing.T) {
+	p := newDNSParser(layers.LayerTypeIPv4, &config.Config{CollectDNSStats: true, CollectDNSDomains: true, EnableDNSTCPReassembly: true})
+	payload := tcpDNSPayload(t, dnsQuery(1, "first.example.com"), dnsQuery(2, "second.example.com"))
+
+	segment := func(seq uint32, payload []byte) []byte {
+		ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolTCP, SrcIP: net.ParseIP("10.0.0.1"), DstIP: net.ParseIP("10.0.0.2")}
+		tcp := &layers.TCP{SrcPort: 40000, DstPort: 53, Seq: seq, ACK: true, PSH: true}
+		require.NoError(t, tcp.SetNetworkLayerForChecksum(ip))
+		buf := gopacket.NewSerializeBuffer()
+		require.NoError(t, gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}, ip, tcp, gopacket.Payload(payload)))
+		return buf.Bytes()
+	}
+
+	// the first segment has both queries but the last byte
+	var pktInfo dnsPacketInfo
+	assert.Equal(t, errSkippedPayload, p.ParseInto(segment(1, payload[:len(payload)-1]), new(translation), &pktInfo))
+	ok, _ := p.ParseReassembledInto(new(translation), &pktInfo)
+	require.True(t, ok)
+	assert.Equal(t, query, pktInfo.pktType)
+	assert.Equal(t, uint16(1), pktInfo.transactionID)
+	assert.Equal(t, util.AddressFromString("10.0.0.1"), pktInfo.key.ClientIP)
+	assert.Equal(t, util.AddressFromString("10.0.0.2"), pktInfo.key.ServerIP)
+	assert.Equal(t, uint16(40000), pktInfo.key.ClientPort)
+	assert.Equal(t, uint8(syscall.IPPROTO_TCP), pktInfo.key.Protocol)
+	ok, _ = p.ParseReassembledInto(new(translation), &pktInfo)
+	assert.False(t, ok)
+
+	pktInfo = dnsPacketInfo{}
+	assert.Equal(t, errSkippedPayload, p.ParseInto(segment(uint32(len(payload)), payload[len(payload)-1:]), new(translation), &pktInfo))
+	ok, err := p.ParseReassembledInto(new(translation), &pktInfo)
+	require.True(t, ok)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(2), pktInfo.transactionID)
+	assert.Equal(t, ToHostname("second.example.com"), pktInfo.question)
+}
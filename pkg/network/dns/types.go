@@ -68,6 +68,8 @@ type StatsByKeyByNameByType map[Key]map[Hostname]map[QueryType]Stats
 type ReverseDNS interface {
 	Resolve(map[util.Address]struct{}) map[util.Address][]Hostname
 	GetDNSStats() StatsByKeyByNameByType
+	// GetResolverLatencies gets the distributions of the latencies of the DNS servers seen since the last call
+	GetResolverLatencies() LatenciesByResolver
 
 	// WaitForDomain is used in tests to ensure a domain has been
 	// seen by the ReverseDNS.
@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build windows || linux_bpf
+
+package dns
+
+import (
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+var _ gopacket.DecodingLayer = &udpWithDNSSupport{}
+
+// udpWithDNSSupport decodes the payload of the UDP datagrams from or to the DNS monitoring
+// ports as DNS, gopacket only does it for port 53
+type udpWithDNSSupport struct {
+	layers.UDP
+	ports map[layers.UDPPort]struct{}
+}
+
+func newUDPWithDNSSupport(ports []uint16) *udpWithDNSSupport {
+	u := &udpWithDNSSupport{ports: make(map[layers.UDPPort]struct{}, len(ports))}
+	for _, port := range ports {
+		u.ports[layers.UDPPort(port)] = struct{}{}
+	}
+	return u
+}
+
+func (u *udpWithDNSSupport) NextLayerType() gopacket.LayerType {
+	if _, ok := u.ports[u.SrcPort]; ok {
+		return layers.LayerTypeDNS
+	}
+	if _, ok := u.ports[u.DstPort]; ok {
+		return layers.LayerTypeDNS
+	}
+	return u.UDP.NextLayerType()
+}
@@ -24,6 +24,13 @@ BPF_PERCPU_HASH_MAP(udp_send_skb_args, u64, conn_tuple_t, 1024)
         return 0;                                           \
     }
 
+// Outside of Fargate, the tracer replaces the kprobes of the host and must see the events of all the tasks
+static __always_inline bool is_task_filter_enabled() {
+    __u64 val = 0;
+    LOAD_CONSTANT("task_filter_enabled", val);
+    return val > 0;
+}
+
 static __always_inline __u32 systemprobe_dev() {
     __u64 val = 0;
     LOAD_CONSTANT("systemprobe_device", val);
@@ -37,6 +44,10 @@ static __always_inline __u32 systemprobe_ino() {
 }
 
 static __always_inline bool event_in_task(char *prog_name) {
+    if (!is_task_filter_enabled()) {
+        return true;
+    }
+
     __u32 dev = systemprobe_dev();
     __u32 ino = systemprobe_ino();
     struct bpf_pidns_info ns = {};
@@ -504,9 +515,35 @@ int BPF_PROG(inet_csk_accept_exit, struct sock *_sk, int flags, int *err, bool k
     return 0;
 }
 
+static __always_inline int handle_inet_csk_listen_start(struct sock *sk, int ret) {
+    if (ret != 0) {
+        return 0;
+    }
+
+    // the port is only known at this point if the socket
+    // was not explicitly bound before calling listen()
+    add_listening_socket(sk, bpf_get_current_pid_tgid(), CONN_TYPE_TCP);
+    return 0;
+}
+
+SEC("fexit/inet_csk_listen_start")
+int BPF_PROG(inet_csk_listen_start_exit, struct sock *sk, int ret) {
+    RETURN_IF_NOT_IN_SYSPROBE_TASK("fexit/inet_csk_listen_start");
+    return handle_inet_csk_listen_start(sk, ret);
+}
+
+// the backlog argument was removed in 5.19
+SEC("fexit/inet_csk_listen_start")
+int BPF_PROG(inet_csk_listen_start_exit_pre_5_19_0, struct sock *sk, int backlog, int ret) {
+    RETURN_IF_NOT_IN_SYSPROBE_TASK("fexit/inet_csk_listen_start");
+    return handle_inet_csk_listen_start(sk, ret);
+}
+
 SEC("fentry/inet_csk_listen_stop")
 int BPF_PROG(inet_csk_listen_stop_enter, struct sock *sk) {
     RETURN_IF_NOT_IN_SYSPROBE_TASK("fentry/inet_csk_listen_stop");
+    remove_listening_socket(sk);
+
     __u16 lport = read_sport(sk);
     if (lport == 0) {
         log_debug("ERR(inet_csk_listen_stop): lport is 0 ");
@@ -522,6 +559,8 @@ int BPF_PROG(inet_csk_listen_stop_enter, struct sock *sk) {
 }
 
 static __always_inline int handle_udp_destroy_sock(void *ctx, struct sock *sk) {
+    remove_listening_socket(sk);
+
     conn_tuple_t tup = {};
     u64 pid_tgid = bpf_get_current_pid_tgid();
     int valid_tuple = read_conn_tuple(&tup, sk, pid_tgid, CONN_TYPE_UDP);
@@ -18,6 +18,10 @@ typedef enum
     // Connection family
     CONN_V4 = 0 << 1,
     CONN_V6 = 1 << 1,
+
+    // SCTP connections are flagged separately so the
+    // TCP/UDP bit keeps its meaning for all the other consumers
+    CONN_TYPE_SCTP = 1 << 2,
 } metadata_mask_t;
 
 typedef struct {
@@ -33,6 +37,7 @@ typedef struct {
     // Metadata description:
     // First bit indicates if the connection is TCP (1) or UDP (0)
     // Second bit indicates if the connection is V6 (1) or V4 (0)
+    // Third bit indicates if the connection is SCTP
     __u32 metadata; // This is that big because it seems that we atleast need a 32-bit aligned struct
 } conn_tuple_t;
 
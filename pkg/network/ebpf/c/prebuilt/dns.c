@@ -2,10 +2,18 @@
 
 #include "bpf_helpers.h"
 #include "bpf_builtins.h"
+#include "map-defs.h"
 
 #include "offsets.h"
 #include "ip.h"
 
+// The ports on which DNS servers listen, filled from the configuration. The values are unused.
+BPF_HASH_MAP(dns_ports, __u16, __u8, 16)
+
+static __always_inline bool is_dns_port(__u16 port) {
+    return bpf_map_lookup_elem(&dns_ports, &port) != NULL;
+}
+
 // This function is meant to be used as a BPF_PROG_TYPE_SOCKET_FILTER.
 // When attached to a RAW_SOCKET, this code filters out everything but DNS traffic.
 // All structs referenced here are kernel independent as they simply map protocol headers (Ethernet, IP and UDP).
@@ -17,7 +25,7 @@ int socket__dns_filter(struct __sk_buff* skb) {
     if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
         return 0;
     }
-    if (tup.sport != 53 && (!dns_stats_enabled() || tup.dport != 53)) {
+    if (!is_dns_port(tup.sport) && (!dns_stats_enabled() || !is_dns_port(tup.dport))) {
         return 0;
     }
 
@@ -0,0 +1,12 @@
+#ifndef __AMQP_MAPS_H
+#define __AMQP_MAPS_H
+
+#include "bpf_helpers.h"
+#include "map-defs.h"
+
+#include "protocols/amqp/types.h"
+
+// Acts as a scratch buffer for AMQP events, for preparing events before they are sent to userspace.
+BPF_PERCPU_ARRAY_MAP(amqp_scratch_buffer, amqp_event_t, 1)
+
+#endif
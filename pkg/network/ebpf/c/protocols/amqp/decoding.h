@@ -0,0 +1,136 @@
+#ifndef __AMQP_DECODING_H
+#define __AMQP_DECODING_H
+
+#include "bpf_builtins.h"
+#include "bpf_endian.h"
+#include "bpf_telemetry.h"
+
+#include "protocols/sockfd.h"
+
+#include "protocols/amqp/decoding-maps.h"
+#include "protocols/amqp/defs.h"
+#include "protocols/amqp/types.h"
+#include "protocols/amqp/usm-events.h"
+#include "protocols/helpers/pktbuf.h"
+#include "protocols/read_into_buffer.h"
+
+PKTBUF_READ_INTO_BUFFER(amqp_arguments, AMQP_BUFFER_SIZE, BLK_SIZE)
+
+// Main processing logic for the AMQP protocol. The segments are made of frames, which are decoded up to
+// AMQP_MAX_FRAMES_PER_SEGMENT. The basic.publish and basic.deliver methods are followed by the content header of their
+// message, holding the size of its body, so they are enqueued once it is read. The acknowledgements are enqueued right
+// away. The segments starting in the middle of a frame, such as the continuations of large bodies, are ignored.
+// Format - https://www.rabbitmq.com/resources/specs/amqp0-9-1.pdf, section 4.2.3.
+static __always_inline void amqp_entrypoint(pktbuf_t pkt, conn_tuple_t *tup) {
+    const u32 zero = 0;
+    amqp_event_t *event = bpf_map_lookup_elem(&amqp_scratch_buffer, &zero);
+    if (!event) {
+        return;
+    }
+    bpf_memcpy(&event->tuple, tup, sizeof(conn_tuple_t));
+
+    bool pending_content = false;
+    u32 offset = pktbuf_data_offset(pkt);
+    const u32 data_end = pktbuf_data_end(pkt);
+    amqp_frame_t frame;
+
+#pragma unroll(AMQP_MAX_FRAMES_PER_SEGMENT)
+    for (int i = 0; i < AMQP_MAX_FRAMES_PER_SEGMENT; i++) {
+        if (offset + sizeof(amqp_frame_t) > data_end) {
+            break;
+        }
+        pktbuf_load_bytes(pkt, offset, &frame, sizeof(frame));
+        const u32 size = bpf_ntohl(frame.size);
+
+        if (frame.type == AMQP_FRAME_METHOD_TYPE) {
+            // The previous message had no content header in the segment.
+            if (pending_content) {
+                amqp_batch_enqueue(event);
+                pending_content = false;
+            }
+            if (bpf_ntohs(frame.header.class_id) != AMQP_BASIC_CLASS) {
+                goto next;
+            }
+            const __u16 method_id = bpf_ntohs(frame.header.method_id);
+            switch (method_id) {
+            case AMQP_METHOD_PUBLISH:
+            case AMQP_METHOD_DELIVER:
+                bpf_memset(&event->tx, 0, sizeof(amqp_transaction_t));
+                event->tx.method_id = method_id;
+                pktbuf_read_into_buffer_amqp_arguments(event->tx.arguments, pkt, offset + sizeof(amqp_frame_t));
+                pending_content = true;
+                break;
+            case AMQP_METHOD_ACK:
+            case AMQP_METHOD_NACK:
+            case AMQP_METHOD_REJECT:
+                bpf_memset(&event->tx, 0, sizeof(amqp_transaction_t));
+                event->tx.method_id = method_id;
+                amqp_batch_enqueue(event);
+                break;
+            }
+        } else if (frame.type == AMQP_FRAME_HEADER_TYPE && pending_content) {
+            // The body size follows the class id and the weight of the content header.
+            if (offset + sizeof(amqp_frame_t) + sizeof(__u64) <= data_end) {
+                __u64 body_size = 0;
+                pktbuf_load_bytes(pkt, offset + sizeof(amqp_frame_t), &body_size, sizeof(body_size));
+                event->tx.body_size = bpf_ntohll(body_size);
+            }
+            amqp_batch_enqueue(event);
+            pending_content = false;
+        }
+
+next:
+        // The frame ends after the segment.
+        if (size >= data_end - offset) {
+            break;
+        }
+        offset += AMQP_FRAME_HEADER_SIZE + size + AMQP_FRAME_END_SIZE;
+    }
+
+    if (pending_content) {
+        amqp_batch_enqueue(event);
+    }
+}
+
+// Entrypoint to process plaintext AMQP traffic. Pulls the connection tuple and the packet buffer from the map and
+// calls the main processing function. No state is kept per connection, so the TCP terminations are ignored.
+SEC("socket/amqp_process")
+int socket__amqp_process(struct __sk_buff* skb) {
+    skb_info_t skb_info = {};
+    conn_tuple_t conn_tuple = {};
+
+    if (!fetch_dispatching_arguments(&conn_tuple, &skb_info)) {
+        return 0;
+    }
+
+    if (is_tcp_termination(&skb_info)) {
+        return 0;
+    }
+
+    normalize_tuple(&conn_tuple);
+
+    pktbuf_t pkt = pktbuf_from_skb(skb, &skb_info);
+    amqp_entrypoint(pkt, &conn_tuple);
+    return 0;
+}
+
+// Entrypoint to process TLS AMQP traffic. Pulls the connection tuple and the packet buffer from the map and calls
+// the main processing function.
+SEC("uprobe/amqp_tls_process")
+int uprobe__amqp_tls_process(struct pt_regs *ctx) {
+    const __u32 zero = 0;
+
+    tls_dispatcher_arguments_t *args = bpf_map_lookup_elem(&tls_dispatcher_arguments, &zero);
+    if (args == NULL) {
+        return 0;
+    }
+
+    // Copying the tuple to the stack to handle verifier issues on kernel 4.14.
+    conn_tuple_t tup = args->tup;
+
+    pktbuf_t pkt = pktbuf_from_tls(args);
+    amqp_entrypoint(pkt, &tup);
+    return 0;
+}
+
+#endif
@@ -20,7 +20,18 @@
 #define AMQP_METHOD_CONSUME 20
 #define AMQP_METHOD_PUBLISH 40
 #define AMQP_METHOD_DELIVER 60
+#define AMQP_METHOD_ACK 80
+#define AMQP_METHOD_REJECT 90
+#define AMQP_METHOD_NACK 120
 #define AMQP_FRAME_METHOD_TYPE 1
+#define AMQP_FRAME_HEADER_TYPE 2
+
+// The size of the header of the frames: their type, channel and payload size.
+#define AMQP_FRAME_HEADER_SIZE 7
+// The size of the octet ending the frames.
+#define AMQP_FRAME_END_SIZE 1
+// The maximum number of frames of a segment we decode.
+#define AMQP_MAX_FRAMES_PER_SEGMENT 8
 
 #define AMQP_MIN_FRAME_LENGTH 8
 #define AMQP_MIN_PAYLOAD_LENGTH 11
@@ -30,4 +41,13 @@ typedef struct {
     __u16 method_id;
 } amqp_header;
 
+// The header of a frame followed by the beginning of its payload: the class and method ids of the method frames, or
+// the class id and weight of the content header frames.
+typedef struct {
+    __u8 type;
+    __u16 channel;
+    __u32 size;
+    amqp_header header;
+} __attribute__((packed)) amqp_frame_t;
+
 #endif
@@ -0,0 +1,29 @@
+#ifndef __AMQP_TYPES_H
+#define __AMQP_TYPES_H
+
+#include "conn_tuple.h"
+
+// Controls the number of AMQP messages read from userspace at a time.
+#define AMQP_BATCH_SIZE 20
+
+// Maximum length of the arguments of the methods to send to userspace. They hold the names of the exchange and of
+// the routing key of the messages.
+#define AMQP_BUFFER_SIZE 64
+
+// AMQP basic method information we send to userspace: a published or delivered message, or an acknowledgement.
+typedef struct {
+    // The beginning of the arguments of basic.publish and basic.deliver. Stored up to AMQP_BUFFER_SIZE bytes.
+    char arguments[AMQP_BUFFER_SIZE];
+    // The size of the body of the message, read from its content header.
+    __u64 body_size;
+    // The id of the method of the basic class.
+    __u16 method_id;
+} amqp_transaction_t;
+
+// The struct we send to userspace, containing the connection tuple and the method information.
+typedef struct {
+    conn_tuple_t tuple;
+    amqp_transaction_t tx;
+} amqp_event_t;
+
+#endif
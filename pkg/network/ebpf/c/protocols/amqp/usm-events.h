@@ -0,0 +1,9 @@
+#ifndef __AMQP_USM_EVENTS_H
+#define __AMQP_USM_EVENTS_H
+
+#include "protocols/events.h"
+#include "protocols/amqp/types.h"
+
+USM_EVENTS_INIT(amqp, amqp_event_t, AMQP_BATCH_SIZE);
+
+#endif
@@ -63,6 +63,9 @@ typedef enum {
     PROTOCOL_AMQP,
     PROTOCOL_REDIS,
     PROTOCOL_MYSQL,
+    PROTOCOL_SSH,
+    PROTOCOL_DNS,
+    PROTOCOL_WEBSOCKET,
     __LAYER_APPLICATION_MAX = LAYER_APPLICATION_MAX,
 
     __LAYER_ENCRYPTION_MIN = LAYER_ENCRYPTION_BIT,
@@ -154,6 +157,10 @@ typedef enum {
     PROG_GRPC,
     PROG_POSTGRES,
     PROG_POSTGRES_PROCESS_PARSE_MESSAGE,
+    PROG_MYSQL,
+    PROG_REDIS,
+    PROG_MONGO,
+    PROG_AMQP,
     // Add before this value.
     PROG_MAX,
 } protocol_prog_t;
@@ -177,6 +184,13 @@ typedef enum {
     TLS_POSTGRES,
     TLS_PROG_POSTGRES_PROCESS_PARSE_MESSAGE,
     TLS_POSTGRES_TERMINATION,
+    TLS_MYSQL,
+    TLS_MYSQL_TERMINATION,
+    TLS_REDIS,
+    TLS_REDIS_TERMINATION,
+    TLS_MONGO,
+    TLS_MONGO_TERMINATION,
+    TLS_AMQP,
     TLS_PROG_MAX,
 } tls_prog_t;
 
@@ -7,6 +7,8 @@
 
 #include "protocols/classification/defs.h"
 #include "protocols/classification/maps.h"
+#include "protocols/amqp/helpers.h"
+#include "protocols/amqp/usm-events.h"
 #include "protocols/classification/structs.h"
 #include "protocols/classification/dispatcher-maps.h"
 #include "protocols/http/classification-helpers.h"
@@ -15,12 +17,21 @@
 #include "protocols/http2/usm-events.h"
 #include "protocols/kafka/kafka-classification.h"
 #include "protocols/kafka/usm-events.h"
+#include "protocols/mongo/helpers.h"
+#include "protocols/mongo/usm-events.h"
+#include "protocols/mysql/helpers.h"
+#include "protocols/mysql/usm-events.h"
 #include "protocols/postgres/helpers.h"
 #include "protocols/postgres/usm-events.h"
+#include "protocols/redis/helpers.h"
+#include "protocols/redis/usm-events.h"
 
 __maybe_unused static __always_inline protocol_prog_t protocol_to_program(protocol_t proto) {
     switch(proto) {
     case PROTOCOL_HTTP:
+    // The upgrade response may be read by the HTTP program after the tracer reclassified the connection, and
+    // the frames following it are ignored as they don't start an HTTP message.
+    case PROTOCOL_WEBSOCKET:
         return PROG_HTTP;
     case PROTOCOL_HTTP2:
         return PROG_HTTP2_HANDLE_FIRST_FRAME;
@@ -28,6 +39,14 @@ __maybe_unused static __always_inline protocol_prog_t protocol_to_program(protoc
         return PROG_KAFKA;
     case PROTOCOL_POSTGRES:
         return PROG_POSTGRES;
+    case PROTOCOL_MYSQL:
+        return PROG_MYSQL;
+    case PROTOCOL_REDIS:
+        return PROG_REDIS;
+    case PROTOCOL_MONGO:
+        return PROG_MONGO;
+    case PROTOCOL_AMQP:
+        return PROG_AMQP;
     default:
         if (proto != PROTOCOL_UNKNOWN) {
             log_debug("protocol doesn't have a matching program: %d", proto);
@@ -77,6 +96,14 @@ static __always_inline void classify_protocol_for_dispatcher(protocol_t *protoco
         *protocol = PROTOCOL_HTTP2;
     } else if (is_postgres_monitoring_enabled() && is_postgres(buf, size)) {
         *protocol = PROTOCOL_POSTGRES;
+    } else if (is_mysql_monitoring_enabled() && is_mysql(tup, buf, size)) {
+        *protocol = PROTOCOL_MYSQL;
+    } else if (is_redis_monitoring_enabled() && is_redis(buf, size)) {
+        *protocol = PROTOCOL_REDIS;
+    } else if (is_mongo_monitoring_enabled() && is_mongo(tup, buf, size)) {
+        *protocol = PROTOCOL_MONGO;
+    } else if (is_amqp_monitoring_enabled() && is_amqp(buf, size)) {
+        *protocol = PROTOCOL_AMQP;
     } else {
         *protocol = PROTOCOL_UNKNOWN;
     }
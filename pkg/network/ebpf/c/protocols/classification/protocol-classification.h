@@ -7,6 +7,7 @@
 #include "port_range.h"
 
 #include "protocols/amqp/helpers.h"
+#include "protocols/dns/helpers.h"
 #include "protocols/classification/common.h"
 #include "protocols/classification/defs.h"
 #include "protocols/classification/maps.h"
@@ -23,7 +24,11 @@
 #include "protocols/mysql/helpers.h"
 #include "protocols/redis/helpers.h"
 #include "protocols/postgres/helpers.h"
+#include "protocols/ssh/helpers.h"
 #include "protocols/tls/tls.h"
+#include "protocols/tls/tls-handshake.h"
+#include "protocols/websocket/helpers.h"
+#include "protocols/websocket/session.h"
 
 // Some considerations about multiple protocol classification:
 //
@@ -88,7 +93,7 @@ static __always_inline void classify_grpc(usm_context_t *usm_ctx, protocol_stack
     mark_as_fully_classified(protocol_stack);
 }
 
-// Checks if a given buffer is http, http2, gRPC.
+// Checks if a given buffer is http, http2, gRPC or ssh.
 static __always_inline protocol_t classify_applayer_protocols(const char *buf, __u32 size) {
     if (is_http(buf, size)) {
         return PROTOCOL_HTTP;
@@ -96,11 +101,14 @@ static __always_inline protocol_t classify_applayer_protocols(const char *buf, _
     if (is_http2(buf, size)) {
         return PROTOCOL_HTTP2;
     }
+    if (is_ssh(buf, size)) {
+        return PROTOCOL_SSH;
+    }
 
     return PROTOCOL_UNKNOWN;
 }
 
-// Checks if a given buffer is redis, mongo, postgres, or mysql.
+// Checks if a given buffer is redis, mongo, postgres, mysql or dns.
 static __always_inline protocol_t classify_db_protocols(conn_tuple_t *tup, const char *buf, __u32 size) {
     if (is_redis(buf, size)) {
         return PROTOCOL_REDIS;
@@ -118,6 +126,12 @@ static __always_inline protocol_t classify_db_protocols(conn_tuple_t *tup, const
         return PROTOCOL_MYSQL;
     }
 
+    // DNS is only recognized by its header, so it is checked last to not
+    // shadow the protocols above.
+    if (is_dns(buf, size)) {
+        return PROTOCOL_DNS;
+    }
+
     return PROTOCOL_UNKNOWN;
 }
 
@@ -158,6 +172,27 @@ __maybe_unused static __always_inline void protocol_classifier_entrypoint(struct
         return;
     }
 
+    const char *buffer = &(usm_ctx->buffer.data[0]);
+    // The hellos are read before bailing out on the connections known to be encrypted, since the
+    // ServerHello follows the ClientHello which classified the connection as TLS.
+    if (is_tls_handshake_info_enabled() && is_tls_hello(buffer, usm_ctx->buffer.size)) {
+        update_tls_info(skb, &skb_info, &usm_ctx->tuple);
+    }
+
+    // When the sessions are tracked, the connections upgraded by an HTTP 101 response are reclassified as
+    // WebSocket, which overrides HTTP, and their following segments are frames instead of HTTP messages.
+    protocol_t app_layer_proto = get_protocol_from_stack(protocol_stack, LAYER_APPLICATION);
+    if (app_layer_proto == PROTOCOL_WEBSOCKET) {
+        update_websocket_session(skb, &skb_info, &usm_ctx->tuple);
+        return;
+    }
+    if (is_websocket_tracking_enabled() && (app_layer_proto == PROTOCOL_UNKNOWN || app_layer_proto == PROTOCOL_HTTP) && is_websocket_upgrade(buffer, usm_ctx->buffer.size)) {
+        set_protocol(protocol_stack, PROTOCOL_WEBSOCKET);
+        mark_as_fully_classified(protocol_stack);
+        start_websocket_session(&usm_ctx->tuple);
+        return;
+    }
+
     if (is_fully_classified(protocol_stack) || is_protocol_layer_known(protocol_stack, LAYER_ENCRYPTION)) {
         return;
     }
@@ -165,7 +200,6 @@ __maybe_unused static __always_inline void protocol_classifier_entrypoint(struct
     // Load information that will be later on used to route tail-calls
     init_routing_cache(usm_ctx, protocol_stack);
 
-    const char *buffer = &(usm_ctx->buffer.data[0]);
     // TLS classification
     if (is_tls(buffer, usm_ctx->buffer.size, skb_info.data_end)) {
         update_protocol_information(usm_ctx, protocol_stack, PROTOCOL_TLS);
@@ -175,7 +209,6 @@ __maybe_unused static __always_inline void protocol_classifier_entrypoint(struct
     }
 
     // If application-layer is known we don't bother to check for HTTP protocols and skip to the next layers
-    protocol_t app_layer_proto = get_protocol_from_stack(protocol_stack, LAYER_APPLICATION);
     if (app_layer_proto != PROTOCOL_UNKNOWN && app_layer_proto != PROTOCOL_HTTP2) {
         goto next_program;
     }
@@ -0,0 +1,27 @@
+#ifndef __DNS_DEFS_H
+#define __DNS_DEFS_H
+
+// Over TCP, DNS messages are prefixed with their length on two bytes (RFC 1035, section 4.2.2).
+#define DNS_TCP_LENGTH_PREFIX_SIZE 2
+#define DNS_HEADER_SIZE 12
+// A question holds at least the root name (1 byte), a type and a class (2 bytes each).
+#define DNS_MIN_MESSAGE_SIZE (DNS_HEADER_SIZE + 5)
+
+#define DNS_FLAG_QR     0x8000
+#define DNS_FLAG_TC     0x0200
+#define DNS_FLAG_Z      0x0040
+#define DNS_OPCODE_MASK 0x7800
+#define DNS_RCODE_MASK  0x000f
+
+// The header of a DNS message, preceded by the TCP length prefix.
+struct dns_tcp_header {
+    __u16 length;
+    __u16 id;
+    __u16 flags;
+    __u16 qdcount;
+    __u16 ancount;
+    __u16 nscount;
+    __u16 arcount;
+} __attribute__((packed));
+
+#endif
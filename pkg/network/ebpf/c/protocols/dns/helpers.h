@@ -0,0 +1,36 @@
+#ifndef __DNS_HELPERS_H
+#define __DNS_HELPERS_H
+
+#include "bpf_endian.h"
+
+#include "protocols/classification/common.h"
+#include "protocols/dns/defs.h"
+
+// Checks if the buffer is a standard DNS query or response sent over TCP.
+// Only the header can be inspected, so we restrict ourselves to messages
+// with a single question, which is what resolvers send in practice.
+static __always_inline bool is_dns(const char *buf, __u32 buf_size) {
+    CHECK_PRELIMINARY_BUFFER_CONDITIONS(buf, buf_size, sizeof(struct dns_tcp_header));
+
+    struct dns_tcp_header *hdr = (struct dns_tcp_header *)buf;
+    if (bpf_ntohs(hdr->length) < DNS_MIN_MESSAGE_SIZE) {
+        return false;
+    }
+
+    __u16 flags = bpf_ntohs(hdr->flags);
+    // Standard queries only, and truncation makes no sense over TCP.
+    if (flags & (DNS_OPCODE_MASK | DNS_FLAG_TC | DNS_FLAG_Z)) {
+        return false;
+    }
+    if (bpf_ntohs(hdr->qdcount) != 1) {
+        return false;
+    }
+    if (flags & DNS_FLAG_QR) {
+        return true;
+    }
+
+    // A query carries no answer, no authority and at most the EDNS record.
+    return (flags & DNS_RCODE_MASK) == 0 && hdr->ancount == 0 && hdr->nscount == 0 && bpf_ntohs(hdr->arcount) <= 1;
+}
+
+#endif
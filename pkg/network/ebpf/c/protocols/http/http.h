@@ -7,11 +7,13 @@
 #include "protocols/sockfd.h"
 
 #include "protocols/classification/common.h"
+#include "protocols/classification/shared-tracer-maps.h"
 
 #include "protocols/http/types.h"
 #include "protocols/http/maps.h"
 #include "protocols/http/usm-events.h"
 #include "protocols/tls/https.h"
+#include "protocols/websocket/helpers.h"
 
 static __always_inline int http_responding(http_transaction_t *http) {
     return (http != NULL && http->response_status_code != 0);
@@ -205,6 +207,17 @@ static __always_inline void http_process(http_event_t *event, skb_info_t *skb_in
         http->response_last_seen = bpf_ktime_get_ns();
     }
 
+    if (http->response_status_code == HTTP_SWITCHING_PROTOCOLS) {
+        // The connection was upgraded, most likely to WebSocket, and no other HTTP message follows: the
+        // transaction is flushed right away so that the session isn't accounted as its latency.
+        http_batch_enqueue_wrapper(tuple, http);
+        bpf_map_delete_elem(&http_in_flight, tuple);
+        if (!is_uprobe_context(skb_info) && is_websocket_tracking_enabled()) {
+            update_protocol_stack(tuple, PROTOCOL_WEBSOCKET);
+        }
+        return;
+    }
+
     if (http->tcp_seq == HTTP_TERMINATING) {
         http_batch_enqueue_wrapper(tuple, http);
         // Check a second time to minimize the chance of accidentally deleting a
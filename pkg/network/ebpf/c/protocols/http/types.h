@@ -12,6 +12,9 @@
 // _________^
 #define HTTP_STATUS_OFFSET 9
 
+// Status code of the responses upgrading the connection to another protocol, such as WebSocket
+#define HTTP_SWITCHING_PROTOCOLS 101
+
 // Pseudo TCP sequence number representing a segment with a FIN or RST flags set
 // For more information see `http_seen_before`
 #define HTTP_TERMINATING 0xFFFFFFFF
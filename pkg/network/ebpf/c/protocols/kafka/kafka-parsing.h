@@ -150,6 +150,9 @@ static __always_inline void kafka_batch_enqueue_wrapper(kafka_info_t *kafka, con
     if (transaction != &event->transaction) {
         bpf_memcpy(&event->transaction, transaction, sizeof(kafka_transaction_t));
     }
+    if (event->transaction.request_api_key == KAFKA_FETCH) {
+        event->transaction.response_last_seen = bpf_ktime_get_ns();
+    }
 
     kafka_batch_enqueue(event);
 }
@@ -1330,6 +1333,14 @@ static __always_inline bool kafka_process_new_response(void *ctx, conn_tuple_t *
     kafka->response.transaction = *request;
     bpf_map_delete_elem(&kafka_in_flight, &key);
 
+    // The top level error code of the fetch responses v7 to v11 follows the throttle time. It is only
+    // recorded if it is part of this packet, the error codes of the partitions are not parsed.
+    u8 api_version = kafka->response.transaction.request_api_version;
+    s16 error_code = 0;
+    if (api_version >= 7 && api_version < 12 && pktbuf_read_big_endian_s16(pkt, offset + sizeof(s32), &error_code)) {
+        kafka->response.transaction.error_code = error_code;
+    }
+
     kafka->response.state = KAFKA_FETCH_RESPONSE_START;
     kafka->response.carry_over_offset = offset - orig_offset;
     kafka->response.expected_tcp_seq = kafka_get_next_tcp_seq(skb_info);
@@ -1414,6 +1425,8 @@ static __always_inline bool kafka_process(conn_tuple_t *tup, kafka_info_t *kafka
     }
 
     kafka_transaction->request_started = bpf_ktime_get_ns();
+    kafka_transaction->response_last_seen = 0;
+    kafka_transaction->error_code = 0;
     kafka_transaction->request_api_key = kafka_header.api_key;
     kafka_transaction->request_api_version = kafka_header.api_version;
 
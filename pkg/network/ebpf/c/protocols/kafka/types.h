@@ -20,6 +20,8 @@ typedef struct {
 
 typedef struct kafka_transaction_t {
     __u64 request_started;
+    // Only set for the fetch requests, which are enqueued once their response is parsed.
+    __u64 response_last_seen;
     __u32 records_count;
     // Request API key and version are 16-bit in the protocol but we store
     // them as u8 to reduce memory usage of the map since the APIs and
@@ -27,6 +29,9 @@ typedef struct kafka_transaction_t {
     __u8 request_api_key;
     __u8 request_api_version;
     __u8 topic_name_size;
+    // Top level error code of the response, the error codes defined by
+    // Kafka fit in 8 bits.
+    __s8 error_code;
     char topic_name[TOPIC_NAME_MAX_STRING_SIZE];
 } kafka_transaction_t;
 
@@ -0,0 +1,15 @@
+#ifndef __MONGO_MAPS_H
+#define __MONGO_MAPS_H
+
+#include "bpf_helpers.h"
+#include "map-defs.h"
+
+#include "protocols/mongo/types.h"
+
+// Keeps track of in-flight Mongo transactions
+BPF_HASH_MAP(mongo_in_flight, conn_tuple_t, mongo_transaction_t, 0)
+
+// Acts as a scratch buffer for Mongo events, for preparing events before they are sent to userspace.
+BPF_PERCPU_ARRAY_MAP(mongo_scratch_buffer, mongo_event_t, 1)
+
+#endif
@@ -0,0 +1,195 @@
+#ifndef __MONGO_DECODING_H
+#define __MONGO_DECODING_H
+
+#include "bpf_builtins.h"
+#include "bpf_telemetry.h"
+
+#include "protocols/sockfd.h"
+
+#include "protocols/classification/structs.h"
+#include "protocols/helpers/pktbuf.h"
+#include "protocols/mongo/decoding-maps.h"
+#include "protocols/mongo/defs.h"
+#include "protocols/mongo/types.h"
+#include "protocols/mongo/usm-events.h"
+#include "protocols/read_into_buffer.h"
+
+PKTBUF_READ_INTO_BUFFER(mongo_body, MONGO_BUFFER_SIZE, BLK_SIZE)
+
+// The beginning of an OP_MSG: its header, its flag bits and the kind of its first section.
+typedef struct {
+    mongo_msg_header header;
+    __u32 flag_bits;
+    __u8 section_kind;
+} __attribute__((packed)) mongo_op_msg_t;
+
+// The first element of the body document of a response: the type of the value, the name of the field and the value.
+typedef struct {
+    __u8 type;
+    char name[3];
+    __u8 value[8];
+} __attribute__((packed)) mongo_first_element_t;
+
+// Enqueues a batch of events to the user-space. To spare stack size, we take a scratch buffer from the map, copy
+// the connection tuple and the transaction to it, and then enqueue the event.
+static __always_inline void mongo_batch_enqueue_wrapper(conn_tuple_t *tuple, mongo_transaction_t *tx) {
+    u32 zero = 0;
+    mongo_event_t *event = bpf_map_lookup_elem(&mongo_scratch_buffer, &zero);
+    if (!event) {
+        return;
+    }
+
+    bpf_memcpy(&event->tuple, tuple, sizeof(conn_tuple_t));
+    bpf_memcpy(&event->tx, tx, sizeof(mongo_transaction_t));
+    mongo_batch_enqueue(event);
+}
+
+// Reads the beginning of an OP_MSG from the given context. Returns true if the segment starts an OP_MSG whose body
+// section comes first, false otherwise. The compressed messages (OP_COMPRESSED) can't be decoded.
+// Format - https://www.mongodb.com/docs/manual/reference/mongodb-wire-protocol/#op_msg
+static __always_inline bool read_mongo_op_msg(pktbuf_t pkt, mongo_op_msg_t *msg) {
+    u32 data_off = pktbuf_data_offset(pkt);
+    u32 data_end = pktbuf_data_end(pkt);
+    // Ensuring that the beginning of the message is in the buffer.
+    if (data_off + sizeof(mongo_op_msg_t) > data_end) {
+        return false;
+    }
+    pktbuf_load_bytes(pkt, data_off, msg, sizeof(mongo_op_msg_t));
+    return msg->header.op_code == MONGO_OP_MSG &&
+        msg->header.message_length >= MONGO_OP_MSG_MIN_LENGTH &&
+        msg->section_kind == MONGO_OP_MSG_SECTION_BODY;
+}
+
+// Returns true if the response reports a failure of the command: its body document starts with {ok: 0}. The
+// successful responses end with {ok: 1} instead, after the results of the command.
+// The write errors of the commands which succeeded, such as duplicate keys, are not counted as failures.
+static __always_inline bool is_error_response(pktbuf_t pkt) {
+    // Skipping the length of the body document.
+    u32 data_off = pktbuf_data_offset(pkt) + MONGO_OP_MSG_BODY_OFFSET + sizeof(__s32);
+    if (data_off + sizeof(mongo_first_element_t) > pktbuf_data_end(pkt)) {
+        return false;
+    }
+    mongo_first_element_t element = {};
+    pktbuf_load_bytes(pkt, data_off, &element, sizeof(element));
+    if (element.name[0] != 'o' || element.name[1] != 'k' || element.name[2] != '\0') {
+        return false;
+    }
+    switch (element.type) {
+    case MONGO_BSON_TYPE_DOUBLE:
+        return *(__u64 *)element.value == 0;
+    case MONGO_BSON_TYPE_INT32:
+        return *(__u32 *)element.value == 0;
+    default:
+        return false;
+    }
+}
+
+// Handles a new request by creating a new transaction holding the beginning of its body document, and storing it in
+// the map. The drivers wait for the response before sending the next request of a connection.
+static __always_inline void handle_new_request(pktbuf_t pkt, conn_tuple_t *conn_tuple, mongo_op_msg_t *msg) {
+    // No response follows the unacknowledged writes.
+    if (msg->flag_bits & MONGO_OP_MSG_MORE_TO_COME) {
+        return;
+    }
+
+    mongo_transaction_t new_transaction = {};
+    new_transaction.request_started = bpf_ktime_get_ns();
+    new_transaction.request_id = msg->header.request_id;
+    pktbuf_read_into_buffer_mongo_body(new_transaction.request_fragment, pkt, pktbuf_data_offset(pkt) + MONGO_OP_MSG_BODY_OFFSET);
+    bpf_map_update_elem(&mongo_in_flight, conn_tuple, &new_transaction, BPF_ANY);
+}
+
+// Handles the response of the server by enqueuing the transaction it responds to, and deleting it from the in-flight
+// map. The following replies of the exhaust cursors respond to the previous replies, so they are ignored.
+static __always_inline void handle_response(pktbuf_t pkt, conn_tuple_t *conn_tuple, mongo_op_msg_t *msg) {
+    mongo_transaction_t *transaction = bpf_map_lookup_elem(&mongo_in_flight, conn_tuple);
+    if (!transaction || transaction->request_id != msg->header.response_to) {
+        return;
+    }
+
+    transaction->response_last_seen = bpf_ktime_get_ns();
+    transaction->is_error = is_error_response(pkt);
+    mongo_batch_enqueue_wrapper(conn_tuple, transaction);
+    bpf_map_delete_elem(&mongo_in_flight, conn_tuple);
+}
+
+static __always_inline void mongo_tcp_termination(conn_tuple_t *tup) {
+    normalize_tuple(tup);
+    bpf_map_delete_elem(&mongo_in_flight, tup);
+}
+
+// Main processing logic for the Mongo protocol. The requests don't respond to any message, while the responses refer
+// to the id of their request. The segments which don't start an OP_MSG, such as the continuations of large documents,
+// are ignored.
+static __always_inline void mongo_entrypoint(pktbuf_t pkt, conn_tuple_t *conn_tuple) {
+    mongo_op_msg_t msg = {};
+    if (!read_mongo_op_msg(pkt, &msg)) {
+        return;
+    }
+
+    if (msg.header.response_to == 0) {
+        handle_new_request(pkt, conn_tuple, &msg);
+        return;
+    }
+    handle_response(pkt, conn_tuple, &msg);
+}
+
+// Entrypoint to process plaintext Mongo traffic. Pulls the connection tuple and the packet buffer from the map and
+// calls the main processing function. If the packet is a TCP termination, it calls the termination function.
+SEC("socket/mongo_process")
+int socket__mongo_process(struct __sk_buff* skb) {
+    skb_info_t skb_info = {};
+    conn_tuple_t conn_tuple = {};
+
+    if (!fetch_dispatching_arguments(&conn_tuple, &skb_info)) {
+        return 0;
+    }
+
+    if (is_tcp_termination(&skb_info)) {
+        mongo_tcp_termination(&conn_tuple);
+        return 0;
+    }
+
+    normalize_tuple(&conn_tuple);
+
+    pktbuf_t pkt = pktbuf_from_skb(skb, &skb_info);
+    mongo_entrypoint(pkt, &conn_tuple);
+    return 0;
+}
+
+// Entrypoint to process TLS Mongo traffic. Pulls the connection tuple and the packet buffer from the map and calls
+// the main processing function.
+SEC("uprobe/mongo_tls_process")
+int uprobe__mongo_tls_process(struct pt_regs *ctx) {
+    const __u32 zero = 0;
+
+    tls_dispatcher_arguments_t *args = bpf_map_lookup_elem(&tls_dispatcher_arguments, &zero);
+    if (args == NULL) {
+        return 0;
+    }
+
+    // Copying the tuple to the stack to handle verifier issues on kernel 4.14.
+    conn_tuple_t tup = args->tup;
+
+    pktbuf_t pkt = pktbuf_from_tls(args);
+    mongo_entrypoint(pkt, &tup);
+    return 0;
+}
+
+// Handles connection termination for a TLS Mongo connection.
+SEC("uprobe/mongo_tls_termination")
+int uprobe__mongo_tls_termination(struct pt_regs *ctx) {
+    const __u32 zero = 0;
+
+    tls_dispatcher_arguments_t *args = bpf_map_lookup_elem(&tls_dispatcher_arguments, &zero);
+    if (args == NULL) {
+        return 0;
+    }
+
+    // Copying the tuple to the stack to handle verifier issues on kernel 4.14.
+    conn_tuple_t tup = args->tup;
+    mongo_tcp_termination(&tup);
+    return 0;
+}
+
+#endif
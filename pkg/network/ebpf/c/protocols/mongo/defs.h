@@ -18,4 +18,20 @@
 
 #define MONGO_HEADER_LENGTH 16
 
+// The flag of an OP_MSG announcing that no response follows it, as for the unacknowledged writes.
+// https://www.mongodb.com/docs/manual/reference/mongodb-wire-protocol/#flag-bits
+#define MONGO_OP_MSG_MORE_TO_COME (1 << 1)
+// The kind of the section holding the body document of an OP_MSG.
+#define MONGO_OP_MSG_SECTION_BODY 0
+// The offset of the body document of an OP_MSG starting with its body section: the header, the flag bits and the kind
+// of the section.
+#define MONGO_OP_MSG_BODY_OFFSET (MONGO_HEADER_LENGTH + sizeof(__u32) + sizeof(__u8))
+// The minimum length of an OP_MSG: its body offset and the length of an empty document.
+#define MONGO_OP_MSG_MIN_LENGTH (MONGO_OP_MSG_BODY_OFFSET + sizeof(__s32) + sizeof(__u8))
+
+// The types of the BSON values which the ok field of the responses can have.
+// https://bsonspec.org/spec.html
+#define MONGO_BSON_TYPE_DOUBLE 0x01
+#define MONGO_BSON_TYPE_INT32 0x10
+
 #endif
@@ -0,0 +1,31 @@
+#ifndef __MONGO_TYPES_H
+#define __MONGO_TYPES_H
+
+#include "conn_tuple.h"
+
+// Controls the number of Mongo transactions read from userspace at a time.
+#define MONGO_BATCH_SIZE 15
+
+// Maximum length of the body document of the requests to send to userspace. It holds the name of the command, its
+// first element, and the name of the collection, its value.
+#define MONGO_BUFFER_SIZE 80
+
+// Mongo transaction information we store in the kernel.
+typedef struct {
+    // The beginning of the body document of the OP_MSG request. Stored up to MONGO_BUFFER_SIZE bytes.
+    char request_fragment[MONGO_BUFFER_SIZE];
+    __u64 request_started;
+    __u64 response_last_seen;
+    // The id of the request, which the response refers to.
+    __s32 request_id;
+    // Set if the server responded that the command failed.
+    __u8 is_error;
+} mongo_transaction_t;
+
+// The struct we send to userspace, containing the connection tuple and the transaction information.
+typedef struct {
+    conn_tuple_t tuple;
+    mongo_transaction_t tx;
+} mongo_event_t;
+
+#endif
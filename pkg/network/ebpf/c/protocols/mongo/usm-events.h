@@ -0,0 +1,9 @@
+#ifndef __MONGO_USM_EVENTS_H
+#define __MONGO_USM_EVENTS_H
+
+#include "protocols/events.h"
+#include "protocols/mongo/types.h"
+
+USM_EVENTS_INIT(mongo, mongo_event_t, MONGO_BATCH_SIZE);
+
+#endif
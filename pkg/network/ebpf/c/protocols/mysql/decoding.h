@@ -0,0 +1,260 @@
+#ifndef __MYSQL_DECODING_H
+#define __MYSQL_DECODING_H
+
+#include "bpf_builtins.h"
+#include "bpf_telemetry.h"
+
+#include "protocols/sockfd.h"
+
+#include "protocols/helpers/pktbuf.h"
+#include "protocols/mysql/decoding-maps.h"
+#include "protocols/mysql/defs.h"
+#include "protocols/mysql/types.h"
+#include "protocols/mysql/usm-events.h"
+#include "protocols/read_into_buffer.h"
+
+PKTBUF_READ_INTO_BUFFER(mysql_query, MYSQL_BUFFER_SIZE, BLK_SIZE)
+
+// Enqueues a batch of events to the user-space. To spare stack size, we take a scratch buffer from the map, copy
+// the connection tuple and the transaction to it, and then enqueue the event.
+static __always_inline void mysql_batch_enqueue_wrapper(conn_tuple_t *tuple, mysql_transaction_t *tx) {
+    u32 zero = 0;
+    mysql_event_t *event = bpf_map_lookup_elem(&mysql_scratch_buffer, &zero);
+    if (!event) {
+        return;
+    }
+
+    bpf_memcpy(&event->tuple, tuple, sizeof(conn_tuple_t));
+    bpf_memcpy(&event->tx, tx, sizeof(mysql_transaction_t));
+    mysql_batch_enqueue(event);
+}
+
+// Reads a packet header from the given context. Returns true if the header was read successfully, false otherwise.
+static __always_inline bool read_mysql_header(pktbuf_t pkt, mysql_hdr *header) {
+    u32 data_off = pktbuf_data_offset(pkt);
+    u32 data_end = pktbuf_data_end(pkt);
+    // Ensuring that the header is in the buffer.
+    if (data_off + sizeof(mysql_hdr) > data_end) {
+        return false;
+    }
+    pktbuf_load_bytes(pkt, data_off, header, sizeof(mysql_hdr));
+    return true;
+}
+
+// Reads the statement id which follows the header of COM_STMT_EXECUTE, COM_STMT_CLOSE and COM_STMT_PREPARE_OK packets
+// into the key of the prepared statement. Returns true if the statement id was read successfully, false otherwise.
+static __always_inline bool read_statement_key(pktbuf_t pkt, conn_tuple_t *tup, mysql_statement_key_t *key) {
+    u32 data_off = pktbuf_data_offset(pkt) + sizeof(mysql_hdr);
+    if (data_off + sizeof(__u32) > pktbuf_data_end(pkt)) {
+        return false;
+    }
+    bpf_memset(key, 0, sizeof(mysql_statement_key_t));
+    bpf_memcpy(&key->tup, tup, sizeof(conn_tuple_t));
+    // The statement id is little-endian, as all the integers of the protocol.
+    pktbuf_load_bytes(pkt, data_off, &key->statement_id, sizeof(__u32));
+    return true;
+}
+
+// Handles a new command of the client by creating a new transaction and storing it in the map. As the client waits for
+// the response before sending its next command, the previous transaction of the connection is complete, so it is
+// enqueued if the server responded to it.
+// COM_QUERY and COM_STMT_PREPARE are followed by the query, COM_STMT_EXECUTE by the id of the executed statement, whose
+// query is looked up in the prepared statements.
+// Formats - https://dev.mysql.com/doc/dev/mysql-server/latest/page_protocol_com_query.html
+static __always_inline void handle_new_command(pktbuf_t pkt, conn_tuple_t *conn_tuple, mysql_hdr *header) {
+    mysql_transaction_t *previous = bpf_map_lookup_elem(&mysql_in_flight, conn_tuple);
+    if (previous && previous->response_last_seen > 0) {
+        mysql_batch_enqueue_wrapper(conn_tuple, previous);
+    }
+
+    mysql_transaction_t new_transaction = {};
+    new_transaction.request_started = bpf_ktime_get_ns();
+    new_transaction.command = header->command_type;
+    if (header->command_type == MYSQL_STMT_EXECUTE) {
+        mysql_statement_key_t key;
+        if (!read_statement_key(pkt, conn_tuple, &key)) {
+            // The previous transaction was enqueued, it must not be enqueued again by the next command.
+            bpf_map_delete_elem(&mysql_in_flight, conn_tuple);
+            return;
+        }
+        // The statements prepared before the monitoring started are reported without their query.
+        mysql_statement_t *statement = bpf_map_lookup_elem(&mysql_prepared_statements, &key);
+        if (statement) {
+            bpf_memcpy(new_transaction.request_fragment, statement->request_fragment, MYSQL_BUFFER_SIZE);
+            new_transaction.original_query_size = statement->original_query_size;
+        }
+    } else {
+        // The payload length includes the command byte, which is part of the header.
+        u32 data_off = pktbuf_data_offset(pkt) + sizeof(mysql_hdr);
+        pktbuf_read_into_buffer_mysql_query((char *)new_transaction.request_fragment, pkt, data_off);
+        new_transaction.original_query_size = header->payload_length - 1;
+    }
+    bpf_map_update_elem(&mysql_in_flight, conn_tuple, &new_transaction, BPF_ANY);
+}
+
+// Handles a COM_STMT_CLOSE by forgetting the prepared statement. The server doesn't respond to it.
+static __always_inline void handle_statement_close(pktbuf_t pkt, conn_tuple_t *conn_tuple) {
+    mysql_statement_key_t key;
+    if (read_statement_key(pkt, conn_tuple, &key)) {
+        bpf_map_delete_elem(&mysql_prepared_statements, &key);
+    }
+}
+
+// Handles a COM_STMT_PREPARE_OK by storing the query of the transaction as the one of the prepared statement. The
+// preparation itself isn't reported, only the executions of the statement are.
+static __always_inline void handle_statement_prepared(pktbuf_t pkt, conn_tuple_t *conn_tuple, mysql_transaction_t *transaction) {
+    mysql_statement_key_t key;
+    if (read_statement_key(pkt, conn_tuple, &key)) {
+        mysql_statement_t statement = {};
+        bpf_memcpy(statement.request_fragment, transaction->request_fragment, MYSQL_BUFFER_SIZE);
+        statement.original_query_size = transaction->original_query_size;
+        bpf_map_update_elem(&mysql_prepared_statements, &key, &statement, BPF_ANY);
+    }
+    bpf_map_delete_elem(&mysql_in_flight, conn_tuple);
+}
+
+// Handles the first packet of a response. OK and ERR packets complete the transaction, so it is enqueued and deleted
+// from the in-flight map. Result sets are spread over as many packets and segments as their rows need, so their
+// transactions are only enqueued on the next command of the client, or on the termination of the connection, and their
+// latency includes the transfer of the whole result set.
+// Formats - https://dev.mysql.com/doc/dev/mysql-server/latest/page_protocol_com_query_response.html
+static __always_inline void handle_first_response(pktbuf_t pkt, conn_tuple_t *conn_tuple, mysql_transaction_t *transaction, mysql_hdr *header) {
+    switch (header->command_type) {
+    case MYSQL_RESPONSE_ERR:
+        {
+            // The error code follows the header of the ERR packet.
+            u32 data_off = pktbuf_data_offset(pkt) + sizeof(mysql_hdr);
+            if (data_off + sizeof(__u16) <= pktbuf_data_end(pkt)) {
+                pktbuf_load_bytes(pkt, data_off, &transaction->error_code, sizeof(__u16));
+            }
+        }
+        break;
+    case MYSQL_RESPONSE_OK:
+        if (transaction->command == MYSQL_PREPARE_QUERY) {
+            handle_statement_prepared(pkt, conn_tuple, transaction);
+            return;
+        }
+        break;
+    default:
+        return;
+    }
+    mysql_batch_enqueue_wrapper(conn_tuple, transaction);
+    bpf_map_delete_elem(&mysql_in_flight, conn_tuple);
+}
+
+static __always_inline void mysql_tcp_termination(conn_tuple_t *tup) {
+    normalize_tuple(tup);
+    mysql_transaction_t *transaction = bpf_map_lookup_elem(&mysql_in_flight, tup);
+    if (!transaction) {
+        return;
+    }
+    // The result set of the last transaction of the connection was complete.
+    if (transaction->response_last_seen > 0) {
+        mysql_batch_enqueue_wrapper(tup, transaction);
+    }
+    bpf_map_delete_elem(&mysql_in_flight, tup);
+}
+
+// Main processing logic for the MySQL protocol. The commands of the clients always start a new sequence, so a packet
+// with a sequence id of 0 starting a segment which it fills is a new command. The following segments of the
+// connection belong to the response of the server, of which only the first packet is decoded.
+static __always_inline void mysql_entrypoint(pktbuf_t pkt, conn_tuple_t *conn_tuple) {
+    mysql_hdr header;
+    if (!read_mysql_header(pkt, &header) || header.payload_length == 0) {
+        return;
+    }
+
+    // The packet length doesn't include the 4 bytes of the payload length and the sequence id.
+    const u32 segment_size = pktbuf_data_end(pkt) - pktbuf_data_offset(pkt);
+    if (header.seq_id == 0 && header.payload_length + sizeof(__u32) >= segment_size) {
+        switch (header.command_type) {
+        case MYSQL_COMMAND_QUERY:
+        case MYSQL_PREPARE_QUERY:
+        case MYSQL_STMT_EXECUTE:
+            handle_new_command(pkt, conn_tuple, &header);
+            return;
+        case MYSQL_STMT_CLOSE:
+            handle_statement_close(pkt, conn_tuple);
+            return;
+        }
+    }
+
+    // We didn't find a new command, thus we assume we're in the middle of a transaction.
+    // We look up the transaction in the in-flight map, and if it doesn't exist, we ignore the segment.
+    mysql_transaction_t *transaction = bpf_map_lookup_elem(&mysql_in_flight, conn_tuple);
+    if (!transaction) {
+        return;
+    }
+
+    if (transaction->response_last_seen > 0) {
+        // The segment continues the result set.
+        transaction->response_last_seen = bpf_ktime_get_ns();
+        return;
+    }
+    // Skips the segments which don't start the response, such as the continuation of a large query.
+    if (header.seq_id != MYSQL_FIRST_RESPONSE_SEQ_ID) {
+        return;
+    }
+    transaction->response_last_seen = bpf_ktime_get_ns();
+    handle_first_response(pkt, conn_tuple, transaction, &header);
+}
+
+// Entrypoint to process plaintext MySQL traffic. Pulls the connection tuple and the packet buffer from the map and
+// calls the main processing function. If the packet is a TCP termination, it calls the termination function.
+SEC("socket/mysql_process")
+int socket__mysql_process(struct __sk_buff* skb) {
+    skb_info_t skb_info = {};
+    conn_tuple_t conn_tuple = {};
+
+    if (!fetch_dispatching_arguments(&conn_tuple, &skb_info)) {
+        return 0;
+    }
+
+    if (is_tcp_termination(&skb_info)) {
+        mysql_tcp_termination(&conn_tuple);
+        return 0;
+    }
+
+    normalize_tuple(&conn_tuple);
+
+    pktbuf_t pkt = pktbuf_from_skb(skb, &skb_info);
+    mysql_entrypoint(pkt, &conn_tuple);
+    return 0;
+}
+
+// Entrypoint to process TLS MySQL traffic. Pulls the connection tuple and the packet buffer from the map and calls
+// the main processing function.
+SEC("uprobe/mysql_tls_process")
+int uprobe__mysql_tls_process(struct pt_regs *ctx) {
+    const __u32 zero = 0;
+
+    tls_dispatcher_arguments_t *args = bpf_map_lookup_elem(&tls_dispatcher_arguments, &zero);
+    if (args == NULL) {
+        return 0;
+    }
+
+    // Copying the tuple to the stack to handle verifier issues on kernel 4.14.
+    conn_tuple_t tup = args->tup;
+
+    pktbuf_t pkt = pktbuf_from_tls(args);
+    mysql_entrypoint(pkt, &tup);
+    return 0;
+}
+
+// Handles connection termination for a TLS MySQL connection.
+SEC("uprobe/mysql_tls_termination")
+int uprobe__mysql_tls_termination(struct pt_regs *ctx) {
+    const __u32 zero = 0;
+
+    tls_dispatcher_arguments_t *args = bpf_map_lookup_elem(&tls_dispatcher_arguments, &zero);
+    if (args == NULL) {
+        return 0;
+    }
+
+    // Copying the tuple to the stack to handle verifier issues on kernel 4.14.
+    conn_tuple_t tup = args->tup;
+    mysql_tcp_termination(&tup);
+    return 0;
+}
+
+#endif
@@ -0,0 +1,47 @@
+#ifndef __MYSQL_TYPES_H
+#define __MYSQL_TYPES_H
+
+#include "conn_tuple.h"
+
+// Controls the number of MySQL transactions read from userspace at a time.
+#define MYSQL_BATCH_SIZE 25
+
+// Maximum length of MySQL query to send to userspace.
+#define MYSQL_BUFFER_SIZE 64
+
+// Maximum number of prepared statements we keep track of, across all connections.
+#define MYSQL_MAX_PREPARED_STATEMENTS 1024
+
+// MySQL transaction information we store in the kernel.
+typedef struct {
+    // The MySQL query, or the query of the executed prepared statement. Stored up to MYSQL_BUFFER_SIZE bytes.
+    char request_fragment[MYSQL_BUFFER_SIZE];
+    __u64 request_started;
+    __u64 response_last_seen;
+    // The actual size of the query stored in request_fragment.
+    __u32 original_query_size;
+    // The code of the ERR packet the server responded with, 0 if it succeeded.
+    __u16 error_code;
+    // The command of the request: COM_QUERY, COM_STMT_PREPARE or COM_STMT_EXECUTE.
+    __u8 command;
+} mysql_transaction_t;
+
+// Identifies a prepared statement of a connection.
+typedef struct {
+    conn_tuple_t tup;
+    __u32 statement_id;
+} mysql_statement_key_t;
+
+// The query of a prepared statement, reported with the transactions executing it.
+typedef struct {
+    char request_fragment[MYSQL_BUFFER_SIZE];
+    __u32 original_query_size;
+} mysql_statement_t;
+
+// The struct we send to userspace, containing the connection tuple and the transaction information.
+typedef struct {
+    conn_tuple_t tuple;
+    mysql_transaction_t tx;
+} mysql_event_t;
+
+#endif
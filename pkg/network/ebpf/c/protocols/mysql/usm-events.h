@@ -0,0 +1,9 @@
+#ifndef __MYSQL_USM_EVENTS_H
+#define __MYSQL_USM_EVENTS_H
+
+#include "protocols/events.h"
+#include "protocols/mysql/types.h"
+
+USM_EVENTS_INIT(mysql, mysql_event_t, MYSQL_BATCH_SIZE);
+
+#endif
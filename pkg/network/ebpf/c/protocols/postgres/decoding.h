@@ -64,6 +64,30 @@ static __always_inline void handle_command_complete(conn_tuple_t *conn_tuple, po
     bpf_map_delete_elem(&postgres_in_flight, conn_tuple);
 }
 
+// Returns true if the message whose payload starts at data_off is an ErrorResponse. Its tag is shared with the Execute
+// message of the clients, so it is told apart by its first field, the severity, which is ERROR, FATAL or PANIC.
+// The severities localized by the server are missed.
+// The format of the error response message is described here: https://www.postgresql.org/docs/current/protocol-message-formats.html#PROTOCOL-MESSAGE-FORMATS-ERRORRESPONSE
+static __always_inline bool is_error_response(pktbuf_t pkt, u32 data_off) {
+    if (data_off + POSTGRES_SEVERITY_FIELD_SIZE > pktbuf_data_end(pkt)) {
+        return false;
+    }
+    char severity[POSTGRES_SEVERITY_FIELD_SIZE] = {0};
+    pktbuf_load_bytes(pkt, data_off, severity, POSTGRES_SEVERITY_FIELD_SIZE);
+    if (severity[0] != 'S' || severity[6] != NULL_TERMINATOR) {
+        return false;
+    }
+    return (severity[1] == 'E' && severity[2] == 'R' && severity[3] == 'R' && severity[4] == 'O' && severity[5] == 'R') ||
+        (severity[1] == 'F' && severity[2] == 'A' && severity[3] == 'T' && severity[4] == 'A' && severity[5] == 'L') ||
+        (severity[1] == 'P' && severity[2] == 'A' && severity[3] == 'N' && severity[4] == 'I' && severity[5] == 'C');
+}
+
+// Handles an error response message by flagging the transaction as failed, and completing it.
+static __always_inline void handle_error_response(conn_tuple_t *conn_tuple, postgres_transaction_t *transaction) {
+    transaction->is_error = 1;
+    handle_command_complete(conn_tuple, transaction);
+}
+
 static void __always_inline postgres_tcp_termination(conn_tuple_t *tup) {
     bpf_map_delete_elem(&postgres_in_flight, tup);
     flip_tuple(tup);
@@ -137,6 +161,10 @@ static __always_inline void postgres_entrypoint(pktbuf_t pkt, conn_tuple_t *conn
         handle_command_complete(conn_tuple, transaction);
         return;
     }
+    if (header->message_tag == POSTGRES_ERROR_RESPONSE_MAGIC_BYTE && is_error_response(pkt, pktbuf_data_offset(pkt))) {
+        handle_error_response(conn_tuple, transaction);
+        return;
+    }
 
     // We're in the middle of a transaction, and the message is not a command complete, but it can be a chain of
     // messages. So we try to read up to POSTGRES_MAX_MESSAGES messages, looking for a command complete message.
@@ -146,6 +174,8 @@ static __always_inline void postgres_entrypoint(pktbuf_t pkt, conn_tuple_t *conn
     // offset to the end of the message header, we want to jump over the payload.
     pktbuf_advance(pkt, header->message_len - sizeof(__u32));
 
+    // The severity of an error response is only checked once out of the loop, to keep its unrolled size down.
+    __u32 error_data_off = 0;
 #pragma unroll(POSTGRES_MAX_MESSAGES)
     for (__u32 iteration = 0; iteration < POSTGRES_MAX_MESSAGES; ++iteration) {
         if (!read_message_header(pkt, header)) {
@@ -153,6 +183,10 @@ static __always_inline void postgres_entrypoint(pktbuf_t pkt, conn_tuple_t *conn
         }
         if (header->message_tag == POSTGRES_COMMAND_COMPLETE_MAGIC_BYTE) {
             handle_command_complete(conn_tuple, transaction);
+            return;
+        }
+        if (header->message_tag == POSTGRES_ERROR_RESPONSE_MAGIC_BYTE) {
+            error_data_off = pktbuf_data_offset(pkt) + sizeof(struct pg_message_header);
             break;
         }
         // We didn't find a command complete message, so we advance the data offset to the end of the message.
@@ -160,7 +194,9 @@ static __always_inline void postgres_entrypoint(pktbuf_t pkt, conn_tuple_t *conn
         // the message tag. So we need to add 1 to the message length to jump over the entire message.
         pktbuf_advance(pkt, header->message_len + 1);
     }
-    return;
+    if (error_data_off > 0 && is_error_response(pkt, error_data_off)) {
+        handle_error_response(conn_tuple, transaction);
+    }
 }
 
 // A dedicated function to handle the parse message. This function is called from a tail call from the main entrypoint.
@@ -29,6 +29,10 @@
 #define POSTGRES_QUERY_MAGIC_BYTE 'Q'
 #define POSTGRES_PARSE_MAGIC_BYTE 'P'
 #define POSTGRES_COMMAND_COMPLETE_MAGIC_BYTE 'C'
+// Both the ErrorResponse of the servers and the Execute message of the clients use this tag.
+#define POSTGRES_ERROR_RESPONSE_MAGIC_BYTE 'E'
+// Size of the first field of an ErrorResponse: the 'S' field type, a 5 characters severity and its null terminator.
+#define POSTGRES_SEVERITY_FIELD_SIZE 7
 
 #define POSTGRES_PING_BODY "-- ping"
 #define NULL_TERMINATOR '\0'
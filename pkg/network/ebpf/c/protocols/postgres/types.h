@@ -20,6 +20,8 @@ typedef struct {
     __u64 response_last_seen;
     // The actual size of the query stored in request_fragment.
     __u32 original_query_size;
+    // Set if the query ended with an ErrorResponse rather than a CommandComplete.
+    __u8 is_error;
 } postgres_transaction_t;
 
 // The struct we send to userspace, containing the connection tuple and the transaction information.
@@ -0,0 +1,239 @@
+#ifndef __REDIS_DECODING_H
+#define __REDIS_DECODING_H
+
+#include "bpf_builtins.h"
+#include "bpf_telemetry.h"
+
+#include "protocols/sockfd.h"
+
+#include "protocols/helpers/pktbuf.h"
+#include "protocols/redis/decoding-maps.h"
+#include "protocols/redis/defs.h"
+#include "protocols/redis/types.h"
+#include "protocols/redis/usm-events.h"
+#include "protocols/read_into_buffer.h"
+
+PKTBUF_READ_INTO_BUFFER(redis_request, REDIS_REQUEST_BUFFER_SIZE, BLK_SIZE)
+
+// Enqueues a batch of events to the user-space. To spare stack size, we take a scratch buffer from the map, copy
+// the connection tuple and the transaction to it, and then enqueue the event.
+static __always_inline void redis_batch_enqueue_wrapper(conn_tuple_t *tuple, redis_transaction_t *tx) {
+    u32 zero = 0;
+    redis_event_t *event = bpf_map_lookup_elem(&redis_scratch_buffer, &zero);
+    if (!event) {
+        return;
+    }
+
+    bpf_memcpy(&event->tuple, tuple, sizeof(conn_tuple_t));
+    bpf_memcpy(&event->tx, tx, sizeof(redis_transaction_t));
+    redis_batch_enqueue(event);
+}
+
+// Parses the beginning of a request, an array of bulk strings "*<count>\r\n$<length>\r\n<command>\r\n...", and
+// returns the offset and the length of the command name, its first bulk string. Returns false if the buffer doesn't
+// start with a request.
+static __always_inline bool parse_redis_command(const char *buf, __u32 buf_size, __u32 *command_offset, __u32 *command_length) {
+    if (buf_size < REDIS_MIN_FRAME_LENGTH || buf[0] != '*') {
+        return false;
+    }
+
+    redis_parse_state_t state = REDIS_PARSE_ARRAY_LENGTH;
+    __u32 length = 0;
+#pragma unroll(REDIS_REQUEST_BUFFER_SIZE - 1)
+    for (int i = 1; i < REDIS_REQUEST_BUFFER_SIZE; i++) {
+        if (i >= buf_size) {
+            return false;
+        }
+        char c = buf[i];
+        switch (state) {
+        case REDIS_PARSE_ARRAY_LENGTH:
+            if (c == '\r') {
+                state = REDIS_PARSE_ARRAY_LF;
+            } else if (c < '0' || c > '9') {
+                return false;
+            }
+            break;
+        case REDIS_PARSE_ARRAY_LF:
+            if (c != '\n') {
+                return false;
+            }
+            state = REDIS_PARSE_BULK_PREFIX;
+            break;
+        case REDIS_PARSE_BULK_PREFIX:
+            if (c != '$') {
+                return false;
+            }
+            state = REDIS_PARSE_BULK_LENGTH;
+            break;
+        case REDIS_PARSE_BULK_LENGTH:
+            if (c == '\r') {
+                state = REDIS_PARSE_BULK_LF;
+            } else if (c < '0' || c > '9') {
+                return false;
+            } else {
+                length = length * 10 + (c - '0');
+                if (length > REDIS_MAX_COMMAND_LENGTH) {
+                    return false;
+                }
+            }
+            break;
+        case REDIS_PARSE_BULK_LF:
+            if (c != '\n' || length == 0) {
+                return false;
+            }
+            *command_offset = i + 1;
+            *command_length = length;
+            return true;
+        }
+    }
+    return false;
+}
+
+// Returns true if the character is the first byte of a RESP2 or RESP3 response.
+// https://redis.io/docs/reference/protocol-spec/#resp-protocol-description
+static __always_inline bool is_response_type(char c) {
+    switch (c) {
+    case '+':
+    case '-':
+    case ':':
+    case '$':
+    case '*':
+    case '_':
+    case '#':
+    case ',':
+    case '(':
+    case '!':
+    case '=':
+    case '%':
+    case '~':
+    case '>':
+    case '|':
+        return true;
+    default:
+        return false;
+    }
+}
+
+// Handles a new request by creating a new transaction holding the name of its command and storing it in the map.
+static __always_inline void handle_new_request(pktbuf_t pkt, conn_tuple_t *conn_tuple) {
+    char buf[REDIS_REQUEST_BUFFER_SIZE] = {0};
+    const u32 data_off = pktbuf_data_offset(pkt);
+    const u32 data_end = pktbuf_data_end(pkt);
+    pktbuf_read_into_buffer_redis_request(buf, pkt, data_off);
+
+    __u32 command_offset = 0;
+    __u32 command_length = 0;
+    const __u32 buf_size = data_end - data_off < REDIS_REQUEST_BUFFER_SIZE ? data_end - data_off : REDIS_REQUEST_BUFFER_SIZE;
+    if (!parse_redis_command(buf, buf_size, &command_offset, &command_length)) {
+        return;
+    }
+
+    redis_transaction_t new_transaction = {};
+    __u32 copy_size = command_length < REDIS_COMMAND_MAX_SIZE ? command_length : REDIS_COMMAND_MAX_SIZE;
+    // Ensuring that the command name is in the buffer, and bounding the size for the verifier.
+    if (copy_size == 0 || copy_size > REDIS_COMMAND_MAX_SIZE || data_off + command_offset + copy_size > data_end) {
+        return;
+    }
+    pktbuf_load_bytes(pkt, data_off + command_offset, new_transaction.command, copy_size);
+    new_transaction.command_size = command_length;
+    new_transaction.request_started = bpf_ktime_get_ns();
+    bpf_map_update_elem(&redis_in_flight, conn_tuple, &new_transaction, BPF_ANY);
+}
+
+// Handles the response of the server by enqueuing the transaction and deleting it from the in-flight map.
+// Errors are simple errors or, in RESP3, bulk errors.
+static __always_inline void handle_response(pktbuf_t pkt, conn_tuple_t *conn_tuple, redis_transaction_t *transaction) {
+    const u32 data_off = pktbuf_data_offset(pkt);
+    if (data_off + sizeof(char) > pktbuf_data_end(pkt)) {
+        return;
+    }
+    char type = 0;
+    pktbuf_load_bytes(pkt, data_off, &type, sizeof(type));
+    // Skips the segments which don't start a response, such as the continuation of a large request.
+    if (!is_response_type(type)) {
+        return;
+    }
+
+    transaction->response_last_seen = bpf_ktime_get_ns();
+    transaction->is_error = type == '-' || type == '!';
+    redis_batch_enqueue_wrapper(conn_tuple, transaction);
+    bpf_map_delete_elem(&redis_in_flight, conn_tuple);
+}
+
+static __always_inline void redis_tcp_termination(conn_tuple_t *tup) {
+    bpf_map_delete_elem(&redis_in_flight, tup);
+    flip_tuple(tup);
+    bpf_map_delete_elem(&redis_in_flight, tup);
+}
+
+// Main processing logic for the Redis protocol. The server answers every command, so the first segment of the
+// connection following a request is its response. While no request is in flight, the segments are parsed as new
+// requests. The commands pipelined by the clients are reported once, as the first one of their segment.
+static __always_inline void redis_entrypoint(pktbuf_t pkt, conn_tuple_t *conn_tuple) {
+    redis_transaction_t *transaction = bpf_map_lookup_elem(&redis_in_flight, conn_tuple);
+    if (transaction) {
+        handle_response(pkt, conn_tuple, transaction);
+        return;
+    }
+    handle_new_request(pkt, conn_tuple);
+}
+
+// Entrypoint to process plaintext Redis traffic. Pulls the connection tuple and the packet buffer from the map and
+// calls the main processing function. If the packet is a TCP termination, it calls the termination function.
+SEC("socket/redis_process")
+int socket__redis_process(struct __sk_buff* skb) {
+    skb_info_t skb_info = {};
+    conn_tuple_t conn_tuple = {};
+
+    if (!fetch_dispatching_arguments(&conn_tuple, &skb_info)) {
+        return 0;
+    }
+
+    if (is_tcp_termination(&skb_info)) {
+        redis_tcp_termination(&conn_tuple);
+        return 0;
+    }
+
+    normalize_tuple(&conn_tuple);
+
+    pktbuf_t pkt = pktbuf_from_skb(skb, &skb_info);
+    redis_entrypoint(pkt, &conn_tuple);
+    return 0;
+}
+
+// Entrypoint to process TLS Redis traffic. Pulls the connection tuple and the packet buffer from the map and calls
+// the main processing function.
+SEC("uprobe/redis_tls_process")
+int uprobe__redis_tls_process(struct pt_regs *ctx) {
+    const __u32 zero = 0;
+
+    tls_dispatcher_arguments_t *args = bpf_map_lookup_elem(&tls_dispatcher_arguments, &zero);
+    if (args == NULL) {
+        return 0;
+    }
+
+    // Copying the tuple to the stack to handle verifier issues on kernel 4.14.
+    conn_tuple_t tup = args->tup;
+
+    pktbuf_t pkt = pktbuf_from_tls(args);
+    redis_entrypoint(pkt, &tup);
+    return 0;
+}
+
+// Handles connection termination for a TLS Redis connection.
+SEC("uprobe/redis_tls_termination")
+int uprobe__redis_tls_termination(struct pt_regs *ctx) {
+    const __u32 zero = 0;
+
+    tls_dispatcher_arguments_t *args = bpf_map_lookup_elem(&tls_dispatcher_arguments, &zero);
+    if (args == NULL) {
+        return 0;
+    }
+
+    // Copying the tuple to the stack to handle verifier issues on kernel 4.14.
+    conn_tuple_t tup = args->tup;
+    redis_tcp_termination(&tup);
+    return 0;
+}
+
+#endif
@@ -3,4 +3,19 @@
 
 #define REDIS_MIN_FRAME_LENGTH 3
 
+// Size of the beginning of a request we parse: the header of the array of arguments and the one of the command name.
+#define REDIS_REQUEST_BUFFER_SIZE 48
+// The bulk strings whose length is larger than this can't be the name of a command.
+#define REDIS_MAX_COMMAND_LENGTH 64
+
+// The states of the parser of the beginning of a request: "*<count>\r\n$<length>\r\n<command>".
+// https://redis.io/docs/reference/protocol-spec/#sending-commands-to-a-redis-server
+typedef enum {
+    REDIS_PARSE_ARRAY_LENGTH = 0,
+    REDIS_PARSE_ARRAY_LF,
+    REDIS_PARSE_BULK_PREFIX,
+    REDIS_PARSE_BULK_LENGTH,
+    REDIS_PARSE_BULK_LF,
+} redis_parse_state_t;
+
 #endif
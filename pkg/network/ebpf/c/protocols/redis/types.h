@@ -0,0 +1,30 @@
+#ifndef __REDIS_TYPES_H
+#define __REDIS_TYPES_H
+
+#include "conn_tuple.h"
+
+// Controls the number of Redis transactions read from userspace at a time.
+#define REDIS_BATCH_SIZE 25
+
+// Maximum length of the command name to send to userspace.
+#define REDIS_COMMAND_MAX_SIZE 24
+
+// Redis transaction information we store in the kernel.
+typedef struct {
+    // The name of the command, the first argument of the request. Stored up to REDIS_COMMAND_MAX_SIZE bytes.
+    char command[REDIS_COMMAND_MAX_SIZE];
+    __u64 request_started;
+    __u64 response_last_seen;
+    // The actual size of the command stored in command.
+    __u8 command_size;
+    // Set if the server responded with an error.
+    __u8 is_error;
+} redis_transaction_t;
+
+// The struct we send to userspace, containing the connection tuple and the transaction information.
+typedef struct {
+    conn_tuple_t tuple;
+    redis_transaction_t tx;
+} redis_event_t;
+
+#endif
@@ -0,0 +1,9 @@
+#ifndef __SSH_DEFS_H
+#define __SSH_DEFS_H
+
+// Both peers start an SSH connection by sending their identification string,
+// which must begin with "SSH-" (RFC 4253, section 4.2).
+#define SSH_IDENTIFICATION_PREFIX "SSH-"
+#define SSH_IDENTIFICATION_PREFIX_SIZE (sizeof(SSH_IDENTIFICATION_PREFIX) - 1)
+
+#endif
@@ -0,0 +1,16 @@
+#ifndef __SSH_HELPERS_H
+#define __SSH_HELPERS_H
+
+#include "bpf_builtins.h"
+
+#include "protocols/classification/common.h"
+#include "protocols/ssh/defs.h"
+
+// Checks if the buffer is an SSH identification string, for instance "SSH-2.0-OpenSSH_9.6".
+static __always_inline bool is_ssh(const char *buf, __u32 buf_size) {
+    CHECK_PRELIMINARY_BUFFER_CONDITIONS(buf, buf_size, SSH_IDENTIFICATION_PREFIX_SIZE);
+
+    return !bpf_memcmp(buf, SSH_IDENTIFICATION_PREFIX, SSH_IDENTIFICATION_PREFIX_SIZE);
+}
+
+#endif
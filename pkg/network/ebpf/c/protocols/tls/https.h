@@ -119,6 +119,22 @@ static __always_inline void tls_process(struct pt_regs *ctx, conn_tuple_t *t, vo
         prog = TLS_POSTGRES;
         final_tuple = normalized_tuple;
         break;
+    case PROTOCOL_MYSQL:
+        prog = TLS_MYSQL;
+        final_tuple = normalized_tuple;
+        break;
+    case PROTOCOL_REDIS:
+        prog = TLS_REDIS;
+        final_tuple = normalized_tuple;
+        break;
+    case PROTOCOL_MONGO:
+        prog = TLS_MONGO;
+        final_tuple = normalized_tuple;
+        break;
+    case PROTOCOL_AMQP:
+        prog = TLS_AMQP;
+        final_tuple = normalized_tuple;
+        break;
     default:
         return;
     }
@@ -207,6 +223,18 @@ static __always_inline void tls_finish(struct pt_regs *ctx, conn_tuple_t *t, boo
         prog = TLS_POSTGRES_TERMINATION;
         final_tuple = normalized_tuple;
         break;
+    case PROTOCOL_MYSQL:
+        prog = TLS_MYSQL_TERMINATION;
+        final_tuple = normalized_tuple;
+        break;
+    case PROTOCOL_REDIS:
+        prog = TLS_REDIS_TERMINATION;
+        final_tuple = normalized_tuple;
+        break;
+    case PROTOCOL_MONGO:
+        prog = TLS_MONGO_TERMINATION;
+        final_tuple = normalized_tuple;
+        break;
     default:
         return;
     }
@@ -0,0 +1,240 @@
+#ifndef __TLS_HANDSHAKE_H
+#define __TLS_HANDSHAKE_H
+
+#include "bpf_builtins.h"
+#include "bpf_endian.h"
+#include "bpf_helpers.h"
+#include "bpf_telemetry.h"
+#include "ip.h"
+
+#include "tracer/tracer.h"
+#include "tracer/maps.h"
+#include "protocols/tls/tls.h"
+
+#define TLS_HANDSHAKE_HEADER_SIZE 4
+#define TLS_RANDOM_SIZE 32
+
+#define TLS_EXTENSION_SERVER_NAME 0x0000
+#define TLS_EXTENSION_ALPN 0x0010
+#define TLS_EXTENSION_SUPPORTED_VERSIONS 0x002b
+#define TLS_SERVER_NAME_HOST_NAME 0
+
+// The extensions past this count are ignored. The clients commonly send up to 20 of them.
+#define TLS_MAX_EXTENSIONS 24
+// The versions offered past this count are ignored, including the GREASE values.
+#define TLS_MAX_SUPPORTED_VERSIONS 8
+
+static __always_inline bool is_tls_handshake_info_enabled() {
+    __u64 val = 0;
+    LOAD_CONSTANT("tls_handshake_info_enabled", val);
+    return val > 0;
+}
+
+// is_tls_hello checks if the buffer starts with a handshake record holding a
+// ClientHello or a ServerHello.
+static __always_inline bool is_tls_hello(const char *buf, __u32 buf_size) {
+    if (buf_size < (sizeof(tls_record_header_t) + sizeof(tls_hello_message_t))) {
+        return false;
+    }
+
+    tls_record_header_t *tls_record_header = (tls_record_header_t *)buf;
+    if (tls_record_header->content_type != TLS_HANDSHAKE) {
+        return false;
+    }
+    return is_tls_handshake((tls_hello_message_t *)(buf + sizeof(tls_record_header_t)));
+}
+
+static __always_inline bool tls_read_u8(struct __sk_buff *skb, __u32 offset, __u32 end, __u8 *out) {
+    if (offset + sizeof(__u8) > end) {
+        return false;
+    }
+    return bpf_skb_load_bytes(skb, offset, out, sizeof(__u8)) >= 0;
+}
+
+static __always_inline bool tls_read_u16(struct __sk_buff *skb, __u32 offset, __u32 end, __u16 *out) {
+    __u16 val = 0;
+    if (offset + sizeof(__u16) > end) {
+        return false;
+    }
+    if (bpf_skb_load_bytes(skb, offset, &val, sizeof(__u16)) < 0) {
+        return false;
+    }
+    *out = bpf_ntohs(val);
+    return true;
+}
+
+// tls_read_string copies a string of the given length, truncated to size - 1 bytes so that it
+// remains NUL terminated.
+static __always_inline void tls_read_string(struct __sk_buff *skb, __u32 offset, __u32 len, __u32 end, char *out, __u32 size) {
+    if (len >= size) {
+        len = size - 1;
+    }
+    if (len == 0 || offset + len > end) {
+        return;
+    }
+    bpf_memset(out, 0, size);
+    bpf_skb_load_bytes(skb, offset, out, len);
+}
+
+static __always_inline __u8 tls_version_offered_flag(__u16 version) {
+    switch (version) {
+    case TLS_VERSION10:
+        return TLS_VERSION10_OFFERED;
+    case TLS_VERSION11:
+        return TLS_VERSION11_OFFERED;
+    case TLS_VERSION12:
+        return TLS_VERSION12_OFFERED;
+    case TLS_VERSION13:
+        return TLS_VERSION13_OFFERED;
+    }
+    return 0;
+}
+
+// parse_supported_versions reads the supported_versions extension: the list of the versions
+// offered by the client, or the version selected by the server.
+static __always_inline void parse_supported_versions(struct __sk_buff *skb, __u32 offset, __u32 end, bool is_client, tls_info_t *info) {
+    __u16 version = 0;
+    if (!is_client) {
+        if (tls_read_u16(skb, offset, end, &version)) {
+            info->chosen_version = version;
+        }
+        return;
+    }
+
+    __u8 list_len = 0;
+    if (!tls_read_u8(skb, offset, end, &list_len)) {
+        return;
+    }
+    offset += sizeof(__u8);
+    __u32 list_end = offset + list_len;
+
+    __u8 offered = 0;
+#pragma unroll(TLS_MAX_SUPPORTED_VERSIONS)
+    for (int i = 0; i < TLS_MAX_SUPPORTED_VERSIONS; i++) {
+        if (offset >= list_end || !tls_read_u16(skb, offset, end, &version)) {
+            break;
+        }
+        offered |= tls_version_offered_flag(version);
+        offset += sizeof(__u16);
+    }
+    // the extension supersedes the version of the ClientHello
+    info->offered_versions = offered;
+}
+
+// parse_tls_extensions walks the extensions of a hello message and reads the server name, the
+// application protocol and the supported versions.
+// Format - https://www.rfc-editor.org/rfc/rfc8446#section-4.2
+static __always_inline void parse_tls_extensions(struct __sk_buff *skb, __u32 offset, __u32 end, bool is_client, tls_info_t *info) {
+    __u16 extensions_len = 0;
+    if (!tls_read_u16(skb, offset, end, &extensions_len)) {
+        return;
+    }
+    offset += sizeof(__u16);
+    if (offset + extensions_len < end) {
+        end = offset + extensions_len;
+    }
+
+    __u16 ext_type = 0;
+    __u16 ext_len = 0;
+    __u16 name_len = 0;
+    __u8 name_type = 0;
+    __u8 proto_len = 0;
+#pragma unroll(TLS_MAX_EXTENSIONS)
+    for (int i = 0; i < TLS_MAX_EXTENSIONS; i++) {
+        if (!tls_read_u16(skb, offset, end, &ext_type) || !tls_read_u16(skb, offset + sizeof(__u16), end, &ext_len)) {
+            return;
+        }
+        offset += 2 * sizeof(__u16);
+
+        switch (ext_type) {
+        case TLS_EXTENSION_SERVER_NAME:
+            // Only the clients send a name: a list holding a single host name.
+            if (is_client && tls_read_u8(skb, offset + sizeof(__u16), end, &name_type) && name_type == TLS_SERVER_NAME_HOST_NAME &&
+                tls_read_u16(skb, offset + sizeof(__u16) + sizeof(__u8), end, &name_len)) {
+                tls_read_string(skb, offset + 2 * sizeof(__u16) + sizeof(__u8), name_len, end, info->server_name, TLS_SERVER_NAME_MAX);
+            }
+            break;
+        case TLS_EXTENSION_ALPN:
+            // The first protocol of the list, which is the selected one for the servers.
+            if (tls_read_u8(skb, offset + sizeof(__u16), end, &proto_len)) {
+                tls_read_string(skb, offset + sizeof(__u16) + sizeof(__u8), proto_len, end, info->alpn, TLS_ALPN_MAX);
+            }
+            break;
+        case TLS_EXTENSION_SUPPORTED_VERSIONS:
+            parse_supported_versions(skb, offset, end, is_client, info);
+            break;
+        }
+        offset += ext_len;
+    }
+}
+
+// parse_tls_hello reads a ClientHello or a ServerHello. The hellos sent over several segments are
+// only read up to the end of the first one.
+// Format - https://www.rfc-editor.org/rfc/rfc8446#section-4.1.2
+static __always_inline void parse_tls_hello(struct __sk_buff *skb, skb_info_t *skb_info, tls_info_t *info) {
+    __u32 offset = skb_info->data_off + sizeof(tls_record_header_t);
+    __u32 end = skb_info->data_end;
+
+    __u8 handshake_type = 0;
+    __u16 version = 0;
+    if (!tls_read_u8(skb, offset, end, &handshake_type) || !tls_read_u16(skb, offset + TLS_HANDSHAKE_HEADER_SIZE, end, &version)) {
+        return;
+    }
+    bool is_client = handshake_type == TLS_HANDSHAKE_CLIENT_HELLO;
+    if (is_client) {
+        info->offered_versions = tls_version_offered_flag(version);
+    } else {
+        info->chosen_version = version;
+    }
+    offset += TLS_HANDSHAKE_HEADER_SIZE + sizeof(__u16) + TLS_RANDOM_SIZE;
+
+    __u8 session_id_len = 0;
+    if (!tls_read_u8(skb, offset, end, &session_id_len)) {
+        return;
+    }
+    offset += sizeof(__u8) + session_id_len;
+
+    if (is_client) {
+        // Skipping the cipher suites and the compression methods offered.
+        __u16 cipher_suites_len = 0;
+        if (!tls_read_u16(skb, offset, end, &cipher_suites_len)) {
+            return;
+        }
+        offset += sizeof(__u16) + cipher_suites_len;
+        __u8 compression_methods_len = 0;
+        if (!tls_read_u8(skb, offset, end, &compression_methods_len)) {
+            return;
+        }
+        offset += sizeof(__u8) + compression_methods_len;
+    } else {
+        __u16 cipher_suite = 0;
+        if (!tls_read_u16(skb, offset, end, &cipher_suite)) {
+            return;
+        }
+        info->cipher_suite = cipher_suite;
+        // Skipping the cipher suite and the compression method selected.
+        offset += sizeof(__u16) + sizeof(__u8);
+    }
+
+    parse_tls_extensions(skb, offset, end, is_client, info);
+}
+
+// update_tls_info parses the hello message of the segment into the handshake metadata of the
+// connection.
+static __always_inline void update_tls_info(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *skb_tup) {
+    conn_tuple_t normalized_tup = *skb_tup;
+    normalize_tuple(&normalized_tup);
+
+    tls_info_t *info = bpf_map_lookup_elem(&tls_handshake_info, &normalized_tup);
+    if (!info) {
+        tls_info_t empty = {};
+        bpf_map_update_with_telemetry(tls_handshake_info, &normalized_tup, &empty, BPF_NOEXIST);
+        info = bpf_map_lookup_elem(&tls_handshake_info, &normalized_tup);
+        if (!info) {
+            return;
+        }
+    }
+    parse_tls_hello(skb, skb_info, info);
+}
+
+#endif
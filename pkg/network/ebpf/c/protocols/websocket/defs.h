@@ -0,0 +1,29 @@
+#ifndef __WEBSOCKET_DEFS_H
+#define __WEBSOCKET_DEFS_H
+
+// The server accepts the upgrade of an HTTP/1.1 connection with a 101 response (RFC 6455, section 4.2.2).
+#define WEBSOCKET_UPGRADE_RESPONSE "HTTP/1.1 101"
+#define WEBSOCKET_UPGRADE_RESPONSE_SIZE (sizeof(WEBSOCKET_UPGRADE_RESPONSE) - 1)
+
+// Frame format - https://www.rfc-editor.org/rfc/rfc6455#section-5.2
+#define WEBSOCKET_FIN_BIT 0x80
+#define WEBSOCKET_RSV_BITS 0x70
+#define WEBSOCKET_OPCODE_MASK 0x0f
+#define WEBSOCKET_MASK_BIT 0x80
+#define WEBSOCKET_PAYLOAD_LEN_MASK 0x7f
+#define WEBSOCKET_PAYLOAD_LEN_16 126
+#define WEBSOCKET_PAYLOAD_LEN_64 127
+#define WEBSOCKET_MIN_HEADER_SIZE 2
+#define WEBSOCKET_MASKING_KEY_SIZE 4
+
+#define WEBSOCKET_OPCODE_CONTINUATION 0x0
+#define WEBSOCKET_OPCODE_TEXT 0x1
+#define WEBSOCKET_OPCODE_BINARY 0x2
+#define WEBSOCKET_OPCODE_CLOSE 0x8
+#define WEBSOCKET_OPCODE_PING 0x9
+#define WEBSOCKET_OPCODE_PONG 0xa
+
+// The frames of a segment past this count are not counted.
+#define WEBSOCKET_MAX_FRAMES_PER_SEGMENT 8
+
+#endif
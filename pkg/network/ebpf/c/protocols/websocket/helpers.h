@@ -0,0 +1,26 @@
+#ifndef __WEBSOCKET_HELPERS_H
+#define __WEBSOCKET_HELPERS_H
+
+#include "bpf_builtins.h"
+#include "bpf_helpers.h"
+
+#include "protocols/classification/common.h"
+#include "protocols/websocket/defs.h"
+
+// The connections upgraded to WebSocket are only reclassified when the sessions are tracked, otherwise
+// they keep being reported as HTTP.
+static __always_inline bool is_websocket_tracking_enabled() {
+    __u64 val = 0;
+    LOAD_CONSTANT("websocket_tracking_enabled", val);
+    return val > 0;
+}
+
+// Checks if the buffer is the response of a server switching the protocol of an HTTP/1.1 connection. The
+// upgrades to HTTP/2 (h2c) are rare enough for all of them to be considered as WebSocket upgrades.
+static __always_inline bool is_websocket_upgrade(const char *buf, __u32 buf_size) {
+    CHECK_PRELIMINARY_BUFFER_CONDITIONS(buf, buf_size, WEBSOCKET_UPGRADE_RESPONSE_SIZE);
+
+    return !bpf_memcmp(buf, WEBSOCKET_UPGRADE_RESPONSE, WEBSOCKET_UPGRADE_RESPONSE_SIZE);
+}
+
+#endif
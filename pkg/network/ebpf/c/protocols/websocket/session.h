@@ -0,0 +1,113 @@
+#ifndef __WEBSOCKET_SESSION_H
+#define __WEBSOCKET_SESSION_H
+
+#include "bpf_endian.h"
+#include "bpf_helpers.h"
+#include "bpf_telemetry.h"
+#include "ip.h"
+
+#include "tracer/tracer.h"
+#include "tracer/maps.h"
+#include "protocols/websocket/defs.h"
+#include "protocols/websocket/helpers.h"
+
+static __always_inline bool is_websocket_data_opcode(__u8 opcode) {
+    return opcode == WEBSOCKET_OPCODE_CONTINUATION || opcode == WEBSOCKET_OPCODE_TEXT || opcode == WEBSOCKET_OPCODE_BINARY;
+}
+
+static __always_inline bool is_websocket_control_opcode(__u8 opcode) {
+    return opcode == WEBSOCKET_OPCODE_CLOSE || opcode == WEBSOCKET_OPCODE_PING || opcode == WEBSOCKET_OPCODE_PONG;
+}
+
+// websocket_count_frames walks the frames of a segment sent by one side of the session, and counts the
+// messages they end. The bytes of the frame in progress at the end of the segment are kept in `remaining`,
+// so that the following segment is read from the next frame. Reading stops on an invalid header, as
+// after a retransmission, until a segment ends on a frame boundary.
+static __always_inline void websocket_count_frames(struct __sk_buff *skb, __u32 offset, __u32 end, __u64 *remaining, __u64 *messages) {
+    if (*remaining >= end - offset) {
+        *remaining -= end - offset;
+        return;
+    }
+    offset += *remaining;
+    *remaining = 0;
+
+    __u8 header[WEBSOCKET_MIN_HEADER_SIZE] = {};
+    __u16 len16 = 0;
+    __u64 len64 = 0;
+#pragma unroll(WEBSOCKET_MAX_FRAMES_PER_SEGMENT)
+    for (int i = 0; i < WEBSOCKET_MAX_FRAMES_PER_SEGMENT; i++) {
+        if (offset + WEBSOCKET_MIN_HEADER_SIZE > end || bpf_skb_load_bytes(skb, offset, header, sizeof(header)) < 0) {
+            return;
+        }
+        const __u8 opcode = header[0] & WEBSOCKET_OPCODE_MASK;
+        if (header[0] & WEBSOCKET_RSV_BITS || !(is_websocket_data_opcode(opcode) || is_websocket_control_opcode(opcode))) {
+            return;
+        }
+
+        __u64 header_size = WEBSOCKET_MIN_HEADER_SIZE;
+        __u64 payload_len = header[1] & WEBSOCKET_PAYLOAD_LEN_MASK;
+        if (payload_len == WEBSOCKET_PAYLOAD_LEN_16) {
+            if (offset + header_size + sizeof(len16) > end || bpf_skb_load_bytes(skb, offset + header_size, &len16, sizeof(len16)) < 0) {
+                return;
+            }
+            payload_len = bpf_ntohs(len16);
+            header_size += sizeof(len16);
+        } else if (payload_len == WEBSOCKET_PAYLOAD_LEN_64) {
+            if (offset + header_size + sizeof(len64) > end || bpf_skb_load_bytes(skb, offset + header_size, &len64, sizeof(len64)) < 0) {
+                return;
+            }
+            payload_len = bpf_ntohll(len64);
+            header_size += sizeof(len64);
+        }
+        if (header[1] & WEBSOCKET_MASK_BIT) {
+            header_size += WEBSOCKET_MASKING_KEY_SIZE;
+        }
+
+        // The messages are counted from their last frame, which may also be the first one.
+        if (header[0] & WEBSOCKET_FIN_BIT && is_websocket_data_opcode(opcode)) {
+            (*messages)++;
+        }
+
+        const __u64 frame_size = header_size + payload_len;
+        if (frame_size >= end - offset) {
+            *remaining = frame_size - (end - offset);
+            return;
+        }
+        offset += frame_size;
+    }
+}
+
+// start_websocket_session creates the session of a connection from the upgrade response sent by the server.
+static __always_inline void start_websocket_session(conn_tuple_t *skb_tup) {
+    conn_tuple_t normalized_tup = *skb_tup;
+    normalize_tuple(&normalized_tup);
+
+    websocket_session_t session = {};
+    session.upgraded_ns = bpf_ktime_get_ns();
+    session.last_seen_ns = session.upgraded_ns;
+    session.server_port = skb_tup->sport;
+    bpf_map_update_with_telemetry(websocket_sessions, &normalized_tup, &session, BPF_NOEXIST);
+}
+
+// update_websocket_session counts the bytes and the messages of the segment into the session of the connection.
+static __always_inline void update_websocket_session(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *skb_tup) {
+    conn_tuple_t normalized_tup = *skb_tup;
+    normalize_tuple(&normalized_tup);
+
+    websocket_session_t *session = bpf_map_lookup_elem(&websocket_sessions, &normalized_tup);
+    if (!session) {
+        return;
+    }
+
+    session->last_seen_ns = bpf_ktime_get_ns();
+    const __u32 len = skb_info->data_end - skb_info->data_off;
+    if (skb_tup->sport == session->server_port) {
+        session->server_bytes += len;
+        websocket_count_frames(skb, skb_info->data_off, skb_info->data_end, &session->server_frame_remaining, &session->server_messages);
+    } else {
+        session->client_bytes += len;
+        websocket_count_frames(skb, skb_info->data_off, skb_info->data_end, &session->client_frame_remaining, &session->client_messages);
+    }
+}
+
+#endif
@@ -18,7 +18,11 @@
 #include "protocols/http2/decoding.h"
 #include "protocols/http2/decoding-tls.h"
 #include "protocols/kafka/kafka-parsing.h"
+#include "protocols/amqp/decoding.h"
+#include "protocols/mongo/decoding.h"
+#include "protocols/mysql/decoding.h"
 #include "protocols/postgres/decoding.h"
+#include "protocols/redis/decoding.h"
 #include "protocols/sockfd-probes.h"
 #include "protocols/tls/java/erpc_dispatcher.h"
 #include "protocols/tls/java/erpc_handlers.h"
@@ -72,6 +76,10 @@ int tracepoint__net__netif_receive_skb(struct pt_regs* ctx) {
     terminated_http2_batch_flush(ctx);
     kafka_batch_flush(ctx);
     postgres_batch_flush(ctx);
+    mysql_batch_flush(ctx);
+    redis_batch_flush(ctx);
+    mongo_batch_flush(ctx);
+    amqp_batch_flush(ctx);
     return 0;
 }
 
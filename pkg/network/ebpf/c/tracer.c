@@ -12,10 +12,14 @@
 #endif
 #include "skb.h"
 #include "tracer/bind.h"
+#include "tracer/drops.h"
 #include "tracer/events.h"
+#include "tracer/listen.h"
 #include "tracer/maps.h"
 #include "tracer/port.h"
+#include "tracer/sctp.h"
 #include "tracer/tcp_recv.h"
+#include "tracer/unix.h"
 #include "protocols/classification/protocol-classification.h"
 
 SEC("socket/classifier_entry")
@@ -928,6 +932,203 @@ int kprobe__tcp_finish_connect(struct pt_regs *ctx) {
     return 0;
 }
 
+#if defined(COMPILE_RUNTIME) || defined(COMPILE_CORE)
+
+SEC("kprobe/tcp_done")
+int kprobe__tcp_done(struct pt_regs *ctx) {
+    struct sock *skp = (struct sock *)PT_REGS_PARM1(ctx);
+
+    // only connection attempts that never got established are tracked here;
+    // tcp_done may run in softirq or timer context, so the pid is taken from
+    // the ongoing connection instead of the current task
+    u64 *pid_tgid_p = bpf_map_lookup_elem(&tcp_ongoing_connect_pid, &skp);
+    if (!pid_tgid_p) {
+        return 0;
+    }
+
+    int err = 0;
+    BPF_CORE_READ_INTO(&err, skp, sk_err);
+    if (err != TCP_CONN_FAILED_RESET && err != TCP_CONN_FAILED_TIMEOUT && err != TCP_CONN_FAILED_REFUSED) {
+        return 0;
+    }
+
+    u64 pid_tgid = *pid_tgid_p;
+    conn_tuple_t t = {};
+    if (!read_conn_tuple(&t, skp, pid_tgid, CONN_TYPE_TCP)) {
+        return 0;
+    }
+
+    log_debug("kprobe/tcp_done: failed connection attempt, netns: %u, sport: %u, err: %d", t.netns, t.sport, err);
+
+    // the local port is released once tcp_done returns, so the connection
+    // can't be resolved anymore by the time the socket gets closed; flush it now
+    bpf_map_delete_elem(&tcp_ongoing_connect_pid, &skp);
+    increment_telemetry_count(tcp_failed_connect);
+
+    tcp_stats_t stats = { .failure_reason = err };
+    update_tcp_stats(&t, stats);
+    cleanup_conn(ctx, &t, skp);
+
+    return 0;
+}
+
+#endif // COMPILE_RUNTIME || COMPILE_CORE
+
+#if defined(COMPILE_RUNTIME) || defined(COMPILE_CORE)
+
+// sctp_sf_do_prm_asoc handles the primitive ASSOCIATE request,
+// i.e. the association is being initiated by this host
+SEC("kprobe/sctp_sf_do_prm_asoc")
+int kprobe__sctp_sf_do_prm_asoc(struct pt_regs *ctx) {
+    struct sctp_association *asoc = (struct sctp_association *)PT_REGS_PARM3(ctx);
+    if (!asoc) {
+        return 0;
+    }
+
+    __u8 initiated = 1;
+    bpf_map_update_with_telemetry(sctp_initiated_assocs, &asoc, &initiated, BPF_ANY);
+    return 0;
+}
+
+SEC("kprobe/sctp_outq_tail")
+int kprobe__sctp_outq_tail(struct pt_regs *ctx) {
+    struct sctp_outq *q = (struct sctp_outq *)PT_REGS_PARM1(ctx);
+    struct sctp_chunk *chunk = (struct sctp_chunk *)PT_REGS_PARM2(ctx);
+    struct sctp_association *asoc = BPF_CORE_READ(q, asoc);
+    return handle_sctp_chunk(asoc, chunk, true);
+}
+
+SEC("kprobe/sctp_ulpevent_make_rcvmsg")
+int kprobe__sctp_ulpevent_make_rcvmsg(struct pt_regs *ctx) {
+    struct sctp_association *asoc = (struct sctp_association *)PT_REGS_PARM1(ctx);
+    struct sctp_chunk *chunk = (struct sctp_chunk *)PT_REGS_PARM2(ctx);
+    return handle_sctp_chunk(asoc, chunk, false);
+}
+
+SEC("kprobe/sctp_association_free")
+int kprobe__sctp_association_free(struct pt_regs *ctx) {
+    struct sctp_association *asoc = (struct sctp_association *)PT_REGS_PARM1(ctx);
+    if (!asoc) {
+        return 0;
+    }
+
+    conn_tuple_t t = {};
+    int valid_tuple = read_sctp_conn_tuple(&t, asoc);
+    bpf_map_delete_elem(&sctp_initiated_assocs, &asoc);
+    if (!valid_tuple) {
+        return 0;
+    }
+
+    cleanup_conn(ctx, &t, BPF_CORE_READ(asoc, base.sk));
+    return 0;
+}
+
+SEC("kretprobe/sctp_association_free")
+int kretprobe__sctp_association_free(struct pt_regs *ctx) {
+    flush_conn_close_if_full(ctx);
+    return 0;
+}
+
+#endif // COMPILE_RUNTIME || COMPILE_CORE
+
+#if defined(COMPILE_RUNTIME) || defined(COMPILE_CORE)
+
+SEC("kprobe/unix_stream_sendmsg")
+int kprobe__unix_stream_sendmsg(struct pt_regs *ctx) {
+    struct socket *sock = (struct socket *)PT_REGS_PARM1(ctx);
+    __u64 pid_tgid = bpf_get_current_pid_tgid();
+    bpf_map_update_with_telemetry(unix_sendmsg_args, &pid_tgid, &sock, BPF_ANY);
+    return 0;
+}
+
+SEC("kretprobe/unix_stream_sendmsg")
+int kretprobe__unix_stream_sendmsg(struct pt_regs *ctx) {
+    return handle_unix_sendmsg_return(PT_REGS_RC(ctx));
+}
+
+SEC("kprobe/unix_dgram_sendmsg")
+int kprobe__unix_dgram_sendmsg(struct pt_regs *ctx) {
+    struct socket *sock = (struct socket *)PT_REGS_PARM1(ctx);
+    __u64 pid_tgid = bpf_get_current_pid_tgid();
+    bpf_map_update_with_telemetry(unix_sendmsg_args, &pid_tgid, &sock, BPF_ANY);
+    return 0;
+}
+
+SEC("kretprobe/unix_dgram_sendmsg")
+int kretprobe__unix_dgram_sendmsg(struct pt_regs *ctx) {
+    return handle_unix_sendmsg_return(PT_REGS_RC(ctx));
+}
+
+// unix_release marks the socket as closed, userspace deletes
+// the entry once its final statistics have been collected
+SEC("kprobe/unix_release")
+int kprobe__unix_release(struct pt_regs *ctx) {
+    struct socket *sock = (struct socket *)PT_REGS_PARM1(ctx);
+    __u64 ino = socket_ino(sock);
+    unix_sock_stats_t *stats = bpf_map_lookup_elem(&unix_sock_stats, &ino);
+    if (stats) {
+        stats->closed = 1;
+        stats->timestamp = bpf_ktime_get_ns();
+    }
+    return 0;
+}
+
+#endif // COMPILE_RUNTIME || COMPILE_CORE
+
+#if defined(COMPILE_RUNTIME) || defined(COMPILE_CORE)
+
+// tcp_conn_request handles the SYNs received by a listening socket
+SEC("kprobe/tcp_conn_request")
+int kprobe__tcp_conn_request(struct pt_regs *ctx) {
+    struct sock *sk = (struct sock *)PT_REGS_PARM3(ctx);
+    bool syn_backlog_full = reqsk_queue_is_full(sk);
+    bool accept_queue_full = acceptq_is_full(sk);
+    if (syn_backlog_full || accept_queue_full) {
+        count_listen_overflow(sk, accept_queue_full, syn_backlog_full);
+    }
+    return 0;
+}
+
+// tcp_v4_syn_recv_sock creates the socket of a connection once the handshake completes
+SEC("kprobe/tcp_v4_syn_recv_sock")
+int kprobe__tcp_v4_syn_recv_sock(struct pt_regs *ctx) {
+    struct sock *sk = (struct sock *)PT_REGS_PARM1(ctx);
+    if (acceptq_is_full(sk)) {
+        count_listen_overflow(sk, true, false);
+    }
+    return 0;
+}
+
+SEC("kprobe/tcp_v6_syn_recv_sock")
+int kprobe__tcp_v6_syn_recv_sock(struct pt_regs *ctx) {
+    struct sock *sk = (struct sock *)PT_REGS_PARM1(ctx);
+    struct sk_buff *skb = (struct sk_buff *)PT_REGS_PARM2(ctx);
+    // IPv4-mapped connections are handed over to tcp_v4_syn_recv_sock
+    if (BPF_CORE_READ(skb, protocol) == bpf_htons(ETH_P_IP)) {
+        return 0;
+    }
+    if (acceptq_is_full(sk)) {
+        count_listen_overflow(sk, true, false);
+    }
+    return 0;
+}
+
+SEC("kprobe/ip_output")
+int kprobe__ip_output(struct pt_regs *ctx) {
+    struct sock *sk = (struct sock *)PT_REGS_PARM2(ctx);
+    struct sk_buff *skb = (struct sk_buff *)PT_REGS_PARM3(ctx);
+    return handle_skb_qos(sk, skb);
+}
+
+SEC("kprobe/ip6_output")
+int kprobe__ip6_output(struct pt_regs *ctx) {
+    struct sock *sk = (struct sock *)PT_REGS_PARM2(ctx);
+    struct sk_buff *skb = (struct sk_buff *)PT_REGS_PARM3(ctx);
+    return handle_skb_qos(sk, skb);
+}
+
+#endif // COMPILE_RUNTIME || COMPILE_CORE
+
 SEC("kretprobe/inet_csk_accept")
 int kretprobe__inet_csk_accept(struct pt_regs *ctx) {
     struct sock *sk = (struct sock *)PT_REGS_RC(ctx);
@@ -954,9 +1155,41 @@ int kretprobe__inet_csk_accept(struct pt_regs *ctx) {
     return 0;
 }
 
+SEC("kprobe/inet_csk_listen_start")
+int kprobe__inet_csk_listen_start(struct pt_regs *ctx) {
+    struct sock *skp = (struct sock *)PT_REGS_PARM1(ctx);
+    u64 pid_tgid = bpf_get_current_pid_tgid();
+    bpf_map_update_with_telemetry(inet_csk_listen_start_args, &pid_tgid, &skp, BPF_ANY);
+    return 0;
+}
+
+SEC("kretprobe/inet_csk_listen_start")
+int kretprobe__inet_csk_listen_start(struct pt_regs *ctx) {
+    u64 pid_tgid = bpf_get_current_pid_tgid();
+    struct sock **skpp = (struct sock **)bpf_map_lookup_elem(&inet_csk_listen_start_args, &pid_tgid);
+    if (!skpp) {
+        return 0;
+    }
+
+    struct sock *skp = *skpp;
+    bpf_map_delete_elem(&inet_csk_listen_start_args, &pid_tgid);
+
+    int ret = PT_REGS_RC(ctx);
+    if (ret != 0 || !skp) {
+        return 0;
+    }
+
+    // the port is only known at this point if the socket
+    // was not explicitly bound before calling listen()
+    add_listening_socket(skp, pid_tgid, CONN_TYPE_TCP);
+    return 0;
+}
+
 SEC("kprobe/inet_csk_listen_stop")
 int kprobe__inet_csk_listen_stop(struct pt_regs *ctx) {
     struct sock *skp = (struct sock *)PT_REGS_PARM1(ctx);
+    remove_listening_socket(skp);
+
     __u16 lport = read_sport(skp);
     if (lport == 0) {
         log_debug("ERR(inet_csk_listen_stop): lport is 0 ");
@@ -967,12 +1200,17 @@ int kprobe__inet_csk_listen_stop(struct pt_regs *ctx) {
     pb.netns = get_netns_from_sock(skp);
     pb.port = lport;
     remove_port_bind(&pb, &port_bindings);
+    if (!bpf_map_lookup_elem(&port_bindings, &pb)) {
+        bpf_map_delete_elem(&listen_overflows, &pb);
+    }
 
     log_debug("kprobe/inet_csk_listen_stop: net ns: %u, lport: %u", pb.netns, pb.port);
     return 0;
 }
 
 static __always_inline int handle_udp_destroy_sock(void *ctx, struct sock *skp) {
+    remove_listening_socket(skp);
+
     conn_tuple_t tup = {};
     u64 pid_tgid = bpf_get_current_pid_tgid();
     int valid_tuple = read_conn_tuple(&tup, skp, pid_tgid, CONN_TYPE_UDP);
@@ -1106,4 +1344,36 @@ int tracepoint__net__net_dev_queue(struct net_dev_queue_ctx* ctx) {
     return 0;
 }
 
+#if defined(COMPILE_CORE)
+
+// the drop reason was added to the tracepoint in kernel 5.17
+SEC("tracepoint/skb/kfree_skb")
+int tracepoint__skb__kfree_skb(struct trace_event_raw_kfree_skb *args) {
+    if (!bpf_core_field_exists(args->reason)) {
+        return 0;
+    }
+    return handle_skb_drop((struct sk_buff *)args->skbaddr, args->reason);
+}
+
+#elif defined(COMPILE_RUNTIME) && LINUX_VERSION_CODE >= KERNEL_VERSION(5, 17, 0)
+
+// Represents the parameters being passed to the tracepoint skb/kfree_skb
+struct kfree_skb_ctx {
+    u64 unused;
+    void *skbaddr;
+    void *location;
+#if LINUX_VERSION_CODE >= KERNEL_VERSION(6, 10, 0)
+    void *rx_sk;
+#endif
+    unsigned short protocol;
+    __u32 reason;
+};
+
+SEC("tracepoint/skb/kfree_skb")
+int tracepoint__skb__kfree_skb(struct kfree_skb_ctx *args) {
+    return handle_skb_drop((struct sk_buff *)args->skbaddr, args->reason);
+}
+
+#endif
+
 char _license[] SEC("license") = "GPL";
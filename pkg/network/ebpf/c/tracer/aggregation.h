@@ -0,0 +1,98 @@
+#ifndef __TRACER_AGGREGATION_H
+#define __TRACER_AGGREGATION_H
+
+#if defined(COMPILE_RUNTIME) || defined(COMPILE_CORE)
+
+#ifdef COMPILE_RUNTIME
+#include <linux/cgroup-defs.h>
+#include <linux/kernfs.h>
+#endif
+
+#include "bpf_builtins.h"
+#include "bpf_core_read.h"
+#include "bpf_helpers.h"
+#include "bpf_telemetry.h"
+
+#include "tracer/tracer.h"
+#include "tracer/maps.h"
+
+static __always_inline bool is_cgroup_aggregation_enabled() {
+    __u64 val = 0;
+    LOAD_CONSTANT("cgroup_aggregation_enabled", val);
+    return val > 0;
+}
+
+// get_sock_cgroup_id returns the id of the cgroup v2 the socket was created in, falling
+// back to the cgroup of the current task on kernels which don't expose it on the socket
+static __always_inline __u64 get_sock_cgroup_id(struct sock *sk) {
+    __u64 id = 0;
+#if defined(COMPILE_CORE)
+    if (sk && bpf_core_field_exists(sk->sk_cgrp_data.cgroup)) {
+        id = BPF_CORE_READ(sk, sk_cgrp_data.cgroup, kn, id);
+    }
+#elif defined(CONFIG_SOCK_CGROUP_DATA) && LINUX_VERSION_CODE >= KERNEL_VERSION(5, 15, 0)
+    if (sk) {
+        id = BPF_CORE_READ(sk, sk_cgrp_data.cgroup, kn, id);
+    }
+#endif
+    if (!id) {
+        id = bpf_get_current_cgroup_id();
+    }
+    return id;
+}
+
+static __always_inline bool is_port_bound(conn_tuple_t *t) {
+    port_binding_t pb = {};
+    pb.netns = t->netns;
+    pb.port = t->sport;
+    __u32 *port_count = (t->metadata & CONN_TYPE_TCP) ? bpf_map_lookup_elem(&port_bindings, &pb) : bpf_map_lookup_elem(&udp_port_bindings, &pb);
+    return port_count != NULL && *port_count > 0;
+}
+
+// aggregate_conn_stats accounts the traffic to the cgroup of the socket, the remote address,
+// the server port and the protocol, instead of to the connection itself.
+// TCP segment counts are absolute values for the whole connection, so they can't be aggregated.
+static __always_inline void aggregate_conn_stats(conn_tuple_t *t, size_t sent_bytes, size_t recv_bytes, u64 ts,
+    __u32 packets_out, __u32 packets_in, packet_count_increment_t segs_type, struct sock *sk) {
+    cgroup_conn_key_t key;
+    bpf_memset(&key, 0, sizeof(key));
+    key.cgroup_id = get_sock_cgroup_id(sk);
+    key.raddr_h = t->daddr_h;
+    key.raddr_l = t->daddr_l;
+    key.netns = t->netns;
+    key.metadata = t->metadata;
+    if (is_port_bound(t)) {
+        key.direction = CONN_DIRECTION_INCOMING;
+        key.port = t->sport;
+    } else {
+        key.direction = CONN_DIRECTION_OUTGOING;
+        key.port = t->dport;
+    }
+
+    cgroup_conn_stats_t empty = {};
+    bpf_map_update_with_telemetry(cgroup_conn_stats, &key, &empty, BPF_NOEXIST);
+    cgroup_conn_stats_t *val = bpf_map_lookup_elem(&cgroup_conn_stats, &key);
+    if (!val) {
+        return;
+    }
+
+    if (sent_bytes) {
+        __sync_fetch_and_add(&val->sent_bytes, sent_bytes);
+    }
+    if (recv_bytes) {
+        __sync_fetch_and_add(&val->recv_bytes, recv_bytes);
+    }
+    if (segs_type == PACKET_COUNT_INCREMENT) {
+        if (packets_out) {
+            __sync_fetch_and_add(&val->sent_packets, packets_out);
+        }
+        if (packets_in) {
+            __sync_fetch_and_add(&val->recv_packets, packets_in);
+        }
+    }
+    val->timestamp = ts;
+}
+
+#endif // COMPILE_RUNTIME || COMPILE_CORE
+
+#endif // __TRACER_AGGREGATION_H
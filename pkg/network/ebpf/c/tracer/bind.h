@@ -90,6 +90,7 @@ static __always_inline int sys_exit_bind(__s64 ret) {
     pb.netns = get_netns_from_sock(sk);
     pb.port = sin_port;
     add_port_bind(&pb, udp_port_bindings);
+    add_listening_socket(sk, tid, CONN_TYPE_UDP);
     log_debug("sys_exit_bind: netns=%u", pb.netns);
     log_debug("sys_exit_bind: bound UDP port %u", sin_port);
 
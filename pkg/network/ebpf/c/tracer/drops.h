@@ -0,0 +1,69 @@
+#ifndef __TRACER_DROPS_H
+#define __TRACER_DROPS_H
+
+#if defined(COMPILE_RUNTIME) || defined(COMPILE_CORE)
+
+#include "bpf_core_read.h"
+#include "bpf_helpers.h"
+#include "bpf_telemetry.h"
+
+#include "tracer/tracer.h"
+#include "tracer/maps.h"
+#include "sock.h"
+
+// handle_skb_drop counts a packet dropped by the kernel for its interface and drop
+// reason, and for its connection if the packet was already attached to a socket
+static __always_inline int handle_skb_drop(struct sk_buff *skb, __u32 reason) {
+    if (!skb) {
+        return 0;
+    }
+
+    skb_drop_key_t key = {};
+    key.reason = reason;
+    struct net_device *dev = BPF_CORE_READ(skb, dev);
+    if (dev) {
+        key.ifindex = BPF_CORE_READ(dev, ifindex);
+    }
+
+    __u64 zero = 0;
+    bpf_map_update_with_telemetry(skb_drops, &key, &zero, BPF_NOEXIST);
+    __u64 *count = bpf_map_lookup_elem(&skb_drops, &key);
+    if (count) {
+        __sync_fetch_and_add(count, 1);
+    }
+
+    struct sock *sk = BPF_CORE_READ(skb, sk);
+    if (!sk) {
+        return 0;
+    }
+
+    metadata_mask_t type = 0;
+    switch (BPF_CORE_READ(sk, sk_protocol)) {
+    case IPPROTO_TCP:
+        type = CONN_TYPE_TCP;
+        break;
+    case IPPROTO_UDP:
+        type = CONN_TYPE_UDP;
+        break;
+    default:
+        return 0;
+    }
+
+    conn_tuple_t t = {};
+    if (!read_conn_tuple(&t, sk, 0, type)) {
+        return 0;
+    }
+
+    __u32 u32_zero = 0;
+    bpf_map_update_with_telemetry(conn_drops, &t, &u32_zero, BPF_NOEXIST);
+    __u32 *drops = bpf_map_lookup_elem(&conn_drops, &t);
+    if (drops) {
+        __sync_fetch_and_add(drops, 1);
+    }
+
+    return 0;
+}
+
+#endif // COMPILE_RUNTIME || COMPILE_CORE
+
+#endif // __TRACER_DROPS_H
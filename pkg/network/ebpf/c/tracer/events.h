@@ -37,10 +37,15 @@ static __always_inline void clean_protocol_classification(conn_tuple_t *tup) {
 __maybe_unused static __always_inline void submit_event(void *ctx, int cpu, void *event_data, size_t data_size) {
     __u64 ringbuffers_enabled = 0;
     LOAD_CONSTANT("ringbuffers_enabled", ringbuffers_enabled);
+    long ret = 0;
     if (ringbuffers_enabled > 0) {
-        bpf_ringbuf_output(&conn_close_event, event_data, data_size, 0);
+        ret = bpf_ringbuf_output(&conn_close_event, event_data, data_size, 0);
     } else {
-        bpf_perf_event_output(ctx, &conn_close_event, cpu, event_data, data_size);
+        ret = bpf_perf_event_output(ctx, &conn_close_event, cpu, event_data, data_size);
+    }
+    // the ring buffers don't report their lost samples to userspace, unlike the perf buffers
+    if (ret < 0) {
+        increment_telemetry_count(closed_conn_output_failed);
     }
 }
 
@@ -70,14 +75,37 @@ static __always_inline void cleanup_conn(void *ctx, conn_tuple_t *tup, struct so
         conn.tup.pid = tup->pid;
 
         conn.tcp_stats.state_transitions |= (1 << TCP_CLOSE);
+        if (sk) {
+            // the congestion state as of the close of the connection
+            read_tcp_congestion(sk, &conn.tcp_congestion);
+        }
     }
 
+    conn.tup.pid = 0;
+    u32 *drops = bpf_map_lookup_elem(&conn_drops, &(conn.tup));
+    if (drops) {
+        conn.drops = *drops;
+        bpf_map_delete_elem(&conn_drops, &(conn.tup));
+    }
+    conn.tup.pid = tup->pid;
+
     cst = bpf_map_lookup_elem(&conn_stats, &(conn.tup));
 
     if (cst) {
         conn.conn_stats = *cst;
         bpf_map_delete_elem(&conn_stats, &(conn.tup));
     } else {
+#if defined(COMPILE_RUNTIME) || defined(COMPILE_CORE)
+        if (is_cgroup_aggregation_enabled()) {
+            // the traffic of the connection was accounted to its cgroup
+            return;
+        }
+#endif
+        if (bpf_map_lookup_elem(&ignored_conns, &(conn.tup))) {
+            // the connection matched one of the ignore rules
+            bpf_map_delete_elem(&ignored_conns, &(conn.tup));
+            return;
+        }
         if (is_udp) {
             increment_telemetry_count(udp_dropped_conns);
             return; // nothing to report
@@ -86,8 +114,17 @@ static __always_inline void cleanup_conn(void *ctx, conn_tuple_t *tup, struct so
         // so cookie is not set, set it here
         conn.conn_stats.cookie = get_sk_cookie(sk);
         // make sure direction is set correctly
-        determine_connection_direction(&conn.tup, &conn.conn_stats);
+        determine_connection_direction(&conn.tup, &conn.conn_stats, sk, 0, 0);
+    }
+
+    conn.tup.pid = 0;
+    conn_qos_t *qos = bpf_map_lookup_elem(&conn_qos, &(conn.tup));
+    if (qos) {
+        conn.conn_stats.dscp = qos->dscp;
+        conn.conn_stats.flow_label = qos->flow_label;
+        bpf_map_delete_elem(&conn_qos, &(conn.tup));
     }
+    conn.tup.pid = tup->pid;
 
     // update the `duration` field to reflect the duration of the
     // connection; `duration` had the creation timestamp for
@@ -0,0 +1,68 @@
+#ifndef __TRACER_FILTER_H
+#define __TRACER_FILTER_H
+
+#include "bpf_helpers.h"
+
+#include "tracer/tracer.h"
+#include "tracer/maps.h"
+
+static __always_inline __u64 conn_filter_count() {
+    __u64 val = 0;
+    LOAD_CONSTANT("conn_filter_count", val);
+    return val;
+}
+
+static __always_inline bool conn_filter_matches_endpoint(conn_filter_t *f, __u64 addr_h, __u64 addr_l, __u16 port) {
+    if ((addr_h & f->mask_h) != f->addr_h || (addr_l & f->mask_l) != f->addr_l) {
+        return false;
+    }
+    return f->low_port == 0 || (port >= f->low_port && port <= f->high_port);
+}
+
+static __always_inline bool conn_filter_matches(conn_filter_t *f, conn_tuple_t *t) {
+    __u8 transport = CONN_FILTER_UDP;
+    if (t->metadata & CONN_TYPE_SCTP) {
+        transport = CONN_FILTER_SCTP;
+    } else if (t->metadata & CONN_TYPE_TCP) {
+        transport = CONN_FILTER_TCP;
+    }
+    __u8 family = (t->metadata & CONN_V6) ? CONN_FILTER_V6 : CONN_FILTER_V4;
+    if (!(f->flags & transport) || !(f->flags & family)) {
+        return false;
+    }
+
+    return conn_filter_matches_endpoint(f, t->saddr_h, t->saddr_l, t->sport) ||
+        conn_filter_matches_endpoint(f, t->daddr_h, t->daddr_l, t->dport);
+}
+
+// is_ignored_conn returns whether the connection matches one of the rules of network_config.ignore_conns,
+// in which case it isn't tracked. The matching connections are remembered in ignored_conns, so that the
+// rules are evaluated, and the connections counted, once per connection.
+static __always_inline bool is_ignored_conn(conn_tuple_t *t) {
+    __u64 count = conn_filter_count();
+    if (count == 0) {
+        return false;
+    }
+    if (bpf_map_lookup_elem(&ignored_conns, t)) {
+        return true;
+    }
+
+#pragma unroll
+    for (__u32 i = 0; i < MAX_CONN_FILTERS; i++) {
+        if (i >= count) {
+            break;
+        }
+        __u32 key = i;
+        conn_filter_t *f = bpf_map_lookup_elem(&conn_filters, &key);
+        if (!f || !conn_filter_matches(f, t)) {
+            continue;
+        }
+        if (bpf_map_update_elem(&ignored_conns, t, &key, BPF_NOEXIST) == 0) {
+            __sync_fetch_and_add(&f->ignored, 1);
+        }
+        return true;
+    }
+    return false;
+}
+
+#endif
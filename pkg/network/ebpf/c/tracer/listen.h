@@ -0,0 +1,57 @@
+#ifndef __TRACER_LISTEN_H
+#define __TRACER_LISTEN_H
+
+#if defined(COMPILE_RUNTIME) || defined(COMPILE_CORE)
+
+#ifdef COMPILE_RUNTIME
+#include <net/inet_connection_sock.h>
+#include <net/sock.h>
+#endif
+
+#include "bpf_core_read.h"
+#include "bpf_helpers.h"
+#include "bpf_telemetry.h"
+
+#include "tracer/tracer.h"
+#include "tracer/maps.h"
+#include "sock.h"
+
+// acceptq_is_full mirrors sk_acceptq_is_full()
+static __always_inline bool acceptq_is_full(struct sock *sk) {
+    return BPF_CORE_READ(sk, sk_ack_backlog) > BPF_CORE_READ(sk, sk_max_ack_backlog);
+}
+
+// reqsk_queue_is_full mirrors inet_csk_reqsk_queue_is_full()
+static __always_inline bool reqsk_queue_is_full(struct sock *sk) {
+    struct inet_connection_sock *icsk = (struct inet_connection_sock *)sk;
+    __u32 qlen = BPF_CORE_READ(icsk, icsk_accept_queue.qlen.counter);
+    return qlen >= BPF_CORE_READ(sk, sk_max_ack_backlog);
+}
+
+static __always_inline void count_listen_overflow(struct sock *sk, bool accept_queue, bool syn_backlog) {
+    port_binding_t pb = {};
+    pb.netns = get_netns_from_sock(sk);
+    pb.port = read_sport(sk);
+    if (pb.port == 0) {
+        return;
+    }
+
+    listen_overflow_t empty = {};
+    bpf_map_update_with_telemetry(listen_overflows, &pb, &empty, BPF_NOEXIST);
+    listen_overflow_t *val = bpf_map_lookup_elem(&listen_overflows, &pb);
+    if (!val) {
+        return;
+    }
+
+    if (accept_queue) {
+        __sync_fetch_and_add(&val->accept_queue_drops, 1);
+    }
+    if (syn_backlog) {
+        __sync_fetch_and_add(&val->syn_backlog_overflows, 1);
+    }
+    log_debug("count_listen_overflow: netns=%u, lport=%u", pb.netns, pb.port);
+}
+
+#endif // COMPILE_RUNTIME || COMPILE_CORE
+
+#endif // __TRACER_LISTEN_H
@@ -88,6 +88,76 @@ BPF_HASH_MAP(port_bindings, port_binding_t, __u32, 0)
  */
 BPF_HASH_MAP(udp_port_bindings, port_binding_t, __u32, 0)
 
+/* Will hold the TCP sockets in the LISTEN state and the bound UDP sockets
+ * Key: the socket
+ * Value: a tuple describing the local endpoint of the socket, along with the PID which opened it
+ */
+BPF_HASH_MAP(listening_sockets, struct sock *, conn_tuple_t, 0)
+
+/* Will hold the SCTP associations initiated by this host */
+BPF_HASH_MAP(sctp_initiated_assocs, struct sctp_association *, __u8, 1024)
+
+/* This map is used to count the overflows of the accept queue and SYN backlog of listening sockets */
+BPF_HASH_MAP(listen_overflows, port_binding_t, listen_overflow_t, 0)
+
+/* This map is used to count the packets dropped by the kernel for each connection.
+ * Like tcp_retransmits, the pid is not part of the key.
+ */
+BPF_HASH_MAP(conn_drops, conn_tuple_t, __u32, 0)
+
+/* This map holds the QoS marking of the packets sent on each connection, when enabled.
+ * Like tcp_retransmits, the pid is not part of the key.
+ */
+BPF_HASH_MAP(conn_qos, conn_tuple_t, conn_qos_t, 0)
+
+/* This map holds the process which created each connection. The entries are not deleted
+ * when the connection is closed, but once userspace has read them, hence the LRU.
+ */
+BPF_LRU_MAP(conn_process, conn_tuple_t, conn_process_t, 0)
+
+/* This map holds the histogram of the RTT samples of each TCP connection, when enabled.
+ * As for conn_process, the entries are left for userspace to read once the connection
+ * is closed, hence the LRU.
+ */
+BPF_LRU_MAP(tcp_rtt_histograms, conn_tuple_t, rtt_histogram_t, 0)
+
+/* This map holds the metadata of the TLS handshakes, keyed by the normalized tuple of the socket filter
+ * (without pid and netns). As for conn_process, the entries are left for userspace to read once the
+ * connection is closed, hence the LRU.
+ */
+BPF_LRU_MAP(tls_handshake_info, conn_tuple_t, tls_info_t, 0)
+
+/* This map holds the WebSocket sessions, keyed like tls_handshake_info */
+BPF_LRU_MAP(websocket_sessions, conn_tuple_t, websocket_session_t, 0)
+
+/* This map is used to count the packets dropped by the kernel by interface and drop reason
+ * It is turned into a BPF_MAP_TYPE_PERCPU_HASH map at load time when supported
+ */
+BPF_HASH_MAP(skb_drops, skb_drop_key_t, __u64, 1024)
+
+/* This map is used to aggregate the traffic by cgroup, destination, port and protocol
+ * when the per connection statistics are disabled
+ */
+BPF_HASH_MAP(cgroup_conn_stats, cgroup_conn_key_t, cgroup_conn_stats_t, 0)
+
+/* This map is used to track the traffic of AF_UNIX sockets
+ * Key: the inode number of the socket
+ * Value: the traffic sent through the socket, along with its bound path
+ */
+BPF_HASH_MAP(unix_sock_stats, __u64, unix_sock_stats_t, 0)
+
+/*
+ * Map to hold struct socket parameter for unix_stream_sendmsg/unix_dgram_sendmsg calls
+ * to be used in the matching kretprobes
+ */
+BPF_HASH_MAP(unix_sendmsg_args, __u64, struct socket *, 1024)
+
+/*
+ * Map to hold struct sock parameter for inet_csk_listen_start calls
+ * to be used in kretprobe/inet_csk_listen_start
+ */
+BPF_HASH_MAP(inet_csk_listen_start_args, __u64, struct sock *, 1024)
+
 /* Similar to pending_sockets this is used for capturing state between the call and return of the bind() system call.
  *
  * Keys: the PID returned by bpf_get_current_pid_tgid()
@@ -95,12 +165,29 @@ BPF_HASH_MAP(udp_port_bindings, port_binding_t, __u32, 0)
  */
 BPF_HASH_MAP(pending_bind, __u64, bind_syscall_args_t, 8192)
 
+/* This map holds the rules of network_config.ignore_conns, the first conn_filter_count entries being set */
+BPF_ARRAY_MAP(conn_filters, conn_filter_t, MAX_CONN_FILTERS)
+
+/* This map holds the connections matching one of the conn_filters, which aren't tracked. The rules
+ * are only evaluated for the connections which aren't in there yet, so that each connection is
+ * counted once. The entries are deleted when the connections are closed, or evicted.
+ * Value: the index of the matching rule
+ */
+BPF_LRU_MAP(ignored_conns, conn_tuple_t, __u32, 0)
+
 /* This map is used for telemetry in kernelspace
  * only key 0 is used
  * value is a telemetry object
+ * It is turned into a BPF_MAP_TYPE_PERCPU_ARRAY map at load time when supported
  */
 BPF_ARRAY_MAP(telemetry, telemetry_t, 1)
 
+/* This map holds the ABI of the programs, so that the userspace reusing their pinned maps
+ * can check that it expects the same layout and features
+ * only key 0 is used
+ */
+BPF_ARRAY_MAP(tracer_abi, tracer_abi_t, 1)
+
 /* Similar to pending_sockets this is used for capturing state between the call and return of the tcp_retransmit_skb() system call.
  *
  * Keys: the PID returned by bpf_get_current_pid_tgid()
@@ -2,6 +2,8 @@
 #define __TRACER_PORT_H
 
 #include "tracer/tracer.h"
+#include "tracer/maps.h"
+#include "sock.h"
 
 #define add_port_bind(pb, pb_map)                                   \
     do {                                                            \
@@ -28,4 +30,22 @@ static __always_inline void remove_port_bind(port_binding_t *pb, void *pb_map) {
     }
 }
 
+static __always_inline void add_listening_socket(struct sock *skp, u64 pid_tgid, metadata_mask_t type) {
+    conn_tuple_t t = {};
+    // listening sockets have no destination, so the
+    // tuple can only ever be partially read
+    read_conn_tuple_partial(&t, skp, pid_tgid, type);
+    if (t.sport == 0) {
+        log_debug("ERR(add_listening_socket): lport is 0");
+        return;
+    }
+
+    bpf_map_update_with_telemetry(listening_sockets, &skp, &t, BPF_ANY);
+    log_debug("add_listening_socket: netns=%u, lport=%u", t.netns, t.sport);
+}
+
+static __always_inline void remove_listening_socket(struct sock *skp) {
+    bpf_map_delete_elem(&listening_sockets, &skp);
+}
+
 #endif
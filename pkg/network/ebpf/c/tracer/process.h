@@ -0,0 +1,103 @@
+#ifndef __TRACER_PROCESS_H
+#define __TRACER_PROCESS_H
+
+#if defined(COMPILE_RUNTIME) || defined(COMPILE_CORE)
+
+#ifdef COMPILE_RUNTIME
+#include <linux/dcache.h>
+#include <linux/fs.h>
+#include <linux/mm_types.h>
+#include <linux/sched.h>
+#endif
+
+#include "bpf_builtins.h"
+#include "bpf_core_read.h"
+#include "bpf_helpers.h"
+#include "bpf_telemetry.h"
+
+#include "tracer/tracer.h"
+#include "tracer/maps.h"
+#include "tracer/aggregation.h"
+
+#define CMDLINE_HASH_MAX_WORDS 8
+#define FNV_OFFSET_BASIS_32 2166136261
+#define FNV_PRIME_32 16777619
+
+static __always_inline bool is_conn_process_enabled() {
+    __u64 val = 0;
+    LOAD_CONSTANT("conn_process_enabled", val);
+    return val > 0;
+}
+
+// hash_cmdline computes the FNV-1a hash of the first bytes of the command line of the task,
+// read 8 bytes at a time
+static __always_inline __u32 hash_cmdline(struct task_struct *task) {
+    struct mm_struct *mm = BPF_CORE_READ(task, mm);
+    if (!mm) {
+        return 0;
+    }
+
+    unsigned long start = BPF_CORE_READ(mm, arg_start);
+    unsigned long end = BPF_CORE_READ(mm, arg_end);
+    if (end <= start) {
+        return 0;
+    }
+
+    __u64 len = end - start;
+    __u32 hash = FNV_OFFSET_BASIS_32;
+#pragma unroll
+    for (int i = 0; i < CMDLINE_HASH_MAX_WORDS; i++) {
+        __u64 offset = i * sizeof(__u64);
+        if (offset >= len) {
+            break;
+        }
+
+        __u64 word = 0;
+        if (bpf_probe_read_user(&word, sizeof(word), (void *)(start + offset)) < 0) {
+            break;
+        }
+        // don't hash the environment which follows the command line
+        __u64 remaining = len - offset;
+        if (remaining < sizeof(word)) {
+            word &= (1ULL << (remaining * 8)) - 1;
+        }
+
+        hash ^= (__u32)word;
+        hash *= FNV_PRIME_32;
+        hash ^= (__u32)(word >> 32);
+        hash *= FNV_PRIME_32;
+    }
+    return hash;
+}
+
+// record_conn_process captures the process which created the connection, so that it
+// can be attributed even if the process exits before userspace reads the connection
+static __always_inline void record_conn_process(conn_tuple_t *t, struct sock *sk) {
+    if (!is_conn_process_enabled()) {
+        return;
+    }
+
+    // the stats of a connection can be created from a softirq, in which
+    // case the current task has nothing to do with the connection
+    if (t->pid == 0 || t->pid != bpf_get_current_pid_tgid() >> 32) {
+        return;
+    }
+
+    conn_process_t empty = {};
+    bpf_memset(&empty, 0, sizeof(conn_process_t));
+    bpf_map_update_with_telemetry(conn_process, t, &empty, BPF_NOEXIST);
+    conn_process_t *proc = bpf_map_lookup_elem(&conn_process, t);
+    if (!proc) {
+        return;
+    }
+
+    struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+    proc->cgroup_id = get_sock_cgroup_id(sk);
+    proc->cmdline_hash = hash_cmdline(task);
+    bpf_get_current_comm(proc->comm, sizeof(proc->comm));
+    BPF_CORE_READ_STR_INTO(&proc->exe, task, mm, exe_file, f_path.dentry, d_name.name);
+}
+
+#endif // COMPILE_RUNTIME || COMPILE_CORE
+
+#endif // __TRACER_PROCESS_H
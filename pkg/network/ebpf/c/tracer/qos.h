@@ -0,0 +1,98 @@
+#ifndef __TRACER_QOS_H
+#define __TRACER_QOS_H
+
+#if defined(COMPILE_RUNTIME) || defined(COMPILE_CORE)
+
+#include "bpf_core_read.h"
+#include "bpf_endian.h"
+#include "bpf_helpers.h"
+#include "bpf_telemetry.h"
+
+#include "tracer/tracer.h"
+#include "tracer/maps.h"
+#include "sock.h"
+#include "skb.h"
+
+#define IPV6_FLOW_LABEL_MASK 0x000FFFFF
+
+static __always_inline bool is_qos_marking_enabled() {
+    __u64 val = 0;
+    LOAD_CONSTANT("qos_marking_enabled", val);
+    return val > 0;
+}
+
+// read_skb_qos reads the DSCP marking and, for IPv6, the flow label from the IP header of the packet
+static __always_inline bool read_skb_qos(struct sk_buff *skb, conn_qos_t *qos) {
+    unsigned char *head = sk_buff_head(skb);
+    u16 net_head = sk_buff_network_header(skb);
+    if (!head || !net_head) {
+        return false;
+    }
+
+    // IPv4: version (4 bits), IHL (4 bits), TOS (8 bits)
+    // IPv6: version (4 bits), traffic class (8 bits), flow label (20 bits)
+    __u32 word = 0;
+    if (bpf_probe_read_kernel(&word, sizeof(word), head + net_head) < 0) {
+        return false;
+    }
+    word = bpf_ntohl(word);
+
+    switch (word >> 28) {
+    case 4:
+        qos->dscp = (word >> 16 & 0xff) >> 2;
+        return true;
+    case 6:
+        qos->dscp = (word >> 20 & 0xff) >> 2;
+        qos->flow_label = word & IPV6_FLOW_LABEL_MASK;
+        return true;
+    }
+    return false;
+}
+
+// handle_skb_qos records the QoS marking of a packet sent on a connection. The IP header is read
+// once the packet went through the netfilter LOCAL_OUT hook, so that the marking set by the
+// firewall rules is reported along with the one set on the socket.
+static __always_inline int handle_skb_qos(struct sock *sk, struct sk_buff *skb) {
+    if (!sk || !skb || !is_qos_marking_enabled()) {
+        return 0;
+    }
+
+    metadata_mask_t type = 0;
+    switch (BPF_CORE_READ(sk, sk_protocol)) {
+    case IPPROTO_TCP:
+        type = CONN_TYPE_TCP;
+        break;
+    case IPPROTO_UDP:
+        type = CONN_TYPE_UDP;
+        break;
+    default:
+        return 0;
+    }
+
+    conn_tuple_t t = {};
+    if (!read_conn_tuple(&t, sk, 0, type)) {
+        // unconnected UDP sockets, the destination is only known from the packet
+        bpf_memset(&t, 0, sizeof(t));
+        if (sk_buff_to_tuple(skb, &t) <= 0) {
+            return 0;
+        }
+        t.netns = get_netns_from_sock(sk);
+    }
+
+    conn_qos_t qos = {};
+    if (!read_skb_qos(skb, &qos)) {
+        return 0;
+    }
+
+    // the marking rarely changes, so the map is only written when it does
+    conn_qos_t *current = bpf_map_lookup_elem(&conn_qos, &t);
+    if (current && current->dscp == qos.dscp && current->flow_label == qos.flow_label) {
+        return 0;
+    }
+    bpf_map_update_with_telemetry(conn_qos, &t, &qos, BPF_ANY);
+    return 0;
+}
+
+#endif // COMPILE_RUNTIME || COMPILE_CORE
+
+#endif // __TRACER_QOS_H
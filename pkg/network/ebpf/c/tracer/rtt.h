@@ -0,0 +1,46 @@
+#ifndef __TRACER_RTT_H
+#define __TRACER_RTT_H
+
+#include "bpf_helpers.h"
+#include "bpf_telemetry.h"
+
+#include "tracer/tracer.h"
+#include "tracer/maps.h"
+
+static __always_inline bool is_rtt_histogram_enabled() {
+    __u64 val = 0;
+    LOAD_CONSTANT("rtt_histogram_enabled", val);
+    return val > 0;
+}
+
+// record_rtt_sample adds a RTT sample, in µs, to the histogram of the connection. Bucket i holds the
+// samples in [2^i, 2^(i+1)) µs, the first bucket also holds the samples under 1µs and the last one all
+// the samples from 2^(RTT_HISTOGRAM_BUCKETS-1) µs.
+static __always_inline void record_rtt_sample(conn_tuple_t *t, __u32 rtt_us) {
+    __u32 bucket = 0;
+#pragma unroll
+    for (int i = 0; i < RTT_HISTOGRAM_BUCKETS - 1; i++) {
+        if (rtt_us < 2) {
+            break;
+        }
+        rtt_us >>= 1;
+        bucket++;
+    }
+    if (bucket >= RTT_HISTOGRAM_BUCKETS) {
+        return;
+    }
+
+    rtt_histogram_t empty = {};
+    bpf_map_update_with_telemetry(tcp_rtt_histograms, t, &empty, BPF_NOEXIST);
+    rtt_histogram_t *val = bpf_map_lookup_elem(&tcp_rtt_histograms, t);
+    if (val == NULL) {
+        return;
+    }
+
+    // the counters saturate rather than wrap
+    if (val->buckets[bucket] < 0xffff) {
+        val->buckets[bucket]++;
+    }
+}
+
+#endif
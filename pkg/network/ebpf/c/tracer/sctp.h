@@ -0,0 +1,91 @@
+#ifndef __TRACER_SCTP_H
+#define __TRACER_SCTP_H
+
+#if defined(COMPILE_RUNTIME) || defined(COMPILE_CORE)
+
+#ifdef COMPILE_RUNTIME
+#include <net/sctp/structs.h>
+#endif
+
+#include "bpf_core_read.h"
+#include "bpf_endian.h"
+
+#include "tracer/tracer.h"
+#include "tracer/maps.h"
+#include "tracer/stats.h"
+#include "ipv6.h"
+#include "sock.h"
+
+// read_sctp_conn_tuple builds the tuple of an association from its primary path.
+// SCTP chunks are mostly processed in softirq context, so the tuple is never
+// attributed to a process. Returns 1 on success, 0 otherwise.
+static __always_inline int read_sctp_conn_tuple(conn_tuple_t *t, struct sctp_association *asoc) {
+    bpf_memset(t, 0, sizeof(conn_tuple_t));
+    t->metadata = CONN_TYPE_SCTP;
+
+    struct sock *sk = BPF_CORE_READ(asoc, base.sk);
+    t->netns = get_netns_from_sock(sk);
+    // both ports are kept in host byte order by the kernel
+    BPF_CORE_READ_INTO(&t->sport, asoc, base.bind_addr.port);
+    BPF_CORE_READ_INTO(&t->dport, asoc, peer.port);
+
+    struct sctp_transport *primary = BPF_CORE_READ(asoc, peer.primary_path);
+    if (!primary) {
+        return 0;
+    }
+
+    union sctp_addr saddr = {};
+    union sctp_addr daddr = {};
+    BPF_CORE_READ_INTO(&saddr, primary, saddr);
+    BPF_CORE_READ_INTO(&daddr, asoc, peer.primary_addr);
+
+    if (daddr.sa.sa_family == AF_INET) {
+        t->metadata |= CONN_V4;
+        t->saddr_l = saddr.v4.sin_addr.s_addr;
+        t->daddr_l = daddr.v4.sin_addr.s_addr;
+    } else if (daddr.sa.sa_family == AF_INET6) {
+        t->metadata |= CONN_V6;
+        read_in6_addr(&t->saddr_h, &t->saddr_l, &saddr.v6.sin6_addr);
+        read_in6_addr(&t->daddr_h, &t->daddr_l, &daddr.v6.sin6_addr);
+    } else {
+        return 0;
+    }
+
+    if (t->sport == 0 || t->dport == 0 || !(t->daddr_h || t->daddr_l)) {
+        log_debug("ERR(read_sctp_conn_tuple): incomplete tuple sport=%u dport=%u", t->sport, t->dport);
+        return 0;
+    }
+
+    return 1;
+}
+
+static __always_inline int handle_sctp_chunk(struct sctp_association *asoc, struct sctp_chunk *chunk, bool sent) {
+    if (!asoc || !chunk) {
+        return 0;
+    }
+
+    conn_tuple_t t = {};
+    if (!read_sctp_conn_tuple(&t, asoc)) {
+        return 0;
+    }
+
+    struct sctp_chunkhdr *hdr = BPF_CORE_READ(chunk, chunk_hdr);
+    if (!hdr) {
+        return 0;
+    }
+    __u16 len = 0;
+    BPF_CORE_READ_INTO(&len, hdr, length);
+    len = bpf_ntohs(len);
+
+    conn_direction_t dir = bpf_map_lookup_elem(&sctp_initiated_assocs, &asoc) ? CONN_DIRECTION_OUTGOING : CONN_DIRECTION_INCOMING;
+    struct sock *sk = BPF_CORE_READ(asoc, base.sk);
+    // each chunk is accounted as a packet
+    if (sent) {
+        return handle_message(&t, len, 0, dir, 1, 0, PACKET_COUNT_INCREMENT, sk);
+    }
+    return handle_message(&t, 0, len, dir, 0, 1, PACKET_COUNT_INCREMENT, sk);
+}
+
+#endif // COMPILE_RUNTIME || COMPILE_CORE
+
+#endif // __TRACER_SCTP_H
@@ -8,6 +8,11 @@
 #include "tracer/tracer.h"
 #include "tracer/maps.h"
 #include "tracer/telemetry.h"
+#include "tracer/aggregation.h"
+#include "tracer/filter.h"
+#include "tracer/process.h"
+#include "tracer/qos.h"
+#include "tracer/rtt.h"
 #include "cookie.h"
 #include "sock.h"
 #include "port_range.h"
@@ -17,6 +22,10 @@
 #include "ip.h"
 #include "skb.h"
 
+#ifdef COMPILE_RUNTIME
+#include <net/tcp.h>
+#endif
+
 #ifdef COMPILE_PREBUILT
 static __always_inline __u64 offset_rtt();
 static __always_inline __u64 offset_rtt_var();
@@ -27,6 +36,9 @@ static __always_inline conn_stats_ts_t *get_conn_stats(conn_tuple_t *t, struct s
     if (cs) {
         return cs;
     }
+    if (is_ignored_conn(t)) {
+        return NULL;
+    }
 
     // initialize-if-no-exist the connection stat, and load it
     conn_stats_ts_t empty = {};
@@ -34,6 +46,9 @@ static __always_inline conn_stats_ts_t *get_conn_stats(conn_tuple_t *t, struct s
     empty.duration = bpf_ktime_get_ns();
     empty.cookie = get_sk_cookie(sk);
     bpf_map_update_with_telemetry(conn_stats, t, &empty, BPF_NOEXIST);
+#if defined(COMPILE_RUNTIME) || defined(COMPILE_CORE)
+    record_conn_process(t, sk);
+#endif
     return bpf_map_lookup_elem(&conn_stats, t);
 }
 
@@ -119,20 +134,55 @@ static __always_inline void update_protocol_classification_information(conn_tupl
     merge_protocol_stacks(&stats->protocol_stack, protocol_stack);
 }
 
-static __always_inline void determine_connection_direction(conn_tuple_t *t, conn_stats_ts_t *conn_stats) {
+// determine_udp_connection_direction sets the direction of UDP flows using, in order:
+// * the bind state of the socket: unconnected sockets explicitly bound to a port are likely serving requests;
+// * the port bindings, which also include the ports bound before the tracer was started;
+// * the first packet seen on the flow, since unconnected sockets have no other state to rely on.
+// The bindings are skipped for connected sockets, as clients may bind a fixed source port before connecting.
+// The directions inferred from a binding are not flagged as observed, as unconnected clients may bind a fixed
+// source port too (NTP, QUIC), so that they can still be fixed in user space for intra-host flows.
+static __always_inline void determine_udp_connection_direction(conn_tuple_t *t, conn_stats_ts_t *conn_stats, struct sock *sk, size_t sent_bytes, size_t recv_bytes) {
+    // a connected socket has a destination port
+    if (!sk || read_dport(sk) == 0) {
+        if (sk && bpf_map_lookup_elem(&listening_sockets, &sk)) {
+            conn_stats->direction = CONN_DIRECTION_INCOMING;
+            return;
+        }
+
+        port_binding_t pb = {};
+        pb.port = t->sport;
+        pb.netns = t->netns;
+        u32 *port_count = bpf_map_lookup_elem(&udp_port_bindings, &pb);
+        if (port_count != NULL && *port_count > 0) {
+            conn_stats->direction = CONN_DIRECTION_INCOMING;
+            return;
+        }
+    }
+
+    if (recv_bytes == 0 && sent_bytes == 0) {
+        // nothing observed yet, wait for the first packet
+        return;
+    }
+
+    conn_stats->direction = (recv_bytes > 0 && sent_bytes == 0) ? CONN_DIRECTION_INCOMING : CONN_DIRECTION_OUTGOING;
+    conn_stats->flags |= CONN_DIRECTION_OBSERVED;
+}
+
+static __always_inline void determine_connection_direction(conn_tuple_t *t, conn_stats_ts_t *conn_stats, struct sock *sk, size_t sent_bytes, size_t recv_bytes) {
     if (conn_stats->direction != CONN_DIRECTION_UNKNOWN) {
         return;
     }
 
+    if (!(t->metadata & CONN_TYPE_TCP)) {
+        determine_udp_connection_direction(t, conn_stats, sk, sent_bytes, recv_bytes);
+        return;
+    }
+
     u32 *port_count = NULL;
     port_binding_t pb = {};
     pb.port = t->sport;
     pb.netns = t->netns;
-    if (t->metadata & CONN_TYPE_TCP) {
-        port_count = bpf_map_lookup_elem(&port_bindings, &pb);
-    } else {
-        port_count = bpf_map_lookup_elem(&udp_port_bindings, &pb);
-    }
+    port_count = bpf_map_lookup_elem(&port_bindings, &pb);
     conn_stats->direction = (port_count != NULL && *port_count > 0) ? CONN_DIRECTION_INCOMING : CONN_DIRECTION_OUTGOING;
 }
 
@@ -151,9 +201,11 @@ static __always_inline void update_conn_stats(conn_tuple_t *t, size_t sent_bytes
     update_conn_state(t, val, sent_bytes, recv_bytes);
     if (sent_bytes) {
         __sync_fetch_and_add(&val->sent_bytes, sent_bytes);
+        val->last_sent_ts = ts;
     }
     if (recv_bytes) {
         __sync_fetch_and_add(&val->recv_bytes, recv_bytes);
+        val->last_recv_ts = ts;
     }
     if (packets_in) {
         if (segs_type == PACKET_COUNT_INCREMENT) {
@@ -174,7 +226,7 @@ static __always_inline void update_conn_stats(conn_tuple_t *t, size_t sent_bytes
     if (dir != CONN_DIRECTION_UNKNOWN) {
         val->direction = dir;
     } else {
-        determine_connection_direction(t, val);
+        determine_connection_direction(t, val, sk, sent_bytes, recv_bytes);
     }
 }
 
@@ -192,18 +244,58 @@ static __always_inline void update_tcp_stats(conn_tuple_t *t, tcp_stats_t stats)
     if (stats.rtt > 0) {
         // For more information on the bit shift operations see:
         // https://elixir.bootlin.com/linux/v4.6/source/net/ipv4/tcp.c#L2686
-        val->rtt = stats.rtt >> 3;
+        __u32 rtt = stats.rtt >> 3;
+        // the smoothed RTT is updated by the kernel with each RTT sample, so each change seen by the
+        // probes is recorded as a sample
+        if (rtt != val->rtt && is_rtt_histogram_enabled()) {
+            record_rtt_sample(t, rtt);
+        }
+        val->rtt = rtt;
         val->rtt_var = stats.rtt_var >> 2;
     }
 
     if (stats.state_transitions > 0) {
         val->state_transitions |= stats.state_transitions;
     }
+
+    if (stats.failure_reason > 0) {
+        val->failure_reason = stats.failure_reason;
+    }
+}
+
+// read_tcp_congestion reads the congestion control state of the socket, as reported by tcp_get_info().
+// It is only read when the connection is flushed on close, to keep it off the send and receive paths.
+static __always_inline void read_tcp_congestion(struct sock *sk, tcp_congestion_t *stats) {
+#if defined(COMPILE_RUNTIME) || defined(COMPILE_CORE)
+    struct tcp_sock *tp = tcp_sk(sk);
+    BPF_CORE_READ_INTO(&stats->cwnd, tp, snd_cwnd);
+    BPF_CORE_READ_INTO(&stats->ssthresh, tp, snd_ssthresh);
+
+#if defined(COMPILE_CORE) || LINUX_VERSION_CODE >= KERNEL_VERSION(4, 9, 0)
+    // the delivery rate estimation was added in 4.9
+    __u32 delivered = 0, interval_us = 0, mss = 0;
+    BPF_CORE_READ_INTO(&delivered, tp, rate_delivered);
+    BPF_CORE_READ_INTO(&interval_us, tp, rate_interval_us);
+    BPF_CORE_READ_INTO(&mss, tp, mss_cache);
+    if (delivered > 0 && interval_us > 0) {
+        stats->delivery_rate = (__u64)delivered * mss * 1000000 / interval_us;
+    }
+#endif
+
+    struct inet_connection_sock *icsk = (struct inet_connection_sock *)sk;
+    BPF_CORE_READ_STR_INTO(&stats->cong_algo, icsk, icsk_ca_ops, name);
+#endif
 }
 
 static __always_inline int handle_message(conn_tuple_t *t, size_t sent_bytes, size_t recv_bytes, conn_direction_t dir,
     __u32 packets_out, __u32 packets_in, packet_count_increment_t segs_type, struct sock *sk) {
     u64 ts = bpf_ktime_get_ns();
+#if defined(COMPILE_RUNTIME) || defined(COMPILE_CORE)
+    if (is_cgroup_aggregation_enabled()) {
+        aggregate_conn_stats(t, sent_bytes, recv_bytes, ts, packets_out, packets_in, segs_type, sk);
+        return 0;
+    }
+#endif
     update_conn_stats(t, sent_bytes, recv_bytes, ts, dir, packets_out, packets_in, segs_type, sk);
     return 0;
 }
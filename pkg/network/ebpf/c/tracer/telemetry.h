@@ -22,6 +22,7 @@ enum telemetry_counter
     udp_send_processed,
     udp_send_missed,
     udp_dropped_conns,
+    closed_conn_output_failed,
 };
 
 static __always_inline void increment_telemetry_count(enum telemetry_counter counter_name) {
@@ -51,6 +52,9 @@ static __always_inline void increment_telemetry_count(enum telemetry_counter cou
     case udp_dropped_conns:
         __sync_fetch_and_add(&val->udp_dropped_conns, 1);
         break;
+    case closed_conn_output_failed:
+        __sync_fetch_and_add(&val->closed_conn_output_failed, 1);
+        break;
     }
 }
 
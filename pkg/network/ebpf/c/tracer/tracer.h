@@ -39,6 +39,10 @@ typedef struct {
     // is removed from the conn_stats map when it
     // is updated with (CURRENT_TIME - duration)
     __u64 duration;
+    // timestamps of the last time data was sent and received
+    // on the connection, 0 if no data was transferred yet
+    __u64 last_sent_ts;
+    __u64 last_recv_ts;
     // "cookie" that uniquely identifies
     // a conn_stas_ts_t. This is used
     // in user space to distinguish between
@@ -51,6 +55,9 @@ typedef struct {
     protocol_stack_t protocol_stack;
     __u8 flags;
     __u8 direction;
+    // DSCP marking of the traffic sent, and flow label for IPv6 connections
+    __u8 dscp;
+    __u32 flow_label;
 } conn_stats_ts_t;
 
 // Connection flags
@@ -58,23 +65,55 @@ typedef enum
 {
     CONN_L_INIT = 1 << 0, // initial/first message sent
     CONN_R_INIT = 1 << 1, // reply received for initial message from remote
-    CONN_ASSURED = 1 << 2 // "3-way handshake" complete, i.e. response to initial reply sent
+    CONN_ASSURED = 1 << 2, // "3-way handshake" complete, i.e. response to initial reply sent
+    CONN_DIRECTION_OBSERVED = 1 << 3, // direction taken from the first packet rather than guessed from the socket bindings
+    CONN_PRE_EXISTING = 1 << 4, // seeded from procfs, the connection was established before the tracer started
 } conn_flags_t;
 
+#define TCP_CA_NAME_MAX 16
+#define RTT_HISTOGRAM_BUCKETS 24
+
 typedef struct {
     __u32 rtt;
     __u32 rtt_var;
 
     // Bit mask containing all TCP state transitions tracked by our tracer
     __u16 state_transitions;
+
+    // errno reported by the kernel if the connection attempt failed
+    __u16 failure_reason;
 } tcp_stats_t;
 
+// congestion state of a TCP connection as of its close, only collected by the runtime compiled and CO-RE
+// tracers. It is read when the connection is flushed, so it isn't kept in the tcp_stats map.
+typedef struct {
+    __u32 cwnd;
+    __u32 ssthresh;
+    // bytes per second, as reported in tcp_info.tcpi_delivery_rate
+    __u64 delivery_rate;
+    char cong_algo[TCP_CA_NAME_MAX];
+} tcp_congestion_t;
+
+// log2 histogram of the RTT samples of a TCP connection in µs, kept out of tcp_stats_t
+// since it is only collected if enabled
+typedef struct {
+    __u16 buckets[RTT_HISTOGRAM_BUCKETS];
+} rtt_histogram_t;
+
+// TCP connection attempt failure reasons, as reported by the kernel in sk->sk_err
+#define TCP_CONN_FAILED_RESET 104 // ECONNRESET
+#define TCP_CONN_FAILED_TIMEOUT 110 // ETIMEDOUT
+#define TCP_CONN_FAILED_REFUSED 111 // ECONNREFUSED
+
 // Full data for a tcp connection
 typedef struct {
     conn_tuple_t tup;
     conn_stats_ts_t conn_stats;
     tcp_stats_t tcp_stats;
+    tcp_congestion_t tcp_congestion;
     __u32 tcp_retransmits;
+    // packets of the connection dropped by the kernel
+    __u32 drops;
 } conn_t;
 
 // Must match the number of conn_t objects embedded in the batch_t struct
@@ -104,8 +143,18 @@ typedef struct {
     __u64 udp_sends_processed;
     __u64 udp_sends_missed;
     __u64 udp_dropped_conns;
+    // closed connection events, single connections or batches, which couldn't be written to the perf or ring buffer
+    __u64 closed_conn_output_failed;
 } telemetry_t;
 
+// ABI of the eBPF programs, written by userspace when the programs load
+typedef struct {
+    // version of the layout of the structs shared with userspace
+    __u64 version;
+    // bitmap of the optional fields and events negotiated with userspace
+    __u64 features;
+} tracer_abi_t;
+
 typedef struct {
     struct sockaddr *addr;
     struct sock *sk;
@@ -136,4 +185,150 @@ typedef struct {
     };
 } ip_make_skb_args_t;
 
+// key of the traffic aggregated by cgroup, which replaces the
+// per connection statistics when cgroup aggregation is enabled
+typedef struct {
+    __u64 cgroup_id;
+    __u64 raddr_h;
+    __u64 raddr_l;
+    __u32 netns;
+    // type and family, as in conn_tuple_t
+    __u32 metadata;
+    // the remote port of outgoing traffic, or the local port of incoming traffic
+    __u16 port;
+    __u8 direction;
+} cgroup_conn_key_t;
+
+typedef struct {
+    __u64 sent_bytes;
+    __u64 recv_bytes;
+    __u64 sent_packets;
+    __u64 recv_packets;
+    __u64 timestamp;
+} cgroup_conn_stats_t;
+
+// QoS marking of the packets sent on a connection, as read from their IP header
+typedef struct {
+    __u8 dscp;
+    // IPv6 only
+    __u32 flow_label;
+} conn_qos_t;
+
+// key of the packet drops counted by interface and drop reason
+typedef struct {
+    __u32 ifindex;
+    // enum skb_drop_reason
+    __u32 reason;
+} skb_drop_key_t;
+
+// overflows of the queues of a listening TCP socket, keyed by its port binding
+typedef struct {
+    // connections dropped because the accept queue was full
+    __u32 accept_queue_drops;
+    // SYNs received while the SYN backlog was full. They are dropped,
+    // unless they can be answered with a SYN cookie.
+    __u32 syn_backlog_overflows;
+} listen_overflow_t;
+
+#define PROCESS_COMM_MAX 16
+#define PROCESS_EXE_NAME_MAX 32
+
+// the process which created a connection, captured when its stats are first created
+typedef struct {
+    // cgroup v2 id of the socket, from which the container is resolved
+    __u64 cgroup_id;
+    // hash of the beginning of the command line
+    __u32 cmdline_hash;
+    char comm[PROCESS_COMM_MAX];
+    // name of the executable file, without its directory
+    char exe[PROCESS_EXE_NAME_MAX];
+} conn_process_t;
+
+#define TLS_SERVER_NAME_MAX 64
+#define TLS_ALPN_MAX 16
+
+// Flags of the TLS versions offered by the client
+typedef enum
+{
+    TLS_VERSION10_OFFERED = 1 << 0,
+    TLS_VERSION11_OFFERED = 1 << 1,
+    TLS_VERSION12_OFFERED = 1 << 2,
+    TLS_VERSION13_OFFERED = 1 << 3,
+} tls_version_offered_t;
+
+// the metadata of the TLS handshake of a connection, read from its ClientHello and ServerHello
+typedef struct {
+    // SNI sent by the client, truncated to TLS_SERVER_NAME_MAX - 1 bytes
+    char server_name[TLS_SERVER_NAME_MAX];
+    // protocol selected by the server, or the first one offered by the client
+    // if the server's selection is encrypted (TLS 1.3)
+    char alpn[TLS_ALPN_MAX];
+    __u16 chosen_version;
+    __u16 cipher_suite;
+    __u8 offered_versions;
+} tls_info_t;
+
+// the WebSocket session of a connection upgraded by an HTTP 101 response
+typedef struct {
+    __u64 upgraded_ns;
+    __u64 last_seen_ns;
+    // messages are counted from the frames starting them, control frames excluded
+    __u64 client_messages;
+    __u64 server_messages;
+    // payload bytes sent by each side since the upgrade
+    __u64 client_bytes;
+    __u64 server_bytes;
+    // bytes of the frame in progress which follow the last segment of each side
+    __u64 client_frame_remaining;
+    __u64 server_frame_remaining;
+    // port of the server, which sent the upgrade response
+    __u16 server_port;
+} websocket_session_t;
+
+#define UNIX_SOCK_PATH_MAX 108
+
+// traffic statistics of an AF_UNIX socket, keyed by the inode number of the socket
+typedef struct {
+    __u64 peer_ino;
+    __u64 sent_bytes;
+    __u64 sent_packets;
+    __u64 timestamp;
+    __u32 pid;
+    // length of the path, which starts with a NUL byte for abstract sockets
+    __u16 path_len;
+    // SOCK_STREAM, SOCK_DGRAM or SOCK_SEQPACKET
+    __u8 type;
+    __u8 closed;
+    char path[UNIX_SOCK_PATH_MAX];
+} unix_sock_stats_t;
+
+#define MAX_CONN_FILTERS 16
+
+// Transports and families matched by a connection filter
+typedef enum
+{
+    CONN_FILTER_TCP = 1 << 0,
+    CONN_FILTER_UDP = 1 << 1,
+    CONN_FILTER_SCTP = 1 << 2,
+    CONN_FILTER_V4 = 1 << 3,
+    CONN_FILTER_V6 = 1 << 4,
+} conn_filter_flags_t;
+
+// a rule of network_config.ignore_conns, matching the connections with either endpoint within
+// the address range and the port range of the rule
+typedef struct {
+    // the address range, in the representation of the conn_tuple_t addresses
+    __u64 addr_h;
+    __u64 addr_l;
+    __u64 mask_h;
+    __u64 mask_l;
+    // number of connections ignored because of the rule
+    __u64 ignored;
+    // all the ports are matched when low_port is 0
+    __u16 low_port;
+    __u16 high_port;
+    // CONN_FILTER_* flags
+    __u8 flags;
+} conn_filter_t;
+
 #endif
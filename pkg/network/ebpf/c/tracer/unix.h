@@ -0,0 +1,92 @@
+#ifndef __TRACER_UNIX_H
+#define __TRACER_UNIX_H
+
+#if defined(COMPILE_RUNTIME) || defined(COMPILE_CORE)
+
+#ifdef COMPILE_RUNTIME
+#include <net/af_unix.h>
+#include <net/sock.h>
+#endif
+
+#include "bpf_core_read.h"
+#include "bpf_helpers.h"
+#include "bpf_telemetry.h"
+
+#include "tracer/tracer.h"
+#include "tracer/maps.h"
+
+// socket_ino returns the inode number of the socket, which is the
+// identifier reported by /proc/<pid>/fd, /proc/net/unix and ss
+static __always_inline __u64 socket_ino(struct socket *sock) {
+    if (!sock) {
+        return 0;
+    }
+    // struct socket is the first member of struct socket_alloc
+    return BPF_CORE_READ((struct socket_alloc *)sock, vfs_inode.i_ino);
+}
+
+// read_unix_sock_path reads the path the socket is bound to, if any
+static __always_inline void read_unix_sock_path(unix_sock_stats_t *stats, struct unix_sock *usk) {
+    struct unix_address *addr = BPF_CORE_READ(usk, addr);
+    if (!addr) {
+        return;
+    }
+
+    int len = BPF_CORE_READ(addr, len);
+    // the address length includes the sun_family field
+    len -= sizeof(sa_family_t);
+    if (len <= 0) {
+        return;
+    }
+    if (len > UNIX_SOCK_PATH_MAX) {
+        len = UNIX_SOCK_PATH_MAX;
+    }
+    stats->path_len = len;
+    bpf_probe_read_kernel_with_telemetry(stats->path, sizeof(stats->path), &addr->name[0].sun_path);
+}
+
+static __always_inline int handle_unix_sendmsg_return(int sent) {
+    __u64 pid_tgid = bpf_get_current_pid_tgid();
+    struct socket **sockpp = bpf_map_lookup_elem(&unix_sendmsg_args, &pid_tgid);
+    if (!sockpp) {
+        return 0;
+    }
+    struct socket *sock = *sockpp;
+    bpf_map_delete_elem(&unix_sendmsg_args, &pid_tgid);
+    if (sent <= 0) {
+        return 0;
+    }
+
+    __u64 ino = socket_ino(sock);
+    if (!ino) {
+        return 0;
+    }
+
+    struct unix_sock *usk = (struct unix_sock *)BPF_CORE_READ(sock, sk);
+    unix_sock_stats_t *stats = bpf_map_lookup_elem(&unix_sock_stats, &ino);
+    if (!stats) {
+        unix_sock_stats_t empty = {};
+        empty.type = BPF_CORE_READ(sock, type);
+        bpf_map_update_with_telemetry(unix_sock_stats, &ino, &empty, BPF_NOEXIST);
+        stats = bpf_map_lookup_elem(&unix_sock_stats, &ino);
+        if (!stats) {
+            return 0;
+        }
+        read_unix_sock_path(stats, usk);
+    }
+
+    // the peer of a datagram socket can change if it is reconnected
+    struct sock *peer = BPF_CORE_READ(usk, peer);
+    if (peer) {
+        stats->peer_ino = socket_ino(BPF_CORE_READ(peer, sk_socket));
+    }
+    stats->pid = pid_tgid >> 32;
+    stats->timestamp = bpf_ktime_get_ns();
+    __sync_fetch_and_add(&stats->sent_bytes, sent);
+    __sync_fetch_and_add(&stats->sent_packets, 1);
+    return 0;
+}
+
+#endif // COMPILE_RUNTIME || COMPILE_CORE
+
+#endif // __TRACER_UNIX_H
@@ -24,8 +24,11 @@ func (t ConnTuple) Family() ConnFamily {
 	return IPv4
 }
 
-// Type returns whether a tuple is TCP or UDP
+// Type returns whether a tuple is TCP, UDP or SCTP
 func (t ConnTuple) Type() ConnType {
+	if t.Metadata&uint32(SCTP) != 0 {
+		return SCTP
+	}
 	if t.Metadata&uint32(TCP) != 0 {
 		return TCP
 	}
@@ -80,6 +83,18 @@ func (cs ConnStats) IsAssured() bool {
 	return cs.Flags&uint8(Assured) != 0
 }
 
+// IsDirectionObserved returns whether the direction of the connection was taken from
+// its first packet, as opposed to being guessed from the bindings of the socket.
+func (cs ConnStats) IsDirectionObserved() bool {
+	return cs.Flags&uint8(DirectionObserved) != 0
+}
+
+// IsPreExisting returns whether the connection was seeded from procfs, because it
+// was established before the tracer started.
+func (cs ConnStats) IsPreExisting() bool {
+	return cs.Flags&uint8(PreExisting) != 0
+}
+
 // ToBatch converts a byte slice to a Batch pointer.
 func ToBatch(data []byte) *Batch {
 	return (*Batch)(unsafe.Pointer(&data[0]))
@@ -18,16 +18,29 @@ import "C"
 
 type ConnTuple C.conn_tuple_t
 type TCPStats C.tcp_stats_t
+type TCPCongestion C.tcp_congestion_t
+type RTTHistogram C.rtt_histogram_t
 type ConnStats C.conn_stats_ts_t
 type Conn C.conn_t
 type Batch C.batch_t
 type Telemetry C.telemetry_t
+type TracerABI C.tracer_abi_t
 type PortBinding C.port_binding_t
 type PIDFD C.pid_fd_t
 type UDPRecvSock C.udp_recv_sock_t
 type BindSyscallArgs C.bind_syscall_args_t
 type ProtocolStack C.protocol_stack_t
 type ProtocolStackWrapper C.protocol_stack_wrapper_t
+type CgroupConnKey C.cgroup_conn_key_t
+type CgroupConnStats C.cgroup_conn_stats_t
+type UnixSockStats C.unix_sock_stats_t
+type SkbDropKey C.skb_drop_key_t
+type ConnQoS C.conn_qos_t
+type ListenOverflow C.listen_overflow_t
+type ConnProcess C.conn_process_t
+type TLSInfo C.tls_info_t
+type WebSocketSession C.websocket_session_t
+type ConnFilter C.conn_filter_t
 
 // udp_recv_sock_t have *sock and *msghdr struct members, we make them opaque here
 type _Ctype_struct_sock uint64
@@ -44,16 +57,32 @@ const (
 type ConnFlags uint32
 
 const (
-	LInit   ConnFlags = C.CONN_L_INIT
-	RInit   ConnFlags = C.CONN_R_INIT
-	Assured ConnFlags = C.CONN_ASSURED
+	LInit             ConnFlags = C.CONN_L_INIT
+	RInit             ConnFlags = C.CONN_R_INIT
+	Assured           ConnFlags = C.CONN_ASSURED
+	DirectionObserved ConnFlags = C.CONN_DIRECTION_OBSERVED
+	PreExisting       ConnFlags = C.CONN_PRE_EXISTING
 )
 
+type ConnFilterFlags uint8
+
+const (
+	ConnFilterTCP  ConnFilterFlags = C.CONN_FILTER_TCP
+	ConnFilterUDP  ConnFilterFlags = C.CONN_FILTER_UDP
+	ConnFilterSCTP ConnFilterFlags = C.CONN_FILTER_SCTP
+	ConnFilterV4   ConnFilterFlags = C.CONN_FILTER_V4
+	ConnFilterV6   ConnFilterFlags = C.CONN_FILTER_V6
+)
+
+const MaxConnFilters = C.MAX_CONN_FILTERS
+
 const BatchSize = C.CONN_CLOSED_BATCH_SIZE
 const SizeofBatch = C.sizeof_batch_t
 
 const SizeofConn = C.sizeof_conn_t
 
+const RTTHistogramBuckets = C.RTT_HISTOGRAM_BUCKETS
+
 type ClassificationProgram = uint32
 
 const (
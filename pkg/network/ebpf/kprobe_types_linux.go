@@ -18,7 +18,16 @@ type TCPStats struct {
 	Rtt               uint32
 	Rtt_var           uint32
 	State_transitions uint16
-	Pad_cgo_0         [2]byte
+	Failure_reason    uint16
+}
+type TCPCongestion struct {
+	Cwnd          uint32
+	Ssthresh      uint32
+	Delivery_rate uint64
+	Cong_algo     [16]int8
+}
+type RTTHistogram struct {
+	Buckets [24]uint16
 }
 type ConnStats struct {
 	Sent_bytes     uint64
@@ -27,17 +36,23 @@ type ConnStats struct {
 	Recv_packets   uint32
 	Timestamp      uint64
 	Duration       uint64
+	Last_sent_ts   uint64
+	Last_recv_ts   uint64
 	Cookie         uint32
 	Protocol_stack ProtocolStack
 	Flags          uint8
 	Direction      uint8
-	Pad_cgo_0      [6]byte
+	Dscp           uint8
+	Pad_cgo_0      [1]byte
+	Flow_label     uint32
 }
 type Conn struct {
 	Tup             ConnTuple
 	Conn_stats      ConnStats
 	Tcp_stats       TCPStats
+	Tcp_congestion  TCPCongestion
 	Tcp_retransmits uint32
+	Drops           uint32
 }
 type Batch struct {
 	C0        Conn
@@ -50,13 +65,18 @@ type Batch struct {
 	Pad_cgo_0 [2]byte
 }
 type Telemetry struct {
-	Tcp_failed_connect  uint64
-	Tcp_sent_miscounts  uint64
-	Unbatched_tcp_close uint64
-	Unbatched_udp_close uint64
-	Udp_sends_processed uint64
-	Udp_sends_missed    uint64
-	Udp_dropped_conns   uint64
+	Tcp_failed_connect        uint64
+	Tcp_sent_miscounts        uint64
+	Unbatched_tcp_close       uint64
+	Unbatched_udp_close       uint64
+	Udp_sends_processed       uint64
+	Udp_sends_missed          uint64
+	Udp_dropped_conns         uint64
+	Closed_conn_output_failed uint64
+}
+type TracerABI struct {
+	Version  uint64
+	Features uint64
 }
 type PortBinding struct {
 	Netns     uint32
@@ -85,6 +105,86 @@ type ProtocolStackWrapper struct {
 	Stack   ProtocolStack
 	Updated uint64
 }
+type CgroupConnKey struct {
+	Cgroup_id uint64
+	Raddr_h   uint64
+	Raddr_l   uint64
+	Netns     uint32
+	Metadata  uint32
+	Port      uint16
+	Direction uint8
+	Pad_cgo_0 [5]byte
+}
+type CgroupConnStats struct {
+	Sent_bytes   uint64
+	Recv_bytes   uint64
+	Sent_packets uint64
+	Recv_packets uint64
+	Timestamp    uint64
+}
+type UnixSockStats struct {
+	Peer_ino     uint64
+	Sent_bytes   uint64
+	Sent_packets uint64
+	Timestamp    uint64
+	Pid          uint32
+	Path_len     uint16
+	Type         uint8
+	Closed       uint8
+	Path         [108]int8
+	Pad_cgo_0    [4]byte
+}
+type TLSInfo struct {
+	Server_name      [64]int8
+	Alpn             [16]int8
+	Chosen_version   uint16
+	Cipher_suite     uint16
+	Offered_versions uint8
+	Pad_cgo_0        [1]byte
+}
+type WebSocketSession struct {
+	Upgraded_ns            uint64
+	Last_seen_ns           uint64
+	Client_messages        uint64
+	Server_messages        uint64
+	Client_bytes           uint64
+	Server_bytes           uint64
+	Client_frame_remaining uint64
+	Server_frame_remaining uint64
+	Server_port            uint16
+	Pad_cgo_0              [6]byte
+}
+type SkbDropKey struct {
+	Ifindex uint32
+	Reason  uint32
+}
+type ConnQoS struct {
+	Dscp       uint8
+	Pad_cgo_0  [3]byte
+	Flow_label uint32
+}
+type ListenOverflow struct {
+	Accept_queue_drops    uint32
+	Syn_backlog_overflows uint32
+}
+type ConnProcess struct {
+	Cgroup_id    uint64
+	Cmdline_hash uint32
+	Comm         [16]int8
+	Exe          [32]int8
+	Pad_cgo_0    [4]byte
+}
+type ConnFilter struct {
+	Addr_h    uint64
+	Addr_l    uint64
+	Mask_h    uint64
+	Mask_l    uint64
+	Ignored   uint64
+	Low_port  uint16
+	High_port uint16
+	Flags     uint8
+	Pad_cgo_0 [3]byte
+}
 
 type _Ctype_struct_sock uint64
 type _Ctype_struct_msghdr uint64
@@ -100,15 +200,31 @@ const (
 type ConnFlags uint32
 
 const (
-	LInit   ConnFlags = 0x1
-	RInit   ConnFlags = 0x2
-	Assured ConnFlags = 0x4
+	LInit             ConnFlags = 0x1
+	RInit             ConnFlags = 0x2
+	Assured           ConnFlags = 0x4
+	DirectionObserved ConnFlags = 0x8
+	PreExisting       ConnFlags = 0x10
+)
+
+type ConnFilterFlags uint8
+
+const (
+	ConnFilterTCP  ConnFilterFlags = 0x1
+	ConnFilterUDP  ConnFilterFlags = 0x2
+	ConnFilterSCTP ConnFilterFlags = 0x4
+	ConnFilterV4   ConnFilterFlags = 0x8
+	ConnFilterV6   ConnFilterFlags = 0x10
 )
 
+const MaxConnFilters = 0x10
+
 const BatchSize = 0x4
-const SizeofBatch = 0x1f0
+const SizeofBatch = 0x2d0
+
+const SizeofConn = 0xb0
 
-const SizeofConn = 0x78
+const RTTHistogramBuckets = 0x18
 
 type ClassificationProgram = uint32
 
@@ -12,14 +12,50 @@ package probes
 type ProbeFuncName = string
 
 const (
+	// SCTPSfDoPrmAsoc traces the handling of the primitive ASSOCIATE request for SCTP associations initiated by the host
+	SCTPSfDoPrmAsoc ProbeFuncName = "kprobe__sctp_sf_do_prm_asoc"
+	// SCTPOutqTail traces the SCTP chunks queued for transmission
+	SCTPOutqTail ProbeFuncName = "kprobe__sctp_outq_tail"
+	// SCTPUlpeventMakeRcvmsg traces the SCTP DATA chunks delivered to the socket
+	SCTPUlpeventMakeRcvmsg ProbeFuncName = "kprobe__sctp_ulpevent_make_rcvmsg"
+	// SCTPAssociationFree traces the teardown of SCTP associations
+	SCTPAssociationFree ProbeFuncName = "kprobe__sctp_association_free"
+	// SCTPAssociationFreeReturn traces the return of sctp_association_free() to flush the closed associations
+	SCTPAssociationFreeReturn ProbeFuncName = "kretprobe__sctp_association_free"
+
+	// UnixStreamSendmsg traces the unix_stream_sendmsg() kernel function
+	UnixStreamSendmsg ProbeFuncName = "kprobe__unix_stream_sendmsg"
+	// UnixStreamSendmsgReturn traces the return value of the unix_stream_sendmsg() kernel function
+	UnixStreamSendmsgReturn ProbeFuncName = "kretprobe__unix_stream_sendmsg"
+	// UnixDgramSendmsg traces the unix_dgram_sendmsg() kernel function
+	UnixDgramSendmsg ProbeFuncName = "kprobe__unix_dgram_sendmsg"
+	// UnixDgramSendmsgReturn traces the return value of the unix_dgram_sendmsg() kernel function
+	UnixDgramSendmsgReturn ProbeFuncName = "kretprobe__unix_dgram_sendmsg"
+	// UnixRelease traces the release of AF_UNIX sockets
+	UnixRelease ProbeFuncName = "kprobe__unix_release"
+
+	// InetCskListenStart traces the inet_csk_listen_start system call (called for both ipv4 and ipv6)
+	InetCskListenStart ProbeFuncName = "kprobe__inet_csk_listen_start"
+	// InetCskListenStartReturn traces the return value for the inet_csk_listen_start system call
+	InetCskListenStartReturn ProbeFuncName = "kretprobe__inet_csk_listen_start"
 	// InetCskListenStop traces the inet_csk_listen_stop system call (called for both ipv4 and ipv6)
 	InetCskListenStop ProbeFuncName = "kprobe__inet_csk_listen_stop"
 
+	// TCPConnRequest traces the tcp_conn_request() kernel function, to detect the SYN backlog and accept queue overflows
+	TCPConnRequest ProbeFuncName = "kprobe__tcp_conn_request"
+	// TCPv4SynRecvSock traces the tcp_v4_syn_recv_sock() kernel function, to detect the accept queue overflows
+	TCPv4SynRecvSock ProbeFuncName = "kprobe__tcp_v4_syn_recv_sock"
+	// TCPv6SynRecvSock traces the tcp_v6_syn_recv_sock() kernel function, to detect the accept queue overflows
+	TCPv6SynRecvSock ProbeFuncName = "kprobe__tcp_v6_syn_recv_sock"
+
 	// TCPConnect traces the connect() system call
 	TCPConnect ProbeFuncName = "kprobe__tcp_connect"
 	// TCPFinishConnect traces tcp_finish_connect() kernel function. This is
 	// used to know when a TCP connection switches to the ESTABLISHED state
 	TCPFinishConnect ProbeFuncName = "kprobe__tcp_finish_connect"
+	// TCPDone traces the tcp_done() kernel function. This is used to
+	// catch TCP connection attempts that failed before being established
+	TCPDone ProbeFuncName = "kprobe__tcp_done"
 	// TCPv6Connect traces the v6 connect() system call
 	TCPv6Connect ProbeFuncName = "kprobe__tcp_v6_connect"
 	// TCPv6ConnectReturn traces the return value for the v6 connect() system call
@@ -38,6 +74,14 @@ const (
 	// belongs (but hidden) for it.
 	NetDevQueue ProbeFuncName = "tracepoint__net__net_dev_queue"
 
+	// SKBKfreeSkb runs on the skb:kfree_skb tracepoint to count the packets dropped by the kernel
+	SKBKfreeSkb ProbeFuncName = "tracepoint__skb__kfree_skb"
+
+	// IPOutput traces the ip_output() kernel function, to read the QoS marking of the IPv4 packets sent
+	IPOutput ProbeFuncName = "kprobe__ip_output"
+	// IP6Output traces the ip6_output() kernel function, to read the QoS marking of the IPv6 packets sent
+	IP6Output ProbeFuncName = "kprobe__ip6_output"
+
 	// TCPSendMsg traces the tcp_sendmsg() system call
 	TCPSendMsg ProbeFuncName = "kprobe__tcp_sendmsg"
 	// TCPSendPage traces the tcp_sendpage() kernel function
@@ -186,6 +230,8 @@ const (
 	ConnMap BPFMapName = "conn_stats"
 	// TCPStatsMap is the map storing TCP stats
 	TCPStatsMap BPFMapName = "tcp_stats"
+	// TCPRTTHistogramsMap is the map storing the histogram of the RTT samples of each TCP connection
+	TCPRTTHistogramsMap BPFMapName = "tcp_rtt_histograms"
 	// TCPRetransmitsMap is the map storing TCP retransmits
 	TCPRetransmitsMap BPFMapName = "tcp_retransmits"
 	// TCPConnectSockPidMap is the map storing the PIDs of ongoing TCP connections
@@ -200,8 +246,40 @@ const (
 	PortBindingsMap BPFMapName = "port_bindings"
 	// UDPPortBindingsMap is the map storing the UDP port bindings
 	UDPPortBindingsMap BPFMapName = "udp_port_bindings"
+	// ListeningSocketsMap is the map storing the listening TCP sockets and bound UDP sockets
+	ListeningSocketsMap BPFMapName = "listening_sockets"
+	// SCTPInitiatedAssocsMap is the map storing the SCTP associations initiated by the host
+	SCTPInitiatedAssocsMap BPFMapName = "sctp_initiated_assocs"
+	// CgroupConnStatsMap is the map storing the traffic aggregated by cgroup
+	CgroupConnStatsMap BPFMapName = "cgroup_conn_stats"
+	// ListenOverflowsMap is the map storing the overflows of the queues of listening TCP sockets
+	ListenOverflowsMap BPFMapName = "listen_overflows"
+	// ConnDropsMap is the map storing the packets dropped by the kernel for each connection
+	ConnDropsMap BPFMapName = "conn_drops"
+	// ConnQoSMap is the map storing the QoS marking of the packets sent on each connection
+	ConnQoSMap BPFMapName = "conn_qos"
+	// ConnProcessMap is the map storing the process which created each connection
+	ConnProcessMap BPFMapName = "conn_process"
+	// ConnFiltersMap is the map storing the rules of network_config.ignore_conns
+	ConnFiltersMap BPFMapName = "conn_filters"
+	// IgnoredConnsMap is the map storing the connections matching one of the rules of network_config.ignore_conns
+	IgnoredConnsMap BPFMapName = "ignored_conns"
+	// TLSHandshakeInfoMap is the map storing the metadata of the TLS handshakes read by the protocol classifier
+	TLSHandshakeInfoMap BPFMapName = "tls_handshake_info"
+	// WebSocketSessionsMap is the map storing the sessions of the connections upgraded to WebSocket
+	WebSocketSessionsMap BPFMapName = "websocket_sessions"
+	// SKBDropsMap is the map storing the packets dropped by the kernel by interface and drop reason
+	SKBDropsMap BPFMapName = "skb_drops"
+	// UnixSockStatsMap is the map storing the traffic statistics of AF_UNIX sockets
+	UnixSockStatsMap BPFMapName = "unix_sock_stats"
+	// UnixSendmsgArgsMap is the map storing the arguments of the unix_stream_sendmsg() and unix_dgram_sendmsg() kernel functions
+	UnixSendmsgArgsMap BPFMapName = "unix_sendmsg_args"
+	// InetCskListenStartArgsMap is the map storing the arguments of the inet_csk_listen_start() kernel function
+	InetCskListenStartArgsMap BPFMapName = "inet_csk_listen_start_args"
 	// TelemetryMap is the map storing telemetry data
 	TelemetryMap BPFMapName = "telemetry"
+	// TracerABIMap is the map storing the ABI version and the negotiated features of the tracer
+	TracerABIMap BPFMapName = "tracer_abi"
 	// ConnCloseBatchMap is the map storing connection close batch events
 	ConnCloseBatchMap BPFMapName = "conn_close_batch"
 	// ConntrackMap is the map storing conntrack entries
@@ -236,4 +314,6 @@ const (
 	ClassificationProgsMap BPFMapName = "classification_progs"
 	// TCPCloseProgsMap is the map storing the programs to run on TCP close events
 	TCPCloseProgsMap BPFMapName = "tcp_close_progs"
+	// DNSPortsMap is the map storing the ports on which the DNS traffic is captured
+	DNSPortsMap BPFMapName = "dns_ports"
 )
@@ -8,10 +8,14 @@
 package ebpf
 
 func (c ConnType) String() string {
-	if c == TCP {
+	switch c {
+	case TCP:
 		return "TCP"
+	case SCTP:
+		return "SCTP"
+	default:
+		return "UDP"
 	}
-	return "UDP"
 }
 
 func (c ConnFamily) String() string {
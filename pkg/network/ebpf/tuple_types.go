@@ -15,8 +15,9 @@ import "C"
 type ConnType uint32
 
 const (
-	UDP ConnType = C.CONN_TYPE_UDP
-	TCP ConnType = C.CONN_TYPE_TCP
+	UDP  ConnType = C.CONN_TYPE_UDP
+	TCP  ConnType = C.CONN_TYPE_TCP
+	SCTP ConnType = C.CONN_TYPE_SCTP
 )
 
 type ConnFamily uint32
@@ -6,8 +6,9 @@ package ebpf
 type ConnType uint32
 
 const (
-	UDP ConnType = 0x0
-	TCP ConnType = 0x1
+	UDP  ConnType = 0x0
+	TCP  ConnType = 0x1
+	SCTP ConnType = 0x4
 )
 
 type ConnFamily uint32
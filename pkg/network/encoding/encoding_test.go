@@ -493,6 +493,23 @@ func testSerialization(t *testing.T, aggregateByStatusCode bool) {
 
 		assertConnsEqual(t, out, result)
 	})
+
+	t.Run("requesting application/msgpack serialization", func(t *testing.T) {
+		newConfig(t)
+		config.SystemProbe.SetWithoutSource("system_probe_config.collect_dns_domains", false)
+		out := getExpectedConnections(false, httpOutBlob)
+
+		assert := assert.New(t)
+		blobWriter := getBlobWriter(t, assert, in, "application/msgpack")
+
+		unmarshaler := unmarshal.GetUnmarshaler("application/msgpack")
+		assert.Equal("application/msgpack", unmarshaler.ContentType())
+		result, err := unmarshaler.Unmarshal(blobWriter.Bytes())
+		require.NoError(t, err)
+		sort.Strings(result.Tags)
+
+		assertConnsEqual(t, out, result)
+	})
 }
 
 func TestHTTPSerializationWithLocalhostTraffic(t *testing.T) {
@@ -1035,3 +1052,35 @@ func TestKafkaSerializationWithLocalhostTraffic(t *testing.T) {
 
 	require.Equal(t, out, result)
 }
+
+func TestSCTPConnectionsNotEncoded(t *testing.T) {
+	in := &network.Connections{
+		BufferedData: network.BufferedData{
+			Conns: []network.ConnectionStats{
+				{
+					Source: util.AddressFromString("10.0.15.1"),
+					SPort:  uint16(60000),
+					Dest:   util.AddressFromString("10.0.15.2"),
+					DPort:  uint16(38412),
+					Type:   network.SCTP,
+				},
+				{
+					Source: util.AddressFromString("10.0.15.1"),
+					SPort:  uint16(60001),
+					Dest:   util.AddressFromString("10.0.15.2"),
+					DPort:  uint16(8080),
+					Type:   network.TCP,
+				},
+			},
+		},
+	}
+
+	blobWriter := getBlobWriter(t, assert.New(t), in, "application/protobuf")
+	unmarshaler := unmarshal.GetUnmarshaler("application/protobuf")
+	result, err := unmarshaler.Unmarshal(blobWriter.Bytes())
+	require.NoError(t, err)
+
+	// the payload has no ConnectionType for SCTP yet
+	require.Len(t, result.Conns, 1)
+	assert.Equal(t, model.ConnectionType_tcp, result.Conns[0].Type)
+}
@@ -0,0 +1,65 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package marshal
+
+import (
+	"compress/gzip"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ContentEncodingGzip holds the HTTP content-encoding of a gzip compressed payload
+	ContentEncodingGzip = "gzip"
+	// ContentEncodingZstd holds the HTTP content-encoding of a zstd compressed payload
+	ContentEncodingZstd = "zstd"
+)
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// GetCompressor returns a writer compressing the payload into the given writer with the preferred encoding
+// accepted by the given Accept-Encoding header, zstd then gzip, along with the name of that encoding.
+// The payload isn't compressed, and the returned encoding is empty, when none of them is accepted.
+// The writer must be closed to flush the end of the payload.
+func GetCompressor(writer io.Writer, acceptEncoding string) (io.WriteCloser, string) {
+	accepted := acceptedEncodings(acceptEncoding)
+	if zstdSupported && accepted[ContentEncodingZstd] {
+		return newZstdWriter(writer), ContentEncodingZstd
+	}
+	if accepted[ContentEncodingGzip] {
+		// the payload is sent to a local client, favor the speed over the ratio
+		gz, _ := gzip.NewWriterLevel(writer, gzip.BestSpeed)
+		return gz, ContentEncodingGzip
+	}
+	return nopWriteCloser{writer}, ""
+}
+
+// acceptedEncodings returns the encodings listed by an Accept-Encoding header, leaving out
+// those explicitly refused with a zero quality value
+func acceptedEncodings(header string) map[string]bool {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				continue
+			}
+		}
+		accepted[name] = true
+	}
+	return accepted
+}
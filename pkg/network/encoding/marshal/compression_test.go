@@ -0,0 +1,39 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package marshal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCompressor(t *testing.T) {
+	zstdOrGzip := ContentEncodingGzip
+	if zstdSupported {
+		zstdOrGzip = ContentEncodingZstd
+	}
+
+	tests := []struct {
+		acceptEncoding string
+		expected       string
+	}{
+		{"", ""},
+		{"br", ""},
+		{"gzip", ContentEncodingGzip},
+		{"gzip, deflate", ContentEncodingGzip},
+		{"zstd, gzip", zstdOrGzip},
+		{"GZIP;q=0.5, zstd;q=1.0", zstdOrGzip},
+		{"zstd;q=0, gzip", ContentEncodingGzip},
+		{"gzip;q=0", ""},
+	}
+	for _, tt := range tests {
+		w, encoding := GetCompressor(&bytes.Buffer{}, tt.acceptEncoding)
+		assert.Equal(t, tt.expected, encoding, tt.acceptEncoding)
+		assert.NoError(t, w.Close())
+	}
+}
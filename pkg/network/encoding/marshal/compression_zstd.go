@@ -0,0 +1,20 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build zstd
+
+package marshal
+
+import (
+	"io"
+
+	"github.com/DataDog/zstd"
+)
+
+const zstdSupported = true
+
+func newZstdWriter(writer io.Writer) io.WriteCloser {
+	return zstd.NewWriterLevel(writer, zstd.BestSpeed)
+}
@@ -7,6 +7,7 @@ package marshal
 
 import (
 	"math"
+	"strconv"
 
 	"github.com/twmb/murmur3"
 
@@ -18,6 +19,11 @@ import (
 
 const maxRoutes = math.MaxInt32
 
+// connectionTypeSCTP is the payload value for SCTP connections, which is not part of the
+// agent-payload ConnectionType enum yet. The SCTP connections are therefore left out of the
+// payload unless network_config.enable_sctp_encoding is set, for backends which know the value.
+const connectionTypeSCTP model.ConnectionType = 2
+
 // RouteIdx stores the route and the index into the route collection for a route
 type RouteIdx struct {
 	Idx   int32
@@ -99,6 +105,12 @@ func FormatConnection(builder *model.ConnectionBuilder, conn network.ConnectionS
 	builder.SetIntraHost(conn.IntraHost)
 	builder.SetLastTcpEstablished(conn.Last.TCPEstablished)
 	builder.SetLastTcpClosed(conn.Last.TCPClosed)
+	for reason, count := range conn.TCPFailures {
+		builder.AddTcpFailuresByErrCode(func(w *model.Connection_TcpFailuresByErrCodeEntryBuilder) {
+			w.SetKey(uint32(reason))
+			w.SetValue(count)
+		})
+	}
 	builder.SetProtocol(func(w *model.ProtocolStackBuilder) {
 		ps := FormatProtocolStack(conn.ProtocolStack, conn.StaticTags)
 		for _, p := range ps.Stack {
@@ -194,6 +206,8 @@ func formatType(f network.ConnectionType) model.ConnectionType {
 		return model.ConnectionType_tcp
 	case network.UDP:
 		return model.ConnectionType_udp
+	case network.SCTP:
+		return connectionTypeSCTP
 	default:
 		return -1
 	}
@@ -273,23 +287,68 @@ func formatTags(c network.ConnectionStats, tagsSet *network.TagsSet, connDynamic
 
 	staticTags := network.GetStaticTags(c.StaticTags)
 	tagsIdx := make([]uint32, 0, len(staticTags)+len(connDynamicTags)+len(c.Tags))
-
-	for _, tag := range staticTags {
+	addTag := func(tag string) {
 		checksum ^= murmur3.StringSum32(tag)
 		tagsIdx = append(tagsIdx, tagsSet.Add(tag))
 	}
 
+	for _, tag := range staticTags {
+		addTag(tag)
+	}
+
+	if tag := network.GetEncryptionTag(&c); tag != "" {
+		addTag(tag)
+	}
+
+	for _, tag := range network.GetTLSTags(&c) {
+		addTag(tag)
+	}
+
+	if c.NATHairpin {
+		addTag("nat_hairpin:true")
+	}
+
+	if c.IsApproximate {
+		addTag("approximate:true")
+	}
+
+	if c.IsPreExisting {
+		addTag("pre_existing:true")
+	}
+
+	if tag := network.GetTrafficClassTag(&c); tag != "" {
+		addTag(tag)
+	}
+
+	for _, tag := range network.GetGatewayTags(&c) {
+		addTag(tag)
+	}
+
+	if c.EncryptedDNS != network.EncryptedDNSNone {
+		addTag("encrypted_dns:" + c.EncryptedDNS.String())
+	}
+
+	// the QoS marking read from the IP header of the packets sent
+	if c.DSCP > 0 {
+		addTag("dscp:" + strconv.FormatUint(uint64(c.DSCP), 10))
+	}
+
+	if c.CongestionAlgorithm != "" {
+		addTag("congestion_algorithm:" + c.CongestionAlgorithm)
+	}
+
+	if !c.WebSocket.IsEmpty() {
+		addTag("websocket:true")
+	}
+
 	// Dynamic tags
 	for tag := range connDynamicTags {
-		checksum ^= murmur3.StringSum32(tag)
-		tagsIdx = append(tagsIdx, tagsSet.Add(tag))
+		addTag(tag)
 	}
 
 	// other tags, e.g., from process env vars like DD_ENV, etc.
 	for tag := range c.Tags {
-		t := tag.Get().(string)
-		checksum ^= murmur3.StringSum32(t)
-		tagsIdx = append(tagsIdx, tagsSet.Add(t))
+		addTag(tag.Get().(string))
 	}
 
 	return tagsIdx, checksum
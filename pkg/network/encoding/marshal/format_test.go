@@ -106,3 +106,110 @@ func BenchmarkFormatTags(b *testing.B) {
 		formatTags(c, tagSet, nil)
 	}
 }
+
+func TestFormatEncryptionTag(t *testing.T) {
+	tagSet := network.NewTagsSet()
+	c := network.ConnectionStats{Type: network.TCP, Encryption: network.EncryptionPlaintext}
+	tags, checksum := formatTags(c, tagSet, nil)
+	require.Len(t, tags, 1)
+	require.Equal(t, "encrypted:false", tagSet.GetStrings()[tags[0]])
+
+	c.Encryption = network.EncryptionUnknown
+	tags, _ = formatTags(c, tagSet, nil)
+	require.Empty(t, tags)
+
+	c.Encryption = network.EncryptionTLS
+	_, tlsChecksum := formatTags(c, tagSet, nil)
+	require.NotEqual(t, checksum, tlsChecksum)
+}
+
+func TestFormatNATHairpinTag(t *testing.T) {
+	tagSet := network.NewTagsSet()
+	c := network.ConnectionStats{Type: network.TCP, NATHairpin: true}
+	tags, _ := formatTags(c, tagSet, nil)
+	require.Len(t, tags, 1)
+	require.Equal(t, "nat_hairpin:true", tagSet.GetStrings()[tags[0]])
+
+	c.NATHairpin = false
+	tags, _ = formatTags(c, tagSet, nil)
+	require.Empty(t, tags)
+}
+
+func TestFormatEncryptedDNSTag(t *testing.T) {
+	tagSet := network.NewTagsSet()
+	c := network.ConnectionStats{Type: network.TCP, EncryptedDNS: network.EncryptedDNSOverHTTPS}
+	tags, _ := formatTags(c, tagSet, nil)
+	require.Len(t, tags, 1)
+	require.Equal(t, "encrypted_dns:doh", tagSet.GetStrings()[tags[0]])
+
+	c.EncryptedDNS = network.EncryptedDNSNone
+	tags, _ = formatTags(c, tagSet, nil)
+	require.Empty(t, tags)
+}
+
+func TestFormatWebSocketTags(t *testing.T) {
+	tagSet := network.NewTagsSet()
+	c := network.ConnectionStats{Type: network.TCP}
+	tags, _ := formatTags(c, tagSet, nil)
+	require.Empty(t, tags)
+
+	c.WebSocket = network.WebSocketSession{Upgraded: 1, SentMessages: 12, RecvMessages: 3, SentBytes: 1234, RecvBytes: 56}
+	tags, _ = formatTags(c, tagSet, nil)
+	var strs []string
+	for _, tag := range tags {
+		strs = append(strs, tagSet.GetStrings()[tag])
+	}
+	require.Equal(t, []string{"websocket:true"}, strs, "the session stats are not tags")
+}
+
+func TestFormatCongestionTags(t *testing.T) {
+	tagSet := network.NewTagsSet()
+	c := network.ConnectionStats{
+		Type:                network.TCP,
+		IsClosed:            true,
+		CongestionAlgorithm: "cubic",
+		Cwnd:                10,
+		Ssthresh:            2147483647,
+		DeliveryRate:        1234567,
+	}
+	tags, _ := formatTags(c, tagSet, nil)
+	var strs []string
+	for _, tag := range tags {
+		strs = append(strs, tagSet.GetStrings()[tag])
+	}
+	require.Equal(t, []string{"congestion_algorithm:cubic"}, strs, "the congestion state is not a tag")
+}
+
+func TestFormatProcessNotTagged(t *testing.T) {
+	tagSet := network.NewTagsSet()
+	c := network.ConnectionStats{Type: network.TCP, Pid: 10}
+	c.Process.Exe = intern.GetByString("/usr/bin/curl")
+	c.Process.Comm = intern.GetByString("curl")
+	tags, _ := formatTags(c, tagSet, nil)
+	require.Empty(t, tags)
+}
+
+func TestFormatQoSTags(t *testing.T) {
+	tagSet := network.NewTagsSet()
+	c := network.ConnectionStats{Type: network.TCP, Family: network.AFINET6, DSCP: 46, FlowLabel: 0xbeef}
+	tags, _ := formatTags(c, tagSet, nil)
+	var strs []string
+	for _, tag := range tags {
+		strs = append(strs, tagSet.GetStrings()[tag])
+	}
+	require.Equal(t, []string{"dscp:46"}, strs, "the flow label is not a tag")
+}
+
+func TestFormatPairNotTagged(t *testing.T) {
+	// the pair id is unique per connection pair, it must not grow the tags of the payload
+	tagSet := network.NewTagsSet()
+	c := network.ConnectionStats{Type: network.TCP, Pid: 10, PairID: 0xabc, PeerPid: 20}
+	tags, _ := formatTags(c, tagSet, nil)
+	require.Empty(t, tags)
+}
+
+func TestFormatType(t *testing.T) {
+	require.Equal(t, model.ConnectionType_tcp, formatType(network.TCP))
+	require.Equal(t, model.ConnectionType_udp, formatType(network.UDP))
+	require.Equal(t, connectionTypeSCTP, formatType(network.SCTP))
+}
@@ -17,6 +17,7 @@ import (
 
 var (
 	pSerializer = protoSerializer{}
+	mSerializer = msgpackSerializer{}
 	jSerializer = jsonSerializer{
 		marshaller: jsonpb.Marshaler{
 			EmitDefaults: true,
@@ -35,6 +36,9 @@ func GetMarshaler(accept string) Marshaler {
 	if strings.Contains(accept, ContentTypeProtobuf) {
 		return pSerializer
 	}
+	if strings.Contains(accept, ContentTypeMsgpack) {
+		return mSerializer
+	}
 
 	return jSerializer
 }
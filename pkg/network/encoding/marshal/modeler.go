@@ -17,6 +17,8 @@ import (
 var (
 	cfgOnce  = sync.Once{}
 	agentCfg *model.AgentConfiguration
+	// sctpEnabled is whether the SCTP associations are encoded, see connectionTypeSCTP
+	sctpEnabled bool
 )
 
 // ConnectionsModeler contains all the necessary structs for modeling a connection.
@@ -65,9 +67,13 @@ func (c *ConnectionsModeler) modelConnections(builder *model.ConnectionsBuilder,
 			UsmEnabled: config.SystemProbe.GetBool("service_monitoring_config.enabled"),
 			CcmEnabled: config.SystemProbe.GetBool("ccm_network_config.enabled"),
 		}
+		sctpEnabled = config.SystemProbe.GetBool("network_config.enable_sctp_encoding")
 	})
 
 	for _, conn := range conns.Conns {
+		if conn.Type == network.SCTP && !sctpEnabled {
+			continue
+		}
 		builder.AddConns(func(builder *model.ConnectionBuilder) {
 			FormatConnection(builder, conn, c.routeIndex, c.httpEncoder, c.http2Encoder, c.kafkaEncoder, c.postgresEncoder, c.dnsFormatter, c.ipc, c.tagsSet)
 		})
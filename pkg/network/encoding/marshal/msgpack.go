@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package marshal
+
+import (
+	"bytes"
+	"io"
+
+	model "github.com/DataDog/agent-payload/v5/process"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+)
+
+// ContentTypeMsgpack holds the HTML content-type of a MessagePack payload
+const ContentTypeMsgpack = "application/msgpack"
+
+// msgpackSerializer encodes the payload with MessagePack, for the local consumers which
+// want a compact binary format without linking the protobuf definitions.
+// The keys are the names of the fields in the JSON payload.
+type msgpackSerializer struct{}
+
+func (msgpackSerializer) Marshal(conns *network.Connections, writer io.Writer, connsModeler *ConnectionsModeler) error {
+	out := bytes.NewBuffer(nil)
+	connsModeler.modelConnections(model.NewConnectionsBuilder(out), conns)
+
+	var payload model.Connections
+	if err := payload.Unmarshal(out.Bytes()); err != nil {
+		return err
+	}
+
+	enc := msgpack.NewEncoder(writer)
+	enc.SetCustomStructTag("json")
+	enc.UseCompactInts(true)
+	return enc.Encode(&payload)
+}
+
+func (msgpackSerializer) ContentType() string {
+	return ContentTypeMsgpack
+}
+
+var _ Marshaler = msgpackSerializer{}
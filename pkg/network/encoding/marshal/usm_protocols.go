@@ -86,6 +86,12 @@ func formatProtocol(proto protocols.ProtocolType) model.ProtocolType {
 		return model.ProtocolType_protocolRedis
 	case protocols.MySQL:
 		return model.ProtocolType_protocolMySQL
+	case protocols.WebSocket:
+		// the sessions are reported as tags of the HTTP connections they were upgraded from
+		return model.ProtocolType_protocolHTTP
+	case protocols.SSH, protocols.DNS:
+		// not represented in the payload yet, but still available in the connection stats
+		return model.ProtocolType_protocolUnknown
 	default:
 		log.Warnf("missing protobuf representation for protocol %d", proto)
 		return model.ProtocolType_protocolUnknown
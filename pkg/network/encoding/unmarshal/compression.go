@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package unmarshal
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// ContentEncodingGzip holds the HTTP content-encoding of a gzip compressed payload
+	ContentEncodingGzip = "gzip"
+	// ContentEncodingZstd holds the HTTP content-encoding of a zstd compressed payload
+	ContentEncodingZstd = "zstd"
+)
+
+// AcceptEncoding returns the HTTP Accept-Encoding header listing the encodings supported by GetDecompressor
+func AcceptEncoding() string {
+	if zstdSupported {
+		return ContentEncodingZstd + ", " + ContentEncodingGzip
+	}
+	return ContentEncodingGzip
+}
+
+// GetDecompressor returns a reader decompressing the payload read from the given reader according to
+// the given Content-Encoding header. The payload is read as is when the header is empty.
+func GetDecompressor(reader io.Reader, contentEncoding string) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case "", "identity":
+		return io.NopCloser(reader), nil
+	case ContentEncodingGzip:
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		return gz, nil
+	case ContentEncodingZstd:
+		if zstdSupported {
+			return newZstdReader(reader), nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported content encoding %q", contentEncoding)
+}
@@ -0,0 +1,32 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package unmarshal
+
+import (
+	"bytes"
+
+	model "github.com/DataDog/agent-payload/v5/process"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// ContentTypeMsgpack holds the HTML content-type of a MessagePack payload
+const ContentTypeMsgpack = "application/msgpack"
+
+type msgpackSerializer struct{}
+
+func (msgpackSerializer) Unmarshal(blob []byte) (*model.Connections, error) {
+	conns := new(model.Connections)
+	dec := msgpack.NewDecoder(bytes.NewReader(blob))
+	dec.SetCustomStructTag("json")
+	if err := dec.Decode(conns); err != nil {
+		return nil, err
+	}
+	return conns, nil
+}
+
+func (msgpackSerializer) ContentType() string {
+	return ContentTypeMsgpack
+}
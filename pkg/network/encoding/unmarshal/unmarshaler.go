@@ -14,6 +14,7 @@ import (
 
 var (
 	pSerializer Unmarshaler = protoSerializer{}
+	mSerializer Unmarshaler = msgpackSerializer{}
 	jSerializer Unmarshaler = jsonSerializer{}
 )
 
@@ -28,5 +29,8 @@ func GetUnmarshaler(ctype string) Unmarshaler {
 	if strings.Contains(ctype, ContentTypeProtobuf) {
 		return pSerializer
 	}
+	if strings.Contains(ctype, ContentTypeMsgpack) {
+		return mSerializer
+	}
 	return jSerializer
 }
@@ -0,0 +1,194 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/network/dns"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+)
+
+// EncryptedDNSProtocol is the protocol of the encrypted DNS traffic carried by a connection
+type EncryptedDNSProtocol uint8
+
+const (
+	// EncryptedDNSNone is used for the connections which don't carry encrypted DNS
+	EncryptedDNSNone EncryptedDNSProtocol = iota
+	// EncryptedDNSOverTLS is used for the connections to port 853 (RFC 7858)
+	EncryptedDNSOverTLS
+	// EncryptedDNSOverHTTPS is used for the HTTPS connections to a DNS over HTTPS server (RFC 8484)
+	EncryptedDNSOverHTTPS
+)
+
+func (p EncryptedDNSProtocol) String() string {
+	switch p {
+	case EncryptedDNSOverTLS:
+		return "dot"
+	case EncryptedDNSOverHTTPS:
+		return "doh"
+	default:
+		return "none"
+	}
+}
+
+const (
+	dnsOverTLSPort = 853
+	httpsPort      = 443
+)
+
+// knownDoHHosts are the hostnames of the public DNS over HTTPS servers
+var knownDoHHosts = []string{
+	"dns.google",
+	"dns.google.com",
+	"cloudflare-dns.com",
+	"mozilla.cloudflare-dns.com",
+	"chrome.cloudflare-dns.com",
+	"one.one.one.one",
+	"1dot1dot1dot1.cloudflare-dns.com",
+	"dns.quad9.net",
+	"dns9.quad9.net",
+	"dns10.quad9.net",
+	"dns11.quad9.net",
+	"doh.opendns.com",
+	"dns.nextdns.io",
+	"doh.cleanbrowsing.org",
+	"dns.adguard.com",
+	"dns.adguard-dns.com",
+	"doh.dns.sb",
+}
+
+// knownDoHAddresses are the addresses of the public resolvers which also serve DNS over HTTPS,
+// to which the clients bootstrapped with an IP connect without resolving a hostname
+var knownDoHAddresses = []string{
+	"8.8.8.8",
+	"8.8.4.4",
+	"1.1.1.1",
+	"1.0.0.1",
+	"9.9.9.9",
+	"149.112.112.112",
+	"208.67.222.222",
+	"208.67.220.220",
+	"2001:4860:4860::8888",
+	"2001:4860:4860::8844",
+	"2606:4700:4700::1111",
+	"2606:4700:4700::1001",
+	"2620:fe::fe",
+	"2620:fe::9",
+}
+
+var encryptedDNSTelemetry = struct {
+	connections telemetry.Counter
+}{
+	telemetry.NewCounter("network_tracer__encrypted_dns", "connections", []string{"protocol"}, "Counter measuring the number of connections opened to carry encrypted DNS"),
+}
+
+// EncryptedDNSKey identifies a server of encrypted DNS
+type EncryptedDNSKey struct {
+	// Host is the hostname of the server, or its address if it wasn't resolved from a known hostname
+	Host     string
+	Protocol EncryptedDNSProtocol
+}
+
+// EncryptedDNSStats counts the traffic to a server of encrypted DNS since the last check
+type EncryptedDNSStats struct {
+	// Connections is the number of connections opened since the last check, the long-lived connections
+	// are only counted in the check following their establishment
+	Connections uint32
+	SentBytes   uint64
+	RecvBytes   uint64
+}
+
+// EncryptedDNSDetector finds the connections carrying DNS over TLS or DNS over HTTPS, which bypass
+// the resolver of the host or the cluster. The TLS payload isn't available to the tracer, so rather than
+// the SNI and ALPN of the handshakes, it relies on port 853, on the hostnames the destinations were
+// resolved from and on the addresses of the public resolvers.
+type EncryptedDNSDetector struct {
+	hosts          map[string]struct{}
+	addresses      map[util.Address]struct{}
+	tagConnections bool
+}
+
+// NewEncryptedDNSDetector returns a detector recognizing the public DNS over HTTPS servers and extraHosts.
+// If tagConnections is set, the protocol is also set on the detected connections.
+func NewEncryptedDNSDetector(extraHosts []string, tagConnections bool) *EncryptedDNSDetector {
+	d := &EncryptedDNSDetector{
+		hosts:          make(map[string]struct{}, len(knownDoHHosts)+len(extraHosts)),
+		addresses:      make(map[util.Address]struct{}, len(knownDoHAddresses)),
+		tagConnections: tagConnections,
+	}
+	for _, host := range knownDoHHosts {
+		d.hosts[host] = struct{}{}
+	}
+	for _, host := range extraHosts {
+		d.hosts[strings.ToLower(strings.TrimSuffix(host, "."))] = struct{}{}
+	}
+	for _, addr := range knownDoHAddresses {
+		d.addresses[util.AddressFromString(addr)] = struct{}{}
+	}
+	return d
+}
+
+// classify returns the encrypted DNS protocol of the connection and the host of its server.
+// names are the hostnames the destination of the connection was resolved from.
+func (d *EncryptedDNSDetector) classify(c *ConnectionStats, names []dns.Hostname) (EncryptedDNSProtocol, string) {
+	if c.Type != TCP || c.Direction == INCOMING {
+		return EncryptedDNSNone, ""
+	}
+
+	switch c.DPort {
+	case dnsOverTLSPort:
+		if len(names) > 0 {
+			return EncryptedDNSOverTLS, dns.ToString(names[0])
+		}
+		return EncryptedDNSOverTLS, c.Dest.String()
+	case httpsPort:
+		for _, name := range names {
+			host := dns.ToString(name)
+			if _, ok := d.hosts[host]; ok {
+				return EncryptedDNSOverHTTPS, host
+			}
+		}
+		if _, ok := d.addresses[c.Dest]; ok {
+			return EncryptedDNSOverHTTPS, c.Dest.String()
+		}
+	}
+	return EncryptedDNSNone, ""
+}
+
+// Detect returns the counters of the encrypted DNS connections by server, tagging them if configured to.
+// It must be called once the destinations of the connections are resolved.
+func (d *EncryptedDNSDetector) Detect(conns *Connections) map[EncryptedDNSKey]*EncryptedDNSStats {
+	var stats map[EncryptedDNSKey]*EncryptedDNSStats
+	for i := range conns.Conns {
+		c := &conns.Conns[i]
+		protocol, host := d.classify(c, conns.DNS[c.Dest])
+		if protocol == EncryptedDNSNone {
+			continue
+		}
+		if d.tagConnections {
+			c.EncryptedDNS = protocol
+		}
+
+		if stats == nil {
+			stats = make(map[EncryptedDNSKey]*EncryptedDNSStats)
+		}
+		key := EncryptedDNSKey{Host: host, Protocol: protocol}
+		s, ok := stats[key]
+		if !ok {
+			s = &EncryptedDNSStats{}
+			stats[key] = s
+		}
+		if c.Last.TCPEstablished > 0 {
+			s.Connections++
+			encryptedDNSTelemetry.connections.Inc(protocol.String())
+		}
+		s.SentBytes += c.Last.SentBytes
+		s.RecvBytes += c.Last.RecvBytes
+	}
+	return stats
+}
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/dns"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestEncryptedDNSDetector(t *testing.T) {
+	conn := func(dest string, dport uint16, direction ConnectionDirection) ConnectionStats {
+		return ConnectionStats{
+			Source:    util.AddressFromString("10.0.0.1"),
+			Dest:      util.AddressFromString(dest),
+			SPort:     40000,
+			DPort:     dport,
+			Type:      TCP,
+			Direction: direction,
+			Last:      StatCounters{SentBytes: 100, RecvBytes: 200, TCPEstablished: 1},
+		}
+	}
+	conns := &Connections{
+		BufferedData: BufferedData{Conns: []ConnectionStats{
+			// DNS over TLS
+			conn("10.0.0.53", 853, OUTGOING),
+			conn("10.0.0.53", 853, OUTGOING),
+			// DNS over HTTPS to a public server resolved by name
+			conn("34.1.1.1", 443, OUTGOING),
+			// DNS over HTTPS to a server configured by address
+			conn("1.1.1.1", 443, OUTGOING),
+			// DNS over HTTPS to an extra host
+			conn("10.0.0.54", 443, OUTGOING),
+			// plain HTTPS
+			conn("34.2.2.2", 443, OUTGOING),
+			// incoming connection to a local DNS over TLS server
+			conn("10.0.0.2", 853, INCOMING),
+		}},
+		DNS: map[util.Address][]dns.Hostname{
+			util.AddressFromString("34.1.1.1"):  {dns.ToHostname("dns.google")},
+			util.AddressFromString("10.0.0.54"): {dns.ToHostname("doh.internal.example.com")},
+			util.AddressFromString("34.2.2.2"):  {dns.ToHostname("www.example.com")},
+		},
+	}
+
+	// a connection established before the last check only counts for its traffic
+	longLived := conn("10.0.0.53", 853, OUTGOING)
+	longLived.Last.TCPEstablished = 0
+	conns.Conns = append(conns.Conns, longLived)
+
+	stats := NewEncryptedDNSDetector([]string{"DoH.internal.example.com."}, false).Detect(conns)
+	require.Len(t, stats, 4)
+	assert.Equal(t, &EncryptedDNSStats{Connections: 2, SentBytes: 300, RecvBytes: 600}, stats[EncryptedDNSKey{Host: "10.0.0.53", Protocol: EncryptedDNSOverTLS}])
+	assert.Equal(t, uint32(1), stats[EncryptedDNSKey{Host: "dns.google", Protocol: EncryptedDNSOverHTTPS}].Connections)
+	assert.Equal(t, uint32(1), stats[EncryptedDNSKey{Host: "1.1.1.1", Protocol: EncryptedDNSOverHTTPS}].Connections)
+	assert.Equal(t, uint32(1), stats[EncryptedDNSKey{Host: "doh.internal.example.com", Protocol: EncryptedDNSOverHTTPS}].Connections)
+	for _, c := range conns.Conns {
+		assert.Equal(t, EncryptedDNSNone, c.EncryptedDNS)
+	}
+
+	NewEncryptedDNSDetector(nil, true).Detect(conns)
+	assert.Equal(t, EncryptedDNSOverTLS, conns.Conns[0].EncryptedDNS)
+	assert.Equal(t, EncryptedDNSOverHTTPS, conns.Conns[2].EncryptedDNS)
+	assert.Equal(t, EncryptedDNSNone, conns.Conns[4].EncryptedDNS)
+	assert.Equal(t, EncryptedDNSNone, conns.Conns[5].EncryptedDNS)
+	assert.Equal(t, EncryptedDNSNone, conns.Conns[6].EncryptedDNS)
+}
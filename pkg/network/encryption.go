@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import "github.com/DataDog/datadog-agent/pkg/network/protocols"
+
+// EncryptionStatus tells whether the payload of a TCP connection is encrypted
+type EncryptionStatus uint8
+
+const (
+	// EncryptionUnknown is used when the payload of the connection wasn't classified
+	EncryptionUnknown EncryptionStatus = iota
+	// EncryptionPlaintext is used when the connection carries a cleartext application protocol
+	EncryptionPlaintext
+	// EncryptionTLS is used when the connection carries TLS traffic
+	EncryptionTLS
+	// EncryptionSSH is used when the connection carries SSH traffic
+	EncryptionSSH
+)
+
+// ClassifyEncryption returns the encryption status of a connection from its protocol stack.
+// The result is only meaningful if protocol classification ran on the connection.
+func ClassifyEncryption(c *ConnectionStats) EncryptionStatus {
+	if c.Type != TCP {
+		return EncryptionUnknown
+	}
+	if IsTLSTag(c.StaticTags) || c.ProtocolStack.Encryption == protocols.TLS {
+		return EncryptionTLS
+	}
+	switch c.ProtocolStack.Application {
+	case protocols.SSH:
+		return EncryptionSSH
+	case protocols.HTTP, protocols.HTTP2, protocols.GRPC, protocols.WebSocket, protocols.Kafka,
+		protocols.Postgres, protocols.Redis, protocols.Mongo, protocols.AMQP, protocols.DNS:
+		// these protocols are only recognized from their cleartext messages. MySQL is left
+		// out, as its handshake is sent in cleartext even when the session switches to TLS.
+		return EncryptionPlaintext
+	default:
+		// a connection carrying an unrecognized protocol may as well be encrypted
+		return EncryptionUnknown
+	}
+}
+
+// GetEncryptionTag returns the tag for the encryption status of the connection,
+// or an empty string if the status is unknown
+func GetEncryptionTag(c *ConnectionStats) string {
+	if c.Encryption == EncryptionUnknown {
+		return ""
+	}
+	// the TLS tags of USM are only merged into the connection when encoding it
+	if c.Encryption == EncryptionTLS || c.Encryption == EncryptionSSH || ClassifyEncryption(c) == EncryptionTLS {
+		return "encrypted:true"
+	}
+	return "encrypted:false"
+}
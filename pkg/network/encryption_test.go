@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+)
+
+func TestClassifyEncryption(t *testing.T) {
+	c := ConnectionStats{Type: TCP}
+	assert.Equal(t, EncryptionUnknown, ClassifyEncryption(&c))
+
+	// traffic alone tells nothing about the encryption of the payload
+	c.Monotonic.SentBytes = 10
+	assert.Equal(t, EncryptionUnknown, ClassifyEncryption(&c))
+
+	c.ProtocolStack = protocols.Stack{Application: protocols.HTTP}
+	assert.Equal(t, EncryptionPlaintext, ClassifyEncryption(&c))
+
+	c.ProtocolStack = protocols.Stack{Application: protocols.MySQL}
+	assert.Equal(t, EncryptionUnknown, ClassifyEncryption(&c))
+
+	c.ProtocolStack = protocols.Stack{Application: protocols.SSH}
+	assert.Equal(t, EncryptionSSH, ClassifyEncryption(&c))
+
+	c.ProtocolStack = protocols.Stack{Application: protocols.HTTP, Encryption: protocols.TLS}
+	assert.Equal(t, EncryptionTLS, ClassifyEncryption(&c))
+
+	udp := ConnectionStats{Type: UDP}
+	udp.Monotonic.SentBytes = 10
+	assert.Equal(t, EncryptionUnknown, ClassifyEncryption(&udp))
+}
+
+func TestGetEncryptionTag(t *testing.T) {
+	c := ConnectionStats{Type: TCP}
+	c.Monotonic.SentBytes = 10
+	assert.Equal(t, "", GetEncryptionTag(&c))
+
+	c.Encryption = EncryptionPlaintext
+	assert.Equal(t, "encrypted:false", GetEncryptionTag(&c))
+
+	c.Encryption = EncryptionSSH
+	assert.Equal(t, "encrypted:true", GetEncryptionTag(&c))
+
+	c.Encryption = EncryptionPlaintext
+
+	// TLS found after the status was set
+	c.ProtocolStack = protocols.Stack{Encryption: protocols.TLS}
+	assert.Equal(t, "encrypted:true", GetEncryptionTag(&c))
+}
@@ -8,6 +8,7 @@ package network
 import (
 	"encoding/binary"
 	"fmt"
+	"net/netip"
 	"strings"
 	"time"
 
@@ -16,9 +17,13 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/network/dns"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/amqp"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mongo"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mysql"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/redis"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 )
 
@@ -29,7 +34,7 @@ const (
 	maxPacketCountChange uint64 = maxByteCountChange / 1300
 )
 
-// ConnectionType will be either TCP or UDP
+// ConnectionType will be either TCP, UDP or SCTP
 type ConnectionType uint8
 
 const (
@@ -38,13 +43,20 @@ const (
 
 	// UDP connection type
 	UDP ConnectionType = 1
+
+	// SCTP connection type
+	SCTP ConnectionType = 2
 )
 
 func (c ConnectionType) String() string {
-	if c == TCP {
+	switch c {
+	case TCP:
 		return "TCP"
+	case SCTP:
+		return "SCTP"
+	default:
+		return "UDP"
 	}
-	return "UDP"
 }
 
 const (
@@ -120,6 +132,33 @@ func (e EphemeralPortType) String() string {
 	}
 }
 
+// TCPFailure is the reason a TCP connection attempt failed, expressed as the errno reported by the kernel
+type TCPFailure uint32
+
+const (
+	// TCPFailureReset means the connection was reset before being established (ECONNRESET)
+	TCPFailureReset TCPFailure = 104
+
+	// TCPFailureTimeout means the connection attempt timed out, e.g. unanswered SYNs (ETIMEDOUT)
+	TCPFailureTimeout TCPFailure = 110
+
+	// TCPFailureRefused means a RST was received in response to a SYN (ECONNREFUSED)
+	TCPFailureRefused TCPFailure = 111
+)
+
+func (f TCPFailure) String() string {
+	switch f {
+	case TCPFailureReset:
+		return "reset"
+	case TCPFailureTimeout:
+		return "timeout"
+	case TCPFailureRefused:
+		return "refused"
+	default:
+		return fmt.Sprintf("errno %d", uint32(f))
+	}
+}
+
 // BufferedData encapsulates data whose underlying memory can be recycled
 type BufferedData struct {
 	Conns  []ConnectionStats
@@ -139,6 +178,19 @@ type Connections struct {
 	HTTP2                       map[http.Key]*http.RequestStats
 	Kafka                       map[kafka.Key]*kafka.RequestStat
 	Postgres                    map[postgres.Key]*postgres.RequestStat
+	MySQL                       map[mysql.Key]*mysql.RequestStat
+	Redis                       map[redis.Key]*redis.RequestStat
+	Mongo                       map[mongo.Key]*mongo.RequestStat
+	AMQP                        map[amqp.Key]*amqp.RequestStat
+	// InterfaceStats holds the counters of the network interfaces, sampled with the connections for the
+	// Prometheus metrics. The /interface_stats endpoint samples them on its own.
+	InterfaceStats []InterfaceStats
+	// Churn is the rate at which each process created and closed connections since the last check
+	Churn []ConnectionChurn
+	// ResolverLatencies holds the distributions of the latencies of the DNS servers since the last check
+	ResolverLatencies dns.LatenciesByResolver
+	// EncryptedDNS counts the connections to each server of DNS over TLS or DNS over HTTPS since the last check
+	EncryptedDNS map[EncryptedDNSKey]*EncryptedDNSStats
 }
 
 // NewConnections create a new Connections object
@@ -172,6 +224,8 @@ const (
 	ConnsBpfMapSize                 ConnTelemetryType = "conns_bpf_map_size"
 	ConntrackSamplingPercent        ConnTelemetryType = "conntrack_sampling_percent"
 	NPMDriverFlowsMissedMaxExceeded ConnTelemetryType = "driver_flows_missed_max_exceeded"
+	// ClosedConnBufferOverflows is the number of closed connections the buffer of the client had no room for
+	ClosedConnBufferOverflows ConnTelemetryType = "closed_conn_buffer_overflows"
 
 	// USM Payload Telemetry
 	USMHTTPHits ConnTelemetryType = "usm.http.total_hits"
@@ -233,6 +287,9 @@ type StatCounters struct {
 	//   are established with the same tuple between two agent checks;
 	TCPEstablished uint32
 	TCPClosed      uint32
+	// Drops is the number of packets of the connection dropped by the kernel, exported as the
+	// network.connection.drops metric by the OTLP exporter
+	Drops uint32
 }
 
 // IsZero returns whether all the stat counter values are zeroes
@@ -261,8 +318,26 @@ type ConnectionStats struct {
 	LastUpdateEpoch uint64
 	Duration        time.Duration
 
+	// Last time data was sent and received on this connection, 0 if no data was transferred
+	LastSentEpoch uint64
+	LastRecvEpoch uint64
+	// IdleDuration is how long no data was transferred on the connection as of the last check.
+	// It is only set for active connections, and exported as a gauge by the OTLP exporter, which
+	// reports the idle connections with it alone.
+	IdleDuration time.Duration
+
 	RTT    uint32 // Stored in µs
 	RTTVar uint32
+	// RTTHistogram is the distribution of the RTT samples of the connection since it was created,
+	// only collected if RTT histograms are enabled. It is sent as a histogram by the OTLP exporter.
+	RTTHistogram RTTHistogram
+
+	// TCP congestion state as of the close of the connection, it is not read for active connections.
+	// The algorithm is a tag of the payload, the rest is exported as gauges by the OTLP exporter.
+	CongestionAlgorithm string
+	DeliveryRate        uint64 // Stored in bytes per second
+	Cwnd                uint32 // Stored in segments
+	Ssthresh            uint32
 
 	Pid   uint32
 	NetNS uint32
@@ -279,24 +354,106 @@ type ConnectionStats struct {
 	IntraHost bool
 	IsAssured bool
 	IsClosed  bool
+	// IsDirectionObserved is set when Direction was taken from the first packet of the connection,
+	// in which case it is not subject to the port based direction fixes
+	IsDirectionObserved bool
+	// NATHairpin is set for the outgoing connections to an off-host address which
+	// are translated by the host's NAT back to a local workload
+	NATHairpin bool
+	// IsApproximate is set for the connections found by polling the sockets rather than traced with eBPF,
+	// for which short-lived connections are missed and some stats are unavailable
+	IsApproximate bool
+	// IsPreExisting is set for the connections established before the tracer started,
+	// which were seeded from procfs so their owner and direction are known
+	IsPreExisting bool
 
 	ContainerID struct {
 		Source, Dest *intern.Value
 	}
+	// Process is the process which created the connection, when captured by the eBPF tracer
+	Process ProcessInfo
+	// TLSInfo is the metadata of the TLS handshake of the connection, when read by the protocol classifier
+	TLSInfo TLSInfo
+	// WebSocket is the session of the connections upgraded to WebSocket, when tracked by the protocol classifier.
+	// The payload only tags the upgrade, the message and byte counts are part of the connection summaries.
+	WebSocket WebSocketSession
+	// AggregatedConnections is the number of connections rolled up into this one,
+	// or 0 if the connection was not rolled up. The OTLP exporter sends it as a gauge,
+	// so that the rolled up connections can be told apart from the single ones.
+	AggregatedConnections uint32
+
+	// CgroupID is set for the traffic aggregated by cgroup, in which case
+	// the connection has no local address nor ephemeral port
+	CgroupID uint64
+
+	// PairID is shared by the client and server side of an intra-host connection when both sides are
+	// tracked, so that the flow can be deduplicated. PeerPid is then the pid of the other side.
+	// Both are set as attributes of the metrics of the OTLP exporter.
+	PairID  uint64
+	PeerPid uint32
 
 	ProtocolStack protocols.Stack
+	// Encryption tells whether the payload of a TCP connection is encrypted,
+	// it is left unknown if protocol classification is not available
+	Encryption EncryptionStatus
+	// EncryptedDNS is the protocol of the encrypted DNS traffic carried by the connection,
+	// only set if the encrypted DNS connections are tagged
+	EncryptedDNS EncryptedDNSProtocol
+	// TrafficClass tells where the traffic routed through a gateway goes, relative to the cloud network
+	// of the host. It is only set if the connections are tagged with their traffic class.
+	TrafficClass TrafficClass
+
+	// DSCP is the differentiated services code point marking of the traffic sent, as read from the
+	// IP header of the last packet
+	DSCP uint8
+	// FlowLabel is the IPv6 flow label of the traffic sent, as read from the IP header of the last packet.
+	// It is effectively random per flow, which rules it out as a tag, so it is only set as an attribute
+	// of the metrics of the OTLP exporter.
+	FlowLabel uint32
 
 	DNSStats map[dns.Hostname]map[dns.QueryType]dns.Stats
+
+	// TCPFailures counts the failed connection attempts for this connection, by failure reason
+	TCPFailures map[TCPFailure]uint32
+}
+
+// ProcessInfo describes the process which created a connection. The payload attributes the
+// connection by pid and container id only, the comm filters the connections queried by
+// process name and is reported by the CEF exporter.
+type ProcessInfo struct {
+	Comm        *intern.Value
+	Exe         *intern.Value
+	CmdlineHash uint32
+	// CgroupID is the id of the cgroup v2 of the socket, from which the container is resolved
+	CgroupID uint64
 }
 
 // Via has info about the routing decision for a flow
 type Via struct {
 	Subnet Subnet `json:"subnet,omitempty"`
+	// Pending is set when the gateway is still being resolved in the background
+	Pending bool `json:"pending,omitempty"`
+	// OnLink is set when the destination is reached without a gateway, in which case there is
+	// no subnet to resolve
+	OnLink bool `json:"on_link,omitempty"`
 }
 
 // Subnet stores info about a subnet
 type Subnet struct {
 	Alias string `json:"alias,omitempty"`
+	// Cidr is the IPv4 address range of the subnet
+	Cidr string `json:"cidr,omitempty"`
+	// NetworkID is the ID of the cloud network of the subnet, such as the AWS VPC
+	NetworkID string `json:"network_id,omitempty"`
+	// NetworkCidrs are the IPv4 address ranges of the cloud network of the subnet
+	NetworkCidrs []netip.Prefix `json:"network_cidrs,omitempty"`
+	// InterfaceID is the ID of the cloud network interface of the host through which the flow
+	// reaches the subnet, such as the AWS ENI
+	InterfaceID string `json:"interface_id,omitempty"`
+	// Zone is the availability zone of the subnet
+	Zone string `json:"zone,omitempty"`
+	// Topology is the topology of the cloud network of the subnet, if it could be retrieved
+	Topology *CloudTopology `json:"-"`
 }
 
 // IPTranslation can be associated with a connection to show the connection is NAT'd
@@ -316,6 +473,24 @@ func (c ConnectionStats) IsExpired(now uint64, timeout uint64) bool {
 	return c.LastUpdateEpoch+timeout <= now
 }
 
+// LastActivityEpoch returns the last time data was sent or received on the connection,
+// or its last update if no data was transferred
+func (c ConnectionStats) LastActivityEpoch() uint64 {
+	if c.LastSentEpoch == 0 && c.LastRecvEpoch == 0 {
+		return c.LastUpdateEpoch
+	}
+	return max(c.LastSentEpoch, c.LastRecvEpoch)
+}
+
+// IdleFor returns how long no data was transferred on the connection according to the provided time
+func (c ConnectionStats) IdleFor(now uint64) time.Duration {
+	last := c.LastActivityEpoch()
+	if last >= now {
+		return 0
+	}
+	return time.Duration(now - last)
+}
+
 // ByteKey returns a unique key for this connection represented as a byte slice
 // It's as following:
 //
@@ -409,12 +584,34 @@ func ConnectionSummary(c *ConnectionStats, names map[util.Address][]dns.Hostname
 			c.Monotonic.TCPEstablished, c.Last.TCPEstablished,
 			c.Monotonic.TCPClosed, c.Last.TCPClosed,
 		)
+		if c.CongestionAlgorithm != "" {
+			str += fmt.Sprintf(", %s cwnd %d ssthresh %d delivery rate %s/s",
+				c.CongestionAlgorithm, c.Cwnd, c.Ssthresh, humanize.Bytes(c.DeliveryRate))
+		}
+		for reason, count := range c.TCPFailures {
+			str += fmt.Sprintf(", %d failed (%s)", count, reason)
+		}
+	}
+
+	if c.Monotonic.Drops > 0 {
+		str += fmt.Sprintf(", %d dropped (+%d)", c.Monotonic.Drops, c.Last.Drops)
 	}
 
 	str += fmt.Sprintf(", last update epoch: %d, cookie: %d", c.LastUpdateEpoch, c.Cookie)
 	str += fmt.Sprintf(", protocol: %+v", c.ProtocolStack)
 	str += fmt.Sprintf(", netns: %d", c.NetNS)
 	str += fmt.Sprintf(", duration: %+v", c.Duration)
+	if c.DSCP > 0 || c.FlowLabel > 0 {
+		str += fmt.Sprintf(", dscp: %d, flow label: %#x", c.DSCP, c.FlowLabel)
+	}
+	if c.IdleDuration > 0 {
+		str += fmt.Sprintf(", idle: %+v", c.IdleDuration)
+	}
+	if !c.WebSocket.IsEmpty() {
+		str += fmt.Sprintf(", websocket: %d messages (%s) sent, %d messages (%s) received",
+			c.WebSocket.SentMessages, humanize.Bytes(c.WebSocket.SentBytes),
+			c.WebSocket.RecvMessages, humanize.Bytes(c.WebSocket.RecvBytes))
+	}
 
 	return str
 }
@@ -468,6 +665,7 @@ func (s StatCounters) Add(other StatCounters) StatCounters {
 		SentPackets:    s.SentPackets + other.SentPackets,
 		TCPClosed:      s.TCPClosed + other.TCPClosed,
 		TCPEstablished: s.TCPEstablished + other.TCPEstablished,
+		Drops:          s.Drops + other.Drops,
 	}
 }
 
@@ -497,6 +695,7 @@ func (s StatCounters) Max(other StatCounters) StatCounters {
 		SentPackets:    maxUint64(s.SentPackets, other.SentPackets),
 		TCPClosed:      maxUint32(s.TCPClosed, other.TCPClosed),
 		TCPEstablished: maxUint32(s.TCPEstablished, other.TCPEstablished),
+		Drops:          maxUint32(s.Drops, other.Drops),
 	}
 }
 
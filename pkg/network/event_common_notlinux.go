@@ -14,6 +14,7 @@ package network
 func (s StatCounters) Sub(other StatCounters) (sc StatCounters, underflow bool) {
 	if (s.Retransmits < other.Retransmits && s.Retransmits > 0) ||
 		(s.TCPClosed < other.TCPClosed && s.TCPClosed > 0) ||
+		(s.Drops < other.Drops && s.Drops > 0) ||
 		(s.TCPEstablished < other.TCPEstablished && s.TCPEstablished > 0) ||
 		isUnderflow(other.RecvBytes, s.RecvBytes, maxByteCountChange) ||
 		isUnderflow(other.SentBytes, s.SentBytes, maxByteCountChange) ||
@@ -38,6 +39,9 @@ func (s StatCounters) Sub(other StatCounters) (sc StatCounters, underflow bool)
 	if s.TCPClosed > 0 {
 		sc.TCPClosed = s.TCPClosed - other.TCPClosed
 	}
+	if s.Drops > 0 {
+		sc.Drops = s.Drops - other.Drops
+	}
 
 	return sc, false
 }
@@ -10,6 +10,7 @@ import (
 	"net"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 
@@ -242,6 +243,27 @@ func TestIsExpired(t *testing.T) {
 	}
 }
 
+func TestIdleFor(t *testing.T) {
+	// a connection on which no data was transferred is idle since its last update
+	c := ConnectionStats{LastUpdateEpoch: 100}
+	assert.Equal(t, uint64(100), c.LastActivityEpoch())
+	assert.Equal(t, time.Duration(50), c.IdleFor(150))
+
+	c.LastSentEpoch = 80
+	c.LastRecvEpoch = 120
+	assert.Equal(t, uint64(120), c.LastActivityEpoch())
+	assert.Equal(t, time.Duration(30), c.IdleFor(150))
+	assert.Zero(t, c.IdleFor(110))
+}
+
+func TestConnectionSummaryWebSocket(t *testing.T) {
+	c := testConn
+	assert.NotContains(t, ConnectionSummary(&c, nil), "websocket")
+
+	c.WebSocket = WebSocketSession{Upgraded: 1, SentMessages: 3, SentBytes: 2000, RecvMessages: 1, RecvBytes: 10}
+	assert.Contains(t, ConnectionSummary(&c, nil), "websocket: 3 messages (2.0 kB) sent, 1 messages (10 B) received")
+}
+
 func BenchmarkByteKey(b *testing.B) {
 	buf := make([]byte, ConnectionByteKeyMaxLen)
 	addrA := util.AddressFromString("127.0.0.1")
@@ -0,0 +1,235 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package cef
+
+import (
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+type listeningKey struct {
+	connType network.ConnectionType
+	addr     util.Address
+	port     uint16
+	netNS    uint32
+}
+
+// reportedExpiry is how long a reported connection is remembered without being seen. The idle
+// connections are left out of the checks, so they must not be forgotten at the first check
+// which misses them.
+const reportedExpiry = 30 * time.Minute
+
+type connectionKey struct {
+	cookie    network.StatCookie
+	signature string
+}
+
+// Detector finds the notable events in the connections and the listening sockets, each one
+// being reported once. An event only counts as reported once its commit was called, so that
+// the events which couldn't be sent are found again by the next check.
+type Detector struct {
+	watchList []netip.Prefix
+
+	// listening holds the sockets of the last listing, nil until the first one, whose sockets are
+	// the baseline rather than new ones
+	listening map[listeningKey]struct{}
+	// reported holds when the reported connections were last seen, until they are seen closed
+	// or expire
+	reported map[connectionKey]time.Time
+}
+
+// NewDetector creates a detector reporting the connections to the given CIDRs
+func NewDetector(watchList []netip.Prefix) *Detector {
+	return &Detector{
+		watchList: watchList,
+		reported:  make(map[connectionKey]time.Time),
+	}
+}
+
+// ConnectionEvents returns the events of the connections to a CIDR of the watch list, and of
+// those which negotiated a deprecated TLS version
+func (d *Detector) ConnectionEvents(conns []network.ConnectionStats, now time.Time) []Event {
+	var events []Event
+	var closed []connectionKey
+	// report returns the commit of the event of the connection, or nil if it was already reported
+	report := func(c *network.ConnectionStats, signature string) func() {
+		k := connectionKey{cookie: c.Cookie, signature: signature}
+		if _, ok := d.reported[k]; ok {
+			d.reported[k] = now
+			if c.IsClosed {
+				closed = append(closed, k)
+			}
+			return nil
+		}
+		return func() {
+			// the closed connections are not seen again, there is no need to remember them
+			if !c.IsClosed {
+				d.reported[k] = now
+			}
+		}
+	}
+
+	for i := range conns {
+		c := &conns[i]
+		if d.watched(c.Dest) {
+			if commit := report(c, signatureWatchedCIDR); commit != nil {
+				events = append(events, Event{
+					SignatureID: signatureWatchedCIDR,
+					Name:        "Connection with a watched network",
+					Severity:    7,
+					Extensions:  connectionExtensions(c),
+					commit:      commit,
+				})
+			}
+		}
+
+		if c.TLSInfo.IsDeprecatedVersion() {
+			if commit := report(c, signatureDeprecatedTLS); commit != nil {
+				ext := connectionExtensions(c)
+				ext = append(ext,
+					Extension{"cs2Label", "tlsVersion"},
+					Extension{"cs2", network.TLSVersionName(c.TLSInfo.Version)},
+				)
+				if c.TLSInfo.ServerName != nil {
+					ext = append(ext, Extension{"dhost", c.TLSInfo.ServerName.Get().(string)})
+				}
+				events = append(events, Event{
+					SignatureID: signatureDeprecatedTLS,
+					Name:        "Connection with a deprecated TLS version",
+					Severity:    5,
+					Extensions:  ext,
+					commit:      commit,
+				})
+			}
+		}
+	}
+
+	for _, k := range closed {
+		delete(d.reported, k)
+	}
+	for k, seen := range d.reported {
+		if now.Sub(seen) > reportedExpiry {
+			delete(d.reported, k)
+		}
+	}
+	return events
+}
+
+// ListeningEvents returns the events of the sockets which started listening since the last call,
+// or whose event wasn't committed
+func (d *Detector) ListeningEvents(sockets []network.ListeningSocket) []Event {
+	var events []Event
+	listening := make(map[listeningKey]struct{}, len(sockets))
+	for _, s := range sockets {
+		k := listeningKey{connType: s.Type, addr: s.Addr, port: s.Port, netNS: s.NetNS}
+		if d.listening == nil {
+			listening[k] = struct{}{}
+			continue
+		}
+		if _, ok := d.listening[k]; ok {
+			listening[k] = struct{}{}
+			continue
+		}
+
+		addr := "0.0.0.0"
+		if s.Family == network.AFINET6 {
+			addr = "::"
+		}
+		if !s.IsWildcard() {
+			addr = s.Addr.String()
+		}
+		ext := []Extension{
+			{"proto", s.Type.String()},
+			{"dst", addr},
+			{"dpt", strconv.Itoa(int(s.Port))},
+		}
+		if s.Pid != 0 {
+			ext = append(ext, Extension{"dpid", strconv.Itoa(int(s.Pid))})
+		}
+		if s.ContainerID != "" {
+			ext = append(ext, Extension{"cs1Label", "containerId"}, Extension{"cs1", s.ContainerID})
+		}
+		events = append(events, Event{
+			SignatureID: signatureListeningPort,
+			Name:        "New listening port",
+			Severity:    5,
+			Extensions:  ext,
+			// the socket only joins the baseline once reported
+			commit: func() { listening[k] = struct{}{} },
+		})
+	}
+
+	d.listening = listening
+	return events
+}
+
+func (d *Detector) watched(addr util.Address) bool {
+	a := addr.Unmap()
+	for _, p := range d.watchList {
+		if p.Contains(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// connectionExtensions describes the connection from the point of view of the host, the
+// source being its local side
+func connectionExtensions(c *network.ConnectionStats) []Extension {
+	ext := []Extension{
+		{"proto", c.Type.String()},
+		{"src", c.Source.String()},
+		{"spt", strconv.Itoa(int(c.SPort))},
+		{"dst", c.Dest.String()},
+		{"dpt", strconv.Itoa(int(c.DPort))},
+		{"deviceDirection", deviceDirection(c.Direction)},
+	}
+	if c.Pid != 0 {
+		ext = append(ext, Extension{"spid", strconv.Itoa(int(c.Pid))})
+	}
+	if c.Process.Comm != nil {
+		ext = append(ext, Extension{"sproc", c.Process.Comm.Get().(string)})
+	}
+	if c.ContainerID.Source != nil {
+		ext = append(ext, Extension{"cs1Label", "containerId"}, Extension{"cs1", c.ContainerID.Source.Get().(string)})
+	}
+	return ext
+}
+
+// deviceDirection returns the direction as defined by CEF, 0 for inbound and 1 for outbound
+func deviceDirection(d network.ConnectionDirection) string {
+	if d == network.INCOMING {
+		return "0"
+	}
+	return "1"
+}
+
+// ParseWatchList parses the CIDRs, or single addresses, of the watch list
+func ParseWatchList(cidrs []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, s := range cidrs {
+		s = strings.TrimSpace(s)
+		if !strings.Contains(s, "/") {
+			addr, err := netip.ParseAddr(s)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+	return prefixes, nil
+}
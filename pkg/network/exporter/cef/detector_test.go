@@ -0,0 +1,143 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package cef
+
+import (
+	"crypto/tls"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestConnectionEvents(t *testing.T) {
+	watchList, err := ParseWatchList([]string{"203.0.113.0/24", "198.51.100.7"})
+	require.NoError(t, err)
+	d := NewDetector(watchList)
+
+	watched := network.ConnectionStats{
+		Source:    util.AddressFromString("10.0.0.1"),
+		Dest:      util.AddressFromString("203.0.113.10"),
+		SPort:     40000,
+		DPort:     443,
+		Type:      network.TCP,
+		Direction: network.OUTGOING,
+		Cookie:    1,
+	}
+	deprecatedTLS := network.ConnectionStats{
+		Source:    util.AddressFromString("10.0.0.1"),
+		Dest:      util.AddressFromString("10.0.0.2"),
+		SPort:     40001,
+		DPort:     443,
+		Type:      network.TCP,
+		Direction: network.OUTGOING,
+		Cookie:    2,
+		TLSInfo:   network.TLSInfo{Version: tls.VersionTLS10},
+	}
+	other := network.ConnectionStats{
+		Source: util.AddressFromString("10.0.0.1"),
+		Dest:   util.AddressFromString("198.51.100.8"),
+		Type:   network.TCP,
+		Cookie: 3,
+	}
+
+	now := time.Now()
+	events := d.ConnectionEvents([]network.ConnectionStats{watched, deprecatedTLS, other}, now)
+	require.Len(t, events, 2)
+	assert.Equal(t, signatureWatchedCIDR, events[0].SignatureID)
+	assert.Contains(t, events[0].Extensions, Extension{"dst", "203.0.113.10"})
+	assert.Contains(t, events[0].Extensions, Extension{"deviceDirection", "1"})
+	assert.Equal(t, signatureDeprecatedTLS, events[1].SignatureID)
+	assert.Contains(t, events[1].Extensions, Extension{"cs2", "tls_1.0"})
+
+	// the events are found again until they are committed
+	events = d.ConnectionEvents([]network.ConnectionStats{watched, deprecatedTLS, other}, now)
+	require.Len(t, events, 2)
+	commit(events)
+
+	// the connections are reported once
+	assert.Empty(t, d.ConnectionEvents([]network.ConnectionStats{watched, deprecatedTLS, other}, now))
+
+	// even when they are idle, and so missing from some checks
+	now = now.Add(time.Minute)
+	assert.Empty(t, d.ConnectionEvents(nil, now))
+	assert.Empty(t, d.ConnectionEvents([]network.ConnectionStats{watched}, now))
+
+	// the closed connections are forgotten
+	closed := deprecatedTLS
+	closed.IsClosed = true
+	assert.Empty(t, d.ConnectionEvents([]network.ConnectionStats{closed}, now))
+	assert.NotContains(t, d.reported, connectionKey{cookie: deprecatedTLS.Cookie, signature: signatureDeprecatedTLS})
+
+	// and so are those which expire
+	now = now.Add(reportedExpiry + time.Second)
+	assert.Empty(t, d.ConnectionEvents(nil, now))
+	assert.Empty(t, d.reported)
+	assert.Len(t, d.ConnectionEvents([]network.ConnectionStats{watched}, now), 1)
+
+	// the closed connections are not remembered once reported
+	d = NewDetector(watchList)
+	closed = watched
+	closed.IsClosed = true
+	commit(d.ConnectionEvents([]network.ConnectionStats{closed}, now))
+	assert.Empty(t, d.reported)
+}
+
+func TestListeningEvents(t *testing.T) {
+	d := NewDetector(nil)
+
+	ssh := network.ListeningSocket{Type: network.TCP, Family: network.AFINET, Port: 22, Pid: 1}
+	// the first listing is the baseline
+	assert.Empty(t, d.ListeningEvents([]network.ListeningSocket{ssh}))
+
+	dns := network.ListeningSocket{
+		Type:        network.UDP,
+		Family:      network.AFINET,
+		Addr:        util.AddressFromString("127.0.0.53"),
+		Port:        53,
+		Pid:         2,
+		ContainerID: "abc",
+	}
+	events := d.ListeningEvents([]network.ListeningSocket{ssh, dns})
+	require.Len(t, events, 1)
+	assert.Equal(t, signatureListeningPort, events[0].SignatureID)
+	assert.Equal(t, []Extension{
+		{"proto", "UDP"},
+		{"dst", "127.0.0.53"},
+		{"dpt", "53"},
+		{"dpid", "2"},
+		{"cs1Label", "containerId"},
+		{"cs1", "abc"},
+	}, events[0].Extensions)
+
+	// the socket joins the baseline once its event is committed
+	require.Len(t, d.ListeningEvents([]network.ListeningSocket{ssh, dns}), 1)
+	commit(d.ListeningEvents([]network.ListeningSocket{ssh, dns}))
+	assert.Empty(t, d.ListeningEvents([]network.ListeningSocket{ssh, dns}))
+}
+
+func commit(events []Event) {
+	for _, ev := range events {
+		ev.Commit()
+	}
+}
+
+func TestParseWatchList(t *testing.T) {
+	prefixes, err := ParseWatchList([]string{"10.1.2.3/8", " 2001:db8::1 "})
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("2001:db8::1/128"),
+	}, prefixes)
+
+	_, err = ParseWatchList([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package cef exports the notable network events, such as new listening ports, as messages in the
+// Common Event Format sent over syslog, to the collector of a SIEM
+package cef
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+const (
+	cefVendor  = "Datadog"
+	cefProduct = "system-probe"
+)
+
+// signature ids of the events
+const (
+	signatureListeningPort = "new-listening-port"
+	signatureWatchedCIDR   = "watched-cidr-connection"
+	signatureDeprecatedTLS = "deprecated-tls-version"
+)
+
+// Event is a notable network event
+type Event struct {
+	SignatureID string
+	Name        string
+	// Severity goes from 0 to 10, the most important events having the highest one
+	Severity   int
+	Extensions []Extension
+
+	// commit marks the event as reported, once sent
+	commit func()
+}
+
+// Commit marks the event as reported, so that the detector doesn't find it again
+func (e Event) Commit() {
+	if e.commit != nil {
+		e.commit()
+	}
+}
+
+// Extension is a key=value pair of the extension of a CEF message, the keys being the ones of the CEF dictionary
+type Extension struct {
+	Key, Value string
+}
+
+var (
+	headerEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`)
+	extensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+)
+
+// String formats the event as a CEF message
+func (e Event) String() string {
+	var b strings.Builder
+	b.WriteString("CEF:0|")
+	for _, field := range []string{cefVendor, cefProduct, version.AgentVersion, e.SignatureID, e.Name} {
+		b.WriteString(headerEscaper.Replace(field))
+		b.WriteByte('|')
+	}
+	b.WriteString(strconv.Itoa(e.Severity))
+	b.WriteByte('|')
+	for i, ext := range e.Extensions {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(ext.Key)
+		b.WriteByte('=')
+		b.WriteString(extensionEscaper.Replace(ext.Value))
+	}
+	return b.String()
+}
@@ -0,0 +1,140 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package cef
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	exporterModuleName = "network__cef_exporter"
+	appName            = "system-probe"
+
+	// the messages are sent with the local4 facility
+	syslogFacility       = 20
+	syslogSeverityWarn   = 4
+	syslogSeverityNotice = 5
+	syslogTimestamp      = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+var exporterTelemetry = struct {
+	events telemetry.Counter
+	errors telemetry.Counter
+}{
+	telemetry.NewCounter(exporterModuleName, "events", []string{"signature"}, "Counter measuring the number of events sent to the syslog collector"),
+	telemetry.NewCounter(exporterModuleName, "errors", []string{}, "Counter measuring the number of events which couldn't be sent to the syslog collector"),
+}
+
+// Exporter sends the notable events found in the connections as CEF messages over syslog (RFC 5424)
+type Exporter struct {
+	protocol, address string
+	conn              net.Conn
+	hostname          string
+	pid               int
+
+	detector         *Detector
+	listeningSockets func() ([]network.ListeningSocket, error)
+}
+
+// NewExporter creates an exporter sending to the syslog collector at the given address over udp or tcp.
+// The new listening ports are only reported when listeningSockets is set.
+func NewExporter(protocol, address string, detector *Detector, listeningSockets func() ([]network.ListeningSocket, error)) (*Exporter, error) {
+	if protocol != "udp" && protocol != "tcp" {
+		return nil, fmt.Errorf("unsupported syslog protocol %q", protocol)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-"
+	}
+	e := &Exporter{
+		protocol:         protocol,
+		address:          address,
+		hostname:         hostname,
+		pid:              os.Getpid(),
+		detector:         detector,
+		listeningSockets: listeningSockets,
+	}
+	if e.conn, err = net.Dial(protocol, address); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Export sends the events of the given connections, and of the sockets which started listening since the last export.
+// The events which couldn't be sent are left unreported, to be sent by the next export.
+func (e *Exporter) Export(conns *network.Connections) error {
+	now := time.Now()
+	events := e.detector.ConnectionEvents(conns.Conns, now)
+	if e.listeningSockets != nil {
+		sockets, err := e.listeningSockets()
+		if errors.Is(err, ebpf.ErrNotImplemented) {
+			e.listeningSockets = nil
+		} else if err != nil {
+			log.Debugf("unable to retrieve the listening sockets: %s", err)
+		} else {
+			events = append(events, e.detector.ListeningEvents(sockets)...)
+		}
+	}
+
+	var errs []error
+	for _, ev := range events {
+		if err := e.send(ev, now); err != nil {
+			exporterTelemetry.errors.Inc()
+			errs = append(errs, err)
+			continue
+		}
+		ev.Commit()
+		exporterTelemetry.events.Inc(ev.SignatureID)
+	}
+	return errors.Join(errs...)
+}
+
+func (e *Exporter) send(ev Event, now time.Time) error {
+	if e.conn == nil {
+		conn, err := net.Dial(e.protocol, e.address)
+		if err != nil {
+			return err
+		}
+		e.conn = conn
+	}
+
+	severity := syslogSeverityNotice
+	if ev.Severity >= 7 {
+		severity = syslogSeverityWarn
+	}
+	msg := fmt.Sprintf("<%d>1 %s %s %s %d %s - %s",
+		syslogFacility*8+severity, now.Format(syslogTimestamp), e.hostname, appName, e.pid, ev.SignatureID, ev)
+	if e.protocol == "tcp" {
+		// the messages are delimited by a newline over tcp (RFC 6587)
+		msg += "\n"
+	}
+
+	if _, err := e.conn.Write([]byte(msg)); err != nil {
+		// the connection is dialed again on the next event, in case the collector restarted
+		e.conn.Close()
+		e.conn = nil
+		return err
+	}
+	return nil
+}
+
+// Close closes the connection to the collector
+func (e *Exporter) Close() error {
+	if e.conn == nil {
+		return nil
+	}
+	return e.conn.Close()
+}
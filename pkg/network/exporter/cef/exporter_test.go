@@ -0,0 +1,85 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package cef
+
+import (
+	"net"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestEventString(t *testing.T) {
+	e := Event{
+		SignatureID: "sig|1",
+		Name:        `a\b`,
+		Severity:    5,
+		Extensions:  []Extension{{"dst", "10.0.0.1"}, {"msg", "a=b\nc"}},
+	}
+	assert.Regexp(t, `^CEF:0\|Datadog\|system-probe\|[^|]*\|sig\\\|1\|a\\\\b\|5\|dst=10\.0\.0\.1 msg=a\\=b\\nc$`, e.String())
+}
+
+func TestExport(t *testing.T) {
+	collector, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer collector.Close()
+
+	watchList, err := ParseWatchList([]string{"203.0.113.0/24"})
+	require.NoError(t, err)
+	e, err := NewExporter("udp", collector.LocalAddr().String(), NewDetector(watchList), nil)
+	require.NoError(t, err)
+	defer e.Close()
+
+	conns := &network.Connections{BufferedData: network.BufferedData{Conns: []network.ConnectionStats{{
+		Source: util.AddressFromString("10.0.0.1"),
+		Dest:   util.AddressFromString("203.0.113.10"),
+		SPort:  40000,
+		DPort:  443,
+		Type:   network.TCP,
+		Cookie: 1,
+	}}}}
+	require.NoError(t, e.Export(conns))
+
+	buf := make([]byte, 2048)
+	require.NoError(t, collector.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := collector.ReadFrom(buf)
+	require.NoError(t, err)
+
+	// local4.warning
+	msg := string(buf[:n])
+	assert.Regexp(t, regexp.MustCompile(`^<164>1 \S+ \S+ system-probe \d+ watched-cidr-connection - CEF:0\|Datadog\|system-probe\|[^|]*\|watched-cidr-connection\|Connection with a watched network\|7\|proto=TCP src=10\.0\.0\.1 spt=40000 dst=203\.0\.113\.10 dpt=443 deviceDirection=1$`), msg)
+
+	_, err = NewExporter("tls", "127.0.0.1:514", NewDetector(nil), nil)
+	assert.Error(t, err)
+}
+
+func TestExportFailure(t *testing.T) {
+	// nothing listens on the address of a closed listener
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := l.Addr().String()
+	l.Close()
+
+	watchList, err := ParseWatchList([]string{"203.0.113.0/24"})
+	require.NoError(t, err)
+	d := NewDetector(watchList)
+	e := &Exporter{protocol: "tcp", address: address, hostname: "-", detector: d}
+
+	conns := []network.ConnectionStats{
+		{Dest: util.AddressFromString("203.0.113.10"), Type: network.TCP, Cookie: 1},
+		{Dest: util.AddressFromString("203.0.113.11"), Type: network.TCP, Cookie: 2},
+	}
+	assert.Error(t, e.Export(&network.Connections{BufferedData: network.BufferedData{Conns: conns}}))
+
+	// the events which couldn't be sent are found again by the next export
+	assert.Len(t, d.ConnectionEvents(conns, time.Now()), 2)
+}
@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package ipfix
+
+import (
+	"net"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+)
+
+const exporterModuleName = "network__ipfix_exporter"
+
+var exporterTelemetry = struct {
+	messages telemetry.Counter
+	errors   telemetry.Counter
+}{
+	telemetry.NewCounter(exporterModuleName, "messages", []string{}, "Counter measuring the number of IPFIX messages sent to the collector"),
+	telemetry.NewCounter(exporterModuleName, "errors", []string{}, "Counter measuring the number of IPFIX messages which couldn't be sent to the collector"),
+}
+
+// Exporter sends connections as IPFIX flows to a collector over UDP
+type Exporter struct {
+	conn    net.Conn
+	encoder *Encoder
+}
+
+// NewExporter creates an exporter sending to the collector at the given host:port address
+func NewExporter(collector string, observationDomainID uint32) (*Exporter, error) {
+	conn, err := net.Dial("udp", collector)
+	if err != nil {
+		return nil, err
+	}
+	return &Exporter{
+		conn:    conn,
+		encoder: NewEncoder(observationDomainID),
+	}, nil
+}
+
+// Export sends the flows of the given connections
+func (e *Exporter) Export(conns *network.Connections) error {
+	for _, m := range e.encoder.Encode(conns.Conns, time.Now()) {
+		if _, err := e.conn.Write(m); err != nil {
+			exporterTelemetry.errors.Inc()
+			return err
+		}
+		exporterTelemetry.messages.Inc()
+	}
+	return nil
+}
+
+// Close closes the connection to the collector
+func (e *Exporter) Close() error {
+	return e.conn.Close()
+}
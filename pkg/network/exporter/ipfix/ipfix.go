@@ -0,0 +1,236 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package ipfix encodes the connections of the network tracer as IPFIX flows (RFC 7011),
+// for the flow collectors of existing flow analysis pipelines
+package ipfix
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+const (
+	version       = 10
+	headerLen     = 16
+	setHeaderLen  = 4
+	templateSetID = 2
+
+	templateIDv4 = 256
+	templateIDv6 = 257
+
+	// maxMessageSize keeps the messages under the usual path MTU, as they are sent over UDP
+	maxMessageSize = 1400
+)
+
+// information elements, https://www.iana.org/assignments/ipfix/ipfix.xhtml
+const (
+	ieOctetDeltaCount          = 1
+	iePacketDeltaCount         = 2
+	ieProtocolIdentifier       = 4
+	ieSourceTransportPort      = 7
+	ieSourceIPv4Address        = 8
+	ieDestinationTransportPort = 11
+	ieDestinationIPv4Address   = 12
+	ieSourceIPv6Address        = 27
+	ieDestinationIPv6Address   = 28
+	ieFlowDirection            = 61
+	ieFlowEndMilliseconds      = 153
+)
+
+// values of the flowDirection information element
+const (
+	flowDirectionIngress = 0
+	flowDirectionEgress  = 1
+)
+
+type field struct {
+	id, length uint16
+}
+
+func templateFields(addrLen uint16, srcID, dstID uint16) []field {
+	return []field{
+		{srcID, addrLen},
+		{dstID, addrLen},
+		{ieSourceTransportPort, 2},
+		{ieDestinationTransportPort, 2},
+		{ieProtocolIdentifier, 1},
+		{ieOctetDeltaCount, 8},
+		{iePacketDeltaCount, 8},
+		{ieFlowDirection, 1},
+		{ieFlowEndMilliseconds, 8},
+	}
+}
+
+// templateSet holds the templates of the IPv4 and IPv6 flows. It is sent in every message, so
+// that a collector can decode the flows right away, and isn't affected by a lost datagram.
+var templateSet = func() []byte {
+	b := []byte{0, templateSetID, 0, 0}
+	for _, t := range []struct {
+		id     uint16
+		fields []field
+	}{
+		{templateIDv4, templateFields(4, ieSourceIPv4Address, ieDestinationIPv4Address)},
+		{templateIDv6, templateFields(16, ieSourceIPv6Address, ieDestinationIPv6Address)},
+	} {
+		b = binary.BigEndian.AppendUint16(b, t.id)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(t.fields)))
+		for _, f := range t.fields {
+			b = binary.BigEndian.AppendUint16(b, f.id)
+			b = binary.BigEndian.AppendUint16(b, f.length)
+		}
+	}
+	binary.BigEndian.PutUint16(b[2:], uint16(len(b)))
+	return b
+}()
+
+// Encoder encodes connections into IPFIX messages
+type Encoder struct {
+	observationDomainID uint32
+	// sequence is the number of data records sent, as defined by RFC 7011
+	sequence uint32
+}
+
+// NewEncoder creates an encoder for the given observation domain
+func NewEncoder(observationDomainID uint32) *Encoder {
+	return &Encoder{observationDomainID: observationDomainID}
+}
+
+// Encode returns the IPFIX messages carrying the flows of the given connections, each one fitting in a
+// datagram. A connection is exported as two flows, one for each direction of its traffic, with the
+// counters of its last interval. The flows without any traffic are left out.
+func (e *Encoder) Encode(conns []network.ConnectionStats, exportTime time.Time) [][]byte {
+	var messages [][]byte
+	m := newMessage()
+	var record []byte
+	for i := range conns {
+		c := &conns[i]
+		proto, ok := protocolNumber(c.Type)
+		if !ok {
+			continue
+		}
+
+		setID := uint16(templateIDv4)
+		if c.Family == network.AFINET6 {
+			setID = templateIDv6
+		}
+
+		flows := [2]struct {
+			src, dst        util.Address
+			sport, dport    uint16
+			octets, packets uint64
+			direction       uint8
+		}{
+			{c.Source, c.Dest, c.SPort, c.DPort, c.Last.SentBytes, c.Last.SentPackets, flowDirectionEgress},
+			{c.Dest, c.Source, c.DPort, c.SPort, c.Last.RecvBytes, c.Last.RecvPackets, flowDirectionIngress},
+		}
+		for _, f := range flows {
+			if f.octets == 0 && f.packets == 0 {
+				continue
+			}
+
+			record = record[:0]
+			record = appendAddress(record, f.src, c.Family)
+			record = appendAddress(record, f.dst, c.Family)
+			record = binary.BigEndian.AppendUint16(record, f.sport)
+			record = binary.BigEndian.AppendUint16(record, f.dport)
+			record = append(record, proto)
+			record = binary.BigEndian.AppendUint64(record, f.octets)
+			record = binary.BigEndian.AppendUint64(record, f.packets)
+			record = append(record, f.direction)
+			record = binary.BigEndian.AppendUint64(record, uint64(exportTime.UnixMilli()))
+
+			if !m.add(setID, record) {
+				messages = append(messages, e.finish(m, exportTime))
+				m = newMessage()
+				m.add(setID, record)
+			}
+		}
+	}
+
+	if m.records > 0 {
+		messages = append(messages, e.finish(m, exportTime))
+	}
+	return messages
+}
+
+// finish writes the header of the message, once all its records were added
+func (e *Encoder) finish(m *message, exportTime time.Time) []byte {
+	m.closeSet()
+	binary.BigEndian.PutUint16(m.buf[0:], version)
+	binary.BigEndian.PutUint16(m.buf[2:], uint16(len(m.buf)))
+	binary.BigEndian.PutUint32(m.buf[4:], uint32(exportTime.Unix()))
+	binary.BigEndian.PutUint32(m.buf[8:], e.sequence)
+	binary.BigEndian.PutUint32(m.buf[12:], e.observationDomainID)
+	e.sequence += m.records
+	return m.buf
+}
+
+type message struct {
+	buf     []byte
+	setID   uint16
+	setOff  int
+	records uint32
+}
+
+func newMessage() *message {
+	buf := make([]byte, headerLen, maxMessageSize)
+	return &message{buf: append(buf, templateSet...)}
+}
+
+// add appends the record to the data set of the given template, and returns false
+// if the message is full
+func (m *message) add(setID uint16, record []byte) bool {
+	size := len(record)
+	if m.setID != setID {
+		size += setHeaderLen
+	}
+	if len(m.buf)+size > maxMessageSize {
+		return false
+	}
+
+	if m.setID != setID {
+		m.closeSet()
+		m.setID = setID
+		m.setOff = len(m.buf)
+		m.buf = binary.BigEndian.AppendUint16(m.buf, setID)
+		m.buf = append(m.buf, 0, 0)
+	}
+	m.buf = append(m.buf, record...)
+	m.records++
+	return true
+}
+
+// closeSet writes the length of the current data set
+func (m *message) closeSet() {
+	if m.setID != 0 {
+		binary.BigEndian.PutUint16(m.buf[m.setOff+2:], uint16(len(m.buf)-m.setOff))
+	}
+}
+
+func appendAddress(b []byte, addr util.Address, family network.ConnectionFamily) []byte {
+	if family == network.AFINET6 {
+		a := addr.As16()
+		return append(b, a[:]...)
+	}
+	a := addr.Unmap().As4()
+	return append(b, a[:]...)
+}
+
+func protocolNumber(t network.ConnectionType) (uint8, bool) {
+	switch t {
+	case network.TCP:
+		return 6, true
+	case network.UDP:
+		return 17, true
+	case network.SCTP:
+		return 132, true
+	default:
+		return 0, false
+	}
+}
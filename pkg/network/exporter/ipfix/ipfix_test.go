@@ -0,0 +1,126 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package ipfix
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestEncode(t *testing.T) {
+	exportTime := time.Unix(1700000000, 0)
+	conns := []network.ConnectionStats{
+		{
+			Source: util.AddressFromString("10.0.0.1"),
+			Dest:   util.AddressFromString("10.0.0.2"),
+			SPort:  40000,
+			DPort:  443,
+			Type:   network.TCP,
+			Family: network.AFINET,
+			Last:   network.StatCounters{SentBytes: 100, SentPackets: 2, RecvBytes: 300, RecvPackets: 3},
+		},
+		{
+			// no traffic, left out
+			Source: util.AddressFromString("10.0.0.1"),
+			Dest:   util.AddressFromString("10.0.0.3"),
+			Type:   network.UDP,
+			Family: network.AFINET,
+		},
+		{
+			Source: util.AddressFromString("fd00::1"),
+			Dest:   util.AddressFromString("fd00::2"),
+			SPort:  5353,
+			DPort:  53,
+			Type:   network.UDP,
+			Family: network.AFINET6,
+			Last:   network.StatCounters{SentBytes: 60, SentPackets: 1},
+		},
+	}
+
+	e := NewEncoder(42)
+	messages := e.Encode(conns, exportTime)
+	require.Len(t, messages, 1)
+	m := messages[0]
+
+	assert.Equal(t, uint16(version), binary.BigEndian.Uint16(m[0:]))
+	assert.Equal(t, uint16(len(m)), binary.BigEndian.Uint16(m[2:]))
+	assert.Equal(t, uint32(exportTime.Unix()), binary.BigEndian.Uint32(m[4:]))
+	assert.Equal(t, uint32(0), binary.BigEndian.Uint32(m[8:]))
+	assert.Equal(t, uint32(42), binary.BigEndian.Uint32(m[12:]))
+
+	b := m[headerLen:]
+	assert.Equal(t, templateSet, b[:len(templateSet)])
+	b = b[len(templateSet):]
+
+	// the ipv4 data set, with both directions of the tcp connection
+	assert.Equal(t, uint16(templateIDv4), binary.BigEndian.Uint16(b[0:]))
+	require.Equal(t, uint16(setHeaderLen+2*38), binary.BigEndian.Uint16(b[2:]))
+	egress := b[setHeaderLen : setHeaderLen+38]
+	assert.Equal(t, []byte{10, 0, 0, 1}, egress[0:4])
+	assert.Equal(t, []byte{10, 0, 0, 2}, egress[4:8])
+	assert.Equal(t, uint16(40000), binary.BigEndian.Uint16(egress[8:]))
+	assert.Equal(t, uint16(443), binary.BigEndian.Uint16(egress[10:]))
+	assert.Equal(t, uint8(6), egress[12])
+	assert.Equal(t, uint64(100), binary.BigEndian.Uint64(egress[13:]))
+	assert.Equal(t, uint64(2), binary.BigEndian.Uint64(egress[21:]))
+	assert.Equal(t, uint8(flowDirectionEgress), egress[29])
+	assert.Equal(t, uint64(exportTime.UnixMilli()), binary.BigEndian.Uint64(egress[30:]))
+
+	ingress := b[setHeaderLen+38 : setHeaderLen+2*38]
+	assert.Equal(t, []byte{10, 0, 0, 2}, ingress[0:4])
+	assert.Equal(t, []byte{10, 0, 0, 1}, ingress[4:8])
+	assert.Equal(t, uint16(443), binary.BigEndian.Uint16(ingress[8:]))
+	assert.Equal(t, uint16(40000), binary.BigEndian.Uint16(ingress[10:]))
+	assert.Equal(t, uint64(300), binary.BigEndian.Uint64(ingress[13:]))
+	assert.Equal(t, uint64(3), binary.BigEndian.Uint64(ingress[21:]))
+	assert.Equal(t, uint8(flowDirectionIngress), ingress[29])
+	b = b[setHeaderLen+2*38:]
+
+	// the ipv6 data set, with the egress direction only
+	assert.Equal(t, uint16(templateIDv6), binary.BigEndian.Uint16(b[0:]))
+	require.Equal(t, uint16(setHeaderLen+62), binary.BigEndian.Uint16(b[2:]))
+	require.Len(t, b, setHeaderLen+62)
+	assert.Equal(t, util.AddressFromString("fd00::1").AsSlice(), b[setHeaderLen:setHeaderLen+16])
+	assert.Equal(t, uint8(17), b[setHeaderLen+36])
+
+	// the sequence number counts the data records
+	messages = e.Encode(conns, exportTime)
+	require.Len(t, messages, 1)
+	assert.Equal(t, uint32(3), binary.BigEndian.Uint32(messages[0][8:]))
+}
+
+func TestEncodeSplitsMessages(t *testing.T) {
+	conns := make([]network.ConnectionStats, 100)
+	for i := range conns {
+		conns[i] = network.ConnectionStats{
+			Source: util.AddressFromString("10.0.0.1"),
+			Dest:   util.AddressFromString("10.0.0.2"),
+			SPort:  uint16(30000 + i),
+			DPort:  80,
+			Type:   network.TCP,
+			Family: network.AFINET,
+			Last:   network.StatCounters{SentBytes: 1, SentPackets: 1},
+		}
+	}
+
+	messages := NewEncoder(0).Encode(conns, time.Now())
+	require.Greater(t, len(messages), 1)
+
+	var records uint32
+	for _, m := range messages {
+		assert.LessOrEqual(t, len(m), maxMessageSize)
+		assert.Equal(t, records, binary.BigEndian.Uint32(m[8:]))
+		records += uint32(len(m)-headerLen-len(templateSet)-setHeaderLen) / 38
+	}
+	assert.Equal(t, uint32(100), records)
+}
@@ -0,0 +1,278 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package metrics aggregates the connections of the network tracer into Prometheus metrics, so that
+// the monitoring of a cluster can scrape them from system-probe directly
+package metrics
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/DataDog/datadog-agent/comp/core/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const namespace = "system_probe_network"
+
+var (
+	connectionsDesc = prometheus.NewDesc(namespace+"_connections",
+		"Number of open connections", []string{"type", "family", "direction"}, nil)
+	connectionsClosedDesc = prometheus.NewDesc(namespace+"_connections_closed_total",
+		"Number of closed connections", []string{"type", "family", "direction"}, nil)
+	bytesDesc = prometheus.NewDesc(namespace+"_bytes_total",
+		"Bytes sent and received on the connections, by application protocol and class of traffic", []string{"direction", "protocol", "traffic_class"}, nil)
+	dnsResponsesDesc = prometheus.NewDesc(namespace+"_dns_responses_total",
+		"Number of DNS responses, by response code", []string{"rcode"}, nil)
+	dnsTimeoutsDesc = prometheus.NewDesc(namespace+"_dns_timeouts_total",
+		"Number of DNS queries which timed out", nil, nil)
+	encryptedDNSConnectionsDesc = prometheus.NewDesc(namespace+"_encrypted_dns_connections_total",
+		"Connections established to the servers of DNS over TLS and DNS over HTTPS", []string{"protocol", "server"}, nil)
+	encryptedDNSBytesDesc = prometheus.NewDesc(namespace+"_encrypted_dns_bytes_total",
+		"Bytes sent to and received from the servers of DNS over TLS and DNS over HTTPS", []string{"protocol", "server", "direction"}, nil)
+	connectionChurnDesc = prometheus.NewDesc(namespace+"_connection_churn_rate",
+		"Connections created and closed per second over the last export interval, by container", []string{"container_id", "event"}, nil)
+	interfaceBytesDesc = prometheus.NewDesc(namespace+"_interface_bytes_total",
+		"Bytes sent and received by the network interfaces", []string{"netns", "interface", "direction"}, nil)
+	interfacePacketsDesc = prometheus.NewDesc(namespace+"_interface_packets_total",
+		"Packets sent and received by the network interfaces", []string{"netns", "interface", "direction"}, nil)
+	interfaceDroppedDesc = prometheus.NewDesc(namespace+"_interface_dropped_total",
+		"Packets dropped by the network interfaces", []string{"netns", "interface", "direction"}, nil)
+	interfaceErrorsDesc = prometheus.NewDesc(namespace+"_interface_errors_total",
+		"Errors of the network interfaces", []string{"netns", "interface", "direction"}, nil)
+	cacheHitRatioDesc = prometheus.NewDesc(namespace+"_cache_hit_ratio",
+		"Ratio of the lookups to the caches of the network tracer which were hits, since system-probe started", []string{"cache"}, nil)
+)
+
+// cacheCounters are the names of the telemetry counters the hit ratio of each cache is computed from
+var cacheCounters = []struct {
+	cache, lookups, hits, misses string
+}{
+	{cache: "dns", lookups: "network_tracer__dns_cache__lookups", hits: "network_tracer__dns_cache__hits"},
+	{cache: "route", lookups: "network_tracer__gateway_lookup_route_cache__lookups", misses: "network_tracer__gateway_lookup_route_cache__misses"},
+	{cache: "subnet", lookups: "network__gateway_lookup__subnet_cache_lookups", misses: "network__gateway_lookup__subnet_cache_misses"},
+}
+
+type connectionKey struct {
+	connType, family, direction string
+}
+
+type encryptedDNSKey struct {
+	protocol, server string
+}
+
+type encryptedDNSBytesKey struct {
+	encryptedDNSKey
+	direction string
+}
+
+type churnKey struct {
+	containerID, event string
+}
+
+type bytesKey struct {
+	direction, protocol, trafficClass string
+}
+
+// Collector is a prometheus collector serving the metrics of the connections it is handed over by
+// the export loop of the tracer, so that scrapes don't read the connections themselves
+type Collector struct {
+	gather func() ([]*telemetry.MetricFamily, error)
+
+	// the counters are accumulated from the deltas of the connections of each export
+	mu                sync.Mutex
+	open              map[connectionKey]float64
+	closed            map[connectionKey]float64
+	bytes             map[bytesKey]float64
+	dnsResponses      map[string]float64
+	dnsTimeouts       float64
+	encryptedDNSConns map[encryptedDNSKey]float64
+	encryptedDNSBytes map[encryptedDNSBytesKey]float64
+	// churn holds the rates of the last export, the processes of a container being summed up so that
+	// the series are bounded by the containers of the host
+	churn map[churnKey]float64
+	// interfaces is the last sample of the counters of the network interfaces, which are monotonic already
+	interfaces []network.InterfaceStats
+}
+
+var _ prometheus.Collector = &Collector{}
+
+// NewCollector creates a collector getting the telemetry of the caches from the gather function
+func NewCollector(gather func() ([]*telemetry.MetricFamily, error)) *Collector {
+	return &Collector{
+		gather:            gather,
+		open:              make(map[connectionKey]float64),
+		closed:            make(map[connectionKey]float64),
+		bytes:             make(map[bytesKey]float64),
+		dnsResponses:      make(map[string]float64),
+		encryptedDNSConns: make(map[encryptedDNSKey]float64),
+		encryptedDNSBytes: make(map[encryptedDNSBytesKey]float64),
+	}
+}
+
+// Export accumulates the metrics of the given connections, the open ones replacing those of the
+// previous export
+func (c *Collector) Export(conns *network.Connections) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.open = make(map[connectionKey]float64, len(c.open))
+	c.churn = make(map[churnKey]float64, len(c.churn))
+	for _, churn := range conns.Churn {
+		c.churn[churnKey{churn.ContainerID, "created"}] += churn.CreatedPerSecond
+		c.churn[churnKey{churn.ContainerID, "closed"}] += churn.ClosedPerSecond
+	}
+	c.interfaces = conns.InterfaceStats
+	c.add(conns)
+	return nil
+}
+
+// Close implements the exporters interface, the collector holds no resource
+func (c *Collector) Close() error {
+	return nil
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- connectionsDesc
+	ch <- connectionsClosedDesc
+	ch <- bytesDesc
+	ch <- dnsResponsesDesc
+	ch <- dnsTimeoutsDesc
+	ch <- encryptedDNSConnectionsDesc
+	ch <- encryptedDNSBytesDesc
+	ch <- connectionChurnDesc
+	ch <- interfaceBytesDesc
+	ch <- interfacePacketsDesc
+	ch <- interfaceDroppedDesc
+	ch <- interfaceErrorsDesc
+	ch <- cacheHitRatioDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, v := range c.open {
+		ch <- prometheus.MustNewConstMetric(connectionsDesc, prometheus.GaugeValue, v, k.connType, k.family, k.direction)
+	}
+	for k, v := range c.closed {
+		ch <- prometheus.MustNewConstMetric(connectionsClosedDesc, prometheus.CounterValue, v, k.connType, k.family, k.direction)
+	}
+	for k, v := range c.bytes {
+		ch <- prometheus.MustNewConstMetric(bytesDesc, prometheus.CounterValue, v, k.direction, k.protocol, k.trafficClass)
+	}
+	for rcode, v := range c.dnsResponses {
+		ch <- prometheus.MustNewConstMetric(dnsResponsesDesc, prometheus.CounterValue, v, rcode)
+	}
+	ch <- prometheus.MustNewConstMetric(dnsTimeoutsDesc, prometheus.CounterValue, c.dnsTimeouts)
+	for k, v := range c.encryptedDNSConns {
+		ch <- prometheus.MustNewConstMetric(encryptedDNSConnectionsDesc, prometheus.CounterValue, v, k.protocol, k.server)
+	}
+	for k, v := range c.encryptedDNSBytes {
+		ch <- prometheus.MustNewConstMetric(encryptedDNSBytesDesc, prometheus.CounterValue, v, k.protocol, k.server, k.direction)
+	}
+	for k, v := range c.churn {
+		ch <- prometheus.MustNewConstMetric(connectionChurnDesc, prometheus.GaugeValue, v, k.containerID, k.event)
+	}
+
+	c.collectInterfaceStats(ch)
+	c.collectCacheHitRatios(ch)
+}
+
+// collectInterfaceStats reports the counters of the network interfaces, sampled with the connections
+// when the interface stats are enabled
+func (c *Collector) collectInterfaceStats(ch chan<- prometheus.Metric) {
+	for _, s := range c.interfaces {
+		netns := strconv.FormatUint(uint64(s.NetNS), 10)
+		for _, m := range []struct {
+			desc           *prometheus.Desc
+			received, sent uint64
+		}{
+			{interfaceBytesDesc, s.RxBytes, s.TxBytes},
+			{interfacePacketsDesc, s.RxPackets, s.TxPackets},
+			{interfaceDroppedDesc, s.RxDropped, s.TxDropped},
+			{interfaceErrorsDesc, s.RxErrors, s.TxErrors},
+		} {
+			ch <- prometheus.MustNewConstMetric(m.desc, prometheus.CounterValue, float64(m.received), netns, s.Name, "received")
+			ch <- prometheus.MustNewConstMetric(m.desc, prometheus.CounterValue, float64(m.sent), netns, s.Name, "sent")
+		}
+	}
+}
+
+func (c *Collector) add(conns *network.Connections) {
+	for i := range conns.Conns {
+		conn := &conns.Conns[i]
+		k := connectionKey{
+			connType:  strings.ToLower(conn.Type.String()),
+			family:    conn.Family.String(),
+			direction: conn.Direction.String(),
+		}
+		if conn.IsClosed {
+			c.closed[k]++
+		} else {
+			c.open[k]++
+		}
+
+		protocol := "unknown"
+		if conn.ProtocolStack.Application != protocols.Unknown {
+			protocol = strings.ToLower(conn.ProtocolStack.Application.String())
+		}
+		trafficClass := conn.TrafficClass.String()
+		c.bytes[bytesKey{"sent", protocol, trafficClass}] += float64(conn.Last.SentBytes)
+		c.bytes[bytesKey{"received", protocol, trafficClass}] += float64(conn.Last.RecvBytes)
+
+		for _, byType := range conn.DNSStats {
+			for _, stats := range byType {
+				c.dnsTimeouts += float64(stats.Timeouts)
+				for rcode, count := range stats.CountByRcode {
+					c.dnsResponses[strconv.FormatUint(uint64(rcode), 10)] += float64(count)
+				}
+			}
+		}
+	}
+
+	for k, stats := range conns.EncryptedDNS {
+		key := encryptedDNSKey{k.Protocol.String(), k.Host}
+		c.encryptedDNSConns[key] += float64(stats.Connections)
+		c.encryptedDNSBytes[encryptedDNSBytesKey{key, "sent"}] += float64(stats.SentBytes)
+		c.encryptedDNSBytes[encryptedDNSBytesKey{key, "received"}] += float64(stats.RecvBytes)
+	}
+}
+
+func (c *Collector) collectCacheHitRatios(ch chan<- prometheus.Metric) {
+	families, err := c.gather()
+	if err != nil {
+		log.Debugf("unable to gather the telemetry of the caches: %s", err)
+		return
+	}
+
+	values := make(map[string]float64)
+	for _, f := range families {
+		var sum float64
+		for _, m := range f.GetMetric() {
+			sum += m.GetCounter().GetValue()
+		}
+		values[f.GetName()] = sum
+	}
+
+	for _, cc := range cacheCounters {
+		lookups := values[cc.lookups]
+		if lookups == 0 {
+			continue
+		}
+		hits := values[cc.hits]
+		if cc.misses != "" {
+			hits = lookups - values[cc.misses]
+		}
+		ch <- prometheus.MustNewConstMetric(cacheHitRatioDesc, prometheus.GaugeValue, hits/lookups, cc.cache)
+	}
+}
@@ -0,0 +1,185 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/DataDog/datadog-agent/comp/core/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/dns"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+)
+
+func counterFamily(name string, value float64) *telemetry.MetricFamily {
+	return &telemetry.MetricFamily{
+		Name:   proto.String(name),
+		Type:   dto.MetricType_COUNTER.Enum(),
+		Metric: []*dto.Metric{{Counter: &dto.Counter{Value: proto.Float64(value)}}},
+	}
+}
+
+func TestCollector(t *testing.T) {
+	exports := [][]network.ConnectionStats{
+		{
+			{
+				Type:          network.TCP,
+				Family:        network.AFINET,
+				Direction:     network.OUTGOING,
+				ProtocolStack: protocols.Stack{Application: protocols.HTTP},
+				TrafficClass:  network.TrafficClassInternet,
+				Last:          network.StatCounters{SentBytes: 100, RecvBytes: 1000},
+			},
+			{
+				Type:      network.UDP,
+				Family:    network.AFINET,
+				Direction: network.OUTGOING,
+				IsClosed:  true,
+				Last:      network.StatCounters{SentBytes: 40, RecvBytes: 80},
+				DNSStats: map[dns.Hostname]map[dns.QueryType]dns.Stats{
+					dns.ToHostname("example.com"): {dns.TypeA: {Timeouts: 1, CountByRcode: map[uint32]uint32{0: 2, 3: 1}}},
+				},
+			},
+		},
+		{
+			{
+				Type:          network.TCP,
+				Family:        network.AFINET,
+				Direction:     network.OUTGOING,
+				ProtocolStack: protocols.Stack{Application: protocols.HTTP},
+				TrafficClass:  network.TrafficClassInternet,
+				Last:          network.StatCounters{SentBytes: 50, RecvBytes: 500},
+			},
+		},
+	}
+
+	c := NewCollector(
+		func() ([]*telemetry.MetricFamily, error) {
+			return []*telemetry.MetricFamily{
+				counterFamily("network_tracer__dns_cache__lookups", 10),
+				counterFamily("network_tracer__dns_cache__hits", 4),
+				counterFamily("network__gateway_lookup__subnet_cache_lookups", 4),
+				counterFamily("network__gateway_lookup__subnet_cache_misses", 1),
+			}, nil
+		},
+	)
+	export := func() {
+		require.NoError(t, c.Export(&network.Connections{BufferedData: network.BufferedData{Conns: exports[0]}}))
+		exports = exports[1:]
+	}
+
+	// the first export
+	export()
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP system_probe_network_connections Number of open connections
+# TYPE system_probe_network_connections gauge
+system_probe_network_connections{direction="outgoing",family="v4",type="tcp"} 1
+# HELP system_probe_network_connections_closed_total Number of closed connections
+# TYPE system_probe_network_connections_closed_total counter
+system_probe_network_connections_closed_total{direction="outgoing",family="v4",type="udp"} 1
+# HELP system_probe_network_dns_responses_total Number of DNS responses, by response code
+# TYPE system_probe_network_dns_responses_total counter
+system_probe_network_dns_responses_total{rcode="0"} 2
+system_probe_network_dns_responses_total{rcode="3"} 1
+# HELP system_probe_network_dns_timeouts_total Number of DNS queries which timed out
+# TYPE system_probe_network_dns_timeouts_total counter
+system_probe_network_dns_timeouts_total 1
+# HELP system_probe_network_cache_hit_ratio Ratio of the lookups to the caches of the network tracer which were hits, since system-probe started
+# TYPE system_probe_network_cache_hit_ratio gauge
+system_probe_network_cache_hit_ratio{cache="dns"} 0.4
+system_probe_network_cache_hit_ratio{cache="subnet"} 0.75
+`),
+		namespace+"_connections", namespace+"_connections_closed_total", namespace+"_dns_responses_total",
+		namespace+"_dns_timeouts_total", namespace+"_cache_hit_ratio"))
+
+	// the second export accumulates the bytes of both
+	export()
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP system_probe_network_bytes_total Bytes sent and received on the connections, by application protocol and class of traffic
+# TYPE system_probe_network_bytes_total counter
+system_probe_network_bytes_total{direction="received",protocol="http",traffic_class="internet"} 1500
+system_probe_network_bytes_total{direction="sent",protocol="http",traffic_class="internet"} 150
+system_probe_network_bytes_total{direction="received",protocol="unknown",traffic_class="unknown"} 80
+system_probe_network_bytes_total{direction="sent",protocol="unknown",traffic_class="unknown"} 40
+`), namespace+"_bytes_total"))
+}
+
+func TestCollectorInterfaceStats(t *testing.T) {
+	c := NewCollector(func() ([]*telemetry.MetricFamily, error) { return nil, nil })
+	require.NoError(t, c.Export(&network.Connections{InterfaceStats: []network.InterfaceStats{
+		{NetNS: 4026531840, Ifindex: 2, Name: "eth0", RxBytes: 1000, TxBytes: 2000, RxPackets: 10, TxPackets: 20, RxDropped: 3, TxErrors: 4},
+	}}))
+
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP system_probe_network_interface_bytes_total Bytes sent and received by the network interfaces
+# TYPE system_probe_network_interface_bytes_total counter
+system_probe_network_interface_bytes_total{direction="received",interface="eth0",netns="4026531840"} 1000
+system_probe_network_interface_bytes_total{direction="sent",interface="eth0",netns="4026531840"} 2000
+# HELP system_probe_network_interface_dropped_total Packets dropped by the network interfaces
+# TYPE system_probe_network_interface_dropped_total counter
+system_probe_network_interface_dropped_total{direction="received",interface="eth0",netns="4026531840"} 3
+system_probe_network_interface_dropped_total{direction="sent",interface="eth0",netns="4026531840"} 0
+# HELP system_probe_network_interface_errors_total Errors of the network interfaces
+# TYPE system_probe_network_interface_errors_total counter
+system_probe_network_interface_errors_total{direction="received",interface="eth0",netns="4026531840"} 0
+system_probe_network_interface_errors_total{direction="sent",interface="eth0",netns="4026531840"} 4
+`), namespace+"_interface_bytes_total", namespace+"_interface_dropped_total", namespace+"_interface_errors_total"))
+
+	// the interfaces are replaced by the sample of each export
+	require.NoError(t, c.Export(&network.Connections{}))
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(""), namespace+"_interface_bytes_total"))
+}
+
+func TestCollectorConnectionChurn(t *testing.T) {
+	c := NewCollector(func() ([]*telemetry.MetricFamily, error) { return nil, nil })
+	require.NoError(t, c.Export(&network.Connections{Churn: []network.ConnectionChurn{
+		{Pid: 10, ContainerID: "abc", CreatedPerSecond: 2, ClosedPerSecond: 1},
+		{Pid: 11, ContainerID: "abc", CreatedPerSecond: 0.5},
+		{Pid: 20, CreatedPerSecond: 3, ClosedPerSecond: 3},
+	}}))
+
+	// the processes of a container are summed up
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP system_probe_network_connection_churn_rate Connections created and closed per second over the last export interval, by container
+# TYPE system_probe_network_connection_churn_rate gauge
+system_probe_network_connection_churn_rate{container_id="",event="closed"} 3
+system_probe_network_connection_churn_rate{container_id="",event="created"} 3
+system_probe_network_connection_churn_rate{container_id="abc",event="closed"} 1
+system_probe_network_connection_churn_rate{container_id="abc",event="created"} 2.5
+`), namespace+"_connection_churn_rate"))
+
+	// the rates are replaced by those of each export
+	require.NoError(t, c.Export(&network.Connections{}))
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(""), namespace+"_connection_churn_rate"))
+}
+
+func TestCollectorEncryptedDNS(t *testing.T) {
+	c := NewCollector(func() ([]*telemetry.MetricFamily, error) { return nil, nil })
+	export := func() {
+		require.NoError(t, c.Export(&network.Connections{EncryptedDNS: map[network.EncryptedDNSKey]*network.EncryptedDNSStats{
+			{Host: "dns.google", Protocol: network.EncryptedDNSOverHTTPS}: {Connections: 2, SentBytes: 300, RecvBytes: 600},
+		}}))
+	}
+
+	// the counters are accumulated over the exports
+	export()
+	export()
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP system_probe_network_encrypted_dns_bytes_total Bytes sent to and received from the servers of DNS over TLS and DNS over HTTPS
+# TYPE system_probe_network_encrypted_dns_bytes_total counter
+system_probe_network_encrypted_dns_bytes_total{direction="received",protocol="doh",server="dns.google"} 1200
+system_probe_network_encrypted_dns_bytes_total{direction="sent",protocol="doh",server="dns.google"} 600
+# HELP system_probe_network_encrypted_dns_connections_total Connections established to the servers of DNS over TLS and DNS over HTTPS
+# TYPE system_probe_network_encrypted_dns_connections_total counter
+system_probe_network_encrypted_dns_connections_total{protocol="doh",server="dns.google"} 4
+`), namespace+"_encrypted_dns_bytes_total", namespace+"_encrypted_dns_connections_total"))
+}
@@ -0,0 +1,379 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package otlp exports the connections of the network tracer and their HTTP stats as OpenTelemetry metrics,
+// with the attributes of the semantic conventions, to a collector accepting OTLP over HTTP
+package otlp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+)
+
+const (
+	exporterModuleName = "network__otlp_exporter"
+	scopeName          = "github.com/DataDog/datadog-agent/pkg/network"
+	metricsPath        = "/v1/metrics"
+	requestTimeout     = 10 * time.Second
+)
+
+var exporterTelemetry = struct {
+	requests   telemetry.Counter
+	errors     telemetry.Counter
+	dataPoints telemetry.Counter
+}{
+	telemetry.NewCounter(exporterModuleName, "requests", []string{}, "Counter measuring the number of OTLP export requests sent to the collector"),
+	telemetry.NewCounter(exporterModuleName, "errors", []string{}, "Counter measuring the number of OTLP export requests which failed"),
+	telemetry.NewCounter(exporterModuleName, "data_points", []string{}, "Counter measuring the number of data points sent to the collector"),
+}
+
+type metric struct {
+	name, unit, description string
+	gauge                   bool
+}
+
+var (
+	connectionIO          = metric{name: "network.connection.io", unit: "By", description: "Bytes sent and received on the connection"}
+	connectionPackets     = metric{name: "network.connection.packets", unit: "{packet}", description: "Packets sent and received on the connection"}
+	connectionAggregated  = metric{name: "network.connection.aggregated", unit: "{connection}", description: "Connections rolled up into the connection", gauge: true}
+	connectionCwnd        = metric{name: "network.connection.cwnd", unit: "{segment}", description: "Congestion window of the TCP connection", gauge: true}
+	connectionSsthresh    = metric{name: "network.connection.ssthresh", unit: "{segment}", description: "Slow start threshold of the TCP connection", gauge: true}
+	connectionDelivery    = metric{name: "network.connection.delivery_rate", unit: "By/s", description: "Delivery rate estimated by the TCP stack for the connection", gauge: true}
+	connectionDrops       = metric{name: "network.connection.drops", unit: "{packet}", description: "Packets of the connection dropped by the kernel"}
+	connectionRetransmits = metric{name: "network.connection.retransmits", unit: "{segment}", description: "TCP segments retransmitted on the connection"}
+	connectionIdle        = metric{name: "network.connection.idle", unit: "s", description: "Time since data was last transferred on the connection", gauge: true}
+	connectionRTT         = metric{name: "network.connection.rtt", unit: "s", description: "Smoothed round trip time of the TCP connection", gauge: true}
+	connectionRTTSamples  = metric{name: "network.connection.rtt.samples", unit: "s", description: "Distribution of the round trip time samples of the TCP connection"}
+	httpRequests          = metric{name: "network.http.requests", unit: "{request}", description: "HTTP requests observed on the connections"}
+)
+
+// rttHistogramBounds are the upper bounds of the buckets of the RTT histograms but the last one, in seconds
+var rttHistogramBounds = func() []float64 {
+	bounds := make([]float64, network.RTTHistogramBuckets-1)
+	for i := range bounds {
+		bounds[i] = (time.Duration(2<<i) * time.Microsecond).Seconds()
+	}
+	return bounds
+}()
+
+// Exporter sends connections as OpenTelemetry metrics to a collector
+type Exporter struct {
+	url       string
+	client    *nethttp.Client
+	batchSize int
+	resource  pcommon.Resource
+	// lastExport is the start of the interval the counters of the next export cover
+	lastExport time.Time
+}
+
+// NewExporter creates an exporter sending to the collector at the given OTLP/HTTP endpoint, such as
+// http://localhost:4318. Each request holds at most batchSize data points.
+func NewExporter(endpoint string, batchSize int) *Exporter {
+	resource := pcommon.NewResource()
+	resource.Attributes().PutStr("service.name", "system-probe")
+	if hostname, err := os.Hostname(); err == nil {
+		resource.Attributes().PutStr("host.name", hostname)
+	}
+
+	return &Exporter{
+		url:        strings.TrimSuffix(endpoint, "/") + metricsPath,
+		client:     &nethttp.Client{Timeout: requestTimeout},
+		batchSize:  batchSize,
+		resource:   resource,
+		lastExport: time.Now(),
+	}
+}
+
+// Export sends the metrics of the given connections, with the counters of their last interval
+func (e *Exporter) Export(conns *network.Connections) error {
+	now := time.Now()
+	b := e.newBatch(e.lastExport, now)
+	e.lastExport = now
+
+	for i := range conns.Conns {
+		b.addConnection(&conns.Conns[i])
+		if b.dataPoints >= e.batchSize {
+			if err := e.send(b); err != nil {
+				return err
+			}
+			b = e.newBatch(b.start.AsTime(), now)
+		}
+	}
+	for k, stats := range conns.HTTP {
+		b.addHTTP(k, stats)
+		if b.dataPoints >= e.batchSize {
+			if err := e.send(b); err != nil {
+				return err
+			}
+			b = e.newBatch(b.start.AsTime(), now)
+		}
+	}
+
+	if b.dataPoints > 0 {
+		return e.send(b)
+	}
+	return nil
+}
+
+func (e *Exporter) send(b *batch) error {
+	body, err := pmetricotlp.NewExportRequestFromMetrics(b.metrics).MarshalProto()
+	if err != nil {
+		return err
+	}
+
+	exporterTelemetry.requests.Inc()
+	resp, err := e.client.Post(e.url, "application/x-protobuf", bytes.NewReader(body))
+	if err != nil {
+		exporterTelemetry.errors.Inc()
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		exporterTelemetry.errors.Inc()
+		return fmt.Errorf("collector returned status %d", resp.StatusCode)
+	}
+	exporterTelemetry.dataPoints.Add(float64(b.dataPoints))
+	return nil
+}
+
+// Close releases the idle connections to the collector
+func (e *Exporter) Close() error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// batch holds the metrics of a single export request
+type batch struct {
+	metrics    pmetric.Metrics
+	scope      pmetric.MetricSlice
+	points     map[string]pmetric.NumberDataPointSlice
+	histograms map[string]pmetric.HistogramDataPointSlice
+	start, now pcommon.Timestamp
+	dataPoints int
+}
+
+func (e *Exporter) newBatch(start, now time.Time) *batch {
+	metrics := pmetric.NewMetrics()
+	rm := metrics.ResourceMetrics().AppendEmpty()
+	e.resource.CopyTo(rm.Resource())
+	sm := rm.ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName(scopeName)
+
+	return &batch{
+		metrics:    metrics,
+		scope:      sm.Metrics(),
+		points:     make(map[string]pmetric.NumberDataPointSlice),
+		histograms: make(map[string]pmetric.HistogramDataPointSlice),
+		start:      pcommon.NewTimestampFromTime(start),
+		now:        pcommon.NewTimestampFromTime(now),
+	}
+}
+
+// point appends a data point to the given metric, the sums being deltas over the export interval
+func (b *batch) point(m metric) pmetric.NumberDataPoint {
+	points, ok := b.points[m.name]
+	if !ok {
+		om := b.scope.AppendEmpty()
+		om.SetName(m.name)
+		om.SetUnit(m.unit)
+		om.SetDescription(m.description)
+		if m.gauge {
+			points = om.SetEmptyGauge().DataPoints()
+		} else {
+			sum := om.SetEmptySum()
+			sum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+			sum.SetIsMonotonic(true)
+			points = sum.DataPoints()
+		}
+		b.points[m.name] = points
+	}
+
+	dp := points.AppendEmpty()
+	if !m.gauge {
+		dp.SetStartTimestamp(b.start)
+	}
+	dp.SetTimestamp(b.now)
+	b.dataPoints++
+	return dp
+}
+
+// histogramPoint appends a data point to the given histogram metric, the histograms being cumulative
+func (b *batch) histogramPoint(m metric) pmetric.HistogramDataPoint {
+	points, ok := b.histograms[m.name]
+	if !ok {
+		om := b.scope.AppendEmpty()
+		om.SetName(m.name)
+		om.SetUnit(m.unit)
+		om.SetDescription(m.description)
+		histogram := om.SetEmptyHistogram()
+		histogram.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+		points = histogram.DataPoints()
+		b.histograms[m.name] = points
+	}
+
+	dp := points.AppendEmpty()
+	dp.SetTimestamp(b.now)
+	b.dataPoints++
+	return dp
+}
+
+func (b *batch) addConnection(c *network.ConnectionStats) {
+	// the idle connections are only reported with their idle duration
+	if c.IdleDuration > 0 {
+		dp := b.point(connectionIdle)
+		dp.SetDoubleValue(c.IdleDuration.Seconds())
+		putConnectionAttributes(dp.Attributes(), c)
+	}
+
+	last := c.Last
+	if last.SentBytes == 0 && last.RecvBytes == 0 && last.SentPackets == 0 && last.RecvPackets == 0 && last.Retransmits == 0 && last.Drops == 0 {
+		return
+	}
+
+	for _, d := range []struct {
+		direction      string
+		bytes, packets uint64
+	}{
+		{"transmit", last.SentBytes, last.SentPackets},
+		{"receive", last.RecvBytes, last.RecvPackets},
+	} {
+		dp := b.point(connectionIO)
+		dp.SetIntValue(int64(d.bytes))
+		putConnectionAttributes(dp.Attributes(), c)
+		dp.Attributes().PutStr("network.io.direction", d.direction)
+
+		dp = b.point(connectionPackets)
+		dp.SetIntValue(int64(d.packets))
+		putConnectionAttributes(dp.Attributes(), c)
+		dp.Attributes().PutStr("network.io.direction", d.direction)
+	}
+	if c.AggregatedConnections > 0 {
+		dp := b.point(connectionAggregated)
+		dp.SetIntValue(int64(c.AggregatedConnections))
+		putConnectionAttributes(dp.Attributes(), c)
+	}
+	if last.Drops > 0 {
+		dp := b.point(connectionDrops)
+		dp.SetIntValue(int64(last.Drops))
+		putConnectionAttributes(dp.Attributes(), c)
+	}
+
+	if c.Type != network.TCP {
+		return
+	}
+	if last.Retransmits > 0 {
+		dp := b.point(connectionRetransmits)
+		dp.SetIntValue(int64(last.Retransmits))
+		putConnectionAttributes(dp.Attributes(), c)
+	}
+	if c.RTT > 0 {
+		dp := b.point(connectionRTT)
+		dp.SetDoubleValue((time.Duration(c.RTT) * time.Microsecond).Seconds())
+		putConnectionAttributes(dp.Attributes(), c)
+	}
+	if !c.RTTHistogram.IsEmpty() {
+		b.addRTTHistogram(c)
+	}
+	if c.Cwnd > 0 {
+		b.addCongestion(c)
+	}
+}
+
+// addCongestion adds the congestion state of the connection, which the eBPF tracer reads once it's closed
+func (b *batch) addCongestion(c *network.ConnectionStats) {
+	for _, g := range []struct {
+		m     metric
+		value uint64
+	}{
+		{connectionCwnd, uint64(c.Cwnd)},
+		{connectionSsthresh, uint64(c.Ssthresh)},
+		{connectionDelivery, c.DeliveryRate},
+	} {
+		dp := b.point(g.m)
+		dp.SetIntValue(int64(g.value))
+		putConnectionAttributes(dp.Attributes(), c)
+		if c.CongestionAlgorithm != "" {
+			dp.Attributes().PutStr("network.tcp.congestion_algorithm", c.CongestionAlgorithm)
+		}
+	}
+}
+
+// addRTTHistogram adds the RTT samples of the connection, which are counted since it was established
+func (b *batch) addRTTHistogram(c *network.ConnectionStats) {
+	dp := b.histogramPoint(connectionRTTSamples)
+	dp.SetStartTimestamp(pcommon.NewTimestampFromTime(b.now.AsTime().Add(-c.Duration)))
+	dp.SetCount(c.RTTHistogram.Count())
+	dp.ExplicitBounds().FromRaw(rttHistogramBounds)
+	counts := dp.BucketCounts()
+	counts.EnsureCapacity(len(c.RTTHistogram))
+	for _, n := range c.RTTHistogram {
+		counts.Append(uint64(n))
+	}
+	putConnectionAttributes(dp.Attributes(), c)
+}
+
+func (b *batch) addHTTP(k http.Key, stats *http.RequestStats) {
+	server := util.FromLowHigh(k.DstIPLow, k.DstIPHigh)
+	for status, s := range stats.Data {
+		if s == nil || s.Count == 0 {
+			continue
+		}
+		dp := b.point(httpRequests)
+		dp.SetIntValue(int64(s.Count))
+		attrs := dp.Attributes()
+		attrs.PutStr("http.request.method", k.Method.String())
+		attrs.PutInt("http.response.status_code", int64(status))
+		attrs.PutStr("url.path", k.Path.Content.Get())
+		attrs.PutStr("server.address", server.String())
+		attrs.PutInt("server.port", int64(k.DstPort))
+	}
+}
+
+func putConnectionAttributes(attrs pcommon.Map, c *network.ConnectionStats) {
+	attrs.PutStr("network.transport", strings.ToLower(c.Type.String()))
+	attrs.PutStr("network.type", networkType(c.Family))
+	attrs.PutStr("network.local.address", c.Source.String())
+	attrs.PutInt("network.local.port", int64(c.SPort))
+	attrs.PutStr("network.peer.address", c.Dest.String())
+	attrs.PutInt("network.peer.port", int64(c.DPort))
+	if c.Pid != 0 {
+		attrs.PutInt("process.pid", int64(c.Pid))
+	}
+	if c.ContainerID.Source != nil {
+		attrs.PutStr("container.id", c.ContainerID.Source.Get().(string))
+	}
+	if c.FlowLabel != 0 {
+		attrs.PutInt("network.ipv6.flow_label", int64(c.FlowLabel))
+	}
+	// both sides of the intra-host connections share the pair id, so that the backend can count the flow once
+	if c.PairID != 0 {
+		attrs.PutStr("network.connection.pair_id", strconv.FormatUint(c.PairID, 16))
+		attrs.PutInt("network.peer.process.pid", int64(c.PeerPid))
+	}
+}
+
+func networkType(family network.ConnectionFamily) string {
+	if family == network.AFINET6 {
+		return "ipv6"
+	}
+	return "ipv4"
+}
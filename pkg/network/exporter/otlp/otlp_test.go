@@ -0,0 +1,335 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package otlp
+
+import (
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func newTestCollector(t *testing.T) (*httptest.Server, *[]pmetric.Metrics) {
+	var received []pmetric.Metrics
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, req *nethttp.Request) {
+		assert.Equal(t, metricsPath, req.URL.Path)
+		assert.Equal(t, "application/x-protobuf", req.Header.Get("Content-Type"))
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		r := pmetricotlp.NewExportRequest()
+		require.NoError(t, r.UnmarshalProto(body))
+		received = append(received, r.Metrics())
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &received
+}
+
+func findMetric(metrics pmetric.Metrics, name string) (pmetric.Metric, bool) {
+	ms := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for i := 0; i < ms.Len(); i++ {
+		if ms.At(i).Name() == name {
+			return ms.At(i), true
+		}
+	}
+	return pmetric.Metric{}, false
+}
+
+func TestExport(t *testing.T) {
+	srv, received := newTestCollector(t)
+
+	httpKey := http.NewKey(
+		util.AddressFromString("10.0.0.1"), util.AddressFromString("10.0.0.2"),
+		40000, 8080, []byte("/api"), true, http.MethodGet,
+	)
+	httpStats := http.NewRequestStats(true)
+	httpStats.AddRequest(200, 10, 0, nil)
+	httpStats.AddRequest(200, 20, 0, nil)
+
+	conns := &network.Connections{
+		BufferedData: network.BufferedData{Conns: []network.ConnectionStats{
+			{
+				Source: util.AddressFromString("10.0.0.1"),
+				Dest:   util.AddressFromString("10.0.0.2"),
+				SPort:  40000,
+				DPort:  8080,
+				Pid:    1234,
+				Type:   network.TCP,
+				Family: network.AFINET,
+				RTT:    1500,
+				// samples in [1024, 2048) µs
+				RTTHistogram: network.RTTHistogram{10: 4},
+				Duration:     time.Minute,
+				Last:         network.StatCounters{SentBytes: 100, RecvBytes: 300, SentPackets: 2, RecvPackets: 3, Drops: 1},
+			},
+			{
+				// no traffic, left out
+				Source: util.AddressFromString("10.0.0.1"),
+				Dest:   util.AddressFromString("10.0.0.3"),
+				Type:   network.UDP,
+				Family: network.AFINET,
+			},
+			{
+				// idle, only reported with its idle duration
+				Source:       util.AddressFromString("10.0.0.1"),
+				Dest:         util.AddressFromString("10.0.0.4"),
+				Type:         network.TCP,
+				Family:       network.AFINET,
+				RTT:          1500,
+				IdleDuration: 90 * time.Second,
+			},
+		}},
+		HTTP: map[http.Key]*http.RequestStats{httpKey: httpStats},
+	}
+
+	e := NewExporter(srv.URL+"/", 1000)
+	defer e.Close()
+	require.NoError(t, e.Export(conns))
+	require.Len(t, *received, 1)
+	metrics := (*received)[0]
+
+	serviceName, ok := metrics.ResourceMetrics().At(0).Resource().Attributes().Get("service.name")
+	require.True(t, ok)
+	assert.Equal(t, "system-probe", serviceName.Str())
+
+	connIO, ok := findMetric(metrics, connectionIO.name)
+	require.True(t, ok)
+	assert.Equal(t, pmetric.AggregationTemporalityDelta, connIO.Sum().AggregationTemporality())
+	require.Equal(t, 2, connIO.Sum().DataPoints().Len())
+	transmit := connIO.Sum().DataPoints().At(0)
+	assert.Equal(t, int64(100), transmit.IntValue())
+	assert.Equal(t, map[string]any{
+		"network.transport":     "tcp",
+		"network.type":          "ipv4",
+		"network.local.address": "10.0.0.1",
+		"network.local.port":    int64(40000),
+		"network.peer.address":  "10.0.0.2",
+		"network.peer.port":     int64(8080),
+		"process.pid":           int64(1234),
+		"network.io.direction":  "transmit",
+	}, transmit.Attributes().AsRaw())
+	assert.Equal(t, int64(300), connIO.Sum().DataPoints().At(1).IntValue())
+
+	drops, ok := findMetric(metrics, connectionDrops.name)
+	require.True(t, ok)
+	require.Equal(t, 1, drops.Sum().DataPoints().Len())
+	assert.Equal(t, int64(1), drops.Sum().DataPoints().At(0).IntValue())
+
+	idle, ok := findMetric(metrics, connectionIdle.name)
+	require.True(t, ok)
+	require.Equal(t, 1, idle.Gauge().DataPoints().Len())
+	idleDP := idle.Gauge().DataPoints().At(0)
+	assert.Equal(t, 90.0, idleDP.DoubleValue())
+	peer, _ := idleDP.Attributes().Get("network.peer.address")
+	assert.Equal(t, "10.0.0.4", peer.Str())
+
+	rtt, ok := findMetric(metrics, connectionRTT.name)
+	require.True(t, ok)
+	require.Equal(t, 1, rtt.Gauge().DataPoints().Len())
+	assert.Equal(t, 0.0015, rtt.Gauge().DataPoints().At(0).DoubleValue())
+
+	samples, ok := findMetric(metrics, connectionRTTSamples.name)
+	require.True(t, ok)
+	assert.Equal(t, pmetric.AggregationTemporalityCumulative, samples.Histogram().AggregationTemporality())
+	hdp := samples.Histogram().DataPoints().At(0)
+	assert.Equal(t, uint64(4), hdp.Count())
+	assert.Equal(t, time.Minute, hdp.Timestamp().AsTime().Sub(hdp.StartTimestamp().AsTime()))
+	require.Equal(t, network.RTTHistogramBuckets, hdp.BucketCounts().Len())
+	assert.Equal(t, uint64(4), hdp.BucketCounts().At(10))
+	// the bucket holds the samples up to 2048µs
+	assert.Equal(t, 0.001024, hdp.ExplicitBounds().At(9))
+	assert.Equal(t, 0.002048, hdp.ExplicitBounds().At(10))
+
+	_, ok = findMetric(metrics, connectionAggregated.name)
+	assert.False(t, ok)
+
+	_, ok = findMetric(metrics, connectionRetransmits.name)
+	assert.False(t, ok)
+
+	requests, ok := findMetric(metrics, httpRequests.name)
+	require.True(t, ok)
+	require.Equal(t, 1, requests.Sum().DataPoints().Len())
+	dp := requests.Sum().DataPoints().At(0)
+	assert.Equal(t, int64(2), dp.IntValue())
+	assert.Equal(t, map[string]any{
+		"http.request.method":       "GET",
+		"http.response.status_code": int64(200),
+		"url.path":                  "/api",
+		"server.address":            "10.0.0.2",
+		"server.port":               int64(8080),
+	}, dp.Attributes().AsRaw())
+}
+
+func TestExportCongestion(t *testing.T) {
+	srv, received := newTestCollector(t)
+
+	conns := &network.Connections{BufferedData: network.BufferedData{Conns: []network.ConnectionStats{
+		{
+			Source:              util.AddressFromString("10.0.0.1"),
+			Dest:                util.AddressFromString("10.0.0.2"),
+			SPort:               40000,
+			DPort:               443,
+			Type:                network.TCP,
+			Family:              network.AFINET,
+			IsClosed:            true,
+			CongestionAlgorithm: "bbr",
+			Cwnd:                10,
+			Ssthresh:            7,
+			DeliveryRate:        1 << 20,
+			Last:                network.StatCounters{SentBytes: 100},
+		},
+	}}}
+
+	e := NewExporter(srv.URL, 1000)
+	defer e.Close()
+	require.NoError(t, e.Export(conns))
+	require.Len(t, *received, 1)
+
+	for name, expected := range map[string]int64{
+		connectionCwnd.name:     10,
+		connectionSsthresh.name: 7,
+		connectionDelivery.name: 1 << 20,
+	} {
+		m, ok := findMetric((*received)[0], name)
+		require.True(t, ok, name)
+		dp := m.Gauge().DataPoints().At(0)
+		assert.Equal(t, expected, dp.IntValue(), name)
+		algo, _ := dp.Attributes().Get("network.tcp.congestion_algorithm")
+		assert.Equal(t, "bbr", algo.Str())
+	}
+}
+
+func TestExportFlowLabel(t *testing.T) {
+	srv, received := newTestCollector(t)
+
+	conns := &network.Connections{BufferedData: network.BufferedData{Conns: []network.ConnectionStats{
+		{
+			Source:    util.AddressFromString("fd00::1"),
+			Dest:      util.AddressFromString("fd00::2"),
+			SPort:     40000,
+			DPort:     443,
+			Type:      network.TCP,
+			Family:    network.AFINET6,
+			FlowLabel: 0x12345,
+			Last:      network.StatCounters{SentBytes: 100},
+		},
+	}}}
+
+	e := NewExporter(srv.URL, 1000)
+	defer e.Close()
+	require.NoError(t, e.Export(conns))
+	require.Len(t, *received, 1)
+
+	connIO, ok := findMetric((*received)[0], connectionIO.name)
+	require.True(t, ok)
+	attrs := connIO.Sum().DataPoints().At(0).Attributes().AsRaw()
+	assert.Equal(t, "ipv6", attrs["network.type"])
+	assert.Equal(t, int64(0x12345), attrs["network.ipv6.flow_label"])
+}
+
+func TestExportConnectionPair(t *testing.T) {
+	srv, received := newTestCollector(t)
+
+	conns := &network.Connections{BufferedData: network.BufferedData{Conns: []network.ConnectionStats{
+		{
+			Source:  util.AddressFromString("127.0.0.1"),
+			Dest:    util.AddressFromString("127.0.0.1"),
+			SPort:   40000,
+			DPort:   8080,
+			Pid:     1234,
+			Type:    network.TCP,
+			Family:  network.AFINET,
+			PairID:  0xabc,
+			PeerPid: 5678,
+			Last:    network.StatCounters{SentBytes: 100},
+		},
+	}}}
+
+	e := NewExporter(srv.URL, 1000)
+	defer e.Close()
+	require.NoError(t, e.Export(conns))
+	require.Len(t, *received, 1)
+
+	connIO, ok := findMetric((*received)[0], connectionIO.name)
+	require.True(t, ok)
+	attrs := connIO.Sum().DataPoints().At(0).Attributes().AsRaw()
+	assert.Equal(t, "abc", attrs["network.connection.pair_id"])
+	assert.Equal(t, int64(5678), attrs["network.peer.process.pid"])
+}
+
+func TestExportAggregatedConnections(t *testing.T) {
+	srv, received := newTestCollector(t)
+
+	conns := &network.Connections{BufferedData: network.BufferedData{Conns: []network.ConnectionStats{
+		{
+			Source:                util.AddressFromString("10.0.0.1"),
+			Dest:                  util.AddressFromString("10.0.0.2"),
+			DPort:                 53,
+			Type:                  network.UDP,
+			Family:                network.AFINET,
+			AggregatedConnections: 3,
+			Last:                  network.StatCounters{SentBytes: 180, SentPackets: 3},
+		},
+	}}}
+
+	e := NewExporter(srv.URL, 1000)
+	defer e.Close()
+	require.NoError(t, e.Export(conns))
+	require.Len(t, *received, 1)
+
+	aggregated, ok := findMetric((*received)[0], connectionAggregated.name)
+	require.True(t, ok)
+	require.Equal(t, 1, aggregated.Gauge().DataPoints().Len())
+	assert.Equal(t, int64(3), aggregated.Gauge().DataPoints().At(0).IntValue())
+}
+
+func TestExportBatches(t *testing.T) {
+	srv, received := newTestCollector(t)
+
+	conns := &network.Connections{}
+	for i := 0; i < 10; i++ {
+		conns.Conns = append(conns.Conns, network.ConnectionStats{
+			Source: util.AddressFromString("10.0.0.1"),
+			Dest:   util.AddressFromString("10.0.0.2"),
+			SPort:  uint16(30000 + i),
+			DPort:  53,
+			Type:   network.UDP,
+			Family: network.AFINET,
+			Last:   network.StatCounters{SentBytes: 60, SentPackets: 1},
+		})
+	}
+
+	// each udp connection has 4 data points
+	e := NewExporter(srv.URL, 8)
+	require.NoError(t, e.Export(conns))
+	require.Len(t, *received, 5)
+	for _, m := range *received {
+		assert.Equal(t, 8, m.DataPointCount())
+	}
+}
+
+func TestExportCollectorError(t *testing.T) {
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, _ *nethttp.Request) {
+		w.WriteHeader(nethttp.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	conns := &network.Connections{BufferedData: network.BufferedData{Conns: []network.ConnectionStats{{
+		Type:   network.TCP,
+		Family: network.AFINET,
+		Last:   network.StatCounters{SentBytes: 1},
+	}}}}
+	assert.Error(t, NewExporter(srv.URL, 1000).Export(conns))
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/google/gopacket/afpacket"
 	"github.com/google/gopacket/layers"
 	"golang.org/x/net/bpf"
+	"golang.org/x/sys/unix"
 
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -39,10 +40,15 @@ var packetSourceTelemetry = struct {
 	telemetry.NewStatCounterWrapper(telemetryModuleName, "dropped_packets", []string{}, "Counter measuring the number of dropped packets"),
 }
 
+// dropAllFilter is the classic BPF filter which drops every packet, attached to the socket while paused
+var dropAllFilter = []bpf.RawInstruction{{Op: unix.BPF_RET | unix.BPF_K, K: 0}}
+
 // AFPacketSource provides a RAW_SOCKET attached to an eBPF SOCKET_FILTER
 type AFPacketSource struct {
 	*afpacket.TPacket
+	fd           int
 	socketFilter *manager.Probe
+	bpfFilter    []bpf.RawInstruction
 
 	exit chan struct{}
 }
@@ -61,11 +67,12 @@ func NewPacketSource(filter *manager.Probe, bpfFilter []bpf.RawInstruction) (*AF
 		return nil, fmt.Errorf("error creating raw socket: %s", err)
 	}
 
+	// The underlying socket file descriptor is private, hence the use of reflection
+	fd := int(reflect.ValueOf(rawSocket).Elem().FieldByName("fd").Int())
 	if filter != nil {
-		// The underlying socket file descriptor is private, hence the use of reflection
 		// Point socket filter program to the RAW_SOCKET file descriptor
 		// Note the filter attachment itself is triggered by the ebpf.Manager
-		filter.SocketFD = int(reflect.ValueOf(rawSocket).Elem().FieldByName("fd").Int())
+		filter.SocketFD = fd
 	} else {
 		err = rawSocket.SetBPF(bpfFilter)
 		if err != nil {
@@ -75,7 +82,9 @@ func NewPacketSource(filter *manager.Probe, bpfFilter []bpf.RawInstruction) (*AF
 
 	ps := &AFPacketSource{
 		TPacket:      rawSocket,
+		fd:           fd,
 		socketFilter: filter,
+		bpfFilter:    bpfFilter,
 		exit:         make(chan struct{}),
 	}
 	go ps.pollStats()
@@ -114,6 +123,32 @@ func (p *AFPacketSource) VisitPackets(exit <-chan struct{}, visit func([]byte, t
 	}
 }
 
+// Pause replaces the filter of the socket with one dropping every packet, so that the kernel stops
+// copying the packets to the socket until Resume is called. Detaching the filter instead would let
+// every packet through.
+func (p *AFPacketSource) Pause() error {
+	if err := p.SetBPF(dropAllFilter); err != nil {
+		return fmt.Errorf("error setting drop-all bpf filter: %w", err)
+	}
+	return nil
+}
+
+// Resume attaches the filter of the socket replaced by Pause
+func (p *AFPacketSource) Resume() error {
+	if p.socketFilter == nil {
+		if err := p.SetBPF(p.bpfFilter); err != nil {
+			return fmt.Errorf("error setting classic bpf filter: %w", err)
+		}
+		return nil
+	}
+
+	// attaching the eBPF program replaces the classic filter
+	if err := unix.SetsockoptInt(p.fd, unix.SOL_SOCKET, unix.SO_ATTACH_BPF, p.socketFilter.Program().FD()); err != nil {
+		return fmt.Errorf("error attaching socket filter: %w", err)
+	}
+	return nil
+}
+
 // PacketType is the gopacket.LayerType for this source
 func (p *AFPacketSource) PacketType() gopacket.LayerType {
 	return layers.LayerTypeEthernet
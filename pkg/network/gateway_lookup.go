@@ -18,3 +18,30 @@ type GatewayLookup interface {
 }
 
 type nsLookupFunc func() (netns.NsHandle, error)
+
+// GetGatewayTags returns the tags describing the gateway the connection is routed through, the
+// payload only carrying the alias of its subnet
+func GetGatewayTags(c *ConnectionStats) []string {
+	if c.Via == nil || c.Via.Pending || c.Via.OnLink {
+		return nil
+	}
+
+	s := &c.Via.Subnet
+	tags := make([]string, 0, 5)
+	if s.Cidr != "" {
+		tags = append(tags, "gateway.subnet_cidr:"+s.Cidr)
+	}
+	if s.NetworkID != "" {
+		tags = append(tags, "gateway.network_id:"+s.NetworkID)
+	}
+	if s.InterfaceID != "" {
+		tags = append(tags, "gateway.interface_id:"+s.InterfaceID)
+	}
+	if s.Zone != "" {
+		tags = append(tags, "gateway.zone:"+s.Zone)
+	}
+	if t := GatewayType(c); t != "" {
+		tags = append(tags, "gateway.type:"+t)
+	}
+	return tags
+}
@@ -0,0 +1,158 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/simplelru"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+)
+
+const (
+	asyncGatewayLookupModuleName = "network__gateway_lookup_async"
+	// asyncGatewayLookupTTL is how long a resolved gateway is served before being refreshed
+	asyncGatewayLookupTTL = 2 * time.Minute
+)
+
+var asyncGatewayLookupTelemetry = struct {
+	enqueued telemetry.Counter
+	dropped  telemetry.Counter
+	pending  telemetry.Gauge
+}{
+	telemetry.NewCounter(asyncGatewayLookupModuleName, "enqueued", []string{}, "Counter measuring the number of gateway lookups queued"),
+	telemetry.NewCounter(asyncGatewayLookupModuleName, "dropped", []string{}, "Counter measuring the number of gateway lookups dropped because the queue was full"),
+	telemetry.NewGauge(asyncGatewayLookupModuleName, "pending", []string{}, "Gauge measuring the number of gateway lookups in the queue"),
+}
+
+type gatewayKey struct {
+	source, dest util.Address
+	netns        uint32
+}
+
+type gatewayResult struct {
+	via     *Via
+	expires time.Time
+}
+
+// asyncGatewayLookup resolves the gateways in the background, so that the route and cloud
+// metadata lookups don't delay the flush of the connections. The connections whose gateway
+// isn't resolved yet are marked as pending, and get it on a later flush.
+type asyncGatewayLookup struct {
+	lookup GatewayLookup
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	results *simplelru.LRU[gatewayKey, gatewayResult]
+	pending map[gatewayKey]struct{}
+
+	queue chan gatewayKey
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// NewAsyncGatewayLookup returns a gateway lookup running the given one in a single background
+// worker, fed by a queue of the given size. The results are cached for up to cacheSize tuples.
+func NewAsyncGatewayLookup(lookup GatewayLookup, queueSize int, cacheSize int) GatewayLookup {
+	if lookup == nil {
+		return nil
+	}
+
+	g := &asyncGatewayLookup{
+		lookup:  lookup,
+		ttl:     asyncGatewayLookupTTL,
+		pending: make(map[gatewayKey]struct{}),
+		queue:   make(chan gatewayKey, queueSize),
+		done:    make(chan struct{}),
+	}
+	g.results, _ = simplelru.NewLRU[gatewayKey, gatewayResult](cacheSize, nil)
+
+	g.wg.Add(1)
+	go g.run()
+	return g
+}
+
+// Lookup returns the gateway of the connection if it was already resolved
+func (g *asyncGatewayLookup) Lookup(cs *ConnectionStats) *Via {
+	dest := cs.Dest
+	if cs.IPTranslation != nil {
+		dest = cs.IPTranslation.ReplSrcIP
+	}
+
+	return g.LookupWithIPs(cs.Source, dest, cs.NetNS)
+}
+
+// LookupWithIPs returns the gateway for the given source, destination and namespace if it was
+// already resolved. Otherwise, it queues its resolution and returns a pending Via.
+func (g *asyncGatewayLookup) LookupWithIPs(source util.Address, dest util.Address, netns uint32) *Via {
+	key := gatewayKey{source: source, dest: dest, netns: netns}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if r, ok := g.results.Get(key); ok {
+		if time.Now().After(r.expires) {
+			// keep serving the previous result until it is refreshed
+			g.enqueue(key)
+		}
+		return r.via
+	}
+
+	g.enqueue(key)
+	return &Via{Pending: true}
+}
+
+// enqueue queues the resolution of the gateway of the given tuple, unless it is already queued.
+// It must be called with the lock held.
+func (g *asyncGatewayLookup) enqueue(key gatewayKey) {
+	if _, ok := g.pending[key]; ok {
+		return
+	}
+
+	select {
+	case g.queue <- key:
+		g.pending[key] = struct{}{}
+		asyncGatewayLookupTelemetry.enqueued.Inc()
+		asyncGatewayLookupTelemetry.pending.Inc()
+	default:
+		// retried on the next lookup of the tuple
+		asyncGatewayLookupTelemetry.dropped.Inc()
+	}
+}
+
+func (g *asyncGatewayLookup) run() {
+	defer g.wg.Done()
+	for {
+		select {
+		case <-g.done:
+			return
+		case key := <-g.queue:
+			via := g.lookup.LookupWithIPs(key.source, key.dest, key.netns)
+
+			g.mu.Lock()
+			g.results.Add(key, gatewayResult{via: via, expires: time.Now().Add(g.ttl)})
+			delete(g.pending, key)
+			g.mu.Unlock()
+			asyncGatewayLookupTelemetry.pending.Dec()
+		}
+	}
+}
+
+// Close stops the background worker and cleans up the resources of the underlying lookup
+func (g *asyncGatewayLookup) Close() {
+	close(g.done)
+	g.wg.Wait()
+	g.lookup.Close()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.results.Purge()
+	asyncGatewayLookupTelemetry.pending.Sub(float64(len(g.pending)))
+	g.pending = make(map[gatewayKey]struct{})
+}
@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+type blockingGatewayLookup struct {
+	release chan struct{}
+	lookups *atomic.Int32
+	closed  *atomic.Bool
+}
+
+func (b *blockingGatewayLookup) Lookup(cs *ConnectionStats) *Via {
+	return b.LookupWithIPs(cs.Source, cs.Dest, cs.NetNS)
+}
+
+func (b *blockingGatewayLookup) LookupWithIPs(_ util.Address, dest util.Address, _ uint32) *Via {
+	<-b.release
+	b.lookups.Inc()
+	return &Via{Subnet: Subnet{Alias: "subnet-" + dest.String()}}
+}
+
+func (b *blockingGatewayLookup) Close() {
+	b.closed.Store(true)
+}
+
+func TestAsyncGatewayLookup(t *testing.T) {
+	inner := &blockingGatewayLookup{
+		release: make(chan struct{}),
+		lookups: atomic.NewInt32(0),
+		closed:  atomic.NewBool(false),
+	}
+	g := NewAsyncGatewayLookup(inner, 1, 10)
+
+	cs := &ConnectionStats{
+		Source: util.AddressFromString("10.0.0.1"),
+		Dest:   util.AddressFromString("10.0.0.2"),
+	}
+	other := &ConnectionStats{
+		Source: util.AddressFromString("10.0.0.1"),
+		Dest:   util.AddressFromString("10.0.0.3"),
+	}
+
+	// the lookup doesn't block while the gateway is resolved
+	via := g.Lookup(cs)
+	require.NotNil(t, via)
+	assert.True(t, via.Pending)
+	// the tuple is only queued once
+	assert.True(t, g.Lookup(cs).Pending)
+
+	close(inner.release)
+	require.Eventually(t, func() bool {
+		via := g.Lookup(cs)
+		return via != nil && !via.Pending
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "subnet-10.0.0.2", g.Lookup(cs).Subnet.Alias)
+	assert.Equal(t, int32(1), inner.lookups.Load())
+
+	require.True(t, g.Lookup(other).Pending)
+	require.Eventually(t, func() bool {
+		return !g.Lookup(other).Pending
+	}, time.Second, 10*time.Millisecond)
+
+	g.Close()
+	assert.True(t, inner.closed.Load())
+}
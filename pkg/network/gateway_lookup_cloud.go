@@ -0,0 +1,203 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux || windows
+
+package network
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	ddconfig "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/ec2"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	defaultMaxRouteCacheSize  = uint32(math.MaxUint32)
+	defaultMaxSubnetCacheSize = 1024
+	gatewayLookupModuleName   = "network__gateway_lookup"
+	// cloudTopologyTTL is how long the topology of a cloud network is cached, whether it could be
+	// retrieved or not
+	cloudTopologyTTL = time.Hour
+)
+
+// Telemetry
+var gatewayLookupTelemetry = struct {
+	subnetCacheSize    *telemetry.StatGaugeWrapper
+	subnetCacheMisses  *telemetry.StatCounterWrapper
+	subnetCacheLookups *telemetry.StatCounterWrapper
+	subnetLookups      *telemetry.StatCounterWrapper
+	subnetLookupErrors *telemetry.StatCounterWrapper
+}{
+	telemetry.NewStatGaugeWrapper(gatewayLookupModuleName, "subnet_cache_size", []string{}, "Counter measuring the size of the subnet cache"),
+	telemetry.NewStatCounterWrapper(gatewayLookupModuleName, "subnet_cache_misses", []string{}, "Counter measuring the number of subnet cache misses"),
+	telemetry.NewStatCounterWrapper(gatewayLookupModuleName, "subnet_cache_lookups", []string{}, "Counter measuring the number of subnet cache lookups"),
+	telemetry.NewStatCounterWrapper(gatewayLookupModuleName, "subnet_lookups", []string{}, "Counter measuring the number of subnet lookups"),
+	telemetry.NewStatCounterWrapper(gatewayLookupModuleName, "subnet_lookup_errors", []string{"reason"}, "Counter measuring the number of subnet lookup errors"),
+}
+
+// onLinkVia is the Via of the connections routed without a gateway. It has no subnet, so it
+// isn't sent in the payload.
+var onLinkVia = &Via{OnLink: true}
+
+type cloudProvider interface {
+	IsAWS() bool
+}
+
+// SubnetForHwAddrFunc is exported for tracer testing purposes
+var SubnetForHwAddrFunc func(net.HardwareAddr) (Subnet, error)
+
+// Cloud is exported for tracer testing purposes
+var Cloud cloudProvider
+
+func init() {
+	Cloud = &cloudProviderImpl{}
+	SubnetForHwAddrFunc = ec2SubnetForHardwareAddr
+}
+
+func gwLookupEnabled() bool {
+	// only enabled on AWS currently, the subnets of the GCP and Azure interfaces not being resolved
+	return Cloud.IsAWS() && ddconfig.IsCloudProviderEnabled(ec2.CloudProviderName)
+}
+
+func ec2SubnetForHardwareAddr(hwAddr net.HardwareAddr) (Subnet, error) {
+	ifc, err := ec2.GetNetworkInterfaceForHardwareAddr(context.TODO(), hwAddr)
+	if err != nil {
+		return Subnet{}, err
+	}
+
+	s := Subnet{
+		Alias:       ifc.Subnet.ID,
+		Cidr:        ifc.Subnet.Cidr,
+		NetworkID:   ifc.VpcID,
+		InterfaceID: ifc.ID,
+		Zone:        ifc.AvailabilityZone,
+	}
+	for _, cidr := range ifc.VpcCidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			log.Debugf("invalid cidr block %q for vpc %s: %s", cidr, ifc.VpcID, err)
+			continue
+		}
+		s.NetworkCidrs = append(s.NetworkCidrs, prefix)
+	}
+	if ifc.VpcID != "" && cloudTopologyEnabled() {
+		s.Topology = vpcTopologies.get(ifc.VpcID)
+	}
+	return s, nil
+}
+
+// cloudTopologyEnabled returns whether the topology of the cloud networks should be retrieved. It is only
+// used to classify the traffic, and unlike the subnets, which come from the metadata service, it requires
+// calling the EC2 API, with the ec2:DescribeSubnets and ec2:DescribeRouteTables permissions.
+func cloudTopologyEnabled() bool {
+	return ddconfig.SystemProbe.GetBool("network_config.enable_traffic_class_tags")
+}
+
+// vpcTopologies caches the topology of the VPCs, shared by their interfaces
+var vpcTopologies = &cloudTopologyCache{
+	entries: make(map[string]cloudTopologyEntry),
+	fetch:   ec2CloudTopology,
+}
+
+type cloudTopologyEntry struct {
+	topology *CloudTopology
+	expires  time.Time
+}
+
+type cloudTopologyCache struct {
+	mu      sync.Mutex
+	entries map[string]cloudTopologyEntry
+	fetches singleflight.Group
+	fetch   func(networkID string) (*CloudTopology, error)
+}
+
+// get returns the topology of the given network, or nil if it couldn't be retrieved, in which
+// case the traffic is classified from the address ranges of the network only. The topology is
+// fetched without holding the lock, so that the lookups of the other networks aren't blocked,
+// and only once for concurrent lookups of the same network.
+func (c *cloudTopologyCache) get(networkID string) *CloudTopology {
+	if topology, ok := c.cached(networkID); ok {
+		return topology
+	}
+
+	v, _, _ := c.fetches.Do(networkID, func() (interface{}, error) {
+		if topology, ok := c.cached(networkID); ok {
+			return topology, nil
+		}
+		topology, err := c.fetch(networkID)
+		if err != nil {
+			log.Debugf("could not get the topology of the network %s: %s", networkID, err)
+		}
+		c.mu.Lock()
+		c.entries[networkID] = cloudTopologyEntry{topology: topology, expires: time.Now().Add(cloudTopologyTTL)}
+		c.mu.Unlock()
+		return topology, nil
+	})
+	return v.(*CloudTopology)
+}
+
+func (c *cloudTopologyCache) cached(networkID string) (*CloudTopology, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[networkID]
+	if !ok || time.Now().After(e.expires) {
+		return nil, false
+	}
+	return e.topology, true
+}
+
+func ec2CloudTopology(vpcID string) (*CloudTopology, error) {
+	vpc, err := ec2.GetVPCTopology(context.TODO(), vpcID)
+	if err != nil {
+		return nil, err
+	}
+	return newCloudTopology(vpc), nil
+}
+
+// newCloudTopology parses the topology of the given VPC, skipping the invalid or IPv6 only subnets
+// and routes
+func newCloudTopology(vpc ec2.VPCTopology) *CloudTopology {
+	t := &CloudTopology{RouteTables: make(map[string][]CloudRoute)}
+	for _, s := range vpc.Subnets {
+		prefix, err := netip.ParsePrefix(s.Cidr)
+		if err != nil {
+			continue
+		}
+		t.Subnets = append(t.Subnets, CloudSubnet{Prefix: prefix, Zone: s.AvailabilityZone})
+	}
+	for _, rt := range vpc.RouteTables {
+		var routes []CloudRoute
+		for _, r := range rt.Routes {
+			prefix, err := netip.ParsePrefix(r.DestinationCidr)
+			if err != nil {
+				continue
+			}
+			routes = append(routes, CloudRoute{Destination: prefix, Target: r.Target})
+		}
+		if rt.Main {
+			t.MainRouteTable = routes
+		}
+		for _, id := range rt.SubnetIDs {
+			t.RouteTables[id] = routes
+		}
+	}
+	return t
+}
+
+type cloudProviderImpl struct{}
+
+func (cp *cloudProviderImpl) IsAWS() bool {
+	return ec2.IsRunningOn(context.TODO())
+}
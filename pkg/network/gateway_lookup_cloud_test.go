@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux || windows
+
+package network
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/util/ec2"
+)
+
+func TestNewCloudTopology(t *testing.T) {
+	topology := newCloudTopology(ec2.VPCTopology{
+		Subnets: []ec2.VPCSubnet{
+			{ID: "subnet-foo", Cidr: "10.0.1.0/24", AvailabilityZone: "us-east-1a"},
+			{ID: "subnet-ipv6", Cidr: ""},
+		},
+		RouteTables: []ec2.VPCRouteTable{
+			{
+				Main:   true,
+				Routes: []ec2.VPCRoute{{DestinationCidr: "10.0.0.0/16", Target: "local"}},
+			},
+			{
+				SubnetIDs: []string{"subnet-foo"},
+				Routes: []ec2.VPCRoute{
+					{DestinationCidr: "10.0.0.0/16", Target: "local"},
+					{DestinationCidr: "0.0.0.0/0", Target: "igw-foo"},
+				},
+			},
+		},
+	})
+
+	assert.Equal(t, []CloudSubnet{{Prefix: netip.MustParsePrefix("10.0.1.0/24"), Zone: "us-east-1a"}}, topology.Subnets)
+	assert.Equal(t, []CloudRoute{{Destination: netip.MustParsePrefix("10.0.0.0/16"), Target: "local"}}, topology.MainRouteTable)
+	require.Len(t, topology.RouteTables["subnet-foo"], 2)
+	assert.Equal(t, "igw-foo", topology.RouteTables["subnet-foo"][1].Target)
+}
+
+func TestCloudTopologyCache(t *testing.T) {
+	fetches := 0
+	c := &cloudTopologyCache{
+		entries: make(map[string]cloudTopologyEntry),
+		fetch: func(string) (*CloudTopology, error) {
+			fetches++
+			return nil, assert.AnError
+		},
+	}
+
+	// failures are cached too, so that the API isn't called for each interface of the network
+	assert.Nil(t, c.get("vpc-foo"))
+	assert.Nil(t, c.get("vpc-foo"))
+	assert.Equal(t, 1, fetches)
+
+	assert.Nil(t, c.get("vpc-bar"))
+	assert.Equal(t, 2, fetches)
+}
+
+func TestCloudTopologyCacheFetchUnlocked(t *testing.T) {
+	release := make(chan struct{})
+	fetching := make(chan struct{})
+	c := &cloudTopologyCache{
+		entries: map[string]cloudTopologyEntry{
+			"vpc-cached": {topology: &CloudTopology{}, expires: time.Now().Add(time.Hour)},
+		},
+		fetch: func(string) (*CloudTopology, error) {
+			close(fetching)
+			<-release
+			return &CloudTopology{}, nil
+		},
+	}
+
+	done := make(chan *CloudTopology)
+	go func() { done <- c.get("vpc-slow") }()
+	<-fetching
+
+	// the cached networks are looked up while another network is fetched
+	assert.NotNil(t, c.get("vpc-cached"))
+
+	close(release)
+	assert.NotNil(t, <-done)
+	assert.NotNil(t, c.get("vpc-slow"))
+}
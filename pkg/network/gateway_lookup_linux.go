@@ -8,70 +8,28 @@
 package network
 
 import (
-	"context"
-	"math"
 	"net"
 	"time"
 
 	"github.com/hashicorp/golang-lru/v2/simplelru"
 	"github.com/vishvananda/netns"
 
-	ddconfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/errors"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
-	"github.com/DataDog/datadog-agent/pkg/telemetry"
-	"github.com/DataDog/datadog-agent/pkg/util/ec2"
 	"github.com/DataDog/datadog-agent/pkg/util/kernel"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
-const (
-	defaultMaxRouteCacheSize  = uint32(math.MaxUint32)
-	defaultMaxSubnetCacheSize = 1024
-	gatewayLookupModuleName   = "network__gateway_lookup"
-)
-
-// Telemetry
-var gatewayLookupTelemetry = struct {
-	subnetCacheSize    *telemetry.StatGaugeWrapper
-	subnetCacheMisses  *telemetry.StatCounterWrapper
-	subnetCacheLookups *telemetry.StatCounterWrapper
-	subnetLookups      *telemetry.StatCounterWrapper
-	subnetLookupErrors *telemetry.StatCounterWrapper
-}{
-	telemetry.NewStatGaugeWrapper(gatewayLookupModuleName, "subnet_cache_size", []string{}, "Counter measuring the size of the subnet cache"),
-	telemetry.NewStatCounterWrapper(gatewayLookupModuleName, "subnet_cache_misses", []string{}, "Counter measuring the number of subnet cache misses"),
-	telemetry.NewStatCounterWrapper(gatewayLookupModuleName, "subnet_cache_lookups", []string{}, "Counter measuring the number of subnet cache lookups"),
-	telemetry.NewStatCounterWrapper(gatewayLookupModuleName, "subnet_lookups", []string{}, "Counter measuring the number of subnet lookups"),
-	telemetry.NewStatCounterWrapper(gatewayLookupModuleName, "subnet_lookup_errors", []string{"reason"}, "Counter measuring the number of subnet lookup errors"),
-}
-
 // gatewayLookup implements a gateway lookup
 // functionality for linux
 type gatewayLookup struct {
 	rootNetNs   netns.NsHandle
 	routeCache  RouteCache
 	subnetCache *simplelru.LRU[int, interface{}] // interface index to subnet cache
-}
-
-type cloudProvider interface {
-	IsAWS() bool
-}
-
-// SubnetForHwAddrFunc is exported for tracer testing purposes
-var SubnetForHwAddrFunc func(net.HardwareAddr) (Subnet, error)
-
-// Cloud is exported for tracer testing purposes
-var Cloud cloudProvider
-
-func init() {
-	Cloud = &cloudProviderImpl{}
-	SubnetForHwAddrFunc = ec2SubnetForHardwareAddr
-}
-
-func gwLookupEnabled() bool {
-	// only enabled on AWS currently
-	return Cloud.IsAWS() && ddconfig.IsCloudProviderEnabled(ec2.CloudProviderName)
+	prober      *gatewayProber
+	// ownsRouteCache is false when the route cache is shared with the caller,
+	// which closes it
+	ownsRouteCache bool
 }
 
 // NewGatewayLookup creates a new instance of a gateway lookup using
@@ -87,12 +45,9 @@ func NewGatewayLookup(rootNsLookup nsLookupFunc, maxRouteCacheSize uint32) Gatew
 		return nil
 	}
 
-	gl := &gatewayLookup{
-		rootNetNs: rootNetNs,
-	}
-
 	router, err := NewNetlinkRouter(rootNetNs)
 	if err != nil {
+		rootNetNs.Close()
 		log.Errorf("could not create gateway lookup: %s", err)
 		return nil
 	}
@@ -104,8 +59,38 @@ func NewGatewayLookup(rootNsLookup nsLookupFunc, maxRouteCacheSize uint32) Gatew
 		log.Warnf("using truncated route cache size of %d instead of %d", routeCacheSize, defaultMaxRouteCacheSize)
 	}
 
-	gl.subnetCache, _ = simplelru.NewLRU[int, interface{}](int(routeCacheSize), nil)
-	gl.routeCache = NewRouteCache(int(routeCacheSize), router)
+	gl := newGatewayLookup(rootNetNs, NewRouteCache(int(routeCacheSize), router), int(routeCacheSize), 0)
+	gl.ownsRouteCache = true
+	return gl
+}
+
+// NewGatewayLookupWithProbing creates a new instance of a gateway lookup resolving the
+// routes with the given route cache, which is shared with the caller and isn't closed
+// with the gateway lookup. The gateway lookup also probes the reachability of the
+// gateways it resolves at the given interval. An interval of 0 disables the probing.
+func NewGatewayLookupWithProbing(rootNsLookup nsLookupFunc, routeCache RouteCache, subnetCacheSize int, probeInterval time.Duration) GatewayLookup {
+	if !gwLookupEnabled() {
+		return nil
+	}
+
+	rootNetNs, err := rootNsLookup()
+	if err != nil {
+		log.Errorf("could not create gateway lookup: %s", err)
+		return nil
+	}
+	return newGatewayLookup(rootNetNs, routeCache, subnetCacheSize, probeInterval)
+}
+
+func newGatewayLookup(rootNetNs netns.NsHandle, routeCache RouteCache, subnetCacheSize int, probeInterval time.Duration) *gatewayLookup {
+	gl := &gatewayLookup{
+		rootNetNs:  rootNetNs,
+		routeCache: routeCache,
+	}
+	gl.subnetCache, _ = simplelru.NewLRU[int, interface{}](subnetCacheSize, nil)
+	if probeInterval > 0 {
+		gl.prober = newGatewayProber(rootNetNs, probeInterval)
+		gl.prober.start()
+	}
 	return gl
 }
 
@@ -130,9 +115,16 @@ func (g *gatewayLookup) LookupWithIPs(source util.Address, dest util.Address, ne
 	// if there is no gateway, we don't need to add subnet info
 	// for gateway resolution in the backend
 	if r.Gateway.IsZero() || r.Gateway.IsUnspecified() {
+		if r.OnLink {
+			return onLinkVia
+		}
 		return nil
 	}
 
+	if g.prober != nil {
+		g.prober.observe(r.Gateway, r.IfIndex)
+	}
+
 	gatewayLookupTelemetry.subnetCacheLookups.Inc()
 	v, ok := g.subnetCache.Get(r.IfIndex)
 	if !ok {
@@ -208,8 +200,13 @@ func (g *gatewayLookup) LookupWithIPs(source util.Address, dest util.Address, ne
 // Close cleans up resources allocated
 // by this struct
 func (g *gatewayLookup) Close() {
+	if g.prober != nil {
+		g.prober.close()
+	}
 	g.rootNetNs.Close()
-	g.routeCache.Close()
+	if g.ownsRouteCache {
+		g.routeCache.Close()
+	}
 	g.purge()
 }
 
@@ -217,18 +214,3 @@ func (g *gatewayLookup) purge() {
 	g.subnetCache.Purge()
 	gatewayLookupTelemetry.subnetCacheSize.Set(0)
 }
-
-func ec2SubnetForHardwareAddr(hwAddr net.HardwareAddr) (Subnet, error) {
-	snet, err := ec2.GetSubnetForHardwareAddr(context.TODO(), hwAddr)
-	if err != nil {
-		return Subnet{}, err
-	}
-
-	return Subnet{Alias: snet.ID}, nil
-}
-
-type cloudProviderImpl struct{}
-
-func (cp *cloudProviderImpl) IsAWS() bool {
-	return ec2.IsRunningOn(context.TODO())
-}
@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestGetGatewayTags(t *testing.T) {
+	via := &Via{Subnet: Subnet{
+		Alias:       "subnet-foo",
+		Cidr:        "10.0.1.0/24",
+		NetworkID:   "vpc-foo",
+		InterfaceID: "eni-foo",
+		Zone:        "us-east-1a",
+		Topology: &CloudTopology{
+			MainRouteTable: []CloudRoute{
+				{Destination: netip.MustParsePrefix("10.0.0.0/16"), Target: "local"},
+				{Destination: netip.MustParsePrefix("0.0.0.0/0"), Target: "nat-foo"},
+			},
+		},
+	}}
+
+	c := ConnectionStats{Dest: util.AddressFromString("8.8.8.8"), Via: via}
+	assert.Equal(t, []string{
+		"gateway.subnet_cidr:10.0.1.0/24",
+		"gateway.network_id:vpc-foo",
+		"gateway.interface_id:eni-foo",
+		"gateway.zone:us-east-1a",
+		"gateway.type:nat_gateway",
+	}, GetGatewayTags(&c))
+
+	// the routes within the network have no gateway
+	c.Dest = util.AddressFromString("10.0.2.20")
+	assert.NotContains(t, GetGatewayTags(&c), "gateway.type:local")
+	assert.Len(t, GetGatewayTags(&c), 4)
+
+	c.Via = &Via{Pending: true}
+	assert.Empty(t, GetGatewayTags(&c))
+	c.Via = &Via{OnLink: true}
+	assert.Empty(t, GetGatewayTags(&c))
+	c.Via = nil
+	assert.Empty(t, GetGatewayTags(&c))
+}
@@ -0,0 +1,259 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build windows
+
+package network
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/simplelru"
+	"golang.org/x/sys/windows"
+
+	"github.com/DataDog/datadog-agent/pkg/errors"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/winutil/iphelper"
+)
+
+const (
+	// routeTableTTL is how long the IPv4 forwarding table is used before being read again, in
+	// case a route change notification was missed
+	routeTableTTL = time.Minute
+
+	// mibIPRouteTypeIndirect is the type of the routes whose next hop is a gateway
+	// https://learn.microsoft.com/en-us/windows/win32/api/ipmib/ns-ipmib-mib_ipforwardrow
+	mibIPRouteTypeIndirect = 4
+)
+
+var routeChangeTelemetry = struct {
+	routeChanges telemetry.Counter
+	routeReads   telemetry.Counter
+}{
+	telemetry.NewCounter(gatewayLookupModuleName, "route_changes", []string{}, "Counter measuring the number of route change notifications"),
+	telemetry.NewCounter(gatewayLookupModuleName, "route_table_reads", []string{}, "Counter measuring the number of times the forwarding table was read"),
+}
+
+var (
+	// routeTableGeneration is incremented on each route change, the forwarding
+	// table read by a lookup is stale once the generation changed
+	routeTableGeneration atomic.Uint64
+
+	// the callbacks created with windows.NewCallback are never released, so a single one is shared
+	routeChangeCallback     uintptr
+	routeChangeCallbackOnce sync.Once
+)
+
+func onRouteChange(_, _, _ uintptr) uintptr {
+	routeTableGeneration.Add(1)
+	routeChangeTelemetry.routeChanges.Inc()
+	return 0
+}
+
+// gatewayLookup implements a gateway lookup
+// functionality for windows, using the IP Helper forwarding table
+type gatewayLookup struct {
+	mu sync.Mutex
+
+	routes           []iphelper.MIB_IPFORWARDROW
+	routesExpires    time.Time
+	routesGeneration uint64
+	// readRoutes is overridden in tests
+	readRoutes func() ([]iphelper.MIB_IPFORWARDROW, error)
+
+	routeChangeHandle windows.Handle
+
+	subnetCache *simplelru.LRU[uint32, interface{}] // interface index to subnet cache
+}
+
+// NewGatewayLookup creates a new instance of a gateway lookup. The network
+// namespace lookup is ignored on windows.
+func NewGatewayLookup(_ nsLookupFunc, maxRouteCacheSize uint32) GatewayLookup {
+	if !gwLookupEnabled() {
+		return nil
+	}
+
+	subnetCacheSize := defaultMaxSubnetCacheSize
+	if maxRouteCacheSize < uint32(subnetCacheSize) {
+		subnetCacheSize = int(maxRouteCacheSize)
+	}
+
+	gl := &gatewayLookup{readRoutes: iphelper.GetIPv4RouteTable}
+	gl.subnetCache, _ = simplelru.NewLRU[uint32, interface{}](subnetCacheSize, nil)
+
+	// the routes rewritten by a VPN client are noticed right away rather than after the TTL
+	routeChangeCallbackOnce.Do(func() {
+		routeChangeCallback = windows.NewCallback(onRouteChange)
+	})
+	h, err := iphelper.NotifyRouteChange2(windows.AF_INET, routeChangeCallback, 0, false)
+	if err != nil {
+		log.Warnf("unable to register for route change notifications, the forwarding table will be read every %s: %s", routeTableTTL, err)
+	} else {
+		gl.routeChangeHandle = h
+	}
+	return gl
+}
+
+// Lookup performs a gateway lookup for connection stats
+func (g *gatewayLookup) Lookup(cs *ConnectionStats) *Via {
+	dest := cs.Dest
+	if cs.IPTranslation != nil {
+		dest = cs.IPTranslation.ReplSrcIP
+	}
+
+	return g.LookupWithIPs(cs.Source, dest, cs.NetNS)
+}
+
+// LookupWithIPs performs a gateway lookup given the source and destination.
+// Only IPv4 destinations are supported.
+func (g *gatewayLookup) LookupWithIPs(_ util.Address, dest util.Address, _ uint32) *Via {
+	if !dest.Is4() {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	routes, err := g.routeTable()
+	if err != nil {
+		log.Debugf("error reading the ipv4 forwarding table: %s", err)
+		return nil
+	}
+
+	r, ok := bestRoute(routes, dest)
+	// if there is no gateway, we don't need to add subnet info
+	// for gateway resolution in the backend
+	if !ok || r.DwForwardType != mibIPRouteTypeIndirect {
+		return nil
+	}
+
+	ifIndex := r.DwForwardIfIndex
+	gatewayLookupTelemetry.subnetCacheLookups.Inc()
+	v, ok := g.subnetCache.Get(ifIndex)
+	if !ok {
+		gatewayLookupTelemetry.subnetCacheMisses.Inc()
+
+		ifi, err := net.InterfaceByIndex(int(ifIndex))
+		if err != nil {
+			log.Debugf("error getting interface for interface index %d: %s", ifIndex, err)
+			// negative cache for 1 minute
+			g.subnetCache.Add(ifIndex, time.Now().Add(1*time.Minute))
+			gatewayLookupTelemetry.subnetCacheSize.Inc()
+			return nil
+		}
+
+		if ifi.Flags&net.FlagLoopback != 0 {
+			// negative cache loopback interfaces
+			g.subnetCache.Add(ifIndex, nil)
+			gatewayLookupTelemetry.subnetCacheSize.Inc()
+			return nil
+		}
+
+		gatewayLookupTelemetry.subnetLookups.Inc()
+		s, err := SubnetForHwAddrFunc(ifi.HardwareAddr)
+		if err != nil {
+			log.Debugf("error getting subnet info for interface index %d: %s", ifIndex, err)
+
+			// cache an empty result so that we don't keep hitting the
+			// ec2 metadata endpoint for this interface
+			if errors.IsTimeout(err) {
+				// retry after a minute if we timed out
+				g.subnetCache.Add(ifIndex, time.Now().Add(time.Minute))
+				gatewayLookupTelemetry.subnetLookupErrors.Inc("timeout")
+			} else {
+				g.subnetCache.Add(ifIndex, nil)
+				gatewayLookupTelemetry.subnetLookupErrors.Inc("general error")
+			}
+			gatewayLookupTelemetry.subnetCacheSize.Inc()
+			return nil
+		}
+
+		via := &Via{Subnet: s}
+		g.subnetCache.Add(ifIndex, via)
+		gatewayLookupTelemetry.subnetCacheSize.Inc()
+		v = via
+	} else if v == nil {
+		return nil
+	}
+
+	switch cv := v.(type) {
+	case time.Time:
+		if time.Now().After(cv) {
+			g.subnetCache.Remove(ifIndex)
+			gatewayLookupTelemetry.subnetCacheSize.Dec()
+		}
+		return nil
+	case *Via:
+		return cv
+	default:
+		return nil
+	}
+}
+
+// routeTable returns the IPv4 forwarding table, read again once a route changed
+// or it expired. It must be called with the lock held.
+func (g *gatewayLookup) routeTable() ([]iphelper.MIB_IPFORWARDROW, error) {
+	// loaded before reading the table, so that a change during the read isn't missed
+	generation := routeTableGeneration.Load()
+	if g.routes != nil && generation == g.routesGeneration && time.Now().Before(g.routesExpires) {
+		return g.routes, nil
+	}
+
+	routeChangeTelemetry.routeReads.Inc()
+	routes, err := g.readRoutes()
+	if err != nil {
+		return nil, err
+	}
+	g.routes = routes
+	g.routesExpires = time.Now().Add(routeTableTTL)
+	g.routesGeneration = generation
+	return routes, nil
+}
+
+// bestRoute returns the route the given destination matches with the longest
+// prefix, and the lowest metric among those
+func bestRoute(routes []iphelper.MIB_IPFORWARDROW, dest util.Address) (iphelper.MIB_IPFORWARDROW, bool) {
+	// the addresses of the forwarding table are in network byte order
+	b := dest.As4()
+	addr := binary.LittleEndian.Uint32(b[:])
+
+	var best iphelper.MIB_IPFORWARDROW
+	bestPrefix := -1
+	for _, r := range routes {
+		if addr&r.DwForwardMask != r.DwForwardDest {
+			continue
+		}
+		prefix := bits.OnesCount32(r.DwForwardMask)
+		if prefix > bestPrefix || (prefix == bestPrefix && r.DwForwardMetric1 < best.DwForwardMetric1) {
+			best = r
+			bestPrefix = prefix
+		}
+	}
+	return best, bestPrefix >= 0
+}
+
+// Close cleans up resources allocated
+// by this struct
+func (g *gatewayLookup) Close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.routeChangeHandle != 0 {
+		if err := iphelper.CancelMibChangeNotify2(g.routeChangeHandle); err != nil {
+			log.Debugf("error canceling the route change notifications: %s", err)
+		}
+		g.routeChangeHandle = 0
+	}
+	g.routes = nil
+	g.subnetCache.Purge()
+	gatewayLookupTelemetry.subnetCacheSize.Set(0)
+}
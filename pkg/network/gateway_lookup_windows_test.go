@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build windows
+
+package network
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/winutil/iphelper"
+)
+
+func TestBestRoute(t *testing.T) {
+	ip := func(s string) uint32 {
+		b := util.AddressFromString(s).As4()
+		return binary.LittleEndian.Uint32(b[:])
+	}
+
+	routes := []iphelper.MIB_IPFORWARDROW{
+		// default route
+		{DwForwardDest: ip("0.0.0.0"), DwForwardMask: ip("0.0.0.0"), DwForwardNextHop: ip("10.0.0.1"), DwForwardIfIndex: 1, DwForwardType: mibIPRouteTypeIndirect, DwForwardMetric1: 10},
+		// on-link subnet
+		{DwForwardDest: ip("10.0.0.0"), DwForwardMask: ip("255.255.255.0"), DwForwardNextHop: ip("10.0.0.5"), DwForwardIfIndex: 1, DwForwardType: 3, DwForwardMetric1: 10},
+		// two gateways for the same subnet
+		{DwForwardDest: ip("172.16.0.0"), DwForwardMask: ip("255.255.0.0"), DwForwardNextHop: ip("10.0.0.2"), DwForwardIfIndex: 2, DwForwardType: mibIPRouteTypeIndirect, DwForwardMetric1: 20},
+		{DwForwardDest: ip("172.16.0.0"), DwForwardMask: ip("255.255.0.0"), DwForwardNextHop: ip("10.0.0.3"), DwForwardIfIndex: 3, DwForwardType: mibIPRouteTypeIndirect, DwForwardMetric1: 5},
+	}
+
+	r, ok := bestRoute(routes, util.AddressFromString("8.8.8.8"))
+	require.True(t, ok)
+	assert.Equal(t, ip("10.0.0.1"), r.DwForwardNextHop)
+
+	r, ok = bestRoute(routes, util.AddressFromString("10.0.0.42"))
+	require.True(t, ok)
+	assert.NotEqual(t, uint32(mibIPRouteTypeIndirect), r.DwForwardType)
+
+	r, ok = bestRoute(routes, util.AddressFromString("172.16.1.1"))
+	require.True(t, ok)
+	assert.Equal(t, uint32(3), r.DwForwardIfIndex)
+
+	_, ok = bestRoute(routes[1:], util.AddressFromString("8.8.8.8"))
+	assert.False(t, ok)
+}
+
+func TestRouteTableReadOnRouteChange(t *testing.T) {
+	reads := 0
+	g := &gatewayLookup{
+		readRoutes: func() ([]iphelper.MIB_IPFORWARDROW, error) {
+			reads++
+			return []iphelper.MIB_IPFORWARDROW{}, nil
+		},
+	}
+
+	_, err := g.routeTable()
+	require.NoError(t, err)
+	_, err = g.routeTable()
+	require.NoError(t, err)
+	assert.Equal(t, 1, reads)
+
+	onRouteChange(0, 0, 0)
+	_, err = g.routeTable()
+	require.NoError(t, err)
+	assert.Equal(t, 2, reads)
+
+	// expired without any notification
+	g.routesExpires = time.Now().Add(-time.Second)
+	_, err = g.routeTable()
+	require.NoError(t, err)
+	assert.Equal(t, 3, reads)
+}
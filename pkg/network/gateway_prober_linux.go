@@ -0,0 +1,202 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+package network
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	probing "github.com/prometheus-community/pro-bing"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	gatewayProberModuleName = "network__gateway_prober"
+	// gatewayProbeExpiry is how long a gateway keeps being probed after the last connection routed through it
+	gatewayProbeExpiry = 10 * time.Minute
+	gatewayPingTimeout = 3 * time.Second
+)
+
+var gatewayProberTelemetry = struct {
+	reachable        telemetry.Gauge
+	neighborResolved telemetry.Gauge
+	rtt              telemetry.Gauge
+	probes           telemetry.Counter
+}{
+	telemetry.NewGauge(gatewayProberModuleName, "reachable", []string{"gateway"}, "Gauge set to 1 when the gateway answered the last ICMP echo request, 0 otherwise"),
+	telemetry.NewGauge(gatewayProberModuleName, "neighbor_resolved", []string{"gateway"}, "Gauge set to 1 when the link layer address of the gateway is resolved by ARP or NDP, 0 otherwise"),
+	telemetry.NewGauge(gatewayProberModuleName, "rtt_seconds", []string{"gateway"}, "Gauge measuring the round trip time of the last ICMP echo request answered by the gateway"),
+	telemetry.NewCounter(gatewayProberModuleName, "probes", []string{}, "Counter measuring the number of gateway probes"),
+}
+
+type gatewayTarget struct {
+	gateway util.Address
+	ifIndex int
+}
+
+// gatewayProber periodically checks the reachability of the gateways the connections are routed
+// through, so that a flapping gateway is noticed before the application traffic suffers from it
+type gatewayProber struct {
+	rootNetNs netns.NsHandle
+	interval  time.Duration
+
+	mu       sync.Mutex
+	gateways map[gatewayTarget]time.Time // last time a connection was routed through the gateway
+
+	// ping and neighborResolved are overridden in tests
+	ping             func(gateway util.Address) (time.Duration, error)
+	neighborResolved func(t gatewayTarget) (bool, error)
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newGatewayProber(rootNetNs netns.NsHandle, interval time.Duration) *gatewayProber {
+	p := &gatewayProber{
+		rootNetNs: rootNetNs,
+		interval:  interval,
+		gateways:  make(map[gatewayTarget]time.Time),
+		done:      make(chan struct{}),
+	}
+	p.ping = p.icmpPing
+	p.neighborResolved = p.neighborState
+	return p
+}
+
+func (p *gatewayProber) start() {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.done:
+				return
+			case now := <-ticker.C:
+				p.probe(now)
+			}
+		}
+	}()
+}
+
+// observe records that a connection is routed through the given gateway
+func (p *gatewayProber) observe(gateway util.Address, ifIndex int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.gateways[gatewayTarget{gateway: gateway, ifIndex: ifIndex}] = time.Now()
+}
+
+// targets returns the gateways to probe, and forgets the ones no connection was routed through lately
+func (p *gatewayProber) targets(now time.Time) []gatewayTarget {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	targets := make([]gatewayTarget, 0, len(p.gateways))
+	for t, lastSeen := range p.gateways {
+		if now.Sub(lastSeen) > gatewayProbeExpiry {
+			delete(p.gateways, t)
+			gw := t.gateway.String()
+			gatewayProberTelemetry.reachable.Delete(gw)
+			gatewayProberTelemetry.neighborResolved.Delete(gw)
+			gatewayProberTelemetry.rtt.Delete(gw)
+			continue
+		}
+		targets = append(targets, t)
+	}
+	return targets
+}
+
+func (p *gatewayProber) probe(now time.Time) {
+	for _, t := range p.targets(now) {
+		gatewayProberTelemetry.probes.Inc()
+		gw := t.gateway.String()
+
+		rtt, err := p.ping(t.gateway)
+		if err != nil {
+			log.Debugf("gateway %s did not answer the icmp echo request: %s", gw, err)
+			gatewayProberTelemetry.reachable.Set(0, gw)
+		} else {
+			gatewayProberTelemetry.reachable.Set(1, gw)
+			gatewayProberTelemetry.rtt.Set(rtt.Seconds(), gw)
+		}
+
+		// the echo request triggers the resolution of the gateway, if its neighbor entry wasn't valid
+		resolved, err := p.neighborResolved(t)
+		if err != nil {
+			log.Debugf("error getting the neighbor entry of gateway %s: %s", gw, err)
+			continue
+		}
+		if resolved {
+			gatewayProberTelemetry.neighborResolved.Set(1, gw)
+		} else {
+			gatewayProberTelemetry.neighborResolved.Set(0, gw)
+		}
+	}
+}
+
+func (p *gatewayProber) icmpPing(gateway util.Address) (rtt time.Duration, err error) {
+	err = kernel.WithNS(p.rootNetNs, func() error {
+		pinger, err := probing.NewPinger(gateway.String())
+		if err != nil {
+			return err
+		}
+		pinger.SetPrivileged(true)
+		pinger.Count = 1
+		pinger.Timeout = gatewayPingTimeout
+		if err := pinger.Run(); err != nil {
+			return err
+		}
+
+		stats := pinger.Statistics()
+		if stats.PacketsRecv == 0 {
+			return fmt.Errorf("no echo reply after %s", gatewayPingTimeout)
+		}
+		rtt = stats.AvgRtt
+		return nil
+	})
+	return
+}
+
+func (p *gatewayProber) neighborState(t gatewayTarget) (bool, error) {
+	h, err := netlink.NewHandleAt(p.rootNetNs)
+	if err != nil {
+		return false, err
+	}
+	defer h.Close()
+
+	family := unix.AF_INET
+	if t.gateway.Is6() {
+		family = unix.AF_INET6
+	}
+	neighbors, err := h.NeighList(t.ifIndex, family)
+	if err != nil {
+		return false, err
+	}
+
+	for _, n := range neighbors {
+		if !n.IP.Equal(t.gateway.AsSlice()) {
+			continue
+		}
+		return n.State&(netlink.NUD_FAILED|netlink.NUD_INCOMPLETE) == 0 && n.State != netlink.NUD_NONE, nil
+	}
+	return false, nil
+}
+
+func (p *gatewayProber) close() {
+	close(p.done)
+	p.wg.Wait()
+}
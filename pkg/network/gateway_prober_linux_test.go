@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+package network
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netns"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestGatewayProber(t *testing.T) {
+	p := newGatewayProber(netns.None(), time.Minute)
+
+	var pinged []util.Address
+	p.ping = func(gateway util.Address) (time.Duration, error) {
+		pinged = append(pinged, gateway)
+		if gateway == util.AddressFromString("10.0.0.2") {
+			return 0, errors.New("timeout")
+		}
+		return time.Millisecond, nil
+	}
+	p.neighborResolved = func(t gatewayTarget) (bool, error) {
+		return t.ifIndex == 1, nil
+	}
+
+	gw1 := util.AddressFromString("10.0.0.1")
+	gw2 := util.AddressFromString("10.0.0.2")
+	p.observe(gw1, 1)
+	p.observe(gw2, 2)
+	// the same gateway is only probed once
+	p.observe(gw1, 1)
+
+	p.probe(time.Now())
+	assert.ElementsMatch(t, []util.Address{gw1, gw2}, pinged)
+
+	assert.Equal(t, 1.0, gatewayProberTelemetry.reachable.WithValues(gw1.String()).Get())
+	assert.Equal(t, 0.0, gatewayProberTelemetry.reachable.WithValues(gw2.String()).Get())
+	assert.Equal(t, 1.0, gatewayProberTelemetry.neighborResolved.WithValues(gw1.String()).Get())
+	assert.Equal(t, 0.0, gatewayProberTelemetry.neighborResolved.WithValues(gw2.String()).Get())
+	assert.Equal(t, time.Millisecond.Seconds(), gatewayProberTelemetry.rtt.WithValues(gw1.String()).Get())
+
+	// the gateways no connection was routed through lately are forgotten
+	pinged = nil
+	p.probe(time.Now().Add(gatewayProbeExpiry + time.Second))
+	assert.Empty(t, pinged)
+	assert.Empty(t, p.gateways)
+}
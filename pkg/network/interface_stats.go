@@ -0,0 +1,23 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+// InterfaceStats holds the counters of a network interface, as reported by the kernel.
+// The counters are monotonic, they are only reset when the interface is recreated.
+type InterfaceStats struct {
+	NetNS   uint32
+	Ifindex int
+	Name    string
+
+	RxBytes   uint64
+	TxBytes   uint64
+	RxPackets uint64
+	TxPackets uint64
+	RxDropped uint64
+	TxDropped uint64
+	RxErrors  uint64
+	TxErrors  uint64
+}
@@ -0,0 +1,118 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+package network
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// InterfaceStatsSampler samples the counters of the interfaces of all the network namespaces,
+// caching the result so that the clients of the same check don't each walk all the namespaces
+type InterfaceStatsSampler struct {
+	procRoot string
+	ttl      time.Duration
+
+	mu          sync.Mutex
+	lastSampled time.Time
+	stats       []InterfaceStats
+}
+
+// NewInterfaceStatsSampler creates a new InterfaceStatsSampler
+//
+// `cacheFor` caches the counters for the given time duration; `0` disables caching
+func NewInterfaceStatsSampler(procRoot string, cacheFor time.Duration) *InterfaceStatsSampler {
+	return &InterfaceStatsSampler{procRoot: procRoot, ttl: cacheFor}
+}
+
+// Get returns the counters of the interfaces, sampling them if the cached ones expired
+func (s *InterfaceStatsSampler) Get() ([]InterfaceStats, error) {
+	return s.get(time.Now(), GetInterfaceStats)
+}
+
+func (s *InterfaceStatsSampler) get(now time.Time, sample func(string) ([]InterfaceStats, error)) ([]InterfaceStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.lastSampled.IsZero() && s.lastSampled.Add(s.ttl).After(now) {
+		return s.stats, nil
+	}
+
+	stats, err := sample(s.procRoot)
+	if err != nil {
+		return nil, err
+	}
+	s.stats = stats
+	s.lastSampled = now
+	return stats, nil
+}
+
+// GetInterfaceStats samples the counters of the interfaces of all the network namespaces, using netlink
+func GetInterfaceStats(procRoot string) ([]InterfaceStats, error) {
+	nss, err := kernel.GetNetNamespaces(procRoot)
+	if err != nil {
+		return nil, fmt.Errorf("could not list network namespaces: %w", err)
+	}
+
+	var stats []InterfaceStats
+	for _, ns := range nss {
+		ino, err := kernel.GetInoForNs(ns)
+		if err != nil {
+			ns.Close()
+			continue
+		}
+
+		// a handle bound to the namespace doesn't require to switch the namespace of the thread
+		h, err := netlink.NewHandleAt(ns)
+		ns.Close()
+		if err != nil {
+			log.Debugf("could not create netlink handle for netns %d: %s", ino, err)
+			continue
+		}
+		links, err := h.LinkList()
+		h.Close()
+		if err != nil {
+			log.Debugf("could not list the interfaces of netns %d: %s", ino, err)
+			continue
+		}
+
+		for _, link := range links {
+			if s, ok := linkStats(ino, link.Attrs()); ok {
+				stats = append(stats, s)
+			}
+		}
+	}
+	return stats, nil
+}
+
+func linkStats(netns uint32, attrs *netlink.LinkAttrs) (InterfaceStats, bool) {
+	if attrs == nil || attrs.Statistics == nil {
+		return InterfaceStats{}, false
+	}
+
+	s := attrs.Statistics
+	return InterfaceStats{
+		NetNS:     netns,
+		Ifindex:   attrs.Index,
+		Name:      attrs.Name,
+		RxBytes:   s.RxBytes,
+		TxBytes:   s.TxBytes,
+		RxPackets: s.RxPackets,
+		TxPackets: s.TxPackets,
+		RxDropped: s.RxDropped,
+		TxDropped: s.TxDropped,
+		RxErrors:  s.RxErrors,
+		TxErrors:  s.TxErrors,
+	}, true
+}
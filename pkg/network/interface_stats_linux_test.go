@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vishvananda/netlink"
+)
+
+func TestLinkStats(t *testing.T) {
+	attrs := &netlink.LinkAttrs{
+		Index: 2,
+		Name:  "eth0",
+		Statistics: &netlink.LinkStatistics{
+			RxBytes:   1000,
+			TxBytes:   2000,
+			RxPackets: 10,
+			TxPackets: 20,
+			RxDropped: 1,
+			TxErrors:  2,
+		},
+	}
+
+	stats, ok := linkStats(4026531840, attrs)
+	assert.True(t, ok)
+	assert.Equal(t, InterfaceStats{
+		NetNS:     4026531840,
+		Ifindex:   2,
+		Name:      "eth0",
+		RxBytes:   1000,
+		TxBytes:   2000,
+		RxPackets: 10,
+		TxPackets: 20,
+		RxDropped: 1,
+		TxErrors:  2,
+	}, stats)
+
+	_, ok = linkStats(4026531840, &netlink.LinkAttrs{Index: 3, Name: "lo"})
+	assert.False(t, ok)
+}
+
+func TestInterfaceStatsSamplerCache(t *testing.T) {
+	samples := 0
+	sample := func(string) ([]InterfaceStats, error) {
+		samples++
+		return []InterfaceStats{{Name: "eth0", RxBytes: uint64(samples)}}, nil
+	}
+
+	s := NewInterfaceStatsSampler("/proc", 10*time.Second)
+	now := time.Now()
+	stats, err := s.get(now, sample)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), stats[0].RxBytes)
+
+	// within the ttl the namespaces are not walked again
+	stats, err = s.get(now.Add(5*time.Second), sample)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), stats[0].RxBytes)
+	assert.Equal(t, 1, samples)
+
+	stats, err = s.get(now.Add(10*time.Second), sample)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), stats[0].RxBytes)
+	assert.Equal(t, 2, samples)
+}
@@ -0,0 +1,61 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// ListeningSocket describes a TCP socket in the LISTEN state or a bound UDP socket
+type ListeningSocket struct {
+	Type   ConnectionType
+	Family ConnectionFamily
+	// Addr is the local address the socket is bound to. It is
+	// the unspecified address for sockets bound to all interfaces.
+	Addr  util.Address
+	Port  uint16
+	NetNS uint32
+	// Pid is the process which started listening on, or bound, the socket
+	Pid uint32
+	// ContainerID is the ID of the container owning Pid, if any
+	ContainerID string
+
+	// ListenOverflow counts the overflows of the queues of TCP sockets
+	ListenOverflow
+}
+
+// ListenOverflow counts the overflows of the queues of a listening TCP socket,
+// which make the clients time out without any error being reported
+type ListenOverflow struct {
+	// AcceptQueueDrops is the number of connections dropped because the accept queue was full
+	AcceptQueueDrops uint32
+	// SynBacklogOverflows is the number of SYNs received while the SYN backlog was full.
+	// They are dropped, unless syncookies are enabled.
+	SynBacklogOverflows uint32
+}
+
+// IsWildcard returns true if the socket is bound to all the interfaces of its network namespace
+func (s ListeningSocket) IsWildcard() bool {
+	return !s.Addr.IsValid() || s.Addr.IsUnspecified()
+}
+
+func (s ListeningSocket) String() string {
+	addr := "*"
+	if !s.IsWildcard() {
+		addr = s.Addr.String()
+	}
+	return fmt.Sprintf("[%s%s] [PID: %d] [ns: %d] %s",
+		s.Type,
+		s.Family,
+		s.Pid,
+		s.NetNS,
+		net.JoinHostPort(addr, strconv.Itoa(int(s.Port))),
+	)
+}
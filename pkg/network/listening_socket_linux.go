@@ -0,0 +1,38 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+package network
+
+// AddListenOverflows sets the overflows of the queues of the TCP sockets listening
+// on the given ports. The ports without a known listening socket, such as those
+// which started listening before system-probe, are added to the sockets.
+func AddListenOverflows(sockets []ListeningSocket, overflows map[PortMapping]ListenOverflow) []ListeningSocket {
+	found := make(map[PortMapping]struct{}, len(overflows))
+	for i := range sockets {
+		if sockets[i].Type != TCP {
+			continue
+		}
+		pm := PortMapping{Ino: sockets[i].NetNS, Port: sockets[i].Port}
+		if o, ok := overflows[pm]; ok {
+			sockets[i].ListenOverflow = o
+			found[pm] = struct{}{}
+		}
+	}
+
+	for pm, o := range overflows {
+		if _, ok := found[pm]; ok {
+			continue
+		}
+		sockets = append(sockets, ListeningSocket{
+			Type:           TCP,
+			Port:           pm.Port,
+			NetNS:          pm.Ino,
+			ListenOverflow: o,
+		})
+	}
+	return sockets
+}
@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddListenOverflows(t *testing.T) {
+	sockets := []ListeningSocket{
+		{Type: TCP, Family: AFINET, Port: 80, NetNS: 1, Pid: 10},
+		{Type: TCP, Family: AFINET6, Port: 80, NetNS: 1, Pid: 11},
+		{Type: UDP, Family: AFINET, Port: 53, NetNS: 1, Pid: 12},
+		{Type: TCP, Family: AFINET, Port: 443, NetNS: 1, Pid: 13},
+	}
+	overflows := map[PortMapping]ListenOverflow{
+		{Ino: 1, Port: 80}:   {AcceptQueueDrops: 3, SynBacklogOverflows: 1},
+		{Ino: 1, Port: 53}:   {AcceptQueueDrops: 1},
+		{Ino: 2, Port: 8080}: {AcceptQueueDrops: 7},
+	}
+
+	sockets = AddListenOverflows(sockets, overflows)
+	require.Len(t, sockets, 6)
+	assert.Equal(t, ListenOverflow{AcceptQueueDrops: 3, SynBacklogOverflows: 1}, sockets[0].ListenOverflow)
+	assert.Equal(t, ListenOverflow{AcceptQueueDrops: 3, SynBacklogOverflows: 1}, sockets[1].ListenOverflow)
+	assert.Zero(t, sockets[2].ListenOverflow)
+	assert.Zero(t, sockets[3].ListenOverflow)
+
+	// ports without a known listening socket are reported on their own
+	added := map[uint16]ListeningSocket{}
+	for _, s := range sockets[4:] {
+		assert.Equal(t, TCP, s.Type)
+		assert.Zero(t, s.Pid)
+		added[s.Port] = s
+	}
+	assert.Equal(t, uint32(7), added[8080].AcceptQueueDrops)
+	assert.Equal(t, uint32(2), added[8080].NetNS)
+	// the UDP socket doesn't match the TCP port
+	assert.Equal(t, uint32(1), added[53].AcceptQueueDrops)
+}
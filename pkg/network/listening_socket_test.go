@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestListeningSocket(t *testing.T) {
+	t.Run("wildcard", func(t *testing.T) {
+		s := ListeningSocket{
+			Type:   TCP,
+			Family: AFINET,
+			Addr:   util.AddressFromString("0.0.0.0"),
+			Port:   8080,
+			NetNS:  4026531840,
+			Pid:    42,
+		}
+		assert.True(t, s.IsWildcard())
+		assert.Equal(t, "[TCPv4] [PID: 42] [ns: 4026531840] *:8080", s.String())
+
+		s.Addr = util.Address{}
+		assert.True(t, s.IsWildcard())
+	})
+
+	t.Run("bound address", func(t *testing.T) {
+		s := ListeningSocket{
+			Type:   UDP,
+			Family: AFINET6,
+			Addr:   util.AddressFromString("::1"),
+			Port:   53,
+			Pid:    7,
+		}
+		assert.False(t, s.IsWildcard())
+		assert.Equal(t, "[UDPv6] [PID: 7] [ns: 0] [::1]:53", s.String())
+	})
+}
@@ -69,6 +69,51 @@ func (mr *MockRouteCacheMockRecorder) Get(source, dest, netns interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockRouteCache)(nil).Get), source, dest, netns)
 }
 
+// GetWithOptions mocks base method.
+func (m *MockRouteCache) GetWithOptions(source, dest util.Address, netns uint32, opts RouteOptions) (Route, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWithOptions", source, dest, netns, opts)
+	ret0, _ := ret[0].(Route)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// GetWithOptions indicates an expected call of GetWithOptions.
+func (mr *MockRouteCacheMockRecorder) GetWithOptions(source, dest, netns, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithOptions", reflect.TypeOf((*MockRouteCache)(nil).GetWithOptions), source, dest, netns, opts)
+}
+
+// Lookup mocks base method.
+func (m *MockRouteCache) Lookup(source, dest util.Address, netns uint32, opts RouteOptions) (Route, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lookup", source, dest, netns, opts)
+	ret0, _ := ret[0].(Route)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lookup indicates an expected call of Lookup.
+func (mr *MockRouteCacheMockRecorder) Lookup(source, dest, netns, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lookup", reflect.TypeOf((*MockRouteCache)(nil).Lookup), source, dest, netns, opts)
+}
+
+// Subscribe mocks base method.
+func (m *MockRouteCache) Subscribe(source, dest util.Address, netns uint32) (<-chan RouteChange, func()) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", source, dest, netns)
+	ret0, _ := ret[0].(<-chan RouteChange)
+	ret1, _ := ret[1].(func())
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockRouteCacheMockRecorder) Subscribe(source, dest, netns interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockRouteCache)(nil).Subscribe), source, dest, netns)
+}
+
 // GetStats mocks base method.
 func (m *MockRouteCache) GetStats() map[string]interface{} {
 	m.ctrl.T.Helper()
@@ -133,16 +178,16 @@ func (mr *MockRouterMockRecorder) GetStats() *gomock.Call {
 }
 
 // Route mocks base method.
-func (m *MockRouter) Route(source, dest util.Address, netns uint32) (Route, bool) {
+func (m *MockRouter) Route(source, dest util.Address, netns uint32, opts RouteOptions) (Route, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Route", source, dest, netns)
+	ret := m.ctrl.Call(m, "Route", source, dest, netns, opts)
 	ret0, _ := ret[0].(Route)
-	ret1, _ := ret[1].(bool)
+	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Route indicates an expected call of Route.
-func (mr *MockRouterMockRecorder) Route(source, dest, netns interface{}) *gomock.Call {
+func (mr *MockRouterMockRecorder) Route(source, dest, netns, opts interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Route", reflect.TypeOf((*MockRouter)(nil).Route), source, dest, netns)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Route", reflect.TypeOf((*MockRouter)(nil).Route), source, dest, netns, opts)
 }
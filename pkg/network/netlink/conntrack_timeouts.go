@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+package netlink
+
+import (
+	"sync"
+	"time"
+
+	"github.com/vishvananda/netns"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/config/sysctl"
+	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// unrepliedTimeouts are the kernel timeouts of the conntrack entries which haven't seen a reply yet,
+// which is the case of most of the orphaned translations
+type unrepliedTimeouts struct {
+	// udp is nf_conntrack_udp_timeout
+	udp time.Duration
+	// tcp is nf_conntrack_tcp_timeout_syn_sent
+	tcp time.Duration
+}
+
+// conntrackTimeouts holds the unreplied timeouts of each network namespace, the conntrack sysctls
+// being namespaced
+type conntrackTimeouts struct {
+	mu   sync.RWMutex
+	root uint32
+	byNS map[uint32]unrepliedTimeouts
+
+	read func() (root uint32, byNS map[uint32]unrepliedTimeouts, err error)
+}
+
+// newConntrackTimeouts creates the timeouts of the root namespace, or of all the namespaces if
+// allNamespaces is set
+func newConntrackTimeouts(procRoot string, allNamespaces bool) *conntrackTimeouts {
+	return &conntrackTimeouts{
+		read: func() (uint32, map[uint32]unrepliedTimeouts, error) {
+			return readConntrackTimeouts(procRoot, allNamespaces)
+		},
+	}
+}
+
+// refresh reads the timeouts again, the namespaces coming and going
+func (t *conntrackTimeouts) refresh() {
+	root, byNS, err := t.read()
+	if err != nil {
+		log.Debugf("could not read the conntrack timeouts: %s", err)
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.root, t.byNS = root, byNS
+}
+
+// unreplied returns the unreplied timeout of the given transport in the given namespace. The
+// namespace of the streamed events is unknown, 0, in which case the timeouts of the root namespace
+// are used.
+func (t *conntrackTimeouts) unreplied(netNS uint32, transport network.ConnectionType) (time.Duration, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	timeouts, ok := t.byNS[netNS]
+	if !ok {
+		if timeouts, ok = t.byNS[t.root]; !ok {
+			return 0, false
+		}
+	}
+	if transport == network.UDP {
+		return timeouts.udp, timeouts.udp > 0
+	}
+	return timeouts.tcp, timeouts.tcp > 0
+}
+
+func readConntrackTimeouts(procRoot string, allNamespaces bool) (uint32, map[uint32]unrepliedTimeouts, error) {
+	rootNS, err := kernel.GetRootNetNamespace(procRoot)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rootNS.Close()
+	root, err := kernel.GetInoForNs(rootNS)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	nss := []netns.NsHandle{rootNS}
+	if allNamespaces {
+		if nss, err = kernel.GetNetNamespaces(procRoot); err != nil {
+			return 0, nil, err
+		}
+		defer func() {
+			for _, ns := range nss {
+				ns.Close()
+			}
+		}()
+	}
+
+	byNS := make(map[uint32]unrepliedTimeouts, len(nss))
+	for _, ns := range nss {
+		ino, err := kernel.GetInoForNs(ns)
+		if err != nil {
+			continue
+		}
+
+		// the sysctls of the namespace are those of the namespace the reader is in
+		var timeouts unrepliedTimeouts
+		err = kernel.WithNS(ns, func() error {
+			udp, err := sysctl.NewInt(procRoot, "net/netfilter/nf_conntrack_udp_timeout", 0).Get()
+			if err != nil {
+				return err
+			}
+			tcp, err := sysctl.NewInt(procRoot, "net/netfilter/nf_conntrack_tcp_timeout_syn_sent", 0).Get()
+			if err != nil {
+				return err
+			}
+			timeouts = unrepliedTimeouts{udp: time.Duration(udp) * time.Second, tcp: time.Duration(tcp) * time.Second}
+			return nil
+		})
+		if err != nil {
+			log.Tracef("could not read the conntrack timeouts of netns %d: %s", ino, err)
+			continue
+		}
+		byNS[ino] = timeouts
+	}
+	return root, byNS, nil
+}
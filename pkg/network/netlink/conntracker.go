@@ -91,6 +91,8 @@ var conntrackerTelemetry = struct {
 	unregistersTotal    telemetry.Counter
 	evictsTotal         telemetry.Counter
 	registersDropped    telemetry.Counter
+	destroysTotal       telemetry.Counter
+	orphansExpired      telemetry.Counter
 	stateSize           *prometheus.Desc
 	orphanSize          *prometheus.Desc
 }{
@@ -102,6 +104,8 @@ var conntrackerTelemetry = struct {
 	telemetry.NewCounter(telemetryModuleName, "unregisters_total", []string{}, "Counter measuring the total number of attempts to delete connection tuples from the map"),
 	telemetry.NewCounter(telemetryModuleName, "evicts_total", []string{}, "Counter measuring the number of evictions from the conntrack cache"),
 	telemetry.NewCounter(telemetryModuleName, "registers_dropped", []string{}, "Counter measuring the number of skipped registers due to a non-NAT connection"),
+	telemetry.NewCounter(telemetryModuleName, "destroys_total", []string{}, "Counter measuring the number of translations removed following a conntrack destroy event"),
+	telemetry.NewCounter(telemetryModuleName, "orphans_expired_total", []string{}, "Counter measuring the number of translations which expired without ever being matched to a connection"),
 	prometheus.NewDesc(telemetryModuleName+"__state_size", "Gauge measuring the current size of the conntrack cache", nil, nil),
 	prometheus.NewDesc(telemetryModuleName+"__orphan_size", "Gauge measuring the number of orphaned items in the conntrack cache", nil, nil),
 }
@@ -142,6 +146,8 @@ func newConntrackerOnce(cfg *config.Config) (Conntracker, error) {
 		exit:          make(chan struct{}),
 		decoder:       NewDecoder(),
 	}
+	ctr.cache.timeouts = newConntrackTimeouts(cfg.ProcRoot, cfg.EnableConntrackAllNamespaces)
+	ctr.cache.timeouts.refresh()
 
 	for _, family := range []uint8{unix.AF_INET, unix.AF_INET6} {
 		events, err := consumer.DumpTable(family)
@@ -265,6 +271,24 @@ func (ctr *realConntracker) register(c Con) int {
 	return 0
 }
 
+// unregister is called whenever a conntrack entry is destroyed by the kernel, either because the
+// connection was closed or because it timed out
+func (ctr *realConntracker) unregister(c Con) {
+	if !IsNAT(c) {
+		return
+	}
+	then := time.Now()
+
+	ctr.Lock()
+	defer ctr.Unlock()
+
+	removed := ctr.cache.RemoveCon(c)
+
+	conntrackerTelemetry.unregistersTotal.Add(float64(removed))
+	conntrackerTelemetry.unregistersDuration.Observe(float64(time.Since(then).Nanoseconds()))
+	conntrackerTelemetry.destroysTotal.Add(float64(removed))
+}
+
 func (ctr *realConntracker) run() error {
 	events, err := ctr.consumer.Events()
 	if err != nil {
@@ -278,6 +302,9 @@ func (ctr *realConntracker) run() error {
 			case <-done:
 				return
 			case <-ctr.compactTicker.C:
+				if ctr.cache.timeouts != nil {
+					ctr.cache.timeouts.refresh()
+				}
 				ctr.compact()
 			}
 		}
@@ -288,6 +315,10 @@ func (ctr *realConntracker) run() error {
 		for e := range events {
 			conns := ctr.decoder.DecodeAndReleaseEvent(e)
 			for _, c := range conns {
+				if c.Destroyed {
+					ctr.unregister(c)
+					continue
+				}
 				ctr.register(c)
 			}
 		}
@@ -299,6 +330,7 @@ func (ctr *realConntracker) compact() {
 	var removed int64
 	defer func() {
 		conntrackerTelemetry.unregistersTotal.Add(float64(removed))
+		conntrackerTelemetry.orphansExpired.Add(float64(removed))
 		log.Debugf("removed %d orphans", removed)
 	}()
 
@@ -312,6 +344,10 @@ type conntrackCache struct {
 	cache         *simplelru.LRU[connKey, *translationEntry]
 	orphans       *list.List
 	orphanTimeout time.Duration
+
+	// timeouts are the kernel timeouts of the unreplied conntrack entries, which cap the
+	// orphanTimeout of the translations when they are shorter
+	timeouts *conntrackTimeouts
 }
 
 func newConntrackCache(maxSize int, orphanTimeout time.Duration) *conntrackCache {
@@ -347,6 +383,30 @@ func (cc *conntrackCache) Remove(k connKey) bool {
 	return cc.cache.Remove(k)
 }
 
+// RemoveCon removes the translations of both directions of the given conntrack entry, and
+// returns the number of translations removed
+func (cc *conntrackCache) RemoveCon(c Con) (removed int) {
+	for _, tuple := range []*ConTuple{&c.Origin, &c.Reply} {
+		if key, ok := formatKey(tuple); ok && cc.cache.Remove(key) {
+			removed++
+		}
+	}
+	return
+}
+
+// orphanExpiry returns when the orphaned translation of the given namespace and key expires. The orphans list
+// is ordered by insertion, so an orphan expiring before the ones inserted earlier is only removed
+// along with them, at most orphanTimeout after its insertion.
+func (cc *conntrackCache) orphanExpiry(netNS uint32, key connKey) time.Time {
+	timeout := cc.orphanTimeout
+	if cc.timeouts != nil {
+		if kernelTimeout, ok := cc.timeouts.unreplied(netNS, key.transport); ok && kernelTimeout < timeout {
+			timeout = kernelTimeout
+		}
+	}
+	return time.Now().Add(timeout)
+}
+
 func (cc *conntrackCache) Purge() {
 	cc.cache.Purge()
 	cc.orphans.Init()
@@ -374,7 +434,7 @@ func (cc *conntrackCache) Add(c Con, orphan bool) (evicts int) {
 		if orphan {
 			t.orphan = cc.orphans.PushFront(&orphanEntry{
 				key:     key,
-				expires: time.Now().Add(cc.orphanTimeout),
+				expires: cc.orphanExpiry(c.NetNS, key),
 			})
 		}
 
@@ -417,6 +417,63 @@ func TestConntrackCacheRemoveOrphans(t *testing.T) {
 
 }
 
+func TestUnregisterDestroyed(t *testing.T) {
+	rt := newConntracker(10)
+	c := makeTranslatedConn(netip.MustParseAddr("10.0.0.0"), netip.MustParseAddr("20.0.0.0"), netip.MustParseAddr("30.0.0.0"), 6, 12345, 80, 80)
+	rt.register(c)
+	require.Equal(t, 2, rt.cache.cache.Len())
+	require.Equal(t, 2, rt.cache.orphans.Len())
+
+	c.Destroyed = true
+	rt.unregister(c)
+	assert.Equal(t, 0, rt.cache.cache.Len())
+	assert.Equal(t, 0, rt.cache.orphans.Len())
+	assert.Nil(t, rt.GetTranslationForConn(
+		network.ConnectionStats{
+			Source: util.AddressFromString("10.0.0.0"),
+			SPort:  12345,
+			Dest:   util.AddressFromString("30.0.0.0"),
+			DPort:  80,
+			Type:   network.TCP,
+		},
+	))
+}
+
+func TestConntrackCacheOrphanTimeouts(t *testing.T) {
+	const rootNS, otherNS = 1, 2
+	rt := newConntracker(10)
+	rt.cache.timeouts = &conntrackTimeouts{
+		read: func() (uint32, map[uint32]unrepliedTimeouts, error) {
+			return rootNS, map[uint32]unrepliedTimeouts{
+				rootNS:  {udp: 30 * time.Second, tcp: 5 * time.Minute},
+				otherNS: {udp: 10 * time.Second, tcp: time.Minute},
+			}, nil
+		},
+	}
+	rt.cache.timeouts.refresh()
+
+	ipGen := randomIPGen()
+	// the namespace of the streamed events is unknown, so the timeouts of the root namespace apply
+	rt.register(makeTranslatedConn(ipGen(), ipGen(), ipGen(), unix.IPPROTO_UDP, 12345, 53, 53))
+	require.Equal(t, int64(0), rt.cache.removeOrphans(time.Now().Add(20*time.Second)))
+	require.Equal(t, int64(2), rt.cache.removeOrphans(time.Now().Add(40*time.Second)))
+
+	c := makeTranslatedConn(ipGen(), ipGen(), ipGen(), unix.IPPROTO_UDP, 12345, 53, 53)
+	c.NetNS = otherNS
+	rt.register(c)
+	require.Equal(t, int64(2), rt.cache.removeOrphans(time.Now().Add(20*time.Second)))
+
+	// the kernel timeouts only apply when they're shorter than the orphan timeout
+	rt.register(makeTranslatedConn(ipGen(), ipGen(), ipGen(), unix.IPPROTO_TCP, 12345, 80, 80))
+	require.Equal(t, int64(0), rt.cache.removeOrphans(time.Now().Add(time.Minute)))
+	require.Equal(t, int64(2), rt.cache.removeOrphans(time.Now().Add(rt.cache.orphanTimeout).Add(time.Second)))
+
+	c = makeTranslatedConn(ipGen(), ipGen(), ipGen(), unix.IPPROTO_TCP, 12345, 80, 80)
+	c.NetNS = otherNS
+	rt.register(c)
+	require.Equal(t, int64(2), rt.cache.removeOrphans(time.Now().Add(time.Minute).Add(time.Second)))
+}
+
 func crossCheckCacheOrphans(t *testing.T, cc *conntrackCache) {
 	for l := cc.orphans.Front(); l != nil; l = l.Next() {
 		o := l.Value.(*orphanEntry)
@@ -33,10 +33,18 @@ const (
 	// http://man7.org/linux/man-pages/man7/netlink.7.html
 	netlinkCtNew = uint32(1)
 
+	// netlinkCtDestroy represents the Netlink multicast group associated to the Conntrack family
+	// representing destroyed connection events, whether they were closed or timed out.
+	netlinkCtDestroy = uint32(3)
+
 	// ipctnlMsgCtGet represents the Conntrack message type used during the initial load.
 	// This value is defined in include/uapi/linux/netfilter/nfnetlink_conntrack.h
 	ipctnlMsgCtGet = 1
 
+	// ipctnlMsgCtDelete represents the Conntrack message type of the destroyed connection events.
+	// This value is defined in include/uapi/linux/netfilter/nfnetlink_conntrack.h
+	ipctnlMsgCtDelete = 2
+
 	// outputBuffer is he size of the Consumer output channel.
 	outputBuffer = 200
 
@@ -82,8 +90,15 @@ type Consumer struct {
 	// streaming is set to true after we finish the initial Conntrack dump.
 	streaming bool
 
+	// dumpRateLimit is the maximum number of entries per second read during the initial Conntrack dump.
+	// A value of 0 disables the limit.
+	dumpRateLimit int
+	// dump paces the reads of the Conntrack dump in progress
+	dump *dumpThrottler
+
 	netlinkSeqNumber    uint32
 	listenAllNamespaces bool
+	destroyEvents       bool
 
 	// for testing purposes
 	recvLoopRunning *atomic.Bool
@@ -113,17 +128,23 @@ func (e *Event) Done() {
 
 // Telemetry
 var consumerTelemetry = struct {
-	enobufs     telemetry.Counter
-	throttles   telemetry.Counter
-	samplingPct telemetry.Gauge
-	readErrors  telemetry.Counter
-	msgErrors   telemetry.Counter
+	enobufs       telemetry.Counter
+	throttles     telemetry.Counter
+	samplingPct   telemetry.Gauge
+	readErrors    telemetry.Counter
+	msgErrors     telemetry.Counter
+	dumpEntries   telemetry.Counter
+	dumpThrottles telemetry.Counter
+	sampledOut    telemetry.Counter
 }{
 	telemetry.NewCounter(telemetryModuleName, "enobufs", []string{}, "Counter measuring the number of consumer enobufs"),
 	telemetry.NewCounter(telemetryModuleName, "throttles", []string{}, "Counter measuring the number of consumer throttles"),
 	telemetry.NewGauge(telemetryModuleName, "sampling_pct", []string{}, "Gauge measuring the percent of events sampled by the consumer"),
 	telemetry.NewCounter(telemetryModuleName, "read_errors", []string{}, "Counter measuring the number of consumer read errors"),
 	telemetry.NewCounter(telemetryModuleName, "msg_errors", []string{}, "Counter measuring the number of consumer message errors"),
+	telemetry.NewCounter(telemetryModuleName, "dump_entries", []string{}, "Counter measuring the number of entries read during the initial conntrack dumps"),
+	telemetry.NewCounter(telemetryModuleName, "dump_throttles", []string{}, "Counter measuring the number of times the initial conntrack dumps were paused to stay under the rate limit"),
+	telemetry.NewCounter(telemetryModuleName, "sampled_out", []string{}, "Counter estimating the number of events dropped by the netlink BPF sampler"),
 }
 
 // NewConsumer creates a new Conntrack event consumer.
@@ -139,8 +160,10 @@ func NewConsumer(cfg *config.Config) (*Consumer, error) {
 		pool:                newBufferPool(),
 		targetRateLimit:     cfg.ConntrackRateLimit,
 		breaker:             NewCircuitBreaker(int64(cfg.ConntrackRateLimit), cfg.ConntrackRateLimitInterval),
+		dumpRateLimit:       cfg.ConntrackDumpRateLimit,
 		netlinkSeqNumber:    1,
 		listenAllNamespaces: cfg.EnableConntrackAllNamespaces,
+		destroyEvents:       cfg.EnableConntrackDestroyEvents,
 		recvLoopRunning:     atomic.NewBool(false),
 		rootNetNs:           ns,
 	}
@@ -149,7 +172,8 @@ func NewConsumer(cfg *config.Config) (*Consumer, error) {
 }
 
 // Events returns a channel of Event objects (wrapping netlink messages) which receives
-// all new connections added to the Conntrack table.
+// all new connections added to the Conntrack table, as well as the ones removed from it
+// if destroy events are enabled.
 func (c *Consumer) Events() (<-chan Event, error) {
 	if err := c.initNetlinkSocket(1.0); err != nil {
 		return nil, fmt.Errorf("could not initialize conntrack netlink socket: %w", err)
@@ -164,7 +188,7 @@ func (c *Consumer) Events() (<-chan Event, error) {
 		}()
 
 		c.streaming = true
-		_ = c.conn.JoinGroup(netlinkCtNew)
+		_ = c.joinGroups()
 		c.receive(output, 0)
 	}()
 
@@ -332,7 +356,9 @@ func (c *Consumer) dumpTable(family uint8, output chan Event, ns netns.NsHandle)
 		}
 
 		c.socket = sock
+		c.dump = newDumpThrottler(c.dumpRateLimit)
 		c.receive(output, nsIno)
+		c.dump.done(ns)
 		return nil
 	})
 }
@@ -466,7 +492,9 @@ func (c *Consumer) dumpAndDiscardTable(family uint8, ns netns.NsHandle) error {
 		}
 
 		c.socket = sock
+		c.dump = newDumpThrottler(c.dumpRateLimit)
 		c.receiveAndDiscard()
+		c.dump.done(ns)
 		return nil
 	})
 }
@@ -566,6 +594,11 @@ ReadLoop:
 			log.Errorf("exiting conntrack netlink consumer loop due to throttling error: %s", err)
 			return
 		}
+		if !c.streaming {
+			c.dump.throttle(len(msgs))
+		} else {
+			consumerTelemetry.sampledOut.Add(sampledOut(len(msgs), c.samplingRate))
+		}
 
 		// Messages with error codes are simply skipped
 		for _, m := range msgs {
@@ -590,10 +623,20 @@ ReadLoop:
 	}
 }
 
+// sampledOut estimates the number of events dropped by the BPF sampler of the socket, from the
+// number of events which went through it
+func sampledOut(received int, samplingRate float64) float64 {
+	if samplingRate <= 0 || samplingRate >= 1 {
+		return 0
+	}
+	return float64(received) * (1/samplingRate - 1)
+}
+
 // receive netlink messages and discard them immediately
 func (c *Consumer) receiveAndDiscard() {
 	for {
-		done, _, err := c.socket.ReceiveAndDiscard()
+		done, nmsgs, err := c.socket.ReceiveAndDiscardChunk()
+		c.dump.throttle(int(nmsgs))
 		if err != nil {
 			log.Tracef("consumer netlink socket error: %s", err)
 			switch socketError(err) {
@@ -659,8 +702,19 @@ func (c *Consumer) throttle(numMessages int) error {
 
 	// Reset circuit breaker
 	c.breaker.Reset()
-	// Re-subscribe netlinkCtNew messages
-	return c.conn.JoinGroup(netlinkCtNew)
+	// Re-subscribe to the Conntrack events
+	return c.joinGroups()
+}
+
+// joinGroups subscribes the socket to the Conntrack events
+func (c *Consumer) joinGroups() error {
+	if err := c.conn.JoinGroup(netlinkCtNew); err != nil {
+		return err
+	}
+	if c.destroyEvents {
+		return c.conn.JoinGroup(netlinkCtDestroy)
+	}
+	return nil
 }
 
 func newBufferPool() *sync.Pool {
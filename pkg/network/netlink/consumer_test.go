@@ -90,3 +90,10 @@ func TestConsumerKeepsRunningAfterCircuitBreakerTrip(t *testing.T) {
 		require.True(t, isRecvLoopRunning())
 	}
 }
+
+func TestSampledOut(t *testing.T) {
+	assert.Zero(t, sampledOut(100, 1))
+	assert.Zero(t, sampledOut(100, 0))
+	assert.InDelta(t, 100, sampledOut(100, 0.5), 0.001)
+	assert.InDelta(t, 300, sampledOut(100, 0.25), 0.001)
+}
@@ -57,6 +57,8 @@ type Con struct {
 	Origin ConTuple
 	Reply  ConTuple
 	NetNS  uint32
+	// Destroyed is set when the entry was removed from the Conntrack table
+	Destroyed bool
 }
 
 func (c Con) String() string {
@@ -82,7 +84,7 @@ func (d *Decoder) DecodeAndReleaseEvent(e Event) []Con {
 	conns := make([]Con, 0, len(msgs))
 
 	for _, msg := range msgs {
-		c := &Con{NetNS: e.netns}
+		c := &Con{NetNS: e.netns, Destroyed: msg.Header.Type&0xff == ipctnlMsgCtDelete}
 		if err := d.scanner.ResetTo(msg.Data); err != nil {
 			log.Debugf("error decoding netlink message: %s", err)
 			continue
@@ -17,6 +17,8 @@ import (
 
 	"github.com/mdlayher/netlink"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
 )
 
 func TestDecodeAndReleaseEvent(t *testing.T) {
@@ -47,6 +49,13 @@ func TestDecodeAndReleaseEvent(t *testing.T) {
 	assert.Equal(t, uint16(5432), c.Reply.Src.Port())
 	assert.Equal(t, uint16(58472), c.Reply.Dst.Port())
 	assert.Equal(t, uint8(6), c.Reply.Proto)
+	assert.False(t, c.Destroyed)
+
+	e.msgs[0].Header.Type = netlink.HeaderType((unix.NFNL_SUBSYS_CTNETLINK << 8) | ipctnlMsgCtDelete)
+	connections = decoder.DecodeAndReleaseEvent(e)
+	require.Len(t, connections, 1)
+	assert.True(t, connections[0].Destroyed)
+	assert.Equal(t, c.Origin, connections[0].Origin)
 }
 
 func BenchmarkDecodeSingleMessage(b *testing.B) {
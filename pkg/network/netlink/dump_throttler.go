@@ -0,0 +1,65 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+package netlink
+
+import (
+	"time"
+
+	"github.com/vishvananda/netns"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// dumpThrottler paces the reads of a Conntrack table dump. The kernel fills the next part of a
+// multi-part dump only once the previous one is read, so pacing the reads also paces the work
+// done by the kernel to walk the table.
+type dumpThrottler struct {
+	rateLimit int
+	entries   int
+	start     time.Time
+
+	// sleep and now are overridden in tests
+	sleep func(time.Duration)
+	now   func() time.Time
+}
+
+func newDumpThrottler(rateLimit int) *dumpThrottler {
+	return &dumpThrottler{
+		rateLimit: rateLimit,
+		start:     time.Now(),
+		sleep:     time.Sleep,
+		now:       time.Now,
+	}
+}
+
+// throttle records that n entries were read, and sleeps as long as needed to keep the dump under the rate limit
+func (d *dumpThrottler) throttle(n int) {
+	if d == nil || n <= 0 {
+		return
+	}
+
+	d.entries += n
+	consumerTelemetry.dumpEntries.Add(float64(n))
+	if d.rateLimit <= 0 {
+		return
+	}
+
+	expected := time.Duration(d.entries) * time.Second / time.Duration(d.rateLimit)
+	if ahead := expected - d.now().Sub(d.start); ahead > 0 {
+		consumerTelemetry.dumpThrottles.Inc()
+		d.sleep(ahead)
+	}
+}
+
+// done logs the progress of the dump once it is complete
+func (d *dumpThrottler) done(ns netns.NsHandle) {
+	if d == nil {
+		return
+	}
+	log.Debugf("dumped %d conntrack entries from namespace %s in %s", d.entries, ns, d.now().Sub(d.start))
+}
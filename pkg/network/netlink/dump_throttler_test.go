@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+package netlink
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpThrottler(t *testing.T) {
+	now := time.Now()
+	var slept time.Duration
+	newThrottler := func(rateLimit int) *dumpThrottler {
+		d := newDumpThrottler(rateLimit)
+		d.start = now
+		d.now = func() time.Time { return now }
+		d.sleep = func(duration time.Duration) {
+			slept += duration
+			now = now.Add(duration)
+		}
+		return d
+	}
+
+	t.Run("no limit", func(t *testing.T) {
+		slept = 0
+		d := newThrottler(0)
+		d.throttle(10000)
+		assert.Equal(t, 10000, d.entries)
+		assert.Zero(t, slept)
+	})
+
+	t.Run("under the limit", func(t *testing.T) {
+		slept = 0
+		d := newThrottler(100)
+		now = now.Add(time.Second)
+		d.throttle(50)
+		assert.Zero(t, slept)
+	})
+
+	t.Run("over the limit", func(t *testing.T) {
+		slept = 0
+		d := newThrottler(100)
+		d.throttle(50)
+		assert.Equal(t, 500*time.Millisecond, slept)
+		d.throttle(100)
+		assert.Equal(t, 1500*time.Millisecond, slept)
+		assert.Equal(t, 150, d.entries)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		var d *dumpThrottler
+		d.throttle(10)
+		d.done(0)
+	})
+}
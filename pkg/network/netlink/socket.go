@@ -163,45 +163,52 @@ func (s *Socket) Receive() ([]netlink.Message, error) {
 //nolint:revive // TODO(NET) Fix revive linter
 func (s *Socket) ReceiveAndDiscard() (bool, uint32, error) {
 	for {
-		n, _, err := s.recvmsg()
-		if err != nil {
-			return false, 0, os.NewSyscallError("recvmsg", err)
+		done, nmsgs, err := s.ReceiveAndDiscardChunk()
+		if err != nil || done {
+			return done, nmsgs, err
+		}
+	}
+}
+
+// ReceiveAndDiscardChunk reads the netlink messages of a single recvmsg call off the socket & discards them.
+// It returns true once the end of the multi-part message is read, along with the number of messages read.
+func (s *Socket) ReceiveAndDiscardChunk() (bool, uint32, error) {
+	n, _, err := s.recvmsg()
+	if err != nil {
+		return false, 0, os.NewSyscallError("recvmsg", err)
+	}
+
+	n = nlmsgAlign(n)
+	i := 0
+	nmsgs := uint32(0)
+	var multi bool
+	for n >= unix.NLMSG_HDRLEN {
+		header := (*netlink.Header)(unsafe.Pointer(&s.recvbuf[i]))
+		msgLen := nlmsgAlign(int(header.Length))
+		if msgLen < syscall.NLMSG_HDRLEN {
+			return false, 0, syscall.EINVAL
 		}
 
-		n = nlmsgAlign(n)
-		i := 0
-		nmsgs := uint32(0)
-		var multi bool
-		for n >= unix.NLMSG_HDRLEN {
-			header := (*netlink.Header)(unsafe.Pointer(&s.recvbuf[i]))
-			msgLen := nlmsgAlign(int(header.Length))
-			if msgLen < syscall.NLMSG_HDRLEN {
-				return false, 0, syscall.EINVAL
-			}
-
-			if err := checkMessage(netlink.Message{
-				Header: *header,
-				Data:   s.recvbuf[i+unix.NLMSG_HDRLEN : i+unix.NLMSG_HDRLEN+msgLen],
-			}); err != nil {
-				return false, 0, err
-			}
-
-			n -= msgLen
-			i += msgLen
-
-			nmsgs++
-
-			if header.Flags&netlink.Multi == 0 {
-				continue
-			}
-
-			multi = header.Type != netlink.Done
+		if err := checkMessage(netlink.Message{
+			Header: *header,
+			Data:   s.recvbuf[i+unix.NLMSG_HDRLEN : i+unix.NLMSG_HDRLEN+msgLen],
+		}); err != nil {
+			return false, 0, err
 		}
 
-		if !multi {
-			return true, nmsgs, nil
+		n -= msgLen
+		i += msgLen
+
+		nmsgs++
+
+		if header.Flags&netlink.Multi == 0 {
+			continue
 		}
+
+		multi = header.Type != netlink.Done
 	}
+
+	return !multi, nmsgs, nil
 }
 
 // ReceiveInto reads one or more netlink.Messages off the socket
@@ -11,13 +11,24 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 var wildcard = netip.Prefix{}
 
+var filterTelemetry = struct {
+	ignoredConns telemetry.Counter
+}{
+	telemetry.NewCounter("network_tracer__filter", "ignored_conns", []string{"rule"}, "Counter measuring the closed connections ignored in userspace by each rule of network_config.ignore_conns"),
+}
+
 // ConnectionFilter holds a user-defined excluded IP/CIDR, and ports
 type ConnectionFilter struct {
+	// Rule is the rule of network_config.ignore_conns the filter was parsed from, empty for the
+	// source and destination excludes
+	Rule string
+
 	IP       netip.Prefix // zero-value matches all IPs
 	AllPorts ConnTypeFilter
 
@@ -26,8 +37,31 @@ type ConnectionFilter struct {
 
 // ConnTypeFilter holds user-defined protocols
 type ConnTypeFilter struct {
-	TCP bool
-	UDP bool
+	TCP  bool
+	UDP  bool
+	SCTP bool
+}
+
+// Matches returns whether the filter matches the given connection type
+func (f ConnTypeFilter) Matches(t ConnectionType) bool {
+	switch t {
+	case TCP:
+		return f.TCP
+	case UDP:
+		return f.UDP
+	case SCTP:
+		return f.SCTP
+	}
+	return false
+}
+
+// Any returns whether the filter matches any connection type
+func (f ConnTypeFilter) Any() bool {
+	return f.TCP || f.UDP || f.SCTP
+}
+
+func (f ConnTypeFilter) or(other ConnTypeFilter) ConnTypeFilter {
+	return ConnTypeFilter{TCP: f.TCP || other.TCP, UDP: f.UDP || other.UDP, SCTP: f.SCTP || other.SCTP}
 }
 
 // ParseConnectionFilters takes the user defined excludelist and returns a slice of ConnectionFilters
@@ -75,15 +109,9 @@ func ParseConnectionFilters(filters map[string][]string) (excludelist []*Connect
 
 				// There can be multiple wildcard port filters.
 				// Since we can do something like "udp *", "*", we want to widen the scope as much as possible.
-				filter.AllPorts.UDP = filter.AllPorts.UDP || transportFilter.UDP
-				filter.AllPorts.TCP = filter.AllPorts.TCP || transportFilter.TCP
+				filter.AllPorts = filter.AllPorts.or(transportFilter)
 			} else { // Otherwise the port filter for this address range is an integer range.
-				for port := lowerPort; port <= upperPort; port++ {
-					filter.Ports[uint16(port)] = ConnTypeFilter{
-						TCP: transportFilter.TCP || filter.Ports[uint16(port)].TCP,
-						UDP: transportFilter.UDP || filter.Ports[uint16(port)].UDP,
-					}
-				}
+				filter.addPorts(lowerPort, upperPort, transportFilter)
 			}
 		}
 
@@ -99,7 +127,7 @@ func ParseConnectionFilters(filters map[string][]string) (excludelist []*Connect
 // and returns a port/port range, protocol, and the validity of those values
 func parsePortFilter(pf string) (uint64, uint64, ConnTypeFilter, error) {
 	lowerPort, upperPort := uint64(0), uint64(0)
-	connTypeFilter := ConnTypeFilter{TCP: true, UDP: true}
+	connTypeFilter := ConnTypeFilter{TCP: true, UDP: true, SCTP: true}
 	var err error
 
 	pf = strings.ToUpper(pf)
@@ -107,11 +135,14 @@ func parsePortFilter(pf string) (uint64, uint64, ConnTypeFilter, error) {
 	// Check if this port range depends on a particular transport type
 	switch {
 	case strings.HasPrefix(pf, "TCP"):
-		connTypeFilter.UDP = false
+		connTypeFilter = ConnTypeFilter{TCP: true}
 		pf = strings.TrimPrefix(pf, "TCP")
 	case strings.HasPrefix(pf, "UDP"):
-		connTypeFilter.TCP = false
+		connTypeFilter = ConnTypeFilter{UDP: true}
 		pf = strings.TrimPrefix(pf, "UDP")
+	case strings.HasPrefix(pf, "SCTP"):
+		connTypeFilter = ConnTypeFilter{SCTP: true}
+		pf = strings.TrimPrefix(pf, "SCTP")
 	}
 
 	pf = strings.TrimSpace(pf)
@@ -154,47 +185,146 @@ func parsePortString(port string) (uint64, error) {
 	return p, nil
 }
 
+func (f *ConnectionFilter) addPorts(lowerPort, upperPort uint64, types ConnTypeFilter) {
+	for port := lowerPort; port <= upperPort; port++ {
+		f.Ports[uint16(port)] = f.Ports[uint16(port)].or(types)
+	}
+}
+
+// CountIgnored counts a connection ignored because of the filter, when it was parsed from a rule
+// of network_config.ignore_conns. The active connections being filtered again with each check,
+// the connections are meant to be counted once they are closed.
+func (f *ConnectionFilter) CountIgnored() {
+	if f.Rule != "" {
+		filterTelemetry.ignoredConns.Inc(f.Rule)
+	}
+}
+
 // IsExcludedConnection returns true if a given connection should be excluded
 // by the tracer based on user defined filters
 func IsExcludedConnection(scf []*ConnectionFilter, dcf []*ConnectionFilter, conn *ConnectionStats) bool {
-	// No filters so short-circuit
-	if len(scf) == 0 && len(dcf) == 0 {
-		return false
+	return MatchingConnectionFilter(scf, dcf, conn) != nil
+}
+
+// MatchingConnectionFilter returns the first of the source filters matching the source of the
+// connection, or else of the destination filters matching its destination, or nil
+func MatchingConnectionFilter(scf []*ConnectionFilter, dcf []*ConnectionFilter, conn *ConnectionStats) *ConnectionFilter {
+	if f := findMatchingFilter(scf, conn.Source.Addr, conn.SPort, conn.Type); f != nil {
+		return f
 	}
+	return findMatchingFilter(dcf, conn.Dest.Addr, conn.DPort, conn.Type)
+}
 
-	if len(scf) > 0 {
-		if findMatchingFilter(scf, conn.Source.Addr, conn.SPort, conn.Type) {
-			return true
+// findMatchingFilter iterates through filters to see if this connection matches any defined filter
+func findMatchingFilter(cf []*ConnectionFilter, ip netip.Addr, addrPort uint16, addrType ConnectionType) *ConnectionFilter {
+	for _, filter := range cf {
+		if filter.IP == wildcard || filter.IP.Contains(ip.Unmap()) {
+			if filter.AllPorts.Matches(addrType) || filter.Ports[addrPort].Matches(addrType) {
+				return filter
+			}
 		}
 	}
-	if len(dcf) > 0 {
-		if findMatchingFilter(dcf, conn.Dest.Addr, conn.DPort, conn.Type) {
-			return true
+	return nil
+}
+
+// ParseIgnoreRules parses the rules of network_config.ignore_conns, such as "10.0.0.0/8:9000" or
+// "udp://*:123", into filters matching the connections with either endpoint within the IP range and
+// the port range of the rule, to be added to both the source and the destination excludes. Invalid
+// rules are logged and skipped.
+func ParseIgnoreRules(rules []string) []*ConnectionFilter {
+	var parsed []*ConnectionFilter
+	for _, r := range rules {
+		filter, err := parseIgnoreRule(r)
+		if err != nil {
+			log.Errorf("connection ignore rule %q will not be respected: %s", r, err)
+			continue
 		}
+		parsed = append(parsed, filter)
 	}
-	return false
+	return parsed
 }
 
-// findMatchingFilter iterates through filters to see if this connection matches any defined filter
-func findMatchingFilter(cf []*ConnectionFilter, ip netip.Addr, addrPort uint16, addrType ConnectionType) bool {
-	for _, filter := range cf {
-		if filter.IP == wildcard || filter.IP.Contains(ip) {
-			if filter.AllPorts.TCP && filter.AllPorts.UDP { // Wildcard port range case
-				return true
-			} else if filter.AllPorts.TCP && addrType == TCP { // Wildcard port range for only TCP
-				return true
-			} else if filter.AllPorts.UDP && addrType == UDP { // Wildcard port range for only UDP
-				return true
-			} else if _, ok := filter.Ports[addrPort]; ok {
-				if filter.Ports[addrPort].TCP && filter.Ports[addrPort].UDP {
-					return true
-				} else if filter.Ports[addrPort].TCP && addrType == TCP {
-					return true
-				} else if filter.Ports[addrPort].UDP && addrType == UDP {
-					return true
-				}
+func parseIgnoreRule(r string) (*ConnectionFilter, error) {
+	filter := &ConnectionFilter{Rule: r, Ports: map[uint16]ConnTypeFilter{}}
+	types := ConnTypeFilter{TCP: true, UDP: true, SCTP: true}
+
+	s := strings.ToLower(strings.TrimSpace(r))
+	if scheme, rest, ok := strings.Cut(s, "://"); ok {
+		switch scheme {
+		case "tcp":
+			types = ConnTypeFilter{TCP: true}
+		case "udp":
+			types = ConnTypeFilter{UDP: true}
+		case "sctp":
+			types = ConnTypeFilter{SCTP: true}
+		default:
+			return nil, fmt.Errorf("unknown protocol %q", scheme)
+		}
+		s = rest
+	}
+
+	host, port := s, "*"
+	if strings.HasPrefix(s, "[") {
+		end := strings.IndexRune(s, ']')
+		if end < 0 {
+			return nil, fmt.Errorf("missing closing bracket")
+		}
+		host = s[1:end]
+		if rest := s[end+1:]; rest != "" {
+			if !strings.HasPrefix(rest, ":") {
+				return nil, fmt.Errorf("unexpected %q after the address", rest)
 			}
+			port = rest[1:]
+		}
+	} else if i := strings.LastIndexByte(s, ':'); i >= 0 {
+		host, port = s[:i], s[i+1:]
+		if strings.ContainsRune(host, ':') {
+			return nil, fmt.Errorf("IPv6 addresses must be enclosed in brackets")
 		}
 	}
-	return false
+
+	var err error
+	switch {
+	case host == "*":
+		filter.IP = wildcard
+	case strings.ContainsRune(host, '/'):
+		if filter.IP, err = netip.ParsePrefix(host); err != nil {
+			return nil, err
+		}
+		filter.IP = filter.IP.Masked()
+	default:
+		addr, err := netip.ParseAddr(host)
+		if err != nil {
+			return nil, err
+		}
+		filter.IP = netip.PrefixFrom(addr, addr.BitLen())
+	}
+
+	if port == "*" {
+		if filter.IP == wildcard {
+			return nil, fmt.Errorf("a rule with * as both address and port would ignore all connections")
+		}
+		filter.AllPorts = types
+		return filter, nil
+	}
+
+	low, high, ok := strings.Cut(port, "-")
+	if !ok {
+		high = low
+	}
+	lowPort, err := parsePortString(low)
+	if err != nil {
+		return nil, err
+	}
+	highPort, err := parsePortString(high)
+	if err != nil {
+		return nil, err
+	}
+	if lowPort == 0 || highPort == 0 {
+		return nil, fmt.Errorf("invalid port 0")
+	} else if lowPort > highPort {
+		return nil, fmt.Errorf("invalid port range %d-%d", lowPort, highPort)
+	}
+	filter.addPorts(lowPort, highPort, types)
+	return filter, nil
 }
@@ -7,9 +7,11 @@ package network
 
 import (
 	"math/rand"
+	"net/netip"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 )
@@ -133,3 +135,65 @@ func randIPv6(count int) (addrs []util.Address) {
 	}
 	return addrs
 }
+
+func TestParseIgnoreRules(t *testing.T) {
+	rules := ParseIgnoreRules([]string{
+		"10.0.0.0/8:9000",
+		"udp://*:123",
+		"TCP://[2001:db8::/32]:8000-8080",
+		"192.168.1.1",
+		"sctp://*:9",
+		"*:*",             // invalid, would ignore everything
+		"quic://*:9",      // invalid protocol
+		"2001:db8::1:443", // invalid, missing brackets
+		"10.0.0.1:0",      // invalid port
+		"10.0.0.1:90-80",  // invalid port range
+	})
+	require.Len(t, rules, 5)
+
+	assert.Equal(t, "10.0.0.0/8:9000", rules[0].Rule)
+	assert.Equal(t, netip.MustParsePrefix("10.0.0.0/8"), rules[0].IP)
+	assert.Equal(t, map[uint16]ConnTypeFilter{9000: {TCP: true, UDP: true, SCTP: true}}, rules[0].Ports)
+
+	assert.Equal(t, wildcard, rules[1].IP)
+	assert.Equal(t, map[uint16]ConnTypeFilter{123: {UDP: true}}, rules[1].Ports)
+
+	assert.Equal(t, netip.MustParsePrefix("2001:db8::/32"), rules[2].IP)
+	assert.Len(t, rules[2].Ports, 81)
+	assert.Equal(t, ConnTypeFilter{TCP: true}, rules[2].Ports[8080])
+
+	assert.Equal(t, netip.MustParsePrefix("192.168.1.1/32"), rules[3].IP)
+	assert.Equal(t, ConnTypeFilter{TCP: true, UDP: true, SCTP: true}, rules[3].AllPorts)
+	assert.Empty(t, rules[3].Ports)
+
+	assert.Equal(t, map[uint16]ConnTypeFilter{9: {SCTP: true}}, rules[4].Ports)
+}
+
+func TestIgnoreRulesExcludeConnection(t *testing.T) {
+	rules := ParseIgnoreRules([]string{"10.0.0.0/8:9000", "udp://*:123", "tcp://[2001:db8::/32]:8000-8080", "sctp://*:2905"})
+	// the rules match either endpoint, so they are both source and destination filters
+	sourceList := append(ParseConnectionFilters(map[string][]string{"172.0.0.1": {"80"}}), rules...)
+	destList := rules
+
+	for _, tc := range []struct {
+		conn    ConnectionStats
+		ignored bool
+	}{
+		{ConnectionStats{Source: util.AddressFromString("192.168.0.1"), Dest: util.AddressFromString("10.1.2.3"), SPort: 40000, DPort: 9000, Type: TCP}, true},
+		{ConnectionStats{Source: util.AddressFromString("10.1.2.3"), Dest: util.AddressFromString("192.168.0.1"), SPort: 9000, DPort: 40000, Type: UDP}, true},
+		{ConnectionStats{Source: util.AddressFromString("192.168.0.1"), Dest: util.AddressFromString("10.1.2.3"), SPort: 40000, DPort: 9001, Type: TCP}, false},
+		{ConnectionStats{Source: util.AddressFromString("192.168.0.1"), Dest: util.AddressFromString("192.168.0.2"), SPort: 40000, DPort: 123, Type: UDP}, true},
+		{ConnectionStats{Source: util.AddressFromString("192.168.0.1"), Dest: util.AddressFromString("192.168.0.2"), SPort: 40000, DPort: 123, Type: TCP}, false},
+		{ConnectionStats{Source: util.AddressFromString("2001:db9::1"), Dest: util.AddressFromString("2001:db8::2"), SPort: 40000, DPort: 8080, Type: TCP}, true},
+		{ConnectionStats{Source: util.AddressFromString("2001:db9::1"), Dest: util.AddressFromString("2001:db8::2"), SPort: 40000, DPort: 8081, Type: TCP}, false},
+		{ConnectionStats{Source: util.AddressFromString("192.168.0.1"), Dest: util.AddressFromString("192.168.0.2"), SPort: 40000, DPort: 2905, Type: SCTP}, true},
+		{ConnectionStats{Source: util.AddressFromString("10.1.2.3"), Dest: util.AddressFromString("192.168.0.2"), SPort: 9000, DPort: 40000, Type: SCTP}, true},
+		{ConnectionStats{Source: util.AddressFromString("172.0.0.1"), Dest: util.AddressFromString("192.168.0.2"), SPort: 80, DPort: 40000, Type: SCTP}, true},
+	} {
+		assert.Equal(t, tc.ignored, IsExcludedConnection(sourceList, destList, &tc.conn), tc.conn.String())
+	}
+
+	f := MatchingConnectionFilter(sourceList, destList, &ConnectionStats{Source: util.AddressFromString("192.168.0.1"), Dest: util.AddressFromString("192.168.0.2"), SPort: 40000, DPort: 123, Type: UDP})
+	require.NotNil(t, f)
+	assert.Equal(t, "udp://*:123", f.Rule)
+}
@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PacketDrops is the number of packets dropped by the kernel on an interface for a given reason
+type PacketDrops struct {
+	// Ifindex is the index of the interface the packets were received on or sent to,
+	// 0 if the packets were dropped before being attached to an interface
+	Ifindex uint32
+	// Reason is the value of the kernel's enum skb_drop_reason
+	Reason uint32
+	// ReasonName is the name of the drop reason, without the SKB_DROP_REASON_ prefix
+	ReasonName string
+	Count      uint64
+}
+
+var dropReasonRegexp = regexp.MustCompile(`\{\s*(\d+),\s*"(\w+)"\s*\}`)
+
+// ParseDropReasons reads the names of the drop reasons from the format of the
+// skb:kfree_skb tracepoint, as found in tracefs under events/skb/kfree_skb/format.
+// The reasons which aren't actual drops are left out.
+func ParseDropReasons(r io.Reader) (map[uint32]string, error) {
+	reasons := make(map[uint32]string)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "print fmt:") {
+			continue
+		}
+		for _, m := range dropReasonRegexp.FindAllStringSubmatch(line, -1) {
+			if m[2] == "NOT_DROPPED_YET" || m[2] == "CONSUMED" {
+				continue
+			}
+			reason, err := strconv.ParseUint(m[1], 10, 32)
+			if err != nil {
+				continue
+			}
+			reasons[uint32(reason)] = m[2]
+		}
+	}
+	return reasons, scanner.Err()
+}
@@ -0,0 +1,41 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const kfreeSkbFormat = `name: kfree_skb
+ID: 1575
+format:
+	field:unsigned short common_type;	offset:0;	size:2;	signed:0;
+	field:void * skbaddr;	offset:8;	size:8;	signed:0;
+	field:void * location;	offset:16;	size:8;	signed:0;
+	field:unsigned short protocol;	offset:24;	size:2;	signed:0;
+	field:enum skb_drop_reason reason;	offset:28;	size:4;	signed:0;
+
+print fmt: "skbaddr=%p protocol=%u location=%pS reason: %s", REC->skbaddr, REC->protocol, REC->location, __print_symbolic(REC->reason, { 1, "NOT_DROPPED_YET" }, { 2, "CONSUMED" }, { 3, "NOT_SPECIFIED" }, { 4, "NO_SOCKET" }, { 5, "PKT_TOO_SMALL" }, { 6, "TCP_CSUM" })
+`
+
+func TestParseDropReasons(t *testing.T) {
+	reasons, err := ParseDropReasons(strings.NewReader(kfreeSkbFormat))
+	require.NoError(t, err)
+	assert.Equal(t, map[uint32]string{
+		3: "NOT_SPECIFIED",
+		4: "NO_SOCKET",
+		5: "PKT_TOO_SMALL",
+		6: "TCP_CSUM",
+	}, reasons)
+
+	reasons, err = ParseDropReasons(strings.NewReader("name: kfree_skb\n"))
+	require.NoError(t, err)
+	assert.Empty(t, reasons)
+}
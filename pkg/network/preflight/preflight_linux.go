@@ -0,0 +1,160 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package preflight
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/asm"
+	"github.com/cilium/ebpf/btf"
+	"github.com/cilium/ebpf/features"
+	"github.com/cilium/ebpf/link"
+
+	ddebpf "github.com/DataDog/datadog-agent/pkg/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/ebpf/maps"
+	"github.com/DataDog/datadog-agent/pkg/util/funcs"
+	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+)
+
+var requiredProgramTypes = []ebpf.ProgramType{
+	ebpf.Kprobe,
+	ebpf.SocketFilter,
+}
+
+var requiredMapTypes = []ebpf.MapType{
+	ebpf.Hash,
+	ebpf.Array,
+	ebpf.PerCPUArray,
+	ebpf.PerfEventArray,
+	ebpf.ProgramArray,
+}
+
+var requiredHelpers = []asm.BuiltinFunc{
+	asm.FnMapLookupElem,
+	asm.FnMapUpdateElem,
+	asm.FnMapDeleteElem,
+	asm.FnPerfEventOutput,
+	asm.FnProbeRead,
+	asm.FnKtimeGetNs,
+	asm.FnGetCurrentPidTgid,
+	asm.FnTailCall,
+}
+
+var requiredProbePoints = []string{
+	"tcp_sendmsg",
+	"tcp_recvmsg",
+	"tcp_close",
+	"tcp_connect",
+	"tcp_finish_connect",
+	"tcp_retransmit_skb",
+	"inet_csk_accept",
+	"inet_csk_listen_stop",
+	"udp_sendmsg",
+	"udp_recvmsg",
+	"udp_destroy_sock",
+	"inet_bind",
+}
+
+// Run probes the running kernel and returns its capability report
+func Run() (*Report, error) {
+	kv, err := kernel.HostVersion()
+	if err != nil {
+		return nil, fmt.Errorf("could not get kernel version: %w", err)
+	}
+
+	r := &Report{
+		KernelVersion: kv.String(),
+		Lockdown:      string(kernel.GetLockdownMode()),
+		BTF:           checkBTF(),
+		Trampolines:   Check{Name: "trampolines", Supported: TrampolinesSupported()},
+		RingBuffers:   newCheck(ebpf.RingBuf.String(), features.HaveMapType(ebpf.RingBuf)),
+		BatchAPI:      Check{Name: "batch_api", Supported: maps.BatchAPISupported()},
+	}
+	for _, pt := range requiredProgramTypes {
+		r.ProgramTypes = append(r.ProgramTypes, newCheck(pt.String(), features.HaveProgramType(pt)))
+	}
+	for _, mt := range requiredMapTypes {
+		r.MapTypes = append(r.MapTypes, newCheck(mt.String(), features.HaveMapType(mt)))
+	}
+	for _, fn := range requiredHelpers {
+		r.Helpers = append(r.Helpers, newCheck(fn.String(), features.HaveProgramHelper(ebpf.Kprobe, fn)))
+	}
+	r.ProbePoints = checkProbePoints()
+
+	r.Tracer = r.selectTracer()
+	return r, nil
+}
+
+// TrampolinesSupported returns whether the kernel can attach fentry/fexit programs to the kernel functions
+var TrampolinesSupported = funcs.MemoizeNoError(func() bool {
+	if features.HaveProgramType(ebpf.Tracing) != nil {
+		return false
+	}
+
+	spec := &ebpf.ProgramSpec{
+		Type:       ebpf.Tracing,
+		AttachType: ebpf.AttachTraceFExit,
+		AttachTo:   "tcp_sendmsg",
+		License:    "GPL",
+		Instructions: asm.Instructions{
+			asm.LoadImm(asm.R0, 0, asm.DWord),
+			asm.Return(),
+		},
+	}
+	prog, err := ebpf.NewProgramWithOptions(spec, ebpf.ProgramOptions{
+		LogDisabled: true,
+	})
+	if err != nil {
+		return false
+	}
+	defer prog.Close()
+
+	l, err := link.AttachTracing(link.TracingOptions{
+		Program: prog,
+	})
+	if err != nil {
+		return false
+	}
+	defer l.Close()
+
+	return true
+})
+
+// newCheck returns the check of a feature from the error of its probe
+func newCheck(name string, err error) Check {
+	c := Check{Name: name, Supported: err == nil}
+	if err != nil && !errors.Is(err, ebpf.ErrNotSupported) {
+		c.Error = err.Error()
+	}
+	return c
+}
+
+// checkBTF checks that the kernel exposes its BTF. The BTF of the other kernels may still be found
+// in the embedded collection or downloaded, at the time the tracer is loaded.
+func checkBTF() Check {
+	_, err := btf.LoadKernelSpec()
+	return newCheck("btf", err)
+}
+
+func checkProbePoints() []Check {
+	missing, err := ddebpf.VerifyKernelFuncs(requiredProbePoints...)
+	checks := make([]Check, 0, len(requiredProbePoints))
+	for _, fn := range requiredProbePoints {
+		c := Check{Name: fn}
+		if err != nil {
+			c.Error = err.Error()
+		} else {
+			_, isMissing := missing[fn]
+			c.Supported = !isMissing
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
@@ -0,0 +1,20 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !linux_bpf
+
+package preflight
+
+import "github.com/DataDog/datadog-agent/pkg/ebpf"
+
+// Run is not supported without eBPF
+func Run() (*Report, error) {
+	return nil, ebpf.ErrNotImplemented
+}
+
+// TrampolinesSupported is not supported without eBPF
+func TrampolinesSupported() bool {
+	return false
+}
@@ -0,0 +1,72 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package preflight probes the running kernel for the features required by the network tracer
+package preflight
+
+// Tracer modes, from the most to the least capable
+const (
+	TracerFentry     = "fentry"
+	TracerKprobeCORE = "kprobe_co-re"
+	TracerKprobe     = "kprobe"
+	TracerSockDiag   = "sock_diag"
+)
+
+// Check is the result of the probe of a kernel feature
+type Check struct {
+	Name      string `json:"name"`
+	Supported bool   `json:"supported"`
+	// Error is the reason why the feature isn't supported, if it isn't the lack of support itself
+	Error string `json:"error,omitempty"`
+}
+
+// Report is the capability report of the running kernel
+type Report struct {
+	KernelVersion string `json:"kernel_version"`
+	// Lockdown is the lockdown mode of the kernel. The confidentiality mode prevents the probes from reading
+	// the kernel memory.
+	Lockdown string `json:"lockdown"`
+	// BTF is the availability of the BTF of the running kernel, required by the CO-RE and fentry tracers
+	BTF         Check `json:"btf"`
+	Trampolines Check `json:"trampolines"`
+	RingBuffers Check `json:"ring_buffers"`
+	BatchAPI    Check `json:"batch_api"`
+
+	// ProgramTypes, MapTypes, Helpers and ProbePoints are the features required by all the eBPF tracers
+	ProgramTypes []Check `json:"program_types"`
+	MapTypes     []Check `json:"map_types"`
+	Helpers      []Check `json:"helpers"`
+	ProbePoints  []Check `json:"probe_points"`
+
+	// Tracer is the most capable tracer mode supported by the kernel
+	Tracer string `json:"tracer"`
+}
+
+// Missing returns the names of the program types, map types, helpers and probe points which aren't supported
+func (r *Report) Missing() []string {
+	var missing []string
+	for _, checks := range [][]Check{r.ProgramTypes, r.MapTypes, r.Helpers, r.ProbePoints} {
+		for _, c := range checks {
+			if !c.Supported {
+				missing = append(missing, c.Name)
+			}
+		}
+	}
+	return missing
+}
+
+// selectTracer returns the most capable tracer mode supported according to the report
+func (r *Report) selectTracer() string {
+	if r.Lockdown == "confidentiality" || len(r.Missing()) > 0 {
+		return TracerSockDiag
+	}
+	if !r.BTF.Supported {
+		return TracerKprobe
+	}
+	if r.Trampolines.Supported {
+		return TracerFentry
+	}
+	return TracerKprobeCORE
+}
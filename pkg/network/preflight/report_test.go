@@ -0,0 +1,38 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package preflight
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectTracer(t *testing.T) {
+	r := Report{
+		Lockdown:     "none",
+		BTF:          Check{Name: "btf", Supported: true},
+		Trampolines:  Check{Name: "trampolines", Supported: true},
+		ProgramTypes: []Check{{Name: "Kprobe", Supported: true}},
+		ProbePoints:  []Check{{Name: "tcp_sendmsg", Supported: true}},
+	}
+	assert.Empty(t, r.Missing())
+	assert.Equal(t, TracerFentry, r.selectTracer())
+
+	r.Trampolines.Supported = false
+	assert.Equal(t, TracerKprobeCORE, r.selectTracer())
+
+	r.BTF.Supported = false
+	assert.Equal(t, TracerKprobe, r.selectTracer())
+
+	r.ProbePoints = append(r.ProbePoints, Check{Name: "tcp_close", Error: "error reading kallsyms"})
+	assert.Equal(t, []string{"tcp_close"}, r.Missing())
+	assert.Equal(t, TracerSockDiag, r.selectTracer())
+
+	r.ProbePoints = r.ProbePoints[:1]
+	r.Lockdown = "confidentiality"
+	assert.Equal(t, TracerSockDiag, r.selectTracer())
+}
@@ -0,0 +1,86 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package debugging provides debug-friendly representations of internal data structures
+package debugging
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/amqp"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// address represents represents a IP:Port
+type address struct {
+	IP   string
+	Port uint16
+}
+
+// key represents a (client, server, exchange, routing key) tuple.
+type key struct {
+	Client     address
+	Server     address
+	Exchange   string
+	RoutingKey string
+}
+
+// Stats consolidates the count and the body bytes of a method
+type Stats struct {
+	Count     int
+	BodyBytes uint64
+}
+
+// RequestSummary represents a (debug-friendly) aggregated view of requests
+// matching a (client, server, exchange, routing key, method) tuple
+type RequestSummary struct {
+	key
+	ByMethod map[string]Stats
+}
+
+// AMQP returns a debug-friendly representation of map[amqp.Key]amqp.RequestStats
+func AMQP(stats map[amqp.Key]*amqp.RequestStat) []RequestSummary {
+	resMap := make(map[key]map[string]Stats)
+	for k, requestStat := range stats {
+		clientAddr := formatIP(k.SrcIPLow, k.SrcIPHigh)
+		serverAddr := formatIP(k.DstIPLow, k.DstIPHigh)
+
+		tempKey := key{
+			Client: address{
+				IP:   clientAddr.String(),
+				Port: k.SrcPort,
+			},
+			Server: address{
+				IP:   serverAddr.String(),
+				Port: k.DstPort,
+			},
+			Exchange:   k.Exchange,
+			RoutingKey: k.RoutingKey,
+		}
+		if _, ok := resMap[tempKey]; !ok {
+			resMap[tempKey] = make(map[string]Stats)
+		}
+		currentStats := resMap[tempKey][k.Method.String()]
+		currentStats.Count += requestStat.Count
+		currentStats.BodyBytes += requestStat.BodyBytes
+		resMap[tempKey][k.Method.String()] = currentStats
+	}
+
+	all := make([]RequestSummary, 0, len(resMap))
+	for key, value := range resMap {
+		debug := RequestSummary{
+			key:      key,
+			ByMethod: value,
+		}
+		all = append(all, debug)
+	}
+	return all
+}
+
+func formatIP(low, high uint64) util.Address {
+	if high > 0 || (low>>32) > 0 {
+		return util.V6Address(low, high)
+	}
+
+	return util.V4Address(uint32(low))
+}
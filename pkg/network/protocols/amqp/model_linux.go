@@ -0,0 +1,137 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package amqp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+const (
+	// publishReservedSize is the size of the reserved short starting the arguments of basic.publish.
+	publishReservedSize = 2
+	// deliverTagSize is the size of the delivery tag and of the redelivered flag following the consumer tag in the
+	// arguments of basic.deliver.
+	deliverTagSize = 8 + 1
+)
+
+// EventWrapper wraps an ebpf event and provides additional methods to extract information from it.
+// We use this wrapper to avoid recomputing the same values (exchange and routing key) multiple times.
+type EventWrapper struct {
+	*EbpfEvent
+
+	parsed     bool
+	exchange   string
+	routingKey string
+}
+
+// NewEventWrapper creates a new EventWrapper from an ebpf event.
+func NewEventWrapper(e *EbpfEvent) *EventWrapper {
+	return &EventWrapper{EbpfEvent: e}
+}
+
+// ConnTuple returns the connection tuple for the transaction
+func (e *EventWrapper) ConnTuple() types.ConnectionKey {
+	return types.ConnectionKey{
+		SrcIPHigh: e.Tuple.Saddr_h,
+		SrcIPLow:  e.Tuple.Saddr_l,
+		DstIPHigh: e.Tuple.Daddr_h,
+		DstIPLow:  e.Tuple.Daddr_l,
+		SrcPort:   e.Tuple.Sport,
+		DstPort:   e.Tuple.Dport,
+	}
+}
+
+// Method returns the method of the basic class (publish, deliver, ack, etc.)
+func (e *EventWrapper) Method() Method {
+	switch m := Method(e.Tx.Method_id); m {
+	case PublishMethod, DeliverMethod, AckMethod, RejectMethod, NackMethod:
+		return m
+	default:
+		return UnknownMethod
+	}
+}
+
+// readShortString reads a short string, made of its length on one octet followed by its content, from the beginning
+// of the buffer. It returns the string and the rest of the buffer. The strings longer than the buffer are truncated.
+func readShortString(buf []byte) (string, []byte) {
+	if len(buf) == 0 {
+		return "", nil
+	}
+	length := int(buf[0])
+	buf = buf[1:]
+	if length > len(buf) {
+		return string(buf), nil
+	}
+	return string(buf[:length]), buf[length:]
+}
+
+// skip returns the buffer without its first n bytes.
+func skip(buf []byte, n int) []byte {
+	if n > len(buf) {
+		return nil
+	}
+	return buf[n:]
+}
+
+// parse extracts the exchange and the routing key from the arguments of basic.publish and basic.deliver.
+// https://www.rabbitmq.com/resources/specs/amqp0-9-1.pdf, section 1.8.3
+func (e *EventWrapper) parse() {
+	e.parsed = true
+	args := e.Tx.Arguments[:]
+	switch e.Method() {
+	case PublishMethod:
+		args = skip(args, publishReservedSize)
+	case DeliverMethod:
+		// Skipping the consumer tag.
+		_, args = readShortString(args)
+		args = skip(args, deliverTagSize)
+	default:
+		return
+	}
+	e.exchange, args = readShortString(args)
+	e.routingKey, _ = readShortString(args)
+}
+
+// Exchange returns the name of the exchange of the message, empty for the default exchange and the acknowledgements.
+func (e *EventWrapper) Exchange() string {
+	if !e.parsed {
+		e.parse()
+	}
+	return e.exchange
+}
+
+// RoutingKey returns the routing key of the message, empty for the acknowledgements.
+func (e *EventWrapper) RoutingKey() string {
+	if !e.parsed {
+		e.parse()
+	}
+	return e.routingKey
+}
+
+// BodySize returns the size of the body of the message, 0 for the acknowledgements.
+func (e *EventWrapper) BodySize() uint64 {
+	return e.Tx.Body_size
+}
+
+const template = `
+ebpfTx{
+	Method: %q,
+	Exchange: %q,
+	Routing Key: %q,
+	Body Size: %d
+}`
+
+// String returns a string representation of the underlying event
+func (e *EventWrapper) String() string {
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf(template, e.Method(), e.Exchange(), e.RoutingKey(), e.BodySize()))
+	return output.String()
+}
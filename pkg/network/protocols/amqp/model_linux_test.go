@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package amqp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExchangeAndRoutingKey(t *testing.T) {
+	tests := []struct {
+		name               string
+		method             Method
+		arguments          [BufferSize]byte
+		expectedExchange   string
+		expectedRoutingKey string
+	}{
+		{
+			name:               "publish",
+			method:             PublishMethod,
+			arguments:          publishArguments("orders", "created"),
+			expectedExchange:   "orders",
+			expectedRoutingKey: "created",
+		},
+		{
+			name:               "publish to the default exchange",
+			method:             PublishMethod,
+			arguments:          publishArguments("", "tasks"),
+			expectedExchange:   "",
+			expectedRoutingKey: "tasks",
+		},
+		{
+			name:               "deliver",
+			method:             DeliverMethod,
+			arguments:          deliverArguments("ctag-1", "orders", "created"),
+			expectedExchange:   "orders",
+			expectedRoutingKey: "created",
+		},
+		{
+			name:               "truncated routing key",
+			method:             PublishMethod,
+			arguments:          publishArguments("orders", strings.Repeat("k", 100)),
+			expectedExchange:   "orders",
+			expectedRoutingKey: strings.Repeat("k", BufferSize-len("orders")-4),
+		},
+		{
+			name:               "ack",
+			method:             AckMethod,
+			expectedExchange:   "",
+			expectedRoutingKey: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEventWrapper(&EbpfEvent{
+				Tx: EbpfTx{
+					Method_id: uint16(tt.method),
+					Arguments: tt.arguments,
+				},
+			})
+			require.Equal(t, tt.method, e.Method())
+			require.Equal(t, tt.expectedExchange, e.Exchange())
+			require.Equal(t, tt.expectedRoutingKey, e.RoutingKey())
+		})
+	}
+}
+
+func shortString(s string) []byte {
+	return append([]byte{byte(len(s))}, s...)
+}
+
+// publishArguments returns the beginning of the arguments of basic.publish.
+func publishArguments(exchange, routingKey string) [BufferSize]byte {
+	var b [BufferSize]byte
+	buf := []byte{0, 0}
+	buf = append(buf, shortString(exchange)...)
+	buf = append(buf, shortString(routingKey)...)
+	copy(b[:], buf)
+	return b
+}
+
+// deliverArguments returns the beginning of the arguments of basic.deliver.
+func deliverArguments(consumerTag, exchange, routingKey string) [BufferSize]byte {
+	var b [BufferSize]byte
+	buf := shortString(consumerTag)
+	// The delivery tag and the redelivered flag.
+	buf = append(buf, 0, 0, 0, 0, 0, 0, 0, 1, 0)
+	buf = append(buf, shortString(exchange)...)
+	buf = append(buf, shortString(routingKey)...)
+	copy(b[:], buf)
+	return b
+}
@@ -0,0 +1,147 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package amqp
+
+import (
+	"io"
+
+	"github.com/cilium/ebpf"
+
+	manager "github.com/DataDog/ebpf-manager"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/events"
+	"github.com/DataDog/datadog-agent/pkg/network/usm/buildmode"
+	"github.com/DataDog/datadog-agent/pkg/network/usm/utils"
+)
+
+const (
+	scratchBufferMap   = "amqp_scratch_buffer"
+	processTailCall    = "socket__amqp_process"
+	tlsProcessTailCall = "uprobe__amqp_tls_process"
+	eventStream        = "amqp"
+)
+
+// protocol holds the state of the AMQP protocol monitoring.
+type protocol struct {
+	cfg            *config.Config
+	eventsConsumer *events.Consumer[EbpfEvent]
+	statskeeper    *StatKeeper
+}
+
+// Spec is the protocol spec for the AMQP protocol.
+var Spec = &protocols.ProtocolSpec{
+	Factory: newAMQPProtocol,
+	Maps: []*manager.Map{
+		{
+			Name: scratchBufferMap,
+		},
+		{
+			Name: "amqp_batch_events",
+		},
+		{
+			Name: "amqp_batch_state",
+		},
+		{
+			Name: "amqp_batches",
+		},
+	},
+	TailCalls: []manager.TailCallRoute{
+		{
+			ProgArrayName: protocols.ProtocolDispatcherProgramsMap,
+			Key:           uint32(protocols.ProgramAMQP),
+			ProbeIdentificationPair: manager.ProbeIdentificationPair{
+				EBPFFuncName: processTailCall,
+			},
+		},
+		{
+			ProgArrayName: protocols.TLSDispatcherProgramsMap,
+			Key:           uint32(protocols.ProgramTLSAMQP),
+			ProbeIdentificationPair: manager.ProbeIdentificationPair{
+				EBPFFuncName: tlsProcessTailCall,
+			},
+		},
+	},
+}
+
+func newAMQPProtocol(cfg *config.Config) (protocols.Protocol, error) {
+	if !cfg.EnableAMQPMonitoring {
+		return nil, nil
+	}
+
+	return &protocol{
+		cfg:         cfg,
+		statskeeper: NewStatkeeper(cfg),
+	}, nil
+}
+
+// Name returns the name of the protocol.
+func (p *protocol) Name() string {
+	return "amqp"
+}
+
+// ConfigureOptions add the necessary options for the AMQP monitoring to work, to be used by the manager.
+func (p *protocol) ConfigureOptions(mgr *manager.Manager, opts *manager.Options) {
+	utils.EnableOption(opts, "amqp_monitoring_enabled")
+	// Configure event stream
+	events.Configure(p.cfg, eventStream, mgr, opts)
+}
+
+// PreStart runs setup required before starting the protocol.
+func (p *protocol) PreStart(mgr *manager.Manager) (err error) {
+	p.eventsConsumer, err = events.NewConsumer(
+		eventStream,
+		mgr,
+		p.processAMQP,
+	)
+	if err != nil {
+		return
+	}
+
+	p.eventsConsumer.Start()
+
+	return
+}
+
+// PostStart is a no-op, as no state is kept per connection.
+func (p *protocol) PostStart(*manager.Manager) error {
+	return nil
+}
+
+// Stop stops all resources associated with the protocol.
+func (p *protocol) Stop(*manager.Manager) {
+	if p.eventsConsumer != nil {
+		p.eventsConsumer.Stop()
+	}
+}
+
+// DumpMaps is a no-op, as no state is kept per connection.
+func (p *protocol) DumpMaps(io.Writer, string, *ebpf.Map) {}
+
+// GetStats returns a map of AMQP stats.
+func (p *protocol) GetStats() *protocols.ProtocolStats {
+	p.eventsConsumer.Sync()
+
+	return &protocols.ProtocolStats{
+		Type:  protocols.AMQP,
+		Stats: p.statskeeper.GetAndResetAllStats(),
+	}
+}
+
+// IsBuildModeSupported returns always true, as AMQP module is supported by all modes.
+func (*protocol) IsBuildModeSupported(buildmode.Type) bool {
+	return true
+}
+
+func (p *protocol) processAMQP(events []EbpfEvent) {
+	for i := range events {
+		tx := &events[i]
+		p.statskeeper.Process(NewEventWrapper(tx))
+	}
+}
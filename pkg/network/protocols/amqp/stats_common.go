@@ -0,0 +1,86 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package amqp
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// This file contains the structs used to store and combine the stats for the AMQP protocol.
+// The file does not have any build tag, so it can be used in any build as it is used by the tracer package.
+
+// Method is a method of the basic class of AMQP 0-9-1
+// https://www.rabbitmq.com/resources/specs/amqp0-9-1.pdf
+type Method uint16
+
+const (
+	// UnknownMethod represents a method we don't decode
+	UnknownMethod Method = 0
+	// PublishMethod represents basic.publish
+	PublishMethod Method = 40
+	// DeliverMethod represents basic.deliver
+	DeliverMethod Method = 60
+	// AckMethod represents basic.ack
+	AckMethod Method = 80
+	// RejectMethod represents basic.reject
+	RejectMethod Method = 90
+	// NackMethod represents basic.nack
+	NackMethod Method = 120
+)
+
+// String returns the name of the method
+func (m Method) String() string {
+	switch m {
+	case PublishMethod:
+		return "publish"
+	case DeliverMethod:
+		return "deliver"
+	case AckMethod:
+		return "ack"
+	case RejectMethod:
+		return "reject"
+	case NackMethod:
+		return "nack"
+	default:
+		return "unknown"
+	}
+}
+
+// Key is an identifier for a group of AMQP methods
+type Key struct {
+	Method Method
+	// Exchange is the name of the exchange of the published and delivered messages, empty for the default exchange
+	Exchange string
+	// RoutingKey is the routing key of the published and delivered messages, the name of the queue for the default
+	// exchange
+	RoutingKey string
+	types.ConnectionKey
+}
+
+// NewKey creates a new AMQP key
+func NewKey(saddr, daddr util.Address, sport, dport uint16, method Method, exchange, routingKey string) Key {
+	return Key{
+		ConnectionKey: types.NewConnectionKey(saddr, daddr, sport, dport),
+		Method:        method,
+		Exchange:      exchange,
+		RoutingKey:    routingKey,
+	}
+}
+
+// RequestStat represents a group of AMQP methods that has a shared key.
+type RequestStat struct {
+	Count int
+	// BodyBytes is the total size of the bodies of the published and delivered messages
+	BodyBytes uint64
+}
+
+// CombineWith merges the data in 2 RequestStats objects
+// newStats is kept as it is, while the method receiver gets mutated
+func (r *RequestStat) CombineWith(newStats *RequestStat) {
+	r.Count += newStats.Count
+	r.BodyBytes += newStats.BodyBytes
+}
@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package amqp
+
+import (
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+)
+
+// StatKeeper is a struct to hold the records for the AMQP protocol
+type StatKeeper struct {
+	stats      map[Key]*RequestStat
+	statsMutex sync.RWMutex
+	maxEntries int
+}
+
+// NewStatkeeper creates a new StatKeeper
+func NewStatkeeper(c *config.Config) *StatKeeper {
+	newStatKeeper := &StatKeeper{
+		maxEntries: c.MaxAMQPStatsBuffered,
+	}
+	newStatKeeper.resetNoLock()
+	return newStatKeeper
+}
+
+// Process processes the AMQP method
+func (s *StatKeeper) Process(tx *EventWrapper) {
+	s.statsMutex.Lock()
+	defer s.statsMutex.Unlock()
+
+	key := Key{
+		Method:        tx.Method(),
+		Exchange:      tx.Exchange(),
+		RoutingKey:    tx.RoutingKey(),
+		ConnectionKey: tx.ConnTuple(),
+	}
+	requestStats, ok := s.stats[key]
+	if !ok {
+		if len(s.stats) >= s.maxEntries {
+			return
+		}
+		requestStats = new(RequestStat)
+		s.stats[key] = requestStats
+	}
+	requestStats.Count++
+	requestStats.BodyBytes += tx.BodySize()
+}
+
+// GetAndResetAllStats returns all the records and resets the statskeeper
+func (s *StatKeeper) GetAndResetAllStats() map[Key]*RequestStat {
+	s.statsMutex.Lock()
+	defer s.statsMutex.Unlock()
+	ret := s.stats // No deep copy needed since `s.statskeeper` gets reset
+	s.resetNoLock()
+	return ret
+}
+
+func (s *StatKeeper) resetNoLock() {
+	s.stats = make(map[Key]*RequestStat)
+}
@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package amqp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+)
+
+func TestStatKeeperProcess(t *testing.T) {
+	cfg := config.New()
+	cfg.MaxAMQPStatsBuffered = 100
+	s := NewStatkeeper(cfg)
+	for i := 0; i < 10; i++ {
+		s.Process(NewEventWrapper(&EbpfEvent{
+			Tx: EbpfTx{
+				Method_id: uint16(PublishMethod),
+				Arguments: publishArguments("orders", "created"),
+				Body_size: 128,
+			},
+		}))
+		s.Process(NewEventWrapper(&EbpfEvent{
+			Tx: EbpfTx{
+				Method_id: uint16(AckMethod),
+			},
+		}))
+	}
+
+	require.Equal(t, 2, len(s.stats))
+	for k, stat := range s.stats {
+		require.Equal(t, 10, stat.Count)
+		switch k.Method {
+		case PublishMethod:
+			require.Equal(t, "orders", k.Exchange)
+			require.Equal(t, "created", k.RoutingKey)
+			require.Equal(t, uint64(1280), stat.BodyBytes)
+		case AckMethod:
+			require.Empty(t, k.Exchange)
+			require.Zero(t, stat.BodyBytes)
+		default:
+			t.Fatalf("unexpected method %s", k.Method)
+		}
+	}
+}
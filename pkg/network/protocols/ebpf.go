@@ -65,6 +65,14 @@ const (
 	ProgramPostgres ProgramType = C.PROG_POSTGRES
 	// ProgramPostgresParseMessage is the Golang representation of the C.PROG_POSTGRES_PROCESS_PARSE_MESSAGE enum
 	ProgramPostgresParseMessage ProgramType = C.PROG_POSTGRES_PROCESS_PARSE_MESSAGE
+	// ProgramMySQL is the Golang representation of the C.PROG_MYSQL enum
+	ProgramMySQL ProgramType = C.PROG_MYSQL
+	// ProgramRedis is the Golang representation of the C.PROG_REDIS enum
+	ProgramRedis ProgramType = C.PROG_REDIS
+	// ProgramMongo is the Golang representation of the C.PROG_MONGO enum
+	ProgramMongo ProgramType = C.PROG_MONGO
+	// ProgramAMQP is the Golang representation of the C.PROG_AMQP enum
+	ProgramAMQP ProgramType = C.PROG_AMQP
 )
 
 // Application layer of the protocol stack.
@@ -111,6 +119,12 @@ func toProtocolType(protoNum uint8, layerBit uint16) ProtocolType {
 		return Redis
 	case C.PROTOCOL_MYSQL:
 		return MySQL
+	case C.PROTOCOL_SSH:
+		return SSH
+	case C.PROTOCOL_DNS:
+		return DNS
+	case C.PROTOCOL_WEBSOCKET:
+		return WebSocket
 	default:
 		log.Errorf("unknown eBPF protocol type: %x", protocol)
 		return Unknown
@@ -156,4 +170,18 @@ const (
 	ProgramTLSPostgresParseMessage TLSProgramType = C.TLS_PROG_POSTGRES_PROCESS_PARSE_MESSAGE
 	// ProgramTLSPostgresTermination is tail call to process Postgres TLS termination.
 	ProgramTLSPostgresTermination TLSProgramType = C.TLS_POSTGRES_TERMINATION
+	// ProgramTLSMySQL is tail call to process MySQL TLS frames.
+	ProgramTLSMySQL TLSProgramType = C.TLS_MYSQL
+	// ProgramTLSMySQLTermination is tail call to process MySQL TLS termination.
+	ProgramTLSMySQLTermination TLSProgramType = C.TLS_MYSQL_TERMINATION
+	// ProgramTLSRedis is tail call to process Redis TLS frames.
+	ProgramTLSRedis TLSProgramType = C.TLS_REDIS
+	// ProgramTLSRedisTermination is tail call to process Redis TLS termination.
+	ProgramTLSRedisTermination TLSProgramType = C.TLS_REDIS_TERMINATION
+	// ProgramTLSMongo is tail call to process Mongo TLS frames.
+	ProgramTLSMongo TLSProgramType = C.TLS_MONGO
+	// ProgramTLSMongoTermination is tail call to process Mongo TLS termination.
+	ProgramTLSMongoTermination TLSProgramType = C.TLS_MONGO_TERMINATION
+	// ProgramTLSAMQP is tail call to process AMQP TLS frames.
+	ProgramTLSAMQP TLSProgramType = C.TLS_AMQP
 )
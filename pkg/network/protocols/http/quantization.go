@@ -8,12 +8,32 @@ package http
 
 import (
 	"bytes"
+	"fmt"
+)
+
+// SegmentType is a type of path segments which are replaced by a wildcard
+type SegmentType string
+
+const (
+	// SegmentNumeric matches the segments made of digits only (eg. 123)
+	SegmentNumeric SegmentType = "numeric"
+	// SegmentHex matches the segments made of hexadecimal digits, at least one of which is a decimal one (eg. 5f3a9c)
+	SegmentHex SegmentType = "hex"
+	// SegmentUUID matches the segments formatted as a UUID (eg. 123e4567-e89b-12d3-a456-426614174000)
+	SegmentUUID SegmentType = "uuid"
 )
 
 // URLQuantizer is responsible for quantizing URLs
 type URLQuantizer struct {
 	tokenizer *tokenizer
 	buf       *bytes.Buffer
+
+	// maxSegments is the number of segments kept, the following ones are collapsed
+	// into a single wildcard. Zero means that all segments are kept.
+	maxSegments int
+	// wildcardTypes are the types of the segments replaced by a wildcard. When
+	// empty, the segments containing digits or special characters are.
+	wildcardTypes map[SegmentType]struct{}
 }
 
 // NewURLQuantizer returns a new instance of a URLQuantizer
@@ -24,11 +44,37 @@ func NewURLQuantizer() *URLQuantizer {
 	}
 }
 
+// NewURLQuantizerWithRules returns a URLQuantizer keeping at most maxSegments segments, if positive,
+// and only replacing the segments of the given types by a wildcard, if any
+func NewURLQuantizerWithRules(maxSegments int, wildcardTypes []string) (*URLQuantizer, error) {
+	q := NewURLQuantizer()
+	if maxSegments < 0 {
+		return nil, fmt.Errorf("invalid maximum number of segments %d", maxSegments)
+	}
+	q.maxSegments = maxSegments
+
+	for _, t := range wildcardTypes {
+		switch segmentType := SegmentType(t); segmentType {
+		case SegmentNumeric, SegmentHex, SegmentUUID:
+			if q.wildcardTypes == nil {
+				q.wildcardTypes = make(map[SegmentType]struct{})
+			}
+			q.wildcardTypes[segmentType] = struct{}{}
+		default:
+			return nil, fmt.Errorf("unknown segment type %q", t)
+		}
+	}
+	return q, nil
+}
+
 // Quantize path (eg /segment1/segment2/segment3) by doing the following:
 // * If a segment contains only letters, we keep it as it is;
 // * If a segment contains one or more digits or special characters, we replace it by '*'
 // * If a segments represents an API version (eg. v123) we keep it as it is
 //
+// When wildcard types are configured, only the segments of these types are replaced by '*'.
+// When a maximum number of segments is configured, the segments past it are replaced by a single '*'.
+//
 // Note that the quantization happens *in-place* and the supplied argument byte
 // slice is modified, so the returned value will still point to the same
 // underlying byte array.
@@ -36,11 +82,19 @@ func (q *URLQuantizer) Quantize(path []byte) []byte {
 	q.tokenizer.Reset(path)
 	q.buf.Reset()
 	replacements := 0
+	segments := 0
 
 	for q.tokenizer.Next() {
 		q.buf.WriteByte('/')
+		if q.maxSegments > 0 && segments == q.maxSegments {
+			replacements++
+			q.buf.WriteByte('*')
+			break
+		}
+		segments++
+
 		tokenType, tokenValue := q.tokenizer.Value()
-		if tokenType == tokenWildcard {
+		if q.isWildcard(tokenType, tokenValue) {
 			replacements++
 			q.buf.WriteByte('*')
 			continue
@@ -59,6 +113,72 @@ func (q *URLQuantizer) Quantize(path []byte) []byte {
 	return path[:n]
 }
 
+func (q *URLQuantizer) isWildcard(tokenType tokenType, segment []byte) bool {
+	if q.wildcardTypes == nil {
+		return tokenType == tokenWildcard
+	}
+	if _, ok := q.wildcardTypes[SegmentNumeric]; ok && isNumeric(segment) {
+		return true
+	}
+	if _, ok := q.wildcardTypes[SegmentHex]; ok && isHex(segment) {
+		return true
+	}
+	if _, ok := q.wildcardTypes[SegmentUUID]; ok && isUUID(segment) {
+		return true
+	}
+	return false
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isNumeric(segment []byte) bool {
+	for _, c := range segment {
+		if !isDigit(c) {
+			return false
+		}
+	}
+	return len(segment) > 0
+}
+
+// isHex requires a decimal digit so that words such as "cafe" or "feed" are kept
+func isHex(segment []byte) bool {
+	digits := 0
+	for _, c := range segment {
+		if !isHexDigit(c) {
+			return false
+		}
+		if isDigit(c) {
+			digits++
+		}
+	}
+	return digits > 0
+}
+
+func isUUID(segment []byte) bool {
+	if len(segment) != 36 {
+		return false
+	}
+	for i, c := range segment {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !isHexDigit(c) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // tokenType represents a type of token handled by the `tokenizer`
 type tokenType string
 
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTokenizer(t *testing.T) {
@@ -175,6 +176,39 @@ func TestURLQuantizer(t *testing.T) {
 }
 
 // The purpose of this benchmark is to ensure that the whole quantization process doesn't allocate
+func TestURLQuantizerWithRules(t *testing.T) {
+	type testCase struct {
+		maxSegments   int
+		wildcardTypes []string
+		path          string
+		expected      string
+	}
+
+	testCases := []testCase{
+		{maxSegments: 2, path: "/a/b", expected: "/a/b"},
+		{maxSegments: 2, path: "/a/b/c/d", expected: "/a/b/*"},
+		{maxSegments: 2, path: "/a/1/c/d", expected: "/a/*/*"},
+		{maxSegments: 1, path: "/a//", expected: "/a/*"},
+		{wildcardTypes: []string{"numeric"}, path: "/orders/123/item-2", expected: "/orders/*/item-2"},
+		{wildcardTypes: []string{"hex"}, path: "/blobs/5f3a9c/cafe", expected: "/blobs/*/cafe"},
+		{wildcardTypes: []string{"hex"}, path: "/blobs/123", expected: "/blobs/*"},
+		{wildcardTypes: []string{"uuid"}, path: "/users/123e4567-e89b-12d3-a456-426614174000/v2", expected: "/users/*/v2"},
+		{wildcardTypes: []string{"uuid"}, path: "/users/123/v2", expected: "/users/123/v2"},
+		{maxSegments: 3, wildcardTypes: []string{"numeric", "uuid"}, path: "/a/1/b/2", expected: "/a/*/b/*"},
+	}
+
+	for _, tc := range testCases {
+		quantizer, err := NewURLQuantizerWithRules(tc.maxSegments, tc.wildcardTypes)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, string(quantizer.Quantize([]byte(tc.path))), "path %s, max segments %d, wildcard types %v", tc.path, tc.maxSegments, tc.wildcardTypes)
+	}
+
+	_, err := NewURLQuantizerWithRules(0, []string{"base64"})
+	assert.Error(t, err)
+	_, err = NewURLQuantizerWithRules(-1, nil)
+	assert.Error(t, err)
+}
+
 func BenchmarkQuantization(b *testing.B) {
 	quantizer := NewURLQuantizer()
 
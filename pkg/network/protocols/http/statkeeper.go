@@ -42,7 +42,11 @@ func NewStatkeeper(c *config.Config, telemetry *Telemetry, incompleteBuffer Inco
 	var quantizer *URLQuantizer
 	// For now we're only enabling path quantization for HTTP/1 traffic
 	if c.EnableUSMQuantization && telemetry.protocol == "http" {
-		quantizer = NewURLQuantizer()
+		var err error
+		if quantizer, err = NewURLQuantizerWithRules(c.USMQuantizationMaxSegments, c.USMQuantizationWildcardTypes); err != nil {
+			log.Errorf("invalid HTTP path quantization rules, using the default ones: %s", err)
+			quantizer = NewURLQuantizer()
+		}
 	}
 
 	var connectionAggregator *utils.ConnectionAggregator
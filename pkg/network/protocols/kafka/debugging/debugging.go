@@ -18,6 +18,9 @@ type RequestSummary struct {
 	Server       Address
 	ByRequestAPI map[string]int
 	TopicName    string
+	// LatencyP50 is the median latency of the requests in nanoseconds, only measured for fetch requests
+	LatencyP50 float64
+	ErrorCodes map[int8]int
 }
 
 // Address represents represents a IP:Port
@@ -59,6 +62,10 @@ func Kafka(stats map[kafka.Key]*kafka.RequestStat) []RequestSummary {
 
 			ByRequestAPI: byRequestAPI,
 			TopicName:    key.TopicName,
+			ErrorCodes:   requestStat.ErrorCodes,
+		}
+		if requestStat.Latencies != nil {
+			debug.LatencyP50, _ = requestStat.Latencies.GetValueAtQuantile(0.5)
 		}
 
 		all = append(all, debug)
@@ -7,7 +7,10 @@
 
 package kafka
 
-import "github.com/DataDog/datadog-agent/pkg/network/types"
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
 
 // ConnTuple returns the connection tuple for the transaction
 func (tx *EbpfTx) ConnTuple() types.ConnectionKey {
@@ -35,3 +38,17 @@ func (tx *EbpfTx) APIVersion() uint16 {
 func (tx *EbpfTx) RecordsCount() uint32 {
 	return tx.Transaction.Records_count
 }
+
+// RequestLatency returns the latency of the request in nanoseconds, or 0 if its response
+// wasn't tracked, which is the case of the produce requests
+func (tx *EbpfTx) RequestLatency() float64 {
+	if tx.Transaction.Request_started == 0 || tx.Transaction.Response_last_seen < tx.Transaction.Request_started {
+		return 0
+	}
+	return protocols.NSTimestampToFloat(tx.Transaction.Response_last_seen - tx.Transaction.Request_started)
+}
+
+// ErrorCode returns the top level error code of the response, 0 meaning no error
+func (tx *EbpfTx) ErrorCode() int8 {
+	return tx.Transaction.Error_code
+}
@@ -57,6 +57,12 @@ func (statKeeper *StatKeeper) Process(tx *EbpfTx) {
 		statKeeper.stats[key] = requestStats
 	}
 	requestStats.Count += int(tx.RecordsCount())
+	if latency := tx.RequestLatency(); latency > 0 {
+		requestStats.addLatency(latency)
+	}
+	if code := tx.ErrorCode(); code != 0 {
+		requestStats.addErrorCode(code, 1)
+	}
 }
 
 // GetAndResetAllStats returns all the stats and resets the stats
@@ -11,6 +11,9 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	"github.com/DataDog/datadog-agent/pkg/network/config"
 )
 
@@ -68,3 +71,36 @@ func TestStatKeeper_extractTopicName(t *testing.T) {
 		})
 	}
 }
+
+func TestStatKeeperLatencyAndErrors(t *testing.T) {
+	sk := NewStatkeeper(&config.Config{MaxKafkaStatsBuffered: 1000}, NewTelemetry())
+
+	fetch := KafkaTransaction{Request_api_key: FetchAPIKey, Request_started: 1000, Response_last_seen: 3000, Records_count: 2}
+	sk.Process(&EbpfTx{Transaction: fetch})
+	fetch.Error_code = 3 // UNKNOWN_TOPIC_OR_PARTITION
+	fetch.Records_count = 0
+	sk.Process(&EbpfTx{Transaction: fetch})
+	sk.Process(&EbpfTx{Transaction: KafkaTransaction{Request_api_key: ProduceAPIKey, Request_started: 1000, Records_count: 1}})
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 2)
+	for key, stat := range stats {
+		switch key.RequestAPIKey {
+		case FetchAPIKey:
+			assert.Equal(t, 2, stat.Count)
+			require.NotNil(t, stat.Latencies)
+			assert.Equal(t, float64(2), stat.Latencies.GetCount())
+			assert.Equal(t, map[int8]int{3: 1}, stat.ErrorCodes)
+
+			merged := &RequestStat{}
+			merged.CombineWith(stat)
+			merged.CombineWith(stat)
+			assert.Equal(t, float64(4), merged.Latencies.GetCount())
+			assert.Equal(t, map[int8]int{3: 2}, merged.ErrorCodes)
+		case ProduceAPIKey:
+			assert.Equal(t, 1, stat.Count)
+			assert.Nil(t, stat.Latencies)
+			assert.Empty(t, stat.ErrorCodes)
+		}
+	}
+}
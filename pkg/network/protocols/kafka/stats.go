@@ -6,10 +6,16 @@
 package kafka
 
 import (
+	"github.com/DataDog/sketches-go/ddsketch"
+
 	"github.com/DataDog/datadog-agent/pkg/network/types"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
+// RelativeAccuracy defines the acceptable error in quantile values calculated by DDSketch.
+const RelativeAccuracy = 0.01
+
 const (
 	// ProduceAPIKey is the API key for produce requests
 	ProduceAPIKey = 0
@@ -39,10 +45,46 @@ func NewKey(saddr, daddr util.Address, sport, dport uint16, topicName string, re
 // RequestStat stores stats for Kafka requests to a particular key
 type RequestStat struct {
 	Count int
+	// Latencies holds the latencies of the requests in nanoseconds. They are only measured
+	// for the fetch requests, so it is nil for the produce ones.
+	Latencies *ddsketch.DDSketch
+	// ErrorCodes counts the failed requests by their Kafka error code
+	ErrorCodes map[int8]int
+}
+
+func (r *RequestStat) addLatency(latency float64) {
+	if r.Latencies == nil {
+		var err error
+		if r.Latencies, err = ddsketch.NewDefaultDDSketch(RelativeAccuracy); err != nil {
+			log.Debugf("could not create new ddsketch for kafka request latency: %v", err)
+			return
+		}
+	}
+	if err := r.Latencies.Add(latency); err != nil {
+		log.Debugf("could not add kafka request latency to ddsketch: %v", err)
+	}
+}
+
+func (r *RequestStat) addErrorCode(code int8, count int) {
+	if r.ErrorCodes == nil {
+		r.ErrorCodes = make(map[int8]int)
+	}
+	r.ErrorCodes[code] += count
 }
 
 // CombineWith merges the data in 2 RequestStats objects
 // newStats is kept as it is, while the method receiver gets mutated
 func (r *RequestStat) CombineWith(newStats *RequestStat) {
 	r.Count += newStats.Count
+	for code, count := range newStats.ErrorCodes {
+		r.addErrorCode(code, count)
+	}
+	if newStats.Latencies == nil {
+		return
+	}
+	if r.Latencies == nil {
+		r.Latencies = newStats.Latencies.Copy()
+	} else if err := r.Latencies.MergeWith(newStats.Latencies); err != nil {
+		log.Debugf("could not merge kafka request latencies: %v", err)
+	}
 }
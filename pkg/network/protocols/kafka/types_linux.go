@@ -31,12 +31,13 @@ type KafkaTransactionKey struct {
 }
 type KafkaTransaction struct {
 	Request_started     uint64
+	Response_last_seen  uint64
 	Records_count       uint32
 	Request_api_key     uint8
 	Request_api_version uint8
 	Topic_name_size     uint8
+	Error_code          int8
 	Topic_name          [80]byte
-	Pad_cgo_0           [1]byte
 }
 
 type KafkaResponseContext struct {
@@ -0,0 +1,153 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package mongo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+const (
+	unknownCommand = "UNKNOWN"
+
+	// bsonTypeString is the type of the BSON UTF-8 strings.
+	// https://bsonspec.org/spec.html
+	bsonTypeString = 0x02
+	// documentLengthSize is the size of the length starting the BSON documents and strings.
+	documentLengthSize = 4
+)
+
+// EventWrapper wraps an ebpf event and provides additional methods to extract information from it.
+// We use this wrapper to avoid recomputing the same values (command and collection) multiple times.
+type EventWrapper struct {
+	*EbpfEvent
+
+	parsed     bool
+	command    string
+	collection string
+}
+
+// NewEventWrapper creates a new EventWrapper from an ebpf event.
+func NewEventWrapper(e *EbpfEvent) *EventWrapper {
+	return &EventWrapper{EbpfEvent: e}
+}
+
+// ConnTuple returns the connection tuple for the transaction
+func (e *EventWrapper) ConnTuple() types.ConnectionKey {
+	return types.ConnectionKey{
+		SrcIPHigh: e.Tuple.Saddr_h,
+		SrcIPLow:  e.Tuple.Saddr_l,
+		DstIPHigh: e.Tuple.Daddr_h,
+		DstIPLow:  e.Tuple.Daddr_l,
+		SrcPort:   e.Tuple.Sport,
+		DstPort:   e.Tuple.Dport,
+	}
+}
+
+// isValidCommand returns true if the command name only contains the characters of the names of the Mongo commands.
+func isValidCommand(command []byte) bool {
+	if len(command) == 0 {
+		return false
+	}
+	for _, c := range command {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '_':
+			continue
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// parse extracts the command and the collection from the body document of the request. The command is the name of
+// the first element of the document, and the collection its value when it is a string, such as in
+// {find: "users", filter: {...}}. The collection names longer than the captured fragment are truncated.
+// https://www.mongodb.com/docs/manual/reference/command/
+func (e *EventWrapper) parse() {
+	e.parsed = true
+	e.command = unknownCommand
+
+	// Skipping the length of the document.
+	fragment := e.Tx.Request_fragment[documentLengthSize:]
+	elementType := fragment[0]
+	fragment = fragment[1:]
+	end := bytes.IndexByte(fragment, 0)
+	if end == -1 || !isValidCommand(fragment[:end]) {
+		return
+	}
+	e.command = string(fragment[:end])
+
+	fragment = fragment[end+1:]
+	if elementType != bsonTypeString || len(fragment) <= documentLengthSize {
+		return
+	}
+	// The length of the string includes its trailing null byte.
+	length := int(int32(binary.LittleEndian.Uint32(fragment)))
+	fragment = fragment[documentLengthSize:]
+	if length <= 1 {
+		return
+	}
+	if length-1 < len(fragment) {
+		fragment = fragment[:length-1]
+	}
+	// The rest of the fragment is zeroed when the document is shorter than it.
+	if i := bytes.IndexByte(fragment, 0); i != -1 {
+		fragment = fragment[:i]
+	}
+	e.collection = string(fragment)
+}
+
+// Command returns the name of the command (find, insert, update, aggregate, etc.)
+func (e *EventWrapper) Command() string {
+	if !e.parsed {
+		e.parse()
+	}
+	return e.command
+}
+
+// Collection returns the name of the collection the command operates on, empty if the command has none.
+func (e *EventWrapper) Collection() string {
+	if !e.parsed {
+		e.parse()
+	}
+	return e.collection
+}
+
+// RequestLatency returns the latency of the request in nanoseconds
+func (e *EventWrapper) RequestLatency() float64 {
+	if uint64(e.Tx.Request_started) == 0 || uint64(e.Tx.Response_last_seen) == 0 {
+		return 0
+	}
+	return protocols.NSTimestampToFloat(e.Tx.Response_last_seen - e.Tx.Request_started)
+}
+
+// IsError returns true if the server responded that the command failed
+func (e *EventWrapper) IsError() bool {
+	return e.Tx.Is_error > 0
+}
+
+const template = `
+ebpfTx{
+	Command: %q,
+	Collection: %q,
+	Latency: %f,
+	Is Error: %t
+}`
+
+// String returns a string representation of the underlying event
+func (e *EventWrapper) String() string {
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf(template, e.Command(), e.Collection(), e.RequestLatency(), e.IsError()))
+	return output.String()
+}
@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package mongo
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandAndCollection(t *testing.T) {
+	tests := []struct {
+		name               string
+		fragment           [BufferSize]byte
+		expectedCommand    string
+		expectedCollection string
+	}{
+		{
+			name:               "find",
+			fragment:           stringElementFragment("find", "users"),
+			expectedCommand:    "find",
+			expectedCollection: "users",
+		},
+		{
+			name:               "aggregate",
+			fragment:           stringElementFragment("aggregate", "orders"),
+			expectedCommand:    "aggregate",
+			expectedCollection: "orders",
+		},
+		{
+			name:               "truncated collection",
+			fragment:           stringElementFragment("insert", strings.Repeat("c", BufferSize)),
+			expectedCommand:    "insert",
+			expectedCollection: strings.Repeat("c", BufferSize-len("insert")-10),
+		},
+		{
+			name:               "command without collection",
+			fragment:           int32ElementFragment("ping", 1),
+			expectedCommand:    "ping",
+			expectedCollection: "",
+		},
+		{
+			name:               "invalid command",
+			fragment:           stringElementFragment("fi\nd", "users"),
+			expectedCommand:    unknownCommand,
+			expectedCollection: "",
+		},
+		{
+			name:               "empty fragment",
+			expectedCommand:    unknownCommand,
+			expectedCollection: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEventWrapper(&EbpfEvent{
+				Tx: EbpfTx{
+					Request_fragment: tt.fragment,
+				},
+			})
+			require.Equal(t, tt.expectedCommand, e.Command())
+			require.Equal(t, tt.expectedCollection, e.Collection())
+		})
+	}
+}
+
+// stringElementFragment returns the beginning of a body document whose first element is {command: collection}.
+func stringElementFragment(command, collection string) [BufferSize]byte {
+	var b [BufferSize]byte
+	buf := binary.LittleEndian.AppendUint32(nil, 0)
+	buf = append(buf, bsonTypeString)
+	buf = append(buf, command...)
+	buf = append(buf, 0)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(collection)+1))
+	buf = append(buf, collection...)
+	buf = append(buf, 0)
+	copy(b[:], buf)
+	return b
+}
+
+// int32ElementFragment returns the beginning of a body document whose first element is {command: value}.
+func int32ElementFragment(command string, value int32) [BufferSize]byte {
+	var b [BufferSize]byte
+	buf := binary.LittleEndian.AppendUint32(nil, 0)
+	// The BSON type of the 32-bit integers.
+	buf = append(buf, 0x10)
+	buf = append(buf, command...)
+	buf = append(buf, 0)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(value))
+	copy(b[:], buf)
+	return b
+}
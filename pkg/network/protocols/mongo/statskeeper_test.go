@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+)
+
+func TestStatKeeperProcess(t *testing.T) {
+	cfg := config.New()
+	cfg.MaxMongoStatsBuffered = 100
+	s := NewStatkeeper(cfg)
+	for i := 0; i < 20; i++ {
+		var isError uint8
+		if i%4 == 0 {
+			isError = 1
+		}
+		s.Process(NewEventWrapper(&EbpfEvent{
+			Tx: EbpfTx{
+				Request_fragment:   stringElementFragment("find", "users"),
+				Request_started:    1,
+				Response_last_seen: 10,
+				Is_error:           isError,
+			},
+		}))
+	}
+
+	require.Equal(t, 1, len(s.stats))
+	for k, stat := range s.stats {
+		require.Equal(t, "find", k.Command)
+		require.Equal(t, "users", k.Collection)
+		require.Equal(t, 20, stat.Count)
+		require.Equal(t, 5, stat.ErrorCount)
+		require.Equal(t, float64(20), stat.Latencies.GetCount())
+	}
+}
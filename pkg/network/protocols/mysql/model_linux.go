@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package mysql
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/DataDog/go-sqllexer"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const unknownQuery = "UNKNOWN"
+
+// EventWrapper wraps an ebpf event and provides additional methods to extract information from it.
+// We use this wrapper to avoid recomputing the same values (operation and query fingerprint) multiple times.
+type EventWrapper struct {
+	*EbpfEvent
+
+	operationSet bool
+	operation    Operation
+	querySet     bool
+	query        string
+	obfuscator   *sqllexer.Obfuscator
+	normalizer   *sqllexer.Normalizer
+}
+
+// NewEventWrapper creates a new EventWrapper from an ebpf event.
+func NewEventWrapper(e *EbpfEvent) *EventWrapper {
+	return &EventWrapper{
+		EbpfEvent:  e,
+		obfuscator: sqllexer.NewObfuscator(),
+		normalizer: sqllexer.NewNormalizer(),
+	}
+}
+
+// ConnTuple returns the connection tuple for the transaction
+func (e *EventWrapper) ConnTuple() types.ConnectionKey {
+	return types.ConnectionKey{
+		SrcIPHigh: e.Tuple.Saddr_h,
+		SrcIPLow:  e.Tuple.Saddr_l,
+		DstIPHigh: e.Tuple.Daddr_h,
+		DstIPLow:  e.Tuple.Daddr_l,
+		SrcPort:   e.Tuple.Sport,
+		DstPort:   e.Tuple.Dport,
+	}
+}
+
+// getFragment returns the actual query fragment from the event.
+func (e *EbpfTx) getFragment() []byte {
+	if e.Original_query_size == 0 {
+		return nil
+	}
+	if e.Original_query_size > uint32(len(e.Request_fragment)) {
+		return e.Request_fragment[:len(e.Request_fragment)]
+	}
+	return e.Request_fragment[:e.Original_query_size]
+}
+
+// Operation returns the operation of the query (SELECT, INSERT, UPDATE, DROP, etc.)
+func (e *EventWrapper) Operation() Operation {
+	if !e.operationSet {
+		e.operation = FromString(string(bytes.SplitN(bytes.TrimSpace(e.Tx.getFragment()), []byte(" "), 2)[0]))
+		e.operationSet = true
+	}
+	return e.operation
+}
+
+// extractQuery returns the fingerprint of the query, in which the literals are obfuscated and the whitespaces and
+// comments are normalized, so that the executions of the same statement with different values are grouped together.
+func (e *EventWrapper) extractQuery() string {
+	fragment := e.Tx.getFragment()
+	if len(fragment) == 0 {
+		// The statement was prepared before the monitoring started.
+		return unknownQuery
+	}
+
+	query, _, err := sqllexer.ObfuscateAndNormalize(string(fragment), e.obfuscator, e.normalizer, sqllexer.WithDBMS(sqllexer.DBMSMySQL))
+	if err != nil {
+		log.Debugf("unable to obfuscate and normalize due to: %s", err)
+		return unknownQuery
+	}
+	if query == "" {
+		return unknownQuery
+	}
+	return query
+}
+
+// Query returns the fingerprint of the query.
+func (e *EventWrapper) Query() string {
+	if !e.querySet {
+		e.query = e.extractQuery()
+		e.querySet = true
+	}
+
+	return e.query
+}
+
+// RequestLatency returns the latency of the request in nanoseconds
+func (e *EventWrapper) RequestLatency() float64 {
+	if uint64(e.Tx.Request_started) == 0 || uint64(e.Tx.Response_last_seen) == 0 {
+		return 0
+	}
+	return protocols.NSTimestampToFloat(e.Tx.Response_last_seen - e.Tx.Request_started)
+}
+
+// ErrorCode returns the code of the ERR packet the server responded with, 0 if the query succeeded
+func (e *EventWrapper) ErrorCode() uint16 {
+	return e.Tx.Error_code
+}
+
+const template = `
+ebpfTx{
+	Operation: %q,
+	Query: %q,
+	Latency: %f,
+	Error Code: %d
+}`
+
+// String returns a string representation of the underlying event
+func (e *EventWrapper) String() string {
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf(template, e.Operation(), e.Query(), e.RequestLatency(), e.ErrorCode()))
+	return output.String()
+}
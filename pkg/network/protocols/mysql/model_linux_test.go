@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryFingerprint(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		operation Operation
+		expected  string
+	}{
+		{
+			name:      "literals are obfuscated",
+			query:     `SELECT name FROM users WHERE id = 42`,
+			operation: SelectOP,
+			expected:  "SELECT name FROM users WHERE id = ?",
+		},
+		{
+			name:      "strings are obfuscated",
+			query:     `select name from users where email = 'a@b.c'`,
+			operation: SelectOP,
+			expected:  "select name from users where email = ?",
+		},
+		{
+			name:      "values are grouped",
+			query:     `INSERT INTO users (id, name) VALUES (1, 'a')`,
+			operation: InsertOP,
+			expected:  "INSERT INTO users ( id, name ) VALUES ( ? )",
+		},
+		{
+			name:      "unknown prepared statement",
+			query:     ``,
+			operation: UnknownOP,
+			expected:  unknownQuery,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEventWrapper(&EbpfEvent{
+				Tx: EbpfTx{
+					Request_fragment:    requestFragment([]byte(tt.query)),
+					Original_query_size: uint32(len(tt.query)),
+				},
+			})
+			require.Equal(t, tt.expected, e.Query())
+			require.Equal(t, tt.operation, e.Operation())
+		})
+	}
+}
+
+func requestFragment(fragment []byte) [BufferSize]byte {
+	if len(fragment) >= BufferSize {
+		return *(*[BufferSize]byte)(fragment)
+	}
+	var b [BufferSize]byte
+	copy(b[:], fragment)
+	return b
+}
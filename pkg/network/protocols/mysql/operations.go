@@ -0,0 +1,86 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mysql
+
+import "strings"
+
+// Operation represents a MySQL query operation supported by our decoder.
+type Operation uint8
+
+const (
+	// UnknownOP represents an unknown operation.
+	UnknownOP Operation = iota
+	// SelectOP represents a SELECT operation.
+	SelectOP
+	// InsertOP represents an INSERT operation.
+	InsertOP
+	// UpdateOP represents an UPDATE operation.
+	UpdateOP
+	// DeleteOP represents a DELETE operation.
+	DeleteOP
+	// ReplaceOP represents a REPLACE operation.
+	ReplaceOP
+	// CreateOP represents a CREATE operation.
+	CreateOP
+	// DropOP represents a DROP operation.
+	DropOP
+	// AlterOP represents an ALTER operation.
+	AlterOP
+	// CallOP represents a CALL operation.
+	CallOP
+)
+
+// String returns the string representation of the operation.
+func (op Operation) String() string {
+	switch op {
+	case SelectOP:
+		return "SELECT"
+	case InsertOP:
+		return "INSERT"
+	case UpdateOP:
+		return "UPDATE"
+	case DeleteOP:
+		return "DELETE"
+	case ReplaceOP:
+		return "REPLACE"
+	case CreateOP:
+		return "CREATE"
+	case DropOP:
+		return "DROP"
+	case AlterOP:
+		return "ALTER"
+	case CallOP:
+		return "CALL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// FromString returns the Operation from a string.
+func FromString(op string) Operation {
+	switch strings.ToUpper(op) {
+	case "SELECT":
+		return SelectOP
+	case "INSERT":
+		return InsertOP
+	case "UPDATE":
+		return UpdateOP
+	case "DELETE":
+		return DeleteOP
+	case "REPLACE":
+		return ReplaceOP
+	case "CREATE":
+		return CreateOP
+	case "DROP":
+		return DropOP
+	case "ALTER":
+		return AlterOP
+	case "CALL":
+		return CallOP
+	default:
+		return UnknownOP
+	}
+}
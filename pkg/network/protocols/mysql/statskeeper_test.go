@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+)
+
+func TestStatKeeperProcess(t *testing.T) {
+	cfg := config.New()
+	cfg.MaxMySQLStatsBuffered = 100
+	s := NewStatkeeper(cfg)
+	for i := 0; i < 20; i++ {
+		var errorCode uint16
+		if i%4 == 0 {
+			// ER_NO_SUCH_TABLE
+			errorCode = 1146
+		}
+		s.Process(&EventWrapper{
+			EbpfEvent: &EbpfEvent{
+				Tx: EbpfTx{
+					Request_started:    1,
+					Response_last_seen: 10,
+					Error_code:         errorCode,
+				},
+			},
+			operationSet: true,
+			operation:    SelectOP,
+			querySet:     true,
+			query:        "SELECT * FROM dummy WHERE id = ?",
+		})
+	}
+
+	require.Equal(t, 1, len(s.stats))
+	for k, stat := range s.stats {
+		require.Equal(t, "SELECT * FROM dummy WHERE id = ?", k.Query)
+		require.Equal(t, SelectOP, k.Operation)
+		require.Equal(t, 20, stat.Count)
+		require.Equal(t, map[uint16]int{1146: 5}, stat.ErrorCodes)
+		require.Equal(t, 5, stat.ErrorCount())
+		require.Equal(t, float64(20), stat.Latencies.GetCount())
+	}
+}
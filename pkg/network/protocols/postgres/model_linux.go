@@ -114,6 +114,11 @@ func (e *EventWrapper) RequestLatency() float64 {
 	return protocols.NSTimestampToFloat(e.Tx.Response_last_seen - e.Tx.Request_started)
 }
 
+// IsError returns true if the query failed with an error response from the server
+func (e *EventWrapper) IsError() bool {
+	return e.Tx.Is_error != 0
+}
+
 const template = `
 ebpfTx{
 	Operation: %q,
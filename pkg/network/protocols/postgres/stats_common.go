@@ -38,12 +38,15 @@ type RequestStat struct {
 	Latencies          *ddsketch.DDSketch
 	FirstLatencySample float64
 	Count              int
+	// ErrorCount is the number of the transactions, out of Count, which failed with an error response
+	ErrorCount int
 }
 
 // CombineWith merges the data in 2 RequestStats objects
 // newStats is kept as it is, while the method receiver gets mutated
 func (r *RequestStat) CombineWith(newStats *RequestStat) {
 	r.Count += newStats.Count
+	r.ErrorCount += newStats.ErrorCount
 	// If the receiver has no latency sample, use the newStats sample
 	if r.FirstLatencySample == 0 {
 		r.FirstLatencySample = newStats.FirstLatencySample
@@ -49,6 +49,9 @@ func (s *StatKeeper) Process(tx *EventWrapper) {
 		s.stats[key] = requestStats
 	}
 	requestStats.Count++
+	if tx.IsError() {
+		requestStats.ErrorCount++
+	}
 	if requestStats.Count == 1 {
 		requestStats.FirstLatencySample = tx.RequestLatency()
 		return
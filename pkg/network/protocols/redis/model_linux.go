@@ -0,0 +1,109 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package redis
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/types"
+)
+
+const unknownCommand = "UNKNOWN"
+
+// EventWrapper wraps an ebpf event and provides additional methods to extract information from it.
+// We use this wrapper to avoid recomputing the same values (command) multiple times.
+type EventWrapper struct {
+	*EbpfEvent
+
+	commandSet bool
+	command    string
+}
+
+// NewEventWrapper creates a new EventWrapper from an ebpf event.
+func NewEventWrapper(e *EbpfEvent) *EventWrapper {
+	return &EventWrapper{EbpfEvent: e}
+}
+
+// ConnTuple returns the connection tuple for the transaction
+func (e *EventWrapper) ConnTuple() types.ConnectionKey {
+	return types.ConnectionKey{
+		SrcIPHigh: e.Tuple.Saddr_h,
+		SrcIPLow:  e.Tuple.Saddr_l,
+		DstIPHigh: e.Tuple.Daddr_h,
+		DstIPLow:  e.Tuple.Daddr_l,
+		SrcPort:   e.Tuple.Sport,
+		DstPort:   e.Tuple.Dport,
+	}
+}
+
+// getCommand returns the actual command name from the event. The names longer than CommandMaxSize are truncated.
+func (e *EbpfTx) getCommand() []byte {
+	if int(e.Command_size) > len(e.Command) {
+		return e.Command[:]
+	}
+	return e.Command[:e.Command_size]
+}
+
+// isValidCommand returns true if the command name only contains the characters of the names of the Redis commands,
+// and of the ones of the modules, such as JSON.GET.
+func isValidCommand(command []byte) bool {
+	if len(command) == 0 {
+		return false
+	}
+	for _, c := range command {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '.', c == '_', c == '|', c == '-':
+			continue
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Command returns the name of the command in upper case (GET, SET, EVAL, etc.), as the commands are case-insensitive.
+func (e *EventWrapper) Command() string {
+	if !e.commandSet {
+		e.command = unknownCommand
+		if command := e.Tx.getCommand(); isValidCommand(command) {
+			e.command = string(bytes.ToUpper(command))
+		}
+		e.commandSet = true
+	}
+	return e.command
+}
+
+// RequestLatency returns the latency of the request in nanoseconds
+func (e *EventWrapper) RequestLatency() float64 {
+	if uint64(e.Tx.Request_started) == 0 || uint64(e.Tx.Response_last_seen) == 0 {
+		return 0
+	}
+	return protocols.NSTimestampToFloat(e.Tx.Response_last_seen - e.Tx.Request_started)
+}
+
+// IsError returns true if the server responded to the command with an error
+func (e *EventWrapper) IsError() bool {
+	return e.Tx.Is_error > 0
+}
+
+const template = `
+ebpfTx{
+	Command: %q,
+	Latency: %f,
+	Is Error: %t
+}`
+
+// String returns a string representation of the underlying event
+func (e *EventWrapper) String() string {
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf(template, e.Command(), e.RequestLatency(), e.IsError()))
+	return output.String()
+}
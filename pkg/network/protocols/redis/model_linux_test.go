@@ -0,0 +1,76 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package redis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		size     int
+		expected string
+	}{
+		{
+			name:     "upper case",
+			command:  "SET",
+			expected: "SET",
+		},
+		{
+			name:     "lower case",
+			command:  "eval",
+			expected: "EVAL",
+		},
+		{
+			name:     "module command",
+			command:  "json.get",
+			expected: "JSON.GET",
+		},
+		{
+			name:     "truncated command",
+			command:  "averyveryveryverylongcommand",
+			size:     28,
+			expected: "AVERYVERYVERYVERYLONGCOM",
+		},
+		{
+			name:     "invalid characters",
+			command:  "GE\r\n",
+			expected: unknownCommand,
+		},
+		{
+			name:     "empty command",
+			command:  "",
+			expected: unknownCommand,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size := tt.size
+			if size == 0 {
+				size = len(tt.command)
+			}
+			e := NewEventWrapper(&EbpfEvent{
+				Tx: EbpfTx{
+					Command:      commandBuffer(tt.command),
+					Command_size: uint8(size),
+				},
+			})
+			require.Equal(t, tt.expected, e.Command())
+		})
+	}
+}
+
+func commandBuffer(command string) [CommandMaxSize]byte {
+	var b [CommandMaxSize]byte
+	copy(b[:], command)
+	return b
+}
@@ -31,6 +31,12 @@ const (
 	MySQL
 	// GRPC protocol
 	GRPC
+	// SSH protocol
+	SSH
+	// DNS protocol, only classified over TCP
+	DNS
+	// WebSocket protocol, classified from the HTTP upgrade of the connection
+	WebSocket
 )
 
 // String returns the string representation of the protocol
@@ -58,6 +64,12 @@ func (p ProtocolType) String() string {
 		return "MySQL"
 	case GRPC:
 		return "gRPC"
+	case SSH:
+		return "SSH"
+	case DNS:
+		return "DNS"
+	case WebSocket:
+		return "WebSocket"
 	default:
 		// shouldn't happen
 		return "Invalid"
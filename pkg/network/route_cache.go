@@ -8,6 +8,8 @@
 package network
 
 import (
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"net"
 	"sync"
@@ -17,6 +19,7 @@ import (
 	"github.com/golang/groupcache/lru"
 	"github.com/vishvananda/netlink"
 	"github.com/vishvananda/netns"
+	"golang.org/x/sync/singleflight"
 	"golang.org/x/sys/unix"
 
 	"github.com/DataDog/datadog-agent/pkg/process/util"
@@ -29,12 +32,35 @@ type routeKey struct {
 	source, dest util.Address
 	netns        uint32
 	connFamily   ConnectionFamily
+	mark         uint32
+	uid          uint32
+	hasUID       bool
+}
+
+// RouteOptions stores optional attributes of a route lookup,
+// used to honor policy routing rules (`ip rule`)
+type RouteOptions struct {
+	// Mark is the firewall mark (fwmark) to match against
+	// policy routing rules; 0 means no mark
+	Mark uint32
+	// UID is the uid to match against uidrange policy
+	// routing rules; nil means no uid
+	UID *uint32
 }
 
 // Route stores info for a route table entry
 type Route struct {
 	Gateway util.Address
 	IfIndex int
+	// Src is the preferred source address the
+	// kernel would select for this route, if any
+	Src util.Address
+	// OnLink is true if the destination is
+	// reached directly, without a gateway
+	OnLink bool
+	// Local is true if the destination is
+	// an address of the host (RTN_LOCAL)
+	Local bool
 }
 
 type routeTTL struct {
@@ -45,11 +71,42 @@ type routeTTL struct {
 	empty bool
 }
 
+func (r *routeTTL) result() (Route, error) {
+	if r.empty {
+		return Route{}, ErrNoRoute
+	}
+	return r.entry, nil
+}
+
+// RouteChange is sent to subscribers when the
+// route for a subscribed tuple changes
+type RouteChange struct {
+	Route Route
+	// Removed is true if there no longer is a route for the tuple
+	Removed bool
+}
+
+type routeSubscribers struct {
+	source, dest util.Address
+	netns        uint32
+	// last is the last route seen for the tuple; nil
+	// until the first successful lookup
+	last  *routeTTL
+	chans []chan RouteChange
+}
+
 type routeCache struct {
-	mu     sync.Mutex
-	cache  *lru.Cache
-	router Router
-	ttl    time.Duration
+	mu      sync.Mutex
+	cache   *lru.Cache
+	router  Router
+	ttl     time.Duration
+	lookups singleflight.Group
+
+	subs        map[routeKey]*routeSubscribers
+	refresher   sync.Once
+	done        chan struct{}
+	closeOnce   sync.Once
+	refreshLoop sync.WaitGroup
 }
 
 const (
@@ -65,6 +122,9 @@ var routeCacheTelemetry = struct {
 	expires telemetry.Counter
 	evicts  telemetry.Counter
 
+	sharedLookups telemetry.Counter
+	lookupErrors  telemetry.Counter
+
 	netlinkLookups telemetry.Counter
 	netlinkErrors  telemetry.Counter
 	netlinkMisses  telemetry.Counter
@@ -80,6 +140,9 @@ var routeCacheTelemetry = struct {
 	telemetry.NewCounter(routeCacheTelemetryModuleName, "expires", []string{}, "Counter measuring the number of route cache expirations"),
 	telemetry.NewCounter(routeCacheTelemetryModuleName, "evicts", []string{}, "Counter measuring the number of route cache evicts"),
 
+	telemetry.NewCounter(routeCacheTelemetryModuleName, "shared_lookups", []string{}, "Counter measuring the number of route cache misses that shared a router lookup with a concurrent miss"),
+	telemetry.NewCounter(routeCacheTelemetryModuleName, "lookup_errors", []string{}, "Counter measuring the number of route cache lookups that failed with an error other than a missing route"),
+
 	telemetry.NewCounter(routerTelemetryModuleName, "netlink_lookups", []string{}, "Counter measuring the number of netlink lookups"),
 	telemetry.NewCounter(routerTelemetryModuleName, "netlink_errors", []string{"error"}, "Counter measuring the number of netlink errors"),
 	telemetry.NewCounter(routerTelemetryModuleName, "netlink_misses", []string{}, "Counter measuring the number of netlink misses"),
@@ -90,15 +153,33 @@ var routeCacheTelemetry = struct {
 	telemetry.NewCounter(routerTelemetryModuleName, "if_cache_errors", []string{"error"}, "Counter measuring the number of interface cache errors"),
 }
 
+// ErrNoRoute is returned by a route lookup that completed
+// successfully, but found no route for the given tuple
+var ErrNoRoute = errors.New("no route")
+
+var errRouterClosed = errors.New("router is closed")
+
 // RouteCache is the interface to a cache that stores routes for a given (source, destination, net ns) tuple
 type RouteCache interface {
 	Get(source, dest util.Address, netns uint32) (Route, bool)
+	GetWithOptions(source, dest util.Address, netns uint32, opts RouteOptions) (Route, bool)
+	// Lookup returns ErrNoRoute if there is no route for the tuple, which
+	// is negatively cached. Any other error is a lookup failure that
+	// is not cached, so the caller can retry later.
+	Lookup(source, dest util.Address, netns uint32, opts RouteOptions) (Route, error)
+	// Subscribe returns a channel that receives a RouteChange whenever the
+	// route for the tuple changes, along with a function to cancel the
+	// subscription. Subscribed tuples are refreshed in the background
+	// every TTL. Only the most recent change is kept if the receiver is slow.
+	Subscribe(source, dest util.Address, netns uint32) (<-chan RouteChange, func())
 	Close()
 }
 
 // Router is an interface to get a route for a (source, destination, net ns) tuple
 type Router interface {
-	Route(source, dest util.Address, netns uint32) (Route, bool)
+	// Route returns ErrNoRoute if there is no route for the tuple, or
+	// another error if the lookup failed
+	Route(source, dest util.Address, netns uint32, opts RouteOptions) (Route, error)
 	Close()
 }
 
@@ -117,6 +198,8 @@ func newRouteCache(size int, router Router, ttl time.Duration) *routeCache {
 		cache:  lru.New(size),
 		router: router,
 		ttl:    ttl,
+		subs:   make(map[routeKey]*routeSubscribers),
+		done:   make(chan struct{}),
 	}
 
 	rc.cache.OnEvicted = func(_ lru.Key, _ interface{}) {
@@ -127,25 +210,41 @@ func newRouteCache(size int, router Router, ttl time.Duration) *routeCache {
 }
 
 func (c *routeCache) Close() {
+	c.closeOnce.Do(func() { close(c.done) })
+	c.refreshLoop.Wait()
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	for k, s := range c.subs {
+		for _, ch := range s.chans {
+			close(ch)
+		}
+		delete(c.subs, k)
+	}
+
 	c.cache.Clear()
 	c.router.Close()
 }
 
 func (c *routeCache) Get(source, dest util.Address, netns uint32) (Route, bool) {
-	c.mu.Lock()
-	defer func() {
-		routeCacheTelemetry.size.Set(float64(c.cache.Len()))
-		c.mu.Unlock()
-	}()
+	return c.GetWithOptions(source, dest, netns, RouteOptions{})
+}
 
+func (c *routeCache) GetWithOptions(source, dest util.Address, netns uint32, opts RouteOptions) (Route, bool) {
+	r, err := c.Lookup(source, dest, netns, opts)
+	return r, err == nil
+}
+
+func (c *routeCache) Lookup(source, dest util.Address, netns uint32, opts RouteOptions) (Route, error) {
 	routeCacheTelemetry.lookups.Inc()
-	k := newRouteKey(source, dest, netns)
+	k := newRouteKey(source, dest, netns, opts)
+
+	c.mu.Lock()
 	if entry, ok := c.cache.Get(k); ok {
 		if time.Now().Unix() < entry.(*routeTTL).eta {
-			return entry.(*routeTTL).entry, !entry.(*routeTTL).empty
+			c.mu.Unlock()
+			return entry.(*routeTTL).result()
 		}
 
 		routeCacheTelemetry.expires.Inc()
@@ -153,22 +252,184 @@ func (c *routeCache) Get(source, dest util.Address, netns uint32) (Route, bool)
 	} else {
 		routeCacheTelemetry.misses.Inc()
 	}
+	c.mu.Unlock()
+
+	v, shared, err := c.resolve(k, source, dest, netns, opts)
+	if shared {
+		routeCacheTelemetry.sharedLookups.Inc()
+	}
+
+	if err != nil {
+		routeCacheTelemetry.lookupErrors.Inc()
+		return Route{}, err
+	}
+
+	return v.result()
+}
+
+// resolve queries the router for the route of a tuple and stores the
+// result in the cache, notifying subscribers if the route changed
+func (c *routeCache) resolve(k routeKey, source, dest util.Address, netns uint32, opts RouteOptions) (*routeTTL, bool, error) {
+	// concurrent misses for the same key share a single router lookup
+	v, err, shared := c.lookups.Do(k.String(), func() (interface{}, error) {
+		// a lookup of the same key may have completed since the miss
+		c.mu.Lock()
+		if entry, ok := c.cache.Get(k); ok && time.Now().Unix() < entry.(*routeTTL).eta {
+			c.mu.Unlock()
+			return entry, nil
+		}
+		c.mu.Unlock()
+
+		r, err := c.router.Route(source, dest, netns, opts)
+		if err != nil && !errors.Is(err, ErrNoRoute) {
+			// don't cache lookup failures, as they may be transient
+			return nil, err
+		}
+
+		entry := &routeTTL{
+			eta:   time.Now().Add(c.ttl).Unix(),
+			entry: r,
+			empty: err != nil,
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.cache.Add(k, entry)
+		routeCacheTelemetry.size.Set(float64(c.cache.Len()))
+		c.notify(k, entry)
+		return entry, nil
+	})
+
+	if err != nil {
+		return nil, shared, err
+	}
+	return v.(*routeTTL), shared, nil
+}
+
+func (c *routeCache) Subscribe(source, dest util.Address, netns uint32) (<-chan RouteChange, func()) {
+	k := newRouteKey(source, dest, netns, RouteOptions{})
+	ch := make(chan RouteChange, 1)
+
+	c.mu.Lock()
+	s, ok := c.subs[k]
+	if !ok {
+		s = &routeSubscribers{source: source, dest: dest, netns: netns}
+		if entry, ok := c.cache.Get(k); ok {
+			s.last = entry.(*routeTTL)
+		}
+		c.subs[k] = s
+	}
+	s.chans = append(s.chans, ch)
+	seeded := s.last != nil
+	c.mu.Unlock()
+
+	c.refresher.Do(func() {
+		c.refreshLoop.Add(1)
+		go c.refreshSubscriptions()
+	})
+
+	if !seeded {
+		// establish the current route, so that subsequent changes
+		// are detected; this is not reported as a change
+		_, _ = c.Lookup(source, dest, netns, RouteOptions{})
+	}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() { c.unsubscribe(k, ch) })
+	}
+}
+
+func (c *routeCache) unsubscribe(k routeKey, ch chan RouteChange) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	r, ok := c.router.Route(source, dest, netns)
-	entry := &routeTTL{
-		eta:   time.Now().Add(c.ttl).Unix(),
-		entry: r,
-		empty: !ok,
+	s, ok := c.subs[k]
+	if !ok {
+		return
 	}
 
-	c.cache.Add(k, entry)
-	return r, ok
+	for i := range s.chans {
+		if s.chans[i] == ch {
+			s.chans = append(s.chans[:i], s.chans[i+1:]...)
+			close(ch)
+			break
+		}
+	}
+
+	if len(s.chans) == 0 {
+		delete(c.subs, k)
+	}
 }
 
-func newRouteKey(source, dest util.Address, netns uint32) routeKey {
-	k := routeKey{netns: netns, source: source, dest: dest}
+// notify must be called with c.mu held
+func (c *routeCache) notify(k routeKey, entry *routeTTL) {
+	s, ok := c.subs[k]
+	if !ok {
+		return
+	}
+
+	last := s.last
+	s.last = entry
+	if last == nil || (last.empty == entry.empty && last.entry == entry.entry) {
+		return
+	}
+
+	change := RouteChange{Route: entry.entry, Removed: entry.empty}
+	for _, ch := range s.chans {
+		// keep only the most recent change if the subscriber hasn't
+		// consumed the previous one yet
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- change
+	}
+}
+
+func (c *routeCache) refreshSubscriptions() {
+	defer c.refreshLoop.Done()
+
+	ticker := time.NewTicker(c.ttl)
+	defer ticker.Stop()
+
+	type subscription struct {
+		k            routeKey
+		source, dest util.Address
+		netns        uint32
+	}
+
+	var subs []subscription
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+
+		subs = subs[:0]
+		c.mu.Lock()
+		for k, s := range c.subs {
+			subs = append(subs, subscription{k: k, source: s.source, dest: s.dest, netns: s.netns})
+		}
+		c.mu.Unlock()
 
-	switch dest.Len() {
+		for _, s := range subs {
+			if _, _, err := c.resolve(s.k, s.source, s.dest, s.netns, RouteOptions{}); err != nil {
+				log.Debugf("error refreshing subscribed route for src=%s dst=%s netns=%d: %s", s.source, s.dest, s.netns, err)
+			}
+		}
+	}
+}
+
+func newRouteKey(source, dest util.Address, netns uint32, opts RouteOptions) routeKey {
+	k := routeKey{netns: netns, source: unmapAddress(source), dest: unmapAddress(dest), mark: opts.Mark}
+	if opts.UID != nil {
+		k.uid = *opts.UID
+		k.hasUID = true
+	}
+
+	switch k.dest.Len() {
 	case 4:
 		k.connFamily = AFINET
 	case 16:
@@ -177,6 +438,36 @@ func newRouteKey(source, dest util.Address, netns uint32) routeKey {
 	return k
 }
 
+// String returns a compact representation of the key, suitable
+// for use as a singleflight key
+func (k routeKey) String() string {
+	var buf [2*net.IPv6len + 14]byte
+	n := k.source.WriteTo(buf[:])
+	n += k.dest.WriteTo(buf[n:])
+	binary.LittleEndian.PutUint32(buf[n:], k.netns)
+	n += 4
+	binary.LittleEndian.PutUint32(buf[n:], k.mark)
+	n += 4
+	binary.LittleEndian.PutUint32(buf[n:], k.uid)
+	n += 4
+	buf[n] = uint8(k.connFamily)
+	n++
+	if k.hasUID {
+		buf[n] = 1
+	}
+	n++
+	return string(buf[:n])
+}
+
+// unmapAddress converts an IPv4-mapped IPv6 address (::ffff:a.b.c.d)
+// to its IPv4 form, so that the address family is inferred correctly
+func unmapAddress(a util.Address) util.Address {
+	if a.Is4In6() {
+		return util.Address{Addr: a.Unmap()}
+	}
+	return a
+}
+
 type ifkey struct {
 	ip    util.Address
 	netns uint32
@@ -239,14 +530,20 @@ func (n *netlinkRouter) Close() {
 	n.closed = true
 }
 
-func (n *netlinkRouter) Route(source, dest util.Address, netns uint32) (Route, bool) {
+func (n *netlinkRouter) Route(source, dest util.Address, netns uint32, opts RouteOptions) (Route, error) {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
 	if n.closed {
-		return Route{}, false
+		return Route{}, errRouterClosed
 	}
 
+	// netlink infers the address family of the query from the
+	// length of the addresses, so IPv4-mapped IPv6 addresses
+	// must be converted to plain IPv4 first
+	source = unmapAddress(source)
+	dest = unmapAddress(dest)
+
 	var iifIndex int
 
 	srcBuf := util.IPBufferPool.Get().(*[]byte)
@@ -263,9 +560,12 @@ func (n *netlinkRouter) Route(source, dest util.Address, netns uint32) (Route, b
 		// which interface is associated with the ns
 
 		// get input interface for src ip
-		iif := n.getInterface(source, srcIP, netns)
-		if iif == nil || iif.index == 0 {
-			return Route{}, false
+		iif, err := n.getInterface(source, srcIP, netns)
+		if err != nil {
+			return Route{}, err
+		}
+		if iif.index == 0 {
+			return Route{}, ErrNoRoute
 		}
 
 		if !iif.loopback {
@@ -280,6 +580,8 @@ func (n *netlinkRouter) Route(source, dest util.Address, netns uint32) (Route, b
 		&netlink.RouteGetOptions{
 			SrcAddr:  srcIP,
 			IifIndex: iifIndex,
+			Mark:     int(opts.Mark),
+			UID:      opts.UID,
 		})
 
 	if err != nil {
@@ -290,22 +592,25 @@ func (n *netlinkRouter) Route(source, dest util.Address, netns uint32) (Route, b
 				n.removeInterface(source, netns)
 			}
 		}
-		log.Debugf("Error getting route via netlink with sourceIP %s, dest IP %s and interface index %d : %s", srcIP, dstIP, iifIndex, err)
+		log.Debugf("Error getting route via netlink with sourceIP %s, dest IP %s, interface index %d and mark %d : %s", srcIP, dstIP, iifIndex, opts.Mark, err)
 	} else if len(routes) != 1 {
-		log.Debugf("Did not get exactly one route with sourceIP %s, dest IP %s and interface index %d, got %d routes", srcIP, dstIP, iifIndex, len(routes))
+		log.Debugf("Did not get exactly one route with sourceIP %s, dest IP %s, interface index %d and mark %d, got %d routes", srcIP, dstIP, iifIndex, opts.Mark, len(routes))
 		routeCacheTelemetry.netlinkMisses.Inc()
 	}
 	if err != nil || len(routes) != 1 {
 		log.Tracef("could not get route for src=%s dest=%s err=%s routes=%+v", source, dest, err, routes)
-		return Route{}, false
+		return Route{}, routeError(err)
 	}
 
 	r := routes[0]
-	log.Tracef("route for src=%s dst=%s: scope=%s gw=%+v if=%d", source, dest, r.Scope, r.Gw, r.LinkIndex)
+	log.Tracef("route for src=%s dst=%s: scope=%s gw=%+v if=%d prefsrc=%s", source, dest, r.Scope, r.Gw, r.LinkIndex, r.Src)
 	return Route{
 		Gateway: util.AddressFromNetIP(r.Gw),
 		IfIndex: r.LinkIndex,
-	}, true
+		Src:     util.AddressFromNetIP(r.Src),
+		OnLink:  len(r.Gw) == 0 && r.Type == unix.RTN_UNICAST,
+		Local:   r.Type == unix.RTN_LOCAL,
+	}, nil
 }
 
 func (n *netlinkRouter) removeInterface(srcAddress util.Address, netns uint32) {
@@ -313,12 +618,12 @@ func (n *netlinkRouter) removeInterface(srcAddress util.Address, netns uint32) {
 	n.ifcache.Remove(key)
 }
 
-func (n *netlinkRouter) getInterface(srcAddress util.Address, srcIP net.IP, netns uint32) *ifEntry {
+func (n *netlinkRouter) getInterface(srcAddress util.Address, srcIP net.IP, netns uint32) (*ifEntry, error) {
 	routeCacheTelemetry.ifCacheLookups.Inc()
 
 	key := ifkey{ip: srcAddress, netns: netns}
 	if entry, ok := n.ifcache.Get(key); ok {
-		return entry.(*ifEntry)
+		return entry.(*ifEntry), nil
 	}
 	routeCacheTelemetry.ifCacheMisses.Inc()
 
@@ -327,17 +632,17 @@ func (n *netlinkRouter) getInterface(srcAddress util.Address, srcIP net.IP, netn
 	if err != nil {
 		_, _ = counterIncWithTag(routeCacheTelemetry.netlinkErrors, err)
 		log.Debugf("Error getting route via netlink %s: %s", srcIP, err)
-		return nil
+		return nil, routeError(err)
 	} else if len(routes) != 1 {
 		log.Debugf("Did not get exactly one route for %s, got %d routes", srcIP, len(routes))
 		routeCacheTelemetry.netlinkMisses.Inc()
-		return nil
+		return nil, ErrNoRoute
 	}
 
 	ifr, err := unix.NewIfreq("")
 	if err != nil {
 		_, _ = counterIncWithTag(routeCacheTelemetry.ifCacheErrors, err)
-		return nil
+		return nil, err
 	}
 
 	ifr.SetUint32(uint32(routes[0].LinkIndex))
@@ -347,19 +652,38 @@ func (n *netlinkRouter) getInterface(srcAddress util.Address, srcIP net.IP, netn
 	if err = unix.IoctlIfreq(n.ioctlFD, unix.SIOCGIFNAME, ifr); err != nil {
 		_, _ = counterIncWithTag(routeCacheTelemetry.ifCacheErrors, err)
 		log.Debugf("error getting interface name for link index %d, src ip %s: %s", routes[0].LinkIndex, srcIP, err)
-		return nil
+		return nil, fmt.Errorf("error getting interface name for link index %d: %w", routes[0].LinkIndex, err)
 	}
 	if err = unix.IoctlIfreq(n.ioctlFD, unix.SIOCGIFFLAGS, ifr); err != nil {
 		_, _ = counterIncWithTag(routeCacheTelemetry.ifCacheErrors, err)
 		log.Debugf("error getting interface flags for link index %d, src ip %s: %s", routes[0].LinkIndex, srcIP, err)
-		return nil
+		return nil, fmt.Errorf("error getting interface flags for link index %d: %w", routes[0].LinkIndex, err)
 	}
 
 	iff := &ifEntry{index: routes[0].LinkIndex, loopback: (ifr.Uint16() & unix.IFF_LOOPBACK) != 0}
 	log.Tracef("adding interface entry, key=%+v, entry=%v", key, *iff)
 	n.ifcache.Add(key, iff)
 	routeCacheTelemetry.ifCacheSize.Inc()
-	return iff
+	return iff, nil
+}
+
+// routeError converts the result of a netlink route query into
+// ErrNoRoute when the kernel definitively reported that no route
+// exists, or when no single route was returned
+func routeError(err error) error {
+	if err == nil {
+		return ErrNoRoute
+	}
+
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		switch errno {
+		case syscall.ENETUNREACH, syscall.EHOSTUNREACH, syscall.ENOENT, syscall.ESRCH:
+			return fmt.Errorf("%w: %s", ErrNoRoute, errno)
+		}
+	}
+
+	return fmt.Errorf("route lookup failed: %w", err)
 }
 
 func counterIncWithTag(counter telemetry.Counter, err error) (errno syscall.Errno, ok bool) {
@@ -8,10 +8,13 @@
 package network
 
 import (
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
 	gomock "github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/process/util"
@@ -34,6 +37,7 @@ func TestRouteCacheGet(t *testing.T) {
 	}{
 		{source: "127.0.0.1", dest: "127.0.0.1", route: Route{IfIndex: 0}, ok: true, times: 1},
 		{source: "10.0.2.2", dest: "8.8.8.8", route: Route{Gateway: util.AddressFromString("10.0.2.1"), IfIndex: 1}, ok: true, times: 1},
+		{source: "10.0.2.3", dest: "8.8.8.8", route: Route{Gateway: util.AddressFromString("10.0.2.1"), IfIndex: 1, Src: util.AddressFromString("10.0.2.2")}, ok: true, times: 1},
 		{source: "1.2.3.4", dest: "5.6.7.8", route: Route{}, ok: false, times: 1}, // this will still be (negative) cached
 	}
 
@@ -46,8 +50,12 @@ func TestRouteCacheGet(t *testing.T) {
 	for _, te := range tests {
 		source := util.AddressFromString(te.source)
 		dest := util.AddressFromString(te.dest)
-		m.EXPECT().Route(gomock.Eq(source), gomock.Eq(dest), gomock.Eq(te.netns)).
-			Return(te.route, te.ok).
+		var err error
+		if !te.ok {
+			err = ErrNoRoute
+		}
+		m.EXPECT().Route(gomock.Eq(source), gomock.Eq(dest), gomock.Eq(te.netns), gomock.Eq(RouteOptions{})).
+			Return(te.route, err).
 			Times(te.times)
 
 		r, ok := cache.Get(source, dest, te.netns)
@@ -71,7 +79,7 @@ func TestRouteCacheTTL(t *testing.T) {
 	m := NewMockRouter(ctrl)
 
 	route := Route{Gateway: util.AddressFromString("1.1.1.1"), IfIndex: 0}
-	m.EXPECT().Route(gomock.Any(), gomock.Any(), gomock.Any()).Return(route, true).Times(2)
+	m.EXPECT().Route(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(route, nil).Times(2)
 
 	cache := newRouteCache(10, m, time.Millisecond)
 	defer cache.Close()
@@ -90,3 +98,193 @@ func TestRouteCacheTTL(t *testing.T) {
 	require.True(t, ok)
 	require.Equal(t, route, r)
 }
+
+func TestRouteCacheConcurrentMisses(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := NewMockRouter(ctrl)
+
+	route := Route{Gateway: util.AddressFromString("10.0.2.1"), IfIndex: 1}
+	release := make(chan struct{})
+	m.EXPECT().Route(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_, _ util.Address, _ uint32, _ RouteOptions) (Route, error) {
+			<-release
+			return route, nil
+		}).
+		Times(1)
+
+	cache := NewRouteCache(10, m)
+	defer cache.Close()
+
+	m.EXPECT().Close()
+
+	source := util.AddressFromString("10.0.2.2")
+	dest := util.AddressFromString("8.8.8.8")
+
+	const numGoroutines = 500
+	var started, wg sync.WaitGroup
+	started.Add(numGoroutines)
+	wg.Add(numGoroutines)
+	for i := 0; i < numGoroutines; i++ {
+		go func() {
+			defer wg.Done()
+			started.Done()
+			r, ok := cache.Get(source, dest, 0)
+			assert.True(t, ok)
+			assert.Equal(t, route, r)
+		}()
+	}
+
+	// the goroutines which miss after the lookup completed find its result in the cache
+	started.Wait()
+	close(release)
+	wg.Wait()
+}
+
+func TestRouteCacheLookupErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := NewMockRouter(ctrl)
+
+	cache := NewRouteCache(10, m)
+	defer cache.Close()
+
+	m.EXPECT().Close()
+
+	source := util.AddressFromString("10.0.2.2")
+	unreachable := util.AddressFromString("192.0.2.1")
+	flaky := util.AddressFromString("198.51.100.1")
+	route := Route{Gateway: util.AddressFromString("10.0.2.1"), IfIndex: 1}
+	errTransient := errors.New("netlink timeout")
+
+	// genuine misses are negatively cached
+	m.EXPECT().Route(source, unreachable, uint32(0), RouteOptions{}).Return(Route{}, ErrNoRoute).Times(1)
+	// lookup failures are not cached, so a subsequent lookup can succeed
+	gomock.InOrder(
+		m.EXPECT().Route(source, flaky, uint32(0), RouteOptions{}).Return(Route{}, errTransient).Times(1),
+		m.EXPECT().Route(source, flaky, uint32(0), RouteOptions{}).Return(route, nil).Times(1),
+	)
+
+	for i := 0; i < 2; i++ {
+		_, err := cache.Lookup(source, unreachable, 0, RouteOptions{})
+		require.ErrorIs(t, err, ErrNoRoute)
+
+		_, ok := cache.Get(source, unreachable, 0)
+		require.False(t, ok)
+	}
+
+	_, err := cache.Lookup(source, flaky, 0, RouteOptions{})
+	require.ErrorIs(t, err, errTransient)
+	require.NotErrorIs(t, err, ErrNoRoute)
+
+	r, err := cache.Lookup(source, flaky, 0, RouteOptions{})
+	require.NoError(t, err)
+	require.Equal(t, route, r)
+
+	r, ok := cache.Get(source, flaky, 0)
+	require.True(t, ok)
+	require.Equal(t, route, r)
+}
+
+func TestRouteCacheSubscribe(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := NewMockRouter(ctrl)
+
+	source := util.AddressFromString("10.0.2.2")
+	dest := util.AddressFromString("8.8.8.8")
+	first := Route{Gateway: util.AddressFromString("10.0.2.1"), IfIndex: 1}
+	second := Route{Gateway: util.AddressFromString("10.0.3.1"), IfIndex: 2}
+
+	gomock.InOrder(
+		m.EXPECT().Route(source, dest, uint32(0), RouteOptions{}).Return(first, nil).Times(1),
+		m.EXPECT().Route(source, dest, uint32(0), RouteOptions{}).Return(second, nil).Times(1),
+		m.EXPECT().Route(source, dest, uint32(0), RouteOptions{}).Return(Route{}, ErrNoRoute).AnyTimes(),
+	)
+
+	cache := newRouteCache(10, m, 10*time.Millisecond)
+	defer cache.Close()
+
+	m.EXPECT().Close()
+
+	changes, unsubscribe := cache.Subscribe(source, dest, 0)
+	defer unsubscribe()
+
+	select {
+	case c := <-changes:
+		require.False(t, c.Removed)
+		require.Equal(t, second, c.Route)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timed out waiting for route change")
+	}
+
+	select {
+	case c := <-changes:
+		require.True(t, c.Removed)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timed out waiting for route removal")
+	}
+}
+
+func TestRouteKeyIPv4MappedIPv6(t *testing.T) {
+	tests := []struct {
+		source, dest string
+		family       ConnectionFamily
+	}{
+		{source: "10.0.2.2", dest: "8.8.8.8", family: AFINET},
+		{source: "::ffff:10.0.2.2", dest: "::ffff:8.8.8.8", family: AFINET},
+		{source: "10.0.2.2", dest: "::ffff:8.8.8.8", family: AFINET},
+		{source: "fd00::1", dest: "2001:4860:4860::8888", family: AFINET6},
+	}
+
+	for _, te := range tests {
+		k := newRouteKey(util.AddressFromString(te.source), util.AddressFromString(te.dest), 0, RouteOptions{})
+		require.Equal(t, te.family, k.connFamily, "%+v", te)
+	}
+
+	mapped := newRouteKey(util.AddressFromString("::ffff:10.0.2.2"), util.AddressFromString("::ffff:8.8.8.8"), 1, RouteOptions{})
+	plain := newRouteKey(util.AddressFromString("10.0.2.2"), util.AddressFromString("8.8.8.8"), 1, RouteOptions{})
+	require.Equal(t, plain, mapped)
+}
+
+func TestRouteCacheGetWithOptions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := NewMockRouter(ctrl)
+
+	cache := NewRouteCache(10, m)
+	defer cache.Close()
+
+	m.EXPECT().Close()
+
+	source := util.AddressFromString("10.0.2.2")
+	dest := util.AddressFromString("8.8.8.8")
+	uid := uint32(1000)
+
+	unmarked := Route{Gateway: util.AddressFromString("10.0.2.1"), IfIndex: 1}
+	marked := Route{Gateway: util.AddressFromString("10.0.3.1"), IfIndex: 2}
+	withUID := Route{Gateway: util.AddressFromString("10.0.4.1"), IfIndex: 3}
+
+	m.EXPECT().Route(source, dest, uint32(0), RouteOptions{}).Return(unmarked, nil).Times(1)
+	m.EXPECT().Route(source, dest, uint32(0), RouteOptions{Mark: 0xb00}).Return(marked, nil).Times(1)
+	m.EXPECT().Route(source, dest, uint32(0), RouteOptions{UID: &uid}).Return(withUID, nil).Times(1)
+
+	// each set of options is cached separately
+	for i := 0; i < 2; i++ {
+		r, ok := cache.Get(source, dest, 0)
+		require.True(t, ok)
+		require.Equal(t, unmarked, r)
+
+		r, ok = cache.GetWithOptions(source, dest, 0, RouteOptions{Mark: 0xb00})
+		require.True(t, ok)
+		require.Equal(t, marked, r)
+
+		r, ok = cache.GetWithOptions(source, dest, 0, RouteOptions{UID: &uid})
+		require.True(t, ok)
+		require.Equal(t, withUID, r)
+	}
+}
@@ -0,0 +1,65 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import "math"
+
+// RTTHistogramBuckets is the number of buckets of the RTT histograms, it must match
+// RTT_HISTOGRAM_BUCKETS of the eBPF tracer
+const RTTHistogramBuckets = 24
+
+// RTTHistogram is the distribution of the RTT samples of a TCP connection, collected by the eBPF
+// tracer. Bucket i counts the samples in [2^i, 2^(i+1)) µs, the first bucket also counts the
+// samples under 1µs and the last one all the samples from 2^(RTTHistogramBuckets-1) µs.
+// The counters saturate at math.MaxUint16.
+type RTTHistogram [RTTHistogramBuckets]uint16
+
+// IsEmpty returns whether no sample was recorded
+func (h *RTTHistogram) IsEmpty() bool {
+	return *h == RTTHistogram{}
+}
+
+// Count returns the number of samples recorded
+func (h *RTTHistogram) Count() uint64 {
+	var n uint64
+	for _, c := range h {
+		n += uint64(c)
+	}
+	return n
+}
+
+// Quantile returns an estimate of the RTT at the given quantile, in µs. The estimate is the
+// middle of the bucket holding the quantile, so it is within 50% of the actual value.
+func (h *RTTHistogram) Quantile(q float64) uint32 {
+	count := h.Count()
+	if count == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(q * float64(count)))
+	var seen uint64
+	for i, c := range h {
+		seen += uint64(c)
+		if seen >= rank && c > 0 {
+			return bucketMiddle(i)
+		}
+	}
+	return bucketMiddle(RTTHistogramBuckets - 1)
+}
+
+func bucketMiddle(i int) uint32 {
+	if i == 0 {
+		return 1
+	}
+	return 3 << (i - 1)
+}
+
+// Merge adds the samples of another histogram
+func (h *RTTHistogram) Merge(other *RTTHistogram) {
+	for i := range h {
+		h[i] = uint16(min(uint32(h[i])+uint32(other[i]), math.MaxUint16))
+	}
+}
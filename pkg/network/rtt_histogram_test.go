@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRTTHistogram(t *testing.T) {
+	var h RTTHistogram
+	assert.True(t, h.IsEmpty())
+	assert.Zero(t, h.Quantile(0.5))
+
+	// 8 samples in [64, 128) µs, 1 in [4096, 8192) µs and 1 over the last bucket
+	h[6] = 8
+	h[12] = 1
+	h[RTTHistogramBuckets-1] = 1
+	assert.False(t, h.IsEmpty())
+	assert.Equal(t, uint64(10), h.Count())
+	assert.Equal(t, uint32(96), h.Quantile(0.5))
+	assert.Equal(t, uint32(96), h.Quantile(0.8))
+	assert.Equal(t, uint32(6144), h.Quantile(0.9))
+	assert.Equal(t, uint32(3<<(RTTHistogramBuckets-2)), h.Quantile(1))
+
+	var first RTTHistogram
+	first[0] = 1
+	assert.Equal(t, uint32(1), first.Quantile(0.5))
+}
+
+func TestRTTHistogramMerge(t *testing.T) {
+	var a, b RTTHistogram
+	a[3], a[5] = 2, math.MaxUint16-1
+	b[3], b[5] = 3, 10
+
+	a.Merge(&b)
+	assert.Equal(t, uint16(5), a[3])
+	assert.Equal(t, uint16(math.MaxUint16), a[5], "the counters saturate")
+}
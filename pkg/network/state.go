@@ -7,19 +7,28 @@ package network
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
+	"net"
+	"net/netip"
 	"strconv"
 	"sync"
 	"time"
 
 	"github.com/cihub/seelog"
+	"github.com/twmb/murmur3"
 	"go4.org/intern"
 
+	"github.com/DataDog/datadog-agent/pkg/network/config"
 	"github.com/DataDog/datadog-agent/pkg/network/dns"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/amqp"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mongo"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mysql"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/redis"
 	"github.com/DataDog/datadog-agent/pkg/network/slice"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
@@ -42,11 +51,16 @@ var stateTelemetry = struct {
 	http2StatsDropped      *telemetry.StatCounterWrapper
 	kafkaStatsDropped      *telemetry.StatCounterWrapper
 	postgresStatsDropped   *telemetry.StatCounterWrapper
+	mysqlStatsDropped      *telemetry.StatCounterWrapper
+	redisStatsDropped      *telemetry.StatCounterWrapper
+	mongoStatsDropped      *telemetry.StatCounterWrapper
+	amqpStatsDropped       *telemetry.StatCounterWrapper
 	dnsPidCollisions       *telemetry.StatCounterWrapper
+	clientsExpired         *telemetry.StatCounterWrapper
 	incomingDirectionFixes telemetry.Counter
 	outgoingDirectionFixes telemetry.Counter
 }{
-	telemetry.NewStatCounterWrapper(stateModuleName, "closed_conn_dropped", []string{"ip_proto"}, "Counter measuring the number of dropped closed connections"),
+	telemetry.NewStatCounterWrapper(stateModuleName, "closed_conn_dropped", []string{"ip_proto"}, "Counter measuring the number of closed connections dropped, the empty and then the oldest ones first, because the buffer of a client was full"),
 	telemetry.NewStatCounterWrapper(stateModuleName, "conn_dropped", []string{}, "Counter measuring the number of closed connections"),
 	telemetry.NewStatCounterWrapper(stateModuleName, "stats_underflows", []string{}, "Counter measuring the number of stats underflows"),
 	telemetry.NewStatCounterWrapper(stateModuleName, "stats_cookie_collisions", []string{}, "Counter measuring the number of stats cookie collisions"),
@@ -56,7 +70,12 @@ var stateTelemetry = struct {
 	telemetry.NewStatCounterWrapper(stateModuleName, "http2_stats_dropped", []string{}, "Counter measuring the number of http2 stats dropped"),
 	telemetry.NewStatCounterWrapper(stateModuleName, "kafka_stats_dropped", []string{}, "Counter measuring the number of kafka stats dropped"),
 	telemetry.NewStatCounterWrapper(stateModuleName, "postgres_stats_dropped", []string{}, "Counter measuring the number of postgres stats dropped"),
+	telemetry.NewStatCounterWrapper(stateModuleName, "mysql_stats_dropped", []string{}, "Counter measuring the number of mysql stats dropped"),
+	telemetry.NewStatCounterWrapper(stateModuleName, "redis_stats_dropped", []string{}, "Counter measuring the number of redis stats dropped"),
+	telemetry.NewStatCounterWrapper(stateModuleName, "mongo_stats_dropped", []string{}, "Counter measuring the number of mongo stats dropped"),
+	telemetry.NewStatCounterWrapper(stateModuleName, "amqp_stats_dropped", []string{}, "Counter measuring the number of amqp stats dropped"),
 	telemetry.NewStatCounterWrapper(stateModuleName, "dns_pid_collisions", []string{}, "Counter measuring the number of DNS PID collisions"),
+	telemetry.NewStatCounterWrapper(stateModuleName, "clients_expired", []string{}, "Counter measuring the number of clients expired after they stopped fetching connections"),
 	telemetry.NewCounter(stateModuleName, "incoming_direction_fixes", []string{}, "Counter measuring the number of udp direction fixes for incoming connections"),
 	telemetry.NewCounter(stateModuleName, "outgoing_direction_fixes", []string{}, "Counter measuring the number of udp/tcp direction fixes for outgoing connections"),
 }
@@ -113,6 +132,9 @@ type State interface {
 	// StoreClosedConnections stores a batch of closed connections
 	StoreClosedConnections(connections []ConnectionStats)
 
+	// StoreResolverLatencies stores the latencies of the DNS servers, returned to each client with its next Delta
+	StoreResolverLatencies(latencies dns.LatenciesByResolver)
+
 	// GetStats returns a map of statistics about the current network state
 	GetStats() map[string]interface{}
 
@@ -127,6 +149,14 @@ type Delta struct {
 	HTTP2    map[http.Key]*http.RequestStats
 	Kafka    map[kafka.Key]*kafka.RequestStat
 	Postgres map[postgres.Key]*postgres.RequestStat
+	MySQL    map[mysql.Key]*mysql.RequestStat
+	Redis    map[redis.Key]*redis.RequestStat
+	Mongo    map[mongo.Key]*mongo.RequestStat
+	AMQP     map[amqp.Key]*amqp.RequestStat
+	// Churn is the rate at which each process created and closed connections since the last call
+	Churn []ConnectionChurn
+	// ResolverLatencies holds the latencies of the DNS servers since the last call
+	ResolverLatencies dns.LatenciesByResolver
 }
 
 type lastStateTelemetry struct {
@@ -140,6 +170,10 @@ type lastStateTelemetry struct {
 	http2StatsDropped     int64
 	kafkaStatsDropped     int64
 	postgresStatsDropped  int64
+	mysqlStatsDropped     int64
+	redisStatsDropped     int64
+	mongoStatsDropped     int64
+	amqpStatsDropped      int64
 	dnsPidCollisions      int64
 }
 
@@ -152,19 +186,27 @@ type closedConnections struct {
 	byCookie map[StatCookie]int
 	// the index of first empty connection in conns
 	emptyStart int
+	// oldest is the index of the oldest connection in conns, once conns is full of non-empty
+	// connections: the connections replacing the dropped ones are stored in turn from there
+	oldest int
+	// overflows counts the connections dropped because conns was full,
+	// since the last time the telemetry of the client was fetched
+	overflows int64
 }
 
 // Inserts a connection into conns and byCookie:
-// This function checks whether conns has reached the maxClosedConns limit. If it has, it drops an empty connection.
+// This function checks whether conns has reached the maxClosedConns limit. If it has, it drops an empty connection,
+// or the oldest connection if there are none.
 // If the limit has not been reached, it places the connection in conns.
 // All empty connections are placed at the end. If it is not empty, it will be placed
 // at the index of the first empty connection, and the first empty connection will be placed at the end.
 // If there are no empty connections, it will be appended at the end.
 func (cc *closedConnections) insert(c ConnectionStats, maxClosedConns uint32) {
-	// If we have reached the limit, drop an empty connection
+	// If we have reached the limit, drop an empty connection or the oldest one
 	if uint32(len(cc.conns)) >= maxClosedConns {
-		stateTelemetry.closedConnDropped.Inc(c.Type.String())
-		cc.dropEmpty(c)
+		dropped := cc.dropOldest(c)
+		stateTelemetry.closedConnDropped.Inc(dropped.Type.String())
+		cc.overflows++
 		return
 	}
 	// If the connection is empty append at the end
@@ -190,18 +232,27 @@ func (cc *closedConnections) insert(c ConnectionStats, maxClosedConns uint32) {
 	cc.emptyStart = len(cc.conns)
 }
 
-// Drops the first empty connection:
-// This method drops the incoming connection if it's empty or there are no empty connections in conns.
-// If neither of these conditions are true, it will drop the first empty connection and replace it with
-// the incoming connection.
-func (cc *closedConnections) dropEmpty(c ConnectionStats) {
-	if isEmpty(c) || cc.emptyStart == len(cc.conns) {
-		return
+// Drops a connection to make room for the incoming one, and returns the dropped connection:
+// This method drops the incoming connection if it's empty, or conns can't hold any connection.
+// Otherwise it will drop the first empty connection, or the oldest connection if there are no empty
+// connections, and replace it with the incoming connection.
+func (cc *closedConnections) dropOldest(c ConnectionStats) ConnectionStats {
+	if isEmpty(c) || len(cc.conns) == 0 {
+		return c
+	}
+	i := cc.emptyStart
+	if i == len(cc.conns) {
+		// conns is full of non-empty connections, which were stored in turn from the oldest one
+		i = cc.oldest % len(cc.conns)
+		cc.oldest = (i + 1) % len(cc.conns)
+	} else {
+		cc.emptyStart++
 	}
-	delete(cc.byCookie, cc.conns[cc.emptyStart].Cookie)
-	cc.conns[cc.emptyStart] = c
-	cc.byCookie[c.Cookie] = cc.emptyStart
-	cc.emptyStart++
+	dropped := cc.conns[i]
+	delete(cc.byCookie, dropped.Cookie)
+	cc.conns[i] = c
+	cc.byCookie[c.Cookie] = i
+	return dropped
 }
 
 // Replaces connection c with the connection at index i:
@@ -233,14 +284,28 @@ func (cc *closedConnections) replaceAt(i int, c ConnectionStats) {
 
 type client struct {
 	lastFetch time.Time
-	closed    *closedConnections
-	stats     map[StatCookie]StatCounters
+	// interval is the time between the last two fetches of the client, 0 until it fetched twice
+	interval time.Duration
+	// merged is set once the connections were merged for the client, the connections
+	// of the first merge can't be told apart between new and already existing ones
+	merged bool
+	closed *closedConnections
+	stats  map[StatCookie]StatCounters
+	// closingStats holds the stats of the connections which left the eBPF map before their close
+	// event was processed. They are kept for one more check, so that a late close event is only
+	// accounted for the traffic which wasn't reported yet.
+	closingStats map[StatCookie]StatCounters
 	// maps by dns key the domain (string) to stats structure
 	dnsStats           dns.StatsByKeyByNameByType
+	resolverLatencies  dns.LatenciesByResolver
 	httpStatsDelta     map[http.Key]*http.RequestStats
 	http2StatsDelta    map[http.Key]*http.RequestStats
 	kafkaStatsDelta    map[kafka.Key]*kafka.RequestStat
 	postgresStatsDelta map[postgres.Key]*postgres.RequestStat
+	mysqlStatsDelta    map[mysql.Key]*mysql.RequestStat
+	redisStatsDelta    map[redis.Key]*redis.RequestStat
+	mongoStatsDelta    map[mongo.Key]*mongo.RequestStat
+	amqpStatsDelta     map[amqp.Key]*amqp.RequestStat
 	lastTelemetries    map[ConnTelemetryType]int64
 }
 
@@ -252,11 +317,18 @@ func (c *client) Reset() {
 
 	c.closed.conns = c.closed.conns[:0]
 	c.closed.byCookie = make(map[StatCookie]int)
+	c.closed.emptyStart = 0
+	c.closed.oldest = 0
 	c.dnsStats = make(dns.StatsByKeyByNameByType)
+	c.resolverLatencies = make(dns.LatenciesByResolver)
 	c.httpStatsDelta = make(map[http.Key]*http.RequestStats)
 	c.http2StatsDelta = make(map[http.Key]*http.RequestStats)
 	c.kafkaStatsDelta = make(map[kafka.Key]*kafka.RequestStat)
 	c.postgresStatsDelta = make(map[postgres.Key]*postgres.RequestStat)
+	c.mysqlStatsDelta = make(map[mysql.Key]*mysql.RequestStat)
+	c.redisStatsDelta = make(map[redis.Key]*redis.RequestStat)
+	c.mongoStatsDelta = make(map[mongo.Key]*mongo.RequestStat)
+	c.amqpStatsDelta = make(map[amqp.Key]*amqp.RequestStat)
 }
 
 type networkState struct {
@@ -270,13 +342,20 @@ type networkState struct {
 
 	// Network state configuration
 	clientExpiry                time.Duration
+	maxClientMissedIntervals    int
 	maxClosedConns              uint32
 	maxClientStats              int
 	maxDNSStats                 int
 	maxHTTPStats                int
 	maxKafkaStats               int
 	maxPostgresStats            int
+	maxMySQLStats               int
+	maxRedisStats               int
+	maxMongoStats               int
+	maxAMQPStats                int
+	dnsPorts                    map[uint16]struct{}
 	enableConnectionRollup      bool
+	enableEphemeralPortRollup   bool
 	processEventConsumerEnabled bool
 
 	mergeStatsBuffers [2][]byte
@@ -284,27 +363,44 @@ type networkState struct {
 	localResolver LocalResolver
 }
 
-// NewState creates a new network state
-func NewState(clientExpiry time.Duration, maxClosedConns uint32, maxClientStats, maxDNSStats, maxHTTPStats, maxKafkaStats, maxPostgresStats int, enableConnectionRollup bool, processEventConsumerEnabled bool) State {
+// NewState creates a new network state. The DNS stats are bound to the connections to cfg.DNSMonitoringPorts, or to
+// port 53 if empty. A client expires once it stopped fetching connections for cfg.ClientStateExpiry, or for
+// cfg.MaxClientMissedIntervals times its fetch interval if shorter.
+func NewState(cfg *config.Config) State {
 	ns := &networkState{
-		clients:                map[string]*client{},
-		clientExpiry:           clientExpiry,
-		maxClosedConns:         maxClosedConns,
-		maxClientStats:         maxClientStats,
-		maxDNSStats:            maxDNSStats,
-		maxHTTPStats:           maxHTTPStats,
-		maxKafkaStats:          maxKafkaStats,
-		maxPostgresStats:       maxPostgresStats,
-		enableConnectionRollup: enableConnectionRollup,
+		clients:                   map[string]*client{},
+		clientExpiry:              cfg.ClientStateExpiry,
+		maxClientMissedIntervals:  cfg.MaxClientMissedIntervals,
+		maxClosedConns:            cfg.MaxClosedConnectionsBuffered,
+		maxClientStats:            cfg.MaxConnectionsStateBuffered,
+		maxDNSStats:               cfg.MaxDNSStatsBuffered,
+		maxHTTPStats:              cfg.MaxHTTPStatsBuffered,
+		maxKafkaStats:             cfg.MaxKafkaStatsBuffered,
+		maxPostgresStats:          cfg.MaxPostgresStatsBuffered,
+		maxMySQLStats:             cfg.MaxMySQLStatsBuffered,
+		maxRedisStats:             cfg.MaxRedisStatsBuffered,
+		maxMongoStats:             cfg.MaxMongoStatsBuffered,
+		maxAMQPStats:              cfg.MaxAMQPStatsBuffered,
+		dnsPorts:                  make(map[uint16]struct{}),
+		enableConnectionRollup:    cfg.EnableNPMConnectionRollup,
+		enableEphemeralPortRollup: cfg.EnableEphemeralPortRollup,
 		mergeStatsBuffers: [2][]byte{
 			make([]byte, ConnectionByteKeyMaxLen),
 			make([]byte, ConnectionByteKeyMaxLen),
 		},
-		localResolver:               NewLocalResolver(processEventConsumerEnabled),
-		processEventConsumerEnabled: processEventConsumerEnabled,
+		localResolver:               NewLocalResolver(cfg.EnableProcessEventMonitoring),
+		processEventConsumerEnabled: cfg.EnableProcessEventMonitoring,
+	}
+
+	dnsPorts := cfg.DNSMonitoringPorts
+	if len(dnsPorts) == 0 {
+		dnsPorts = []uint16{53}
+	}
+	for _, port := range dnsPorts {
+		ns.dnsPorts[port] = struct{}{}
 	}
 
-	if ns.enableConnectionRollup && !processEventConsumerEnabled {
+	if ns.enableConnectionRollup && !ns.processEventConsumerEnabled {
 		log.Warnf("disabling port rollups since network event consumer is not enabled")
 		ns.enableConnectionRollup = false
 	}
@@ -375,10 +471,11 @@ func (ns *networkState) GetDelta(
 	defer client.Reset()
 
 	// Update all connections with relevant up-to-date stats for client
-	active, closed := ns.mergeConnections(id, active)
+	active, closed, churn := ns.mergeConnections(id, active)
 
 	cs := slice.NewChain(active, closed)
 	ns.determineConnectionIntraHost(cs)
+	ns.pairIntraHostConnections(cs)
 
 	// resolve local connections if rollups are enabled
 	if ns.enableConnectionRollup {
@@ -389,7 +486,7 @@ func (ns *networkState) GetDelta(
 		ns.storeDNSStats(dnsStats)
 	}
 
-	aggr := newConnectionAggregator((len(closed)+len(active))/2, ns.enableConnectionRollup, ns.processEventConsumerEnabled, client.dnsStats)
+	aggr := newConnectionAggregator((len(closed)+len(active))/2, ns.enableConnectionRollup, ns.enableEphemeralPortRollup, ns.processEventConsumerEnabled, client.dnsStats, ns.dnsPorts)
 	active = filterConnections(active, func(c *ConnectionStats) bool {
 		return !aggr.Aggregate(c)
 	})
@@ -414,6 +511,18 @@ func (ns *networkState) GetDelta(
 		case protocols.Postgres:
 			stats := protocolStats.(map[postgres.Key]*postgres.RequestStat)
 			ns.storePostgresStats(stats)
+		case protocols.MySQL:
+			stats := protocolStats.(map[mysql.Key]*mysql.RequestStat)
+			ns.storeMySQLStats(stats)
+		case protocols.Redis:
+			stats := protocolStats.(map[redis.Key]*redis.RequestStat)
+			ns.storeRedisStats(stats)
+		case protocols.Mongo:
+			stats := protocolStats.(map[mongo.Key]*mongo.RequestStat)
+			ns.storeMongoStats(stats)
+		case protocols.AMQP:
+			stats := protocolStats.(map[amqp.Key]*amqp.RequestStat)
+			ns.storeAMQPStats(stats)
 		}
 	}
 
@@ -423,6 +532,13 @@ func (ns *networkState) GetDelta(
 		HTTP2:    client.http2StatsDelta,
 		Kafka:    client.kafkaStatsDelta,
 		Postgres: client.postgresStatsDelta,
+		MySQL:    client.mysqlStatsDelta,
+		Redis:    client.redisStatsDelta,
+		Mongo:    client.mongoStatsDelta,
+		AMQP:     client.amqpStatsDelta,
+		Churn:    churn,
+
+		ResolverLatencies: client.resolverLatencies,
 	}
 }
 
@@ -462,6 +578,13 @@ func (ns *networkState) getTelemetryDelta(id string, telemetry map[ConnTelemetry
 		}
 	}
 
+	// unlike closed_conn_dropped, which is shared by all the clients,
+	// this only counts the overflows of the buffer of this client
+	if client.closed.overflows > 0 {
+		res[ClosedConnBufferOverflows] = client.closed.overflows
+		client.closed.overflows = 0
+	}
+
 	return res
 }
 
@@ -476,11 +599,17 @@ func (ns *networkState) logTelemetry() {
 	http2StatsDroppedDelta := stateTelemetry.http2StatsDropped.Load() - ns.lastTelemetry.http2StatsDropped
 	kafkaStatsDroppedDelta := stateTelemetry.kafkaStatsDropped.Load() - ns.lastTelemetry.kafkaStatsDropped
 	postgresStatsDroppedDelta := stateTelemetry.postgresStatsDropped.Load() - ns.lastTelemetry.postgresStatsDropped
+	mysqlStatsDroppedDelta := stateTelemetry.mysqlStatsDropped.Load() - ns.lastTelemetry.mysqlStatsDropped
+	redisStatsDroppedDelta := stateTelemetry.redisStatsDropped.Load() - ns.lastTelemetry.redisStatsDropped
+	mongoStatsDroppedDelta := stateTelemetry.mongoStatsDropped.Load() - ns.lastTelemetry.mongoStatsDropped
+	amqpStatsDroppedDelta := stateTelemetry.amqpStatsDropped.Load() - ns.lastTelemetry.amqpStatsDropped
 	dnsPidCollisionsDelta := stateTelemetry.dnsPidCollisions.Load() - ns.lastTelemetry.dnsPidCollisions
 
 	// Flush log line if any metric is non-zero
 	if connDroppedDelta > 0 || closedConnDroppedDelta > 0 || dnsStatsDroppedDelta > 0 || httpStatsDroppedDelta > 0 ||
-		http2StatsDroppedDelta > 0 || kafkaStatsDroppedDelta > 0 || postgresStatsDroppedDelta > 0 {
+		http2StatsDroppedDelta > 0 || kafkaStatsDroppedDelta > 0 || postgresStatsDroppedDelta > 0 || mysqlStatsDroppedDelta > 0 ||
+		redisStatsDroppedDelta > 0 || mongoStatsDroppedDelta > 0 ||
+		amqpStatsDroppedDelta > 0 {
 		s := "State telemetry: "
 		s += " [%d connections dropped due to stats]"
 		s += " [%d closed connections dropped]"
@@ -489,6 +618,10 @@ func (ns *networkState) logTelemetry() {
 		s += " [%d HTTP2 stats dropped]"
 		s += " [%d Kafka stats dropped]"
 		s += " [%d postgres stats dropped]"
+		s += " [%d mysql stats dropped]"
+		s += " [%d redis stats dropped]"
+		s += " [%d mongo stats dropped]"
+		s += " [%d amqp stats dropped]"
 		log.Warnf(s,
 			connDroppedDelta,
 			closedConnDroppedDelta,
@@ -497,6 +630,10 @@ func (ns *networkState) logTelemetry() {
 			http2StatsDroppedDelta,
 			kafkaStatsDroppedDelta,
 			postgresStatsDroppedDelta,
+			mysqlStatsDroppedDelta,
+			redisStatsDroppedDelta,
+			mongoStatsDroppedDelta,
+			amqpStatsDroppedDelta,
 		)
 	}
 
@@ -526,6 +663,10 @@ func (ns *networkState) logTelemetry() {
 	ns.lastTelemetry.http2StatsDropped = stateTelemetry.http2StatsDropped.Load()
 	ns.lastTelemetry.kafkaStatsDropped = stateTelemetry.kafkaStatsDropped.Load()
 	ns.lastTelemetry.postgresStatsDropped = stateTelemetry.postgresStatsDropped.Load()
+	ns.lastTelemetry.mysqlStatsDropped = stateTelemetry.mysqlStatsDropped.Load()
+	ns.lastTelemetry.redisStatsDropped = stateTelemetry.redisStatsDropped.Load()
+	ns.lastTelemetry.mongoStatsDropped = stateTelemetry.mongoStatsDropped.Load()
+	ns.lastTelemetry.amqpStatsDropped = stateTelemetry.amqpStatsDropped.Load()
 	ns.lastTelemetry.dnsPidCollisions = stateTelemetry.dnsPidCollisions.Load()
 }
 
@@ -586,6 +727,9 @@ func (ns *networkState) StoreClosedConnections(closed []ConnectionStats) {
 	ns.Lock()
 	defer ns.Unlock()
 
+	// the clients are otherwise only expired when connections are fetched, a client which
+	// stopped polling while being the only one would keep buffering closed connections
+	ns.removeExpiredClients(time.Now())
 	ns.storeClosedConnections(closed)
 }
 
@@ -674,6 +818,19 @@ func (ns *networkState) storeDNSStats(stats dns.StatsByKeyByNameByType) {
 	}
 }
 
+// StoreResolverLatencies merges the latencies of the DNS servers into those of each client
+func (ns *networkState) StoreResolverLatencies(latencies dns.LatenciesByResolver) {
+	if len(latencies) == 0 {
+		return
+	}
+
+	ns.Lock()
+	defer ns.Unlock()
+	for _, client := range ns.clients {
+		client.resolverLatencies.Merge(latencies)
+	}
+}
+
 // storeHTTPStats stores the latest HTTP stats for all clients
 func (ns *networkState) storeHTTPStats(allStats map[http.Key]*http.RequestStats) {
 	if len(ns.clients) == 1 {
@@ -798,6 +955,127 @@ func (ns *networkState) storePostgresStats(allStats map[postgres.Key]*postgres.R
 	}
 }
 
+// storeMySQLStats stores the latest MySQL stats for the debug client, the only one reading
+// them: the connections payload has no message for MySQL, whose stats are only served by /debug/mysql_monitoring
+func (ns *networkState) storeMySQLStats(allStats map[mysql.Key]*mysql.RequestStat) {
+	client, ok := ns.clients[DEBUGCLIENT]
+	if !ok {
+		return
+	}
+
+	if len(client.mysqlStatsDelta) == 0 && len(allStats) <= ns.maxMySQLStats {
+		// no memory allocation is needed without previous state
+		client.mysqlStatsDelta = allStats
+		return
+	}
+
+	for key, stats := range allStats {
+		prevStats, ok := client.mysqlStatsDelta[key]
+		if !ok && len(client.mysqlStatsDelta) >= ns.maxMySQLStats {
+			stateTelemetry.mysqlStatsDropped.Inc()
+			continue
+		}
+
+		if prevStats != nil {
+			prevStats.CombineWith(stats)
+			client.mysqlStatsDelta[key] = prevStats
+		} else {
+			client.mysqlStatsDelta[key] = stats
+		}
+	}
+}
+
+// storeRedisStats stores the latest Redis stats for the debug client, the only one reading
+// them: the connections payload has no message for Redis, whose stats are only served by /debug/redis_monitoring
+func (ns *networkState) storeRedisStats(allStats map[redis.Key]*redis.RequestStat) {
+	client, ok := ns.clients[DEBUGCLIENT]
+	if !ok {
+		return
+	}
+
+	if len(client.redisStatsDelta) == 0 && len(allStats) <= ns.maxRedisStats {
+		// no memory allocation is needed without previous state
+		client.redisStatsDelta = allStats
+		return
+	}
+
+	for key, stats := range allStats {
+		prevStats, ok := client.redisStatsDelta[key]
+		if !ok && len(client.redisStatsDelta) >= ns.maxRedisStats {
+			stateTelemetry.redisStatsDropped.Inc()
+			continue
+		}
+
+		if prevStats != nil {
+			prevStats.CombineWith(stats)
+			client.redisStatsDelta[key] = prevStats
+		} else {
+			client.redisStatsDelta[key] = stats
+		}
+	}
+}
+
+// storeMongoStats stores the latest Mongo stats for the debug client, the only one reading
+// them: the connections payload has no message for MongoDB, whose stats are only served by /debug/mongo_monitoring
+func (ns *networkState) storeMongoStats(allStats map[mongo.Key]*mongo.RequestStat) {
+	client, ok := ns.clients[DEBUGCLIENT]
+	if !ok {
+		return
+	}
+
+	if len(client.mongoStatsDelta) == 0 && len(allStats) <= ns.maxMongoStats {
+		// no memory allocation is needed without previous state
+		client.mongoStatsDelta = allStats
+		return
+	}
+
+	for key, stats := range allStats {
+		prevStats, ok := client.mongoStatsDelta[key]
+		if !ok && len(client.mongoStatsDelta) >= ns.maxMongoStats {
+			stateTelemetry.mongoStatsDropped.Inc()
+			continue
+		}
+
+		if prevStats != nil {
+			prevStats.CombineWith(stats)
+			client.mongoStatsDelta[key] = prevStats
+		} else {
+			client.mongoStatsDelta[key] = stats
+		}
+	}
+}
+
+// storeAMQPStats stores the latest AMQP stats for the debug client, the only one reading
+// them: DataStreamsAggregations only carries Kafka aggregations, so the AMQP stats are only served by
+// /debug/amqp_monitoring
+func (ns *networkState) storeAMQPStats(allStats map[amqp.Key]*amqp.RequestStat) {
+	client, ok := ns.clients[DEBUGCLIENT]
+	if !ok {
+		return
+	}
+
+	if len(client.amqpStatsDelta) == 0 && len(allStats) <= ns.maxAMQPStats {
+		// no memory allocation is needed without previous state
+		client.amqpStatsDelta = allStats
+		return
+	}
+
+	for key, stats := range allStats {
+		prevStats, ok := client.amqpStatsDelta[key]
+		if !ok && len(client.amqpStatsDelta) >= ns.maxAMQPStats {
+			stateTelemetry.amqpStatsDropped.Inc()
+			continue
+		}
+
+		if prevStats != nil {
+			prevStats.CombineWith(stats)
+			client.amqpStatsDelta[key] = prevStats
+		} else {
+			client.amqpStatsDelta[key] = stats
+		}
+	}
+}
+
 func (ns *networkState) getClient(clientID string) *client {
 	if c, ok := ns.clients[clientID]; ok {
 		return c
@@ -806,33 +1084,53 @@ func (ns *networkState) getClient(clientID string) *client {
 	c := &client{
 		lastFetch:          time.Now(),
 		stats:              make(map[StatCookie]StatCounters),
+		closingStats:       make(map[StatCookie]StatCounters),
 		closed:             closedConnections,
 		dnsStats:           dns.StatsByKeyByNameByType{},
+		resolverLatencies:  make(dns.LatenciesByResolver),
 		httpStatsDelta:     map[http.Key]*http.RequestStats{},
 		http2StatsDelta:    map[http.Key]*http.RequestStats{},
 		kafkaStatsDelta:    map[kafka.Key]*kafka.RequestStat{},
 		postgresStatsDelta: map[postgres.Key]*postgres.RequestStat{},
+		mysqlStatsDelta:    map[mysql.Key]*mysql.RequestStat{},
+		redisStatsDelta:    map[redis.Key]*redis.RequestStat{},
+		mongoStatsDelta:    map[mongo.Key]*mongo.RequestStat{},
+		amqpStatsDelta:     map[amqp.Key]*amqp.RequestStat{},
 		lastTelemetries:    make(map[ConnTelemetryType]int64),
 	}
 	ns.clients[clientID] = c
 	return c
 }
 
-// mergeConnections return the connections and takes care of updating their last stat counters
-func (ns *networkState) mergeConnections(id string, active []ConnectionStats) (_, closed []ConnectionStats) {
+// mergeConnections return the connections and takes care of updating their last stat counters.
+// It also returns the churn of the connections of each process since the last merge.
+func (ns *networkState) mergeConnections(id string, active []ConnectionStats) (_, closed []ConnectionStats, _ []ConnectionChurn) {
 	now := time.Now()
 
 	client := ns.clients[id]
+	interval := now.Sub(client.lastFetch)
 	client.lastFetch = now
+	churn := make(churnCounter)
 
 	// index active connection by cookie, merging
 	// connections with the same cookie
 	active, activeByCookie := ns.mergeByCookie(active)
 
+	// restore the stats of the connections whose close event is late
+	for cookie, sts := range client.closingStats {
+		if _, ok := client.stats[cookie]; !ok {
+			client.stats[cookie] = sts
+		}
+	}
+	closedCookies := make(map[StatCookie]struct{}, len(client.closed.conns))
+
 	// filter closed connections, keeping those that have changed or have not
 	// been aggregated into another connection
 	closed = filterConnections(client.closed.conns, func(closedConn *ConnectionStats) bool {
 		cookie := closedConn.Cookie
+		closedCookies[cookie] = struct{}{}
+		_, known := client.stats[cookie]
+		churn.add(closedConn, !known, true)
 		if activeConn := activeByCookie[cookie]; activeConn != nil {
 			if ns.mergeConnectionStats(closedConn, activeConn) {
 				stateTelemetry.statsCookieCollisions.Inc()
@@ -853,7 +1151,7 @@ func (ns *networkState) mergeConnections(id string, active []ConnectionStats) (_
 		ns.updateConnWithStats(client, cookie, closedConn)
 
 		//nolint:gosimple // TODO(NET) Fix gosimple linter
-		if closedConn.Last.IsZero() {
+		if closedConn.Last.IsZero() && len(closedConn.TCPFailures) == 0 {
 			// not reporting an "empty" connection
 			return false
 		}
@@ -869,6 +1167,8 @@ func (ns *networkState) mergeConnections(id string, active []ConnectionStats) (_
 			return false
 		}
 
+		_, known := client.stats[c.Cookie]
+		churn.add(c, !known, false)
 		ns.createStatsForCookie(client, c.Cookie)
 		ns.updateConnWithStats(client, c.Cookie, c)
 
@@ -883,9 +1183,32 @@ func (ns *networkState) mergeConnections(id string, active []ConnectionStats) (_
 		return true
 	})
 
+	// keep the stats of the connections which are neither active nor closed for one more check,
+	// as their close event may not have been processed yet
+	closingStats := make(map[StatCookie]StatCounters)
+	for cookie, sts := range client.stats {
+		if _, ok := newStats[cookie]; ok {
+			continue
+		}
+		if _, ok := closedCookies[cookie]; ok {
+			continue
+		}
+		if _, ok := client.closingStats[cookie]; ok {
+			// already kept for a check
+			continue
+		}
+		closingStats[cookie] = sts
+	}
+
 	client.stats = newStats
+	client.closingStats = closingStats
 
-	return active, closed
+	if !client.merged {
+		client.merged = true
+		return active, closed, nil
+	}
+	client.interval = interval
+	return active, closed, churn.rates(interval)
 }
 
 func (ns *networkState) updateConnWithStats(client *client, cookie StatCookie, c *ConnectionStats) {
@@ -933,15 +1256,30 @@ func (ns *networkState) RemoveExpiredClients(now time.Time) {
 	ns.Lock()
 	defer ns.Unlock()
 
+	ns.removeExpiredClients(now)
+}
+
+func (ns *networkState) removeExpiredClients(now time.Time) {
 	for id, c := range ns.clients {
-		if c.lastFetch.Add(ns.clientExpiry).Before(now) {
+		if c.lastFetch.Add(ns.expiry(c)).Before(now) {
 			log.Debugf("expiring client: %s, had %d stats and %d closed connections", id, len(c.stats), len(c.closed.conns))
 			delete(ns.clients, id)
 			ClientPool.RemoveExpiredClient(id)
+			stateTelemetry.clientsExpired.Inc()
 		}
 	}
 }
 
+// expiry returns the time after which the client expires if it doesn't fetch connections
+func (ns *networkState) expiry(c *client) time.Duration {
+	if ns.maxClientMissedIntervals > 0 && c.interval > 0 {
+		if missed := time.Duration(ns.maxClientMissedIntervals) * c.interval; missed < ns.clientExpiry {
+			return missed
+		}
+	}
+	return ns.clientExpiry
+}
+
 func (ns *networkState) RemoveConnections(conns []*ConnectionStats) {
 	ns.Lock()
 	defer ns.Unlock()
@@ -949,6 +1287,7 @@ func (ns *networkState) RemoveConnections(conns []*ConnectionStats) {
 	for _, cl := range ns.clients {
 		for _, c := range conns {
 			delete(cl.stats, c.Cookie)
+			delete(cl.closingStats, c.Cookie)
 		}
 	}
 }
@@ -962,8 +1301,11 @@ func (ns *networkState) GetStats() map[string]interface{} {
 	for id, c := range ns.clients {
 		clientInfo[id] = map[string]int{
 			"stats":              len(c.stats),
+			"closing_stats":      len(c.closingStats),
 			"closed_connections": len(c.closed.conns),
+			"closed_overflows":   int(c.closed.overflows),
 			"last_fetch":         int(c.lastFetch.Unix()),
+			"fetch_interval":     int(c.interval.Seconds()),
 		}
 	}
 
@@ -973,6 +1315,7 @@ func (ns *networkState) GetStats() map[string]interface{} {
 			"closed_conn_dropped": stateTelemetry.closedConnDropped.Load(),
 			"conn_dropped":        stateTelemetry.connDropped.Load(),
 			"dns_stats_dropped":   stateTelemetry.dnsStatsDropped.Load(),
+			"clients_expired":     stateTelemetry.clientsExpired.Load(),
 		},
 		"current_time":       time.Now().Unix(),
 		"latest_bpf_time_ns": ns.latestTimeEpoch,
@@ -1064,10 +1407,19 @@ func (ns *networkState) determineConnectionIntraHost(connections slice.Chain[Con
 			_, conn.IntraHost = lAddrs[keyWithRAddr]
 		}
 
-		switch conn.Direction {
-		case OUTGOING:
+		// the NAT hairpinning flag set by the tracer is only kept for the connections which are
+		// confirmed to be intra-host
+		if conn.NATHairpin && !(conn.IntraHost && isDNAT(conn)) {
+			conn.NATHairpin = false
+		}
+
+		switch {
+		case conn.IsDirectionObserved:
+			// the direction does not come from the port
+			// bindings, so there is nothing to fix
+		case conn.Direction == OUTGOING:
 			fixOutgoingConnectionDirection(conn)
-		case INCOMING:
+		case conn.Direction == INCOMING:
 			fixIncomingConnectionDirection(conn)
 		}
 
@@ -1103,6 +1455,69 @@ func (ns *networkState) determineConnectionIntraHost(connections slice.Chain[Con
 	})
 }
 
+// pairIntraHostConnections links the client and server side of the intra-host connections for
+// which both sides are tracked, by (translated tuple, netns) matching. Both sides share the same
+// PairID, derived from the tuple of the flow, and get the pid of the other side as PeerPid.
+func (ns *networkState) pairIntraHostConnections(connections slice.Chain[ConnectionStats]) {
+	type flowKey struct {
+		client, server netip.AddrPort
+		proto          ConnectionType
+		netns          uint32
+	}
+
+	// sides holds the client and server side of each flow
+	sides := make(map[flowKey][2]*ConnectionStats)
+	connections.Iterate(func(_ int, conn *ConnectionStats) {
+		if !conn.IntraHost {
+			return
+		}
+
+		source, dest := translatedAddrs(conn)
+		k := flowKey{proto: conn.Type}
+		side := 0
+		switch conn.Direction {
+		case OUTGOING:
+			k.client, k.server = source, dest
+		case INCOMING:
+			k.client, k.server = dest, source
+			side = 1
+		default:
+			return
+		}
+		// loopback addresses are only unique within a network namespace
+		if k.server.Addr().IsLoopback() {
+			k.netns = conn.NetNS
+		}
+
+		s := sides[k]
+		if s[side] == nil {
+			s[side] = conn
+			sides[k] = s
+		}
+	})
+
+	// the tuple of a flow is at most two IPv6 endpoints, the protocol and the netns
+	buf := make([]byte, 0, 2*(net.IPv6len+2)+1+4)
+	for k, s := range sides {
+		client, server := s[0], s[1]
+		if client == nil || server == nil {
+			continue
+		}
+
+		buf = buf[:0]
+		buf = append(buf, k.client.Addr().AsSlice()...)
+		buf = binary.LittleEndian.AppendUint16(buf, k.client.Port())
+		buf = append(buf, k.server.Addr().AsSlice()...)
+		buf = binary.LittleEndian.AppendUint16(buf, k.server.Port())
+		buf = append(buf, uint8(k.proto))
+		buf = binary.LittleEndian.AppendUint32(buf, k.netns)
+		id := murmur3.Sum64(buf)
+
+		client.PairID, server.PairID = id, id
+		client.PeerPid, server.PeerPid = server.Pid, client.Pid
+	}
+}
+
 // fixIncomingConnectionDirection fixes connection direction
 // for UDP incoming connections.
 //
@@ -1185,16 +1600,20 @@ type connectionAggregator struct {
 	conns                       map[aggregationKey][]*aggregateConnection
 	buf                         []byte
 	dnsStats                    dns.StatsByKeyByNameByType
+	dnsPorts                    map[uint16]struct{}
 	enablePortRollups           bool
+	enableEphemeralPortRollups  bool
 	processEventConsumerEnabled bool
 }
 
-func newConnectionAggregator(size int, enablePortRollups, processEventConsumerEnabled bool, dnsStats dns.StatsByKeyByNameByType) *connectionAggregator {
+func newConnectionAggregator(size int, enablePortRollups, enableEphemeralPortRollups, processEventConsumerEnabled bool, dnsStats dns.StatsByKeyByNameByType, dnsPorts map[uint16]struct{}) *connectionAggregator {
 	return &connectionAggregator{
 		conns:                       make(map[aggregationKey][]*aggregateConnection, size),
 		buf:                         make([]byte, ConnectionByteKeyMaxLen),
 		dnsStats:                    dnsStats,
+		dnsPorts:                    dnsPorts,
 		enablePortRollups:           enablePortRollups,
+		enableEphemeralPortRollups:  enableEphemeralPortRollups,
 		processEventConsumerEnabled: processEventConsumerEnabled,
 	}
 }
@@ -1206,24 +1625,19 @@ func (a *connectionAggregator) key(c *ConnectionStats) (key aggregationKey, spor
 		key.containers.source = c.ContainerID.Source
 	}
 
-	if !a.enablePortRollups {
+	if !a.enablePortRollups && !a.enableEphemeralPortRollups {
 		return key, false, false
 	}
 
-	// local resolution is done in system-probe if rollups
-	// are enabled, so add the destination container id to
-	// the key as well
-	key.containers.dest = c.ContainerID.Dest
-
-	isShortLived := c.IsClosed && (c.Duration > 0 && c.Duration < shortLivedConnectionThreshold)
-	sportRolledUp = c.Direction == OUTGOING
-	dportRolledUp = c.Direction == INCOMING
+	if a.enablePortRollups {
+		// local resolution is done in system-probe if rollups
+		// are enabled, so add the destination container id to
+		// the key as well
+		key.containers.dest = c.ContainerID.Dest
+	}
 
-	log.TraceFunc(func() string {
-		return fmt.Sprintf("type=%s isShortLived=%+v sportRolledUp=%+v", c.Type, isShortLived, sportRolledUp)
-	})
-	if !isShortLived ||
-		(!sportRolledUp && !dportRolledUp) {
+	sportRolledUp, dportRolledUp = a.rolledUpPorts(c)
+	if !sportRolledUp && !dportRolledUp {
 		log.TraceFunc(func() string { return fmt.Sprintf("not rolling up connection %+v ", c) })
 		return key, false, false
 	}
@@ -1252,6 +1666,37 @@ func (a *connectionAggregator) key(c *ConnectionStats) (key aggregationKey, spor
 	return key, sportRolledUp, dportRolledUp
 }
 
+// rolledUpPorts returns whether the source or the destination port of the connection,
+// whichever is the client port, should be dropped from its aggregation key
+func (a *connectionAggregator) rolledUpPorts(c *ConnectionStats) (sport, dport bool) {
+	if a.enableEphemeralPortRollups {
+		// connections are rolled up regardless of their
+		// duration, as long as the client port is ephemeral
+		switch c.Direction {
+		case OUTGOING:
+			sport = IsPortInEphemeralRange(c.Family, c.Type, c.SPort) == EphemeralTrue
+		case INCOMING:
+			dport = IsPortInEphemeralRange(c.Family, c.Type, c.DPort) == EphemeralTrue
+		}
+		if sport || dport {
+			return sport, dport
+		}
+	}
+
+	if !a.enablePortRollups {
+		return false, false
+	}
+
+	isShortLived := c.IsClosed && (c.Duration > 0 && c.Duration < shortLivedConnectionThreshold)
+	log.TraceFunc(func() string {
+		return fmt.Sprintf("type=%s isShortLived=%+v direction=%s", c.Type, isShortLived, c.Direction)
+	})
+	if !isShortLived {
+		return false, false
+	}
+	return c.Direction == OUTGOING, c.Direction == INCOMING
+}
+
 func (a *connectionAggregator) canAggregateIPTranslation(t1, t2 *IPTranslation, sportRolledUp, dportRolledUp bool) bool {
 	if t1 == t2 || t1 == nil || t2 == nil || *t1 == *t2 {
 		return true
@@ -1286,7 +1731,7 @@ func (a *connectionAggregator) canAggregateProtocolStack(p1, p2 protocols.Stack)
 }
 
 func (a *connectionAggregator) dns(c *ConnectionStats) map[dns.Hostname]map[dns.QueryType]dns.Stats {
-	key, isDNS := DNSKey(c)
+	key, isDNS := DNSKey(c, a.dnsPorts)
 	if !isDNS {
 		return nil
 	}
@@ -1315,6 +1760,12 @@ func (a *connectionAggregator) Aggregate(c *ConnectionStats) bool {
 	// get dns stats for connection
 	c.DNSStats = a.dns(c)
 
+	if a.enableEphemeralPortRollups {
+		// drop the client port even if the connection ends up not being merged with
+		// any other, so that the aggregate is reported consistently across checks
+		clearRolledUpPorts(c, sportRolledUp, dportRolledUp)
+	}
+
 	aggrConns, ok := a.conns[key]
 	if !ok {
 		a.conns[key] = []*aggregateConnection{
@@ -1335,25 +1786,9 @@ func (a *connectionAggregator) Aggregate(c *ConnectionStats) bool {
 		}
 
 		aggrConn.merge(c)
-
-		if sportRolledUp {
-			// more than one connection with
-			// source port dropped in key,
-			// so set source port to 0
-			aggrConn.SPort = 0
-			if aggrConn.IPTranslation != nil {
-				aggrConn.IPTranslation.ReplDstPort = 0
-			}
-		}
-		if dportRolledUp {
-			// more than one connection with
-			// dest port dropped in key,
-			// so set dest port to 0
-			aggrConn.DPort = 0
-			if aggrConn.IPTranslation != nil {
-				aggrConn.IPTranslation.ReplSrcPort = 0
-			}
-		}
+		// more than one connection with the
+		// port dropped in key, so set it to 0
+		clearRolledUpPorts(aggrConn.ConnectionStats, sportRolledUp, dportRolledUp)
 
 		return true
 	}
@@ -1368,21 +1803,48 @@ func (a *connectionAggregator) Aggregate(c *ConnectionStats) bool {
 	return false
 }
 
+func clearRolledUpPorts(c *ConnectionStats, sportRolledUp, dportRolledUp bool) {
+	if sportRolledUp {
+		c.SPort = 0
+		if c.IPTranslation != nil {
+			c.IPTranslation.ReplDstPort = 0
+		}
+	}
+	if dportRolledUp {
+		c.DPort = 0
+		if c.IPTranslation != nil {
+			c.IPTranslation.ReplSrcPort = 0
+		}
+	}
+}
+
 func (ac *aggregateConnection) merge(c *ConnectionStats) {
 	ac.Monotonic = ac.Monotonic.Add(c.Monotonic)
 	ac.Last = ac.Last.Add(c.Last)
 	ac.rttSum += uint64(c.RTT)
 	ac.rttVarSum += uint64(c.RTTVar)
+	ac.RTTHistogram.Merge(&c.RTTHistogram)
 	ac.count++
 	if ac.LastUpdateEpoch < c.LastUpdateEpoch {
 		ac.LastUpdateEpoch = c.LastUpdateEpoch
 	}
+	ac.LastSentEpoch = max(ac.LastSentEpoch, c.LastSentEpoch)
+	ac.LastRecvEpoch = max(ac.LastRecvEpoch, c.LastRecvEpoch)
+	ac.IdleDuration = min(ac.IdleDuration, c.IdleDuration)
 	if ac.IPTranslation == nil {
 		ac.IPTranslation = c.IPTranslation
 	}
 
 	ac.ProtocolStack.MergeWith(c.ProtocolStack)
 
+	if ac.TCPFailures == nil {
+		ac.TCPFailures = c.TCPFailures
+	} else {
+		for reason, count := range c.TCPFailures {
+			ac.TCPFailures[reason] += count
+		}
+	}
+
 	if ac.DNSStats == nil {
 		ac.DNSStats = c.DNSStats
 	} else {
@@ -1419,6 +1881,9 @@ func (a *connectionAggregator) finalize() {
 		for _, c := range aggrConns {
 			c.RTT = uint32(c.rttSum / uint64(c.count))
 			c.RTTVar = uint32(c.rttVarSum / uint64(c.count))
+			if c.count > 1 {
+				c.AggregatedConnections = c.count
+			}
 		}
 	}
 }
@@ -1439,6 +1904,8 @@ func (ns *networkState) mergeConnectionStats(a, b *ConnectionStats) (collision b
 	if b.LastUpdateEpoch > a.LastUpdateEpoch {
 		a.LastUpdateEpoch = b.LastUpdateEpoch
 	}
+	a.LastSentEpoch = max(a.LastSentEpoch, b.LastSentEpoch)
+	a.LastRecvEpoch = max(a.LastRecvEpoch, b.LastRecvEpoch)
 
 	if a.IPTranslation == nil {
 		a.IPTranslation = b.IPTranslation
@@ -1446,11 +1913,23 @@ func (ns *networkState) mergeConnectionStats(a, b *ConnectionStats) (collision b
 
 	a.ProtocolStack.MergeWith(b.ProtocolStack)
 
+	// both connections share the same cookie, so any failure
+	// they report is a failure of the same connection attempt
+	if a.TCPFailures == nil {
+		a.TCPFailures = b.TCPFailures
+	} else {
+		for reason, count := range b.TCPFailures {
+			if count > a.TCPFailures[reason] {
+				a.TCPFailures[reason] = count
+			}
+		}
+	}
+
 	return false
 }
 
 func isEmpty(conn ConnectionStats) bool {
 	return conn.Monotonic.RecvBytes == 0 && conn.Monotonic.RecvPackets == 0 &&
 		conn.Monotonic.SentBytes == 0 && conn.Monotonic.SentPackets == 0 &&
-		conn.Monotonic.Retransmits == 0
+		conn.Monotonic.Retransmits == 0 && len(conn.TCPFailures) == 0
 }
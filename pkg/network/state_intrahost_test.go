@@ -55,3 +55,61 @@ func AddIPTranslationToConnection(conn *ConnectionStats, ReplSrcIP string, ReplD
 	}
 	conn.IPTranslation = &translation
 }
+
+func TestPairIntraHostConnections(t *testing.T) {
+	ns := networkState{}
+	client := CreateConnectionStat("10.0.25.1", "10.0.25.2", 59782, 8000, TCP)
+	client.Direction, client.Pid = OUTGOING, 10
+	server := CreateConnectionStat("10.0.25.2", "10.0.25.1", 8000, 59782, TCP)
+	server.Direction, server.Pid = INCOMING, 20
+	// the server side of the DNAT'ed connection sees the client through its real address
+	dnatClient := CreateConnectionStat("10.0.25.1", "2.2.2.2", 59783, 80, TCP)
+	dnatClient.Direction, dnatClient.Pid = OUTGOING, 11
+	AddIPTranslationToConnection(&dnatClient, "10.0.25.2", "10.0.25.1", 8000, 59783)
+	dnatServer := CreateConnectionStat("10.0.25.2", "10.0.25.1", 8000, 59783, TCP)
+	dnatServer.Direction, dnatServer.Pid = INCOMING, 20
+	// loopback connections in different network namespaces are distinct flows
+	loopClient := CreateConnectionStat("127.0.0.1", "127.0.0.1", 59784, 9000, TCP)
+	loopClient.Direction, loopClient.Pid, loopClient.NetNS = OUTGOING, 12, 1
+	loopServer := CreateConnectionStat("127.0.0.1", "127.0.0.1", 9000, 59784, TCP)
+	loopServer.Direction, loopServer.Pid, loopServer.NetNS = INCOMING, 21, 2
+
+	conns := slice.NewChain([]ConnectionStats{client, server, dnatClient, dnatServer}, []ConnectionStats{loopClient, loopServer})
+	ns.determineConnectionIntraHost(conns)
+	ns.pairIntraHostConnections(conns)
+
+	assert.NotZero(t, conns.Get(0).PairID)
+	assert.Equal(t, conns.Get(0).PairID, conns.Get(1).PairID)
+	assert.Equal(t, uint32(20), conns.Get(0).PeerPid)
+	assert.Equal(t, uint32(10), conns.Get(1).PeerPid)
+
+	assert.NotZero(t, conns.Get(2).PairID)
+	assert.NotEqual(t, conns.Get(0).PairID, conns.Get(2).PairID)
+	assert.Equal(t, conns.Get(2).PairID, conns.Get(3).PairID)
+	assert.Equal(t, uint32(20), conns.Get(2).PeerPid)
+	assert.Equal(t, uint32(11), conns.Get(3).PeerPid)
+
+	assert.Zero(t, conns.Get(4).PairID)
+	assert.Zero(t, conns.Get(5).PairID)
+	assert.Zero(t, conns.Get(5).PeerPid)
+}
+
+func TestNATHairpinIntraHost(t *testing.T) {
+	ns := networkState{}
+	// the public address of the service is translated to a local workload
+	hairpin := CreateConnectionStat("10.0.25.1", "3.3.3.3", 59782, 443, TCP)
+	hairpin.Direction, hairpin.NATHairpin = OUTGOING, true
+	AddIPTranslationToConnection(&hairpin, "10.0.25.2", "10.0.25.1", 8443, 59782)
+	server := CreateConnectionStat("10.0.25.2", "10.0.25.1", 8443, 59782, TCP)
+	server.Direction = INCOMING
+	// the translated destination of this one is remote
+	remote := CreateConnectionStat("10.0.25.1", "3.3.3.4", 59783, 443, TCP)
+	remote.Direction, remote.NATHairpin = OUTGOING, true
+	AddIPTranslationToConnection(&remote, "10.1.0.1", "10.0.25.1", 8443, 59783)
+
+	conns := slice.NewChain([]ConnectionStats{hairpin, server, remote})
+	ns.determineConnectionIntraHost(conns)
+	assert.True(t, conns.Get(0).NATHairpin)
+	assert.False(t, conns.Get(1).NATHairpin)
+	assert.False(t, conns.Get(2).NATHairpin)
+}
@@ -54,3 +54,55 @@ func TestStatsOverflow(t *testing.T) {
 	assert.Equal(t, uint64(12), conns[0].Last.SentPackets)
 	assert.Equal(t, uint64(14), conns[0].Last.RecvPackets)
 }
+
+func TestEphemeralPortRollup(t *testing.T) {
+	low, high := EphemeralRange()
+	if low == 0 || high <= low+2 {
+		t.Skip("ephemeral port range is not available")
+	}
+
+	outgoing := func(sport uint16, cookie StatCookie) ConnectionStats {
+		return ConnectionStats{
+			Pid:       1,
+			Source:    util.AddressFromString("10.0.0.1"),
+			SPort:     sport,
+			Dest:      util.AddressFromString("10.0.0.2"),
+			DPort:     443,
+			Family:    AFINET,
+			Type:      TCP,
+			Direction: OUTGOING,
+			Monotonic: StatCounters{SentBytes: 10, RecvBytes: 20},
+			Cookie:    cookie,
+		}
+	}
+	conns := []ConnectionStats{
+		outgoing(low, 1),
+		outgoing(low+1, 2),
+		outgoing(low+2, 3),
+		// the client port is not ephemeral, so the connection is not rolled up
+		outgoing(low-1, 4),
+	}
+
+	ns := newDefaultState()
+	ns.enableEphemeralPortRollup = true
+	ns.RegisterClient("foo")
+	delta := ns.GetDelta("foo", 0, conns, nil, nil)
+	require.Len(t, delta.Conns, 2)
+
+	var rolledUp, single *ConnectionStats
+	for i := range delta.Conns {
+		if delta.Conns[i].SPort == 0 {
+			rolledUp = &delta.Conns[i]
+		} else {
+			single = &delta.Conns[i]
+		}
+	}
+	require.NotNil(t, rolledUp)
+	require.NotNil(t, single)
+	assert.Equal(t, uint32(3), rolledUp.AggregatedConnections)
+	assert.Equal(t, uint64(30), rolledUp.Monotonic.SentBytes)
+	assert.Equal(t, uint64(60), rolledUp.Monotonic.RecvBytes)
+	assert.Equal(t, uint16(443), rolledUp.DPort)
+	assert.Zero(t, single.AggregatedConnections)
+	assert.Equal(t, low-1, single.SPort)
+}
@@ -23,10 +23,15 @@ import (
 	"go4.org/intern"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	networkconfig "github.com/DataDog/datadog-agent/pkg/network/config"
 	"github.com/DataDog/datadog-agent/pkg/network/dns"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/amqp"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mongo"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mysql"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/redis"
 	"github.com/DataDog/datadog-agent/pkg/network/slice"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 )
@@ -262,25 +267,28 @@ func TestDropEmptyConnections(t *testing.T) {
 		assert.False(t, ok)
 
 	})
-	t.Run("drop incoming connection when conns full", func(t *testing.T) {
-		// drop incoming non-empty conn when conns is full
+	t.Run("drop oldest connection when conns full", func(t *testing.T) {
+		// drop the oldest non-empty conns when conns is full
 		state := newDefaultState()
-		state.maxClosedConns = 1
+		state.maxClosedConns = 2
 		state.RegisterClient(clientID)
 
-		state.storeClosedConnections([]ConnectionStats{conn})
-
-		conn2 := conn
+		conn2, conn3, conn4 := conn, conn, conn
 		conn2.Cookie = 2
-		state.storeClosedConnections([]ConnectionStats{conn2})
-
-		conns := state.clients[clientID].closed.conns
-		_, ok := state.clients[clientID].closed.byCookie[2]
+		conn3.Cookie = 3
+		conn4.Cookie = 4
+		state.storeClosedConnections([]ConnectionStats{conn, conn2})
+		state.storeClosedConnections([]ConnectionStats{conn3})
 
-		assert.Equal(t, 1, len(conns))
-		assert.Equal(t, []ConnectionStats{conn}, conns)
+		closed := state.clients[clientID].closed
+		assert.Equal(t, []ConnectionStats{conn3, conn2}, closed.conns)
+		_, ok := closed.byCookie[1]
 		assert.False(t, ok)
 
+		state.storeClosedConnections([]ConnectionStats{conn4})
+		assert.Equal(t, []ConnectionStats{conn3, conn4}, closed.conns)
+		assert.Equal(t, map[StatCookie]int{3: 0, 4: 1}, closed.byCookie)
+		assert.Equal(t, int64(2), closed.overflows)
 	})
 	t.Run("replace empty connection with non-empty", func(t *testing.T) {
 		state := newDefaultState()
@@ -544,7 +552,9 @@ func TestNoPriorRegistrationActiveConnections(t *testing.T) {
 func TestCleanupClient(t *testing.T) {
 	clientID := "1"
 
-	state := NewState(100*time.Millisecond, 50000, 75000, 75000, 7500, 75000, 75000, false, false)
+	cfg := newTestConfig()
+	cfg.ClientStateExpiry = 100 * time.Millisecond
+	state := NewState(cfg)
 	clients := state.(*networkState).getClients()
 	assert.Equal(t, 0, len(clients))
 
@@ -564,6 +574,56 @@ func TestCleanupClient(t *testing.T) {
 	assert.Equal(t, 0, len(clients))
 }
 
+func TestStoreClosedConnectionsExpiresClients(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.ClientStateExpiry = 100 * time.Millisecond
+	state := NewState(cfg).(*networkState)
+	state.RegisterClient("1")
+	state.RegisterClient("2")
+
+	// client 1 stopped fetching connections
+	state.clients["1"].lastFetch = time.Now().Add(-time.Second)
+
+	conn := ConnectionStats{
+		Pid:       123,
+		Type:      TCP,
+		Family:    AFINET,
+		Source:    util.AddressFromString("127.0.0.1"),
+		Dest:      util.AddressFromString("127.0.0.1"),
+		SPort:     9000,
+		DPort:     1234,
+		Monotonic: StatCounters{SentBytes: 1},
+	}
+	state.StoreClosedConnections([]ConnectionStats{conn})
+
+	assert.Equal(t, []string{"2"}, state.getClients())
+	assert.Len(t, state.clients["2"].closed.conns, 1)
+}
+
+func TestExpireClientsMissingIntervals(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.MaxClientMissedIntervals = 4
+	state := NewState(cfg).(*networkState)
+	state.RegisterClient("1")
+	state.RegisterClient("2")
+	now := time.Now()
+
+	// client 1 fetches connections every 10s and client 2 every minute
+	state.clients["1"].interval = 10 * time.Second
+	state.clients["2"].interval = time.Minute
+	state.clients["1"].lastFetch = now.Add(-30 * time.Second)
+	state.clients["2"].lastFetch = now.Add(-30 * time.Second)
+	state.RemoveExpiredClients(now)
+	assert.ElementsMatch(t, []string{"1", "2"}, state.getClients())
+
+	// client 1 missed 4 intervals, client 2 is still within the expiry
+	state.RemoveExpiredClients(now.Add(11 * time.Second))
+	assert.Equal(t, []string{"2"}, state.getClients())
+
+	state.RemoveExpiredClients(now.Add(91 * time.Second))
+	assert.Empty(t, state.getClients())
+}
+
 func TestLastStats(t *testing.T) {
 	client1 := "1"
 	client2 := "2"
@@ -713,6 +773,143 @@ func TestLastStatsForClosedConnection(t *testing.T) {
 	assert.Equal(t, conn2.Monotonic.Retransmits, conns[0].Monotonic.Retransmits)
 }
 
+func TestLateClosedConnection(t *testing.T) {
+	clientID := "1"
+	state := newDefaultState()
+
+	conn := ConnectionStats{
+		Pid:       123,
+		Type:      TCP,
+		Family:    AFINET,
+		Source:    util.AddressFromString("127.0.0.1"),
+		Dest:      util.AddressFromString("127.0.0.1"),
+		SPort:     31890,
+		DPort:     80,
+		Monotonic: StatCounters{SentBytes: 36, RecvBytes: 24},
+		Cookie:    1,
+	}
+	closedConn := conn
+	closedConn.Monotonic.SentBytes += 10
+	closedConn.Monotonic.TCPClosed = 1
+
+	state.RegisterClient(clientID)
+	conns := state.GetDelta(clientID, latestEpochTime(), []ConnectionStats{conn}, nil, nil).Conns
+	require.Len(t, conns, 1)
+
+	// the connection left the eBPF map, but its close event was not processed yet
+	conns = state.GetDelta(clientID, latestEpochTime(), nil, nil, nil).Conns
+	assert.Empty(t, conns)
+
+	// only the traffic which wasn't reported yet is accounted for
+	state.StoreClosedConnections([]ConnectionStats{closedConn})
+	conns = state.GetDelta(clientID, latestEpochTime(), nil, nil, nil).Conns
+	require.Len(t, conns, 1)
+	assert.Equal(t, uint64(10), conns[0].Last.SentBytes)
+	assert.Equal(t, uint64(0), conns[0].Last.RecvBytes)
+	assert.Empty(t, state.clients[clientID].stats)
+	assert.Empty(t, state.clients[clientID].closingStats)
+
+	// the stats are only kept for one check
+	state.GetDelta(clientID, latestEpochTime(), []ConnectionStats{conn}, nil, nil)
+	state.GetDelta(clientID, latestEpochTime(), nil, nil, nil)
+	assert.Len(t, state.clients[clientID].closingStats, 1)
+	state.GetDelta(clientID, latestEpochTime(), nil, nil, nil)
+	assert.Empty(t, state.clients[clientID].closingStats)
+	assert.Empty(t, state.clients[clientID].stats)
+}
+
+func TestConnectionChurn(t *testing.T) {
+	clientID := "1"
+	state := newDefaultState()
+
+	conn := ConnectionStats{
+		Pid:       123,
+		Type:      TCP,
+		Family:    AFINET,
+		Source:    util.AddressFromString("127.0.0.1"),
+		Dest:      util.AddressFromString("127.0.0.1"),
+		SPort:     31890,
+		DPort:     80,
+		Monotonic: StatCounters{SentBytes: 36},
+		Cookie:    1,
+	}
+	shortLived := conn
+	shortLived.SPort = 31891
+	shortLived.Cookie = 2
+	shortLived.Monotonic.TCPClosed = 1
+	other := conn
+	other.Pid = 456
+	other.SPort = 31892
+	other.Cookie = 3
+	other.ContainerID.Source = intern.GetByString("container")
+
+	state.RegisterClient(clientID)
+	// all the connections are new on the first check
+	delta := state.GetDelta(clientID, latestEpochTime(), []ConnectionStats{conn}, nil, nil)
+	assert.Empty(t, delta.Churn)
+
+	conn.Monotonic.SentBytes++
+	state.StoreClosedConnections([]ConnectionStats{shortLived})
+	delta = state.GetDelta(clientID, latestEpochTime(), []ConnectionStats{conn, other}, nil, nil)
+	require.Len(t, delta.Churn, 2)
+	churns := make(map[uint32]ConnectionChurn)
+	for _, churn := range delta.Churn {
+		churns[churn.Pid] = churn
+	}
+	assert.Equal(t, uint32(1), churns[123].Created)
+	assert.Equal(t, uint32(1), churns[123].Closed)
+	assert.Greater(t, churns[123].CreatedPerSecond, float64(0))
+	assert.Equal(t, uint32(1), churns[456].Created)
+	assert.Equal(t, uint32(0), churns[456].Closed)
+	assert.Equal(t, "container", churns[456].ContainerID)
+
+	// closing a known connection doesn't count it as created
+	conn.Monotonic.TCPClosed = 1
+	state.StoreClosedConnections([]ConnectionStats{conn})
+	delta = state.GetDelta(clientID, latestEpochTime(), []ConnectionStats{other}, nil, nil)
+	require.Len(t, delta.Churn, 1)
+	assert.Equal(t, ConnectionChurn{Pid: 123, Closed: 1, ClosedPerSecond: delta.Churn[0].ClosedPerSecond}, delta.Churn[0])
+}
+
+func TestClosedConnBufferOverflows(t *testing.T) {
+	state := newDefaultState()
+	state.maxClosedConns = 1
+	state.RegisterClient("1")
+	state.RegisterClient("2")
+
+	conn := ConnectionStats{
+		Pid:       123,
+		Type:      TCP,
+		Family:    AFINET,
+		Source:    util.AddressFromString("127.0.0.1"),
+		Dest:      util.AddressFromString("127.0.0.1"),
+		SPort:     31890,
+		DPort:     80,
+		Monotonic: StatCounters{SentBytes: 36},
+		Cookie:    1,
+	}
+	conn2 := conn
+	conn2.SPort++
+	conn2.Cookie = 2
+	conn3 := conn
+	conn3.SPort += 2
+	conn3.Cookie = 3
+
+	state.StoreClosedConnections([]ConnectionStats{conn, conn2, conn3})
+	// the buffer of the first client is emptied
+	state.GetDelta("1", latestEpochTime(), nil, nil, nil)
+	state.StoreClosedConnections([]ConnectionStats{conn2})
+
+	tel := state.GetTelemetryDelta("1", buildBasicTelemetry())
+	assert.Equal(t, int64(2), tel[ClosedConnBufferOverflows])
+	tel = state.GetTelemetryDelta("2", buildBasicTelemetry())
+	assert.Equal(t, int64(3), tel[ClosedConnBufferOverflows])
+
+	// the overflows are only reported once
+	tel = state.GetTelemetryDelta("1", buildBasicTelemetry())
+	assert.NotContains(t, tel, ClosedConnBufferOverflows)
+}
+
 func TestRaceConditions(t *testing.T) { //nolint:revive // TODO fix revive unused-parameter
 	nClients := 10
 
@@ -2100,6 +2297,34 @@ func TestIntraHostFixDirection(t *testing.T) {
 			},
 			direction: INCOMING,
 		},
+		{
+			name: "incoming udp non ephemeral to ephemeral with observed direction",
+			conn: ConnectionStats{
+				Type:                UDP,
+				IntraHost:           true,
+				Source:              util.AddressFromString("1.1.1.1"),
+				Dest:                util.AddressFromString("1.1.1.1"),
+				SPort:               49612,
+				DPort:               123,
+				Direction:           INCOMING,
+				IsDirectionObserved: true,
+			},
+			direction: INCOMING,
+		},
+		{
+			name: "outgoing udp non ephemeral to ephemeral with observed direction",
+			conn: ConnectionStats{
+				Type:                UDP,
+				IntraHost:           true,
+				Source:              util.AddressFromString("1.1.1.1"),
+				Dest:                util.AddressFromString("1.1.1.1"),
+				SPort:               123,
+				DPort:               49612,
+				Direction:           OUTGOING,
+				IsDirectionObserved: true,
+			},
+			direction: OUTGOING,
+		},
 		{
 			name: "incoming tcp non ephemeral to ephemeral",
 			conn: ConnectionStats{
@@ -2255,6 +2480,48 @@ func TestClosedMergingWithAddressCollision(t *testing.T) {
 
 }
 
+func TestTCPFailedConnections(t *testing.T) {
+	const client = "foo"
+
+	failed := ConnectionStats{
+		Pid:         123,
+		Type:        TCP,
+		Family:      AFINET,
+		Source:      util.AddressFromString("10.0.0.1"),
+		Dest:        util.AddressFromString("10.0.0.2"),
+		SPort:       31890,
+		DPort:       80,
+		Cookie:      1,
+		TCPFailures: map[TCPFailure]uint32{TCPFailureRefused: 1},
+	}
+
+	t.Run("failed connections are not dropped as empty", func(t *testing.T) {
+		state := newDefaultState()
+		state.maxClosedConns = 1
+		state.RegisterClient(client)
+
+		state.storeClosedConnections([]ConnectionStats{failed})
+		state.storeClosedConnections([]ConnectionStats{{Cookie: 2}})
+
+		conns := state.clients[client].closed.conns
+		require.Len(t, conns, 1)
+		assert.Equal(t, failed, conns[0])
+	})
+
+	t.Run("same connection reported twice", func(t *testing.T) {
+		state := newDefaultState()
+		state.RegisterClient(client)
+
+		dup := failed
+		dup.TCPFailures = map[TCPFailure]uint32{TCPFailureRefused: 1}
+		state.storeClosedConnections([]ConnectionStats{failed, dup})
+
+		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		require.Len(t, conns, 1)
+		assert.Equal(t, map[TCPFailure]uint32{TCPFailureRefused: 1}, conns[0].TCPFailures)
+	})
+}
+
 func TestKafkaStats(t *testing.T) {
 	c := ConnectionStats{
 		Source: util.AddressFromString("1.1.1.1"),
@@ -2345,6 +2612,48 @@ func TestKafkaStatsWithMultipleClients(t *testing.T) {
 	assert.Len(t, delta.Kafka, 2)
 }
 
+func TestDebugOnlyProtocolStats(t *testing.T) {
+	c := ConnectionStats{
+		Source: util.AddressFromString("1.1.1.1"),
+		Dest:   util.AddressFromString("0.0.0.0"),
+		SPort:  1000,
+		DPort:  3306,
+	}
+
+	getStats := func() map[protocols.ProtocolType]interface{} {
+		return map[protocols.ProtocolType]interface{}{
+			protocols.MySQL: map[mysql.Key]*mysql.RequestStat{
+				mysql.NewKey(c.Source, c.Dest, c.SPort, c.DPort, mysql.SelectOP, "SELECT * FROM t"): {Count: 2},
+			},
+			protocols.Redis: map[redis.Key]*redis.RequestStat{
+				redis.NewKey(c.Source, c.Dest, c.SPort, c.DPort, "GET"): {Count: 1},
+			},
+			protocols.Mongo: map[mongo.Key]*mongo.RequestStat{
+				mongo.NewKey(c.Source, c.Dest, c.SPort, c.DPort, "find", "users"): {Count: 3},
+			},
+			protocols.AMQP: map[amqp.Key]*amqp.RequestStat{
+				amqp.NewKey(c.Source, c.Dest, c.SPort, c.DPort, amqp.PublishMethod, "", "tasks"): {Count: 4, BodyBytes: 100},
+			},
+		}
+	}
+
+	// the stats without a message in the payload are only kept for the debug client
+	state := newDefaultState()
+	state.RegisterClient(DEBUGCLIENT)
+	delta := state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, getStats())
+	assert.Empty(t, delta.MySQL)
+	assert.Empty(t, delta.Redis)
+	assert.Empty(t, delta.Mongo)
+	assert.Empty(t, delta.AMQP)
+
+	delta = state.GetDelta(DEBUGCLIENT, latestEpochTime(), []ConnectionStats{c}, nil, getStats())
+	assert.Len(t, delta.MySQL, 1)
+	assert.Equal(t, 4, delta.MySQL[mysql.NewKey(c.Source, c.Dest, c.SPort, c.DPort, mysql.SelectOP, "SELECT * FROM t")].Count)
+	assert.Equal(t, 2, delta.Redis[redis.NewKey(c.Source, c.Dest, c.SPort, c.DPort, "GET")].Count)
+	assert.Equal(t, 6, delta.Mongo[mongo.NewKey(c.Source, c.Dest, c.SPort, c.DPort, "find", "users")].Count)
+	assert.Equal(t, &amqp.RequestStat{Count: 8, BodyBytes: 200}, delta.AMQP[amqp.NewKey(c.Source, c.Dest, c.SPort, c.DPort, amqp.PublishMethod, "", "tasks")])
+}
+
 func TestConnectionRollup(t *testing.T) {
 	conns := []ConnectionStats{
 		{
@@ -2820,6 +3129,52 @@ func TestDNSPIDCollision(t *testing.T) {
 	assert.Empty(t, delta.Conns[1].DNSStats, "dns stats should not be empty")
 }
 
+func TestDNSStatsOnMonitoringPort(t *testing.T) {
+	conn := ConnectionStats{
+		Source:    util.AddressFromString("10.1.1.1"),
+		Dest:      util.AddressFromString("10.1.1.2"),
+		Pid:       1,
+		SPort:     1000,
+		DPort:     5353,
+		Type:      UDP,
+		Family:    AFINET,
+		Direction: OUTGOING,
+		Cookie:    1,
+		Monotonic: StatCounters{
+			RecvBytes: 2,
+		},
+	}
+	newDNSStats := func() dns.StatsByKeyByNameByType {
+		return dns.StatsByKeyByNameByType{
+			dns.Key{
+				ClientIP:   util.AddressFromString("10.1.1.1"),
+				ServerIP:   util.AddressFromString("10.1.1.2"),
+				ClientPort: uint16(1000),
+				Protocol:   syscall.IPPROTO_UDP,
+			}: map[dns.Hostname]map[dns.QueryType]dns.Stats{
+				dns.ToHostname("foo.com"): {
+					dns.TypeA: {CountByRcode: map[uint32]uint32{0: 1}},
+				},
+			},
+		}
+	}
+
+	config.SystemProbe.SetWithoutSource("system_probe_config.collect_dns_domains", true)
+	config.SystemProbe.SetWithoutSource("network_config.enable_dns_by_querytype", false)
+
+	state := newDefaultState()
+	state.RegisterClient("foo")
+	delta := state.GetDelta("foo", 0, []ConnectionStats{conn}, newDNSStats(), nil)
+	assert.Empty(t, delta.Conns[0].DNSStats)
+
+	cfg := newTestConfig()
+	cfg.DNSMonitoringPorts = []uint16{53, 5353}
+	state = NewState(cfg).(*networkState)
+	state.RegisterClient("foo")
+	delta = state.GetDelta("foo", 0, []ConnectionStats{conn}, newDNSStats(), nil)
+	assert.NotEmpty(t, delta.Conns[0].DNSStats)
+}
+
 func generateRandConnections(n int) []ConnectionStats {
 	cs := make([]ConnectionStats, 0, n)
 	for i := 0; i < n; i++ {
@@ -2847,9 +3202,25 @@ func latestEpochTime() uint64 {
 	return latestTime.Inc()
 }
 
-func newDefaultState() *networkState {
+func newTestConfig() *networkconfig.Config {
 	// Using values from ebpf.NewConfig()
-	return NewState(2*time.Minute, 50000, 75000, 75000, 7500, 7500, 7500, false, false).(*networkState)
+	return &networkconfig.Config{
+		ClientStateExpiry:            2 * time.Minute,
+		MaxClosedConnectionsBuffered: 50000,
+		MaxConnectionsStateBuffered:  75000,
+		MaxDNSStatsBuffered:          75000,
+		MaxHTTPStatsBuffered:         7500,
+		MaxKafkaStatsBuffered:        7500,
+		MaxPostgresStatsBuffered:     7500,
+		MaxMySQLStatsBuffered:        7500,
+		MaxRedisStatsBuffered:        7500,
+		MaxMongoStatsBuffered:        7500,
+		MaxAMQPStatsBuffered:         7500,
+	}
+}
+
+func newDefaultState() *networkState {
+	return NewState(newTestConfig()).(*networkState)
 }
 
 func getIPProtocol(nt ConnectionType) uint8 {
@@ -2862,3 +3233,29 @@ func getIPProtocol(nt ConnectionType) uint8 {
 		panic("unknown connection type")
 	}
 }
+
+func TestStoreResolverLatencies(t *testing.T) {
+	state := newDefaultState()
+	state.RegisterClient("c1")
+	state.RegisterClient("c2")
+
+	server := util.AddressFromString("8.8.8.8")
+	latencies := make(dns.LatenciesByResolver)
+	latencies.Add(server, 1000, true)
+	latencies.Add(server, 3000, false)
+	state.StoreResolverLatencies(latencies)
+
+	latencies = make(dns.LatenciesByResolver)
+	latencies.Add(server, 2000, true)
+	state.StoreResolverLatencies(latencies)
+
+	delta := state.GetDelta("c1", latestEpochTime(), nil, nil, nil)
+	require.Contains(t, delta.ResolverLatencies, server)
+	assert.Equal(t, 2.0, delta.ResolverLatencies[server].Success.GetCount())
+	assert.Equal(t, 1.0, delta.ResolverLatencies[server].Failure.GetCount())
+
+	// the latencies are returned once to each client
+	assert.Empty(t, state.GetDelta("c1", latestEpochTime(), nil, nil, nil).ResolverLatencies)
+	delta = state.GetDelta("c2", latestEpochTime(), nil, nil, nil)
+	assert.Equal(t, 2.0, delta.ResolverLatencies[server].Success.GetCount())
+}
@@ -0,0 +1,92 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"go4.org/intern"
+)
+
+// TLSVersionFlags is the set of the TLS versions offered by a client
+type TLSVersionFlags uint8
+
+// The TLS versions which may be offered by a client, must match tls_version_offered_t
+const (
+	TLSVersion10Offered TLSVersionFlags = 1 << iota
+	TLSVersion11Offered
+	TLSVersion12Offered
+	TLSVersion13Offered
+)
+
+// TLSInfo is the metadata of the TLS handshake of a connection, read from its ClientHello and ServerHello
+type TLSInfo struct {
+	// ServerName is the SNI sent by the client
+	ServerName *intern.Value
+	// ALPN is the application protocol selected by the server, or the first one offered by the client
+	// when the selection is encrypted (TLS 1.3)
+	ALPN *intern.Value
+	// Version is the negotiated version, e.g. tls.VersionTLS12, or 0 if the ServerHello wasn't seen
+	Version uint16
+	// CipherSuite is the negotiated cipher suite, e.g. tls.TLS_AES_128_GCM_SHA256
+	CipherSuite     uint16
+	OfferedVersions TLSVersionFlags
+}
+
+// IsEmpty returns true if no hello was read for the connection
+func (i *TLSInfo) IsEmpty() bool {
+	return i.ServerName == nil && i.ALPN == nil && i.Version == 0 && i.CipherSuite == 0 && i.OfferedVersions == 0
+}
+
+// IsDeprecatedVersion returns true if the negotiated version is older than TLS 1.2
+func (i *TLSInfo) IsDeprecatedVersion() bool {
+	return i.Version != 0 && i.Version < tls.VersionTLS12
+}
+
+// TLSVersionName returns the name of the given TLS version, as used in the tags of the connections
+func TLSVersionName(version uint16) string {
+	switch version {
+	case 0x0200:
+		return "ssl_2.0"
+	case tls.VersionSSL30: //nolint:staticcheck // SSL 3.0 is still seen on the wire
+		return "ssl_3.0"
+	case tls.VersionTLS10:
+		return "tls_1.0"
+	case tls.VersionTLS11:
+		return "tls_1.1"
+	case tls.VersionTLS12:
+		return "tls_1.2"
+	case tls.VersionTLS13:
+		return "tls_1.3"
+	default:
+		return fmt.Sprintf("0x%04x", version)
+	}
+}
+
+// GetTLSTags returns the tags describing the TLS handshake of the connection
+func GetTLSTags(c *ConnectionStats) []string {
+	info := &c.TLSInfo
+	if info.IsEmpty() {
+		return nil
+	}
+
+	tags := make([]string, 0, 4)
+	if info.Version != 0 {
+		tags = append(tags, "tls.version:"+TLSVersionName(info.Version))
+	}
+	if info.CipherSuite != 0 {
+		// the unknown suites are named after their id by the standard library
+		tags = append(tags, "tls.cipher_suite:"+tls.CipherSuiteName(info.CipherSuite))
+	}
+	if info.ServerName != nil {
+		tags = append(tags, "tls.server_name:"+info.ServerName.Get().(string))
+	}
+	if info.ALPN != nil {
+		tags = append(tags, "tls.alpn:"+info.ALPN.Get().(string))
+	}
+	return tags
+}
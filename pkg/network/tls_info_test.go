@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go4.org/intern"
+)
+
+func TestGetTLSTags(t *testing.T) {
+	c := ConnectionStats{Type: TCP}
+	assert.Empty(t, GetTLSTags(&c))
+
+	c.TLSInfo = TLSInfo{
+		ServerName:      intern.GetByString("api.example.com"),
+		ALPN:            intern.GetByString("h2"),
+		Version:         tls.VersionTLS12,
+		CipherSuite:     tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		OfferedVersions: TLSVersion12Offered | TLSVersion13Offered,
+	}
+	assert.ElementsMatch(t, []string{
+		"tls.version:tls_1.2",
+		"tls.cipher_suite:TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+		"tls.server_name:api.example.com",
+		"tls.alpn:h2",
+	}, GetTLSTags(&c))
+	assert.False(t, c.TLSInfo.IsDeprecatedVersion())
+
+	// only the ClientHello was seen
+	c.TLSInfo = TLSInfo{ServerName: intern.GetByString("legacy.example.com"), OfferedVersions: TLSVersion10Offered}
+	assert.Equal(t, []string{"tls.server_name:legacy.example.com"}, GetTLSTags(&c))
+	assert.False(t, c.TLSInfo.IsDeprecatedVersion())
+}
+
+func TestTLSVersionName(t *testing.T) {
+	assert.Equal(t, "tls_1.0", TLSVersionName(tls.VersionTLS10))
+	assert.Equal(t, "tls_1.3", TLSVersionName(tls.VersionTLS13))
+	assert.Equal(t, "0x7f1c", TLSVersionName(0x7f1c))
+
+	info := TLSInfo{Version: tls.VersionTLS11}
+	assert.True(t, info.IsDeprecatedVersion())
+}
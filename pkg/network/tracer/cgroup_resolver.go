@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package tracer
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go4.org/intern"
+
+	"github.com/DataDog/datadog-agent/pkg/util/cgroups"
+)
+
+const (
+	// cgroupRefreshInterval bounds how often the cgroup hierarchy is walked
+	// again when the cgroup of an aggregated connection is unknown
+	cgroupRefreshInterval = 10 * time.Second
+)
+
+// cgroupResolver resolves the cgroup v2 ids reported by the eBPF
+// programs, which are the inode numbers of the cgroup directories,
+// to container IDs
+type cgroupResolver struct {
+	mu          sync.Mutex
+	reader      *cgroups.Reader
+	lastRefresh time.Time
+}
+
+func newCgroupResolver(procRoot string) (*cgroupResolver, error) {
+	var hostPrefix string
+	if strings.HasPrefix(procRoot, "/host") {
+		hostPrefix = "/host"
+	}
+
+	reader, err := cgroups.NewReader(
+		cgroups.WithProcPath(procRoot),
+		cgroups.WithHostPrefix(hostPrefix),
+		cgroups.WithReaderFilter(cgroups.ContainerFilter),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not create cgroup reader: %w", err)
+	}
+	if reader.CgroupVersion() != 2 {
+		return nil, fmt.Errorf("cgroup ids can only be resolved on cgroup v2")
+	}
+
+	return &cgroupResolver{reader: reader}, nil
+}
+
+// containerID returns the ID of the container owning the given cgroup, or nil
+// if the cgroup doesn't belong to a container
+func (r *cgroupResolver) containerID(cgroupID uint64) *intern.Value {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cg := r.reader.GetCgroupByInode(cgroupID)
+	if cg == nil && time.Since(r.lastRefresh) > cgroupRefreshInterval {
+		r.lastRefresh = time.Now()
+		if err := r.reader.RefreshCgroups(0); err == nil {
+			cg = r.reader.GetCgroupByInode(cgroupID)
+		}
+	}
+	if cg == nil || cg.Identifier() == "" {
+		return nil
+	}
+
+	return intern.GetByString(cg.Identifier())
+}
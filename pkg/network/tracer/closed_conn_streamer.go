@@ -0,0 +1,120 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf || (windows && npm)
+
+package tracer
+
+import (
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+)
+
+const closedConnStreamerModuleName = "network_tracer__closed_conn_streamer"
+
+var closedConnStreamerTelemetry = struct {
+	subscribers    telemetry.Gauge
+	batchesSent    telemetry.Counter
+	batchesDropped telemetry.Counter
+}{
+	telemetry.NewGauge(closedConnStreamerModuleName, "subscribers", []string{}, "Gauge measuring the number of closed connection stream subscribers"),
+	telemetry.NewCounter(closedConnStreamerModuleName, "batches_sent", []string{}, "Counter measuring the number of closed connection batches sent to subscribers"),
+	telemetry.NewCounter(closedConnStreamerModuleName, "batches_dropped", []string{}, "Counter measuring the number of closed connection batches dropped because a subscriber was too slow"),
+}
+
+// closedConnStreamer fans out closed connections, as they are
+// observed by the tracer, to any number of subscribers
+type closedConnStreamer struct {
+	mu     sync.Mutex
+	subs   map[uint64]chan []network.ConnectionStats
+	nextID uint64
+	closed bool
+}
+
+func newClosedConnStreamer() *closedConnStreamer {
+	return &closedConnStreamer{
+		subs: make(map[uint64]chan []network.ConnectionStats),
+	}
+}
+
+// subscribe returns a channel receiving batches of closed connections, along with
+// a function that must be called to cancel the subscription. Up to bufferSize
+// batches are buffered for the subscriber; further batches are dropped until
+// the subscriber catches up.
+func (s *closedConnStreamer) subscribe(bufferSize int) (<-chan []network.ConnectionStats, func()) {
+	if bufferSize <= 0 {
+		bufferSize = 1
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := make(chan []network.ConnectionStats, bufferSize)
+	if s.closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	id := s.nextID
+	s.nextID++
+	s.subs[id] = ch
+	closedConnStreamerTelemetry.subscribers.Set(float64(len(s.subs)))
+
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if ch, ok := s.subs[id]; ok {
+			delete(s.subs, id)
+			close(ch)
+			closedConnStreamerTelemetry.subscribers.Set(float64(len(s.subs)))
+		}
+	}
+}
+
+// publish sends a copy of the given connections to all subscribers.
+// It never blocks, so it is safe to call from the closed connection
+// callback of the tracer.
+func (s *closedConnStreamer) publish(conns []network.ConnectionStats) {
+	if len(conns) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.subs) == 0 {
+		return
+	}
+
+	// the given slice is backed by a buffer that is reused by the tracer,
+	// and subscribers only get to read the batch, so a single copy is enough
+	batch := make([]network.ConnectionStats, len(conns))
+	copy(batch, conns)
+
+	for _, ch := range s.subs {
+		select {
+		case ch <- batch:
+			closedConnStreamerTelemetry.batchesSent.Inc()
+		default:
+			closedConnStreamerTelemetry.batchesDropped.Inc()
+		}
+	}
+}
+
+// close terminates all subscriptions
+func (s *closedConnStreamer) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, ch := range s.subs {
+		delete(s.subs, id)
+		close(ch)
+	}
+	s.closed = true
+	closedConnStreamerTelemetry.subscribers.Set(0)
+}
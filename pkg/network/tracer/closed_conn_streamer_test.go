@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package tracer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+)
+
+func TestClosedConnStreamer(t *testing.T) {
+	t.Run("publish without subscribers", func(t *testing.T) {
+		s := newClosedConnStreamer()
+		s.publish([]network.ConnectionStats{{Pid: 1}})
+	})
+
+	t.Run("batches are copied", func(t *testing.T) {
+		s := newClosedConnStreamer()
+		ch, cancel := s.subscribe(1)
+		defer cancel()
+
+		conns := []network.ConnectionStats{{Pid: 1}, {Pid: 2}}
+		s.publish(conns)
+		conns[0].Pid = 42
+
+		batch := <-ch
+		require.Len(t, batch, 2)
+		assert.Equal(t, uint32(1), batch[0].Pid)
+		assert.Equal(t, uint32(2), batch[1].Pid)
+	})
+
+	t.Run("slow subscribers drop batches", func(t *testing.T) {
+		s := newClosedConnStreamer()
+		ch, cancel := s.subscribe(1)
+		defer cancel()
+
+		s.publish([]network.ConnectionStats{{Pid: 1}})
+		s.publish([]network.ConnectionStats{{Pid: 2}})
+
+		batch := <-ch
+		require.Len(t, batch, 1)
+		assert.Equal(t, uint32(1), batch[0].Pid)
+		assert.Empty(t, ch)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		s := newClosedConnStreamer()
+		ch, cancel := s.subscribe(1)
+		cancel()
+		cancel()
+
+		_, ok := <-ch
+		assert.False(t, ok)
+		s.publish([]network.ConnectionStats{{Pid: 1}})
+	})
+
+	t.Run("close", func(t *testing.T) {
+		s := newClosedConnStreamer()
+		ch, cancel := s.subscribe(1)
+		s.close()
+		cancel()
+
+		_, ok := <-ch
+		assert.False(t, ok)
+
+		ch, _ = s.subscribe(1)
+		_, ok = <-ch
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,109 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package connection
+
+import (
+	"net/netip"
+	"slices"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// kernelConnFilters converts the rules of network_config.ignore_conns into the filters of the eBPF
+// programs, along with the rule of each filter, a rule being split into one filter per port range.
+// Nothing is returned if the rules need more than netebpf.MaxConnFilters filters, in which case the
+// connections they match are only ignored in userspace.
+func kernelConnFilters(rules []*network.ConnectionFilter) ([]netebpf.ConnFilter, []string) {
+	var filters []netebpf.ConnFilter
+	var filterRules []string
+	for _, r := range rules {
+		base := netebpf.ConnFilter{Flags: uint8(netebpf.ConnFilterV4 | netebpf.ConnFilterV6)}
+		if r.IP.IsValid() {
+			base.Addr_l, base.Addr_h = util.ToLowHighIP(r.IP.Addr())
+			base.Mask_l, base.Mask_h = util.ToLowHighIP(prefixMask(r.IP))
+			if r.IP.Addr().Is4() {
+				base.Flags = uint8(netebpf.ConnFilterV4)
+			} else {
+				base.Flags = uint8(netebpf.ConnFilterV6)
+			}
+		}
+
+		if r.AllPorts.Any() {
+			f := base
+			f.Flags |= connFilterTransports(r.AllPorts)
+			filters = append(filters, f)
+			filterRules = append(filterRules, r.Rule)
+		}
+		for _, pr := range portRanges(r.Ports) {
+			f := base
+			f.Low_port, f.High_port = pr.low, pr.high
+			f.Flags |= connFilterTransports(pr.types)
+			filters = append(filters, f)
+			filterRules = append(filterRules, r.Rule)
+		}
+	}
+
+	if len(filters) > netebpf.MaxConnFilters {
+		log.Warnf("the rules of network_config.ignore_conns need %d filters, more than the %d supported by the eBPF programs: the connections they match will be ignored in userspace only", len(filters), netebpf.MaxConnFilters)
+		return nil, nil
+	}
+	return filters, filterRules
+}
+
+func connFilterTransports(types network.ConnTypeFilter) uint8 {
+	var flags netebpf.ConnFilterFlags
+	if types.TCP {
+		flags |= netebpf.ConnFilterTCP
+	}
+	if types.UDP {
+		flags |= netebpf.ConnFilterUDP
+	}
+	if types.SCTP {
+		flags |= netebpf.ConnFilterSCTP
+	}
+	return uint8(flags)
+}
+
+// prefixMask returns the network mask of the prefix, as an address of the same family
+func prefixMask(p netip.Prefix) netip.Addr {
+	var b [16]byte
+	for i := 0; i < p.Bits(); i++ {
+		b[i/8] |= 0x80 >> (i % 8)
+	}
+	if p.Addr().Is4() {
+		return netip.AddrFrom4([4]byte(b[:4]))
+	}
+	return netip.AddrFrom16(b)
+}
+
+type portRange struct {
+	low, high uint16
+	types     network.ConnTypeFilter
+}
+
+// portRanges groups the consecutive ports matching the same connection types
+func portRanges(ports map[uint16]network.ConnTypeFilter) []portRange {
+	sorted := make([]uint16, 0, len(ports))
+	for p := range ports {
+		sorted = append(sorted, p)
+	}
+	slices.Sort(sorted)
+
+	var ranges []portRange
+	for _, p := range sorted {
+		if n := len(ranges); n > 0 && ranges[n-1].high == p-1 && ranges[n-1].types == ports[p] {
+			ranges[n-1].high = p
+			continue
+		}
+		ranges = append(ranges, portRange{low: p, high: p, types: ports[p]})
+	}
+	return ranges
+}
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package connection
+
+import (
+	"fmt"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestKernelConnFilters(t *testing.T) {
+	filters, rules := kernelConnFilters(network.ParseIgnoreRules([]string{
+		"10.0.0.0/8:9000-9010",
+		"udp://*:123",
+		"[2001:db8::1]",
+	}))
+	require.Len(t, filters, 3)
+	assert.Equal(t, []string{"10.0.0.0/8:9000-9010", "udp://*:123", "[2001:db8::1]"}, rules)
+
+	// the address and the mask are in the representation of the connection tuples
+	addrL, addrH := util.ToLowHighIP(netip.MustParseAddr("10.0.0.0"))
+	maskL, maskH := util.ToLowHighIP(netip.MustParseAddr("255.0.0.0"))
+	assert.Equal(t, netebpf.ConnFilter{
+		Addr_l:    addrL,
+		Addr_h:    addrH,
+		Mask_l:    maskL,
+		Mask_h:    maskH,
+		Low_port:  9000,
+		High_port: 9010,
+		Flags:     uint8(netebpf.ConnFilterV4 | netebpf.ConnFilterTCP | netebpf.ConnFilterUDP | netebpf.ConnFilterSCTP),
+	}, filters[0])
+
+	assert.Equal(t, netebpf.ConnFilter{
+		Low_port:  123,
+		High_port: 123,
+		Flags:     uint8(netebpf.ConnFilterV4 | netebpf.ConnFilterV6 | netebpf.ConnFilterUDP),
+	}, filters[1])
+
+	assert.Equal(t, ^uint64(0), filters[2].Mask_l)
+	assert.Equal(t, ^uint64(0), filters[2].Mask_h)
+	assert.Zero(t, filters[2].Low_port)
+	assert.Equal(t, uint8(netebpf.ConnFilterV6|netebpf.ConnFilterTCP|netebpf.ConnFilterUDP|netebpf.ConnFilterSCTP), filters[2].Flags)
+
+	// too many rules are only applied in userspace
+	var many []string
+	for i := 0; i <= netebpf.MaxConnFilters; i++ {
+		many = append(many, fmt.Sprintf("*:%d", 1000+i))
+	}
+	filters, rules = kernelConnFilters(network.ParseIgnoreRules(many))
+	assert.Empty(t, filters)
+	assert.Empty(t, rules)
+}
+
+func TestPortRanges(t *testing.T) {
+	tcp := network.ConnTypeFilter{TCP: true}
+	udp := network.ConnTypeFilter{UDP: true}
+	assert.Equal(t, []portRange{
+		{low: 10, high: 12, types: tcp},
+		{low: 13, high: 13, types: udp},
+		{low: 20, high: 20, types: tcp},
+	}, portRanges(map[uint16]network.ConnTypeFilter{10: tcp, 11: tcp, 12: tcp, 13: udp, 20: tcp}))
+}
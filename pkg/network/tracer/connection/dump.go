@@ -136,6 +136,121 @@ func dumpMapsHandler(w io.Writer, manager *manager.Manager, mapName string, curr
 			spew.Fdump(w, key, value)
 		}
 
+	case probes.ListeningSocketsMap: // maps/listening_sockets (BPF_MAP_TYPE_HASH), key C.__u64, value ConnTuple
+		io.WriteString(w, "Map: '"+mapName+"', key: 'C.__u64', value: 'ConnTuple'\n")
+		iter := currentMap.Iterate()
+		var key uint64
+		var value ddebpf.ConnTuple
+		for iter.Next(unsafe.Pointer(&key), unsafe.Pointer(&value)) {
+			spew.Fdump(w, key, value)
+		}
+
+	case probes.UnixSockStatsMap: // maps/unix_sock_stats (BPF_MAP_TYPE_HASH), key C.__u64, value UnixSockStats
+		io.WriteString(w, "Map: '"+mapName+"', key: 'C.__u64', value: 'UnixSockStats'\n")
+		iter := currentMap.Iterate()
+		var key uint64
+		var value ddebpf.UnixSockStats
+		for iter.Next(unsafe.Pointer(&key), unsafe.Pointer(&value)) {
+			spew.Fdump(w, key, value)
+		}
+
+	case probes.ListenOverflowsMap: // maps/listen_overflows (BPF_MAP_TYPE_HASH), key C.port_binding_t, value ListenOverflow
+		io.WriteString(w, "Map: '"+mapName+"', key: 'C.port_binding_t', value: 'ListenOverflow'\n")
+		iter := currentMap.Iterate()
+		var key ddebpf.PortBinding
+		var value ddebpf.ListenOverflow
+		for iter.Next(unsafe.Pointer(&key), unsafe.Pointer(&value)) {
+			spew.Fdump(w, key, value)
+		}
+
+	case probes.ConnDropsMap: // maps/conn_drops (BPF_MAP_TYPE_HASH), key ConnTuple, value C.__u32
+		io.WriteString(w, "Map: '"+mapName+"', key: 'ConnTuple', value: 'C.__u32'\n")
+		iter := currentMap.Iterate()
+		var key ddebpf.ConnTuple
+		var value uint32
+		for iter.Next(unsafe.Pointer(&key), unsafe.Pointer(&value)) {
+			spew.Fdump(w, key, value)
+		}
+
+	case probes.ConnQoSMap: // maps/conn_qos (BPF_MAP_TYPE_HASH), key ConnTuple, value ConnQoS
+		io.WriteString(w, "Map: '"+mapName+"', key: 'ConnTuple', value: 'ConnQoS'\n")
+		iter := currentMap.Iterate()
+		var key ddebpf.ConnTuple
+		var value ddebpf.ConnQoS
+		for iter.Next(unsafe.Pointer(&key), unsafe.Pointer(&value)) {
+			spew.Fdump(w, key, value)
+		}
+
+	case probes.ConnProcessMap: // maps/conn_process (BPF_MAP_TYPE_LRU_HASH), key ConnTuple, value ConnProcess
+		io.WriteString(w, "Map: '"+mapName+"', key: 'ConnTuple', value: 'ConnProcess'\n")
+		iter := currentMap.Iterate()
+		var key ddebpf.ConnTuple
+		var value ddebpf.ConnProcess
+		for iter.Next(unsafe.Pointer(&key), unsafe.Pointer(&value)) {
+			spew.Fdump(w, key, value)
+		}
+
+	case probes.TCPRTTHistogramsMap: // maps/tcp_rtt_histograms (BPF_MAP_TYPE_LRU_HASH), key ConnTuple, value RTTHistogram
+		io.WriteString(w, "Map: '"+mapName+"', key: 'ConnTuple', value: 'RTTHistogram'\n")
+		iter := currentMap.Iterate()
+		var key ddebpf.ConnTuple
+		var value ddebpf.RTTHistogram
+		for iter.Next(unsafe.Pointer(&key), unsafe.Pointer(&value)) {
+			spew.Fdump(w, key, value)
+		}
+
+	case probes.ConnFiltersMap: // maps/conn_filters (BPF_MAP_TYPE_ARRAY), key uint32, value ConnFilter
+		io.WriteString(w, "Map: '"+mapName+"', key: 'uint32', value: 'ConnFilter'\n")
+		iter := currentMap.Iterate()
+		var key uint32
+		var value ddebpf.ConnFilter
+		for iter.Next(unsafe.Pointer(&key), unsafe.Pointer(&value)) {
+			spew.Fdump(w, key, value)
+		}
+
+	case probes.IgnoredConnsMap: // maps/ignored_conns (BPF_MAP_TYPE_LRU_HASH), key ConnTuple, value uint32
+		io.WriteString(w, "Map: '"+mapName+"', key: 'ConnTuple', value: 'uint32'\n")
+		iter := currentMap.Iterate()
+		var key ddebpf.ConnTuple
+		var value uint32
+		for iter.Next(unsafe.Pointer(&key), unsafe.Pointer(&value)) {
+			spew.Fdump(w, key, value)
+		}
+
+	case probes.TLSHandshakeInfoMap: // maps/tls_handshake_info (BPF_MAP_TYPE_LRU_HASH), key ConnTuple, value TLSInfo
+		io.WriteString(w, "Map: '"+mapName+"', key: 'ConnTuple', value: 'TLSInfo'\n")
+		iter := currentMap.Iterate()
+		var key ddebpf.ConnTuple
+		var value ddebpf.TLSInfo
+		for iter.Next(unsafe.Pointer(&key), unsafe.Pointer(&value)) {
+			spew.Fdump(w, key, value)
+		}
+
+	case probes.WebSocketSessionsMap: // maps/websocket_sessions (BPF_MAP_TYPE_LRU_HASH), key ConnTuple, value WebSocketSession
+		io.WriteString(w, "Map: '"+mapName+"', key: 'ConnTuple', value: 'WebSocketSession'\n")
+		iter := currentMap.Iterate()
+		var key ddebpf.ConnTuple
+		var value ddebpf.WebSocketSession
+		for iter.Next(unsafe.Pointer(&key), unsafe.Pointer(&value)) {
+			spew.Fdump(w, key, value)
+		}
+
+	case probes.SKBDropsMap: // maps/skb_drops (BPF_MAP_TYPE_HASH or BPF_MAP_TYPE_PERCPU_HASH), key SkbDropKey, value C.__u64
+		io.WriteString(w, "Map: '"+mapName+"', key: 'SkbDropKey', value: 'C.__u64'\n")
+		iter := currentMap.Iterate()
+		var key ddebpf.SkbDropKey
+		if isPerCPUMap(manager, mapName) {
+			var perCPU []uint64
+			for iter.Next(&key, &perCPU) {
+				spew.Fdump(w, key, sumPerCPU(perCPU))
+			}
+			break
+		}
+		var value uint64
+		for iter.Next(unsafe.Pointer(&key), unsafe.Pointer(&value)) {
+			spew.Fdump(w, key, value)
+		}
+
 	case "pending_bind": // maps/pending_bind (BPF_MAP_TYPE_HASH), key C.__u64, value C.bind_syscall_args_t
 		io.WriteString(w, "Map: '"+mapName+"', key: 'C.__u64', value: 'C.bind_syscall_args_t'\n")
 		iter := currentMap.Iterate()
@@ -145,9 +260,26 @@ func dumpMapsHandler(w io.Writer, manager *manager.Manager, mapName string, curr
 			spew.Fdump(w, key, value)
 		}
 
-	case probes.TelemetryMap: // maps/telemetry (BPF_MAP_TYPE_ARRAY), key C.u32, value kernelTelemetry
+	case probes.TracerABIMap: // maps/tracer_abi (BPF_MAP_TYPE_ARRAY), key C.u32, value C.tracer_abi_t
+		io.WriteString(w, "Map: '"+mapName+"', key: 'C.u32', value: 'C.tracer_abi_t'\n")
+		var zero uint32
+		abi := &ddebpf.TracerABI{}
+		if err := currentMap.Lookup(unsafe.Pointer(&zero), unsafe.Pointer(abi)); err != nil {
+			log.Tracef("error retrieving the tracer ABI: %s", err)
+		}
+		spew.Fdump(w, abi)
+
+	case probes.TelemetryMap: // maps/telemetry (BPF_MAP_TYPE_ARRAY or BPF_MAP_TYPE_PERCPU_ARRAY), key C.u32, value kernelTelemetry
 		io.WriteString(w, "Map: '"+mapName+"', key: 'C.u32', value: 'kernelTelemetry'\n")
-		var zero uint64
+		var zero uint32
+		if isPerCPUMap(manager, mapName) {
+			var perCPU []ddebpf.Telemetry
+			if err := currentMap.Lookup(&zero, &perCPU); err != nil {
+				log.Tracef("error retrieving the telemetry struct: %s", err)
+			}
+			spew.Fdump(w, sumTelemetry(perCPU))
+			break
+		}
 		telemetry := &ddebpf.Telemetry{}
 		if err := currentMap.Lookup(unsafe.Pointer(&zero), unsafe.Pointer(telemetry)); err != nil {
 			// This can happen if we haven't initialized the telemetry object yet
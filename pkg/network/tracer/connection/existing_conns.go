@@ -0,0 +1,215 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package connection
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"net/netip"
+
+	"github.com/cilium/ebpf"
+
+	ddebpf "github.com/DataDog/datadog-agent/pkg/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/ebpf/maps"
+	"github.com/DataDog/datadog-agent/pkg/network"
+	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/network/usm/procnet"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// the values of the st column of /proc/net/tcp
+const (
+	procTCPEstablished = 1
+	procTCPSynSent     = 2
+	procTCPSynRecv     = 3
+	procTCPCloseWait   = 8
+	procTCPListen      = 10
+)
+
+type existingConn struct {
+	tuple netebpf.ConnTuple
+	stats netebpf.ConnStats
+}
+
+// seedExistingConnections adds the TCP connections established before the tracer
+// started to the conn_stats map. Otherwise they would only be created on their
+// first traced packet, with the pid of the task running at that time, which isn't
+// always the owner of the socket, and with a direction guessed from the port bindings.
+// The sockets for which the probes already created an entry, whatever its pid, are skipped.
+func seedExistingConnections(conns *maps.GenericMap[netebpf.ConnTuple, netebpf.ConnStats]) {
+	now, err := ddebpf.NowNanoseconds()
+	if err != nil {
+		log.Warnf("could not seed the existing connections: %s", err)
+		return
+	}
+
+	tracked := make(map[netebpf.ConnTuple]struct{})
+	key, stats := new(netebpf.ConnTuple), new(netebpf.ConnStats)
+	entries := conns.Iterate()
+	for entries.Next(key, stats) {
+		tuple := *key
+		tuple.Pid = 0
+		tracked[tuple] = struct{}{}
+	}
+	if err := entries.Err(); err != nil {
+		log.Warnf("could not seed the existing connections: %s", err)
+		return
+	}
+
+	seeded := 0
+	for _, c := range existingConnections(procnet.GetTCPConnections(), uint64(now)) {
+		tuple := c.tuple
+		tuple.Pid = 0
+		if _, ok := tracked[tuple]; ok {
+			continue
+		}
+		if err := conns.Update(&c.tuple, &c.stats, ebpf.UpdateNoExist); err != nil {
+			if errors.Is(err, ebpf.ErrKeyExist) {
+				continue
+			}
+			log.Warnf("could not seed the existing connections, %d out of them were seeded: %s", seeded, err)
+			return
+		}
+		seeded++
+	}
+	log.Infof("seeded %d existing TCP connections", seeded)
+}
+
+// existingConnections turns the sockets found in procfs into conn_stats entries. The established
+// sockets are incoming when a socket of the same network namespace listens on their local port.
+// A socket shared by several processes is attributed to the one with the lowest pid, so that seeding
+// twice gives the same entry, until the probes trace its traffic in one of them (see
+// mergeSeededConnections). The cookie is derived from the socket tuple for the same reason. The
+// connections are flagged as pre-existing, and their duration starts at now.
+func existingConnections(sockets []procnet.TCPConnection, now uint64) []existingConn {
+	type binding struct {
+		netns uint32
+		port  uint16
+	}
+	listening := make(map[binding]struct{})
+	for _, s := range sockets {
+		if s.State == procTCPListen {
+			listening[binding{s.NetNS, s.Lport}] = struct{}{}
+		}
+	}
+
+	type socket struct {
+		netns        uint32
+		laddr, raddr netip.AddrPort
+	}
+	owners := make(map[socket]int)
+	var conns []existingConn
+	for _, s := range sockets {
+		if s.State != procTCPEstablished || s.PID == 0 {
+			continue
+		}
+
+		laddr, raddr := s.Laddr.Unmap(), s.Raddr.Unmap()
+		key := socket{s.NetNS, netip.AddrPortFrom(laddr, s.Lport), netip.AddrPortFrom(raddr, s.Rport)}
+		if i, ok := owners[key]; ok {
+			if s.PID < conns[i].tuple.Pid {
+				conns[i].tuple.Pid = s.PID
+			}
+			continue
+		}
+
+		c := existingConn{
+			tuple: netebpf.ConnTuple{
+				Sport:    s.Lport,
+				Dport:    s.Rport,
+				Netns:    s.NetNS,
+				Pid:      s.PID,
+				Metadata: uint32(netebpf.TCP),
+			},
+			stats: netebpf.ConnStats{
+				Timestamp: now,
+				Duration:  now,
+				Flags:     uint8(netebpf.PreExisting),
+				Direction: uint8(netebpf.Outgoing),
+			},
+		}
+		if laddr.Is4() {
+			c.tuple.Metadata |= uint32(netebpf.IPv4)
+		} else {
+			c.tuple.Metadata |= uint32(netebpf.IPv6)
+		}
+		c.tuple.Saddr_l, c.tuple.Saddr_h = util.ToLowHighIP(laddr)
+		c.tuple.Daddr_l, c.tuple.Daddr_h = util.ToLowHighIP(raddr)
+		if _, ok := listening[binding{s.NetNS, s.Lport}]; ok {
+			c.stats.Direction = uint8(netebpf.Incoming)
+		}
+		c.stats.Cookie = seededCookie(&c.tuple)
+
+		owners[key] = len(conns)
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+// seededCookie derives the cookie of a seeded connection from its tuple, regardless of its pid
+func seededCookie(tuple *netebpf.ConnTuple) uint32 {
+	h := fnv.New32a()
+	var b [8]byte
+	for _, v := range []uint64{tuple.Saddr_h, tuple.Saddr_l, tuple.Daddr_h, tuple.Daddr_l} {
+		binary.LittleEndian.PutUint64(b[:], v)
+		_, _ = h.Write(b[:])
+	}
+	binary.LittleEndian.PutUint16(b[:], tuple.Sport)
+	binary.LittleEndian.PutUint16(b[2:], tuple.Dport)
+	binary.LittleEndian.PutUint32(b[4:], tuple.Netns)
+	_, _ = h.Write(b[:])
+	return h.Sum32()
+}
+
+// mergeSeededConnections merges the seeded connections without traffic into the entries created by the
+// probes for the same sockets under another pid, which is the pid of the task their traffic was traced
+// in. These entries inherit the pre-existing flag and the direction of the seeded ones, which are left
+// out. It returns the number of connections left.
+func mergeSeededConnections(conns []network.ConnectionStats) int {
+	seeded := make(map[netebpf.ConnTuple]int)
+	var tuple netebpf.ConnTuple
+	for i := range conns {
+		if c := &conns[i]; c.IsPreExisting && c.Monotonic.SentBytes == 0 && c.Monotonic.RecvBytes == 0 {
+			toConnTuple(c, &tuple)
+			tuple.Pid = 0
+			seeded[tuple] = i
+		}
+	}
+	if len(seeded) == 0 {
+		return len(conns)
+	}
+
+	merged := make(map[int]struct{})
+	for i := range conns {
+		c := &conns[i]
+		toConnTuple(c, &tuple)
+		tuple.Pid = 0
+		j, ok := seeded[tuple]
+		if !ok || j == i {
+			continue
+		}
+		c.IsPreExisting = true
+		c.Direction = conns[j].Direction
+		merged[j] = struct{}{}
+	}
+	if len(merged) == 0 {
+		return len(conns)
+	}
+
+	n := 0
+	for i := range conns {
+		if _, ok := merged[i]; ok {
+			continue
+		}
+		conns[n] = conns[i]
+		n++
+	}
+	return n
+}
@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package connection
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/network/usm/procnet"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestExistingConnections(t *testing.T) {
+	local := netip.MustParseAddr("10.0.0.1")
+	remote := netip.MustParseAddr("10.0.0.2")
+	sockets := []procnet.TCPConnection{
+		// the zero entries GetTCPConnections may return
+		{},
+		{Laddr: netip.IPv6Unspecified(), Lport: 8080, State: procTCPListen, PID: 10, NetNS: 1},
+		// accepted by a server and shared with its worker
+		{Laddr: local, Lport: 8080, Raddr: remote, Rport: 40000, State: procTCPEstablished, PID: 11, NetNS: 1},
+		{Laddr: local, Lport: 8080, Raddr: remote, Rport: 40000, State: procTCPEstablished, PID: 10, NetNS: 1},
+		// the same port isn't listened on in another namespace
+		{Laddr: netip.MustParseAddr("::ffff:10.0.0.1"), Lport: 8080, Raddr: remote, Rport: 443, State: procTCPEstablished, PID: 20, NetNS: 2},
+		// closing
+		{Laddr: local, Lport: 50000, Raddr: remote, Rport: 443, State: 6, PID: 30, NetNS: 1},
+	}
+
+	conns := existingConnections(sockets, 100)
+	require.Len(t, conns, 2)
+
+	incoming := conns[0]
+	assert.Equal(t, uint32(10), incoming.tuple.Pid)
+	assert.Equal(t, uint32(1), incoming.tuple.Netns)
+	assert.Equal(t, netebpf.TCP, incoming.tuple.Type())
+	assert.Equal(t, netebpf.IPv4, incoming.tuple.Family())
+	assert.Equal(t, util.AddressFromNetIP(local.AsSlice()), incoming.tuple.SourceAddress())
+	assert.Equal(t, util.AddressFromNetIP(remote.AsSlice()), incoming.tuple.DestAddress())
+	assert.Equal(t, uint16(8080), incoming.tuple.Sport)
+	assert.Equal(t, uint16(40000), incoming.tuple.Dport)
+	assert.Equal(t, netebpf.Incoming, incoming.stats.ConnectionDirection())
+	assert.True(t, incoming.stats.IsPreExisting())
+	assert.Equal(t, uint64(100), incoming.stats.Duration)
+
+	outgoing := conns[1]
+	assert.Equal(t, uint32(20), outgoing.tuple.Pid)
+	assert.Equal(t, netebpf.IPv4, outgoing.tuple.Family())
+	assert.Equal(t, util.AddressFromNetIP(local.AsSlice()), outgoing.tuple.SourceAddress())
+	assert.Equal(t, netebpf.Outgoing, outgoing.stats.ConnectionDirection())
+	assert.True(t, outgoing.stats.IsPreExisting())
+
+	// seeding again gives the same entries
+	again := existingConnections(sockets, 100)
+	assert.Equal(t, conns, again)
+	assert.NotEqual(t, incoming.stats.Cookie, outgoing.stats.Cookie)
+}
+
+func TestMergeSeededConnections(t *testing.T) {
+	seeded := network.ConnectionStats{
+		Source:        util.AddressFromString("10.0.0.1"),
+		Dest:          util.AddressFromString("10.0.0.2"),
+		SPort:         8080,
+		DPort:         40000,
+		Pid:           10,
+		NetNS:         1,
+		Type:          network.TCP,
+		Family:        network.AFINET,
+		Direction:     network.INCOMING,
+		IsPreExisting: true,
+	}
+	// the traffic of the socket was traced in the worker
+	traced := seeded
+	traced.Pid, traced.Direction, traced.IsPreExisting = 11, network.OUTGOING, false
+	traced.Monotonic.SentBytes = 100
+	// another seeded connection without traffic yet
+	idle := seeded
+	idle.DPort = 40001
+
+	conns := []network.ConnectionStats{seeded, traced, idle}
+	n := mergeSeededConnections(conns)
+	require.Equal(t, 2, n)
+	assert.Equal(t, uint32(11), conns[0].Pid)
+	assert.True(t, conns[0].IsPreExisting)
+	assert.Equal(t, network.INCOMING, conns[0].Direction)
+	assert.Equal(t, idle, conns[1])
+}
@@ -27,12 +27,26 @@ func initManager(mgr *ddebpf.Manager, connCloseEventHandler ddebpf.EventHandler,
 		{Name: "udpv6_recv_sock"},
 		{Name: probes.PortBindingsMap},
 		{Name: probes.UDPPortBindingsMap},
+		{Name: probes.ListeningSocketsMap},
+		{Name: probes.UnixSockStatsMap},
+		{Name: probes.CgroupConnStatsMap},
+		{Name: probes.ListenOverflowsMap},
+		{Name: probes.ConnDropsMap},
+		{Name: probes.ConnQoSMap},
+		{Name: probes.ConnProcessMap},
+		{Name: probes.TCPRTTHistogramsMap},
+		{Name: probes.TLSHandshakeInfoMap},
+		{Name: probes.WebSocketSessionsMap},
+		{Name: probes.SKBDropsMap},
 		{Name: "pending_bind"},
 		{Name: probes.TelemetryMap},
+		{Name: probes.TracerABIMap},
 		{Name: probes.MapErrTelemetryMap},
 		{Name: probes.HelperErrTelemetryMap},
 	}
 	util.SetupClosedConnHandler(connCloseEventHandler, mgr, cfg)
+	// the fentry programs don't collect any of the optional features
+	util.PinTracerMaps(mgr.Manager, cfg, 0)
 	for funcName := range programs {
 		p := &manager.Probe{
 			ProbeIdentificationPair: manager.ProbeIdentificationPair{
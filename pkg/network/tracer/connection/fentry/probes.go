@@ -16,6 +16,9 @@ import (
 )
 
 const (
+	// inetCskListenStartReturn traces the return value of the inet_csk_listen_start() kernel function
+	inetCskListenStartReturn        = "inet_csk_listen_start_exit"
+	inetCskListenStartPre5190Return = "inet_csk_listen_start_exit_pre_5_19_0"
 	// inetCskListenStop traces the inet_csk_listen_stop system call (called for both ipv4 and ipv6)
 	inetCskListenStop = "inet_csk_listen_stop_enter"
 
@@ -89,6 +92,7 @@ var programs = map[string]struct{}{
 	inet6BindRet:              {},
 	inetBindRet:               {},
 	inetCskAcceptReturn:       {},
+	inetCskListenStartReturn:  {},
 	inetCskListenStop:         {},
 	tcpRecvMsgReturn:          {},
 	tcpClose:                  {},
@@ -118,6 +122,8 @@ var programs = map[string]struct{}{
 	tcpRecvMsgPre5190Return:   {},
 	udpRecvMsgPre5190Return:   {},
 	udpv6RecvMsgPre5190Return: {},
+
+	inetCskListenStartPre5190Return: {},
 }
 
 func enableProgram(enabled map[string]struct{}, name string) {
@@ -144,6 +150,7 @@ func enabledPrograms(c *config.Config) (map[string]struct{}, error) {
 		enableProgram(enabled, tcpConnect)
 		enableProgram(enabled, tcpFinishConnect)
 		enableProgram(enabled, inetCskAcceptReturn)
+		enableProgram(enabled, selectVersionBasedProbe(kv, inetCskListenStartReturn, inetCskListenStartPre5190Return, kv5190))
 		enableProgram(enabled, inetCskListenStop)
 		enableProgram(enabled, tcpRetransmit)
 		enableProgram(enabled, tcpRetransmitRet)
@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"syscall"
 
 	manager "github.com/DataDog/ebpf-manager"
@@ -20,19 +21,30 @@ import (
 	ebpftelemetry "github.com/DataDog/datadog-agent/pkg/ebpf/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/network/config"
 	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/network/preflight"
 	"github.com/DataDog/datadog-agent/pkg/network/tracer/connection/util"
 	"github.com/DataDog/datadog-agent/pkg/util/fargate"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 const probeUID = "net"
 
 //nolint:revive // TODO(NET) Fix revive linter
-var ErrorNotSupported = errors.New("fentry tracer is only supported on Fargate")
-
-// LoadTracer loads a new tracer
-func LoadTracer(config *config.Config, mgrOpts manager.Options, connCloseEventHandler ddebpf.EventHandler) (*manager.Manager, func(), error) {
-	if !fargate.IsFargateInstance() {
-		return nil, nil, ErrorNotSupported
+var ErrorNotSupported = errors.New("fentry tracer is not supported")
+
+// LoadTracer loads a new tracer. Outside of Fargate, the tracer is only loaded when it is enabled,
+// selected by the capability report of the kernel, and no enabled feature requires the kprobe
+// tracer, which is used as a fallback otherwise.
+func LoadTracer(config *config.Config, report *preflight.Report, mgrOpts manager.Options, connCloseEventHandler ddebpf.EventHandler) (*manager.Manager, func(), error) {
+	isFargate := fargate.IsFargateInstance()
+	if !isFargate {
+		if !config.EnableFentry || !selected(report) {
+			return nil, nil, ErrorNotSupported
+		}
+		if dropped := droppedFeatures(config); len(dropped) > 0 {
+			log.Infof("the fentry tracer doesn't support the following enabled features: %s", strings.Join(dropped, ", "))
+			return nil, nil, ErrorNotSupported
+		}
 	}
 
 	m := ddebpf.NewManagerWithDefault(&manager.Manager{}, &ebpftelemetry.ErrorsTelemetryModifier{})
@@ -68,6 +80,7 @@ func LoadTracer(config *config.Config, mgrOpts manager.Options, connCloseEventHa
 			Value: inode,
 		})
 		util.AddBoolConst(&o, "ringbuffers_enabled", ringbufferEnabled)
+		util.AddBoolConst(&o, "task_filter_enabled", isFargate)
 		if ringbufferEnabled {
 			util.EnableRingbuffersViaMapEditor(&mgrOpts)
 		}
@@ -89,12 +102,52 @@ func LoadTracer(config *config.Config, mgrOpts manager.Options, connCloseEventHa
 				})
 		}
 
-		return m.InitWithOptions(ar, &o)
+		if err := m.InitWithOptions(ar, &o); err != nil {
+			return err
+		}
+		util.WriteABI(m.Manager, 0)
+		return nil
 	})
 
 	if err != nil {
+		if !isFargate {
+			// the kernel may lack the BTF or the functions required by the tracer
+			return nil, nil, fmt.Errorf("%w: %s", ErrorNotSupported, err)
+		}
 		return nil, nil, err
 	}
 
 	return m.Manager, nil, nil
 }
+
+// droppedFeatures returns the enabled features which the fentry tracer doesn't implement, which
+// include all the optional fields of the connections
+func droppedFeatures(config *config.Config) []string {
+	var dropped []string
+	for _, f := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"protocol classification", config.ProtocolClassificationEnabled},
+		{"SCTP connections", config.CollectSCTPConns},
+		{"unix sockets", config.CollectUnixSockets},
+		{"listen queue overflows", config.EnableListenOverflowMonitoring},
+	} {
+		if f.enabled {
+			dropped = append(dropped, f.name)
+		}
+	}
+	if features := util.NegotiateFeatures(config, true); features != 0 {
+		dropped = append(dropped, features.String())
+	}
+	return dropped
+}
+
+// selected returns whether the capability report selects the fentry tracer. Without report, the
+// kernel is only probed for BPF trampolines.
+func selected(report *preflight.Report) bool {
+	if report == nil {
+		return preflight.TrampolinesSupported()
+	}
+	return report.Tracer == preflight.TracerFentry
+}
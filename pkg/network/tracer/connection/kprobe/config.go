@@ -28,6 +28,7 @@ func enabledProbes(c *config.Config, runtimeTracer, coreTracer bool) (map[probes
 	kv410 := kernel.VersionCode(4, 1, 0)
 	kv470 := kernel.VersionCode(4, 7, 0)
 	kv4180 := kernel.VersionCode(4, 18, 0)
+	kv5170 := kernel.VersionCode(5, 17, 0)
 	kv5180 := kernel.VersionCode(5, 18, 0)
 	kv5190 := kernel.VersionCode(5, 19, 0)
 	kv650 := kernel.VersionCode(6, 5, 0)
@@ -60,8 +61,22 @@ func enabledProbes(c *config.Config, runtimeTracer, coreTracer bool) (map[probes
 		enableProbe(enabled, probes.TCPCloseFlushReturn)
 		enableProbe(enabled, probes.TCPConnect)
 		enableProbe(enabled, probes.TCPFinishConnect)
+		// reading the socket error requires BTF or kernel headers
+		if c.TCPFailedConnectionsEnabled && (runtimeTracer || coreTracer) {
+			enableProbe(enabled, probes.TCPDone)
+		}
 		enableProbe(enabled, probes.InetCskAcceptReturn)
+		enableProbe(enabled, probes.InetCskListenStart)
+		enableProbe(enabled, probes.InetCskListenStartReturn)
 		enableProbe(enabled, probes.InetCskListenStop)
+		// reading the queues of the listening sockets requires BTF or kernel headers
+		if c.EnableListenOverflowMonitoring && (runtimeTracer || coreTracer) {
+			enableProbe(enabled, probes.TCPConnRequest)
+			enableProbe(enabled, probes.TCPv4SynRecvSock)
+			if c.CollectTCPv6Conns {
+				enableProbe(enabled, probes.TCPv6SynRecvSock)
+			}
+		}
 		// special case for tcp_retransmit_skb probe: on CO-RE,
 		// we want to load the version that makes use of
 		// the tcp_sock field, which is the same as the
@@ -126,6 +141,36 @@ func enabledProbes(c *config.Config, runtimeTracer, coreTracer bool) (map[probes
 		enableProbe(enabled, selectVersionBasedProbe(runtimeTracer || coreTracer, kv, probes.UDPv6RecvMsgReturn, probes.UDPv6RecvMsgReturnPre470, kv470))
 	}
 
+	// the SCTP structures can only be read with BTF or kernel headers
+	if c.CollectSCTPConns && (runtimeTracer || coreTracer) {
+		enableProbe(enabled, probes.SCTPSfDoPrmAsoc)
+		enableProbe(enabled, probes.SCTPOutqTail)
+		enableProbe(enabled, probes.SCTPUlpeventMakeRcvmsg)
+		enableProbe(enabled, probes.SCTPAssociationFree)
+		enableProbe(enabled, probes.SCTPAssociationFreeReturn)
+	}
+
+	if c.CollectUnixSockets && (runtimeTracer || coreTracer) {
+		enableProbe(enabled, probes.UnixStreamSendmsg)
+		enableProbe(enabled, probes.UnixStreamSendmsgReturn)
+		enableProbe(enabled, probes.UnixDgramSendmsg)
+		enableProbe(enabled, probes.UnixDgramSendmsgReturn)
+		enableProbe(enabled, probes.UnixRelease)
+	}
+
+	// the QoS marking is only read by the runtime compiled and CO-RE tracers
+	if c.EnableQoSMarking && (runtimeTracer || coreTracer) {
+		enableProbe(enabled, probes.IPOutput)
+		if c.CollectTCPv6Conns || c.CollectUDPv6Conns {
+			enableProbe(enabled, probes.IP6Output)
+		}
+	}
+
+	// the drop reason was added to the skb:kfree_skb tracepoint in 5.17
+	if c.EnablePacketDropMonitoring && (runtimeTracer || coreTracer) && kv >= kv5170 {
+		enableProbe(enabled, probes.SKBKfreeSkb)
+	}
+
 	if (c.CollectUDPv4Conns || c.CollectUDPv6Conns) && (runtimeTracer || coreTracer || kv >= kv470) {
 		if err := enableAdvancedUDP(enabled); err != nil {
 			return nil, err
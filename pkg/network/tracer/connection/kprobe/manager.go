@@ -35,6 +35,7 @@ var mainProbes = []probes.ProbeFuncName{
 	probes.TCPCloseFlushReturn,
 	probes.TCPConnect,
 	probes.TCPFinishConnect,
+	probes.TCPDone,
 	probes.IPMakeSkb,
 	probes.IPMakeSkbReturn,
 	probes.IP6MakeSkb,
@@ -46,7 +47,14 @@ var mainProbes = []probes.ProbeFuncName{
 	probes.TCPRetransmit,
 	probes.TCPRetransmitRet,
 	probes.InetCskAcceptReturn,
+	probes.InetCskListenStart,
+	probes.InetCskListenStartReturn,
 	probes.InetCskListenStop,
+	probes.TCPConnRequest,
+	probes.TCPv4SynRecvSock,
+	probes.TCPv6SynRecvSock,
+	probes.IPOutput,
+	probes.IP6Output,
 	probes.UDPDestroySock,
 	probes.UDPDestroySockReturn,
 	probes.UDPv6DestroySock,
@@ -57,6 +65,17 @@ var mainProbes = []probes.ProbeFuncName{
 	probes.Inet6BindRet,
 	probes.UDPSendPage,
 	probes.UDPSendPageReturn,
+	probes.SCTPSfDoPrmAsoc,
+	probes.SCTPOutqTail,
+	probes.SCTPUlpeventMakeRcvmsg,
+	probes.SCTPAssociationFree,
+	probes.SCTPAssociationFreeReturn,
+	probes.UnixStreamSendmsg,
+	probes.UnixStreamSendmsgReturn,
+	probes.UnixDgramSendmsg,
+	probes.UnixDgramSendmsgReturn,
+	probes.UnixRelease,
+	probes.SKBKfreeSkb,
 }
 
 func initManager(mgr *ddebpf.Manager, connCloseEventHandler ddebpf.EventHandler, runtimeTracer bool, cfg *config.Config) error {
@@ -69,8 +88,23 @@ func initManager(mgr *ddebpf.Manager, connCloseEventHandler ddebpf.EventHandler,
 		{Name: "udpv6_recv_sock"},
 		{Name: probes.PortBindingsMap},
 		{Name: probes.UDPPortBindingsMap},
+		{Name: probes.ListeningSocketsMap},
+		{Name: probes.InetCskListenStartArgsMap},
+		{Name: probes.SCTPInitiatedAssocsMap},
+		{Name: probes.UnixSockStatsMap},
+		{Name: probes.CgroupConnStatsMap},
+		{Name: probes.ListenOverflowsMap},
+		{Name: probes.ConnDropsMap},
+		{Name: probes.ConnQoSMap},
+		{Name: probes.ConnProcessMap},
+		{Name: probes.TCPRTTHistogramsMap},
+		{Name: probes.TLSHandshakeInfoMap},
+		{Name: probes.WebSocketSessionsMap},
+		{Name: probes.SKBDropsMap},
+		{Name: probes.UnixSendmsgArgsMap},
 		{Name: "pending_bind"},
 		{Name: probes.TelemetryMap},
+		{Name: probes.TracerABIMap},
 		{Name: probes.ConnectionProtocolMap},
 		{Name: probes.TCPSendMsgArgsMap},
 		{Name: probes.TCPSendPageArgsMap},
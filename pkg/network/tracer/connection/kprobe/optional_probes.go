@@ -0,0 +1,113 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package kprobe
+
+import (
+	manager "github.com/DataDog/ebpf-manager"
+
+	"github.com/DataDog/datadog-agent/pkg/network/ebpf/probes"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// optionalProbeGroups are the probes of the optional features of the tracer. They are attached in best effort
+// mode, so that a feature whose hooks are missing from the kernel is disabled alone, instead of preventing the
+// whole tracer from starting.
+var optionalProbeGroups = map[string][]probes.ProbeFuncName{
+	"tcp_failed_connections": {probes.TCPDone},
+	"listen_overflows":       {probes.TCPConnRequest, probes.TCPv4SynRecvSock, probes.TCPv6SynRecvSock},
+	"sctp": {
+		probes.SCTPSfDoPrmAsoc,
+		probes.SCTPOutqTail,
+		probes.SCTPUlpeventMakeRcvmsg,
+		probes.SCTPAssociationFree,
+		probes.SCTPAssociationFreeReturn,
+	},
+	"unix_sockets": {
+		probes.UnixStreamSendmsg,
+		probes.UnixStreamSendmsgReturn,
+		probes.UnixDgramSendmsg,
+		probes.UnixDgramSendmsgReturn,
+		probes.UnixRelease,
+	},
+	"packet_drops": {probes.SKBKfreeSkb},
+	"qos_marking":  {probes.IPOutput, probes.IP6Output},
+}
+
+// probeSelectors returns the selectors activating the given probes: the probes of the optional features are
+// selected in best effort mode, while the other ones must attach
+func probeSelectors(enabled map[probes.ProbeFuncName]struct{}, skipped map[manager.ProbeIdentificationPair]struct{}) []manager.ProbesSelector {
+	var selectors []manager.ProbesSelector
+	optional := make(map[probes.ProbeFuncName]struct{})
+	for _, group := range optionalProbeGroups {
+		var groupSelectors []manager.ProbesSelector
+		for _, funcName := range group {
+			optional[funcName] = struct{}{}
+			pip := manager.ProbeIdentificationPair{EBPFFuncName: funcName, UID: probeUID}
+			if _, ok := enabled[funcName]; !ok {
+				continue
+			}
+			if _, ok := skipped[pip]; ok {
+				continue
+			}
+			groupSelectors = append(groupSelectors, &manager.ProbeSelector{ProbeIdentificationPair: pip})
+		}
+		if len(groupSelectors) > 0 {
+			selectors = append(selectors, &manager.BestEffort{Selectors: groupSelectors})
+		}
+	}
+
+	for funcName := range enabled {
+		pip := manager.ProbeIdentificationPair{EBPFFuncName: funcName, UID: probeUID}
+		if _, ok := optional[funcName]; ok {
+			continue
+		}
+		if _, ok := skipped[pip]; ok {
+			continue
+		}
+		selectors = append(selectors, &manager.ProbeSelector{ProbeIdentificationPair: pip})
+	}
+	return selectors
+}
+
+// CheckOptionalProbes returns whether the probes of each enabled optional feature attached, once the manager is
+// started. The probes of the features which only partially attached are detached, since the feature can't
+// work without all of them.
+func CheckOptionalProbes(m *manager.Manager) map[string]bool {
+	attached := make(map[string]bool)
+	for feature, group := range optionalProbeGroups {
+		var running []*manager.Probe
+		var failed *manager.Probe
+		for _, funcName := range group {
+			p, found := m.GetProbe(manager.ProbeIdentificationPair{EBPFFuncName: funcName, UID: probeUID})
+			if !found || !p.Enabled {
+				continue
+			}
+			if p.IsRunning() {
+				running = append(running, p)
+			} else {
+				failed = p
+			}
+		}
+		if len(running) == 0 && failed == nil {
+			// the feature is disabled
+			continue
+		}
+
+		attached[feature] = failed == nil
+		if failed == nil {
+			continue
+		}
+		log.Warnf("disabling %s, probe %s could not be attached: %s", feature, failed.EBPFFuncName, failed.GetLastError())
+		for _, p := range running {
+			if err := m.DetachHook(p.ProbeIdentificationPair); err != nil {
+				log.Warnf("could not detach probe %s: %s", p.EBPFFuncName, err)
+			}
+		}
+	}
+	return attached
+}
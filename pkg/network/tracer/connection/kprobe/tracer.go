@@ -178,6 +178,9 @@ func loadTracerFromAsset(buf bytecode.AssetReader, runtimeTracer, coreTracer boo
 	if err := initManager(m, connCloseEventHandler, runtimeTracer, config); err != nil {
 		return nil, nil, fmt.Errorf("could not initialize manager: %w", err)
 	}
+	// reading the cgroup, the process and the QoS marking of sockets requires BTF or kernel headers
+	features := util.NegotiateFeatures(config, runtimeTracer || coreTracer)
+	util.PinTracerMaps(m.Manager, config, features)
 	ringbufferEnabled := false
 	switch connCloseEventHandler.(type) {
 	case *ddebpf.RingBufferHandler:
@@ -245,26 +248,15 @@ func loadTracerFromAsset(buf bytecode.AssetReader, runtimeTracer, coreTracer boo
 
 	_, udpSendPageEnabled := enabledProbes[probes.UDPSendPage]
 	util.AddBoolConst(&mgrOpts, "udp_send_page_enabled", udpSendPageEnabled)
+	util.AddFeatureConsts(&mgrOpts, features)
 
-	for funcName := range enabledProbes {
-		probeIdentifier := manager.ProbeIdentificationPair{
-			EBPFFuncName: funcName,
-			UID:          probeUID,
-		}
-		if _, ok := tailCallsIdentifiersSet[probeIdentifier]; ok {
-			// tail calls should be enabled (a.k.a. not excluded) but not activated.
-			continue
-		}
-		mgrOpts.ActivatedProbes = append(
-			mgrOpts.ActivatedProbes,
-			&manager.ProbeSelector{
-				ProbeIdentificationPair: probeIdentifier,
-			})
-	}
+	// tail calls should be enabled (a.k.a. not excluded) but not activated.
+	mgrOpts.ActivatedProbes = append(mgrOpts.ActivatedProbes, probeSelectors(enabledProbes, tailCallsIdentifiersSet)...)
 
 	if err := m.InitWithOptions(buf, &mgrOpts); err != nil {
 		return nil, nil, fmt.Errorf("failed to init ebpf manager: %w", err)
 	}
+	util.WriteABI(m.Manager, features)
 
 	return m.Manager, closeProtocolClassifierSocketFilterFn, nil
 }
@@ -18,6 +18,7 @@ import (
 	ddebpf "github.com/DataDog/datadog-agent/pkg/ebpf"
 	"github.com/DataDog/datadog-agent/pkg/ebpf/bytecode"
 	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/ebpf/probes"
 	"github.com/DataDog/datadog-agent/pkg/util/kernel"
 )
 
@@ -321,3 +322,33 @@ func TestDefaultKprobeMaxActiveSet(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestProbeSelectors(t *testing.T) {
+	enabled := map[probes.ProbeFuncName]struct{}{
+		probes.TCPSendMsg:       {},
+		probes.TCPConnRequest:   {},
+		probes.TCPv4SynRecvSock: {},
+		probes.SKBKfreeSkb:      {},
+	}
+	skipped := map[manager.ProbeIdentificationPair]struct{}{
+		{EBPFFuncName: probes.SKBKfreeSkb, UID: probeUID}: {},
+	}
+
+	var mandatory []string
+	var bestEffort [][]string
+	for _, s := range probeSelectors(enabled, skipped) {
+		var names []string
+		for _, pip := range s.GetProbesIdentificationPairList() {
+			names = append(names, pip.EBPFFuncName)
+		}
+		switch s.(type) {
+		case *manager.BestEffort:
+			bestEffort = append(bestEffort, names)
+		default:
+			mandatory = append(mandatory, names...)
+		}
+	}
+
+	assert.Equal(t, []string{probes.TCPSendMsg}, mandatory)
+	assert.Equal(t, [][]string{{probes.TCPConnRequest, probes.TCPv4SynRecvSock}}, bestEffort)
+}
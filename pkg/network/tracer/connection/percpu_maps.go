@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package connection
+
+import (
+	manager "github.com/DataDog/ebpf-manager"
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/features"
+
+	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/network/ebpf/probes"
+)
+
+// perCPUCounterMaps are the maps of counters updated by every probe hit. They are declared as shared maps,
+// since the per-CPU maps aren't available in all the supported kernels (4.4 ~ 4.6), and are turned into
+// per-CPU maps at load time when possible, so that the CPUs don't contend for the same cache lines. The eBPF
+// programs update both kinds the same way, while userspace sums the values of the CPUs.
+//
+// The port bindings are left shared, since the probes need their count across all the CPUs.
+var perCPUCounterMaps = map[string]ebpf.MapType{
+	probes.TelemetryMap: ebpf.PerCPUArray,
+	probes.SKBDropsMap:  ebpf.PerCPUHash,
+}
+
+// setupPerCPUCounterMaps turns the counter maps into per-CPU maps, when supported by the kernel
+func setupPerCPUCounterMaps(opts *manager.Options) {
+	if opts.MapSpecEditors == nil {
+		opts.MapSpecEditors = make(map[string]manager.MapSpecEditor)
+	}
+	for name, mapType := range perCPUCounterMaps {
+		if features.HaveMapType(mapType) != nil {
+			continue
+		}
+		me := opts.MapSpecEditors[name]
+		me.Type = mapType
+		me.EditorFlag |= manager.EditType
+		opts.MapSpecEditors[name] = me
+	}
+}
+
+// sumPerCPU sums the values of a per-CPU counter map
+func sumPerCPU(values []uint64) uint64 {
+	var sum uint64
+	for _, v := range values {
+		sum += v
+	}
+	return sum
+}
+
+// sumTelemetry sums the telemetry of all the CPUs
+func sumTelemetry(values []netebpf.Telemetry) *netebpf.Telemetry {
+	sum := &netebpf.Telemetry{}
+	for _, v := range values {
+		sum.Tcp_failed_connect += v.Tcp_failed_connect
+		sum.Tcp_sent_miscounts += v.Tcp_sent_miscounts
+		sum.Unbatched_tcp_close += v.Unbatched_tcp_close
+		sum.Unbatched_udp_close += v.Unbatched_udp_close
+		sum.Udp_sends_processed += v.Udp_sends_processed
+		sum.Udp_sends_missed += v.Udp_sends_missed
+		sum.Udp_dropped_conns += v.Udp_dropped_conns
+		sum.Closed_conn_output_failed += v.Closed_conn_output_failed
+	}
+	return sum
+}
+
+// isPerCPUMap returns whether the given map of the manager was loaded as a per-CPU map
+func isPerCPUMap(mgr *manager.Manager, name string) bool {
+	m, found, _ := mgr.GetMap(name)
+	if !found {
+		return false
+	}
+	switch m.Type() {
+	case ebpf.PerCPUArray, ebpf.PerCPUHash:
+		return true
+	}
+	return false
+}
@@ -52,10 +52,17 @@ func newPerfBatchManager(batchMap *maps.GenericMap[uint32, netebpf.Batch], numCP
 	state := make([]percpuState, numCPUs)
 	for cpu := uint32(0); cpu < numCPUs; cpu++ {
 		b := new(netebpf.Batch)
-		// Ring buffer events don't have CPU information, so we associate each
-		// batch entry with a CPU during startup. This information is used by
-		// the code that does the batch offset tracking.
-		b.Cpu = cpu
+		if err := batchMap.Lookup(&cpu, b); err == nil && b.Cpu == cpu {
+			// The batch pinned by the previous instance of system-probe is kept, so that the connections
+			// closed before it stopped, and not sent yet, are read with the pending connections.
+			resetBatchCounters(b)
+		} else {
+			*b = netebpf.Batch{}
+			// Ring buffer events don't have CPU information, so we associate each
+			// batch entry with a CPU during startup. This information is used by
+			// the code that does the batch offset tracking.
+			b.Cpu = cpu
+		}
 		if err := batchMap.Put(&cpu, b); err != nil {
 			return nil, fmt.Errorf("error initializing perf batch manager maps: %w", err)
 		}
@@ -158,6 +165,8 @@ func (p *perfBatchManager) extractBatchInto(buffer *network.ConnectionBuffer, b
 		conn := buffer.Next()
 		populateConnStats(conn, &ct.Tup, &ct.Conn_stats, p.ch)
 		updateTCPStats(conn, &ct.Tcp_stats, ct.Tcp_retransmits)
+		updateTCPCongestion(conn, &ct.Tcp_congestion)
+		conn.Monotonic.Drops = ct.Drops
 	}
 }
 
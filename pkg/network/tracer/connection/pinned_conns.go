@@ -0,0 +1,168 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package connection
+
+import (
+	"net/netip"
+	"time"
+
+	ddebpf "github.com/DataDog/datadog-agent/pkg/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/ebpf/maps"
+	"github.com/DataDog/datadog-agent/pkg/network"
+	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/network/usm/procnet"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// tcpSocket identifies a TCP socket, whatever the process owning it
+type tcpSocket struct {
+	netns        uint32
+	laddr, raddr netip.AddrPort
+}
+
+// preparePinnedConnections prepares the entries of the connection map reused from the previous instance of
+// system-probe, when the maps are pinned, before the probes are attached:
+//   - the counters of the entries are reset: they were reported up to the last check of the previous instance,
+//     which isn't known here, so they would be reported again in full otherwise
+//   - the TCP connections closed while system-probe was down, whose close wasn't traced, are deleted and
+//     returned, to be reported as closed. The UDP connections expire with the UDP timeout.
+func (t *tracer) preparePinnedConnections() []network.ConnectionStats {
+	if t.conns == nil {
+		return nil
+	}
+	now, err := ddebpf.NowNanoseconds()
+	if err != nil {
+		log.Warnf("could not prepare the pinned connections: %s", err)
+		return nil
+	}
+
+	var (
+		alive    map[tcpSocket]struct{}
+		closed   []network.ConnectionStats
+		stale    []netebpf.ConnTuple
+		reused   []netebpf.ConnTuple
+		reusedCS []netebpf.ConnStats
+	)
+	key, stats := new(netebpf.ConnTuple), new(netebpf.ConnStats)
+	entries := t.conns.Iterate()
+	for entries.Next(key, stats) {
+		resetConnCounters(stats)
+		if key.Type() == netebpf.TCP {
+			if alive == nil {
+				alive = liveTCPSockets(procnet.GetTCPConnections())
+			}
+			if _, ok := alive[tupleSocket(key)]; !ok {
+				conn := network.ConnectionStats{}
+				populateConnStats(&conn, key, stats, t.ch)
+				conn.Duration = time.Duration(uint64(now)-stats.Duration) * time.Nanosecond
+				conn.Monotonic.TCPClosed = 1
+				closed = append(closed, conn)
+				stale = append(stale, *key)
+				continue
+			}
+		}
+		reused = append(reused, *key)
+		reusedCS = append(reusedCS, *stats)
+	}
+	if err := entries.Err(); err != nil {
+		log.Warnf("could not prepare the pinned connections: %s", err)
+	}
+	if len(reused) == 0 && len(stale) == 0 {
+		return nil
+	}
+
+	for i := range reused {
+		_ = t.conns.Put(&reused[i], &reusedCS[i])
+		t.resetTCPCounters(reused[i])
+	}
+	for i := range stale {
+		_ = t.conns.Delete(&stale[i])
+		tuple := stale[i]
+		tuple.Pid = 0
+		_ = t.tcpStats.Delete(&tuple)
+		_ = t.tcpRetransmits.Delete(&tuple)
+	}
+	log.Infof("reusing %d pinned connections, %d were closed while system-probe was down", len(reused), len(closed))
+	return closed
+}
+
+// resetTCPCounters resets the retransmits of the TCP connection, and the transition to the established
+// state, which is counted like the other counters
+func (t *tracer) resetTCPCounters(tuple netebpf.ConnTuple) {
+	if tuple.Type() != netebpf.TCP {
+		return
+	}
+	tuple.Pid = 0
+	var zero uint32
+	if t.tcpRetransmits.Lookup(&tuple, new(uint32)) == nil {
+		_ = t.tcpRetransmits.Put(&tuple, &zero)
+	}
+	tcpStats := new(netebpf.TCPStats)
+	if t.tcpStats.Lookup(&tuple, tcpStats) == nil {
+		tcpStats.State_transitions &^= 1 << netebpf.Established
+		_ = t.tcpStats.Put(&tuple, tcpStats)
+	}
+}
+
+func resetConnCounters(stats *netebpf.ConnStats) {
+	stats.Sent_bytes, stats.Recv_bytes = 0, 0
+	stats.Sent_packets, stats.Recv_packets = 0, 0
+}
+
+// resetBatchCounters resets the counters of the closed connections of a batch pinned by the previous instance
+// of system-probe, like those of the connection map
+func resetBatchCounters(b *netebpf.Batch) {
+	for _, c := range []*netebpf.Conn{&b.C0, &b.C1, &b.C2, &b.C3} {
+		resetConnCounters(&c.Conn_stats)
+		c.Tcp_retransmits = 0
+		c.Drops = 0
+		c.Tcp_stats.State_transitions &^= 1 << netebpf.Established
+	}
+}
+
+// discardStalePortBindings deletes the port bindings pinned by the previous instance of system-probe
+// which aren't bound anymore
+func discardStalePortBindings(portMap *maps.GenericMap[netebpf.PortBinding, uint32], ports map[network.PortMapping]uint32) {
+	var stale []netebpf.PortBinding
+	pb, count := new(netebpf.PortBinding), new(uint32)
+	entries := portMap.Iterate()
+	for entries.Next(pb, count) {
+		if _, ok := ports[network.PortMapping{Ino: pb.Netns, Port: pb.Port}]; !ok {
+			stale = append(stale, *pb)
+		}
+	}
+	for i := range stale {
+		_ = portMap.Delete(&stale[i])
+	}
+}
+
+// liveTCPSockets returns the TCP sockets found in procfs which weren't closed by their process yet
+func liveTCPSockets(sockets []procnet.TCPConnection) map[tcpSocket]struct{} {
+	alive := make(map[tcpSocket]struct{}, len(sockets))
+	for _, s := range sockets {
+		switch s.State {
+		case procTCPEstablished, procTCPSynSent, procTCPSynRecv, procTCPCloseWait:
+		default:
+			continue
+		}
+		alive[tcpSocket{
+			netns: s.NetNS,
+			laddr: netip.AddrPortFrom(s.Laddr.Unmap(), s.Lport),
+			raddr: netip.AddrPortFrom(s.Raddr.Unmap(), s.Rport),
+		}] = struct{}{}
+	}
+	return alive
+}
+
+func tupleSocket(t *netebpf.ConnTuple) tcpSocket {
+	return tcpSocket{
+		netns: t.Netns,
+		laddr: netip.AddrPortFrom(t.SourceAddress().Addr.Unmap(), t.Sport),
+		raddr: netip.AddrPortFrom(t.DestAddress().Addr.Unmap(), t.Dport),
+	}
+}
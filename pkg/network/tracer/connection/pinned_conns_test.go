@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package connection
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/network/usm/procnet"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestResetBatchCounters(t *testing.T) {
+	b := netebpf.Batch{Len: 2, Id: 7, Cpu: 1}
+	b.C0.Conn_stats = netebpf.ConnStats{Sent_bytes: 10, Recv_bytes: 20, Sent_packets: 1, Recv_packets: 2, Duration: 100}
+	b.C0.Tcp_retransmits = 3
+	b.C0.Drops = 4
+	b.C0.Tcp_stats.State_transitions = 1<<netebpf.Established | 1<<netebpf.Close
+	b.C0.Tcp_stats.Rtt = 50
+
+	resetBatchCounters(&b)
+	assert.Equal(t, netebpf.ConnStats{Duration: 100}, b.C0.Conn_stats)
+	assert.Zero(t, b.C0.Tcp_retransmits)
+	assert.Zero(t, b.C0.Drops)
+	// the close is still reported, but the connection isn't counted as established again
+	assert.Equal(t, uint16(1<<netebpf.Close), b.C0.Tcp_stats.State_transitions)
+	assert.Equal(t, uint32(50), b.C0.Tcp_stats.Rtt)
+	// the pending connections are still read
+	assert.Equal(t, uint16(2), b.Len)
+	assert.Equal(t, uint64(7), b.Id)
+	assert.Equal(t, uint32(1), b.Cpu)
+}
+
+func TestLiveTCPSockets(t *testing.T) {
+	local := netip.MustParseAddr("10.0.0.1")
+	remote := netip.MustParseAddr("10.0.0.2")
+	alive := liveTCPSockets([]procnet.TCPConnection{
+		{Laddr: netip.IPv6Unspecified(), Lport: 8080, State: procTCPListen, NetNS: 1},
+		{Laddr: netip.MustParseAddr("::ffff:10.0.0.1"), Lport: 8080, Raddr: netip.MustParseAddr("::ffff:10.0.0.2"), Rport: 40000, State: procTCPEstablished, NetNS: 1},
+		// closed by its process
+		{Laddr: local, Lport: 50000, Raddr: remote, Rport: 443, State: 6, NetNS: 1},
+	})
+	assert.Len(t, alive, 1)
+
+	tuple := func(sport, dport uint16, netns uint32) *netebpf.ConnTuple {
+		tup := &netebpf.ConnTuple{Sport: sport, Dport: dport, Netns: netns, Pid: 10}
+		tup.Saddr_l, tup.Saddr_h = util.ToLowHighIP(local)
+		tup.Daddr_l, tup.Daddr_h = util.ToLowHighIP(remote)
+		return tup
+	}
+	assert.Contains(t, alive, tupleSocket(tuple(8080, 40000, 1)))
+	assert.NotContains(t, alive, tupleSocket(tuple(8080, 40000, 2)))
+	assert.NotContains(t, alive, tupleSocket(tuple(50000, 443, 1)))
+}
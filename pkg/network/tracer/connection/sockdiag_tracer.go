@@ -0,0 +1,454 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package connection
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vishvananda/netlink"
+	"go.uber.org/atomic"
+	"golang.org/x/sys/unix"
+
+	ddebpf "github.com/DataDog/datadog-agent/pkg/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// sockDiagPollInterval is how often the sockets are listed to detect the closed connections
+	sockDiagPollInterval = 5 * time.Second
+
+	tcpListenState = 10
+)
+
+// errNotSupportedBySockDiag is returned for the features which require eBPF
+var errNotSupportedBySockDiag = errors.New("not supported by the sock_diag tracer")
+
+// sockDiagTracer is a degraded mode tracer for the hosts where eBPF is unavailable or forbidden.
+// It periodically lists the TCP and UDP sockets of the root network namespace with NETLINK_SOCK_DIAG,
+// and reports the counters of tcp_info for TCP connections. The connections which are opened and
+// closed between two polls are missed, and no byte counts are available for UDP, so all the
+// connections it reports are marked as approximate.
+type sockDiagTracer struct {
+	config   *config.Config
+	rootNsID uint32
+
+	mu sync.Mutex
+	// conns are the connections seen during the last poll, by socket cookie
+	conns map[uint64]*sockDiagConn
+	// listening are the TCP sockets in the LISTEN state and the
+	// unconnected UDP sockets seen during the last poll
+	listening      []network.ListeningSocket
+	listeningPorts map[listeningPort]struct{}
+	pids           map[uint32]uint32
+
+	closedCallback func([]network.ConnectionStats)
+	paused         atomic.Bool
+	done           chan struct{}
+	wg             sync.WaitGroup
+}
+
+type listeningPort struct {
+	typ  network.ConnectionType
+	port uint16
+}
+
+type sockDiagConn struct {
+	stats     network.ConnectionStats
+	inode     uint32
+	firstSeen uint64
+}
+
+func newSockDiagTracer(cfg *config.Config) (*sockDiagTracer, error) {
+	rootNs, err := cfg.GetRootNetNs()
+	if err != nil {
+		return nil, fmt.Errorf("could not get the root network namespace: %w", err)
+	}
+	defer rootNs.Close()
+
+	rootNsID, err := kernel.GetInoForNs(rootNs)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the inode of the root network namespace: %w", err)
+	}
+
+	return &sockDiagTracer{
+		config:         cfg,
+		rootNsID:       rootNsID,
+		conns:          make(map[uint64]*sockDiagConn),
+		listeningPorts: make(map[listeningPort]struct{}),
+		pids:           make(map[uint32]uint32),
+		done:           make(chan struct{}),
+	}, nil
+}
+
+// Start begins polling the sockets
+func (t *sockDiagTracer) Start(callback func([]network.ConnectionStats)) error {
+	t.closedCallback = callback
+	if err := t.poll(); err != nil {
+		return fmt.Errorf("could not list the sockets: %w", err)
+	}
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		ticker := time.NewTicker(sockDiagPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.done:
+				return
+			case <-ticker.C:
+				if t.paused.Load() {
+					continue
+				}
+				if err := t.poll(); err != nil {
+					log.Warnf("could not list the sockets: %s", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop halts the polling of the sockets
+func (t *sockDiagTracer) Stop() {
+	select {
+	case <-t.done:
+	default:
+		close(t.done)
+	}
+	t.wg.Wait()
+}
+
+// GetConnections lists the sockets and returns the active connections
+func (t *sockDiagTracer) GetConnections(buffer *network.ConnectionBuffer, filter func(*network.ConnectionStats) bool) error {
+	if err := t.poll(); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, c := range t.conns {
+		conn := buffer.Next()
+		*conn = c.stats
+		if filter != nil && !filter(conn) {
+			buffer.Reclaim(1)
+		}
+	}
+	return nil
+}
+
+// poll lists the sockets, updates the active connections and reports the ones
+// which disappeared since the previous poll as closed
+func (t *sockDiagTracer) poll() error {
+	now, err := ddebpf.NowNanoseconds()
+	if err != nil {
+		return err
+	}
+
+	var tcp []*netlink.InetDiagTCPInfoResp
+	var udp []*netlink.Socket
+	for _, family := range t.families() {
+		socks, err := netlink.SocketDiagTCPInfo(family)
+		if err != nil {
+			return fmt.Errorf("error listing TCP sockets: %w", err)
+		}
+		tcp = append(tcp, socks...)
+
+		if t.config.CollectUDPv4Conns || t.config.CollectUDPv6Conns {
+			socks, err := netlink.SocketDiagUDP(family)
+			if err != nil {
+				return fmt.Errorf("error listing UDP sockets: %w", err)
+			}
+			udp = append(udp, socks...)
+		}
+	}
+
+	t.mu.Lock()
+	closed := t.update(uint64(now), tcp, udp)
+	t.mu.Unlock()
+
+	if len(closed) > 0 && t.closedCallback != nil {
+		t.closedCallback(closed)
+	}
+	return nil
+}
+
+func (t *sockDiagTracer) families() []uint8 {
+	var families []uint8
+	if t.config.CollectTCPv4Conns || t.config.CollectUDPv4Conns {
+		families = append(families, unix.AF_INET)
+	}
+	if t.config.CollectTCPv6Conns || t.config.CollectUDPv6Conns {
+		families = append(families, unix.AF_INET6)
+	}
+	return families
+}
+
+// update replaces the active connections with the sockets listed at the given time,
+// and returns the connections which are gone
+func (t *sockDiagTracer) update(now uint64, tcp []*netlink.InetDiagTCPInfoResp, udp []*netlink.Socket) []network.ConnectionStats {
+	clear(t.listeningPorts)
+	t.listening = t.listening[:0]
+	listen := func(s *netlink.Socket, typ network.ConnectionType) {
+		t.listeningPorts[listeningPort{typ: typ, port: s.ID.SourcePort}] = struct{}{}
+		family := network.AFINET
+		if s.Family == unix.AF_INET6 {
+			family = network.AFINET6
+		}
+		t.listening = append(t.listening, network.ListeningSocket{
+			Type:   typ,
+			Family: family,
+			Addr:   util.AddressFromNetIP(s.ID.Source),
+			Port:   s.ID.SourcePort,
+			NetNS:  t.rootNsID,
+			Pid:    t.pids[s.INode],
+		})
+	}
+	for _, s := range tcp {
+		if s.InetDiagMsg.State == tcpListenState {
+			listen(s.InetDiagMsg, network.TCP)
+		}
+	}
+	for _, s := range udp {
+		if s.ID.DestinationPort == 0 {
+			listen(s, network.UDP)
+		}
+	}
+
+	seen := make(map[uint64]struct{}, len(t.conns))
+	var unknownInodes map[uint32]struct{}
+	add := func(s *netlink.Socket, typ network.ConnectionType, info *netlink.TCPInfo) {
+		cookie := uint64(s.ID.Cookie[1])<<32 | uint64(s.ID.Cookie[0])
+		c, ok := t.conns[cookie]
+		if !ok {
+			c = &sockDiagConn{inode: s.INode, firstSeen: now}
+			t.conns[cookie] = c
+			if _, known := t.pids[s.INode]; !known && s.INode != 0 {
+				if unknownInodes == nil {
+					unknownInodes = make(map[uint32]struct{})
+				}
+				unknownInodes[s.INode] = struct{}{}
+			}
+		}
+		seen[cookie] = struct{}{}
+		populateSockDiagConnStats(&c.stats, s, typ, info)
+		c.stats.NetNS = t.rootNsID
+		c.stats.Cookie = network.StatCookie(cookie)
+		c.stats.LastUpdateEpoch = now
+		c.stats.Duration = time.Duration(now - c.firstSeen)
+		c.stats.Direction = network.OUTGOING
+		if _, ok := t.listeningPorts[listeningPort{typ: typ, port: c.stats.SPort}]; ok {
+			c.stats.Direction = network.INCOMING
+		}
+	}
+
+	for _, s := range tcp {
+		if s.InetDiagMsg.State == tcpListenState || !t.collect(network.TCP, s.InetDiagMsg.Family) {
+			continue
+		}
+		add(s.InetDiagMsg, network.TCP, s.TCPInfo)
+	}
+	for _, s := range udp {
+		if s.ID.DestinationPort == 0 || !t.collect(network.UDP, s.Family) {
+			continue
+		}
+		add(s, network.UDP, nil)
+	}
+
+	if len(unknownInodes) > 0 {
+		for ino, pid := range socketPids(t.config.ProcRoot, unknownInodes) {
+			t.pids[ino] = pid
+		}
+	}
+
+	var closed []network.ConnectionStats
+	for cookie, c := range t.conns {
+		if _, ok := seen[cookie]; !ok {
+			closed = append(closed, c.stats)
+			delete(t.conns, cookie)
+			delete(t.pids, c.inode)
+			continue
+		}
+		c.stats.Pid = t.pids[c.inode]
+	}
+	return closed
+}
+
+func (t *sockDiagTracer) collect(typ network.ConnectionType, family uint8) bool {
+	switch {
+	case typ == network.TCP && family == unix.AF_INET:
+		return t.config.CollectTCPv4Conns
+	case typ == network.TCP && family == unix.AF_INET6:
+		return t.config.CollectTCPv6Conns
+	case typ == network.UDP && family == unix.AF_INET:
+		return t.config.CollectUDPv4Conns
+	case typ == network.UDP && family == unix.AF_INET6:
+		return t.config.CollectUDPv6Conns
+	}
+	return false
+}
+
+// populateSockDiagConnStats fills in the connection stats from a socket listed by sock_diag,
+// and from its tcp_info for TCP sockets
+func populateSockDiagConnStats(stats *network.ConnectionStats, s *netlink.Socket, typ network.ConnectionType, info *netlink.TCPInfo) {
+	stats.Type = typ
+	stats.Family = network.AFINET
+	if s.Family == unix.AF_INET6 {
+		stats.Family = network.AFINET6
+	}
+	stats.Source = util.AddressFromNetIP(s.ID.Source)
+	stats.Dest = util.AddressFromNetIP(s.ID.Destination)
+	stats.SPort = s.ID.SourcePort
+	stats.DPort = s.ID.DestinationPort
+	stats.IsApproximate = true
+
+	if info == nil {
+		return
+	}
+	stats.Monotonic.SentBytes = info.Bytes_acked
+	stats.Monotonic.RecvBytes = info.Bytes_received
+	stats.Monotonic.SentPackets = uint64(info.Segs_out)
+	stats.Monotonic.RecvPackets = uint64(info.Segs_in)
+	stats.Monotonic.Retransmits = info.Total_retrans
+	stats.Monotonic.TCPEstablished = 1
+	stats.RTT = info.Rtt
+	stats.RTTVar = info.Rttvar
+	stats.Cwnd = info.Snd_cwnd
+	stats.Ssthresh = info.Snd_ssthresh
+}
+
+// socketPids returns the pid of a process holding each of the socket inodes,
+// by going over the file descriptors of all the processes
+func socketPids(procRoot string, inodes map[uint32]struct{}) map[uint32]uint32 {
+	pids := make(map[uint32]uint32, len(inodes))
+	errDone := errors.New("done")
+	_ = kernel.WithAllProcs(procRoot, func(pid int) error {
+		fdDir := filepath.Join(procRoot, strconv.Itoa(pid), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			return nil
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			ino, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]"), 10, 32)
+			if err != nil {
+				continue
+			}
+			if _, ok := inodes[uint32(ino)]; !ok {
+				continue
+			}
+			if _, ok := pids[uint32(ino)]; !ok {
+				pids[uint32(ino)] = uint32(pid)
+			}
+		}
+		if len(pids) == len(inodes) {
+			return errDone
+		}
+		return nil
+	})
+	return pids
+}
+
+// GetListeningSockets returns the TCP sockets in the LISTEN state and the unconnected UDP sockets
+// seen during the last poll. The overflows of the queues are not available.
+func (t *sockDiagTracer) GetListeningSockets() ([]network.ListeningSocket, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]network.ListeningSocket(nil), t.listening...), nil
+}
+
+// GetUnixSockets is not supported by the sock_diag tracer
+func (t *sockDiagTracer) GetUnixSockets() ([]network.UnixSocket, error) {
+	return nil, errNotSupportedBySockDiag
+}
+
+// ExpireUnixSockets is a no-op, as the sock_diag tracer doesn't track the AF_UNIX sockets
+func (t *sockDiagTracer) ExpireUnixSockets(uint64) {}
+
+// GetPacketDrops is not supported by the sock_diag tracer
+func (t *sockDiagTracer) GetPacketDrops() ([]network.PacketDrops, error) {
+	return nil, errNotSupportedBySockDiag
+}
+
+// FlushPending is a no-op, as the closed connections are reported with each poll
+func (t *sockDiagTracer) FlushPending() {}
+
+// Remove forgets about the connection until it is listed again
+func (t *sockDiagTracer) Remove(conn *network.ConnectionStats) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.conns, uint64(conn.Cookie))
+	return nil
+}
+
+// RemoveBatch forgets about the connections until they are listed again
+func (t *sockDiagTracer) RemoveBatch(conns []network.ConnectionStats) []*network.ConnectionStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	removed := make([]*network.ConnectionStats, 0, len(conns))
+	for i := range conns {
+		delete(t.conns, uint64(conns[i].Cookie))
+		removed = append(removed, &conns[i])
+	}
+	return removed
+}
+
+// GetMap returns nil, as the sock_diag tracer has no eBPF maps
+func (t *sockDiagTracer) GetMap(string) *ebpf.Map {
+	return nil
+}
+
+// DumpMaps returns an error, as the sock_diag tracer has no eBPF maps
+func (t *sockDiagTracer) DumpMaps(_ io.Writer, _ ...string) error {
+	return errNotSupportedBySockDiag
+}
+
+// Type returns TracerTypeSockDiag
+func (t *sockDiagTracer) Type() TracerType {
+	return TracerTypeSockDiag
+}
+
+// OptionalProbes returns nil, since no probe is attached
+func (t *sockDiagTracer) OptionalProbes() map[string]bool {
+	return nil
+}
+
+// Pause suspends the polling of the sockets in the background
+func (t *sockDiagTracer) Pause() error {
+	t.paused.Store(true)
+	return nil
+}
+
+// Resume resumes the polling of the sockets in the background
+func (t *sockDiagTracer) Resume() error {
+	t.paused.Store(false)
+	return nil
+}
+
+// Describe is a no-op, the sock_diag tracer has no telemetry of its own
+func (t *sockDiagTracer) Describe(chan<- *prometheus.Desc) {}
+
+// Collect is a no-op, the sock_diag tracer has no telemetry of its own
+func (t *sockDiagTracer) Collect(chan<- prometheus.Metric) {}
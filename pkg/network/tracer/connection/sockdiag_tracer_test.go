@@ -0,0 +1,84 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package connection
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+)
+
+func TestSockDiagTracerUpdate(t *testing.T) {
+	cfg := &config.Config{CollectTCPv4Conns: true, CollectUDPv4Conns: true}
+	tr := &sockDiagTracer{
+		config:         cfg,
+		rootNsID:       42,
+		conns:          make(map[uint64]*sockDiagConn),
+		listeningPorts: make(map[listeningPort]struct{}),
+		pids:           map[uint32]uint32{100: 1234},
+	}
+
+	socket := func(state uint8, sport, dport uint16, cookie uint32, inode uint32) *netlink.Socket {
+		return &netlink.Socket{
+			Family: unix.AF_INET,
+			State:  state,
+			INode:  inode,
+			ID: netlink.SocketID{
+				SourcePort:      sport,
+				DestinationPort: dport,
+				Source:          net.ParseIP("10.0.0.1"),
+				Destination:     net.ParseIP("10.0.0.2"),
+				Cookie:          [2]uint32{cookie, 0},
+			},
+		}
+	}
+
+	listener := &netlink.InetDiagTCPInfoResp{InetDiagMsg: socket(tcpListenState, 8080, 0, 1, 0)}
+	client := &netlink.InetDiagTCPInfoResp{
+		InetDiagMsg: socket(1, 40000, 443, 2, 100),
+		TCPInfo:     &netlink.TCPInfo{Bytes_acked: 10, Bytes_received: 20, Segs_out: 3, Segs_in: 4, Rtt: 500},
+	}
+	server := &netlink.InetDiagTCPInfoResp{InetDiagMsg: socket(1, 8080, 50000, 3, 100)}
+	udp := socket(0, 53000, 53, 4, 100)
+
+	closed := tr.update(uint64(time.Second), []*netlink.InetDiagTCPInfoResp{listener, client, server}, []*netlink.Socket{udp})
+	assert.Empty(t, closed)
+	require.Len(t, tr.conns, 3)
+
+	c := tr.conns[2].stats
+	assert.Equal(t, network.TCP, c.Type)
+	assert.Equal(t, network.OUTGOING, c.Direction)
+	assert.Equal(t, uint64(10), c.Monotonic.SentBytes)
+	assert.Equal(t, uint64(20), c.Monotonic.RecvBytes)
+	assert.Equal(t, uint32(500), c.RTT)
+	assert.Equal(t, uint32(1234), c.Pid)
+	assert.Equal(t, uint32(42), c.NetNS)
+	assert.True(t, c.IsApproximate)
+
+	assert.Equal(t, network.INCOMING, tr.conns[3].stats.Direction)
+	assert.Equal(t, network.UDP, tr.conns[4].stats.Type)
+
+	listening, err := tr.GetListeningSockets()
+	require.NoError(t, err)
+	require.Len(t, listening, 1)
+	assert.Equal(t, uint16(8080), listening[0].Port)
+
+	// the connections which are no longer listed are closed
+	closed = tr.update(uint64(2*time.Second), []*netlink.InetDiagTCPInfoResp{listener, server}, nil)
+	require.Len(t, closed, 2)
+	assert.Len(t, tr.conns, 1)
+	assert.Equal(t, time.Second, tr.conns[3].stats.Duration)
+}
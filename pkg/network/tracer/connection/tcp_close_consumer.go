@@ -86,6 +86,8 @@ func (c *tcpCloseConsumer) extractConn(data []byte) {
 	conn := c.buffer.Next()
 	populateConnStats(conn, &ct.Tup, &ct.Conn_stats, c.ch)
 	updateTCPStats(conn, &ct.Tcp_stats, ct.Tcp_retransmits)
+	updateTCPCongestion(conn, &ct.Tcp_congestion)
+	conn.Monotonic.Drops = ct.Drops
 }
 
 func (c *tcpCloseConsumer) Start(callback func([]network.ConnectionStats)) {
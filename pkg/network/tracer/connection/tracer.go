@@ -22,6 +22,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/twmb/murmur3"
 	"go.uber.org/atomic"
+	"go4.org/intern"
 	"golang.org/x/sys/unix"
 
 	manager "github.com/DataDog/ebpf-manager"
@@ -34,9 +35,11 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/network/config"
 	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
 	"github.com/DataDog/datadog-agent/pkg/network/ebpf/probes"
+	"github.com/DataDog/datadog-agent/pkg/network/preflight"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols"
 	"github.com/DataDog/datadog-agent/pkg/network/tracer/connection/fentry"
 	"github.com/DataDog/datadog-agent/pkg/network/tracer/connection/kprobe"
+	connutil "github.com/DataDog/datadog-agent/pkg/network/tracer/connection/util"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -54,6 +57,8 @@ const (
 	TracerTypeKProbeCORE
 	//nolint:revive // TODO(NET) Fix revive linter
 	TracerTypeFentry
+	// TracerTypeSockDiag is the degraded mode tracer polling the sockets with sock_diag, used when eBPF is unavailable
+	TracerTypeSockDiag
 )
 
 const (
@@ -71,11 +76,24 @@ type Tracer interface {
 	// GetConnections returns the list of currently active connections, using the buffer provided.
 	// The optional filter function is used to prevent unwanted connections from being returned and consuming resources.
 	GetConnections(buffer *network.ConnectionBuffer, filter func(*network.ConnectionStats) bool) error
+	// GetListeningSockets returns the TCP sockets in the LISTEN state and the bound UDP sockets,
+	// along with the overflows of the queues of the TCP sockets.
+	GetListeningSockets() ([]network.ListeningSocket, error)
+	// GetUnixSockets returns the AF_UNIX sockets which sent data since the tracer was loaded.
+	GetUnixSockets() ([]network.UnixSocket, error)
+	// ExpireUnixSockets deletes the AF_UNIX sockets closed before the given time, in nanoseconds since boot.
+	ExpireUnixSockets(before uint64)
+	// GetPacketDrops returns the number of packets dropped by the kernel by interface and drop reason.
+	GetPacketDrops() ([]network.PacketDrops, error)
 	// FlushPending forces any closed connections waiting for batching to be processed immediately.
 	FlushPending()
 	// Remove deletes the connection from tracking state.
 	// It does not prevent the connection from re-appearing later, if additional traffic occurs.
 	Remove(conn *network.ConnectionStats) error
+	// RemoveBatch deletes the connections from tracking state, in batches when supported by the kernel, and
+	// returns the connections which were deleted. The connections which were already deleted, as when
+	// they were closed in the meantime, are skipped.
+	RemoveBatch(conns []network.ConnectionStats) []*network.ConnectionStats
 	// GetMap returns the underlying named map. This is useful if any maps are shared with other eBPF components.
 	// An individual tracer implementation may choose which maps to expose via this function.
 	GetMap(string) *ebpf.Map
@@ -83,6 +101,8 @@ type Tracer interface {
 	DumpMaps(w io.Writer, maps ...string) error
 	// Type returns the type of the underlying ebpf tracer that is currently loaded
 	Type() TracerType
+	// OptionalProbes returns whether the probes of each enabled optional feature attached
+	OptionalProbes() map[string]bool
 
 	Pause() error
 	Resume() error
@@ -96,6 +116,11 @@ type Tracer interface {
 const (
 	defaultClosedChannelSize = 500
 	connTracerModuleName     = "network_tracer__ebpf"
+	// connMapBatchSize is the number of entries of the connection map read with each batch lookup
+	connMapBatchSize = 1000
+	// maxListeningSockets is the number of sockets of the listening_sockets map, which only holds the TCP
+	// sockets in the LISTEN state and the bound UDP sockets
+	maxListeningSockets = 8192
 )
 
 //nolint:revive // TODO(NET) Fix revive linter
@@ -113,10 +138,13 @@ var ConnTracerTelemetry = struct {
 	//nolint:revive // TODO(NET) Fix revive linter
 	UdpSendsMissed *prometheus.Desc
 	//nolint:revive // TODO(NET) Fix revive linter
-	UdpDroppedConns *prometheus.Desc
-	PidCollisions   *telemetry.StatCounterWrapper
-	iterationDups   telemetry.Counter
-	iterationAborts telemetry.Counter
+	UdpDroppedConns        *prometheus.Desc
+	closedConnOutputFailed *prometheus.Desc
+	optionalProbesAttached *prometheus.Desc
+	ignoredConns           *prometheus.Desc
+	PidCollisions          *telemetry.StatCounterWrapper
+	iterationDups          telemetry.Counter
+	iterationAborts        telemetry.Counter
 
 	//nolint:revive // TODO(NET) Fix revive linter
 	lastTcpFailedConnects *atomic.Int64
@@ -131,7 +159,8 @@ var ConnTracerTelemetry = struct {
 	//nolint:revive // TODO(NET) Fix revive linter
 	lastUdpSendsMissed *atomic.Int64
 	//nolint:revive // TODO(NET) Fix revive linter
-	lastUdpDroppedConns *atomic.Int64
+	lastUdpDroppedConns        *atomic.Int64
+	lastClosedConnOutputFailed *atomic.Int64
 }{
 	telemetry.NewGauge(connTracerModuleName, "connections", []string{"ip_proto", "family"}, "Gauge measuring the number of active connections in the EBPF map"),
 	prometheus.NewDesc(connTracerModuleName+"__tcp_failed_connects", "Counter measuring the number of failed TCP connections in the EBPF map", nil, nil),
@@ -141,6 +170,9 @@ var ConnTracerTelemetry = struct {
 	prometheus.NewDesc(connTracerModuleName+"__udp_sends_processed", "Counter measuring the number of processed UDP sends in EBPF", nil, nil),
 	prometheus.NewDesc(connTracerModuleName+"__udp_sends_missed", "Counter measuring failures to process UDP sends in EBPF", nil, nil),
 	prometheus.NewDesc(connTracerModuleName+"__udp_dropped_conns", "Counter measuring the number of dropped UDP connections in the EBPF map", nil, nil),
+	prometheus.NewDesc(connTracerModuleName+"__closed_conn_output_failed", "Counter measuring the number of closed connection events (single connections or batches) which couldn't be written to the perf or ring buffer", nil, nil),
+	prometheus.NewDesc(connTracerModuleName+"__optional_probes_attached", "Gauge set to 1 when the probes of an enabled optional feature attached, 0 when the feature was disabled", []string{"feature"}, nil),
+	prometheus.NewDesc(connTracerModuleName+"__ignored_conns", "Counter measuring the connections ignored in the kernel by each rule of network_config.ignore_conns", []string{"rule"}, nil),
 	telemetry.NewStatCounterWrapper(connTracerModuleName, "pid_collisions", []string{}, "Counter measuring number of process collisions"),
 	telemetry.NewCounter(connTracerModuleName, "iteration_dups", []string{}, "Counter measuring the number of connections iterated more than once"),
 	telemetry.NewCounter(connTracerModuleName, "iteration_aborts", []string{}, "Counter measuring how many times ebpf iteration of connection map was aborted"),
@@ -151,6 +183,7 @@ var ConnTracerTelemetry = struct {
 	atomic.NewInt64(0),
 	atomic.NewInt64(0),
 	atomic.NewInt64(0),
+	atomic.NewInt64(0),
 }
 
 type tracer struct {
@@ -159,7 +192,37 @@ type tracer struct {
 	conns          *maps.GenericMap[netebpf.ConnTuple, netebpf.ConnStats]
 	tcpStats       *maps.GenericMap[netebpf.ConnTuple, netebpf.TCPStats]
 	tcpRetransmits *maps.GenericMap[netebpf.ConnTuple, uint32]
-	config         *config.Config
+	// listeningSockets is keyed by the kernel address of the socket
+	listeningSockets *maps.GenericMap[uint64, netebpf.ConnTuple]
+	// cgroupConns holds the traffic aggregated by cgroup, when enabled
+	cgroupConns *maps.GenericMap[netebpf.CgroupConnKey, netebpf.CgroupConnStats]
+	// unixSockStats is keyed by the inode number of the socket
+	unixSockStats *maps.GenericMap[uint64, netebpf.UnixSockStats]
+	// listenOverflows is keyed by the port binding of the listening sockets
+	listenOverflows *maps.GenericMap[netebpf.PortBinding, netebpf.ListenOverflow]
+	// connDrops and skbDrops hold the packets dropped by the kernel, when enabled
+	connDrops *maps.GenericMap[netebpf.ConnTuple, uint32]
+	skbDrops  *maps.GenericMap[netebpf.SkbDropKey, uint64]
+	// skbDropsPerCPU replaces skbDrops when the map was loaded as a per-CPU map
+	skbDropsPerCPU *maps.GenericMap[netebpf.SkbDropKey, []uint64]
+	// connQoS holds the QoS marking of the packets sent on each connection, when enabled
+	connQoS *maps.GenericMap[netebpf.ConnTuple, netebpf.ConnQoS]
+	// connProcess holds the process which created each connection, when enabled
+	connProcess *maps.GenericMap[netebpf.ConnTuple, netebpf.ConnProcess]
+	// rttHistograms holds the histogram of the RTT samples of each TCP connection, when enabled
+	rttHistograms *maps.GenericMap[netebpf.ConnTuple, netebpf.RTTHistogram]
+	// tlsInfo holds the metadata of the TLS handshakes, keyed by the normalized tuple without pid and netns
+	tlsInfo *maps.GenericMap[netebpf.ConnTuple, netebpf.TLSInfo]
+	// webSocketSessions holds the sessions of the connections upgraded to WebSocket, keyed like tlsInfo
+	webSocketSessions *maps.GenericMap[netebpf.ConnTuple, netebpf.WebSocketSession]
+	// connFilters holds the rules of network_config.ignore_conns applied by the eBPF programs, whose
+	// rules are in connFilterRules
+	connFilters      *maps.GenericMap[uint32, netebpf.ConnFilter]
+	connFilterRules  []string
+	kernelFilters    []netebpf.ConnFilter
+	ignoredConnsMu   sync.Mutex
+	lastIgnoredConns []uint64
+	config           *config.Config
 
 	// tcp_close events
 	closeConsumer *tcpCloseConsumer
@@ -170,14 +233,38 @@ type tracer struct {
 	stopOnce    sync.Once
 
 	ebpfTracerType TracerType
+	// optionalProbes holds whether the probes of each enabled optional feature attached
+	optionalProbes map[string]bool
 
 	exitTelemetry chan struct{}
 
 	ch *cookieHasher
 }
 
-// NewTracer creates a new tracer
-func NewTracer(config *config.Config) (Tracer, error) {
+// NewTracer creates a new tracer of the mode selected by the capability report of the kernel, which
+// may be nil if the kernel couldn't be probed. If the kernel lacks the features required by the
+// eBPF tracer, or it can't be loaded, and the sock_diag fallback is enabled, a tracer polling the
+// sockets is returned instead.
+func NewTracer(config *config.Config, report *preflight.Report) (Tracer, error) {
+	if report != nil && report.Tracer == preflight.TracerSockDiag && config.EnableSockDiagFallback {
+		log.Warnf("kernel %s doesn't support the eBPF tracer, polling the sockets with sock_diag", report.KernelVersion)
+		return newSockDiagTracer(config)
+	}
+
+	tr, err := newEbpfTracer(config, report)
+	if err == nil || !config.EnableSockDiagFallback {
+		return tr, err
+	}
+
+	log.Warnf("could not load the eBPF tracer, falling back to polling the sockets with sock_diag: %s", err)
+	sdTracer, sdErr := newSockDiagTracer(config)
+	if sdErr != nil {
+		return nil, fmt.Errorf("could not load the eBPF tracer: %w, nor the sock_diag tracer: %s", err, sdErr)
+	}
+	return sdTracer, nil
+}
+
+func newEbpfTracer(config *config.Config, report *preflight.Report) (Tracer, error) {
 	mgrOptions := manager.Options{
 		// Extend RLIMIT_MEMLOCK (8) size
 		// On some systems, the default for RLIMIT_MEMLOCK may be as low as 64 bytes.
@@ -195,6 +282,7 @@ func NewTracer(config *config.Config) (Tracer, error) {
 			probes.TCPRetransmitsMap:                 {MaxEntries: config.MaxTrackedConnections, EditorFlag: manager.EditMaxEntries},
 			probes.PortBindingsMap:                   {MaxEntries: config.MaxTrackedConnections, EditorFlag: manager.EditMaxEntries},
 			probes.UDPPortBindingsMap:                {MaxEntries: config.MaxTrackedConnections, EditorFlag: manager.EditMaxEntries},
+			probes.ListeningSocketsMap:               {MaxEntries: maxListeningSockets, EditorFlag: manager.EditMaxEntries},
 			probes.ConnectionProtocolMap:             {MaxEntries: config.MaxTrackedConnections, EditorFlag: manager.EditMaxEntries},
 			probes.ConnectionTupleToSocketSKBConnMap: {MaxEntries: config.MaxTrackedConnections, EditorFlag: manager.EditMaxEntries},
 		},
@@ -210,11 +298,36 @@ func NewTracer(config *config.Config) (Tracer, error) {
 		DefaultKProbeMaxActive: maxActive,
 	}
 
+	setupPerCPUCounterMaps(&mgrOptions)
+
+	kernelFilters, connFilterRules := kernelConnFilters(network.ParseIgnoreRules(config.IgnoredConnections))
+	// the hash maps are preallocated, so the maps of the disabled features are kept as small as possible
+	for name, enabled := range map[string]bool{
+		probes.UnixSockStatsMap:     config.CollectUnixSockets,
+		probes.CgroupConnStatsMap:   config.EnableCgroupAggregation,
+		probes.ListenOverflowsMap:   config.EnableListenOverflowMonitoring,
+		probes.ConnDropsMap:         config.EnablePacketDropMonitoring,
+		probes.ConnQoSMap:           config.EnableQoSMarking,
+		probes.ConnProcessMap:       config.EnableConnectionProcessInfo,
+		probes.TCPRTTHistogramsMap:  config.EnableRTTHistograms,
+		probes.TLSHandshakeInfoMap:  config.EnableTLSHandshakeInfo,
+		probes.WebSocketSessionsMap: config.EnableWebSocketTracking,
+		probes.IgnoredConnsMap:      len(kernelFilters) > 0,
+	} {
+		mgrOptions.MapSpecEditors[name] = manager.MapSpecEditor{
+			MaxEntries: connutil.FeatureMapMaxEntries(config, enabled),
+			EditorFlag: manager.EditMaxEntries,
+		}
+	}
+
 	begin, end := network.EphemeralRange()
 	mgrOptions.ConstantEditors = append(mgrOptions.ConstantEditors,
 		manager.ConstantEditor{Name: "ephemeral_range_begin", Value: uint64(begin)},
 		manager.ConstantEditor{Name: "ephemeral_range_end", Value: uint64(end)})
 
+	mgrOptions.ConstantEditors = append(mgrOptions.ConstantEditors,
+		manager.ConstantEditor{Name: "conn_filter_count", Value: uint64(len(kernelFilters))})
+
 	closedChannelSize := defaultClosedChannelSize
 	if config.ClosedChannelSize > 0 {
 		closedChannelSize = config.ClosedChannelSize
@@ -229,7 +342,7 @@ func NewTracer(config *config.Config) (Tracer, error) {
 	//nolint:revive // TODO(NET) Fix revive linter
 	var tracerType TracerType = TracerTypeFentry
 	var closeTracerFn func()
-	m, closeTracerFn, err := fentry.LoadTracer(config, mgrOptions, connCloseEventHandler)
+	m, closeTracerFn, err := fentry.LoadTracer(config, report, mgrOptions, connCloseEventHandler)
 	if err != nil && !errors.Is(err, fentry.ErrorNotSupported) {
 		// failed to load fentry tracer
 		return nil, err
@@ -264,6 +377,10 @@ func NewTracer(config *config.Config) (Tracer, error) {
 		ebpfTracerType: tracerType,
 		exitTelemetry:  make(chan struct{}),
 		ch:             newCookieHasher(),
+
+		kernelFilters:    kernelFilters,
+		connFilterRules:  connFilterRules,
+		lastIgnoredConns: make([]uint64, len(kernelFilters)),
 	}
 
 	tr.conns, err = maps.GetMap[netebpf.ConnTuple, netebpf.ConnStats](m, probes.ConnMap)
@@ -283,6 +400,71 @@ func NewTracer(config *config.Config) (Tracer, error) {
 		return nil, fmt.Errorf("error retrieving the bpf %s map: %s", probes.TCPRetransmitsMap, err)
 	}
 
+	if tr.listeningSockets, err = maps.GetMap[uint64, netebpf.ConnTuple](m, probes.ListeningSocketsMap); err != nil {
+		tr.Stop()
+		return nil, fmt.Errorf("error retrieving the bpf %s map: %s", probes.ListeningSocketsMap, err)
+	}
+
+	if tr.cgroupConns, err = maps.GetMap[netebpf.CgroupConnKey, netebpf.CgroupConnStats](m, probes.CgroupConnStatsMap); err != nil {
+		tr.Stop()
+		return nil, fmt.Errorf("error retrieving the bpf %s map: %s", probes.CgroupConnStatsMap, err)
+	}
+
+	if tr.unixSockStats, err = maps.GetMap[uint64, netebpf.UnixSockStats](m, probes.UnixSockStatsMap); err != nil {
+		tr.Stop()
+		return nil, fmt.Errorf("error retrieving the bpf %s map: %s", probes.UnixSockStatsMap, err)
+	}
+
+	if tr.listenOverflows, err = maps.GetMap[netebpf.PortBinding, netebpf.ListenOverflow](m, probes.ListenOverflowsMap); err != nil {
+		tr.Stop()
+		return nil, fmt.Errorf("error retrieving the bpf %s map: %s", probes.ListenOverflowsMap, err)
+	}
+
+	if tr.connDrops, err = maps.GetMap[netebpf.ConnTuple, uint32](m, probes.ConnDropsMap); err != nil {
+		tr.Stop()
+		return nil, fmt.Errorf("error retrieving the bpf %s map: %s", probes.ConnDropsMap, err)
+	}
+
+	if tr.connQoS, err = maps.GetMap[netebpf.ConnTuple, netebpf.ConnQoS](m, probes.ConnQoSMap); err != nil {
+		tr.Stop()
+		return nil, fmt.Errorf("error retrieving the bpf %s map: %s", probes.ConnQoSMap, err)
+	}
+
+	if isPerCPUMap(m, probes.SKBDropsMap) {
+		tr.skbDropsPerCPU, err = maps.GetMap[netebpf.SkbDropKey, []uint64](m, probes.SKBDropsMap)
+	} else {
+		tr.skbDrops, err = maps.GetMap[netebpf.SkbDropKey, uint64](m, probes.SKBDropsMap)
+	}
+	if err != nil {
+		tr.Stop()
+		return nil, fmt.Errorf("error retrieving the bpf %s map: %s", probes.SKBDropsMap, err)
+	}
+
+	if tr.connProcess, err = maps.GetMap[netebpf.ConnTuple, netebpf.ConnProcess](m, probes.ConnProcessMap); err != nil {
+		tr.Stop()
+		return nil, fmt.Errorf("error retrieving the bpf %s map: %s", probes.ConnProcessMap, err)
+	}
+
+	if tr.rttHistograms, err = maps.GetMap[netebpf.ConnTuple, netebpf.RTTHistogram](m, probes.TCPRTTHistogramsMap); err != nil {
+		tr.Stop()
+		return nil, fmt.Errorf("error retrieving the bpf %s map: %s", probes.TCPRTTHistogramsMap, err)
+	}
+
+	if tr.tlsInfo, err = maps.GetMap[netebpf.ConnTuple, netebpf.TLSInfo](m, probes.TLSHandshakeInfoMap); err != nil {
+		tr.Stop()
+		return nil, fmt.Errorf("error retrieving the bpf %s map: %s", probes.TLSHandshakeInfoMap, err)
+	}
+
+	if tr.webSocketSessions, err = maps.GetMap[netebpf.ConnTuple, netebpf.WebSocketSession](m, probes.WebSocketSessionsMap); err != nil {
+		tr.Stop()
+		return nil, fmt.Errorf("error retrieving the bpf %s map: %s", probes.WebSocketSessionsMap, err)
+	}
+
+	if tr.connFilters, err = maps.GetMap[uint32, netebpf.ConnFilter](m, probes.ConnFiltersMap); err != nil {
+		tr.Stop()
+		return nil, fmt.Errorf("error retrieving the bpf %s map: %s", probes.ConnFiltersMap, err)
+	}
+
 	return tr, nil
 }
 
@@ -310,14 +492,107 @@ func (t *tracer) Start(callback func([]network.ConnectionStats)) (err error) {
 		return fmt.Errorf("error initializing port binding maps: %s", err)
 	}
 
+	for i := range t.kernelFilters {
+		key := uint32(i)
+		if err := t.connFilters.Put(&key, &t.kernelFilters[i]); err != nil {
+			return fmt.Errorf("error initializing the connection filters: %s", err)
+		}
+	}
+
+	// the entries of the connection map are prepared before the probes are attached, so that
+	// all of them come from the previous instance of system-probe
+	var closedWhileDown []network.ConnectionStats
+	if t.config.PinnedMapsDir != "" {
+		closedWhileDown = t.preparePinnedConnections()
+	}
+
 	if err := t.m.Start(); err != nil {
 		return fmt.Errorf("could not start ebpf manager: %s", err)
 	}
+	t.optionalProbes = kprobe.CheckOptionalProbes(t.m)
 
+	// seeded once the probes are attached, so that no connection falls in between
+	if t.config.SeedExistingConnections {
+		seedExistingConnections(t.conns)
+	}
+
+	if t.config.EnableConnectionProcessInfo {
+		callback = t.withClosedConnProcess(callback)
+	}
+	if t.config.EnableRTTHistograms {
+		callback = t.withClosedRTTHistogram(callback)
+	}
+	if t.config.EnableTLSHandshakeInfo {
+		callback = t.withClosedTLSInfo(callback)
+	}
+	if t.config.EnableWebSocketTracking {
+		callback = t.withClosedWebSocketSession(callback)
+	}
 	t.closeConsumer.Start(callback)
+	if len(closedWhileDown) > 0 {
+		callback(closedWhileDown)
+	}
 	return nil
 }
 
+// withClosedConnProcess wraps the callback of closed connections to add the process which
+// created them. The process entries aren't deleted when the connections are closed, so that
+// they can be read here.
+func (t *tracer) withClosedConnProcess(callback func([]network.ConnectionStats)) func([]network.ConnectionStats) {
+	tuple := &netebpf.ConnTuple{}
+	return func(conns []network.ConnectionStats) {
+		for i := range conns {
+			toConnTuple(&conns[i], tuple)
+			if t.getConnProcess(&conns[i], tuple) {
+				_ = t.connProcess.Delete(tuple)
+			}
+		}
+		callback(conns)
+	}
+}
+
+// withClosedRTTHistogram wraps the callback of closed connections to add the histogram of their RTT
+// samples. As for the processes, the entries are deleted once read.
+func (t *tracer) withClosedRTTHistogram(callback func([]network.ConnectionStats)) func([]network.ConnectionStats) {
+	tuple := &netebpf.ConnTuple{}
+	return func(conns []network.ConnectionStats) {
+		for i := range conns {
+			toConnTuple(&conns[i], tuple)
+			if t.getRTTHistogram(&conns[i], tuple) {
+				_ = t.rttHistograms.Delete(tuple)
+			}
+		}
+		callback(conns)
+	}
+}
+
+// withClosedTLSInfo wraps the callback of closed connections to add the metadata of their TLS
+// handshake. The entries are shared by both ends of the localhost connections, so they are left
+// for the LRU to evict.
+func (t *tracer) withClosedTLSInfo(callback func([]network.ConnectionStats)) func([]network.ConnectionStats) {
+	tuple := &netebpf.ConnTuple{}
+	return func(conns []network.ConnectionStats) {
+		for i := range conns {
+			toConnTuple(&conns[i], tuple)
+			t.getTLSInfo(&conns[i], tuple)
+		}
+		callback(conns)
+	}
+}
+
+// withClosedWebSocketSession wraps the callback of closed connections to add their WebSocket session. As
+// for the TLS handshakes, the entries are left for the LRU to evict.
+func (t *tracer) withClosedWebSocketSession(callback func([]network.ConnectionStats)) func([]network.ConnectionStats) {
+	tuple := &netebpf.ConnTuple{}
+	return func(conns []network.ConnectionStats) {
+		for i := range conns {
+			toConnTuple(&conns[i], tuple)
+			t.getWebSocketSession(&conns[i], tuple)
+		}
+		callback(conns)
+	}
+}
+
 func (t *tracer) Pause() error {
 	// add small delay for socket filters to properly detach
 	time.Sleep(1 * time.Millisecond)
@@ -340,7 +615,7 @@ func (t *tracer) Stop() {
 		close(t.exitTelemetry)
 		ebpfcheck.RemoveNameMappings(t.m)
 		ebpftelemetry.UnregisterTelemetry(t.m)
-		_ = t.m.Stop(manager.CleanAll)
+		_ = t.m.Stop(connutil.MapCleanupType(t.config))
 		t.closeConsumer.Stop()
 		if t.closeTracer != nil {
 			t.closeTracer()
@@ -364,6 +639,7 @@ func (t *tracer) GetConnections(buffer *network.ConnectionBuffer, filter func(*n
 	// Iterate through all key-value pairs in map
 	key, stats := &netebpf.ConnTuple{}, &netebpf.ConnStats{}
 	seen := make(map[netebpf.ConnTuple]struct{})
+	seenDrops := make(map[netebpf.ConnTuple]struct{})
 	// connsByTuple is used to detect whether we are iterating over
 	// a connection we have previously seen. This can happen when
 	// ebpf maps are being iterated over and deleted at the same time.
@@ -374,9 +650,10 @@ func (t *tracer) GetConnections(buffer *network.ConnectionBuffer, filter func(*n
 	// Cached objects
 	conn := new(network.ConnectionStats)
 	tcp := new(netebpf.TCPStats)
+	start := buffer.Len()
 
 	var tcp4, tcp6, udp4, udp6 float64
-	entries := t.conns.Iterate()
+	entries := t.conns.IterateWithBatchSize(connMapBatchSize)
 	for entries.Next(key, stats) {
 		if _, exists := connsByTuple[*key]; exists {
 			// already seen the connection in current batch processing,
@@ -414,6 +691,24 @@ func (t *tracer) GetConnections(buffer *network.ConnectionBuffer, filter func(*n
 		if retrans, ok := t.getTCPRetransmits(key, seen); ok {
 			updateTCPStats(conn, nil, retrans)
 		}
+		if t.config.EnablePacketDropMonitoring {
+			conn.Monotonic.Drops = t.getDrops(key, seenDrops)
+		}
+		if t.config.EnableQoSMarking {
+			t.getQoS(conn, key)
+		}
+		if t.config.EnableConnectionProcessInfo {
+			t.getConnProcess(conn, key)
+		}
+		if t.config.EnableRTTHistograms {
+			t.getRTTHistogram(conn, key)
+		}
+		if t.config.EnableTLSHandshakeInfo {
+			t.getTLSInfo(conn, key)
+		}
+		if t.config.EnableWebSocketTracking {
+			t.getWebSocketSession(conn, key)
+		}
 
 		*buffer.Next() = *conn
 	}
@@ -427,11 +722,88 @@ func (t *tracer) GetConnections(buffer *network.ConnectionBuffer, filter func(*n
 		ConnTracerTelemetry.iterationAborts.Inc()
 	}
 
+	if t.config.SeedExistingConnections {
+		conns := buffer.Connections()[start:]
+		buffer.Reclaim(len(conns) - mergeSeededConnections(conns))
+	}
+
+	if t.config.EnableCgroupAggregation {
+		if err := t.getCgroupConnections(buffer, filter); err != nil {
+			return err
+		}
+	}
+
 	updateTelemetry(tcp4, tcp6, udp4, udp6)
 
 	return nil
 }
 
+// getCgroupConnections adds the traffic aggregated by cgroup to the buffer. Each aggregate is
+// reported as a connection without a local address and an ephemeral port, tagged with its cgroup.
+func (t *tracer) getCgroupConnections(buffer *network.ConnectionBuffer, filter func(*network.ConnectionStats) bool) error {
+	key, stats := &netebpf.CgroupConnKey{}, &netebpf.CgroupConnStats{}
+	seen := make(map[netebpf.CgroupConnKey]struct{})
+	conn := new(network.ConnectionStats)
+	entries := t.cgroupConns.Iterate()
+	for entries.Next(key, stats) {
+		if _, exists := seen[*key]; exists {
+			ConnTracerTelemetry.iterationDups.Inc()
+			continue
+		}
+		seen[*key] = struct{}{}
+
+		populateCgroupConnStats(conn, key, stats, t.ch)
+		if filter != nil && !filter(conn) {
+			continue
+		}
+		*buffer.Next() = *conn
+	}
+
+	if err := entries.Err(); err != nil {
+		if !errors.Is(err, ebpf.ErrIterationAborted) {
+			return fmt.Errorf("unable to iterate cgroup connection map: %w", err)
+		}
+
+		log.Warn("eBPF cgroup_conn_stats map iteration aborted. Some connections may not be reported")
+		ConnTracerTelemetry.iterationAborts.Inc()
+	}
+	return nil
+}
+
+func populateCgroupConnStats(stats *network.ConnectionStats, k *netebpf.CgroupConnKey, s *netebpf.CgroupConnStats, ch *cookieHasher) {
+	t := netebpf.ConnTuple{
+		Daddr_h:  k.Raddr_h,
+		Daddr_l:  k.Raddr_l,
+		Netns:    k.Netns,
+		Metadata: k.Metadata,
+	}
+	cs := netebpf.ConnStats{
+		Timestamp: s.Timestamp,
+		Direction: k.Direction,
+	}
+	if netebpf.ConnDirection(k.Direction) == netebpf.Incoming {
+		t.Sport = k.Port
+	} else {
+		t.Dport = k.Port
+	}
+
+	populateConnStats(stats, &t, &cs, nil)
+	stats.Source = util.Address{}
+	stats.CgroupID = k.Cgroup_id
+	// the cgroup id is part of the cookie, so that the aggregates
+	// of different cgroups sharing a destination are kept apart
+	stats.Cookie = network.StatCookie(k.Cgroup_id)
+	stats.Monotonic = network.StatCounters{
+		SentBytes:   s.Sent_bytes,
+		RecvBytes:   s.Recv_bytes,
+		SentPackets: s.Sent_packets,
+		RecvPackets: s.Recv_packets,
+	}
+	if ch != nil {
+		ch.Hash(stats)
+	}
+}
+
 func updateTelemetry(tcp4 float64, tcp6 float64, udp4 float64, udp6 float64) {
 	ConnTracerTelemetry.connections.Set(tcp4, "tcp", "v4")
 	ConnTracerTelemetry.connections.Set(tcp6, "tcp", "v6")
@@ -457,25 +829,175 @@ func removeConnection(conn *network.ConnectionStats) {
 	}
 }
 
-func (t *tracer) Remove(conn *network.ConnectionStats) error {
-	t.removeTuple.Sport = conn.SPort
-	t.removeTuple.Dport = conn.DPort
-	t.removeTuple.Netns = conn.NetNS
-	t.removeTuple.Pid = conn.Pid
-	t.removeTuple.Saddr_l, t.removeTuple.Saddr_h = util.ToLowHigh(conn.Source)
-	t.removeTuple.Daddr_l, t.removeTuple.Daddr_h = util.ToLowHigh(conn.Dest)
+// GetListeningSockets returns the TCP sockets in the LISTEN state and the bound UDP sockets.
+// Sockets which started listening before the tracer was loaded are not reported.
+func (t *tracer) GetListeningSockets() ([]network.ListeningSocket, error) {
+	var sockets []network.ListeningSocket
+	var sk uint64
+	tuple := new(netebpf.ConnTuple)
+	seen := make(map[uint64]struct{})
+	entries := t.listeningSockets.Iterate()
+	for entries.Next(&sk, tuple) {
+		// the iteration can restart if entries are deleted concurrently
+		if _, ok := seen[sk]; ok {
+			continue
+		}
+		seen[sk] = struct{}{}
 
-	if conn.Family == network.AFINET6 {
-		t.removeTuple.Metadata = uint32(netebpf.IPv6)
-	} else {
-		t.removeTuple.Metadata = uint32(netebpf.IPv4)
+		s := network.ListeningSocket{
+			Addr:  tuple.SourceAddress(),
+			Port:  tuple.Sport,
+			NetNS: tuple.Netns,
+			Pid:   tuple.Pid,
+		}
+		if tuple.Type() == netebpf.TCP {
+			s.Type = network.TCP
+		} else {
+			s.Type = network.UDP
+		}
+		if tuple.Family() == netebpf.IPv6 {
+			s.Family = network.AFINET6
+		}
+		sockets = append(sockets, s)
 	}
-	if conn.Type == network.TCP {
-		t.removeTuple.Metadata |= uint32(netebpf.TCP)
-	} else {
-		t.removeTuple.Metadata |= uint32(netebpf.UDP)
+
+	if err := entries.Err(); err != nil {
+		return nil, fmt.Errorf("unable to iterate listening sockets map: %w", err)
+	}
+
+	if t.config.EnableListenOverflowMonitoring {
+		overflows, err := t.getListenOverflows()
+		if err != nil {
+			return nil, err
+		}
+		sockets = network.AddListenOverflows(sockets, overflows)
+	}
+
+	return sockets, nil
+}
+
+func (t *tracer) getListenOverflows() (map[network.PortMapping]network.ListenOverflow, error) {
+	overflows := make(map[network.PortMapping]network.ListenOverflow)
+	pb := new(netebpf.PortBinding)
+	o := new(netebpf.ListenOverflow)
+	entries := t.listenOverflows.Iterate()
+	for entries.Next(pb, o) {
+		overflows[network.PortMapping{Ino: pb.Netns, Port: pb.Port}] = network.ListenOverflow{
+			AcceptQueueDrops:    o.Accept_queue_drops,
+			SynBacklogOverflows: o.Syn_backlog_overflows,
+		}
+	}
+
+	if err := entries.Err(); err != nil {
+		return nil, fmt.Errorf("unable to iterate listen overflows map: %w", err)
+	}
+
+	return overflows, nil
+}
+
+// GetUnixSockets returns the AF_UNIX sockets which sent data since the tracer was loaded.
+// Closed sockets are reported until they are removed from the map by ExpireUnixSockets, so
+// that every reader gets their final stats.
+func (t *tracer) GetUnixSockets() ([]network.UnixSocket, error) {
+	var sockets []network.UnixSocket
+	var ino uint64
+	stats := new(netebpf.UnixSockStats)
+	seen := make(map[uint64]struct{})
+	entries := t.unixSockStats.Iterate()
+	for entries.Next(&ino, stats) {
+		// the iteration can restart if entries are deleted concurrently
+		if _, ok := seen[ino]; ok {
+			continue
+		}
+		seen[ino] = struct{}{}
+
+		pathLen := int(stats.Path_len)
+		if pathLen > len(stats.Path) {
+			pathLen = len(stats.Path)
+		}
+		path := make([]byte, pathLen)
+		for i := range path {
+			path[i] = byte(stats.Path[i])
+		}
+
+		sockets = append(sockets, network.UnixSocket{
+			Type:            network.UnixSocketType(stats.Type),
+			Ino:             ino,
+			PeerIno:         stats.Peer_ino,
+			Path:            network.UnixSocketPath(path),
+			Pid:             stats.Pid,
+			SentBytes:       stats.Sent_bytes,
+			SentPackets:     stats.Sent_packets,
+			LastUpdateEpoch: stats.Timestamp,
+			Closed:          stats.Closed != 0,
+		})
+	}
+
+	if err := entries.Err(); err != nil {
+		return nil, fmt.Errorf("unable to iterate unix sockets map: %w", err)
+	}
+
+	network.ResolveUnixSocketPeers(sockets)
+	return sockets, nil
+}
+
+// ExpireUnixSockets deletes the AF_UNIX sockets closed before the given time, in nanoseconds since boot,
+// which is the only way closed sockets leave the map.
+func (t *tracer) ExpireUnixSockets(before uint64) {
+	var expired []uint64
+	var ino uint64
+	stats := new(netebpf.UnixSockStats)
+	entries := t.unixSockStats.Iterate()
+	for entries.Next(&ino, stats) {
+		if stats.Closed != 0 && stats.Timestamp < before {
+			expired = append(expired, ino)
+		}
+	}
+	if err := entries.Err(); err != nil {
+		log.Warnf("unable to iterate unix sockets map: %s", err)
+	}
+
+	for i := range expired {
+		_ = t.unixSockStats.Delete(&expired[i])
+	}
+}
+
+// GetPacketDrops returns the number of packets dropped by the kernel by interface and drop reason,
+// since the tracer was loaded.
+func (t *tracer) GetPacketDrops() ([]network.PacketDrops, error) {
+	if t.skbDropsPerCPU != nil {
+		return iteratePacketDrops(t.skbDropsPerCPU, sumPerCPU)
+	}
+	return iteratePacketDrops(t.skbDrops, func(count uint64) uint64 { return count })
+}
+
+func iteratePacketDrops[V any](m *maps.GenericMap[netebpf.SkbDropKey, V], count func(V) uint64) ([]network.PacketDrops, error) {
+	var drops []network.PacketDrops
+	var value V
+	key := new(netebpf.SkbDropKey)
+	entries := m.Iterate()
+	for entries.Next(key, &value) {
+		drops = append(drops, network.PacketDrops{
+			Ifindex: key.Ifindex,
+			Reason:  key.Reason,
+			Count:   count(value),
+		})
+	}
+
+	if err := entries.Err(); err != nil {
+		return nil, fmt.Errorf("unable to iterate packet drops map: %w", err)
+	}
+
+	return drops, nil
+}
+
+func (t *tracer) Remove(conn *network.ConnectionStats) error {
+	if conn.CgroupID != 0 {
+		return t.removeCgroupConn(conn)
 	}
 
+	toConnTuple(conn, t.removeTuple)
+
 	err := t.conns.Delete(t.removeTuple)
 	if err != nil {
 		// If this entry no longer exists in the eBPF map it means `tcp_close` has executed
@@ -487,6 +1009,9 @@ func (t *tracer) Remove(conn *network.ConnectionStats) error {
 	}
 
 	removeConnection(conn)
+	if t.config.EnableConnectionProcessInfo {
+		_ = t.connProcess.Delete(t.removeTuple)
+	}
 
 	// We have to remove the PID to remove the element from the TCP Map since we don't use the pid there
 	t.removeTuple.Pid = 0
@@ -494,11 +1019,155 @@ func (t *tracer) Remove(conn *network.ConnectionStats) error {
 		// We can ignore the error for this map since it will not always contain the entry
 		_ = t.tcpStats.Delete(t.removeTuple)
 	}
+	if t.config.EnablePacketDropMonitoring {
+		_ = t.connDrops.Delete(t.removeTuple)
+	}
+	if t.config.EnableQoSMarking {
+		_ = t.connQoS.Delete(t.removeTuple)
+	}
+	return nil
+}
+
+func (t *tracer) RemoveBatch(conns []network.ConnectionStats) []*network.ConnectionStats {
+	removed := make([]*network.ConnectionStats, 0, len(conns))
+	if !maps.BatchAPISupported() {
+		for i := range conns {
+			if err := t.Remove(&conns[i]); err != nil {
+				if !errors.Is(err, ebpf.ErrKeyNotExist) {
+					log.Warnf("failed to remove entry from connections: %s", err)
+				}
+				continue
+			}
+			removed = append(removed, &conns[i])
+		}
+		return removed
+	}
+
+	pending := make([]*network.ConnectionStats, 0, len(conns))
+	keys := make([]netebpf.ConnTuple, 0, len(conns))
+	for i := range conns {
+		conn := &conns[i]
+		if conn.CgroupID != 0 {
+			if err := t.removeCgroupConn(conn); err != nil {
+				if !errors.Is(err, ebpf.ErrKeyNotExist) {
+					log.Warnf("failed to remove entry from connections: %s", err)
+				}
+				continue
+			}
+			removed = append(removed, conn)
+			continue
+		}
+		var key netebpf.ConnTuple
+		toConnTuple(conn, &key)
+		pending = append(pending, conn)
+		keys = append(keys, key)
+	}
+
+	deleted := make([]bool, len(keys))
+	if err := batchDelete(t.conns, keys, deleted); err != nil {
+		log.Warnf("failed to remove entries from connections: %s", err)
+	}
+
+	// the entries of the other maps are only deleted along with the connections
+	var procKeys, tcpKeys, dropKeys, qosKeys []netebpf.ConnTuple
+	for i, conn := range pending {
+		if !deleted[i] {
+			continue
+		}
+		removeConnection(conn)
+		removed = append(removed, conn)
+
+		if t.config.EnableConnectionProcessInfo {
+			procKeys = append(procKeys, keys[i])
+		}
+		// the TCP stats, the drops and the QoS marking aren't keyed by pid
+		key := keys[i]
+		key.Pid = 0
+		if conn.Type == network.TCP {
+			tcpKeys = append(tcpKeys, key)
+		}
+		if t.config.EnablePacketDropMonitoring {
+			dropKeys = append(dropKeys, key)
+		}
+		if t.config.EnableQoSMarking {
+			qosKeys = append(qosKeys, key)
+		}
+	}
+	// We can ignore the errors for these maps since they will not always contain the entries
+	_ = batchDelete(t.connProcess, procKeys, nil)
+	_ = batchDelete(t.tcpStats, tcpKeys, nil)
+	_ = batchDelete(t.connDrops, dropKeys, nil)
+	_ = batchDelete(t.connQoS, qosKeys, nil)
+	return removed
+}
+
+// batchDelete deletes the keys from the map and, if given, reports in deleted whether each key was deleted.
+// The kernel stops a batch at the first key missing from the map, so the deletion resumes after it.
+func batchDelete[K any, V any](m *maps.GenericMap[K, V], keys []K, deleted []bool) error {
+	for start := 0; start < len(keys); {
+		n, err := m.BatchDelete(keys[start:])
+		if deleted != nil {
+			for i := start; i < start+n; i++ {
+				deleted[i] = true
+			}
+		}
+		start += n
+		if err != nil {
+			if !errors.Is(err, ebpf.ErrKeyNotExist) {
+				return err
+			}
+			start++
+		}
+	}
 	return nil
 }
 
+func (t *tracer) removeCgroupConn(conn *network.ConnectionStats) error {
+	key := netebpf.CgroupConnKey{
+		Cgroup_id: conn.CgroupID,
+		Netns:     conn.NetNS,
+		Port:      conn.DPort,
+		Direction: uint8(netebpf.Outgoing),
+	}
+	key.Raddr_l, key.Raddr_h = util.ToLowHigh(conn.Dest)
+	if conn.Direction == network.INCOMING {
+		key.Port = conn.SPort
+		key.Direction = uint8(netebpf.Incoming)
+	}
+
+	if conn.Family == network.AFINET6 {
+		key.Metadata = uint32(netebpf.IPv6)
+	} else {
+		key.Metadata = uint32(netebpf.IPv4)
+	}
+	switch conn.Type {
+	case network.TCP:
+		key.Metadata |= uint32(netebpf.TCP)
+	case network.SCTP:
+		key.Metadata |= uint32(netebpf.SCTP)
+	default:
+		key.Metadata |= uint32(netebpf.UDP)
+	}
+
+	return t.cgroupConns.Delete(&key)
+}
+
 func (t *tracer) getEBPFTelemetry() *netebpf.Telemetry {
 	var zero uint32
+	if isPerCPUMap(t.m, probes.TelemetryMap) {
+		mp, err := maps.GetMap[uint32, []netebpf.Telemetry](t.m, probes.TelemetryMap)
+		if err != nil {
+			log.Warnf("error retrieving telemetry map: %s", err)
+			return nil
+		}
+		var perCPU []netebpf.Telemetry
+		if err := mp.Lookup(&zero, &perCPU); err != nil {
+			log.Tracef("error retrieving the telemetry struct: %s", err)
+			return nil
+		}
+		return sumTelemetry(perCPU)
+	}
+
 	mp, err := maps.GetMap[uint32, netebpf.Telemetry](t.m, probes.TelemetryMap)
 	if err != nil {
 		log.Warnf("error retrieving telemetry map: %s", err)
@@ -526,10 +1195,22 @@ func (t *tracer) Describe(ch chan<- *prometheus.Desc) {
 	ch <- ConnTracerTelemetry.UdpSendsProcessed
 	ch <- ConnTracerTelemetry.UdpSendsMissed
 	ch <- ConnTracerTelemetry.UdpDroppedConns
+	ch <- ConnTracerTelemetry.closedConnOutputFailed
+	ch <- ConnTracerTelemetry.optionalProbesAttached
+	ch <- ConnTracerTelemetry.ignoredConns
 }
 
 // Collect returns the current state of all metrics of the collector
 func (t *tracer) Collect(ch chan<- prometheus.Metric) {
+	for feature, attached := range t.optionalProbes {
+		var value float64
+		if attached {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(ConnTracerTelemetry.optionalProbesAttached, prometheus.GaugeValue, value, feature)
+	}
+	t.collectIgnoredConns(ch)
+
 	ebpfTelemetry := t.getEBPFTelemetry()
 	if ebpfTelemetry == nil {
 		return
@@ -561,6 +1242,31 @@ func (t *tracer) Collect(ch chan<- prometheus.Metric) {
 	delta = int64(ebpfTelemetry.Udp_dropped_conns) - ConnTracerTelemetry.lastUdpDroppedConns.Load()
 	ConnTracerTelemetry.lastUdpDroppedConns.Store(int64(ebpfTelemetry.Udp_dropped_conns))
 	ch <- prometheus.MustNewConstMetric(ConnTracerTelemetry.UdpDroppedConns, prometheus.CounterValue, float64(delta))
+
+	delta = int64(ebpfTelemetry.Closed_conn_output_failed) - ConnTracerTelemetry.lastClosedConnOutputFailed.Load()
+	ConnTracerTelemetry.lastClosedConnOutputFailed.Store(int64(ebpfTelemetry.Closed_conn_output_failed))
+	ch <- prometheus.MustNewConstMetric(ConnTracerTelemetry.closedConnOutputFailed, prometheus.CounterValue, float64(delta))
+}
+
+// collectIgnoredConns reports the connections ignored by the eBPF programs since the last collection,
+// by rule of network_config.ignore_conns
+func (t *tracer) collectIgnoredConns(ch chan<- prometheus.Metric) {
+	t.ignoredConnsMu.Lock()
+	defer t.ignoredConnsMu.Unlock()
+
+	deltas := make(map[string]uint64)
+	for i := range t.lastIgnoredConns {
+		key := uint32(i)
+		var f netebpf.ConnFilter
+		if err := t.connFilters.Lookup(&key, &f); err != nil {
+			continue
+		}
+		deltas[t.connFilterRules[i]] += f.Ignored - t.lastIgnoredConns[i]
+		t.lastIgnoredConns[i] = f.Ignored
+	}
+	for rule, delta := range deltas {
+		ch <- prometheus.MustNewConstMetric(ConnTracerTelemetry.ignoredConns, prometheus.CounterValue, float64(delta), rule)
+	}
 }
 
 // DumpMaps (for debugging purpose) returns all maps content by default or selected maps from maps parameter.
@@ -573,6 +1279,11 @@ func (t *tracer) Type() TracerType {
 	return t.ebpfTracerType
 }
 
+// OptionalProbes returns whether the probes of each enabled optional feature attached
+func (t *tracer) OptionalProbes() map[string]bool {
+	return t.optionalProbes
+}
+
 func initializePortBindingMaps(config *config.Config, m *manager.Manager) error {
 	tcpPorts, err := network.ReadInitialState(config.ProcRoot, network.TCP, config.CollectTCPv6Conns)
 	if err != nil {
@@ -583,10 +1294,16 @@ func initializePortBindingMaps(config *config.Config, m *manager.Manager) error
 	if err != nil {
 		return fmt.Errorf("failed to get TCP port binding map: %w", err)
 	}
+	// the bindings pinned by the previous instance of system-probe are replaced by the current ones
+	updateFlags := ebpf.UpdateNoExist
+	if config.PinnedMapsDir != "" {
+		updateFlags = ebpf.UpdateAny
+		discardStalePortBindings(tcpPortMap, tcpPorts)
+	}
 	for p, count := range tcpPorts {
 		log.Debugf("adding initial TCP port binding: netns: %d port: %d", p.Ino, p.Port)
 		pb := netebpf.PortBinding{Netns: p.Ino, Port: p.Port}
-		err = tcpPortMap.Update(&pb, &count, ebpf.UpdateNoExist)
+		err = tcpPortMap.Update(&pb, &count, updateFlags)
 		if err != nil && !errors.Is(err, ebpf.ErrKeyExist) {
 			return fmt.Errorf("failed to update TCP port binding map: %w", err)
 		}
@@ -601,6 +1318,9 @@ func initializePortBindingMaps(config *config.Config, m *manager.Manager) error
 	if err != nil {
 		return fmt.Errorf("failed to get UDP port binding map: %w", err)
 	}
+	if config.PinnedMapsDir != "" {
+		discardStalePortBindings(udpPortMap, udpPorts)
+	}
 	for p, count := range udpPorts {
 		// ignore ephemeral port binds as they are more likely to be from
 		// clients calling bind with port 0
@@ -611,7 +1331,7 @@ func initializePortBindingMaps(config *config.Config, m *manager.Manager) error
 
 		log.Debugf("adding initial UDP port binding: netns: %d port: %d", p.Ino, p.Port)
 		pb := netebpf.PortBinding{Netns: p.Ino, Port: p.Port}
-		err = udpPortMap.Update(&pb, &count, ebpf.UpdateNoExist)
+		err = udpPortMap.Update(&pb, &count, updateFlags)
 		if err != nil && !errors.Is(err, ebpf.ErrKeyExist) {
 			return fmt.Errorf("failed to update UDP port binding map: %w", err)
 		}
@@ -643,6 +1363,175 @@ func (t *tracer) getTCPRetransmits(tuple *netebpf.ConnTuple, seen map[netebpf.Co
 	return retransmits, true
 }
 
+// getQoS sets the QoS marking of the packets sent on the given connection
+func (t *tracer) getQoS(conn *network.ConnectionStats, tuple *netebpf.ConnTuple) {
+	// The PID isn't used as a key in the QoS map, we will temporarily set it to 0 here and reset it when we're done
+	pid := tuple.Pid
+	tuple.Pid = 0
+	defer func() { tuple.Pid = pid }()
+
+	var qos netebpf.ConnQoS
+	if err := t.connQoS.Lookup(tuple, &qos); err != nil {
+		return
+	}
+	conn.DSCP = qos.Dscp
+	conn.FlowLabel = qos.Flow_label
+}
+
+// getDrops returns the number of packets of the given connection dropped by the kernel
+func (t *tracer) getDrops(tuple *netebpf.ConnTuple, seen map[netebpf.ConnTuple]struct{}) uint32 {
+	// The PID isn't used as a key in the drops map, we will temporarily set it to 0 here and reset it when we're done
+	pid := tuple.Pid
+	tuple.Pid = 0
+	defer func() { tuple.Pid = pid }()
+
+	var drops uint32
+	if err := t.connDrops.Lookup(tuple, &drops); err != nil {
+		return 0
+	}
+	// connections sharing the same socket must not report the drops twice
+	if _, reported := seen[*tuple]; reported {
+		return 0
+	}
+	seen[*tuple] = struct{}{}
+	return drops
+}
+
+// getConnProcess adds the process which created the connection, returning false if it wasn't captured
+func (t *tracer) getConnProcess(conn *network.ConnectionStats, tuple *netebpf.ConnTuple) bool {
+	var proc netebpf.ConnProcess
+	if err := t.connProcess.Lookup(tuple, &proc); err != nil {
+		return false
+	}
+	populateConnProcess(conn, &proc)
+	return true
+}
+
+// getRTTHistogram adds the histogram of the RTT samples of a TCP connection, returning false if none was recorded
+func (t *tracer) getRTTHistogram(conn *network.ConnectionStats, tuple *netebpf.ConnTuple) bool {
+	if conn.Type != network.TCP {
+		return false
+	}
+
+	var h netebpf.RTTHistogram
+	if err := t.rttHistograms.Lookup(tuple, &h); err != nil {
+		return false
+	}
+	conn.RTTHistogram = network.RTTHistogram(h.Buckets)
+	return true
+}
+
+func populateConnProcess(conn *network.ConnectionStats, p *netebpf.ConnProcess) {
+	conn.Process = network.ProcessInfo{
+		CmdlineHash: p.Cmdline_hash,
+		CgroupID:    p.Cgroup_id,
+	}
+	conn.Process.Comm = internCString(p.Comm[:])
+	conn.Process.Exe = internCString(p.Exe[:])
+}
+
+// getTLSInfo adds the metadata of the TLS handshake of the connection, if the protocol classifier read it
+func (t *tracer) getTLSInfo(conn *network.ConnectionStats, tuple *netebpf.ConnTuple) {
+	if conn.ProtocolStack.Encryption != protocols.TLS {
+		return
+	}
+
+	var info netebpf.TLSInfo
+	if lookupClassifierTuple(t.tlsInfo, tuple, &info) {
+		populateTLSInfo(conn, &info)
+	}
+}
+
+// lookupClassifierTuple looks up the entry of a map of the protocol classifier. The socket filter knows neither
+// the pid nor the netns, and stores the normalized tuple, which is found by looking up both orientations.
+func lookupClassifierTuple[V any](m *maps.GenericMap[netebpf.ConnTuple, V], tuple *netebpf.ConnTuple, value *V) bool {
+	key := *tuple
+	key.Pid = 0
+	key.Netns = 0
+	if err := m.Lookup(&key, value); err == nil {
+		return true
+	}
+	key.Saddr_h, key.Saddr_l, key.Daddr_h, key.Daddr_l = key.Daddr_h, key.Daddr_l, key.Saddr_h, key.Saddr_l
+	key.Sport, key.Dport = key.Dport, key.Sport
+	return m.Lookup(&key, value) == nil
+}
+
+func populateTLSInfo(conn *network.ConnectionStats, info *netebpf.TLSInfo) {
+	conn.TLSInfo = network.TLSInfo{
+		ServerName:      internCString(info.Server_name[:]),
+		ALPN:            internCString(info.Alpn[:]),
+		Version:         info.Chosen_version,
+		CipherSuite:     info.Cipher_suite,
+		OfferedVersions: network.TLSVersionFlags(info.Offered_versions),
+	}
+}
+
+// getWebSocketSession adds the WebSocket session of the connection, if it was upgraded
+func (t *tracer) getWebSocketSession(conn *network.ConnectionStats, tuple *netebpf.ConnTuple) {
+	if conn.ProtocolStack.Application != protocols.WebSocket {
+		return
+	}
+
+	var session netebpf.WebSocketSession
+	if lookupClassifierTuple(t.webSocketSessions, tuple, &session) {
+		populateWebSocketSession(conn, &session)
+	}
+}
+
+// populateWebSocketSession converts the session, counted by side in eBPF, to the side of the connection
+func populateWebSocketSession(conn *network.ConnectionStats, s *netebpf.WebSocketSession) {
+	conn.WebSocket = network.WebSocketSession{
+		Upgraded: s.Upgraded_ns,
+		LastSeen: s.Last_seen_ns,
+	}
+	if conn.SPort == s.Server_port {
+		conn.WebSocket.SentMessages, conn.WebSocket.RecvMessages = s.Server_messages, s.Client_messages
+		conn.WebSocket.SentBytes, conn.WebSocket.RecvBytes = s.Server_bytes, s.Client_bytes
+		return
+	}
+	conn.WebSocket.SentMessages, conn.WebSocket.RecvMessages = s.Client_messages, s.Server_messages
+	conn.WebSocket.SentBytes, conn.WebSocket.RecvBytes = s.Client_bytes, s.Server_bytes
+}
+
+// internCString interns the given NUL terminated string, or returns nil if it is empty
+func internCString(s []int8) *intern.Value {
+	n := 0
+	for ; n < len(s) && s[n] != 0; n++ {
+	}
+	if n == 0 {
+		return nil
+	}
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(s[i])
+	}
+	return intern.GetByString(string(b))
+}
+
+// toConnTuple builds the eBPF key of the given connection
+func toConnTuple(conn *network.ConnectionStats, tuple *netebpf.ConnTuple) {
+	tuple.Sport = conn.SPort
+	tuple.Dport = conn.DPort
+	tuple.Netns = conn.NetNS
+	tuple.Pid = conn.Pid
+	tuple.Saddr_l, tuple.Saddr_h = util.ToLowHigh(conn.Source)
+	tuple.Daddr_l, tuple.Daddr_h = util.ToLowHigh(conn.Dest)
+
+	if conn.Family == network.AFINET6 {
+		tuple.Metadata = uint32(netebpf.IPv6)
+	} else {
+		tuple.Metadata = uint32(netebpf.IPv4)
+	}
+	switch conn.Type {
+	case network.TCP:
+		tuple.Metadata |= uint32(netebpf.TCP)
+	case network.SCTP:
+		tuple.Metadata |= uint32(netebpf.SCTP)
+	default:
+		tuple.Metadata |= uint32(netebpf.UDP)
+	}
+}
+
 // getTCPStats reads tcp related stats for the given ConnTuple
 func (t *tracer) getTCPStats(stats *netebpf.TCPStats, tuple *netebpf.ConnTuple) bool {
 	if tuple.Type() != netebpf.TCP {
@@ -666,9 +1555,15 @@ func populateConnStats(stats *network.ConnectionStats, t *netebpf.ConnTuple, s *
 			SentPackets: uint64(s.Sent_packets),
 			RecvPackets: uint64(s.Recv_packets),
 		},
-		LastUpdateEpoch: s.Timestamp,
-		IsAssured:       s.IsAssured(),
-		Cookie:          network.StatCookie(s.Cookie),
+		LastUpdateEpoch:     s.Timestamp,
+		LastSentEpoch:       s.Last_sent_ts,
+		LastRecvEpoch:       s.Last_recv_ts,
+		DSCP:                s.Dscp,
+		FlowLabel:           s.Flow_label,
+		IsAssured:           s.IsAssured(),
+		IsDirectionObserved: s.IsDirectionObserved(),
+		IsPreExisting:       s.IsPreExisting(),
+		Cookie:              network.StatCookie(s.Cookie),
 	}
 
 	if s.Duration <= uint64(math.MaxInt64) {
@@ -681,9 +1576,12 @@ func populateConnStats(stats *network.ConnectionStats, t *netebpf.ConnTuple, s *
 		Encryption:  protocols.Encryption(s.Protocol_stack.Encryption),
 	}
 
-	if t.Type() == netebpf.TCP {
+	switch t.Type() {
+	case netebpf.TCP:
 		stats.Type = network.TCP
-	} else {
+	case netebpf.SCTP:
+		stats.Type = network.SCTP
+	default:
 		stats.Type = network.UDP
 	}
 
@@ -721,6 +1619,55 @@ func updateTCPStats(conn *network.ConnectionStats, tcpStats *netebpf.TCPStats, r
 		conn.Monotonic.TCPClosed = uint32(tcpStats.State_transitions >> netebpf.Close & 1)
 		conn.RTT = tcpStats.Rtt
 		conn.RTTVar = tcpStats.Rtt_var
+		if tcpStats.Failure_reason != 0 {
+			conn.TCPFailures = map[network.TCPFailure]uint32{
+				network.TCPFailure(tcpStats.Failure_reason): 1,
+			}
+		}
+	}
+}
+
+// updateTCPCongestion sets the congestion state read when a TCP connection was closed
+func updateTCPCongestion(conn *network.ConnectionStats, congestion *netebpf.TCPCongestion) {
+	if conn.Type != network.TCP {
+		return
+	}
+
+	conn.Cwnd = congestion.Cwnd
+	conn.Ssthresh = congestion.Ssthresh
+	conn.DeliveryRate = congestion.Delivery_rate
+	conn.CongestionAlgorithm = congestionAlgorithm(&congestion.Cong_algo)
+}
+
+// congestionAlgorithm returns the name of the congestion control algorithm read from the
+// kernel, without allocating for the algorithms shipped with it
+func congestionAlgorithm(name *[16]int8) string {
+	var b [16]byte
+	n := 0
+	for ; n < len(name) && name[n] != 0; n++ {
+		b[n] = byte(name[n])
+	}
+	switch s := b[:n]; string(s) {
+	case "":
+		return ""
+	case "cubic":
+		return "cubic"
+	case "bbr":
+		return "bbr"
+	case "reno":
+		return "reno"
+	case "dctcp":
+		return "dctcp"
+	case "bic":
+		return "bic"
+	case "htcp":
+		return "htcp"
+	case "vegas":
+		return "vegas"
+	case "westwood":
+		return "westwood"
+	default:
+		return string(s)
 	}
 }
 
@@ -0,0 +1,243 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package connection
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/features"
+	"github.com/cilium/ebpf/rlimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/ebpf/maps"
+	"github.com/DataDog/datadog-agent/pkg/network"
+	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestPopulateCgroupConnStats(t *testing.T) {
+	dest := util.AddressFromString("10.0.0.1")
+	key := netebpf.CgroupConnKey{
+		Cgroup_id: 1234,
+		Netns:     42,
+		Metadata:  uint32(netebpf.TCP) | uint32(netebpf.IPv4),
+		Port:      443,
+		Direction: uint8(netebpf.Outgoing),
+	}
+	key.Raddr_l, key.Raddr_h = util.ToLowHigh(dest)
+	stats := netebpf.CgroupConnStats{Sent_bytes: 10, Recv_bytes: 20, Timestamp: 5}
+
+	var outgoing network.ConnectionStats
+	populateCgroupConnStats(&outgoing, &key, &stats, newCookieHasher())
+	assert.Equal(t, uint64(1234), outgoing.CgroupID)
+	assert.Equal(t, network.TCP, outgoing.Type)
+	assert.Equal(t, network.AFINET, outgoing.Family)
+	assert.Equal(t, network.OUTGOING, outgoing.Direction)
+	assert.Equal(t, dest, outgoing.Dest)
+	assert.False(t, outgoing.Source.IsValid())
+	assert.Equal(t, uint16(0), outgoing.SPort)
+	assert.Equal(t, uint16(443), outgoing.DPort)
+	assert.Equal(t, uint64(10), outgoing.Monotonic.SentBytes)
+	assert.Equal(t, uint64(20), outgoing.Monotonic.RecvBytes)
+	assert.Equal(t, uint32(0), outgoing.Pid)
+
+	// the same destination from another cgroup must not share the cookie
+	other := key
+	other.Cgroup_id = 5678
+	var fromOther network.ConnectionStats
+	populateCgroupConnStats(&fromOther, &other, &stats, newCookieHasher())
+	assert.NotEqual(t, outgoing.Cookie, fromOther.Cookie)
+
+	incomingKey := key
+	incomingKey.Direction = uint8(netebpf.Incoming)
+	incomingKey.Port = 8080
+	var incoming network.ConnectionStats
+	populateCgroupConnStats(&incoming, &incomingKey, &stats, nil)
+	assert.Equal(t, network.INCOMING, incoming.Direction)
+	assert.Equal(t, uint16(8080), incoming.SPort)
+	assert.Equal(t, uint16(0), incoming.DPort)
+}
+
+func TestUpdateTCPCongestion(t *testing.T) {
+	congestion := netebpf.TCPCongestion{Cwnd: 10, Ssthresh: 7, Delivery_rate: 1 << 20}
+	for i, c := range "bbr" {
+		congestion.Cong_algo[i] = int8(c)
+	}
+
+	conn := network.ConnectionStats{Type: network.TCP}
+	updateTCPCongestion(&conn, &congestion)
+	assert.Equal(t, "bbr", conn.CongestionAlgorithm)
+	assert.Equal(t, uint32(10), conn.Cwnd)
+	assert.Equal(t, uint32(7), conn.Ssthresh)
+	assert.Equal(t, uint64(1<<20), conn.DeliveryRate)
+
+	for i, c := range "custom_cc" {
+		congestion.Cong_algo[i] = int8(c)
+	}
+	updateTCPCongestion(&conn, &congestion)
+	assert.Equal(t, "custom_cc", conn.CongestionAlgorithm)
+
+	// the congestion state is not collected by the prebuilt tracer
+	updateTCPCongestion(&conn, &netebpf.TCPCongestion{})
+	assert.Equal(t, "", conn.CongestionAlgorithm)
+	assert.Zero(t, conn.Cwnd)
+
+	// nor read for the UDP connections
+	udp := network.ConnectionStats{Type: network.UDP}
+	updateTCPCongestion(&udp, &congestion)
+	assert.Zero(t, udp.Cwnd)
+}
+
+func TestPopulateConnProcess(t *testing.T) {
+	proc := netebpf.ConnProcess{Cgroup_id: 42, Cmdline_hash: 1234}
+	for i, c := range "curl" {
+		proc.Comm[i] = int8(c)
+	}
+	for i, c := range "curl-8.5" {
+		proc.Exe[i] = int8(c)
+	}
+
+	var conn network.ConnectionStats
+	populateConnProcess(&conn, &proc)
+	assert.Equal(t, "curl", conn.Process.Comm.Get().(string))
+	assert.Equal(t, "curl-8.5", conn.Process.Exe.Get().(string))
+	assert.Equal(t, uint32(1234), conn.Process.CmdlineHash)
+	assert.Equal(t, uint64(42), conn.Process.CgroupID)
+
+	// the executable can't be read for kernel threads
+	populateConnProcess(&conn, &netebpf.ConnProcess{Comm: proc.Comm})
+	assert.Nil(t, conn.Process.Exe)
+	assert.Zero(t, conn.Process.CgroupID)
+}
+
+func TestPopulateTLSInfo(t *testing.T) {
+	info := netebpf.TLSInfo{
+		Chosen_version:   tls.VersionTLS13,
+		Cipher_suite:     tls.TLS_AES_128_GCM_SHA256,
+		Offered_versions: uint8(network.TLSVersion12Offered | network.TLSVersion13Offered),
+	}
+	for i, c := range "api.example.com" {
+		info.Server_name[i] = int8(c)
+	}
+	for i, c := range "h2" {
+		info.Alpn[i] = int8(c)
+	}
+
+	var conn network.ConnectionStats
+	populateTLSInfo(&conn, &info)
+	assert.Equal(t, "api.example.com", conn.TLSInfo.ServerName.Get().(string))
+	assert.Equal(t, "h2", conn.TLSInfo.ALPN.Get().(string))
+	assert.Equal(t, uint16(tls.VersionTLS13), conn.TLSInfo.Version)
+	assert.Equal(t, uint16(tls.TLS_AES_128_GCM_SHA256), conn.TLSInfo.CipherSuite)
+	assert.Equal(t, network.TLSVersion12Offered|network.TLSVersion13Offered, conn.TLSInfo.OfferedVersions)
+
+	// only the ClientHello was seen
+	populateTLSInfo(&conn, &netebpf.TLSInfo{Server_name: info.Server_name, Offered_versions: uint8(network.TLSVersion10Offered)})
+	assert.Nil(t, conn.TLSInfo.ALPN)
+	assert.Zero(t, conn.TLSInfo.Version)
+	assert.False(t, conn.TLSInfo.IsEmpty())
+}
+
+func TestPopulateWebSocketSession(t *testing.T) {
+	session := netebpf.WebSocketSession{
+		Upgraded_ns:     1_000_000_000,
+		Last_seen_ns:    4_000_000_000,
+		Client_messages: 3,
+		Server_messages: 10,
+		Client_bytes:    120,
+		Server_bytes:    2048,
+		Server_port:     8080,
+	}
+
+	client := network.ConnectionStats{SPort: 45000, DPort: 8080}
+	populateWebSocketSession(&client, &session)
+	assert.Equal(t, uint64(3), client.WebSocket.SentMessages)
+	assert.Equal(t, uint64(10), client.WebSocket.RecvMessages)
+	assert.Equal(t, uint64(120), client.WebSocket.SentBytes)
+	assert.Equal(t, uint64(2048), client.WebSocket.RecvBytes)
+	assert.Equal(t, 3*time.Second, client.WebSocket.Duration())
+
+	server := network.ConnectionStats{SPort: 8080, DPort: 45000}
+	populateWebSocketSession(&server, &session)
+	assert.Equal(t, uint64(10), server.WebSocket.SentMessages)
+	assert.Equal(t, uint64(3), server.WebSocket.RecvMessages)
+	assert.Equal(t, uint64(2048), server.WebSocket.SentBytes)
+	assert.Equal(t, uint64(120), server.WebSocket.RecvBytes)
+	assert.False(t, server.WebSocket.IsEmpty())
+}
+
+func TestPopulateConnStatsQoS(t *testing.T) {
+	tuple := netebpf.ConnTuple{Metadata: uint32(netebpf.TCP) | uint32(netebpf.IPv6), Sport: 40000, Dport: 443}
+	stats := netebpf.ConnStats{Timestamp: 30, Last_sent_ts: 20, Last_recv_ts: 30, Dscp: 46, Flow_label: 0xabcde}
+
+	var conn network.ConnectionStats
+	populateConnStats(&conn, &tuple, &stats, nil)
+	assert.Equal(t, uint8(46), conn.DSCP)
+	assert.Equal(t, uint32(0xabcde), conn.FlowLabel)
+	assert.Equal(t, uint64(20), conn.LastSentEpoch)
+	assert.Equal(t, uint64(30), conn.LastRecvEpoch)
+}
+
+func TestBatchDelete(t *testing.T) {
+	if !maps.BatchAPISupported() {
+		t.Skip("Batch API not supported")
+	}
+	require.NoError(t, rlimit.RemoveMemlock())
+
+	m, err := maps.NewGenericMap[uint32, uint32](&ebpf.MapSpec{
+		Type:       ebpf.Hash,
+		MaxEntries: 10,
+	})
+	require.NoError(t, err)
+	for _, k := range []uint32{1, 2, 4, 6} {
+		require.NoError(t, m.Put(&k, &k))
+	}
+
+	// the missing keys must not stop the deletion of the following ones
+	keys := []uint32{1, 2, 3, 4, 5, 6}
+	deleted := make([]bool, len(keys))
+	require.NoError(t, batchDelete(m, keys, deleted))
+	assert.Equal(t, []bool{true, true, false, true, false, true}, deleted)
+
+	var k, v uint32
+	assert.False(t, m.Iterate().Next(&k, &v))
+	assert.NoError(t, batchDelete(m, keys, nil))
+}
+
+func TestPerCPUPacketDrops(t *testing.T) {
+	require.NoError(t, rlimit.RemoveMemlock())
+	if features.HaveMapType(ebpf.PerCPUHash) != nil {
+		t.Skip("per-CPU maps not supported")
+	}
+
+	m, err := maps.NewGenericMap[netebpf.SkbDropKey, []uint64](&ebpf.MapSpec{
+		Type:       ebpf.PerCPUHash,
+		MaxEntries: 10,
+	})
+	require.NoError(t, err)
+	nCPU, err := ebpf.PossibleCPU()
+	require.NoError(t, err)
+
+	key := netebpf.SkbDropKey{Ifindex: 2, Reason: 5}
+	perCPU := make([]uint64, nCPU)
+	for i := range perCPU {
+		perCPU[i] = uint64(i + 1)
+	}
+	require.NoError(t, m.Put(&key, &perCPU))
+
+	drops, err := iteratePacketDrops(m, sumPerCPU)
+	require.NoError(t, err)
+	require.Len(t, drops, 1)
+	assert.Equal(t, uint32(2), drops[0].Ifindex)
+	assert.Equal(t, uint32(5), drops[0].Reason)
+	assert.Equal(t, uint64(nCPU*(nCPU+1)/2), drops[0].Count)
+}
@@ -0,0 +1,131 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package util
+
+import (
+	"strings"
+
+	manager "github.com/DataDog/ebpf-manager"
+
+	"github.com/DataDog/datadog-agent/pkg/ebpf/maps"
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/network/ebpf/probes"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// ABIVersion is the version of the layout of the structs shared between the eBPF programs and userspace. It
+// must be bumped when a key or a value changes without changing its size, so that the state pinned by the
+// previous versions of system-probe is discarded instead of being misread.
+const ABIVersion = 2
+
+// Features is a bitmap of the optional fields and events of the eBPF tracer
+type Features uint64
+
+// Optional fields and events of the eBPF tracer. The values are stored in the tracer_abi map, so they must
+// not be reused.
+const (
+	FeatureCgroupAggregation Features = 1 << iota
+	FeatureConnProcess
+	FeatureTLSHandshakeInfo
+	FeatureWebSocketTracking
+	FeatureQoSMarking
+	FeatureRTTHistogram
+)
+
+// featureConstants are the load-time constants enabling each feature in the eBPF programs
+var featureConstants = []struct {
+	feature  Features
+	name     string
+	constant string
+}{
+	{FeatureCgroupAggregation, "cgroup_aggregation", "cgroup_aggregation_enabled"},
+	{FeatureConnProcess, "conn_process", "conn_process_enabled"},
+	{FeatureTLSHandshakeInfo, "tls_handshake_info", "tls_handshake_info_enabled"},
+	{FeatureWebSocketTracking, "websocket_tracking", "websocket_tracking_enabled"},
+	{FeatureQoSMarking, "qos_marking", "qos_marking_enabled"},
+	{FeatureRTTHistogram, "rtt_histogram", "rtt_histogram_enabled"},
+}
+
+// kernelStructFeatures are the features reading kernel structs whose layout isn't guessed by the offset
+// guesser, which require BTF or kernel headers
+const kernelStructFeatures = FeatureCgroupAggregation | FeatureConnProcess | FeatureQoSMarking
+
+// Has returns whether all the given features are set
+func (f Features) Has(features Features) bool {
+	return f&features == features
+}
+
+func (f Features) String() string {
+	var names []string
+	for _, fc := range featureConstants {
+		if f.Has(fc.feature) {
+			names = append(names, fc.name)
+		}
+	}
+	return "[" + strings.Join(names, ",") + "]"
+}
+
+// NegotiateFeatures returns the features requested by the configuration which are supported by the eBPF
+// programs, according to whether they can read the kernel structs
+func NegotiateFeatures(cfg *config.Config, kernelStructs bool) Features {
+	var requested Features
+	if cfg.EnableCgroupAggregation {
+		requested |= FeatureCgroupAggregation
+	}
+	if cfg.EnableConnectionProcessInfo {
+		requested |= FeatureConnProcess
+	}
+	if cfg.EnableTLSHandshakeInfo {
+		requested |= FeatureTLSHandshakeInfo
+	}
+	if cfg.EnableWebSocketTracking {
+		requested |= FeatureWebSocketTracking
+	}
+	if cfg.EnableQoSMarking {
+		requested |= FeatureQoSMarking
+	}
+	if cfg.EnableRTTHistograms {
+		requested |= FeatureRTTHistogram
+	}
+
+	negotiated := requested
+	if !kernelStructs {
+		negotiated &^= kernelStructFeatures
+	}
+	if negotiated != requested {
+		log.Infof("features %s aren't supported by the eBPF tracer", requested&^negotiated)
+	}
+	return negotiated
+}
+
+// AddFeatureConsts modifies the options to enable the given features in the eBPF programs
+func AddFeatureConsts(options *manager.Options, features Features) {
+	for _, fc := range featureConstants {
+		AddBoolConst(options, fc.constant, features.Has(fc.feature))
+	}
+}
+
+// WriteABI stores the ABI version and the negotiated features in the tracer_abi map, once the eBPF
+// programs are loaded. Without it, the maps pinned by the tracer are discarded by the next instance of
+// system-probe.
+func WriteABI(mgr *manager.Manager, features Features) {
+	abiMap, err := maps.GetMap[uint32, netebpf.TracerABI](mgr, probes.TracerABIMap)
+	if err != nil {
+		log.Warnf("error retrieving the bpf %s map: %s", probes.TracerABIMap, err)
+		return
+	}
+
+	var zero uint32
+	abi := netebpf.TracerABI{Version: ABIVersion, Features: uint64(features)}
+	if err := abiMap.Put(&zero, &abi); err != nil {
+		log.Warnf("error writing the tracer ABI: %s", err)
+		return
+	}
+	log.Infof("eBPF tracer ABI version %d, features %s", ABIVersion, features)
+}
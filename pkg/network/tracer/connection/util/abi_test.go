@@ -0,0 +1,34 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+)
+
+func TestNegotiateFeatures(t *testing.T) {
+	cfg := &config.Config{
+		EnableConnectionProcessInfo: true,
+		EnableTLSHandshakeInfo:      true,
+		EnableQoSMarking:            true,
+		EnableRTTHistograms:         true,
+	}
+
+	features := NegotiateFeatures(cfg, true)
+	assert.Equal(t, FeatureConnProcess|FeatureTLSHandshakeInfo|FeatureQoSMarking|FeatureRTTHistogram, features)
+	assert.Equal(t, "[conn_process,tls_handshake_info,qos_marking,rtt_histogram]", features.String())
+
+	// the prebuilt programs can't read the process and the QoS marking of sockets
+	features = NegotiateFeatures(cfg, false)
+	assert.Equal(t, FeatureTLSHandshakeInfo|FeatureRTTHistogram, features)
+	assert.False(t, features.Has(FeatureConnProcess))
+}
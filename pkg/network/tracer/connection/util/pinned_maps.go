@@ -0,0 +1,215 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	manager "github.com/DataDog/ebpf-manager"
+	cebpf "github.com/cilium/ebpf"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/network/ebpf/probes"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// pinnedMapsVersionPrefix prefixes the directories holding the maps pinned with each ABI version
+const pinnedMapsVersionPrefix = "v"
+
+// connCloseBatchMaxEntries is the size of the conn_close_batch map, which isn't edited at load time
+const connCloseBatchMaxEntries = 1024
+
+// PinnedMapSpec is the layout expected from a pinned map. A map pinned with another layout is discarded.
+type PinnedMapSpec struct {
+	Type       cebpf.MapType
+	KeySize    uint32
+	ValueSize  uint32
+	MaxEntries uint32
+}
+
+// PinTracerMaps pins the connection map of the tracer, when enabled, along with the maps its entries go with:
+// the TCP stats, retransmits and RTT histograms, the process which created the connections, the port bindings
+// which tell their direction, and the batches of closed connections not sent yet. The maps pinned by a tracer
+// which negotiated other features are discarded, since their entries lack the fields of the missing features,
+// or hold those of the features which are now disabled.
+func PinTracerMaps(mgr *manager.Manager, cfg *config.Config, features Features) {
+	connTupleSize := uint32(unsafe.Sizeof(netebpf.ConnTuple{}))
+	portBindingSize := uint32(unsafe.Sizeof(netebpf.PortBinding{}))
+	specs := map[string]PinnedMapSpec{
+		probes.ConnMap: {
+			Type:       cebpf.Hash,
+			KeySize:    connTupleSize,
+			ValueSize:  uint32(unsafe.Sizeof(netebpf.ConnStats{})),
+			MaxEntries: cfg.MaxTrackedConnections,
+		},
+		probes.TCPStatsMap: {
+			Type:       cebpf.Hash,
+			KeySize:    connTupleSize,
+			ValueSize:  uint32(unsafe.Sizeof(netebpf.TCPStats{})),
+			MaxEntries: cfg.MaxTrackedConnections,
+		},
+		probes.TCPRetransmitsMap: {
+			Type:       cebpf.Hash,
+			KeySize:    connTupleSize,
+			ValueSize:  uint32(unsafe.Sizeof(uint32(0))),
+			MaxEntries: cfg.MaxTrackedConnections,
+		},
+		probes.ConnProcessMap: {
+			Type:       cebpf.LRUHash,
+			KeySize:    connTupleSize,
+			ValueSize:  uint32(unsafe.Sizeof(netebpf.ConnProcess{})),
+			MaxEntries: FeatureMapMaxEntries(cfg, cfg.EnableConnectionProcessInfo),
+		},
+		probes.TCPRTTHistogramsMap: {
+			Type:       cebpf.LRUHash,
+			KeySize:    connTupleSize,
+			ValueSize:  uint32(unsafe.Sizeof(netebpf.RTTHistogram{})),
+			MaxEntries: FeatureMapMaxEntries(cfg, cfg.EnableRTTHistograms),
+		},
+		probes.PortBindingsMap: {
+			Type:       cebpf.Hash,
+			KeySize:    portBindingSize,
+			ValueSize:  uint32(unsafe.Sizeof(uint32(0))),
+			MaxEntries: cfg.MaxTrackedConnections,
+		},
+		probes.UDPPortBindingsMap: {
+			Type:       cebpf.Hash,
+			KeySize:    portBindingSize,
+			ValueSize:  uint32(unsafe.Sizeof(uint32(0))),
+			MaxEntries: cfg.MaxTrackedConnections,
+		},
+		probes.ConnCloseBatchMap: {
+			Type:       cebpf.Hash,
+			KeySize:    uint32(unsafe.Sizeof(uint32(0))),
+			ValueSize:  uint32(unsafe.Sizeof(netebpf.Batch{})),
+			MaxEntries: connCloseBatchMaxEntries,
+		},
+		probes.TracerABIMap: {
+			Type:       cebpf.Array,
+			KeySize:    uint32(unsafe.Sizeof(uint32(0))),
+			ValueSize:  uint32(unsafe.Sizeof(netebpf.TracerABI{})),
+			MaxEntries: 1,
+		},
+	}
+	if cfg.PinnedMapsDir != "" {
+		discardIncompatibleABI(pinnedMapsDir(cfg), features, specs)
+	}
+	PinMaps(mgr, cfg, specs)
+}
+
+// FeatureMapMaxEntries returns the size of a map of an optional feature. Since the hash maps are preallocated,
+// the maps of the disabled features only have a single entry, the smallest size allowed.
+func FeatureMapMaxEntries(cfg *config.Config, enabled bool) uint32 {
+	if !enabled {
+		return 1
+	}
+	return cfg.MaxTrackedConnections
+}
+
+// PinMaps sets the pin path of the given maps of the manager, so that they are reused by the next instance of
+// system-probe instead of being created empty. The maps pinned with another layout are discarded beforehand.
+func PinMaps(mgr *manager.Manager, cfg *config.Config, specs map[string]PinnedMapSpec) {
+	if cfg.PinnedMapsDir == "" {
+		return
+	}
+
+	dir := pinnedMapsDir(cfg)
+	discardStaleLayouts(cfg.PinnedMapsDir, dir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		log.Warnf("could not create the directory of the pinned maps, the maps won't be pinned: %s", err)
+		return
+	}
+
+	for _, m := range mgr.Maps {
+		spec, ok := specs[m.Name]
+		if !ok {
+			continue
+		}
+		m.PinPath = filepath.Join(dir, m.Name)
+		discardIncompatibleMap(m.PinPath, spec)
+	}
+}
+
+// MapCleanupType returns the cleanup type with which the managers must be stopped, so that the pinned maps
+// are kept for the next instance of system-probe
+func MapCleanupType(cfg *config.Config) manager.MapCleanupType {
+	if cfg.PinnedMapsDir != "" {
+		return manager.CleanInternalNotPinned
+	}
+	return manager.CleanAll
+}
+
+// pinnedMapsDir returns the directory of the maps pinned with the current ABI version
+func pinnedMapsDir(cfg *config.Config) string {
+	return filepath.Join(cfg.PinnedMapsDir, fmt.Sprintf("%s%d", pinnedMapsVersionPrefix, ABIVersion))
+}
+
+// discardIncompatibleABI removes the given maps pinned in the directory if the tracer which pinned them had
+// another ABI version or negotiated other features
+func discardIncompatibleABI(dir string, features Features, specs map[string]PinnedMapSpec) {
+	m, err := cebpf.LoadPinnedMap(filepath.Join(dir, probes.TracerABIMap), nil)
+	if err != nil {
+		return
+	}
+	defer m.Close()
+
+	var zero uint32
+	var abi netebpf.TracerABI
+	if err := m.Lookup(&zero, &abi); err == nil && abi.Version == ABIVersion && Features(abi.Features) == features {
+		return
+	}
+	log.Infof("discarding the pinned maps of the tracer with ABI version %d and features %s", abi.Version, Features(abi.Features))
+	for name := range specs {
+		_ = os.Remove(filepath.Join(dir, name))
+	}
+}
+
+// discardStaleLayouts removes the maps pinned by the versions of system-probe with another layout
+func discardStaleLayouts(root string, current string) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		path := filepath.Join(root, entry.Name())
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), pinnedMapsVersionPrefix) || path == current {
+			continue
+		}
+		log.Infof("discarding the maps pinned with the layout %s", entry.Name())
+		if err := os.RemoveAll(path); err != nil {
+			log.Warnf("could not discard the pinned maps of %s: %s", path, err)
+		}
+	}
+}
+
+// discardIncompatibleMap removes the map pinned at the given path if its type or its layout differs from the
+// expected one, as when the maximum number of tracked connections was changed
+func discardIncompatibleMap(path string, spec PinnedMapSpec) {
+	m, err := cebpf.LoadPinnedMap(path, nil)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Warnf("discarding the unreadable pinned map %s: %s", path, err)
+			_ = os.Remove(path)
+		}
+		return
+	}
+	defer m.Close()
+
+	if m.Type() != spec.Type || m.KeySize() != spec.KeySize || m.ValueSize() != spec.ValueSize || m.MaxEntries() != spec.MaxEntries {
+		log.Infof("discarding the pinned map %s, whose layout changed", path)
+		_ = os.Remove(path)
+		return
+	}
+	log.Infof("reusing the state of the pinned map %s", path)
+}
@@ -14,6 +14,7 @@ import (
 	"io"
 	"sync"
 	"time"
+	"unsafe"
 
 	"github.com/cihub/seelog"
 	"github.com/cilium/ebpf"
@@ -35,6 +36,7 @@ import (
 	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
 	"github.com/DataDog/datadog-agent/pkg/network/ebpf/probes"
 	"github.com/DataDog/datadog-agent/pkg/network/netlink"
+	connutil "github.com/DataDog/datadog-agent/pkg/network/tracer/connection/util"
 	"github.com/DataDog/datadog-agent/pkg/network/tracer/offsetguess"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
@@ -81,6 +83,8 @@ type ebpfConntracker struct {
 	stop chan struct{}
 
 	isPrebuilt bool
+
+	cleanupType manager.MapCleanupType
 }
 
 var ebpfConntrackerCORECreator func(cfg *config.Config) (*manager.Manager, error) = getCOREConntracker
@@ -157,6 +161,7 @@ func NewEBPFConntracker(cfg *config.Config) (netlink.Conntracker, error) {
 		rootNS:       rootNS,
 		stop:         make(chan struct{}),
 		isPrebuilt:   isPrebuilt,
+		cleanupType:  connutil.MapCleanupType(cfg),
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConntrackInitTimeout)
@@ -324,9 +329,20 @@ func (e *ebpfConntracker) GetTelemetryMap() *ebpf.Map {
 	return e.telemetryMap.Map()
 }
 
+// Pause detaches the conntrack probes, until Resume is called. The NAT translations made in the meantime
+// are missed.
+func (e *ebpfConntracker) Pause() error {
+	return e.m.Pause()
+}
+
+// Resume attaches the conntrack probes detached by Pause
+func (e *ebpfConntracker) Resume() error {
+	return e.m.Resume()
+}
+
 func (e *ebpfConntracker) Close() {
 	ebpfcheck.RemoveNameMappings(e.m)
-	err := e.m.Stop(manager.CleanAll)
+	err := e.m.Stop(e.cleanupType)
 	if err != nil {
 		log.Warnf("error cleaning up ebpf conntrack: %s", err)
 	}
@@ -423,6 +439,14 @@ func getManager(cfg *config.Config, buf io.ReaderAt, opts manager.Options) (*man
 			},
 		},
 	}, &ebpftelemetry.ErrorsTelemetryModifier{})
+	connutil.PinMaps(mgr.Manager, cfg, map[string]connutil.PinnedMapSpec{
+		probes.ConntrackMap: {
+			Type:       ebpf.Hash,
+			KeySize:    uint32(unsafe.Sizeof(netebpf.ConntrackTuple{})),
+			ValueSize:  uint32(unsafe.Sizeof(netebpf.ConntrackTuple{})),
+			MaxEntries: uint32(cfg.ConntrackMaxStateSize),
+		},
+	})
 
 	opts.DefaultKprobeAttachMethod = manager.AttachKprobeWithPerfEventOpen
 	if cfg.AttachKprobesWithKprobeEventsABI {
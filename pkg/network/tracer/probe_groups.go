@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package tracer
+
+import (
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Probe groups which can be detached and attached again at runtime, without restarting system-probe
+const (
+	ProbeGroupConntrack = "conntrack"
+	ProbeGroupDNS       = "dns"
+	ProbeGroupUSM       = "usm"
+)
+
+// pausable is implemented by the components whose probes can be detached at runtime
+type pausable interface {
+	Pause() error
+	Resume() error
+}
+
+// probeGroups returns the components of the tracer which can be paused, by probe group. The components which
+// aren't running, such as the netlink conntracker or the null reverse DNS, are left out.
+func (t *Tracer) probeGroups() map[string]pausable {
+	groups := make(map[string]pausable)
+	if p, ok := t.conntracker.(pausable); ok {
+		groups[ProbeGroupConntrack] = p
+	}
+	if p, ok := t.reverseDNS.(pausable); ok {
+		groups[ProbeGroupDNS] = p
+	}
+	if t.usmMonitor != nil {
+		groups[ProbeGroupUSM] = t.usmMonitor
+	}
+	return groups
+}
+
+// ProbeGroups returns whether each of the probe groups running in the tracer is enabled
+func (t *Tracer) ProbeGroups() map[string]bool {
+	t.probeGroupsLock.Lock()
+	defer t.probeGroupsLock.Unlock()
+
+	enabled := make(map[string]bool)
+	for name := range t.probeGroups() {
+		enabled[name] = !t.pausedProbeGroups[name]
+	}
+	return enabled
+}
+
+// SetProbeGroupEnabled detaches or attaches again the probes of the given group. The traffic seen while a
+// group is disabled is missed by it: a disabled conntrack group doesn't resolve the NAT of the new connections,
+// a disabled DNS group doesn't resolve their domains, and a disabled USM group doesn't report their requests.
+func (t *Tracer) SetProbeGroupEnabled(group string, enabled bool) error {
+	p, ok := t.probeGroups()[group]
+	if !ok {
+		return fmt.Errorf("unknown or inactive probe group %q", group)
+	}
+
+	t.probeGroupsLock.Lock()
+	defer t.probeGroupsLock.Unlock()
+
+	if t.pausedProbeGroups[group] == !enabled {
+		return nil
+	}
+	if enabled {
+		if err := p.Resume(); err != nil {
+			return fmt.Errorf("could not enable probe group %s: %w", group, err)
+		}
+		delete(t.pausedProbeGroups, group)
+		log.Infof("probe group %s enabled", group)
+		return nil
+	}
+
+	if err := p.Pause(); err != nil {
+		return fmt.Errorf("could not disable probe group %s: %w", group, err)
+	}
+	if t.pausedProbeGroups == nil {
+		t.pausedProbeGroups = make(map[string]bool)
+	}
+	t.pausedProbeGroups[group] = true
+	log.Infof("probe group %s disabled", group)
+	return nil
+}
@@ -12,12 +12,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/DataDog/ebpf-manager/tracefs"
 	"github.com/cihub/seelog"
-	"github.com/cilium/ebpf"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/atomic"
 	"go4.org/intern"
@@ -34,8 +34,10 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/network/ebpf/probes"
 	"github.com/DataDog/datadog-agent/pkg/network/events"
 	"github.com/DataDog/datadog-agent/pkg/network/netlink"
+	"github.com/DataDog/datadog-agent/pkg/network/preflight"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
 	"github.com/DataDog/datadog-agent/pkg/network/tracer/connection"
+	"github.com/DataDog/datadog-agent/pkg/network/tracer/connection/kprobe"
 	"github.com/DataDog/datadog-agent/pkg/network/usm"
 	"github.com/DataDog/datadog-agent/pkg/process/procutil"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
@@ -48,6 +50,10 @@ import (
 const defaultUDPConnTimeoutNanoSeconds = uint64(time.Duration(120) * time.Second)
 const tracerModuleName = "network_tracer"
 
+// interfaceStatsCacheFor is how long the counters of the network interfaces are reused for, which
+// spares a walk of all the network namespaces to each client requesting the connections
+const interfaceStatsCacheFor = 10 * time.Second
+
 // Telemetry
 // Will track the count of expired TCP connections
 // We are manually expiring TCP connections because it seems that we are losing some TCP close events
@@ -64,15 +70,19 @@ const tracerModuleName = "network_tracer"
 var tracerTelemetry = struct {
 	skippedConns         telemetry.Counter
 	expiredTCPConns      telemetry.Counter
+	idleConns            telemetry.Counter
 	closedConns          *telemetry.StatCounterWrapper
 	connStatsMapSize     telemetry.Gauge
 	payloadSizePerClient telemetry.Gauge
+	tcpFailedConns       telemetry.Counter
 }{
 	telemetry.NewCounter(tracerModuleName, "skipped_conns", []string{"ip_proto"}, "Counter measuring skipped connections"),
 	telemetry.NewCounter(tracerModuleName, "expired_tcp_conns", []string{}, "Counter measuring expired TCP connections"),
+	telemetry.NewCounter(tracerModuleName, "idle_conns", []string{"ip_proto"}, "Counter measuring connections evicted after being idle for longer than the idle timeout"),
 	telemetry.NewStatCounterWrapper(tracerModuleName, "closed_conns", []string{"ip_proto"}, "Counter measuring closed TCP connections"),
 	telemetry.NewGauge(tracerModuleName, "conn_stats_map_size", []string{}, "Gauge measuring the size of the active connections map"),
 	telemetry.NewGauge(tracerModuleName, "payload_conn_count", []string{"client_id", "ip_proto"}, "Gauge measuring the number of connections in the system-probe payload"),
+	telemetry.NewCounter(tracerModuleName, "tcp_failed_conns", []string{"reason"}, "Counter measuring TCP connection attempts that failed before being established"),
 }
 
 // Tracer implements the functionality of the network tracer
@@ -85,6 +95,12 @@ type Tracer struct {
 	ebpfTracer         connection.Tracer
 	bpfErrorsCollector prometheus.Collector
 	lastCheck          *atomic.Int64
+	// preflight is the capability report of the kernel the tracer was loaded on
+	preflight *preflight.Report
+
+	// pausedProbeGroups holds the probe groups detached at runtime through SetProbeGroupEnabled
+	probeGroupsLock   sync.Mutex
+	pausedProbeGroups map[string]bool
 
 	bufferLock sync.Mutex
 
@@ -93,13 +109,33 @@ type Tracer struct {
 	destExcludes   []*network.ConnectionFilter
 
 	gwLookup network.GatewayLookup
+	// interfaceStats samples the counters of the network interfaces, when enabled
+	interfaceStats *network.InterfaceStatsSampler
+	// routes resolves the routes of the connections, for the gateway lookup and
+	// the detection of the connections hairpinning back to the host through its NAT
+	routes network.RouteCache
+
+	// encryptedDNS finds the connections carrying DNS over TLS or DNS over HTTPS
+	encryptedDNS *network.EncryptedDNSDetector
 
 	sysctlUDPConnTimeout       *sysctl.Int
 	sysctlUDPConnStreamTimeout *sysctl.Int
 
 	processCache *processCache
+	// cgroupResolver resolves the containers of the traffic aggregated by cgroup
+	cgroupResolver *cgroupResolver
+
+	// classifyEncryption is set if the payload of TCP connections is classified,
+	// in which case connections are tagged as encrypted or plaintext
+	classifyEncryption bool
+
+	// dropReasons maps the kernel drop reasons to their names, read once from tracefs
+	dropReasons     map[uint32]string
+	dropReasonsOnce sync.Once
 
 	timeResolver *timeresolver.Resolver
+
+	closedConnStreamer *closedConnStreamer
 }
 
 // NewTracer creates a Tracer
@@ -119,7 +155,7 @@ func NewTracer(config *config.Config) (*Tracer, error) {
 // newTracer is an internal function used by tests primarily
 // (and NewTracer above)
 func newTracer(cfg *config.Config) (_ *Tracer, reterr error) {
-	if _, err := tracefs.Root(); err != nil {
+	if _, err := tracefs.Root(); err != nil && !cfg.EnableSockDiagFallback {
 		return nil, fmt.Errorf("system-probe unsupported: %s", err)
 	}
 
@@ -151,6 +187,10 @@ func newTracer(cfg *config.Config) (_ *Tracer, reterr error) {
 		lastCheck:                  atomic.NewInt64(time.Now().Unix()),
 		sysctlUDPConnTimeout:       sysctl.NewInt(cfg.ProcRoot, "net/netfilter/nf_conntrack_udp_timeout", time.Minute),
 		sysctlUDPConnStreamTimeout: sysctl.NewInt(cfg.ProcRoot, "net/netfilter/nf_conntrack_udp_timeout_stream", time.Minute),
+		closedConnStreamer:         newClosedConnStreamer(),
+	}
+	if cfg.EnableInterfaceStats {
+		tr.interfaceStats = network.NewInterfaceStatsSampler(cfg.ProcRoot, interfaceStatsCacheFor)
 	}
 	defer func() {
 		if reterr != nil {
@@ -164,11 +204,21 @@ func newTracer(cfg *config.Config) (_ *Tracer, reterr error) {
 		log.Debug("eBPF telemetry not supported")
 	}
 
-	tr.ebpfTracer, err = connection.NewTracer(cfg)
+	if tr.preflight, err = preflight.Run(); err != nil {
+		log.Warnf("could not probe the kernel capabilities: %s", err)
+	} else if missing := tr.preflight.Missing(); len(missing) > 0 {
+		log.Warnf("kernel %s lacks features required by the eBPF tracer: %s", tr.preflight.KernelVersion, strings.Join(missing, ", "))
+	}
+
+	tr.ebpfTracer, err = connection.NewTracer(cfg, tr.preflight)
 	if err != nil {
 		return nil, err
 	}
 	coretelemetry.GetCompatComponent().RegisterCollector(tr.ebpfTracer)
+	// the fentry and sock_diag tracers don't run the protocol classifier
+	tr.classifyEncryption = kprobe.ClassificationSupported(cfg) &&
+		tr.ebpfTracer.Type() != connection.TracerTypeFentry &&
+		tr.ebpfTracer.Type() != connection.TracerTypeSockDiag
 
 	tr.conntracker, err = newConntracker(cfg)
 	if err != nil {
@@ -176,11 +226,27 @@ func newTracer(cfg *config.Config) (_ *Tracer, reterr error) {
 	}
 	coretelemetry.GetCompatComponent().RegisterCollector(tr.conntracker)
 
-	if cfg.EnableGatewayLookup {
-		tr.gwLookup = network.NewGatewayLookup(cfg.GetRootNetNs, cfg.MaxTrackedConnections)
+	if cfg.EnableGatewayLookup || cfg.EnableNATHairpinDetection {
+		if tr.routes, err = newRouteCache(cfg); err != nil {
+			log.Warnf("could not create the route cache, neither the gateways nor NAT hairpinning will be resolved: %s", err)
+		}
+	}
+	if cfg.EnableGatewayLookup && tr.routes != nil {
+		var probeInterval time.Duration
+		if cfg.EnableGatewayProbing {
+			probeInterval = cfg.GatewayProbingInterval
+		}
+		tr.gwLookup = network.NewGatewayLookupWithProbing(cfg.GetRootNetNs, tr.routes, int(cfg.MaxTrackedConnections), probeInterval)
+		if tr.gwLookup != nil && cfg.GatewayLookupQueueSize > 0 {
+			tr.gwLookup = network.NewAsyncGatewayLookup(tr.gwLookup, cfg.GatewayLookupQueueSize, int(cfg.MaxTrackedConnections))
+		}
 	}
 	if tr.gwLookup != nil {
 		log.Info("gateway lookup enabled")
+	} else if tr.routes != nil && !cfg.EnableNATHairpinDetection {
+		// the gateway lookup is only supported on AWS
+		tr.routes.Close()
+		tr.routes = nil
 	}
 
 	tr.reverseDNS = newReverseDNS(cfg)
@@ -203,19 +269,19 @@ func newTracer(cfg *config.Config) (_ *Tracer, reterr error) {
 		events.RegisterHandler(tr.processCache)
 	}
 
-	tr.sourceExcludes = network.ParseConnectionFilters(cfg.ExcludedSourceConnections)
-	tr.destExcludes = network.ParseConnectionFilters(cfg.ExcludedDestinationConnections)
-	tr.state = network.NewState(
-		cfg.ClientStateExpiry,
-		cfg.MaxClosedConnectionsBuffered,
-		cfg.MaxConnectionsStateBuffered,
-		cfg.MaxDNSStatsBuffered,
-		cfg.MaxHTTPStatsBuffered,
-		cfg.MaxKafkaStatsBuffered,
-		cfg.MaxPostgresStatsBuffered,
-		cfg.EnableNPMConnectionRollup,
-		cfg.EnableProcessEventMonitoring,
-	)
+	if cfg.EnableCgroupAggregation || cfg.EnableConnectionProcessInfo {
+		if tr.cgroupResolver, err = newCgroupResolver(cfg.ProcRoot); err != nil {
+			log.Warnf("connections will not be attributed to containers from their cgroup: %s", err)
+		}
+	}
+
+	// the ignore rules match either endpoint of the connections. The eBPF tracer applies them in the
+	// kernel too, so that the ignored connections aren't tracked.
+	ignoreRules := network.ParseIgnoreRules(cfg.IgnoredConnections)
+	tr.sourceExcludes = append(network.ParseConnectionFilters(cfg.ExcludedSourceConnections), ignoreRules...)
+	tr.destExcludes = append(network.ParseConnectionFilters(cfg.ExcludedDestinationConnections), ignoreRules...)
+	tr.encryptedDNS = network.NewEncryptedDNSDetector(cfg.EncryptedDNSHosts, cfg.EnableEncryptedDNSTags)
+	tr.state = network.NewState(cfg)
 
 	return tr, nil
 }
@@ -297,7 +363,7 @@ func (t *Tracer) storeClosedConnections(connections []network.ConnectionStats) {
 	for i := range connections {
 		cs := &connections[i]
 		cs.IsClosed = true
-		if t.shouldSkipConnection(cs) {
+		if t.shouldSkipClosedConnection(cs) {
 			connections[rejected], connections[i] = connections[i], connections[rejected]
 			rejected++
 			tracerTelemetry.skippedConns.Inc(cs.Type.String())
@@ -306,21 +372,47 @@ func (t *Tracer) storeClosedConnections(connections []network.ConnectionStats) {
 
 		cs.IPTranslation = t.conntracker.GetTranslationForConn(*cs)
 		t.connVia(cs)
+		t.checkNATHairpin(cs)
 		if cs.IPTranslation != nil {
 			t.conntracker.DeleteTranslation(*cs)
 		}
 
 		t.addProcessInfo(cs)
+		if t.classifyEncryption {
+			cs.Encryption = network.ClassifyEncryption(cs)
+		}
 
 		tracerTelemetry.closedConns.Inc(cs.Type.String())
+		for reason, count := range cs.TCPFailures {
+			tracerTelemetry.tcpFailedConns.Add(float64(count), reason.String())
+		}
 	}
 
 	connections = connections[rejected:]
+	t.closedConnStreamer.publish(connections)
 	t.state.StoreClosedConnections(connections)
 }
 
 //nolint:revive // TODO(NET) Fix revive linter
 func (t *Tracer) addProcessInfo(c *network.ConnectionStats) {
+	if c.CgroupID != 0 {
+		// aggregated connections are not attributed to a process
+		if t.cgroupResolver != nil {
+			c.ContainerID.Source = t.cgroupResolver.containerID(c.CgroupID)
+		}
+		return
+	}
+
+	t.addCachedProcessInfo(c)
+
+	// fall back to the cgroup captured by the eBPF tracer, which is known
+	// even if the process exited before the process cache saw it
+	if c.ContainerID.Source == nil && c.Process.CgroupID != 0 && t.cgroupResolver != nil {
+		c.ContainerID.Source = t.cgroupResolver.containerID(c.Process.CgroupID)
+	}
+}
+
+func (t *Tracer) addCachedProcessInfo(c *network.ConnectionStats) {
 	if t.processCache == nil {
 		return
 	}
@@ -353,9 +445,13 @@ func (t *Tracer) addProcessInfo(c *network.ConnectionStats) {
 //
 //nolint:revive // TODO(NET) Fix revive linter
 func (t *Tracer) Stop() {
+	t.closedConnStreamer.close()
 	if t.gwLookup != nil {
 		t.gwLookup.Close()
 	}
+	if t.routes != nil {
+		t.routes.Close()
+	}
 	if t.reverseDNS != nil {
 		t.reverseDNS.Close()
 	}
@@ -397,6 +493,7 @@ func (t *Tracer) GetActiveConnections(clientID string) (*network.Connections, er
 		return nil, fmt.Errorf("error retrieving connections: %s", err)
 	}
 
+	t.state.StoreResolverLatencies(t.reverseDNS.GetResolverLatencies())
 	delta := t.state.GetDelta(clientID, latestTime, active, t.reverseDNS.GetDNSStats(), t.usmMonitor.GetProtocolStats())
 
 	ips := make(map[util.Address]struct{}, len(delta.Conns)/2)
@@ -419,15 +516,25 @@ func (t *Tracer) GetActiveConnections(clientID string) (*network.Connections, er
 	buffer.ConnectionBuffer.Assign(delta.Conns)
 	conns := network.NewConnections(buffer)
 	conns.DNS = t.reverseDNS.Resolve(ips)
+	conns.EncryptedDNS = t.encryptedDNS.Detect(conns)
 	conns.HTTP = delta.HTTP
 	conns.HTTP2 = delta.HTTP2
 	conns.Kafka = delta.Kafka
 	conns.Postgres = delta.Postgres
+	conns.MySQL = delta.MySQL
+	conns.Redis = delta.Redis
+	conns.Mongo = delta.Mongo
+	conns.AMQP = delta.AMQP
+	conns.Churn = delta.Churn
+	conns.ResolverLatencies = delta.ResolverLatencies
 	conns.ConnTelemetry = t.state.GetTelemetryDelta(clientID, t.getConnTelemetry(len(active)))
 	conns.CompilationTelemetryByAsset = t.getRuntimeCompilationTelemetry()
 	conns.KernelHeaderFetchResult = int32(kernel.HeaderProvider.GetResult())
 	conns.CORETelemetryByAsset = ebpftelemetry.GetCORETelemetryByAsset()
 	conns.PrebuiltAssets = netebpf.GetModulesInUse()
+	if conns.InterfaceStats, err = t.GetInterfaceStats(); err != nil {
+		log.Warnf("unable to sample interface stats: %s", err)
+	}
 	t.lastCheck.Store(time.Now().Unix())
 
 	return conns, nil
@@ -532,6 +639,7 @@ func (t *Tracer) getConnections(activeBuffer *network.ConnectionBuffer) (latestU
 
 	activeConnections = activeBuffer.Connections()
 	for i := range activeConnections {
+		activeConnections[i].IdleDuration = activeConnections[i].IdleFor(uint64(latestTime))
 		activeConnections[i].IPTranslation = t.conntracker.GetTranslationForConn(activeConnections[i])
 		// do gateway resolution only on active connections outside
 		// the map iteration loop to not add to connections while
@@ -539,7 +647,11 @@ func (t *Tracer) getConnections(activeBuffer *network.ConnectionBuffer) (latestU
 		// since gateway resolution connects to the ec2 metadata
 		// endpoint)
 		t.connVia(&activeConnections[i])
+		t.checkNATHairpin(&activeConnections[i])
 		t.addProcessInfo(&activeConnections[i])
+		if t.classifyEncryption {
+			activeConnections[i].Encryption = network.ClassifyEncryption(&activeConnections[i])
+		}
 	}
 
 	// get rid of stale process entries in the cache
@@ -555,6 +667,10 @@ func (t *Tracer) getConnections(activeBuffer *network.ConnectionBuffer) (latestU
 
 	// Remove expired entries
 	t.removeEntries(expired)
+	if timeout := t.config.TCPConnTimeout.Nanoseconds(); t.config.CollectUnixSockets && latestTime > timeout {
+		// the closed sockets are reported until they are older than the TCP connection timeout
+		t.ebpfTracer.ExpireUnixSockets(uint64(latestTime - timeout))
+	}
 
 	// check for expired clients in the state
 	t.state.RemoveExpiredClients(time.Now())
@@ -569,24 +685,11 @@ func (t *Tracer) getConnections(activeBuffer *network.ConnectionBuffer) (latestU
 //nolint:revive // TODO(NET) Fix revive linter
 func (t *Tracer) removeEntries(entries []network.ConnectionStats) {
 	now := time.Now()
-	// Byte keys of the connections to remove
-	toRemove := make([]*network.ConnectionStats, 0, len(entries))
 	// Remove the entries from the eBPF Map
-	for i := range entries {
-		entry := &entries[i]
-		err := t.ebpfTracer.Remove(entry)
-		if err != nil {
-			if !errors.Is(err, ebpf.ErrKeyNotExist) {
-				log.Warnf("failed to remove entry from connections: %s", err)
-			}
-			continue
-		}
-
+	toRemove := t.ebpfTracer.RemoveBatch(entries)
+	for _, entry := range toRemove {
 		// Delete conntrack entry for this connection
 		t.conntracker.DeleteTranslation(*entry)
-
-		// Append the connection key to the keys to remove from the userspace state
-		toRemove = append(toRemove, entry)
 	}
 
 	t.state.RemoveConnections(toRemove)
@@ -598,7 +701,7 @@ func (t *Tracer) removeEntries(entries []network.ConnectionStats) {
 
 //nolint:revive // TODO(NET) Fix revive linter
 func (t *Tracer) timeoutForConn(c *network.ConnectionStats) uint64 {
-	if c.Type == network.TCP {
+	if c.Type == network.TCP || c.Type == network.SCTP {
 		return uint64(t.config.TCPConnTimeout.Nanoseconds())
 	}
 
@@ -676,12 +779,22 @@ func (t *Tracer) getStats(comps ...statsComp) (map[string]interface{}, error) {
 func (t *Tracer) GetStats() (map[string]interface{}, error) {
 	return map[string]interface{}{
 		"tracer": map[string]interface{}{
-			"last_check": t.lastCheck.Load(),
+			"last_check":      t.lastCheck.Load(),
+			"preflight":       t.preflight,
+			"optional_probes": t.ebpfTracer.OptionalProbes(),
 		},
 		"universal_service_monitoring": t.usmMonitor.GetUSMStats(),
 	}, nil
 }
 
+// PreflightReport returns the capability report of the kernel, probed when the tracer was loaded
+func (t *Tracer) PreflightReport() (*preflight.Report, error) {
+	if t.preflight == nil {
+		return nil, errors.New("the kernel capabilities could not be probed")
+	}
+	return t.preflight, nil
+}
+
 // DebugNetworkState returns a map with the current tracer's internal state, for debugging
 //
 //nolint:revive // TODO(NET) Fix revive linter
@@ -692,6 +805,105 @@ func (t *Tracer) DebugNetworkState(clientID string) (map[string]interface{}, err
 	return t.state.DumpState(clientID), nil
 }
 
+// GetListeningSockets returns the TCP sockets in the LISTEN state and the bound UDP sockets of the host,
+// along with the container owning each of them
+func (t *Tracer) GetListeningSockets() ([]network.ListeningSocket, error) {
+	sockets, err := t.ebpfTracer.GetListeningSockets()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving listening sockets: %s", err)
+	}
+
+	if t.processCache == nil {
+		return sockets, nil
+	}
+
+	now := time.Now().UnixNano()
+	for i := range sockets {
+		p, ok := t.processCache.Get(sockets[i].Pid, now)
+		if !ok || p.ContainerID == nil {
+			continue
+		}
+		if cid, ok := p.ContainerID.Get().(string); ok {
+			sockets[i].ContainerID = cid
+		}
+	}
+
+	return sockets, nil
+}
+
+// GetUnixSockets returns the AF_UNIX sockets which sent data since the tracer was loaded,
+// along with the containers owning each end of them
+func (t *Tracer) GetUnixSockets() ([]network.UnixSocket, error) {
+	if !t.config.CollectUnixSockets {
+		return nil, nil
+	}
+
+	sockets, err := t.ebpfTracer.GetUnixSockets()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving unix sockets: %s", err)
+	}
+
+	if t.processCache == nil {
+		return sockets, nil
+	}
+
+	now := time.Now().UnixNano()
+	containerID := func(pid uint32) string {
+		p, ok := t.processCache.Get(pid, now)
+		if !ok || p.ContainerID == nil {
+			return ""
+		}
+		cid, _ := p.ContainerID.Get().(string)
+		return cid
+	}
+	for i := range sockets {
+		sockets[i].ContainerID = containerID(sockets[i].Pid)
+		if sockets[i].PeerPid != 0 {
+			sockets[i].PeerContainerID = containerID(sockets[i].PeerPid)
+		}
+	}
+
+	return sockets, nil
+}
+
+// GetInterfaceStats returns the counters of the network interfaces of all the network namespaces
+func (t *Tracer) GetInterfaceStats() ([]network.InterfaceStats, error) {
+	if t.interfaceStats == nil {
+		return nil, nil
+	}
+	return t.interfaceStats.Get()
+}
+
+// GetPacketDrops returns the number of packets dropped by the kernel since the tracer was loaded,
+// by interface and drop reason
+func (t *Tracer) GetPacketDrops() ([]network.PacketDrops, error) {
+	if !t.config.EnablePacketDropMonitoring {
+		return nil, nil
+	}
+
+	drops, err := t.ebpfTracer.GetPacketDrops()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving packet drops: %s", err)
+	}
+
+	t.dropReasonsOnce.Do(func() {
+		f, err := tracefs.Open("events/skb/kfree_skb/format")
+		if err != nil {
+			log.Warnf("unable to read the names of the drop reasons: %s", err)
+			return
+		}
+		defer f.Close()
+		if t.dropReasons, err = network.ParseDropReasons(f); err != nil {
+			log.Warnf("unable to parse the names of the drop reasons: %s", err)
+		}
+	})
+	for i := range drops {
+		drops[i].ReasonName = t.dropReasons[drops[i].Reason]
+	}
+
+	return drops, nil
+}
+
 // DebugNetworkMaps returns all connections stored in the BPF maps without modifications from network state
 //
 //nolint:revive // TODO(NET) Fix revive linter
@@ -739,14 +951,20 @@ func (t *Tracer) DebugEBPFMaps(w io.Writer, maps ...string) error {
 //
 //nolint:revive // TODO(NET) Fix revive linter
 func (t *Tracer) connectionExpired(conn *network.ConnectionStats, latestTime uint64, ctr *cachedConntrack) bool {
+	if t.connectionIdle(conn, latestTime) {
+		tracerTelemetry.idleConns.Inc(conn.Type.String())
+		return true
+	}
+
 	timeout := t.timeoutForConn(conn)
 	if !conn.IsExpired(latestTime, timeout) {
 		return false
 	}
 
-	// skip connection check for udp connections or if
-	// the pid for the connection is dead
-	if conn.Type == network.UDP || !procutil.PidExists(int(conn.Pid)) {
+	// skip connection check for udp and sctp connections or if
+	// the pid for the connection is dead. SCTP associations are
+	// not attributed to a pid, and are kept alive by heartbeats.
+	if conn.Type != network.TCP || !procutil.PidExists(int(conn.Pid)) {
 		return true
 	}
 
@@ -764,6 +982,15 @@ func (t *Tracer) connectionExpired(conn *network.ConnectionStats, latestTime uin
 	return !exists
 }
 
+// connectionIdle returns whether no data was transferred on the connection for longer than the idle timeout,
+// in which case it is evicted regardless of the state of its socket
+func (t *Tracer) connectionIdle(conn *network.ConnectionStats, latestTime uint64) bool {
+	if t.config.IdleConnectionTimeout <= 0 {
+		return false
+	}
+	return conn.IdleFor(latestTime) >= t.config.IdleConnectionTimeout
+}
+
 //nolint:revive // TODO(NET) Fix revive linter
 func (t *Tracer) connVia(cs *network.ConnectionStats) {
 	if t.gwLookup == nil {
@@ -771,6 +998,46 @@ func (t *Tracer) connVia(cs *network.ConnectionStats) {
 	}
 
 	cs.Via = t.gwLookup.Lookup(cs)
+	if t.config.EnableTrafficClassTags {
+		cs.TrafficClass = network.ClassifyTraffic(cs)
+	}
+}
+
+func newRouteCache(cfg *config.Config) (network.RouteCache, error) {
+	rootNs, err := cfg.GetRootNetNs()
+	if err != nil {
+		return nil, err
+	}
+	defer rootNs.Close()
+
+	router, err := network.NewNetlinkRouter(rootNs)
+	if err != nil {
+		return nil, err
+	}
+	return network.NewRouteCache(int(cfg.MaxTrackedConnections), router), nil
+}
+
+// checkNATHairpin flags the outgoing DNAT'ed connections whose original destination is routed
+// through a gateway, i.e. which would leave the host if they were not translated, but whose
+// translated destination is local: an address of the host, or a workload reached without a
+// gateway, such as a pod behind a NodePort or a public IP. The flag is only kept by the network
+// state for the connections confirmed to be intra-host, which rules out the on-link neighbors.
+func (t *Tracer) checkNATHairpin(cs *network.ConnectionStats) {
+	cs.NATHairpin = false
+	if !t.config.EnableNATHairpinDetection || t.routes == nil || cs.Direction != network.OUTGOING || cs.IPTranslation == nil {
+		return
+	}
+	translated := cs.IPTranslation.ReplSrcIP
+	if translated == cs.Dest {
+		return
+	}
+
+	r, ok := t.routes.Get(cs.Source, cs.Dest, cs.NetNS)
+	if !ok || r.Gateway.IsZero() || r.Gateway.IsUnspecified() {
+		return
+	}
+	r, ok = t.routes.Get(cs.Source, translated, cs.NetNS)
+	cs.NATHairpin = ok && (r.Local || r.Gateway.IsZero() || r.Gateway.IsUnspecified())
 }
 
 // DebugCachedConntrack dumps the cached NAT conntrack data
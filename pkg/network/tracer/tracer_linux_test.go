@@ -853,8 +853,10 @@ func (s *TracerSuite) TestGatewayLookupCrossNamespace() {
 			return ok && conn.Direction == network.OUTGOING
 		}, 3*time.Second, 100*time.Millisecond)
 
-		// conn.Via should be nil, since traffic is local
-		require.Nil(t, conn.Via)
+		// traffic is on-link, so Via should have no subnet
+		require.NotNil(t, conn.Via)
+		require.True(t, conn.Via.OnLink)
+		require.Empty(t, conn.Via.Subnet.Alias)
 	})
 
 	t.Run("client in other namespace", func(t *testing.T) {
@@ -885,8 +887,10 @@ func (s *TracerSuite) TestGatewayLookupCrossNamespace() {
 			return ok && conn.Direction == network.OUTGOING
 		}, 3*time.Second, 100*time.Millisecond)
 
-		// traffic is local, so Via field should not be set
-		require.Nil(t, conn.Via)
+		// traffic is on-link, so Via should have no subnet
+		require.NotNil(t, conn.Via)
+		require.True(t, conn.Via.OnLink)
+		require.Empty(t, conn.Via.Subnet.Alias)
 
 		// try connecting to something outside
 		dnsAddr := net.ParseIP("8.8.8.8")
@@ -2337,3 +2341,62 @@ LOOP:
 	assert.Greater(t, conn.Duration, time.Second, "connection duration should be between 1 and 2 seconds")
 	assert.Less(t, conn.Duration, 2*time.Second, "connection duration should be between 1 and 2 seconds")
 }
+
+// hairpinRouter routes the host and pod addresses locally, and the other destinations through a gateway
+type hairpinRouter struct{}
+
+func (hairpinRouter) Route(_, dest util.Address, _ uint32, _ network.RouteOptions) (network.Route, error) {
+	switch dest.String() {
+	case "10.0.25.1":
+		return network.Route{Local: true}, nil
+	case "10.244.0.5":
+		return network.Route{OnLink: true, IfIndex: 5}, nil
+	}
+	return network.Route{Gateway: util.AddressFromString("10.0.0.1")}, nil
+}
+
+func (hairpinRouter) Close() {}
+
+func TestCheckNATHairpin(t *testing.T) {
+	tr := &Tracer{
+		config: &config.Config{EnableNATHairpinDetection: true},
+		routes: network.NewRouteCache(10, hairpinRouter{}),
+	}
+	t.Cleanup(tr.routes.Close)
+
+	// the public address of a service is translated to a pod of the client host
+	c := network.ConnectionStats{
+		Source:    util.AddressFromString("10.244.0.4"),
+		Dest:      util.AddressFromString("3.3.3.3"),
+		SPort:     59782,
+		DPort:     443,
+		Direction: network.OUTGOING,
+		IPTranslation: &network.IPTranslation{
+			ReplSrcIP:   util.AddressFromString("10.244.0.5"),
+			ReplDstIP:   util.AddressFromString("10.244.0.4"),
+			ReplSrcPort: 8443,
+			ReplDstPort: 59782,
+		},
+	}
+	tr.checkNATHairpin(&c)
+	assert.True(t, c.NATHairpin)
+
+	// or to an address of the host, such as a NodePort
+	c.IPTranslation.ReplSrcIP = util.AddressFromString("10.0.25.1")
+	tr.checkNATHairpin(&c)
+	assert.True(t, c.NATHairpin)
+
+	// a service translated to a remote workload is not hairpinned
+	c.IPTranslation.ReplSrcIP = util.AddressFromString("10.1.0.7")
+	tr.checkNATHairpin(&c)
+	assert.False(t, c.NATHairpin)
+
+	// nor is a connection which is only SNAT'ed
+	c.IPTranslation.ReplSrcIP = c.Dest
+	tr.checkNATHairpin(&c)
+	assert.False(t, c.NATHairpin)
+
+	c.IPTranslation = nil
+	tr.checkNATHairpin(&c)
+	assert.False(t, c.NATHairpin)
+}
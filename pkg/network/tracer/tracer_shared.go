@@ -13,12 +13,30 @@ import (
 
 // shouldSkipConnection returns whether or not the tracer should ignore a given connection:
 //   - Local DNS (*:53) requests if configured (default: true)
+//   - Connections matching the source and destination excludes, which include the ignore rules
 func (t *Tracer) shouldSkipConnection(conn *network.ConnectionStats) bool {
 	isDNSConnection := conn.DPort == 53 || conn.SPort == 53
 	if !t.config.CollectLocalDNS && isDNSConnection && conn.Dest.IsLoopback() {
 		return true
-	} else if network.IsExcludedConnection(t.sourceExcludes, t.destExcludes, conn) {
+	}
+	return network.IsExcludedConnection(t.sourceExcludes, t.destExcludes, conn)
+}
+
+// shouldSkipClosedConnection is shouldSkipConnection for the closed connections, which also counts the
+// connections ignored by the rules of network_config.ignore_conns. The active connections being filtered
+// again with each check, they are counted once closed.
+func (t *Tracer) shouldSkipClosedConnection(conn *network.ConnectionStats) bool {
+	if f := network.MatchingConnectionFilter(t.sourceExcludes, t.destExcludes, conn); f != nil {
+		f.CountIgnored()
 		return true
 	}
-	return false
+	return t.shouldSkipConnection(conn)
+}
+
+// SubscribeClosedConnections returns a channel receiving batches of connections as
+// they are closed, along with a function that must be called to cancel the subscription.
+// Batches are dropped if the subscriber falls more than bufferSize batches behind.
+func (t *Tracer) SubscribeClosedConnections(bufferSize int) (<-chan []network.ConnectionStats, func(), error) {
+	ch, cancel := t.closedConnStreamer.subscribe(bufferSize)
+	return ch, cancel, nil
 }
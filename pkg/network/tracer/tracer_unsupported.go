@@ -15,6 +15,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/ebpf"
 	"github.com/DataDog/datadog-agent/pkg/network"
 	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/preflight"
 )
 
 // Tracer is not implemented
@@ -33,6 +34,31 @@ func (t *Tracer) GetActiveConnections(_ string) (*network.Connections, error) {
 	return nil, ebpf.ErrNotImplemented
 }
 
+// SubscribeClosedConnections is not implemented on this OS for Tracer
+func (t *Tracer) SubscribeClosedConnections(_ int) (<-chan []network.ConnectionStats, func(), error) {
+	return nil, nil, ebpf.ErrNotImplemented
+}
+
+// GetListeningSockets is not implemented on this OS for Tracer
+func (t *Tracer) GetListeningSockets() ([]network.ListeningSocket, error) {
+	return nil, ebpf.ErrNotImplemented
+}
+
+// GetUnixSockets is not implemented on this OS for Tracer
+func (t *Tracer) GetUnixSockets() ([]network.UnixSocket, error) {
+	return nil, ebpf.ErrNotImplemented
+}
+
+// GetInterfaceStats is not implemented on this OS for Tracer
+func (t *Tracer) GetInterfaceStats() ([]network.InterfaceStats, error) {
+	return nil, ebpf.ErrNotImplemented
+}
+
+// GetPacketDrops is not implemented on this OS for Tracer
+func (t *Tracer) GetPacketDrops() ([]network.PacketDrops, error) {
+	return nil, ebpf.ErrNotImplemented
+}
+
 // RegisterClient registers the client
 func (t *Tracer) RegisterClient(clientID string) error { //nolint:revive // TODO fix revive unused-parameter
 	return ebpf.ErrNotImplemented
@@ -48,6 +74,11 @@ func (t *Tracer) DebugNetworkState(clientID string) (map[string]interface{}, err
 	return nil, ebpf.ErrNotImplemented
 }
 
+// PreflightReport is not implemented on this OS for Tracer
+func (t *Tracer) PreflightReport() (*preflight.Report, error) {
+	return nil, ebpf.ErrNotImplemented
+}
+
 // DebugNetworkMaps is not implemented on this OS for Tracer
 func (t *Tracer) DebugNetworkMaps() (*network.Connections, error) {
 	return nil, ebpf.ErrNotImplemented
@@ -72,3 +103,13 @@ func (t *Tracer) DebugHostConntrack(context.Context) (interface{}, error) {
 func (t *Tracer) DebugDumpProcessCache(context.Context) (interface{}, error) {
 	return nil, ebpf.ErrNotImplemented
 }
+
+// ProbeGroups is not implemented on this OS for Tracer
+func (t *Tracer) ProbeGroups() map[string]bool {
+	return nil
+}
+
+// SetProbeGroupEnabled is not implemented on this OS for Tracer
+func (t *Tracer) SetProbeGroupEnabled(_ string, _ bool) error {
+	return ebpf.ErrNotImplemented
+}
@@ -25,6 +25,7 @@ import (
 
 	redis2 "github.com/go-redis/redis/v9"
 	gorilla "github.com/gorilla/mux"
+	"github.com/miekg/dns"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"github.com/twmb/franz-go/pkg/kgo"
@@ -2140,6 +2141,85 @@ func testHTTP2ProtocolClassification(t *testing.T, tr *Tracer, clientHost, targe
 	}
 }
 
+// testPayloadSignatureProtocolClassification checks protocols which are only classified
+// from the first bytes of the payload, on ports not associated with them.
+func testPayloadSignatureProtocolClassification(t *testing.T, tr *Tracer, clientHost, targetHost, serverHost string) {
+	defaultDialer := &net.Dialer{
+		LocalAddr: &net.TCPAddr{
+			IP: net.ParseIP(clientHost),
+		},
+	}
+
+	dnsQuery := new(dns.Msg)
+	dnsQuery.SetQuestion("example.com.", dns.TypeA)
+	packedQuery, err := dnsQuery.Pack()
+	require.NoError(t, err)
+	dnsPayload := append([]byte{byte(len(packedQuery) >> 8), byte(len(packedQuery))}, packedQuery...)
+
+	echoServerSetup := func(t *testing.T, ctx testContext) {
+		server := NewTCPServerOnAddress(ctx.serverAddress, func(c net.Conn) {
+			defer c.Close()
+			buf := make([]byte, 512)
+			n, err := c.Read(buf)
+			if err == nil {
+				c.Write(buf[:n])
+			}
+		})
+		ctx.extras["server"] = server
+		require.NoError(t, server.Run())
+	}
+	sendPayload := func(payload []byte) func(t *testing.T, ctx testContext) {
+		return func(t *testing.T, ctx testContext) {
+			timedContext, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			c, err := defaultDialer.DialContext(timedContext, "tcp", ctx.targetAddress)
+			require.NoError(t, err)
+			defer c.Close()
+			c.Write(payload)
+			io.Copy(io.Discard, c)
+		}
+	}
+	teardown := func(t *testing.T, ctx testContext) {
+		if server, ok := ctx.extras["server"].(*TCPServer); ok {
+			server.Shutdown()
+		}
+	}
+
+	tests := []protocolClassificationAttributes{
+		{
+			name: "ssh",
+			context: testContext{
+				serverPort:    "10010",
+				serverAddress: net.JoinHostPort(serverHost, "10010"),
+				targetAddress: net.JoinHostPort(targetHost, "10010"),
+				extras:        make(map[string]interface{}),
+			},
+			preTracerSetup:  echoServerSetup,
+			postTracerSetup: sendPayload([]byte("SSH-2.0-Go\r\n")),
+			teardown:        teardown,
+			validation:      validateProtocolConnection(&protocols.Stack{Application: protocols.SSH}),
+		},
+		{
+			name: "dns over tcp",
+			context: testContext{
+				serverPort:    "10011",
+				serverAddress: net.JoinHostPort(serverHost, "10011"),
+				targetAddress: net.JoinHostPort(targetHost, "10011"),
+				extras:        make(map[string]interface{}),
+			},
+			preTracerSetup:  echoServerSetup,
+			postTracerSetup: sendPayload(dnsPayload),
+			teardown:        teardown,
+			validation:      validateProtocolConnection(&protocols.Stack{Application: protocols.DNS}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testProtocolClassificationInner(t, tt, tr)
+		})
+	}
+}
+
 func testProtocolClassificationLinux(t *testing.T, tr *Tracer, clientHost, targetHost, serverHost string) {
 	tests := []struct {
 		name     string
@@ -2173,6 +2253,10 @@ func testProtocolClassificationLinux(t *testing.T, tr *Tracer, clientHost, targe
 			name:     "http2",
 			testFunc: testHTTP2ProtocolClassification,
 		},
+		{
+			name:     "payload signatures",
+			testFunc: testPayloadSignatureProtocolClassification,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
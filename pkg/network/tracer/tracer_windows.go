@@ -24,6 +24,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/network/config"
 	"github.com/DataDog/datadog-agent/pkg/network/dns"
 	driver "github.com/DataDog/datadog-agent/pkg/network/driver"
+	"github.com/DataDog/datadog-agent/pkg/network/preflight"
 	"github.com/DataDog/datadog-agent/pkg/network/usm"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -43,6 +44,9 @@ type Tracer struct {
 	state           network.State
 	reverseDNS      dns.ReverseDNS
 	usmMonitor      usm.Monitor
+	// encryptedDNS finds the connections carrying DNS over TLS or DNS over HTTPS
+	encryptedDNS *network.EncryptedDNSDetector
+	gwLookup     network.GatewayLookup
 
 	closedBuffer *network.ConnectionBuffer
 	connLock     sync.Mutex
@@ -58,6 +62,8 @@ type Tracer struct {
 
 	// windows event handle for stopping the closed connection event loop
 	hStopClosedLoopEvent windows.Handle
+
+	closedConnStreamer *closedConnStreamer
 }
 
 // NewTracer returns an initialized tracer struct
@@ -74,17 +80,7 @@ func NewTracer(config *config.Config) (*Tracer, error) {
 		return nil, fmt.Errorf("could not create windows driver controller: %v", err)
 	}
 
-	state := network.NewState(
-		config.ClientStateExpiry,
-		config.MaxClosedConnectionsBuffered,
-		config.MaxConnectionsStateBuffered,
-		config.MaxDNSStatsBuffered,
-		config.MaxHTTPStatsBuffered,
-		config.MaxKafkaStatsBuffered,
-		config.MaxPostgresStatsBuffered,
-		config.EnableNPMConnectionRollup,
-		config.EnableProcessEventMonitoring,
-	)
+	state := network.NewState(config)
 
 	reverseDNS := dns.NewNullReverseDNS()
 	if config.DNSInspection {
@@ -98,6 +94,8 @@ func NewTracer(config *config.Config) (*Tracer, error) {
 	if err != nil {
 		return nil, fmt.Errorf("could not create stop event: %w", err)
 	}
+	// the ignore rules match either endpoint of the connections
+	ignoreRules := network.ParseIgnoreRules(config.IgnoredConnections)
 	tr := &Tracer{
 		config:               config,
 		driverInterface:      di,
@@ -107,10 +105,22 @@ func NewTracer(config *config.Config) (*Tracer, error) {
 		closedBuffer:         network.NewConnectionBuffer(defaultBufferSize, minBufferSize),
 		reverseDNS:           reverseDNS,
 		usmMonitor:           newUSMMonitor(config, di.GetHandle()),
-		sourceExcludes:       network.ParseConnectionFilters(config.ExcludedSourceConnections),
-		destExcludes:         network.ParseConnectionFilters(config.ExcludedDestinationConnections),
+		sourceExcludes:       append(network.ParseConnectionFilters(config.ExcludedSourceConnections), ignoreRules...),
+		destExcludes:         append(network.ParseConnectionFilters(config.ExcludedDestinationConnections), ignoreRules...),
+		encryptedDNS:         network.NewEncryptedDNSDetector(config.EncryptedDNSHosts, config.EnableEncryptedDNSTags),
 		hStopClosedLoopEvent: stopEvent,
+		closedConnStreamer:   newClosedConnStreamer(),
+	}
+	if config.EnableGatewayLookup {
+		tr.gwLookup = network.NewGatewayLookup(nil, config.MaxTrackedConnections)
+		if tr.gwLookup != nil && config.GatewayLookupQueueSize > 0 {
+			tr.gwLookup = network.NewAsyncGatewayLookup(tr.gwLookup, config.GatewayLookupQueueSize, int(config.MaxTrackedConnections))
+		}
+	}
+	if tr.gwLookup != nil {
+		log.Info("gateway lookup enabled")
 	}
+
 	tr.closedEventLoop.Add(1)
 	go func() {
 		defer tr.closedEventLoop.Done()
@@ -129,10 +139,11 @@ func NewTracer(config *config.Config) (*Tracer, error) {
 
 			case windows.WAIT_OBJECT_0 + 1:
 				_, err = tr.driverInterface.GetClosedConnectionStats(tr.closedBuffer, func(c *network.ConnectionStats) bool {
-					return !tr.shouldSkipConnection(c)
+					return !tr.shouldSkipClosedConnection(c)
 				})
 				closedConnStats := tr.closedBuffer.Connections()
 
+				tr.closedConnStreamer.publish(closedConnStats)
 				tr.state.StoreClosedConnections(closedConnStats)
 
 			case windows.WAIT_FAILED:
@@ -154,9 +165,13 @@ func (t *Tracer) Stop() {
 		_ = t.usmMonitor.Stop()
 	}
 	t.reverseDNS.Close()
+	if t.gwLookup != nil {
+		t.gwLookup.Close()
+	}
 
 	windows.SetEvent(t.hStopClosedLoopEvent)
 	t.closedEventLoop.Wait()
+	t.closedConnStreamer.close()
 	err := t.driverInterface.Close()
 	if err != nil {
 		log.Errorf("error closing driver interface: %s", err)
@@ -184,7 +199,7 @@ func (t *Tracer) GetActiveConnections(clientID string) (*network.Connections, er
 		return nil, fmt.Errorf("error retrieving open connections from driver: %w", err)
 	}
 	_, err = t.driverInterface.GetClosedConnectionStats(t.closedBuffer, func(c *network.ConnectionStats) bool {
-		return !t.shouldSkipConnection(c)
+		return !t.shouldSkipClosedConnection(c)
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving closed connections from driver: %w", err)
@@ -196,6 +211,7 @@ func (t *Tracer) GetActiveConnections(clientID string) (*network.Connections, er
 	t.state.RemoveExpiredClients(time.Now())
 
 	t.state.StoreClosedConnections(closedConnStats)
+	t.state.StoreResolverLatencies(t.reverseDNS.GetResolverLatencies())
 
 	var delta network.Delta
 	if t.usmMonitor != nil { //nolint
@@ -204,6 +220,15 @@ func (t *Tracer) GetActiveConnections(clientID string) (*network.Connections, er
 		delta = t.state.GetDelta(clientID, uint64(time.Now().Nanosecond()), activeConnStats, t.reverseDNS.GetDNSStats(), nil)
 	}
 
+	if t.gwLookup != nil {
+		for i := range delta.Conns {
+			delta.Conns[i].Via = t.gwLookup.Lookup(&delta.Conns[i])
+			if t.config.EnableTrafficClassTags {
+				delta.Conns[i].TrafficClass = network.ClassifyTraffic(&delta.Conns[i])
+			}
+		}
+	}
+
 	ips := make(map[util.Address]struct{}, len(delta.Conns)/2)
 	for _, conn := range delta.Conns {
 		ips[conn.Source] = struct{}{}
@@ -213,8 +238,11 @@ func (t *Tracer) GetActiveConnections(clientID string) (*network.Connections, er
 	buffer.Assign(delta.Conns)
 	conns := network.NewConnections(buffer)
 	conns.DNS = t.reverseDNS.Resolve(ips)
+	conns.EncryptedDNS = t.encryptedDNS.Detect(conns)
 	conns.ConnTelemetry = t.state.GetTelemetryDelta(clientID, t.getConnTelemetry())
 	conns.HTTP = delta.HTTP
+	conns.Churn = delta.Churn
+	conns.ResolverLatencies = delta.ResolverLatencies
 	return conns, nil
 }
 
@@ -251,6 +279,31 @@ func (t *Tracer) DebugNetworkState(_ string) (map[string]interface{}, error) {
 	return nil, ebpf.ErrNotImplemented
 }
 
+// GetListeningSockets is not implemented on this OS for Tracer
+func (t *Tracer) GetListeningSockets() ([]network.ListeningSocket, error) {
+	return nil, ebpf.ErrNotImplemented
+}
+
+// GetUnixSockets is not implemented on this OS for Tracer
+func (t *Tracer) GetUnixSockets() ([]network.UnixSocket, error) {
+	return nil, ebpf.ErrNotImplemented
+}
+
+// GetInterfaceStats is not implemented on this OS for Tracer
+func (t *Tracer) GetInterfaceStats() ([]network.InterfaceStats, error) {
+	return nil, ebpf.ErrNotImplemented
+}
+
+// GetPacketDrops is not implemented on this OS for Tracer
+func (t *Tracer) GetPacketDrops() ([]network.PacketDrops, error) {
+	return nil, ebpf.ErrNotImplemented
+}
+
+// PreflightReport is not implemented on this OS for Tracer
+func (t *Tracer) PreflightReport() (*preflight.Report, error) {
+	return nil, ebpf.ErrNotImplemented
+}
+
 // DebugNetworkMaps returns all connections stored in the maps without modifications from network state
 func (t *Tracer) DebugNetworkMaps() (*network.Connections, error) {
 	return nil, ebpf.ErrNotImplemented
@@ -302,3 +355,13 @@ func newUSMMonitor(c *config.Config, dh driver.Handle) usm.Monitor {
 	monitor.Start()
 	return monitor
 }
+
+// ProbeGroups is not implemented on this OS for Tracer
+func (t *Tracer) ProbeGroups() map[string]bool {
+	return nil
+}
+
+// SetProbeGroupEnabled is not implemented on this OS for Tracer
+func (t *Tracer) SetProbeGroupEnabled(_ string, _ bool) error {
+	return ebpf.ErrNotImplemented
+}
@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import "net/netip"
+
+// TrafficClass tells where the traffic of a connection routed through a gateway goes, relative to
+// the cloud network of the host
+type TrafficClass uint8
+
+const (
+	// TrafficClassUnknown is used when the connection isn't routed through a gateway, or when the
+	// cloud network of the gateway is unknown
+	TrafficClassUnknown TrafficClass = iota
+	// TrafficClassSameSubnet is used when the destination is reached without a gateway, in the subnet
+	// of the interface of the host
+	TrafficClassSameSubnet
+	// TrafficClassSameNetwork is used when the destination is in another subnet of the cloud network
+	// of the host, such as the same AWS VPC, in the same zone or an unknown one
+	TrafficClassSameNetwork
+	// TrafficClassPrivate is used when the destination is a private address outside of the cloud
+	// network of the host, reached through a VPN or an unknown route
+	TrafficClassPrivate
+	// TrafficClassInternet is used when the destination is a public address
+	TrafficClassInternet
+	// TrafficClassCrossZone is used when the destination is in a subnet of the cloud network of the
+	// host in another availability zone
+	TrafficClassCrossZone
+	// TrafficClassPeering is used when the destination is reached through a VPC peering
+	TrafficClassPeering
+	// TrafficClassTransitGateway is used when the destination is reached through a transit gateway
+	TrafficClassTransitGateway
+)
+
+func (c TrafficClass) String() string {
+	switch c {
+	case TrafficClassSameSubnet:
+		return "same_subnet"
+	case TrafficClassSameNetwork:
+		return "same_network"
+	case TrafficClassPrivate:
+		return "private"
+	case TrafficClassInternet:
+		return "internet"
+	case TrafficClassCrossZone:
+		return "cross_zone"
+	case TrafficClassPeering:
+		return "vpc_peering"
+	case TrafficClassTransitGateway:
+		return "transit_gateway"
+	default:
+		return "unknown"
+	}
+}
+
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598, which isn't routable on the internet
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// ClassifyTraffic returns the class of the traffic of a connection, from its route and the subnet
+// of the gateway it is routed through. The gateway of the connection must already be resolved.
+func ClassifyTraffic(c *ConnectionStats) TrafficClass {
+	if c.Via == nil || c.Via.Pending {
+		return TrafficClassUnknown
+	}
+	if c.Via.OnLink {
+		return TrafficClassSameSubnet
+	}
+	if c.Via.Subnet.NetworkID == "" {
+		return TrafficClassUnknown
+	}
+
+	addr := routedDest(c)
+	if subnet, err := netip.ParsePrefix(c.Via.Subnet.Cidr); err == nil && subnet.Contains(addr) {
+		return TrafficClassSameSubnet
+	}
+	if t := c.Via.Subnet.Topology; t != nil {
+		if class, ok := t.classify(c.Via.Subnet.Alias, c.Via.Subnet.Zone, addr); ok {
+			return class
+		}
+	}
+	for _, prefix := range c.Via.Subnet.NetworkCidrs {
+		if prefix.Contains(addr) {
+			return TrafficClassSameNetwork
+		}
+	}
+	if addr.IsPrivate() || sharedAddressSpace.Contains(addr) {
+		return TrafficClassPrivate
+	}
+	if addr.IsGlobalUnicast() {
+		return TrafficClassInternet
+	}
+	return TrafficClassUnknown
+}
+
+// routedDest returns the destination the connection is routed to, after NAT
+func routedDest(c *ConnectionStats) netip.Addr {
+	dest := c.Dest
+	if c.IPTranslation != nil {
+		dest = c.IPTranslation.ReplSrcIP
+	}
+	return dest.Unmap()
+}
+
+// GetTrafficClassTag returns the tag for the traffic class of the connection, or an empty
+// string if the class is unknown
+func GetTrafficClassTag(c *ConnectionStats) string {
+	if c.TrafficClass == TrafficClassUnknown {
+		return ""
+	}
+	return "traffic_class:" + c.TrafficClass.String()
+}
@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestClassifyTraffic(t *testing.T) {
+	via := &Via{Subnet: Subnet{
+		Alias:        "subnet-foo",
+		Cidr:         "10.0.1.0/24",
+		NetworkID:    "vpc-foo",
+		NetworkCidrs: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/16")},
+	}}
+
+	tests := []struct {
+		dest     string
+		expected TrafficClass
+	}{
+		{"10.0.1.20", TrafficClassSameSubnet},
+		{"10.0.2.20", TrafficClassSameNetwork},
+		{"10.1.0.20", TrafficClassPrivate},
+		{"100.64.0.1", TrafficClassPrivate},
+		{"8.8.8.8", TrafficClassInternet},
+	}
+	for _, tt := range tests {
+		c := ConnectionStats{Dest: util.AddressFromString(tt.dest), Via: via}
+		assert.Equal(t, tt.expected, ClassifyTraffic(&c), tt.dest)
+	}
+
+	t.Run("translated destination", func(t *testing.T) {
+		c := ConnectionStats{
+			Dest:          util.AddressFromString("8.8.8.8"),
+			Via:           via,
+			IPTranslation: &IPTranslation{ReplSrcIP: util.AddressFromString("10.0.2.20")},
+		}
+		assert.Equal(t, TrafficClassSameNetwork, ClassifyTraffic(&c))
+	})
+
+	t.Run("on-link", func(t *testing.T) {
+		c := ConnectionStats{Dest: util.AddressFromString("172.17.0.2"), Via: &Via{OnLink: true}}
+		assert.Equal(t, TrafficClassSameSubnet, ClassifyTraffic(&c))
+	})
+
+	t.Run("topology", func(t *testing.T) {
+		local := []CloudRoute{{Destination: netip.MustParsePrefix("10.0.0.0/16"), Target: "local"}}
+		topology := &CloudTopology{
+			Subnets: []CloudSubnet{
+				{Prefix: netip.MustParsePrefix("10.0.1.0/24"), Zone: "us-east-1a"},
+				{Prefix: netip.MustParsePrefix("10.0.2.0/24"), Zone: "us-east-1a"},
+				{Prefix: netip.MustParsePrefix("10.0.3.0/24"), Zone: "us-east-1b"},
+			},
+			RouteTables: map[string][]CloudRoute{
+				"subnet-foo": append([]CloudRoute{
+					{Destination: netip.MustParsePrefix("10.1.0.0/16"), Target: "pcx-foo"},
+					{Destination: netip.MustParsePrefix("10.2.0.0/16"), Target: "tgw-foo"},
+					{Destination: netip.MustParsePrefix("10.3.0.0/16"), Target: "vgw-foo"},
+					{Destination: netip.MustParsePrefix("0.0.0.0/0"), Target: "nat-foo"},
+				}, local...),
+			},
+			MainRouteTable: local,
+		}
+		via := &Via{Subnet: via.Subnet}
+		via.Subnet.Zone = "us-east-1a"
+		via.Subnet.Topology = topology
+
+		tests := []struct {
+			dest     string
+			expected TrafficClass
+		}{
+			{"10.0.1.20", TrafficClassSameSubnet},
+			{"10.0.2.20", TrafficClassSameNetwork},
+			{"10.0.3.20", TrafficClassCrossZone},
+			{"10.1.0.20", TrafficClassPeering},
+			{"10.2.0.20", TrafficClassTransitGateway},
+			{"10.3.0.20", TrafficClassPrivate},
+			{"8.8.8.8", TrafficClassInternet},
+		}
+		for _, tt := range tests {
+			c := ConnectionStats{Dest: util.AddressFromString(tt.dest), Via: via}
+			assert.Equal(t, tt.expected, ClassifyTraffic(&c), tt.dest)
+		}
+
+		// the subnets without a route table of their own use the main one
+		other := &Via{Subnet: via.Subnet}
+		other.Subnet.Alias = "subnet-bar"
+		other.Subnet.Cidr = "10.0.2.0/24"
+		c := ConnectionStats{Dest: util.AddressFromString("10.0.3.20"), Via: other}
+		assert.Equal(t, TrafficClassCrossZone, ClassifyTraffic(&c))
+		c.Dest = util.AddressFromString("10.1.0.20")
+		assert.Equal(t, TrafficClassPrivate, ClassifyTraffic(&c))
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		c := ConnectionStats{Dest: util.AddressFromString("8.8.8.8")}
+		assert.Equal(t, TrafficClassUnknown, ClassifyTraffic(&c))
+		assert.Empty(t, GetTrafficClassTag(&c))
+
+		c.Via = &Via{Pending: true}
+		assert.Equal(t, TrafficClassUnknown, ClassifyTraffic(&c))
+
+		c.Via = &Via{Subnet: Subnet{Alias: "subnet-foo"}}
+		assert.Equal(t, TrafficClassUnknown, ClassifyTraffic(&c))
+	})
+
+	c := ConnectionStats{TrafficClass: TrafficClassSameNetwork}
+	assert.Equal(t, "traffic_class:same_network", GetTrafficClassTag(&c))
+}
@@ -0,0 +1,124 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"fmt"
+)
+
+// UnixSocketType is the type of an AF_UNIX socket
+type UnixSocketType uint8
+
+const (
+	// UnixStream is a SOCK_STREAM socket
+	UnixStream UnixSocketType = 1
+	// UnixDgram is a SOCK_DGRAM socket
+	UnixDgram UnixSocketType = 2
+	// UnixSeqPacket is a SOCK_SEQPACKET socket
+	UnixSeqPacket UnixSocketType = 5
+)
+
+func (t UnixSocketType) String() string {
+	switch t {
+	case UnixStream:
+		return "stream"
+	case UnixDgram:
+		return "dgram"
+	case UnixSeqPacket:
+		return "seqpacket"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(t))
+	}
+}
+
+// UnixSocket describes the traffic sent and received through an AF_UNIX socket
+type UnixSocket struct {
+	Type UnixSocketType
+	// Ino is the inode number of the socket, as reported in /proc/<pid>/fd
+	Ino uint64
+	// PeerIno is the inode number of the socket this socket is connected to, if any
+	PeerIno uint64
+	// Path is the path the socket, or its peer, is bound to.
+	// Abstract names are prefixed with '@', as done by ss and /proc/net/unix.
+	Path string
+
+	// Pid is the last process which sent data through the socket
+	Pid         uint32
+	PeerPid     uint32
+	ContainerID string
+	// PeerContainerID is the ID of the container owning PeerPid, if any
+	PeerContainerID string
+
+	SentBytes   uint64
+	SentPackets uint64
+	RecvBytes   uint64
+	RecvPackets uint64
+
+	LastUpdateEpoch uint64
+	// Closed is true if the socket was released, in which case it is reported until it expires
+	Closed bool
+}
+
+// UnixSocketPath returns the printable form of the path of an AF_UNIX socket
+func UnixSocketPath(path []byte) string {
+	if len(path) == 0 {
+		return ""
+	}
+	// abstract names start with a NUL byte and are not NUL terminated
+	if path[0] == 0 {
+		return "@" + string(path[1:])
+	}
+	for i, c := range path {
+		if c == 0 {
+			return string(path[:i])
+		}
+	}
+	return string(path)
+}
+
+// ResolveUnixSocketPeers fills in the fields of each socket which are derived from its peer:
+// the received traffic, the peer process, and the path of unbound client sockets.
+// Sockets which never sent any data are not tracked, so their peers report no received traffic.
+func ResolveUnixSocketPeers(sockets []UnixSocket) {
+	byIno := make(map[uint64]int, len(sockets))
+	for i := range sockets {
+		byIno[sockets[i].Ino] = i
+	}
+
+	for i := range sockets {
+		s := &sockets[i]
+		if s.PeerIno == 0 {
+			continue
+		}
+		j, ok := byIno[s.PeerIno]
+		if !ok {
+			continue
+		}
+		peer := &sockets[j]
+		s.PeerPid = peer.Pid
+		if s.Path == "" {
+			s.Path = peer.Path
+		}
+		// the peer of a datagram socket may be shared by many senders
+		if peer.PeerIno == s.Ino {
+			s.RecvBytes = peer.SentBytes
+			s.RecvPackets = peer.SentPackets
+		}
+	}
+}
+
+func (s UnixSocket) String() string {
+	return fmt.Sprintf("[unix %s] [PID: %d] [ino: %d] [peer PID: %d] [peer ino: %d] %s (sent: %d bytes, received: %d bytes)",
+		s.Type,
+		s.Pid,
+		s.Ino,
+		s.PeerPid,
+		s.PeerIno,
+		s.Path,
+		s.SentBytes,
+		s.RecvBytes,
+	)
+}
@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnixSocketPath(t *testing.T) {
+	assert.Equal(t, "", UnixSocketPath(nil))
+	assert.Equal(t, "/var/run/docker.sock", UnixSocketPath([]byte("/var/run/docker.sock")))
+	assert.Equal(t, "/tmp/a", UnixSocketPath([]byte("/tmp/a\x00\x00garbage")))
+	assert.Equal(t, "@envoy_admin", UnixSocketPath([]byte("\x00envoy_admin")))
+}
+
+func TestResolveUnixSocketPeers(t *testing.T) {
+	sockets := []UnixSocket{
+		// client connected to docker.sock
+		{Type: UnixStream, Ino: 10, PeerIno: 11, Pid: 100, SentBytes: 50, SentPackets: 2},
+		// server side of the connection
+		{Type: UnixStream, Ino: 11, PeerIno: 10, Path: "/var/run/docker.sock", Pid: 1, SentBytes: 500, SentPackets: 3},
+		// datagram sockets sending to a shared receiver
+		{Type: UnixDgram, Ino: 20, PeerIno: 22, Pid: 200, SentBytes: 10, SentPackets: 1},
+		{Type: UnixDgram, Ino: 21, PeerIno: 22, Pid: 201, SentBytes: 20, SentPackets: 1},
+		{Type: UnixDgram, Ino: 22, Path: "@syslog", Pid: 2},
+		// peer not tracked
+		{Type: UnixStream, Ino: 30, PeerIno: 31, Pid: 300, SentBytes: 5, SentPackets: 1},
+	}
+	ResolveUnixSocketPeers(sockets)
+
+	assert.Equal(t, "/var/run/docker.sock", sockets[0].Path)
+	assert.Equal(t, uint32(1), sockets[0].PeerPid)
+	assert.Equal(t, uint64(500), sockets[0].RecvBytes)
+	assert.Equal(t, uint64(3), sockets[0].RecvPackets)
+	assert.Equal(t, uint32(100), sockets[1].PeerPid)
+	assert.Equal(t, uint64(50), sockets[1].RecvBytes)
+
+	assert.Equal(t, uint32(2), sockets[2].PeerPid)
+	assert.Zero(t, sockets[2].RecvBytes)
+	assert.Equal(t, "@syslog", sockets[3].Path)
+
+	assert.Zero(t, sockets[5].PeerPid)
+	assert.Equal(t, "", sockets[5].Path)
+}
@@ -418,10 +418,11 @@ var opensslSpec = &protocols.ProtocolSpec{
 }
 
 type sslProgram struct {
-	cfg           *config.Config
-	watcher       *sharedlibraries.Watcher
-	istioMonitor  *istioMonitor
-	nodeJSMonitor *nodeJSMonitor
+	cfg              *config.Config
+	watcher          *sharedlibraries.Watcher
+	istioMonitor     *istioMonitor
+	nodeJSMonitor    *nodeJSMonitor
+	staticTLSMonitor *staticTLSMonitor
 }
 
 func newSSLProgramProtocolFactory(m *manager.Manager) protocols.ProtocolFactory {
@@ -441,17 +442,17 @@ func newSSLProgramProtocolFactory(m *manager.Manager) protocols.ProtocolFactory
 			watcher, err = sharedlibraries.NewWatcher(c,
 				sharedlibraries.Rule{
 					Re:           regexp.MustCompile(`libssl.so`),
-					RegisterCB:   addHooks(m, procRoot, openSSLProbes),
+					RegisterCB:   withAttachTelemetry("openssl", addHooks(m, procRoot, openSSLProbes)),
 					UnregisterCB: removeHooks(m, openSSLProbes),
 				},
 				sharedlibraries.Rule{
 					Re:           regexp.MustCompile(`libcrypto.so`),
-					RegisterCB:   addHooks(m, procRoot, cryptoProbes),
+					RegisterCB:   withAttachTelemetry("libcrypto", addHooks(m, procRoot, cryptoProbes)),
 					UnregisterCB: removeHooks(m, cryptoProbes),
 				},
 				sharedlibraries.Rule{
 					Re:           regexp.MustCompile(`libgnutls.so`),
-					RegisterCB:   addHooks(m, procRoot, gnuTLSProbes),
+					RegisterCB:   withAttachTelemetry("gnutls", addHooks(m, procRoot, gnuTLSProbes)),
 					UnregisterCB: removeHooks(m, gnuTLSProbes),
 				},
 			)
@@ -461,10 +462,11 @@ func newSSLProgramProtocolFactory(m *manager.Manager) protocols.ProtocolFactory
 		}
 
 		return &sslProgram{
-			cfg:           c,
-			watcher:       watcher,
-			istioMonitor:  newIstioMonitor(c, m),
-			nodeJSMonitor: newNodeJSMonitor(c, m),
+			cfg:              c,
+			watcher:          watcher,
+			istioMonitor:     newIstioMonitor(c, m),
+			nodeJSMonitor:    newNodeJSMonitor(c, m),
+			staticTLSMonitor: newStaticTLSMonitor(c, m),
 		}, nil
 	}
 }
@@ -487,6 +489,7 @@ func (o *sslProgram) PreStart(*manager.Manager) error {
 	o.watcher.Start()
 	o.istioMonitor.Start()
 	o.nodeJSMonitor.Start()
+	o.staticTLSMonitor.Start()
 	return nil
 }
 
@@ -500,6 +503,7 @@ func (o *sslProgram) Stop(*manager.Manager) {
 	o.watcher.Stop()
 	o.istioMonitor.Stop()
 	o.nodeJSMonitor.Stop()
+	o.staticTLSMonitor.Stop()
 }
 
 // DumpMaps dumps the content of the map represented by mapName & currentMap, if it used by the eBPF program, to output.
@@ -192,6 +192,16 @@ func (m *Monitor) Stop() {
 	m.closeFilterFn()
 }
 
+// Pause detaches the probes of USM, until Resume is called
+func (m *Monitor) Pause() error {
+	return m.ebpfProgram.Pause()
+}
+
+// Resume attaches the probes of USM detached by Pause
+func (m *Monitor) Resume() error {
+	return m.ebpfProgram.Resume()
+}
+
 // DumpMaps dumps the maps associated with the monitor
 func (m *Monitor) DumpMaps(w io.Writer, maps ...string) error {
 	return m.ebpfProgram.DumpMaps(w, maps...)
@@ -9,9 +9,6 @@ package usm
 
 import (
 	"errors"
-	"os"
-	"path/filepath"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -269,27 +266,7 @@ func (m *nodeJSMonitor) handleProcessExec(pid uint32) {
 // getNodeJSPath returns the executable path of the nodejs binary for a given PID.
 // In case the PID doesn't represent a nodejs process, an empty string is returned.
 func (m *nodeJSMonitor) getNodeJSPath(pid uint32) string {
-	pidAsStr := strconv.FormatUint(uint64(pid), 10)
-	exePath := filepath.Join(m.procRoot, pidAsStr, "exe")
-
-	binPath, err := os.Readlink(exePath)
-	if err != nil {
-		// We receive the Exec event, /proc could be slow to update
-		end := time.Now().Add(10 * time.Millisecond)
-		for end.After(time.Now()) {
-			binPath, err = os.Readlink(exePath)
-			if err == nil {
-				break
-			}
-			time.Sleep(time.Millisecond)
-		}
-	}
-	if err != nil {
-		// we can't access to the binary path here (pid probably ended already)
-		// there are not much we can do, and we don't want to flood the logs
-		return ""
-	}
-
+	binPath := getExecutablePath(m.procRoot, pid)
 	if strings.Contains(binPath, nodeJSPath) {
 		return binPath
 	}
@@ -0,0 +1,262 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package usm
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	manager "github.com/DataDog/ebpf-manager"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
+	libtelemetry "github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/network/usm/utils"
+	"github.com/DataDog/datadog-agent/pkg/process/monitor"
+	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// staticTLSProbes are the OpenSSL probes attached to the executables which statically link the library. The
+// selectors are not shared with the shared library watcher, as their identification pairs are edited when
+// attaching.
+var staticTLSProbes = []manager.ProbesSelector{
+	&manager.BestEffort{
+		Selectors: probeSelectors(sslReadExProbe, sslReadExRetprobe, sslWriteExProbe, sslWriteExRetprobe),
+	},
+	&manager.AllOf{
+		Selectors: probeSelectors(
+			sslDoHandshakeProbe, sslDoHandshakeRetprobe, sslConnectProbe, sslConnectRetprobe,
+			sslSetBioProbe, sslSetFDProbe, sslReadProbe, sslReadRetprobe, sslWriteProbe, sslWriteRetprobe,
+			sslShutdownProbe, bioNewSocketProbe, bioNewSocketRetprobe,
+		),
+	},
+}
+
+func probeSelectors(funcNames ...string) []manager.ProbesSelector {
+	selectors := make([]manager.ProbesSelector, 0, len(funcNames))
+	for _, funcName := range funcNames {
+		selectors = append(selectors, &manager.ProbeSelector{
+			ProbeIdentificationPair: manager.ProbeIdentificationPair{
+				EBPFFuncName: funcName,
+			},
+		})
+	}
+	return selectors
+}
+
+// withAttachTelemetry counts the successful and failed attachments of the hooks of the given library
+func withAttachTelemetry(library string, registerCB func(utils.FilePath) error) func(utils.FilePath) error {
+	metricGroup := libtelemetry.NewMetricGroup("usm.tls.attach", "library:"+library, libtelemetry.OptPrometheus)
+	succeeded := metricGroup.NewCounter("succeeded")
+	failed := metricGroup.NewCounter("failed")
+	return func(fpath utils.FilePath) error {
+		if err := registerCB(fpath); err != nil {
+			failed.Add(1)
+			return err
+		}
+		succeeded.Add(1)
+		return nil
+	}
+}
+
+// staticTLSMonitor scans for the processes of the executables statically linking OpenSSL, or a library exposing
+// its API such as LibreSSL or BoringSSL, and attaches the SSL uprobes to them. The executables are selected by
+// the configured patterns of their paths, as seen from the process, and are resolved through its root so that
+// the copies vendored in container images are hooked.
+type staticTLSMonitor struct {
+	registry *utils.FileRegistry
+	procRoot string
+	binaries []*regexp.Regexp
+
+	// `utils.FileRegistry` callbacks
+	registerCB   func(utils.FilePath) error
+	unregisterCB func(utils.FilePath) error
+
+	// Termination
+	wg   sync.WaitGroup
+	done chan struct{}
+}
+
+// Validate that staticTLSMonitor implements the Attacher interface.
+var _ utils.Attacher = &staticTLSMonitor{}
+
+func newStaticTLSMonitor(c *config.Config, mgr *manager.Manager) *staticTLSMonitor {
+	if !c.EnableNativeTLSMonitoring || !http.TLSSupported(c) || len(c.NativeTLSStaticBinaries) == 0 {
+		return nil
+	}
+
+	binaries := make([]*regexp.Regexp, 0, len(c.NativeTLSStaticBinaries))
+	for _, pattern := range c.NativeTLSStaticBinaries {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			log.Errorf("ignoring invalid pattern of statically linked TLS executables %q: %s", pattern, err)
+			continue
+		}
+		binaries = append(binaries, re)
+	}
+	if len(binaries) == 0 {
+		return nil
+	}
+
+	procRoot := kernel.ProcFSRoot()
+	return &staticTLSMonitor{
+		registry: utils.NewFileRegistry("static_tls"),
+		procRoot: procRoot,
+		binaries: binaries,
+		done:     make(chan struct{}),
+
+		// Callbacks
+		registerCB:   withAttachTelemetry("static", addHooks(mgr, procRoot, staticTLSProbes)),
+		unregisterCB: removeHooks(mgr, staticTLSProbes),
+	}
+}
+
+// Start the staticTLSMonitor
+func (m *staticTLSMonitor) Start() {
+	if m == nil {
+		return
+	}
+
+	processMonitor := monitor.GetProcessMonitor()
+
+	// Subscribe to process events, the processes of the containers being started included
+	doneExec := processMonitor.SubscribeExec(m.handleProcessExec)
+	doneExit := processMonitor.SubscribeExit(m.handleProcessExit)
+
+	// Attach to existing processes
+	m.sync()
+
+	m.wg.Add(1)
+	go func() {
+		processSync := time.NewTicker(scanTerminatedProcessesInterval)
+
+		defer func() {
+			processSync.Stop()
+			doneExec()
+			doneExit()
+			processMonitor.Stop()
+			m.registry.Clear()
+			m.wg.Done()
+		}()
+
+		for {
+			select {
+			case <-m.done:
+				return
+			case <-processSync.C:
+				m.sync()
+				m.registry.Log()
+			}
+		}
+	}()
+	utils.AddAttacher("static-tls", m)
+	log.Infof("statically linked TLS monitoring enabled for %d patterns", len(m.binaries))
+}
+
+// Stop the staticTLSMonitor.
+func (m *staticTLSMonitor) Stop() {
+	if m == nil {
+		return
+	}
+
+	close(m.done)
+	m.wg.Wait()
+}
+
+// DetachPID detaches a given pid from the eBPF program
+func (m *staticTLSMonitor) DetachPID(pid uint32) error {
+	return m.registry.Unregister(pid)
+}
+
+var (
+	// ErrNoStaticTLSPath is returned when the executable of a given PID doesn't match the configured patterns
+	ErrNoStaticTLSPath = errors.New("no statically linked TLS executable found for PID")
+)
+
+// AttachPID attaches a given pid to the eBPF program
+func (m *staticTLSMonitor) AttachPID(pid uint32) error {
+	path := getExecutablePath(m.procRoot, pid)
+	if path == "" || !m.matches(path) {
+		return ErrNoStaticTLSPath
+	}
+
+	return m.registry.Register(
+		path,
+		pid,
+		m.registerCB,
+		m.unregisterCB,
+	)
+}
+
+func (m *staticTLSMonitor) matches(path string) bool {
+	for _, re := range m.binaries {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// sync state of staticTLSMonitor with the current state of procFS, see nodeJSMonitor.sync
+func (m *staticTLSMonitor) sync() {
+	deletionCandidates := m.registry.GetRegisteredProcesses()
+
+	_ = kernel.WithAllProcs(m.procRoot, func(pid int) error {
+		if _, ok := deletionCandidates[uint32(pid)]; ok {
+			delete(deletionCandidates, uint32(pid))
+			return nil
+		}
+
+		m.handleProcessExec(uint32(pid))
+		return nil
+	})
+
+	for pid := range deletionCandidates {
+		m.handleProcessExit(pid)
+	}
+}
+
+func (m *staticTLSMonitor) handleProcessExit(pid uint32) {
+	_ = m.DetachPID(pid)
+}
+
+func (m *staticTLSMonitor) handleProcessExec(pid uint32) {
+	_ = m.AttachPID(pid)
+}
+
+// getExecutablePath returns the path of the executable of the given PID, as seen from the process, or an empty
+// string if it can't be read.
+func getExecutablePath(procRoot string, pid uint32) string {
+	pidAsStr := strconv.FormatUint(uint64(pid), 10)
+	exePath := filepath.Join(procRoot, pidAsStr, "exe")
+
+	binPath, err := os.Readlink(exePath)
+	if err != nil {
+		// We receive the Exec event, /proc could be slow to update
+		end := time.Now().Add(10 * time.Millisecond)
+		for end.After(time.Now()) {
+			binPath, err = os.Readlink(exePath)
+			if err == nil {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	if err != nil {
+		// we can't access to the binary path here (pid probably ended already)
+		// there are not much we can do, and we don't want to flood the logs
+		return ""
+	}
+	return binPath
+}
@@ -0,0 +1,36 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import "time"
+
+// WebSocketSession is the activity of a connection since it was upgraded to WebSocket by an HTTP 101 response.
+// The messages and the bytes are counted from the side of the connection.
+type WebSocketSession struct {
+	// Upgraded is the monotonic timestamp, in nanoseconds, of the upgrade response
+	Upgraded uint64
+	// LastSeen is the monotonic timestamp, in nanoseconds, of the last segment of the session
+	LastSeen uint64
+
+	SentMessages uint64
+	RecvMessages uint64
+	// SentBytes and RecvBytes are the payload bytes exchanged since the upgrade, the HTTP handshake excluded
+	SentBytes uint64
+	RecvBytes uint64
+}
+
+// IsEmpty returns true if the connection wasn't upgraded to WebSocket
+func (s *WebSocketSession) IsEmpty() bool {
+	return s.Upgraded == 0
+}
+
+// Duration returns the time elapsed between the upgrade and the last segment of the session
+func (s *WebSocketSession) Duration() time.Duration {
+	if s.LastSeen < s.Upgraded {
+		return 0
+	}
+	return time.Duration(s.LastSeen - s.Upgraded)
+}
@@ -139,6 +139,7 @@ func (r *RemoteSysProbeUtil) GetConnections(clientID string) (*model.Connections
 	}
 
 	req.Header.Set("Accept", contentTypeProtobuf)
+	req.Header.Set("Accept-Encoding", netEncoding.AcceptEncoding())
 	resp, err := r.httpClient.Do(req)
 	if err != nil {
 		return nil, err
@@ -150,7 +151,13 @@ func (r *RemoteSysProbeUtil) GetConnections(clientID string) (*model.Connections
 		return nil, fmt.Errorf("conn request failed: Probe Path %s, url: %s, status code: %d", r.path, connectionsURL, resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	reader, err := netEncoding.GetDecompressor(resp.Body, resp.Header.Get("Content-Encoding"))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	body, err := io.ReadAll(reader)
 	if err != nil {
 		return nil, err
 	}
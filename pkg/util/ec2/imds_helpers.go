@@ -22,6 +22,7 @@ var (
 	imdsTags        = "/tags/instance" //nolint:unused
 	imdsIPv4        = "/public-ipv4"
 	imdsNetworkMacs = "/network/interfaces/macs"
+	imdsZone        = "/placement/availability-zone"
 )
 
 func getToken(ctx context.Context) (string, time.Time, error) {
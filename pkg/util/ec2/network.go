@@ -13,6 +13,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/util/cachedfetch"
 	"github.com/DataDog/datadog-agent/pkg/util/common"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 var publicIPv4Fetcher = cachedfetch.Fetcher{
@@ -98,3 +99,75 @@ func GetSubnetForHardwareAddr(ctx context.Context, hwAddr net.HardwareAddr) (sub
 	subnet.Cidr = strings.TrimSpace(resp)
 	return
 }
+
+// NetworkInterface stores information about an AWS elastic network interface (ENI)
+type NetworkInterface struct {
+	ID       string
+	VpcID    string
+	VpcCidrs []string
+	Subnet   Subnet
+	// AvailabilityZone is the zone of the host, and so of the subnet of the interface
+	AvailabilityZone string
+}
+
+// GetNetworkInterfaceForHardwareAddr returns info about the network interface associated
+// with a hardware address (mac address) on the current host. Only the subnet is required,
+// the other fields being left empty when they can't be retrieved.
+func GetNetworkInterfaceForHardwareAddr(ctx context.Context, hwAddr net.HardwareAddr) (ifc NetworkInterface, err error) {
+	ifc.Subnet, err = GetSubnetForHardwareAddr(ctx, hwAddr)
+	if err != nil {
+		return
+	}
+
+	item := func(name string) string {
+		resp, err := getMetadataItem(ctx, fmt.Sprintf("%s/%s/%s", imdsNetworkMacs, hwAddr, name), false)
+		if err != nil {
+			log.Debugf("could not get the %s of the interface %s: %s", name, hwAddr, err)
+			return ""
+		}
+		return strings.TrimSpace(resp)
+	}
+
+	ifc.ID = item("interface-id")
+	ifc.VpcID = item("vpc-id")
+	for _, cidr := range strings.Split(item("vpc-ipv4-cidr-blocks"), "\n") {
+		if cidr = strings.TrimSpace(cidr); cidr != "" {
+			ifc.VpcCidrs = append(ifc.VpcCidrs, cidr)
+		}
+	}
+
+	if zone, err := getMetadataItem(ctx, imdsZone, false); err != nil {
+		log.Debugf("could not get the availability zone: %s", err)
+	} else {
+		ifc.AvailabilityZone = strings.TrimSpace(zone)
+	}
+	return ifc, nil
+}
+
+// VPCSubnet is a subnet of a VPC
+type VPCSubnet struct {
+	ID               string
+	Cidr             string
+	AvailabilityZone string
+}
+
+// VPCRoute is a route of a VPC route table
+type VPCRoute struct {
+	DestinationCidr string
+	// Target is the ID of the target of the route, such as igw-, nat-, tgw- or pcx-, or local for
+	// the routes within the VPC
+	Target string
+}
+
+// VPCRouteTable is a route table of a VPC, and the subnets it is associated with
+type VPCRouteTable struct {
+	Main      bool
+	SubnetIDs []string
+	Routes    []VPCRoute
+}
+
+// VPCTopology holds the subnets and the route tables of a VPC
+type VPCTopology struct {
+	Subnets     []VPCSubnet
+	RouteTables []VPCRouteTable
+}
@@ -8,6 +8,7 @@ package ec2
 import (
 	"context"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -112,3 +113,72 @@ func TestGetInstanceIDMultipleVPC(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "too many mac addresses returned")
 }
+
+func TestGetNetworkInterfaceForHardwareAddr(t *testing.T) {
+	ctx := context.Background()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		switch r.RequestURI {
+		case "/network/interfaces/macs/00:00:00:00:00:01/subnet-id":
+			io.WriteString(w, "subnet-12345")
+		case "/network/interfaces/macs/00:00:00:00:00:01/subnet-ipv4-cidr-block":
+			io.WriteString(w, "10.0.0.0/24")
+		case "/network/interfaces/macs/00:00:00:00:00:01/interface-id":
+			io.WriteString(w, "eni-12345")
+		case "/network/interfaces/macs/00:00:00:00:00:01/vpc-id":
+			io.WriteString(w, "vpc-12345")
+		case "/network/interfaces/macs/00:00:00:00:00:01/vpc-ipv4-cidr-blocks":
+			io.WriteString(w, "10.0.0.0/16\n10.1.0.0/16")
+		case "/placement/availability-zone":
+			io.WriteString(w, "us-east-1a")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	defer ts.Close()
+	metadataURL = ts.URL
+	config.Datadog().SetWithoutSource("ec2_metadata_timeout", 1000)
+	defer resetPackageVars()
+
+	ifc, err := GetNetworkInterfaceForHardwareAddr(ctx, net.HardwareAddr{0, 0, 0, 0, 0, 1})
+	require.NoError(t, err)
+	assert.Equal(t, NetworkInterface{
+		ID:       "eni-12345",
+		VpcID:    "vpc-12345",
+		VpcCidrs: []string{"10.0.0.0/16", "10.1.0.0/16"},
+		Subnet:   Subnet{ID: "subnet-12345", Cidr: "10.0.0.0/24"},
+
+		AvailabilityZone: "us-east-1a",
+	}, ifc)
+}
+
+func TestGetNetworkInterfaceForHardwareAddrBestEffort(t *testing.T) {
+	ctx := context.Background()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		switch r.RequestURI {
+		case "/network/interfaces/macs/00:00:00:00:00:01/subnet-id":
+			io.WriteString(w, "subnet-12345")
+		case "/network/interfaces/macs/00:00:00:00:00:01/subnet-ipv4-cidr-block":
+			io.WriteString(w, "10.0.0.0/24")
+		case "/network/interfaces/macs/00:00:00:00:00:01/vpc-id":
+			io.WriteString(w, "vpc-12345")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	defer ts.Close()
+	metadataURL = ts.URL
+	config.Datadog().SetWithoutSource("ec2_metadata_timeout", 1000)
+	defer resetPackageVars()
+
+	// only the subnet is required
+	ifc, err := GetNetworkInterfaceForHardwareAddr(ctx, net.HardwareAddr{0, 0, 0, 0, 0, 1})
+	require.NoError(t, err)
+	assert.Equal(t, NetworkInterface{
+		VpcID:  "vpc-12345",
+		Subnet: Subnet{ID: "subnet-12345", Cidr: "10.0.0.0/24"},
+	}, ifc)
+}
@@ -0,0 +1,120 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build ec2
+
+package ec2
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// GetVPCTopology returns the subnets and the route tables of the given VPC from the EC2 API, which
+// requires the ec2:DescribeSubnets and ec2:DescribeRouteTables permissions
+func GetVPCTopology(ctx context.Context, vpcID string) (VPCTopology, error) {
+	instanceIdentity, err := GetInstanceIdentity(ctx)
+	if err != nil {
+		return VPCTopology{}, err
+	}
+
+	// like for the tags, try the automatic credentials detection first, and the instance role then
+	topology, err := getVPCTopologyWithCreds(ctx, instanceIdentity.Region, vpcID, nil)
+	if err == nil {
+		return topology, nil
+	}
+	log.Debugf("unable to describe vpc %s using default credentials (falling back to instance role): %s", vpcID, err)
+
+	iamParams, err := getSecurityCreds(ctx)
+	if err != nil {
+		return VPCTopology{}, err
+	}
+	awsCreds := credentials.NewStaticCredentialsProvider(iamParams.AccessKeyID, iamParams.SecretAccessKey, iamParams.Token)
+	return getVPCTopologyWithCreds(ctx, instanceIdentity.Region, vpcID, awsCreds)
+}
+
+func getVPCTopologyWithCreds(ctx context.Context, region, vpcID string, awsCreds aws.CredentialsProvider) (VPCTopology, error) {
+	connection := ec2.New(ec2.Options{
+		Region:      region,
+		Credentials: awsCreds,
+	})
+
+	ctx, cancel := context.WithTimeout(ctx, config.Datadog().GetDuration("ec2_metadata_timeout")*time.Millisecond)
+	defer cancel()
+
+	filters := []types.Filter{{Name: aws.String("vpc-id"), Values: []string{vpcID}}}
+	var topology VPCTopology
+
+	subnets := ec2.NewDescribeSubnetsPaginator(connection, &ec2.DescribeSubnetsInput{Filters: filters})
+	for subnets.HasMorePages() {
+		page, err := subnets.NextPage(ctx)
+		if err != nil {
+			return VPCTopology{}, err
+		}
+		for _, s := range page.Subnets {
+			topology.Subnets = append(topology.Subnets, VPCSubnet{
+				ID:               aws.ToString(s.SubnetId),
+				Cidr:             aws.ToString(s.CidrBlock),
+				AvailabilityZone: aws.ToString(s.AvailabilityZone),
+			})
+		}
+	}
+
+	routeTables := ec2.NewDescribeRouteTablesPaginator(connection, &ec2.DescribeRouteTablesInput{Filters: filters})
+	for routeTables.HasMorePages() {
+		page, err := routeTables.NextPage(ctx)
+		if err != nil {
+			return VPCTopology{}, err
+		}
+		for _, rt := range page.RouteTables {
+			var table VPCRouteTable
+			for _, a := range rt.Associations {
+				if aws.ToBool(a.Main) {
+					table.Main = true
+				}
+				if a.SubnetId != nil {
+					table.SubnetIDs = append(table.SubnetIDs, *a.SubnetId)
+				}
+			}
+			for _, r := range rt.Routes {
+				if r.DestinationCidrBlock == nil || r.State == types.RouteStateBlackhole {
+					continue
+				}
+				table.Routes = append(table.Routes, VPCRoute{
+					DestinationCidr: *r.DestinationCidrBlock,
+					Target:          routeTarget(r),
+				})
+			}
+			topology.RouteTables = append(topology.RouteTables, table)
+		}
+	}
+	return topology, nil
+}
+
+// routeTarget returns the ID of the target of the route
+func routeTarget(r types.Route) string {
+	for _, id := range []*string{
+		r.GatewayId,
+		r.NatGatewayId,
+		r.TransitGatewayId,
+		r.VpcPeeringConnectionId,
+		r.EgressOnlyInternetGatewayId,
+		r.NetworkInterfaceId,
+		r.InstanceId,
+	} {
+		if id != nil {
+			return *id
+		}
+	}
+	return ""
+}
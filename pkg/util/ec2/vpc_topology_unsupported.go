@@ -0,0 +1,18 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !ec2
+
+package ec2
+
+import (
+	"context"
+	"errors"
+)
+
+// GetVPCTopology is not available without the EC2 API
+func GetVPCTopology(_ context.Context, _ string) (VPCTopology, error) {
+	return VPCTopology{}, errors.New("the EC2 API is not available in this build")
+}
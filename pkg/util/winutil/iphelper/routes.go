@@ -23,6 +23,9 @@ var (
 	procGetExtendedTcpTable = modiphelper.NewProc("GetExtendedTcpTable")
 	procGetIpForwardTable   = modiphelper.NewProc("GetIpForwardTable")
 	procGetIfTable          = modiphelper.NewProc("GetIfTable")
+
+	procNotifyRouteChange2     = modiphelper.NewProc("NotifyRouteChange2")
+	procCancelMibChangeNotify2 = modiphelper.NewProc("CancelMibChangeNotify2")
 )
 
 //revive:enable:var-naming (API)
@@ -119,6 +122,40 @@ func GetIPv4RouteTable() (table []MIB_IPFORWARDROW, err error) {
 
 }
 
+// NotifyRouteChange2 registers a callback called each time a route of the given address family
+// is added, deleted or changed. The callback must be created with windows.NewCallback, following
+// the PIPFORWARD_CHANGE_CALLBACK signature. The returned handle cancels the notifications when
+// given to CancelMibChangeNotify2.
+//
+// https://learn.microsoft.com/en-us/windows/win32/api/netioapi/nf-netioapi-notifyroutechange2
+func NotifyRouteChange2(family uint16, callback uintptr, callerContext uintptr, initialNotification bool) (windows.Handle, error) {
+	var handle windows.Handle
+	var initial uintptr
+	if initialNotification {
+		initial = 1
+	}
+	r, _, _ := procNotifyRouteChange2.Call(uintptr(family),
+		callback,
+		callerContext,
+		initial,
+		uintptr(unsafe.Pointer(&handle)))
+	if r != 0 {
+		return 0, windows.Errno(r)
+	}
+	return handle, nil
+}
+
+// CancelMibChangeNotify2 cancels the notifications registered with NotifyRouteChange2
+//
+// https://learn.microsoft.com/en-us/windows/win32/api/netioapi/nf-netioapi-cancelmibchangenotify2
+func CancelMibChangeNotify2(handle windows.Handle) error {
+	r, _, _ := procCancelMibChangeNotify2.Call(uintptr(handle))
+	if r != 0 {
+		return windows.Errno(r)
+	}
+	return nil
+}
+
 // GetExtendedTcpV4Table returns a list of ipv4 tcp connections indexed by owning PID
 //
 // https://learn.microsoft.com/en-us/windows/win32/api/iphlpapi/nf-iphlpapi-getextendedtcptable
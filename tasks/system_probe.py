@@ -422,6 +422,18 @@ def ninja_cgo_type_files(nw):
             "pkg/network/protocols/postgres/types.go": [
                 "pkg/network/ebpf/c/protocols/postgres/types.h",
             ],
+            "pkg/network/protocols/mysql/types.go": [
+                "pkg/network/ebpf/c/protocols/mysql/types.h",
+            ],
+            "pkg/network/protocols/redis/types.go": [
+                "pkg/network/ebpf/c/protocols/redis/types.h",
+            ],
+            "pkg/network/protocols/mongo/types.go": [
+                "pkg/network/ebpf/c/protocols/mongo/types.h",
+            ],
+            "pkg/network/protocols/amqp/types.go": [
+                "pkg/network/ebpf/c/protocols/amqp/types.h",
+            ],
             "pkg/ebpf/telemetry/types.go": [
                 "pkg/ebpf/c/telemetry_types.h",
             ],
@@ -1618,6 +1630,34 @@ def generate_event_monitor_proto(ctx):
             f.write(replaced_content)
 
 
+@task
+def generate_network_tracer_proto(ctx):
+    with tempfile.TemporaryDirectory() as temp_gobin:
+        with environ({"GOBIN": temp_gobin}):
+            ctx.run("go install google.golang.org/protobuf/cmd/protoc-gen-go@v1.28.1")
+            ctx.run("go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@v1.2.0")
+
+            plugin_opts = " ".join(
+                [
+                    f"--plugin protoc-gen-go=\"{temp_gobin}/protoc-gen-go\"",
+                    f"--plugin protoc-gen-go-grpc=\"{temp_gobin}/protoc-gen-go-grpc\"",
+                ]
+            )
+
+            ctx.run(
+                f"protoc -I. {plugin_opts} --go_out=paths=source_relative:. --go-grpc_out=paths=source_relative:. pkg/network/proto/api/api.proto"
+            )
+
+    for path in glob.glob("pkg/network/proto/**/*.pb.go", recursive=True):
+        print(f"replacing protoc version in {path}")
+        with open(path) as f:
+            content = f.read()
+
+        replaced_content = re.sub(r"\/\/\s*protoc\s*v\d+\.\d+\.\d+", "//  protoc", content)
+        with open(path, "w") as f:
+            f.write(replaced_content)
+
+
 @task
 def print_failed_tests(_, output_dir):
     fail_count = 0